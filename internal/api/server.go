@@ -20,7 +20,9 @@ import (
 	"golandproject/yscan/internal/model"
 	"golandproject/yscan/internal/report"
 	"golandproject/yscan/internal/storage"
+	"golandproject/yscan/internal/vuln"
 	"golandproject/yscan/internal/web"
+	"golandproject/yscan/internal/workflow"
 )
 
 type TaskRunner func(taskType, target string) (int64, error)
@@ -41,6 +43,19 @@ type AccessPolicy struct {
 	TrustedCIDRs       []string
 	LocalHealthAddress string
 	LocalHealthToken   string
+	// EvidenceCIDRs are the clients allowed to read redacted finding
+	// evidence. It is a separate grant: loopback and trusted clients are not
+	// included unless listed, and an empty list disables the evidence API.
+	EvidenceCIDRs []string
+}
+
+type evidencePermissionKey struct{}
+
+// evidencePermitted reports whether AccessPolicy.Wrap granted the evidence
+// permission to the client of r.
+func evidencePermitted(r *http.Request) bool {
+	permitted, _ := r.Context().Value(evidencePermissionKey{}).(bool)
+	return permitted
 }
 
 const LocalHealthTokenHeader = "X-Yscan-Local-Health-Token"
@@ -173,6 +188,11 @@ func (policy AccessPolicy) Validate(addr string) error {
 			return fmt.Errorf("invalid trusted client CIDR %q", cidr)
 		}
 	}
+	for _, cidr := range policy.EvidenceCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("invalid evidence client CIDR %q", cidr)
+		}
+	}
 	if host == "localhost" || net.ParseIP(host).IsLoopback() {
 		return nil
 	}
@@ -185,15 +205,13 @@ func (policy AccessPolicy) Validate(addr string) error {
 }
 
 func (policy AccessPolicy) Wrap(next http.Handler) http.Handler {
+	if len(policy.EvidenceCIDRs) > 0 {
+		next = policy.wrapEvidencePermission(next)
+	}
 	if len(policy.TrustedCIDRs) == 0 {
 		return next
 	}
-	networks := make([]*net.IPNet, 0, len(policy.TrustedCIDRs))
-	for _, cidr := range policy.TrustedCIDRs {
-		if _, network, err := net.ParseCIDR(cidr); err == nil {
-			networks = append(networks, network)
-		}
-	}
+	networks := parseCIDRs(policy.TrustedCIDRs)
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		host, _, _ := net.SplitHostPort(r.RemoteAddr)
 		ip := net.ParseIP(host)
@@ -216,6 +234,31 @@ func (policy AccessPolicy) Wrap(next http.Handler) http.Handler {
 	})
 }
 
+func (policy AccessPolicy) wrapEvidencePermission(next http.Handler) http.Handler {
+	networks := parseCIDRs(policy.EvidenceCIDRs)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		ip := net.ParseIP(host)
+		for _, network := range networks {
+			if ip != nil && network.Contains(ip) {
+				r = r.WithContext(context.WithValue(r.Context(), evidencePermissionKey{}, true))
+				break
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func parseCIDRs(cidrs []string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if _, network, err := net.ParseCIDR(cidr); err == nil {
+			networks = append(networks, network)
+		}
+	}
+	return networks
+}
+
 func newHandler(db *sql.DB, runTask TaskRunner) (http.Handler, error) {
 	return newHandlerWithScanTasks(db, runTask, nil, nil)
 }
@@ -442,6 +485,41 @@ func newHandlerWithScanTasksContextAndGroup(serviceContext context.Context, db *
 			writeJSON(w, http.StatusOK, task)
 			return
 		}
+		if len(parts) == 4 && parts[1] == "runs" && parts[3] == "revalidate" {
+			if r.Method != http.MethodPost {
+				writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+				return
+			}
+			sourceID, err := strconv.ParseInt(parts[2], 10, 64)
+			if err != nil || sourceID <= 0 {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid scan task run id"})
+				return
+			}
+			source, err := storage.GetScanTaskRun(db, sourceID)
+			if errors.Is(err, storage.ErrScanTaskRunNotFound) || (err == nil && source.ScanTaskID != taskID) {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "scan task run not found"})
+				return
+			}
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			run, err := storage.CreateRevalidationScanTaskRun(db, source.ID)
+			if err != nil {
+				status := http.StatusInternalServerError
+				if errors.Is(err, storage.ErrRevalidationSourceUnavailable) || errors.Is(err, storage.ErrRevalidationDisabled) || errors.Is(err, storage.ErrScanTaskNotEnabled) {
+					status = http.StatusConflict
+				}
+				writeJSON(w, status, map[string]string{"error": err.Error()})
+				return
+			}
+			launch := startRun != nil
+			if launch {
+				launchScanTaskRun(serviceContext, activeRuns, startRun, run)
+			}
+			writeJSON(w, http.StatusAccepted, map[string]interface{}{"run": run, "source_run_id": source.ID, "started": launch})
+			return
+		}
 		if len(parts) >= 3 && parts[1] == "runs" {
 			handleScanTaskRunRoute(db, w, r, taskID, parts)
 			return
@@ -481,6 +559,12 @@ func newHandlerWithScanTasksContextAndGroup(serviceContext context.Context, db *
 				launchScanTaskRun(serviceContext, activeRuns, startRun, run)
 			}
 			writeJSON(w, http.StatusAccepted, map[string]interface{}{"run": run, "started": launch})
+		case "plan":
+			if r.Method != http.MethodGet {
+				writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+				return
+			}
+			handleScanTaskValidationPlan(db, w, r, taskID)
 		case "pause", "resume", "archive":
 			if r.Method != http.MethodPost {
 				writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
@@ -519,6 +603,16 @@ func newHandlerWithScanTasksContextAndGroup(serviceContext context.Context, db *
 		query := storage.HostInventoryQuery{
 			Scope:  r.URL.Query().Get("scope"),
 			Source: r.URL.Query().Get("source"),
+			Search: r.URL.Query().Get("q"),
+			Sort:   strings.TrimSpace(r.URL.Query().Get("sort")),
+		}
+		if query.Sort != "" && query.Sort != storage.HostInventorySortIP && query.Sort != storage.HostInventorySortRisk {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "sort must be ip or risk"})
+			return
+		}
+		if len(query.Search) > 256 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "q must be at most 256 bytes"})
+			return
 		}
 		if rawActive := strings.TrimSpace(r.URL.Query().Get("active")); rawActive != "" {
 			active, err := strconv.ParseBool(rawActive)
@@ -537,6 +631,121 @@ func newHandlerWithScanTasksContextAndGroup(serviceContext context.Context, db *
 		writeJSON(w, http.StatusOK, assets)
 	})
 
+	mux.HandleFunc("/api/ssh-host-keys", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		report, err := storage.GetSSHHostKeyReport(db)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, report)
+	})
+
+	mux.HandleFunc("/api/risk/labels", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		labels, err := storage.ListAssetCriticalityLabels(db)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, labels)
+	})
+
+	mux.HandleFunc("/api/findings", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		page, pageSize, err := pageParams(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		query := r.URL.Query()
+		items, err := storage.ListGlobalFindings(db, model.GlobalFindingFilter{
+			Severity:       query.Get("severity"),
+			TemplateID:     query.Get("template"),
+			ProductKey:     query.Get("product"),
+			CIDR:           query.Get("cidr"),
+			State:          query.Get("state"),
+			FirstSeenSince: query.Get("first_seen_since"),
+			FirstSeenUntil: query.Get("first_seen_until"),
+		})
+		if errors.Is(err, storage.ErrInvalidGlobalFindingFilter) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		total := len(items)
+		start := (page - 1) * pageSize
+		if start > total {
+			start = total
+		}
+		end := start + pageSize
+		if end > total {
+			end = total
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"items": items[start:end], "page": page, "page_size": pageSize, "total": total})
+	})
+
+	// Triage is keyed by finding, not by run, so the decision carries over to
+	// every later run that observes the same finding.
+	mux.HandleFunc("/api/findings/triage", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			triage, err := storage.GetFindingTriage(db, r.URL.Query().Get("key"))
+			if errors.Is(err, storage.ErrFindingNotFound) {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "finding not found"})
+				return
+			}
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, triage)
+		case http.MethodPost:
+			var req model.FindingTriageUpdate
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+				return
+			}
+			triage, err := storage.UpdateFindingTriage(db, req)
+			if errors.Is(err, storage.ErrFindingNotFound) {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "finding not found"})
+				return
+			}
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, triage)
+		default:
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		}
+	})
+
+	mux.HandleFunc("/api/cve/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		summary, err := storage.GetCVEDataSummary(db)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, summary)
+	})
+
 	// Fingerprint catalog endpoints are intentionally read-only. Mutating
 	// imports and review mappings remains a local CLI operation with an
 	// auditable manifest, rather than a broadly exposed network API.
@@ -557,7 +766,7 @@ func newHandlerWithScanTasksContextAndGroup(serviceContext context.Context, db *
 			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
 			return
 		}
-		page, pageSize, err := fingerprintPageParams(r)
+		page, pageSize, err := pageParams(r)
 		if err != nil {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
@@ -574,7 +783,35 @@ func newHandlerWithScanTasksContextAndGroup(serviceContext context.Context, db *
 			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
 			return
 		}
-		id, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/fingerprints/imports/"), "/"), 10, 64)
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/fingerprints/imports/"), "/"), "/")
+		if len(parts) == 3 && parts[1] == "diff" {
+			fromID, fromErr := strconv.ParseInt(parts[0], 10, 64)
+			toID, toErr := strconv.ParseInt(parts[2], 10, 64)
+			if fromErr != nil || toErr != nil || fromID <= 0 || toID <= 0 {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid fingerprint import id"})
+				return
+			}
+			diff, err := storage.DiffFingerprintImports(db, fromID, toID, r.URL.Query().Get("impact") == "1")
+			if errors.Is(err, storage.ErrFingerprintImportNotFound) {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "fingerprint import not found"})
+				return
+			}
+			if errors.Is(err, storage.ErrFingerprintImportSourceMismatch) {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, diff)
+			return
+		}
+		if len(parts) != 1 {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+			return
+		}
+		id, err := strconv.ParseInt(parts[0], 10, 64)
 		if err != nil || id <= 0 {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid fingerprint import id"})
 			return
@@ -631,6 +868,23 @@ func newHandlerWithScanTasksContextAndGroup(serviceContext context.Context, db *
 		}
 		writeJSON(w, http.StatusOK, rules)
 	})
+	mux.HandleFunc("/api/fingerprints/stats", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		limit, err := optionalPositiveInt(r.URL.Query().Get("limit"), 20)
+		if err != nil || limit > 500 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be between 1 and 500"})
+			return
+		}
+		stats, err := storage.FingerprintRuleStatistics(db, storage.FingerprintRuleStatsQuery{SourceKey: r.URL.Query().Get("source"), Limit: limit})
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, stats)
+	})
 	mux.HandleFunc("/api/fingerprints/template-mappings", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
@@ -644,6 +898,65 @@ func newHandlerWithScanTasksContextAndGroup(serviceContext context.Context, db *
 		writeJSON(w, http.StatusOK, mappings)
 	})
 
+	// Overrides are operator judgements about this inventory, not catalog
+	// content, so the console may create and revoke them. Each carries a
+	// reason and only shapes conclusions of later runs.
+	mux.HandleFunc("/api/fingerprints/overrides", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			overrides, err := storage.ListFingerprintOverrides(db, r.URL.Query().Get("all") == "1")
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, overrides)
+		case http.MethodPost:
+			var req model.FingerprintOverride
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+				return
+			}
+			override, err := storage.CreateFingerprintOverride(db, model.FingerprintOverride{
+				Kind: req.Kind, SourceKey: req.SourceKey, SourceRuleID: req.SourceRuleID, ProductKey: req.ProductKey,
+				IP: req.IP, Port: req.Port, Protocol: req.Protocol, Version: req.Version, CPE: req.CPE,
+				Reason: req.Reason, ExpiresAt: req.ExpiresAt,
+			})
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusCreated, override)
+		default:
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		}
+	})
+	mux.HandleFunc("/api/fingerprints/overrides/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/fingerprints/overrides/"), "/"), "/")
+		if len(parts) != 2 || parts[1] != "revoke" {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+			return
+		}
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		id, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil || id <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid fingerprint override id"})
+			return
+		}
+		override, err := storage.RevokeFingerprintOverride(db, id)
+		if errors.Is(err, storage.ErrFingerprintOverrideNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "fingerprint override not found"})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, override)
+	})
+
 	mux.HandleFunc("/api/assets/", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
@@ -706,7 +1019,9 @@ func optionalPositiveInt64(value string) (int64, error) {
 	return parsed, nil
 }
 
-func fingerprintPageParams(r *http.Request) (int, int, error) {
+// pageParams reads the page and page_size query parameters shared by every
+// paginated listing.
+func pageParams(r *http.Request) (int, int, error) {
 	page, err := optionalPositiveInt(r.URL.Query().Get("page"), 1)
 	if err != nil {
 		return 0, 0, errors.New("page must be a positive integer")
@@ -718,6 +1033,42 @@ func fingerprintPageParams(r *http.Request) (int, int, error) {
 	return page, pageSize, nil
 }
 
+// handleScanTaskRunFindingEvidence returns the redacted evidence of the
+// finding named by ?finding= in one run. Only clients granted the evidence
+// permission by AccessPolicy.EvidenceCIDRs may read it.
+func handleScanTaskRunFindingEvidence(db *sql.DB, w http.ResponseWriter, r *http.Request, runID int64) {
+	if !evidencePermitted(r) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "finding evidence requires the evidence permission (YSCAN_EVIDENCE_CIDRS)"})
+		return
+	}
+	findingKey := strings.TrimSpace(r.URL.Query().Get("finding"))
+	if findingKey == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "finding is required"})
+		return
+	}
+	snapshot, err := storage.GetScanTaskRunSnapshot(db, runID)
+	if errors.Is(err, storage.ErrScanTaskRunSnapshotUnavailable) {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "scan task run snapshot is not available"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	for _, finding := range snapshot.Vulnerabilities {
+		if finding.FindingKey != findingKey {
+			continue
+		}
+		if strings.TrimSpace(finding.Evidence) == "" {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "finding has no stored evidence"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"finding_key": finding.FindingKey, "template_id": finding.TemplateID, "matched_at": finding.MatchedAt, "evidence": vuln.RedactFindingEvidence(finding.Evidence, vuln.ConfiguredEvidenceRedaction())})
+		return
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"error": "finding not found in scan task run"})
+}
+
 // handleScanTaskRunRoute exposes immutable run state and its task-local Diff.
 // A run ID is always checked against the parent logical task before returning
 // data, so callers cannot accidentally compare results across tasks.
@@ -793,20 +1144,36 @@ func handleScanTaskRunRoute(db *sql.DB, w http.ResponseWriter, r *http.Request,
 			}
 			return
 		}
+		if !evidencePermitted(r) {
+			content = report.WithoutAuditFindingEvidence(content)
+		}
 		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
 		_, _ = w.Write(content)
 		return
 	}
+	if parts[3] == "evidence" {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		handleScanTaskRunFindingEvidence(db, w, r, runID)
+		return
+	}
 	if parts[3] == "findings" {
 		if r.Method != http.MethodGet {
 			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
 			return
 		}
-		page, pageSize, err := fingerprintPageParams(r)
+		page, pageSize, err := pageParams(r)
 		if err != nil {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
+		state := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("state")))
+		if state != "" && !model.ValidFindingState(state) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "state must be one of " + strings.Join(model.FindingStates, ", ")})
+			return
+		}
 		snapshot, err := storage.GetScanTaskRunSnapshot(db, runID)
 		if errors.Is(err, storage.ErrScanTaskRunSnapshotUnavailable) {
 			writeJSON(w, http.StatusConflict, map[string]string{"error": "scan task run snapshot is not available"})
@@ -816,7 +1183,13 @@ func handleScanTaskRunRoute(db *sql.DB, w http.ResponseWriter, r *http.Request,
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 			return
 		}
-		total := len(snapshot.Vulnerabilities)
+		items := make([]model.ScanTaskRunVulnerability, 0, len(snapshot.Vulnerabilities))
+		for _, finding := range snapshot.Vulnerabilities {
+			if state == "" || finding.State == state {
+				items = append(items, finding)
+			}
+		}
+		total := len(items)
 		start := (page - 1) * pageSize
 		if start > total {
 			start = total
@@ -825,7 +1198,112 @@ func handleScanTaskRunRoute(db *sql.DB, w http.ResponseWriter, r *http.Request,
 		if end > total {
 			end = total
 		}
-		writeJSON(w, http.StatusOK, map[string]interface{}{"validation": snapshot.Validation, "items": snapshot.Vulnerabilities[start:end], "page": page, "page_size": pageSize, "total": total})
+		writeJSON(w, http.StatusOK, map[string]interface{}{"validation": snapshot.Validation, "items": items[start:end], "page": page, "page_size": pageSize, "total": total, "evidence_permitted": evidencePermitted(r)})
+		return
+	}
+	if parts[3] == "weaknesses" {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		page, pageSize, err := pageParams(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		snapshot, err := storage.GetScanTaskRunSnapshot(db, runID)
+		if errors.Is(err, storage.ErrScanTaskRunSnapshotUnavailable) {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "scan task run snapshot is not available"})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		items := make([]model.ScanTaskRunWeakness, 0, len(snapshot.Weaknesses))
+		severity := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("severity")))
+		for _, weakness := range snapshot.Weaknesses {
+			if severity == "" || weakness.Severity == severity {
+				items = append(items, weakness)
+			}
+		}
+		total := len(items)
+		start := (page - 1) * pageSize
+		if start > total {
+			start = total
+		}
+		end := start + pageSize
+		if end > total {
+			end = total
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"items": items[start:end], "page": page, "page_size": pageSize, "total": total})
+		return
+	}
+	if parts[3] == "risk" {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		snapshot, err := storage.GetScanTaskRunSnapshot(db, runID)
+		if errors.Is(err, storage.ErrScanTaskRunSnapshotUnavailable) {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "scan task run snapshot is not available"})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if snapshot.Risk == nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "scan task run was saved before risk scoring"})
+			return
+		}
+		writeJSON(w, http.StatusOK, snapshot.Risk)
+		return
+	}
+	if parts[3] == "potential-vulnerabilities" {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		page, pageSize, err := pageParams(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		knownExploitedOnly := false
+		if value := strings.TrimSpace(r.URL.Query().Get("kev")); value != "" {
+			knownExploitedOnly, err = strconv.ParseBool(value)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "kev must be true or false"})
+				return
+			}
+		}
+		snapshot, err := storage.GetScanTaskRunSnapshot(db, runID)
+		if errors.Is(err, storage.ErrScanTaskRunSnapshotUnavailable) {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "scan task run snapshot is not available"})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		items := make([]model.ScanTaskRunPotentialVulnerability, 0, len(snapshot.PotentialVulnerabilities))
+		severity := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("severity")))
+		for _, item := range snapshot.PotentialVulnerabilities {
+			if (severity == "" || item.Severity == severity) && (!knownExploitedOnly || item.KnownExploited) {
+				items = append(items, item)
+			}
+		}
+		total := len(items)
+		start := (page - 1) * pageSize
+		if start > total {
+			start = total
+		}
+		end := start + pageSize
+		if end > total {
+			end = total
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"validated": false, "items": items[start:end], "page": page, "page_size": pageSize, "total": total})
 		return
 	}
 	if parts[3] == "fingerprints" {
@@ -837,7 +1315,7 @@ func handleScanTaskRunRoute(db *sql.DB, w http.ResponseWriter, r *http.Request,
 			writeJSON(w, http.StatusOK, map[string]interface{}{"resources": []string{"imports", "matches", "evidence", "conclusions"}})
 			return
 		}
-		page, pageSize, err := fingerprintPageParams(r)
+		page, pageSize, err := pageParams(r)
 		if err != nil {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
@@ -901,6 +1379,42 @@ func handleScanTaskRunRoute(db *sql.DB, w http.ResponseWriter, r *http.Request,
 	writeJSON(w, http.StatusOK, changes)
 }
 
+// handleScanTaskValidationPlan previews vulnerability validation for the
+// task's last successful run, or the run named by ?run=, without executing it.
+func handleScanTaskValidationPlan(db *sql.DB, w http.ResponseWriter, r *http.Request, taskID int64) {
+	task, err := storage.GetScanTask(db, taskID)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "scan task not found"})
+		return
+	}
+	var run model.ScanTaskRun
+	if rawRunID := strings.TrimSpace(r.URL.Query().Get("run")); rawRunID != "" {
+		runID, parseErr := strconv.ParseInt(rawRunID, 10, 64)
+		if parseErr != nil || runID <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "run must be a positive integer"})
+			return
+		}
+		run, err = storage.GetScanTaskRun(db, runID)
+	} else {
+		run, err = storage.GetLatestSuccessfulScanTaskRun(db, taskID)
+	}
+	if err == nil {
+		var plan model.ValidationPlan
+		if plan, err = workflow.PlanValidation(r.Context(), db, task, run); err == nil {
+			writeJSON(w, http.StatusOK, plan)
+			return
+		}
+	}
+	switch {
+	case errors.Is(err, storage.ErrScanTaskRunNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "scan task run not found"})
+	case errors.Is(err, storage.ErrScanTaskRunSnapshotUnavailable):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "scan task run snapshot is not available"})
+	default:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
+
 func writeJSON(w http.ResponseWriter, status int, v interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
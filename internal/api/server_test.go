@@ -10,6 +10,7 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -331,6 +332,134 @@ func TestFingerprintImportAPISeparatesSummaryDetailAndBoundsPages(t *testing.T)
 	}
 }
 
+func TestFingerprintImportDiffAPISeparatesInvalidRequestsFromStorageErrors(t *testing.T) {
+	db, err := storage.InitDBAt(filepath.Join(t.TempDir(), "api.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	imports := make([]model.FingerprintImport, 0, 2)
+	for _, sourceKey := range []string{"diff-a", "diff-b"} {
+		fingerprintImport, err := storage.ImportFingerprintBatch(db, storage.FingerprintImportBatch{
+			Source: model.FingerprintSource{SourceKey: sourceKey, RepositoryURL: "https://example.invalid/" + sourceKey, License: "test", Status: "enabled"},
+			Import: model.FingerprintImport{
+				Commit: sourceKey + "-v1", ContentSHA256: strings.Repeat("a", 64), UpstreamContentSHA256: strings.Repeat("a", 64),
+				AdapterVersion: "diff-adapter-v1", ManifestJSON: `{}`, RuleTotal: 1, ExecutableTotal: 1,
+			},
+			Rules: []model.FingerprintSourceRule{{SourceRuleID: "diff-rule", SourcePath: "diff/rule.json", ContentSHA256: strings.Repeat("b", 64), RawContent: `{"rule":"diff"}`, ImportStatus: "executable"}},
+			Projections: []model.FingerprintRuleProjection{{
+				SourcePath: "diff/rule.json", ContentSHA256: strings.Repeat("b", 64), Product: model.FingerprintProduct{CanonicalName: "diff-product"}, Protocol: "http",
+				Root: model.FingerprintMatchGroupProjection{Operator: "all", Matchers: []model.FingerprintMatcher{{EvidenceType: "http_body", Target: "body", Operator: "contains", Value: "diff"}}},
+			}},
+		})
+		if err != nil {
+			t.Fatalf("import %s: %v", sourceKey, err)
+		}
+		imports = append(imports, fingerprintImport)
+	}
+	handler, err := newHandler(db, func(string, string) (int64, error) { return 1, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	diffPath := func(from, to int64) string {
+		return "/api/fingerprints/imports/" + strconv.FormatInt(from, 10) + "/diff/" + strconv.FormatInt(to, 10) + "?impact=1"
+	}
+
+	mismatch := httptest.NewRecorder()
+	handler.ServeHTTP(mismatch, httptest.NewRequest(http.MethodGet, diffPath(imports[0].ID, imports[1].ID), nil))
+	if mismatch.Code != http.StatusBadRequest {
+		t.Fatalf("cross-source diff status=%d body=%s", mismatch.Code, mismatch.Body.String())
+	}
+
+	same := httptest.NewRecorder()
+	handler.ServeHTTP(same, httptest.NewRequest(http.MethodGet, diffPath(imports[0].ID, imports[0].ID), nil))
+	if same.Code != http.StatusOK || !bytes.Contains(same.Body.Bytes(), []byte(`"mode":"loss_only"`)) {
+		t.Fatalf("same-import diff status=%d body=%s", same.Code, same.Body.String())
+	}
+
+	if _, err := db.Exec(`ALTER TABLE fingerprint_products RENAME TO fingerprint_products_unavailable`); err != nil {
+		t.Fatal(err)
+	}
+	broken := httptest.NewRecorder()
+	handler.ServeHTTP(broken, httptest.NewRequest(http.MethodGet, diffPath(imports[0].ID, imports[0].ID), nil))
+	if broken.Code != http.StatusInternalServerError {
+		t.Fatalf("storage failure status=%d body=%s", broken.Code, broken.Body.String())
+	}
+}
+
+func TestFingerprintOverrideAPICreatesListsAndRevokes(t *testing.T) {
+	db, err := storage.InitDBAt(filepath.Join(t.TempDir(), "api.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	handler, err := newHandler(db, func(string, string) (int64, error) { return 1, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rejected := httptest.NewRecorder()
+	handler.ServeHTTP(rejected, httptest.NewRequest(http.MethodPost, "/api/fingerprints/overrides", bytes.NewBufferString(`{"kind":"suppress","product_key":"weblogic"}`)))
+	if rejected.Code != http.StatusBadRequest {
+		t.Fatalf("override without reason status=%d body=%s", rejected.Code, rejected.Body.String())
+	}
+	created := httptest.NewRecorder()
+	handler.ServeHTTP(created, httptest.NewRequest(http.MethodPost, "/api/fingerprints/overrides", bytes.NewBufferString(`{"kind":"suppress","product_key":"weblogic","ip":"10.0.0.5","port":7001,"reason":"error page false positive"}`)))
+	var override model.FingerprintOverride
+	if err := json.Unmarshal(created.Body.Bytes(), &override); err != nil || created.Code != http.StatusCreated || override.ID == 0 {
+		t.Fatalf("create override status=%d body=%s err=%v", created.Code, created.Body.String(), err)
+	}
+
+	revokePath := "/api/fingerprints/overrides/" + strconv.FormatInt(override.ID, 10) + "/revoke"
+	revoked := httptest.NewRecorder()
+	handler.ServeHTTP(revoked, httptest.NewRequest(http.MethodPost, revokePath, nil))
+	if revoked.Code != http.StatusOK || !strings.Contains(revoked.Body.String(), `"revoked_at"`) {
+		t.Fatalf("revoke status=%d body=%s", revoked.Code, revoked.Body.String())
+	}
+	again := httptest.NewRecorder()
+	handler.ServeHTTP(again, httptest.NewRequest(http.MethodPost, revokePath, nil))
+	if again.Code != http.StatusConflict {
+		t.Fatalf("second revoke status=%d body=%s", again.Code, again.Body.String())
+	}
+	missing := httptest.NewRecorder()
+	handler.ServeHTTP(missing, httptest.NewRequest(http.MethodPost, "/api/fingerprints/overrides/999/revoke", nil))
+	if missing.Code != http.StatusNotFound {
+		t.Fatalf("missing revoke status=%d body=%s", missing.Code, missing.Body.String())
+	}
+
+	for query, want := range map[string]int{"": 0, "?all=1": 1} {
+		list := httptest.NewRecorder()
+		handler.ServeHTTP(list, httptest.NewRequest(http.MethodGet, "/api/fingerprints/overrides"+query, nil))
+		var overrides []model.FingerprintOverride
+		if err := json.Unmarshal(list.Body.Bytes(), &overrides); err != nil || list.Code != http.StatusOK || len(overrides) != want {
+			t.Fatalf("list%s status=%d body=%s", query, list.Code, list.Body.String())
+		}
+	}
+}
+
+func TestFingerprintStatsAPIListsEverySource(t *testing.T) {
+	db, err := storage.InitDBAt(filepath.Join(t.TempDir(), "api.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	handler, err := newHandler(db, func(string, string) (int64, error) { return 1, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/fingerprints/stats?limit=5", nil))
+	var stats model.FingerprintRuleStats
+	if err := json.Unmarshal(recorder.Body.Bytes(), &stats); err != nil || recorder.Code != http.StatusOK || len(stats.Sources) == 0 || stats.Rules == nil {
+		t.Fatalf("stats status=%d body=%s err=%v", recorder.Code, recorder.Body.String(), err)
+	}
+	invalid := httptest.NewRecorder()
+	handler.ServeHTTP(invalid, httptest.NewRequest(http.MethodGet, "/api/fingerprints/stats?limit=501", nil))
+	if invalid.Code != http.StatusBadRequest {
+		t.Fatalf("oversized limit status=%d body=%s", invalid.Code, invalid.Body.String())
+	}
+}
+
 func TestSubnetTaskRejectsNonCIDRTarget(t *testing.T) {
 	handler, err := newHandler(nil, func(string, string) (int64, error) {
 		t.Fatal("task runner should not be called")
@@ -428,6 +557,12 @@ func TestAssetAPIReturnsScopeReadModel(t *testing.T) {
 	if asset.Host.ScopeCount != 2 || len(asset.Scopes) != 2 || asset.Scopes[0].Scope != scope16 || asset.Scopes[1].Scope != scope24 {
 		t.Fatalf("asset detail = %#v", asset)
 	}
+
+	hostKeys := httptest.NewRecorder()
+	handler.ServeHTTP(hostKeys, httptest.NewRequest(http.MethodGet, "/api/ssh-host-keys", nil))
+	if hostKeys.Code != http.StatusOK || !strings.Contains(hostKeys.Body.String(), `"clones":[]`) || !strings.Contains(hostKeys.Body.String(), `"changes":[]`) {
+		t.Fatalf("SSH host key report = %d %s", hostKeys.Code, hostKeys.Body.String())
+	}
 }
 
 func TestScanTaskAPICreatesAndManagesInternalTasks(t *testing.T) {
@@ -948,11 +1083,12 @@ func TestScanTaskRunFindingsAPIReportsValidationStateAndPaginates(t *testing.T)
 		Validation model.ScanTaskRunValidation      `json:"validation"`
 		Items      []model.ScanTaskRunVulnerability `json:"items"`
 		Total      int                              `json:"total"`
+		Permitted  bool                             `json:"evidence_permitted"`
 	}
 	if err := json.Unmarshal(response.Body.Bytes(), &result); err != nil {
 		t.Fatal(err)
 	}
-	if result.Validation.Status != model.ScanTaskRunValidationSuccess || result.Total != 2 || len(result.Items) != 1 || result.Items[0].FindingKey != "two" || result.Items[0].Description != "Readable API description" {
+	if result.Validation.Status != model.ScanTaskRunValidationSuccess || result.Total != 2 || len(result.Items) != 1 || result.Items[0].FindingKey != "two" || result.Items[0].Description != "Readable API description" || result.Permitted {
 		t.Fatalf("unexpected findings response: %#v", result)
 	}
 	if strings.Contains(response.Body.String(), "nuclei-json") || strings.Contains(response.Body.String(), "\"evidence\"") {
@@ -960,6 +1096,394 @@ func TestScanTaskRunFindingsAPIReportsValidationStateAndPaginates(t *testing.T)
 	}
 }
 
+func TestScanTaskRunWeaknessesAPIFiltersBySeverity(t *testing.T) {
+	db := openScanTaskAPIDB(t)
+	service := schedule.NewTaskService(db, nil)
+	handler, err := newHandlerWithScanTasks(db, func(string, string) (int64, error) { return 1, nil }, service, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, _, err := service.Create(context.Background(), model.ScanTask{Target: "192.168.72.0/24", ScanType: model.ScanTypeSubnet, Mode: model.ScanTaskModeScheduled, Cron: "0 2 * * *", Timezone: "UTC"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := createCompletedScanTaskRunForAPI(t, db, task.ID, "2026-07-24T02:00:00Z", model.ScanTaskRunSnapshot{
+		Weaknesses: []model.ScanTaskRunWeakness{
+			{IP: "192.168.72.10", Port: 443, Protocol: "https", Check: "missing_hsts", Severity: "low", Path: "/"},
+			{IP: "192.168.72.10", Port: 443, Protocol: "https", Check: "directory_listing", Subject: "/files/", Severity: "medium", Path: "/files/"},
+		},
+	})
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/scan-tasks/%d/runs/%d/weaknesses?severity=medium", task.ID, run.ID), nil))
+	if response.Code != http.StatusOK {
+		t.Fatalf("weaknesses status=%d body=%s", response.Code, response.Body.String())
+	}
+	var result struct {
+		Items []model.ScanTaskRunWeakness `json:"items"`
+		Total int                         `json:"total"`
+	}
+	if err := json.Unmarshal(response.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 1 || len(result.Items) != 1 || result.Items[0].Check != "directory_listing" {
+		t.Fatalf("unexpected weaknesses response: %#v", result)
+	}
+}
+
+func TestScanTaskRunRiskAPIAndAssetRiskSort(t *testing.T) {
+	db := openScanTaskAPIDB(t)
+	service := schedule.NewTaskService(db, nil)
+	handler, err := newHandlerWithScanTasks(db, func(string, string) (int64, error) { return 1, nil }, service, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, _, err := service.Create(context.Background(), model.ScanTask{Target: "192.168.73.0/24", ScanType: model.ScanTypeSubnet, Mode: model.ScanTaskModeScheduled, Cron: "0 2 * * *", Timezone: "UTC"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := createCompletedScanTaskRunForAPI(t, db, task.ID, "2026-07-24T02:00:00Z", model.ScanTaskRunSnapshot{
+		Ports: []model.ScanTaskRunPort{{IP: "192.168.73.10", Port: 22, ServiceType: "ssh"}, {IP: "192.168.73.20", Port: 6379, ServiceType: "redis"}},
+	})
+	if err := storage.SyncHostInventory(db, task.Target, []string{"192.168.73.10", "192.168.73.20"}); err != nil {
+		t.Fatal(err)
+	}
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/scan-tasks/%d/runs/%d/risk", task.ID, run.ID), nil))
+	if response.Code != http.StatusOK {
+		t.Fatalf("risk status=%d body=%s", response.Code, response.Body.String())
+	}
+	var risk model.ScanTaskRunRisk
+	if err := json.Unmarshal(response.Body.Bytes(), &risk); err != nil {
+		t.Fatal(err)
+	}
+	if risk.Score != 20 || risk.Level != model.RiskLevelLow || len(risk.Hosts) != 2 || len(risk.Endpoints) != 2 || risk.Endpoints[0].Factors[0].Kind != model.RiskFactorExposure {
+		t.Fatalf("risk=%#v", risk)
+	}
+
+	assets := httptest.NewRecorder()
+	handler.ServeHTTP(assets, httptest.NewRequest(http.MethodGet, "/api/assets?sort=risk", nil))
+	var hosts []model.HostInventory
+	if err := json.Unmarshal(assets.Body.Bytes(), &hosts); err != nil || assets.Code != http.StatusOK {
+		t.Fatalf("assets status=%d body=%s err=%v", assets.Code, assets.Body.String(), err)
+	}
+	if len(hosts) != 2 || hosts[0].IP != "192.168.73.20" || hosts[0].RiskScore != 20 || hosts[1].RiskScore != 15 || hosts[1].RiskLevel != model.RiskLevelLow {
+		t.Fatalf("assets by risk=%#v", hosts)
+	}
+	invalid := httptest.NewRecorder()
+	handler.ServeHTTP(invalid, httptest.NewRequest(http.MethodGet, "/api/assets?sort=severity", nil))
+	if invalid.Code != http.StatusBadRequest {
+		t.Fatalf("invalid sort status=%d", invalid.Code)
+	}
+}
+
+func TestRiskLabelsAPIListsCriticalityLabels(t *testing.T) {
+	db := openScanTaskAPIDB(t)
+	handler, err := newHandlerWithScanTasks(db, func(string, string) (int64, error) { return 1, nil }, schedule.NewTaskService(db, nil), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, label := range []struct{ cidr, level, note string }{{"192.168.74.0/24", model.RiskLevelHigh, "payments"}, {"192.168.74.10", model.RiskLevelCritical, "ledger"}} {
+		if _, err := storage.SetAssetCriticalityLabel(db, label.cidr, label.level, label.note); err != nil {
+			t.Fatal(err)
+		}
+	}
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/api/risk/labels", nil))
+	var labels []model.AssetCriticalityLabel
+	if err := json.Unmarshal(response.Body.Bytes(), &labels); err != nil || response.Code != http.StatusOK {
+		t.Fatalf("labels status=%d body=%s err=%v", response.Code, response.Body.String(), err)
+	}
+	if len(labels) != 2 || labels[0].CIDR != "192.168.74.0/24" || labels[0].Note != "payments" || labels[1].CIDR != "192.168.74.10/32" || labels[1].Level != model.RiskLevelCritical {
+		t.Fatalf("labels=%#v", labels)
+	}
+	post := httptest.NewRecorder()
+	handler.ServeHTTP(post, httptest.NewRequest(http.MethodPost, "/api/risk/labels", nil))
+	if post.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("labels POST status=%d", post.Code)
+	}
+}
+
+func TestFindingTriageAPIUpdatesStateAndFiltersRunFindings(t *testing.T) {
+	db := openScanTaskAPIDB(t)
+	service := schedule.NewTaskService(db, nil)
+	handler, err := newHandlerWithScanTasks(db, func(string, string) (int64, error) { return 1, nil }, service, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, _, err := service.Create(context.Background(), model.ScanTask{Target: "192.168.74.0/24", ScanType: model.ScanTypeSubnet, Mode: model.ScanTaskModeScheduled, Cron: "0 2 * * *", Timezone: "UTC"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := createCompletedScanTaskRunForAPI(t, db, task.ID, "2026-07-24T02:00:00Z", model.ScanTaskRunSnapshot{
+		Vulnerabilities: []model.ScanTaskRunVulnerability{
+			{FindingKey: "redis-unauth|192.168.74.10:6379", TemplateID: "redis-unauth", Severity: "high", Target: "192.168.74.10:6379"},
+			{FindingKey: "git-config|http://192.168.74.11/.git/config", TemplateID: "git-config", Severity: "medium", Target: "http://192.168.74.11/.git/config"},
+		},
+	})
+	update := httptest.NewRecorder()
+	handler.ServeHTTP(update, httptest.NewRequest(http.MethodPost, "/api/findings/triage", strings.NewReader(`{"finding_key":"git-config|http://192.168.74.11/.git/config","state":"false_positive","author":"alice","comment":"honeypot"}`)))
+	if update.Code != http.StatusOK {
+		t.Fatalf("triage status=%d body=%s", update.Code, update.Body.String())
+	}
+	var triage model.FindingTriage
+	if err := json.Unmarshal(update.Body.Bytes(), &triage); err != nil {
+		t.Fatal(err)
+	}
+	if triage.State != model.FindingStateFalsePositive || len(triage.Events) != 1 || triage.Events[0].Author != "alice" {
+		t.Fatalf("unexpected triage: %#v", triage)
+	}
+	missing := httptest.NewRecorder()
+	handler.ServeHTTP(missing, httptest.NewRequest(http.MethodGet, "/api/findings/triage?key=unknown", nil))
+	if missing.Code != http.StatusNotFound {
+		t.Fatalf("unknown finding status=%d", missing.Code)
+	}
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/scan-tasks/%d/runs/%d/findings?state=open", task.ID, run.ID), nil))
+	if response.Code != http.StatusOK {
+		t.Fatalf("findings status=%d body=%s", response.Code, response.Body.String())
+	}
+	var result struct {
+		Items []model.ScanTaskRunVulnerability `json:"items"`
+		Total int                              `json:"total"`
+	}
+	if err := json.Unmarshal(response.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 1 || result.Items[0].TemplateID != "redis-unauth" || result.Items[0].State != model.FindingStateOpen {
+		t.Fatalf("unexpected open findings: %#v", result)
+	}
+	invalid := httptest.NewRecorder()
+	handler.ServeHTTP(invalid, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/scan-tasks/%d/runs/%d/findings?state=closed", task.ID, run.ID), nil))
+	if invalid.Code != http.StatusBadRequest {
+		t.Fatalf("invalid state filter status=%d", invalid.Code)
+	}
+}
+
+func TestGlobalFindingsAPIMergesOverlappingTasks(t *testing.T) {
+	db := openScanTaskAPIDB(t)
+	service := schedule.NewTaskService(db, nil)
+	handler, err := newHandlerWithScanTasks(db, func(string, string) (int64, error) { return 1, nil }, service, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	redis := model.ScanTaskRunVulnerability{FindingKey: "redis-unauth|192.168.75.10:6379", TemplateID: "redis-unauth", Severity: "high", Target: "192.168.75.10:6379", TargetIP: "192.168.75.10", TargetPort: 6379}
+	for _, target := range []string{"192.168.75.0/24", "192.168.75.0/28"} {
+		task, _, err := service.Create(context.Background(), model.ScanTask{Target: target, ScanType: model.ScanTypeSubnet, Mode: model.ScanTaskModeScheduled, Cron: "0 2 * * *", Timezone: "UTC"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		createCompletedScanTaskRunForAPI(t, db, task.ID, "2026-07-25T02:00:00Z", model.ScanTaskRunSnapshot{Vulnerabilities: []model.ScanTaskRunVulnerability{redis}})
+	}
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/api/findings?cidr=192.168.75.0/24&severity=high", nil))
+	if response.Code != http.StatusOK {
+		t.Fatalf("findings status=%d body=%s", response.Code, response.Body.String())
+	}
+	var result struct {
+		Items []model.GlobalFinding `json:"items"`
+		Total int                   `json:"total"`
+	}
+	if err := json.Unmarshal(response.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 1 || len(result.Items[0].Sources) != 2 || result.Items[0].FirstSeenAt != "2026-07-25 02:00:00" {
+		t.Fatalf("unexpected global findings: %#v", result)
+	}
+	invalid := httptest.NewRecorder()
+	handler.ServeHTTP(invalid, httptest.NewRequest(http.MethodGet, "/api/findings?cidr=192.168.75.0", nil))
+	if invalid.Code != http.StatusBadRequest {
+		t.Fatalf("invalid cidr filter status=%d", invalid.Code)
+	}
+}
+
+func TestScanTaskRunPotentialVulnerabilitiesAPIFiltersKnownExploited(t *testing.T) {
+	db := openScanTaskAPIDB(t)
+	service := schedule.NewTaskService(db, nil)
+	handler, err := newHandlerWithScanTasks(db, func(string, string) (int64, error) { return 1, nil }, service, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, _, err := service.Create(context.Background(), model.ScanTask{Target: "192.168.73.0/24", ScanType: model.ScanTypeSubnet, Mode: model.ScanTaskModeScheduled, Cron: "0 2 * * *", Timezone: "UTC"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := createCompletedScanTaskRunForAPI(t, db, task.ID, "2026-07-24T02:00:00Z", model.ScanTaskRunSnapshot{})
+	for _, statement := range []string{
+		`INSERT INTO scan_task_run_cve_matches (scan_task_run_id, ip, port, protocol, product_key, cpe, version, cve_id, criteria, severity, known_exploited) VALUES (?, '192.168.73.10', 80, 'http', 'nginx', 'cpe:2.3:a:f5:nginx:1.18.0', '1.18.0', 'CVE-2021-23017', 'cpe:2.3:a:f5:nginx:*', 'high', 0)`,
+		`INSERT INTO scan_task_run_cve_matches (scan_task_run_id, ip, port, protocol, product_key, cpe, version, cve_id, criteria, severity, known_exploited) VALUES (?, '192.168.73.10', 80, 'http', 'nginx', 'cpe:2.3:a:f5:nginx:1.18.0', '1.18.0', 'CVE-2020-0001', 'cpe:2.3:a:f5:nginx:1.18.0', 'critical', 1)`,
+	} {
+		if _, err := db.Exec(statement, run.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/scan-tasks/%d/runs/%d/potential-vulnerabilities?kev=true", task.ID, run.ID), nil))
+	if response.Code != http.StatusOK {
+		t.Fatalf("potential vulnerabilities status=%d body=%s", response.Code, response.Body.String())
+	}
+	var result struct {
+		Validated bool                                      `json:"validated"`
+		Items     []model.ScanTaskRunPotentialVulnerability `json:"items"`
+		Total     int                                       `json:"total"`
+	}
+	if err := json.Unmarshal(response.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Validated || result.Total != 1 || len(result.Items) != 1 || result.Items[0].CVEID != "CVE-2020-0001" || !result.Items[0].KnownExploited {
+		t.Fatalf("unexpected potential vulnerabilities response: %#v", result)
+	}
+	invalid := httptest.NewRecorder()
+	handler.ServeHTTP(invalid, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/scan-tasks/%d/runs/%d/potential-vulnerabilities?kev=maybe", task.ID, run.ID), nil))
+	if invalid.Code != http.StatusBadRequest {
+		t.Fatalf("invalid kev filter status=%d", invalid.Code)
+	}
+}
+
+func TestScanTaskPlanAPIRequiresSuccessfulRun(t *testing.T) {
+	db := openScanTaskAPIDB(t)
+	service := schedule.NewTaskService(db, nil)
+	handler, err := newHandlerWithScanTasks(db, func(string, string) (int64, error) { return 1, nil }, service, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, _, err := service.Create(context.Background(), model.ScanTask{Target: "192.168.76.0/28", ScanType: model.ScanTypeSubnet, Mode: model.ScanTaskModeScheduled, Cron: "0 2 * * *", Timezone: "UTC"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]int{
+		fmt.Sprintf("/api/scan-tasks/%d/plan", task.ID):        http.StatusNotFound,
+		fmt.Sprintf("/api/scan-tasks/%d/plan?run=x", task.ID):  http.StatusBadRequest,
+		fmt.Sprintf("/api/scan-tasks/%d/plan?run=99", task.ID): http.StatusNotFound,
+		"/api/scan-tasks/999/plan":                             http.StatusNotFound,
+	} {
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, path, nil))
+		if response.Code != want {
+			t.Fatalf("%s status=%d want=%d body=%s", path, response.Code, want, response.Body.String())
+		}
+	}
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/scan-tasks/%d/plan", task.ID), nil))
+	if response.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("plan POST status=%d", response.Code)
+	}
+}
+
+func TestScanTaskRevalidateAPIRequiresValidationEnabledSource(t *testing.T) {
+	db := openScanTaskAPIDB(t)
+	service := schedule.NewTaskService(db, nil)
+	handler, err := newHandlerWithScanTasks(db, func(string, string) (int64, error) { return 1, nil }, service, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, _, err := service.Create(context.Background(), model.ScanTask{Target: "192.168.77.0/28", ScanType: model.ScanTypeSubnet, Mode: model.ScanTaskModeScheduled, Cron: "0 2 * * *", Timezone: "UTC"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := createCompletedScanTaskRunForAPI(t, db, task.ID, "2026-10-19T02:00:00Z", model.ScanTaskRunSnapshot{
+		Hosts: []model.ScanTaskRunHost{{IP: "192.168.77.10", IsActive: true}},
+		Ports: []model.ScanTaskRunPort{{IP: "192.168.77.10", Port: 6379, ServiceType: "redis"}},
+	})
+	for path, want := range map[string]int{
+		fmt.Sprintf("/api/scan-tasks/%d/runs/99/revalidate", task.ID):            http.StatusNotFound,
+		fmt.Sprintf("/api/scan-tasks/999/runs/%d/revalidate", source.ID):         http.StatusNotFound,
+		fmt.Sprintf("/api/scan-tasks/%d/runs/%d/revalidate", task.ID, source.ID): http.StatusConflict,
+	} {
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest(http.MethodPost, path, nil))
+		if response.Code != want {
+			t.Fatalf("%s status=%d want=%d body=%s", path, response.Code, want, response.Body.String())
+		}
+	}
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/scan-tasks/%d/runs/%d/revalidate", task.ID, source.ID), nil))
+	if response.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("revalidate GET status=%d", response.Code)
+	}
+}
+
+func TestScanTaskRunEvidenceAPIRequiresEvidencePermission(t *testing.T) {
+	db := openScanTaskAPIDB(t)
+	service := schedule.NewTaskService(db, nil)
+	handler, err := newHandlerWithScanTasks(db, func(string, string) (int64, error) { return 1, nil }, service, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, _, err := service.Create(context.Background(), model.ScanTask{Target: "192.168.78.0/28", ScanType: model.ScanTypeSubnet, Mode: model.ScanTaskModeScheduled, Cron: "0 2 * * *", Timezone: "UTC"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := createCompletedScanTaskRunForAPI(t, db, task.ID, "2026-10-19T02:00:00Z", model.ScanTaskRunSnapshot{
+		Hosts: []model.ScanTaskRunHost{{IP: "192.168.78.10", IsActive: true}},
+		Ports: []model.ScanTaskRunPort{{IP: "192.168.78.10", Port: 80, ServiceType: "http"}},
+		Vulnerabilities: []model.ScanTaskRunVulnerability{{FindingKey: "exposed-panel|http://192.168.78.10/admin|192.168.78.10|80", TemplateID: "exposed-panel", Target: "http://192.168.78.10/admin", TargetIP: "192.168.78.10", TargetPort: 80,
+			Evidence: `{"template-id":"exposed-panel","request":"GET /admin HTTP/1.1\r\nCookie: sid=0xdeadbeef\r\n\r\n","response":"HTTP/1.1 200 OK\r\n\r\nadmin console"}`}},
+	})
+	path := fmt.Sprintf("/api/scan-tasks/%d/runs/%d/evidence?finding=%s", task.ID, run.ID, url.QueryEscape("exposed-panel|http://192.168.78.10/admin|192.168.78.10|80"))
+	policy := AccessPolicy{TrustedCIDRs: []string{"192.168.0.0/16"}, EvidenceCIDRs: []string{"192.168.1.5/32"}}
+	serve := func(handler http.Handler, remote, path string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		request.RemoteAddr = remote + ":40000"
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		return response
+	}
+	for _, denied := range []struct {
+		handler http.Handler
+		remote  string
+	}{{handler, "127.0.0.1"}, {policy.Wrap(handler), "127.0.0.1"}, {policy.Wrap(handler), "192.168.1.6"}} {
+		if response := serve(denied.handler, denied.remote, path); response.Code != http.StatusForbidden {
+			t.Fatalf("evidence from %s status=%d body=%s", denied.remote, response.Code, response.Body.String())
+		}
+	}
+	wrapped := policy.Wrap(handler)
+	response := serve(wrapped, "192.168.1.5", path)
+	if response.Code != http.StatusOK || strings.Contains(response.Body.String(), "0xdeadbeef") || !strings.Contains(response.Body.String(), "Cookie: [REDACTED]") || !strings.Contains(response.Body.String(), "admin console") {
+		t.Fatalf("evidence status=%d body=%s", response.Code, response.Body.String())
+	}
+	findingsPath := fmt.Sprintf("/api/scan-tasks/%d/runs/%d/findings", task.ID, run.ID)
+	for remote, want := range map[string]string{"192.168.1.5": `"evidence_permitted":true`, "192.168.1.6": `"evidence_permitted":false`} {
+		if response := serve(wrapped, remote, findingsPath); response.Code != http.StatusOK || !strings.Contains(response.Body.String(), want) {
+			t.Fatalf("findings from %s status=%d body=%s", remote, response.Code, response.Body.String())
+		}
+	}
+	for target, want := range map[string]int{
+		fmt.Sprintf("/api/scan-tasks/%d/runs/%d/evidence?finding=missing", task.ID, run.ID): http.StatusNotFound,
+		fmt.Sprintf("/api/scan-tasks/%d/runs/%d/evidence", task.ID, run.ID):                 http.StatusBadRequest,
+	} {
+		if response := serve(wrapped, "192.168.1.5", target); response.Code != want {
+			t.Fatalf("%s status=%d want=%d body=%s", target, response.Code, want, response.Body.String())
+		}
+	}
+
+	workingDirectory, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(workingDirectory) })
+	snapshot, err := storage.GetScanTaskRunSnapshot(db, run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := report.WriteScanTaskRunReport(report.DefaultDirectory, report.ScanTaskRunReport{Task: task, Run: run, Snapshot: snapshot}); err != nil {
+		t.Fatalf("write report: %v", err)
+	}
+	auditPath := fmt.Sprintf("/api/scan-tasks/%d/runs/%d/audit-report", task.ID, run.ID)
+	response = serve(policy.Wrap(handler), "192.168.1.6", auditPath)
+	if body := response.Body.String(); response.Code != http.StatusOK || !strings.Contains(body, "## Finding Evidence") || strings.Contains(body, "admin console") || !strings.Contains(body, "requires the evidence permission") {
+		t.Fatalf("audit report without permission status=%d body=%s", response.Code, body)
+	}
+	if response := serve(wrapped, "192.168.1.5", auditPath); response.Code != http.StatusOK || !strings.Contains(response.Body.String(), "admin console") {
+		t.Fatalf("audit report with permission status=%d body=%s", response.Code, response.Body.String())
+	}
+}
+
 func createCompletedScanTaskRunForAPI(t *testing.T, db *sql.DB, taskID int64, scheduledFor string, snapshot model.ScanTaskRunSnapshot) model.ScanTaskRun {
 	t.Helper()
 	run, err := storage.CreateScanTaskRun(db, model.ScanTaskRun{ScanTaskID: taskID, ScheduledFor: scheduledFor})
@@ -1016,6 +1540,16 @@ func openScanTaskAPIDB(t *testing.T) *sql.DB {
 		`CREATE TABLE scan_task_run_protocol_evidence (scan_task_run_id INTEGER NOT NULL, ip TEXT NOT NULL, port INTEGER NOT NULL, evidence_type TEXT NOT NULL, probe_name TEXT NOT NULL DEFAULT '', protocol TEXT NOT NULL, responded INTEGER NOT NULL DEFAULT 0, outcome TEXT NOT NULL DEFAULT '', diagnostic TEXT NOT NULL DEFAULT '', status_code INTEGER, server TEXT, title TEXT, banner_captured_length INTEGER NOT NULL DEFAULT 0, banner_sha256 TEXT, banner_truncated INTEGER NOT NULL DEFAULT 0, header_captured_length INTEGER NOT NULL DEFAULT 0, header_sha256 TEXT, header_truncated INTEGER NOT NULL DEFAULT 0, body_captured_length INTEGER NOT NULL DEFAULT 0, body_sha256 TEXT, body_truncated INTEGER NOT NULL DEFAULT 0, PRIMARY KEY(scan_task_run_id, ip, port, evidence_type, protocol, probe_name))`,
 		`CREATE TABLE scan_task_run_validation (scan_task_run_id INTEGER PRIMARY KEY, status TEXT NOT NULL, identified_product_count INTEGER NOT NULL DEFAULT 0, mapped_product_count INTEGER NOT NULL DEFAULT 0, unmapped_products_json TEXT NOT NULL DEFAULT '[]', candidate_endpoint_count INTEGER NOT NULL DEFAULT 0, executed_endpoint_count INTEGER NOT NULL DEFAULT 0, template_count INTEGER NOT NULL DEFAULT 0, executed_template_count INTEGER NOT NULL DEFAULT 0, finding_count INTEGER NOT NULL DEFAULT 0, started_at TEXT, finished_at TEXT, error_message TEXT)`,
 		`CREATE TABLE scan_task_run_vulnerabilities (scan_task_run_id INTEGER NOT NULL, finding_key TEXT NOT NULL, template_id TEXT, name TEXT, severity TEXT, target TEXT NOT NULL, target_ip TEXT, target_port INTEGER, matched_at TEXT, description TEXT, evidence TEXT, PRIMARY KEY(scan_task_run_id, finding_key))`,
+		`CREATE TABLE scan_task_run_weaknesses (scan_task_run_id INTEGER NOT NULL, ip TEXT NOT NULL, port INTEGER NOT NULL, protocol TEXT NOT NULL, check_id TEXT NOT NULL, subject TEXT NOT NULL DEFAULT '', severity TEXT NOT NULL, path TEXT NOT NULL, detail TEXT NOT NULL DEFAULT '', PRIMARY KEY(scan_task_run_id, ip, port, protocol, check_id, subject))`,
+		`CREATE TABLE finding_triage (finding_key TEXT PRIMARY KEY, state TEXT NOT NULL DEFAULT 'open', assignee TEXT NOT NULL DEFAULT '', risk_accepted_until TEXT NOT NULL DEFAULT '', created_at DATETIME NOT NULL DEFAULT (datetime('now')), updated_at DATETIME NOT NULL DEFAULT (datetime('now')))`,
+		`CREATE TABLE finding_triage_events (id INTEGER PRIMARY KEY AUTOINCREMENT, finding_key TEXT NOT NULL, kind TEXT NOT NULL, state TEXT NOT NULL DEFAULT '', assignee TEXT NOT NULL DEFAULT '', author TEXT NOT NULL DEFAULT '', comment TEXT NOT NULL DEFAULT '', scan_task_run_id INTEGER, created_at DATETIME NOT NULL DEFAULT (datetime('now')))`,
+		`CREATE TABLE scan_task_run_cve_matches (scan_task_run_id INTEGER NOT NULL, ip TEXT NOT NULL, port INTEGER NOT NULL, protocol TEXT NOT NULL, product_key TEXT NOT NULL, cpe TEXT NOT NULL, version TEXT NOT NULL, cve_id TEXT NOT NULL, criteria TEXT NOT NULL, version_range TEXT NOT NULL DEFAULT '', cvss_version TEXT NOT NULL DEFAULT '', cvss_score REAL NOT NULL DEFAULT 0, severity TEXT NOT NULL DEFAULT '', known_exploited INTEGER NOT NULL DEFAULT 0, kev_date_added TEXT NOT NULL DEFAULT '', PRIMARY KEY(scan_task_run_id, ip, port, protocol, product_key, cve_id))`,
+		`CREATE TABLE cve_records (cve_id TEXT PRIMARY KEY, cvss_score REAL NOT NULL DEFAULT 0)`,
+		`CREATE TABLE cve_known_exploited (cve_id TEXT PRIMARY KEY)`,
+		`CREATE TABLE scan_task_run_risk_scores (scan_task_run_id INTEGER NOT NULL, scope TEXT NOT NULL, ip TEXT NOT NULL DEFAULT '', port INTEGER NOT NULL DEFAULT 0, score INTEGER NOT NULL DEFAULT 0, level TEXT NOT NULL, factors_json TEXT NOT NULL DEFAULT '[]', PRIMARY KEY(scan_task_run_id, scope, ip, port))`,
+		`CREATE TABLE asset_criticality_labels (id INTEGER PRIMARY KEY AUTOINCREMENT, cidr TEXT NOT NULL UNIQUE, level TEXT NOT NULL, note TEXT NOT NULL DEFAULT '', created_at DATETIME NOT NULL DEFAULT (datetime('now')), updated_at DATETIME NOT NULL DEFAULT (datetime('now')))`,
+		`CREATE TABLE host_inventory (id INTEGER PRIMARY KEY AUTOINCREMENT, ip TEXT NOT NULL UNIQUE, source TEXT, first_seen DATETIME NOT NULL, last_seen DATETIME NOT NULL, last_scan DATETIME, is_active INTEGER NOT NULL DEFAULT 1)`,
+		`CREATE TABLE host_inventory_scopes (scope TEXT NOT NULL, ip TEXT NOT NULL, first_seen DATETIME NOT NULL, last_seen DATETIME NOT NULL, last_checked DATETIME NOT NULL, is_active INTEGER NOT NULL DEFAULT 1, PRIMARY KEY (scope, ip))`,
 	} {
 		if _, err := db.Exec(statement); err != nil {
 			t.Fatalf("create scan task API schema: %v", err)
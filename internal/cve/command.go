@@ -0,0 +1,73 @@
+package cve
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golandproject/yscan/internal/storage"
+)
+
+const usage = "usage: yscan cve import <nvd-feed.json[.gz]|known_exploited_vulnerabilities.json>...\n       yscan cve status"
+
+// RunCLI imports offline CVE data for "yscan cve". Runs correlate against
+// whatever is imported when their snapshot is saved; an earlier run picks up
+// new data only through fingerprint reevaluate.
+func RunCLI(db *sql.DB, args []string, output io.Writer) error {
+	if db == nil || output == nil {
+		return errors.New("CVE CLI database and output are required")
+	}
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	switch strings.ToLower(strings.TrimSpace(args[0])) {
+	case "import":
+		if len(args) < 2 {
+			return errors.New(usage)
+		}
+		for _, path := range args[1:] {
+			if err := importFile(db, path, output); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		return nil
+	case "status":
+		summary, err := storage.GetCVEDataSummary(db)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(output, "cve_records=%d cpe_matches=%d known_exploited=%d last_modified=%s\n",
+			summary.Records, summary.CPEMatches, summary.KnownExploited, firstNonEmpty(summary.LastModified, "-"))
+		return err
+	default:
+		return errors.New(usage)
+	}
+}
+
+func importFile(db *sql.DB, path string, output io.Writer) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	feed, err := ParseFeed(file)
+	if err != nil {
+		return err
+	}
+	if feed.Kind == FeedKEV {
+		if err := storage.ReplaceKnownExploitedCVEs(db, feed.KnownExploited); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(output, "imported CISA KEV catalog %s: %d known exploited CVEs\n", feed.CatalogVersion, len(feed.KnownExploited))
+		return err
+	}
+	matches, err := storage.ImportCVERecords(db, feed.Records)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(output, "imported NVD feed: %d CVEs, %d vulnerable CPE criteria\n", len(feed.Records), matches)
+	return err
+}
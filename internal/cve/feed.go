@@ -0,0 +1,235 @@
+// Package cve reads offline NVD CVE JSON 2.0 feeds and the CISA Known
+// Exploited Vulnerabilities catalog so endpoint CPEs can be correlated with
+// published CVEs without network access.
+package cve
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golandproject/yscan/internal/model"
+)
+
+// Feed kinds recognised by ParseFeed.
+const (
+	FeedNVD = "nvd"
+	FeedKEV = "kev"
+)
+
+// Feed is the content of one imported file: NVD records or the KEV catalog.
+type Feed struct {
+	Kind           string
+	CatalogVersion string
+	Records        []model.CVERecord
+	KnownExploited []model.KnownExploitedCVE
+}
+
+type feedDocument struct {
+	Format          string            `json:"format"`
+	CatalogVersion  string            `json:"catalogVersion"`
+	Vulnerabilities []json.RawMessage `json:"vulnerabilities"`
+}
+
+type nvdItem struct {
+	CVE *nvdCVE `json:"cve"`
+}
+
+type nvdCVE struct {
+	ID           string `json:"id"`
+	Published    string `json:"published"`
+	LastModified string `json:"lastModified"`
+	VulnStatus   string `json:"vulnStatus"`
+	Descriptions []struct {
+		Lang  string `json:"lang"`
+		Value string `json:"value"`
+	} `json:"descriptions"`
+	Metrics        map[string][]nvdMetric `json:"metrics"`
+	Configurations []struct {
+		Nodes []nvdNode `json:"nodes"`
+	} `json:"configurations"`
+}
+
+type nvdMetric struct {
+	Type         string `json:"type"`
+	BaseSeverity string `json:"baseSeverity"`
+	CVSSData     struct {
+		Version      string  `json:"version"`
+		VectorString string  `json:"vectorString"`
+		BaseScore    float64 `json:"baseScore"`
+		BaseSeverity string  `json:"baseSeverity"`
+	} `json:"cvssData"`
+}
+
+type nvdNode struct {
+	Negate   bool `json:"negate"`
+	CPEMatch []struct {
+		Vulnerable            bool   `json:"vulnerable"`
+		Criteria              string `json:"criteria"`
+		VersionStartIncluding string `json:"versionStartIncluding"`
+		VersionStartExcluding string `json:"versionStartExcluding"`
+		VersionEndIncluding   string `json:"versionEndIncluding"`
+		VersionEndExcluding   string `json:"versionEndExcluding"`
+	} `json:"cpeMatch"`
+}
+
+type kevItem struct {
+	CVEID                      string `json:"cveID"`
+	VendorProject              string `json:"vendorProject"`
+	Product                    string `json:"product"`
+	VulnerabilityName          string `json:"vulnerabilityName"`
+	DateAdded                  string `json:"dateAdded"`
+	DueDate                    string `json:"dueDate"`
+	KnownRansomwareCampaignUse string `json:"knownRansomwareCampaignUse"`
+}
+
+// nvdMetricPreference picks the CVSS metric shown with a record. v3.x is
+// preferred because it is what most NVD entries and KEV triage use.
+var nvdMetricPreference = []string{"cvssMetricV31", "cvssMetricV30", "cvssMetricV40", "cvssMetricV2"}
+
+// ParseFeed reads an NVD CVE JSON 2.0 feed or a KEV catalog, optionally
+// gzip-compressed as NVD distributes its yearly feeds.
+func ParseFeed(reader io.Reader) (Feed, error) {
+	buffered := bufio.NewReader(reader)
+	if magic, err := buffered.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		decompressed, err := gzip.NewReader(buffered)
+		if err != nil {
+			return Feed{}, fmt.Errorf("open gzip feed: %w", err)
+		}
+		defer decompressed.Close()
+		buffered = bufio.NewReader(decompressed)
+	}
+	var document feedDocument
+	if err := json.NewDecoder(buffered).Decode(&document); err != nil {
+		return Feed{}, fmt.Errorf("decode feed: %w", err)
+	}
+	if document.CatalogVersion != "" {
+		return parseKEV(document)
+	}
+	if document.Format == "NVD_CVE" || firstItemHasCVE(document.Vulnerabilities) {
+		return parseNVD(document)
+	}
+	return Feed{}, errors.New("file is neither an NVD CVE JSON 2.0 feed nor a CISA KEV catalog")
+}
+
+func firstItemHasCVE(items []json.RawMessage) bool {
+	if len(items) == 0 {
+		return false
+	}
+	var item nvdItem
+	return json.Unmarshal(items[0], &item) == nil && item.CVE != nil
+}
+
+func parseNVD(document feedDocument) (Feed, error) {
+	feed := Feed{Kind: FeedNVD, Records: make([]model.CVERecord, 0, len(document.Vulnerabilities))}
+	for index, raw := range document.Vulnerabilities {
+		var item nvdItem
+		if err := json.Unmarshal(raw, &item); err != nil {
+			return Feed{}, fmt.Errorf("decode NVD vulnerability %d: %w", index+1, err)
+		}
+		if item.CVE == nil || strings.TrimSpace(item.CVE.ID) == "" {
+			return Feed{}, fmt.Errorf("NVD vulnerability %d has no CVE ID", index+1)
+		}
+		if strings.EqualFold(item.CVE.VulnStatus, "Rejected") {
+			continue
+		}
+		feed.Records = append(feed.Records, nvdRecord(*item.CVE))
+	}
+	return feed, nil
+}
+
+func nvdRecord(item nvdCVE) model.CVERecord {
+	record := model.CVERecord{ID: item.ID, Published: item.Published, LastModified: item.LastModified}
+	for _, description := range item.Descriptions {
+		if description.Lang == "en" {
+			record.Description = description.Value
+			break
+		}
+	}
+	if metric, ok := preferredMetric(item.Metrics); ok {
+		record.CVSSVersion = metric.CVSSData.Version
+		record.CVSSScore = metric.CVSSData.BaseScore
+		record.CVSSVector = metric.CVSSData.VectorString
+		// CVSS v2 keeps the severity beside cvssData rather than inside it.
+		record.CVSSSeverity = strings.ToLower(firstNonEmpty(metric.CVSSData.BaseSeverity, metric.BaseSeverity))
+	}
+	seen := make(map[model.CVECPEMatch]struct{})
+	for _, configuration := range item.Configurations {
+		for _, node := range configuration.Nodes {
+			if node.Negate {
+				continue
+			}
+			for _, candidate := range node.CPEMatch {
+				if !candidate.Vulnerable || strings.TrimSpace(candidate.Criteria) == "" {
+					continue
+				}
+				match := model.CVECPEMatch{
+					Criteria:              strings.TrimSpace(candidate.Criteria),
+					VersionStartIncluding: candidate.VersionStartIncluding,
+					VersionStartExcluding: candidate.VersionStartExcluding,
+					VersionEndIncluding:   candidate.VersionEndIncluding,
+					VersionEndExcluding:   candidate.VersionEndExcluding,
+				}
+				if _, duplicate := seen[match]; duplicate {
+					continue
+				}
+				seen[match] = struct{}{}
+				record.CPEMatches = append(record.CPEMatches, match)
+			}
+		}
+	}
+	return record
+}
+
+// preferredMetric returns the NVD primary metric of the preferred CVSS
+// version, falling back to a secondary source's score.
+func preferredMetric(metrics map[string][]nvdMetric) (nvdMetric, bool) {
+	for _, key := range nvdMetricPreference {
+		candidates := metrics[key]
+		for _, metric := range candidates {
+			if metric.Type == "Primary" {
+				return metric, true
+			}
+		}
+		if len(candidates) > 0 {
+			return candidates[0], true
+		}
+	}
+	return nvdMetric{}, false
+}
+
+func parseKEV(document feedDocument) (Feed, error) {
+	feed := Feed{Kind: FeedKEV, CatalogVersion: document.CatalogVersion, KnownExploited: make([]model.KnownExploitedCVE, 0, len(document.Vulnerabilities))}
+	for index, raw := range document.Vulnerabilities {
+		var item kevItem
+		if err := json.Unmarshal(raw, &item); err != nil {
+			return Feed{}, fmt.Errorf("decode KEV entry %d: %w", index+1, err)
+		}
+		if strings.TrimSpace(item.CVEID) == "" {
+			return Feed{}, fmt.Errorf("KEV entry %d has no CVE ID", index+1)
+		}
+		feed.KnownExploited = append(feed.KnownExploited, model.KnownExploitedCVE{
+			CVEID:             item.CVEID,
+			VendorProject:     item.VendorProject,
+			Product:           item.Product,
+			VulnerabilityName: item.VulnerabilityName,
+			DateAdded:         item.DateAdded,
+			DueDate:           item.DueDate,
+			KnownRansomware:   item.KnownRansomwareCampaignUse,
+		})
+	}
+	return feed, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package cve
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+)
+
+const nvdFeedFixture = `{
+  "resultsPerPage": 2, "format": "NVD_CVE", "version": "2.0",
+  "vulnerabilities": [
+    {"cve": {
+      "id": "CVE-2021-41773", "published": "2021-10-05T09:15:07.593", "lastModified": "2024-07-24T17:08:24.167", "vulnStatus": "Analyzed",
+      "descriptions": [{"lang": "es", "value": "Un fallo"}, {"lang": "en", "value": "A flaw was found in a change made to path normalization in Apache HTTP Server 2.4.49."}],
+      "metrics": {
+        "cvssMetricV2": [{"source": "nvd@nist.gov", "type": "Primary", "baseSeverity": "MEDIUM", "cvssData": {"version": "2.0", "vectorString": "AV:N/AC:L/Au:N/C:P/I:N/A:N", "baseScore": 4.3}}],
+        "cvssMetricV31": [
+          {"source": "secalert@apache.org", "type": "Secondary", "cvssData": {"version": "3.1", "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N", "baseScore": 7.5, "baseSeverity": "HIGH"}},
+          {"source": "nvd@nist.gov", "type": "Primary", "cvssData": {"version": "3.1", "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "baseScore": 9.8, "baseSeverity": "CRITICAL"}}
+        ]
+      },
+      "configurations": [{"nodes": [
+        {"operator": "OR", "negate": false, "cpeMatch": [
+          {"vulnerable": true, "criteria": "cpe:2.3:a:apache:http_server:2.4.49:*:*:*:*:*:*:*"},
+          {"vulnerable": true, "criteria": "cpe:2.3:a:apache:http_server:2.4.49:*:*:*:*:*:*:*"},
+          {"vulnerable": false, "criteria": "cpe:2.3:o:fedoraproject:fedora:34:*:*:*:*:*:*:*"}
+        ]},
+        {"operator": "OR", "negate": true, "cpeMatch": [{"vulnerable": true, "criteria": "cpe:2.3:a:apache:http_server:2.4.50:*:*:*:*:*:*:*"}]}
+      ]}]
+    }},
+    {"cve": {"id": "CVE-2021-99999", "vulnStatus": "Rejected"}}
+  ]
+}`
+
+func TestParseFeedReadsNVDRecordsAndPrefersPrimaryCVSSV31(t *testing.T) {
+	feed, err := ParseFeed(strings.NewReader(nvdFeedFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.Kind != FeedNVD || len(feed.Records) != 1 {
+		t.Fatalf("feed = %#v", feed)
+	}
+	record := feed.Records[0]
+	if record.ID != "CVE-2021-41773" || !strings.HasPrefix(record.Description, "A flaw") {
+		t.Fatalf("record = %#v", record)
+	}
+	if record.CVSSVersion != "3.1" || record.CVSSScore != 9.8 || record.CVSSSeverity != "critical" {
+		t.Fatalf("CVSS = %s %.1f %s", record.CVSSVersion, record.CVSSScore, record.CVSSSeverity)
+	}
+	if len(record.CPEMatches) != 1 || record.CPEMatches[0].Criteria != "cpe:2.3:a:apache:http_server:2.4.49:*:*:*:*:*:*:*" {
+		t.Fatalf("CPE matches = %#v", record.CPEMatches)
+	}
+}
+
+func TestParseFeedReadsGzipFeedsAndKEVCatalog(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write([]byte(nvdFeedFixture)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if feed, err := ParseFeed(&compressed); err != nil || len(feed.Records) != 1 {
+		t.Fatalf("gzip feed = %#v err=%v", feed, err)
+	}
+
+	kev, err := ParseFeed(strings.NewReader(`{"title": "CISA Catalog of Known Exploited Vulnerabilities", "catalogVersion": "2026.10.17", "count": 1,
+		"vulnerabilities": [{"cveID": "CVE-2021-41773", "vendorProject": "Apache", "product": "HTTP Server", "dateAdded": "2021-11-03", "dueDate": "2021-11-17", "knownRansomwareCampaignUse": "Known"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kev.Kind != FeedKEV || kev.CatalogVersion != "2026.10.17" || len(kev.KnownExploited) != 1 || kev.KnownExploited[0].DateAdded != "2021-11-03" {
+		t.Fatalf("KEV feed = %#v", kev)
+	}
+
+	if _, err := ParseFeed(strings.NewReader(`{"items": []}`)); err == nil {
+		t.Fatal("unrecognised JSON must be rejected")
+	}
+}
@@ -0,0 +1,94 @@
+package diff
+
+import (
+	"sort"
+
+	"golandproject/yscan/internal/model"
+)
+
+// compareHostIdentities reports identity fields that both runs observed for a
+// host with different values. A field that one run did not observe, for
+// example because the disclosing endpoint was closed, is not a change.
+func compareHostIdentities(before, after []model.ScanTaskRunHostIdentity) []model.HostIdentityChange {
+	beforeByIP := mergedHostIdentities(before)
+	afterByIP := mergedHostIdentities(after)
+	changes := make([]model.HostIdentityChange, 0)
+	for ip, current := range afterByIP {
+		baseline, found := beforeByIP[ip]
+		if !found {
+			continue
+		}
+		for _, field := range hostIdentityFields(baseline) {
+			after := hostIdentityField(current, field.name)
+			if field.value != "" && after != "" && field.value != after {
+				changes = append(changes, model.HostIdentityChange{IP: ip, Field: field.name, Before: field.value, After: after})
+			}
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].IP != changes[j].IP {
+			return changes[i].IP < changes[j].IP
+		}
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+// mergedHostIdentities folds the per-endpoint identities of each host into
+// one, taking each field from the lowest port that disclosed it.
+func mergedHostIdentities(identities []model.ScanTaskRunHostIdentity) map[string]model.ScanTaskRunHostIdentity {
+	ordered := append([]model.ScanTaskRunHostIdentity(nil), identities...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Port != ordered[j].Port {
+			return ordered[i].Port < ordered[j].Port
+		}
+		return ordered[i].Source < ordered[j].Source
+	})
+	merged := make(map[string]model.ScanTaskRunHostIdentity)
+	for _, identity := range ordered {
+		current := merged[identity.IP]
+		current.IP = identity.IP
+		current.ComputerName = firstNonEmpty(current.ComputerName, identity.ComputerName)
+		current.DomainName = firstNonEmpty(current.DomainName, identity.DomainName)
+		current.DNSComputerName = firstNonEmpty(current.DNSComputerName, identity.DNSComputerName)
+		current.DNSDomainName = firstNonEmpty(current.DNSDomainName, identity.DNSDomainName)
+		current.DNSTreeName = firstNonEmpty(current.DNSTreeName, identity.DNSTreeName)
+		current.OSVersion = firstNonEmpty(current.OSVersion, identity.OSVersion)
+		merged[identity.IP] = current
+	}
+	return merged
+}
+
+type identityField struct {
+	name  string
+	value string
+}
+
+func hostIdentityFields(identity model.ScanTaskRunHostIdentity) []identityField {
+	return []identityField{
+		{name: "computer_name", value: identity.ComputerName},
+		{name: "domain_name", value: identity.DomainName},
+		{name: "dns_computer_name", value: identity.DNSComputerName},
+		{name: "dns_domain_name", value: identity.DNSDomainName},
+		{name: "dns_tree_name", value: identity.DNSTreeName},
+		{name: "os_version", value: identity.OSVersion},
+	}
+}
+
+func hostIdentityField(identity model.ScanTaskRunHostIdentity, name string) string {
+	for _, field := range hostIdentityFields(identity) {
+		if field.name == name {
+			return field.value
+		}
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
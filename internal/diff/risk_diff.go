@@ -0,0 +1,58 @@
+package diff
+
+import (
+	"sort"
+
+	"golandproject/yscan/internal/model"
+)
+
+// compareRisk reports the task score trend and every host whose score moved.
+// A host missing from one run scored zero there. Runs saved before risk
+// scoring have no scores to compare, so the trend is omitted.
+func compareRisk(before, after *model.ScanTaskRunRisk) *model.RiskChanges {
+	if before == nil || after == nil {
+		return nil
+	}
+	changes := &model.RiskChanges{
+		Before:      before.Score,
+		After:       after.Score,
+		Delta:       after.Score - before.Score,
+		LevelBefore: model.RiskLevelForScore(before.Score),
+		LevelAfter:  model.RiskLevelForScore(after.Score),
+		Hosts:       make([]model.HostRiskChange, 0),
+	}
+	scores := make(map[string]*model.HostRiskChange)
+	for _, host := range before.Hosts {
+		scores[host.IP] = &model.HostRiskChange{IP: host.IP, Before: host.Score}
+	}
+	for _, host := range after.Hosts {
+		if scores[host.IP] == nil {
+			scores[host.IP] = &model.HostRiskChange{IP: host.IP}
+		}
+		scores[host.IP].After = host.Score
+	}
+	for _, host := range scores {
+		if host.Before == host.After {
+			continue
+		}
+		host.Delta = host.After - host.Before
+		host.LevelBefore = model.RiskLevelForScore(host.Before)
+		host.LevelAfter = model.RiskLevelForScore(host.After)
+		changes.Hosts = append(changes.Hosts, *host)
+	}
+	sort.Slice(changes.Hosts, func(i, j int) bool {
+		left, right := absInt(changes.Hosts[i].Delta), absInt(changes.Hosts[j].Delta)
+		if left != right {
+			return left > right
+		}
+		return changes.Hosts[i].IP < changes.Hosts[j].IP
+	})
+	return changes
+}
+
+func absInt(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,39 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+
+	"golandproject/yscan/internal/model"
+)
+
+// compareSSHHostKeys reports endpoints that presented a different key of the
+// same algorithm in both runs. Keys that appear or disappear with their port
+// are already covered by the port changes.
+func compareSSHHostKeys(before, after []model.ScanTaskRunSSHHostKey) []model.SSHHostKeyChange {
+	baseline := make(map[string]string, len(before))
+	for _, key := range before {
+		baseline[sshHostKeyIdentity(key)] = key.Fingerprint
+	}
+	changes := make([]model.SSHHostKeyChange, 0)
+	for _, key := range after {
+		previous, found := baseline[sshHostKeyIdentity(key)]
+		if found && previous != key.Fingerprint {
+			changes = append(changes, model.SSHHostKeyChange{IP: key.IP, Port: key.Port, Algorithm: key.Algorithm, Before: previous, After: key.Fingerprint})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].IP != changes[j].IP {
+			return changes[i].IP < changes[j].IP
+		}
+		if changes[i].Port != changes[j].Port {
+			return changes[i].Port < changes[j].Port
+		}
+		return changes[i].Algorithm < changes[j].Algorithm
+	})
+	return changes
+}
+
+func sshHostKeyIdentity(key model.ScanTaskRunSSHHostKey) string {
+	return fmt.Sprintf("%s:%d/%s", key.IP, key.Port, key.Algorithm)
+}
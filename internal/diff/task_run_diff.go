@@ -34,7 +34,17 @@ func CompareScanTaskRuns(db *sql.DB, baselineRunID, currentRunID int64) (model.S
 	if baselineRun.Status != model.ScanTaskRunStatusSuccess || currentRun.Status != model.ScanTaskRunStatusSuccess {
 		return model.ScanTaskRunChanges{}, ErrScanTaskRunNotSuccessful
 	}
-	if baselineRun.ConfigHash != currentRun.ConfigHash {
+	// Only the source of a revalidation shares its observations; any other
+	// baseline is compared like an ordinary run.
+	revalidationSource := false
+	if currentRun.Trigger == model.ScanTaskRunTriggerRevalidate {
+		sourceID, err := storage.GetScanTaskRunDerivationSource(db, currentRunID)
+		if err != nil {
+			return model.ScanTaskRunChanges{}, err
+		}
+		revalidationSource = sourceID == baselineRunID
+	}
+	if baselineRun.ConfigHash != currentRun.ConfigHash && !revalidationSource {
 		return configChangedRunChanges(currentRun.ScanTaskID, baselineRunID, currentRunID), nil
 	}
 
@@ -46,6 +56,9 @@ func CompareScanTaskRuns(db *sql.DB, baselineRunID, currentRunID int64) (model.S
 	if err != nil {
 		return model.ScanTaskRunChanges{}, err
 	}
+	if revalidationSource {
+		return compareRunFindings(currentRun.ScanTaskID, baselineRunID, currentRunID, baseline, current), nil
+	}
 	return compareRunSnapshots(currentRun.ScanTaskID, baselineRunID, currentRunID, baseline, current), nil
 }
 
@@ -75,6 +88,22 @@ func compareRunWithPreviousSuccess(db *sql.DB, currentRunID int64, allowReportin
 		return model.ScanTaskRunChanges{}, err
 	}
 
+	// A revalidation reuses its source's observations; only the findings of
+	// the two validation passes can differ.
+	if currentRun.Trigger == model.ScanTaskRunTriggerRevalidate {
+		sourceID, err := storage.GetScanTaskRunDerivationSource(db, currentRunID)
+		if err != nil {
+			return model.ScanTaskRunChanges{}, err
+		}
+		if sourceID > 0 {
+			baseline, err := storage.GetScanTaskRunSnapshot(db, sourceID)
+			if err != nil {
+				return model.ScanTaskRunChanges{}, fmt.Errorf("load revalidation source run %d: %w", sourceID, err)
+			}
+			return compareRunFindings(currentRun.ScanTaskID, sourceID, currentRunID, baseline, current), nil
+		}
+	}
+
 	runs, err := storage.ListScanTaskRuns(db, currentRun.ScanTaskID)
 	if err != nil {
 		return model.ScanTaskRunChanges{}, err
@@ -101,7 +130,7 @@ func compareRunWithPreviousSuccess(db *sql.DB, currentRunID int64, allowReportin
 		currentRun.ScanTaskID,
 		0,
 		currentRunID,
-		model.ScanTaskRunSnapshot{Hosts: []model.ScanTaskRunHost{}, Ports: []model.ScanTaskRunPort{}, Vulnerabilities: []model.ScanTaskRunVulnerability{}},
+		model.ScanTaskRunSnapshot{Hosts: []model.ScanTaskRunHost{}, Ports: []model.ScanTaskRunPort{}, Vulnerabilities: []model.ScanTaskRunVulnerability{}, Risk: &model.ScanTaskRunRisk{Level: model.RiskLevelNone}},
 		current,
 	), nil
 }
@@ -128,9 +157,22 @@ func configChangedRunChanges(scanTaskID, baselineRunID, currentRunID int64) mode
 			New:      make([]model.VulnerabilityChange, 0),
 			Resolved: make([]model.VulnerabilityChange, 0),
 		},
+		WeaknessChanges: model.WeaknessChanges{
+			New:      make([]model.ScanTaskRunWeakness, 0),
+			Resolved: make([]model.ScanTaskRunWeakness, 0),
+		},
 	}
 }
 
+func compareRunFindings(scanTaskID, baselineRunID, currentRunID int64, baseline, current model.ScanTaskRunSnapshot) model.ScanTaskRunChanges {
+	changes := configChangedRunChanges(scanTaskID, baselineRunID, currentRunID)
+	changes.ConfigChanged = false
+	changes.FindingsOnly = true
+	changes.VulnerabilityChanges = compareSnapshotVulnerabilities(baseline.Vulnerabilities, current.Vulnerabilities)
+	changes.RiskChanges = compareRisk(baseline.Risk, current.Risk)
+	return changes
+}
+
 func compareRunSnapshots(scanTaskID, baselineRunID, currentRunID int64, baseline, current model.ScanTaskRunSnapshot) model.ScanTaskRunChanges {
 	return model.ScanTaskRunChanges{
 		ScanTaskID:           scanTaskID,
@@ -139,6 +181,10 @@ func compareRunSnapshots(scanTaskID, baselineRunID, currentRunID int64, baseline
 		HostChanges:          CompareHosts(snapshotHosts(baseline.Hosts), snapshotHosts(current.Hosts)),
 		PortChanges:          ComparePorts(snapshotPorts(baseline.Ports), snapshotPorts(current.Ports)),
 		VulnerabilityChanges: compareSnapshotVulnerabilities(baseline.Vulnerabilities, current.Vulnerabilities),
+		IdentityChanges:      compareHostIdentities(baseline.HostIdentities, current.HostIdentities),
+		SSHHostKeyChanges:    compareSSHHostKeys(baseline.SSHHostKeys, current.SSHHostKeys),
+		WeaknessChanges:      compareWeaknesses(baseline.Weaknesses, current.Weaknesses, baseline.Ports, current.Ports),
+		RiskChanges:          compareRisk(baseline.Risk, current.Risk),
 	}
 }
 
@@ -136,6 +136,15 @@ func openDiffTestDB(t *testing.T) *sql.DB {
 		`CREATE TABLE scan_task_run_hosts (scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id), ip TEXT NOT NULL, is_active INTEGER NOT NULL, PRIMARY KEY(scan_task_run_id, ip))`,
 		`CREATE TABLE scan_task_run_ports (scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id), ip TEXT NOT NULL, port INTEGER NOT NULL, service_type TEXT NOT NULL, product TEXT, banner TEXT, PRIMARY KEY(scan_task_run_id, ip, port))`,
 		`CREATE TABLE scan_task_run_vulnerabilities (scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id), finding_key TEXT NOT NULL, template_id TEXT, name TEXT, severity TEXT, target TEXT NOT NULL, target_ip TEXT, target_port INTEGER, matched_at TEXT, description TEXT, evidence TEXT, PRIMARY KEY(scan_task_run_id, finding_key))`,
+		`CREATE TABLE scan_task_run_host_identities (scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id), ip TEXT NOT NULL, port INTEGER NOT NULL, source TEXT NOT NULL, computer_name TEXT NOT NULL DEFAULT '', domain_name TEXT NOT NULL DEFAULT '', dns_computer_name TEXT NOT NULL DEFAULT '', dns_domain_name TEXT NOT NULL DEFAULT '', dns_tree_name TEXT NOT NULL DEFAULT '', os_version TEXT NOT NULL DEFAULT '', PRIMARY KEY(scan_task_run_id, ip, port, source))`,
+		`CREATE TABLE scan_task_run_ssh_host_keys (scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id), ip TEXT NOT NULL, port INTEGER NOT NULL, algorithm TEXT NOT NULL, fingerprint TEXT NOT NULL, bits INTEGER NOT NULL DEFAULT 0, PRIMARY KEY(scan_task_run_id, ip, port, algorithm))`,
+		`CREATE TABLE scan_task_run_weaknesses (scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id), ip TEXT NOT NULL, port INTEGER NOT NULL, protocol TEXT NOT NULL, check_id TEXT NOT NULL, subject TEXT NOT NULL DEFAULT '', severity TEXT NOT NULL, path TEXT NOT NULL, detail TEXT NOT NULL DEFAULT '', PRIMARY KEY(scan_task_run_id, ip, port, protocol, check_id, subject))`,
+		`CREATE TABLE finding_triage (finding_key TEXT PRIMARY KEY, state TEXT NOT NULL DEFAULT 'open', assignee TEXT NOT NULL DEFAULT '', risk_accepted_until TEXT NOT NULL DEFAULT '', created_at DATETIME NOT NULL DEFAULT (datetime('now')), updated_at DATETIME NOT NULL DEFAULT (datetime('now')))`,
+		`CREATE TABLE cve_records (cve_id TEXT PRIMARY KEY, cvss_score REAL NOT NULL DEFAULT 0)`,
+		`CREATE TABLE cve_known_exploited (cve_id TEXT PRIMARY KEY)`,
+		`CREATE TABLE scan_task_run_cve_matches (scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id), ip TEXT NOT NULL, port INTEGER NOT NULL, protocol TEXT NOT NULL, product_key TEXT NOT NULL, cpe TEXT NOT NULL, version TEXT NOT NULL, cve_id TEXT NOT NULL, criteria TEXT NOT NULL, version_range TEXT NOT NULL DEFAULT '', cvss_version TEXT NOT NULL DEFAULT '', cvss_score REAL NOT NULL DEFAULT 0, severity TEXT NOT NULL DEFAULT '', known_exploited INTEGER NOT NULL DEFAULT 0, kev_date_added TEXT NOT NULL DEFAULT '')`,
+		`CREATE TABLE scan_task_run_risk_scores (scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id), scope TEXT NOT NULL, ip TEXT NOT NULL DEFAULT '', port INTEGER NOT NULL DEFAULT 0, score INTEGER NOT NULL DEFAULT 0, level TEXT NOT NULL, factors_json TEXT NOT NULL DEFAULT '[]', PRIMARY KEY(scan_task_run_id, scope, ip, port))`,
+		`CREATE TABLE asset_criticality_labels (id INTEGER PRIMARY KEY, cidr TEXT NOT NULL UNIQUE, level TEXT NOT NULL, note TEXT NOT NULL DEFAULT '', created_at DATETIME NOT NULL DEFAULT (datetime('now')), updated_at DATETIME NOT NULL DEFAULT (datetime('now')))`,
 	}
 	for _, statement := range statements {
 		if _, err := db.Exec(statement); err != nil {
@@ -182,3 +191,110 @@ func createCompletedDiffRun(t *testing.T, db *sql.DB, taskID int64, scheduledFor
 	}
 	return run
 }
+
+func TestCompareRunWithPreviousSuccessReportsHostIdentityAndKeyChanges(t *testing.T) {
+	db := openDiffTestDB(t)
+	task := createDiffTask(t, db, "192.168.10.0/24")
+	createCompletedDiffRun(t, db, task.ID, "2026-07-24T02:00:00Z", model.ScanTaskRunSnapshot{
+		Hosts: []model.ScanTaskRunHost{{IP: "192.168.10.10", IsActive: true}, {IP: "192.168.10.11", IsActive: true}},
+		SSHHostKeys: []model.ScanTaskRunSSHHostKey{
+			{IP: "192.168.10.10", Port: 22, Algorithm: "ssh-ed25519", Fingerprint: "SHA256:old"},
+			{IP: "192.168.10.10", Port: 22, Algorithm: "ssh-rsa", Fingerprint: "SHA256:rsa", Bits: 3072},
+		},
+		HostIdentities: []model.ScanTaskRunHostIdentity{
+			{IP: "192.168.10.10", Port: 445, Source: model.HostIdentitySourceSMB, ComputerName: "FILES01", DomainName: "CORP", DNSTreeName: "corp.example", OSVersion: "10.0.17763"},
+			{IP: "192.168.10.11", Port: 3389, Source: model.HostIdentitySourceRDP, ComputerName: "APP01"},
+		},
+	})
+	current := createCompletedDiffRun(t, db, task.ID, "2026-07-25T02:00:00Z", model.ScanTaskRunSnapshot{
+		Hosts: []model.ScanTaskRunHost{{IP: "192.168.10.10", IsActive: true}, {IP: "192.168.10.11", IsActive: true}},
+		SSHHostKeys: []model.ScanTaskRunSSHHostKey{
+			{IP: "192.168.10.10", Port: 22, Algorithm: "ssh-ed25519", Fingerprint: "SHA256:new"},
+			{IP: "192.168.10.10", Port: 22, Algorithm: "ssh-rsa", Fingerprint: "SHA256:rsa", Bits: 3072},
+		},
+		HostIdentities: []model.ScanTaskRunHostIdentity{
+			{IP: "192.168.10.10", Port: 445, Source: model.HostIdentitySourceSMB, ComputerName: "FILES01", DomainName: "CORP", OSVersion: "10.0.20348"},
+			{IP: "192.168.10.10", Port: 5985, Source: model.HostIdentitySourceWinRM, DNSTreeName: "corp.example"},
+		},
+	})
+
+	changes, err := CompareRunWithPreviousSuccess(db, current.ID)
+	if err != nil {
+		t.Fatalf("compare current run: %v", err)
+	}
+	want := []model.HostIdentityChange{{IP: "192.168.10.10", Field: "os_version", Before: "10.0.17763", After: "10.0.20348"}}
+	if !reflect.DeepEqual(changes.IdentityChanges, want) {
+		t.Fatalf("identity changes = %#v, want %#v", changes.IdentityChanges, want)
+	}
+	wantKeys := []model.SSHHostKeyChange{{IP: "192.168.10.10", Port: 22, Algorithm: "ssh-ed25519", Before: "SHA256:old", After: "SHA256:new"}}
+	if !reflect.DeepEqual(changes.SSHHostKeyChanges, wantKeys) {
+		t.Fatalf("SSH host key changes = %#v, want %#v", changes.SSHHostKeyChanges, wantKeys)
+	}
+}
+
+func TestCompareRunWithPreviousSuccessReportsWeaknessChangesOnSharedEndpoints(t *testing.T) {
+	db := openDiffTestDB(t)
+	task := createDiffTask(t, db, "192.168.11.0/24")
+	hosts := []model.ScanTaskRunHost{{IP: "192.168.11.10", IsActive: true}}
+	createCompletedDiffRun(t, db, task.ID, "2026-07-24T02:00:00Z", model.ScanTaskRunSnapshot{
+		Hosts: hosts,
+		Ports: []model.ScanTaskRunPort{{IP: "192.168.11.10", Port: 443, ServiceType: "https"}, {IP: "192.168.11.10", Port: 8080, ServiceType: "http"}},
+		Weaknesses: []model.ScanTaskRunWeakness{
+			{IP: "192.168.11.10", Port: 443, Protocol: "https", Check: "missing_hsts", Severity: "low", Path: "/"},
+			{IP: "192.168.11.10", Port: 8080, Protocol: "http", Check: "missing_csp", Severity: "info", Path: "/"},
+		},
+	})
+	current := createCompletedDiffRun(t, db, task.ID, "2026-07-25T02:00:00Z", model.ScanTaskRunSnapshot{
+		Hosts: hosts,
+		Ports: []model.ScanTaskRunPort{{IP: "192.168.11.10", Port: 443, ServiceType: "https"}, {IP: "192.168.11.10", Port: 9090, ServiceType: "http"}},
+		Weaknesses: []model.ScanTaskRunWeakness{
+			{IP: "192.168.11.10", Port: 443, Protocol: "https", Check: "cookie_without_secure", Subject: "sid", Severity: "low", Path: "/login"},
+			{IP: "192.168.11.10", Port: 9090, Protocol: "http", Check: "directory_listing", Subject: "/", Severity: "medium", Path: "/"},
+		},
+	})
+
+	changes, err := CompareRunWithPreviousSuccess(db, current.ID)
+	if err != nil {
+		t.Fatalf("compare current run: %v", err)
+	}
+	if len(changes.WeaknessChanges.New) != 1 || changes.WeaknessChanges.New[0].Check != "cookie_without_secure" {
+		t.Fatalf("new weaknesses = %#v", changes.WeaknessChanges.New)
+	}
+	if len(changes.WeaknessChanges.Resolved) != 1 || changes.WeaknessChanges.Resolved[0].Check != "missing_hsts" {
+		t.Fatalf("resolved weaknesses = %#v", changes.WeaknessChanges.Resolved)
+	}
+}
+
+func TestCompareRunWithPreviousSuccessReportsRiskTrend(t *testing.T) {
+	db := openDiffTestDB(t)
+	task := createDiffTask(t, db, "192.168.12.0/24")
+	medium := model.ScanTaskRunVulnerability{FindingKey: "exposed-panel:http://192.168.12.10", TemplateID: "exposed-panel", Severity: "medium", Target: "http://192.168.12.10", TargetIP: "192.168.12.10", TargetPort: 80}
+	createCompletedDiffRun(t, db, task.ID, "2026-07-24T02:00:00Z", model.ScanTaskRunSnapshot{
+		Hosts:           []model.ScanTaskRunHost{{IP: "192.168.12.10", IsActive: true}},
+		Ports:           []model.ScanTaskRunPort{{IP: "192.168.12.10", Port: 80, ServiceType: "http"}},
+		Vulnerabilities: []model.ScanTaskRunVulnerability{medium},
+	})
+	current := createCompletedDiffRun(t, db, task.ID, "2026-07-25T02:00:00Z", model.ScanTaskRunSnapshot{
+		Hosts: []model.ScanTaskRunHost{{IP: "192.168.12.10", IsActive: true}, {IP: "192.168.12.20", IsActive: true}},
+		Ports: []model.ScanTaskRunPort{
+			{IP: "192.168.12.10", Port: 80, ServiceType: "http"},
+			{IP: "192.168.12.10", Port: 6379, ServiceType: "redis"},
+			{IP: "192.168.12.20", Port: 22, ServiceType: "ssh"},
+		},
+		Vulnerabilities: []model.ScanTaskRunVulnerability{medium, {
+			FindingKey: "redis-unauth:192.168.12.10:6379", TemplateID: "redis-unauth", Severity: "critical", Target: "192.168.12.10:6379", TargetIP: "192.168.12.10", TargetPort: 6379,
+		}},
+	})
+
+	changes, err := CompareRunWithPreviousSuccess(db, current.ID)
+	if err != nil {
+		t.Fatalf("compare current run: %v", err)
+	}
+	want := &model.RiskChanges{Before: 12, After: 62, Delta: 50, LevelBefore: model.RiskLevelLow, LevelAfter: model.RiskLevelHigh, Hosts: []model.HostRiskChange{
+		{IP: "192.168.12.10", Before: 12, After: 62, Delta: 50, LevelBefore: model.RiskLevelLow, LevelAfter: model.RiskLevelHigh},
+		{IP: "192.168.12.20", Before: 0, After: 15, Delta: 15, LevelBefore: model.RiskLevelNone, LevelAfter: model.RiskLevelLow},
+	}}
+	if !reflect.DeepEqual(changes.RiskChanges, want) {
+		t.Fatalf("risk changes = %#v, want %#v", changes.RiskChanges, want)
+	}
+}
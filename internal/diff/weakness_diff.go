@@ -0,0 +1,53 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+
+	"golandproject/yscan/internal/model"
+)
+
+// compareWeaknesses reports posture findings that appeared or disappeared on
+// endpoints open in both runs. Findings on opened or closed ports follow from
+// the port changes and are not repeated here.
+func compareWeaknesses(before, after []model.ScanTaskRunWeakness, beforePorts, afterPorts []model.ScanTaskRunPort) model.WeaknessChanges {
+	shared := make(map[string]struct{})
+	open := make(map[string]struct{}, len(beforePorts))
+	for _, port := range beforePorts {
+		open[fmt.Sprintf("%s:%d", port.IP, port.Port)] = struct{}{}
+	}
+	for _, port := range afterPorts {
+		endpoint := fmt.Sprintf("%s:%d", port.IP, port.Port)
+		if _, found := open[endpoint]; found {
+			shared[endpoint] = struct{}{}
+		}
+	}
+	return model.WeaknessChanges{
+		New:      weaknessesMissingFrom(after, before, shared),
+		Resolved: weaknessesMissingFrom(before, after, shared),
+	}
+}
+
+func weaknessesMissingFrom(source, other []model.ScanTaskRunWeakness, shared map[string]struct{}) []model.ScanTaskRunWeakness {
+	present := make(map[string]struct{}, len(other))
+	for _, weakness := range other {
+		present[weaknessIdentity(weakness)] = struct{}{}
+	}
+	missing := make([]model.ScanTaskRunWeakness, 0)
+	for _, weakness := range source {
+		if _, found := shared[fmt.Sprintf("%s:%d", weakness.IP, weakness.Port)]; !found {
+			continue
+		}
+		if _, found := present[weaknessIdentity(weakness)]; !found {
+			missing = append(missing, weakness)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		return weaknessIdentity(missing[i]) < weaknessIdentity(missing[j])
+	})
+	return missing
+}
+
+func weaknessIdentity(weakness model.ScanTaskRunWeakness) string {
+	return fmt.Sprintf("%s:%05d/%s/%s/%s", weakness.IP, weakness.Port, weakness.Protocol, weakness.Check, weakness.Subject)
+}
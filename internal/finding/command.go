@@ -0,0 +1,204 @@
+// Package finding lists validated findings across scan tasks and manages
+// their triage across runs.
+package finding
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golandproject/yscan/internal/model"
+	"golandproject/yscan/internal/storage"
+)
+
+const usage = "usage: yscan findings --all [--severity <severity>] [--template <id>] [--product <key>] [--cidr <cidr>] [--state <state>] [--since <date>] [--until <date>]\n" +
+	"       yscan findings show <finding_key>\n" +
+	"       yscan findings triage <finding_key> [--state <state>] [--assignee <name>] [--until <date>] [--comment <text>] [--author <name>]\n" +
+	"       yscan findings comment <finding_key> --comment <text> [--author <name>]"
+
+// RunCLI lists the global findings register and shows and updates finding
+// triage for "yscan findings". Finding keys are printed by "yscan schedule
+// findings".
+func RunCLI(db *sql.DB, args []string, output io.Writer) error {
+	if db == nil || output == nil {
+		return errors.New("findings CLI database and output are required")
+	}
+	if len(args) > 0 && args[0] == "--all" {
+		filter, err := parseGlobalFilterArgs(args[1:])
+		if err != nil {
+			return err
+		}
+		findings, err := storage.ListGlobalFindings(db, filter)
+		if err != nil {
+			return err
+		}
+		return writeGlobalFindings(output, findings)
+	}
+	if len(args) < 2 || strings.TrimSpace(args[1]) == "" {
+		return errors.New(usage)
+	}
+	findingKey := args[1]
+	switch args[0] {
+	case "show":
+		if len(args) != 2 {
+			return errors.New(usage)
+		}
+		triage, err := storage.GetFindingTriage(db, findingKey)
+		if err != nil {
+			return err
+		}
+		return writeTriage(output, triage)
+	case "triage", "comment":
+		update, err := parseTriageArgs(findingKey, args[2:])
+		if err != nil {
+			return err
+		}
+		if args[0] == "comment" {
+			if update.State != "" || update.Assignee != nil || update.RiskAcceptedUntil != "" {
+				return errors.New("usage: yscan findings comment <finding_key> --comment <text> [--author <name>]")
+			}
+			if update.Comment == "" {
+				return errors.New("--comment is required")
+			}
+		}
+		triage, err := storage.UpdateFindingTriage(db, update)
+		if err != nil {
+			return err
+		}
+		return writeTriage(output, triage)
+	default:
+		return errors.New(usage)
+	}
+}
+
+// IsCommand reports whether name is a "yscan findings" subcommand rather
+// than the scan task ID of the "yscan findings <task> <run>" shorthand.
+func IsCommand(name string) bool {
+	switch name {
+	case "--all", "show", "triage", "comment":
+		return true
+	}
+	return false
+}
+
+func parseTriageArgs(findingKey string, args []string) (model.FindingTriageUpdate, error) {
+	update := model.FindingTriageUpdate{FindingKey: findingKey}
+	for index := 0; index < len(args); index++ {
+		if index+1 >= len(args) {
+			return model.FindingTriageUpdate{}, fmt.Errorf("%s requires a value", args[index])
+		}
+		value := strings.TrimSpace(args[index+1])
+		switch args[index] {
+		case "--state":
+			update.State = value
+		case "--assignee":
+			update.Assignee = &value
+		case "--until":
+			update.RiskAcceptedUntil = value
+		case "--comment":
+			update.Comment = value
+		case "--author":
+			update.Author = value
+		default:
+			return model.FindingTriageUpdate{}, fmt.Errorf("unsupported flag: %s", args[index])
+		}
+		index++
+	}
+	return update, nil
+}
+
+func parseGlobalFilterArgs(args []string) (model.GlobalFindingFilter, error) {
+	var filter model.GlobalFindingFilter
+	for index := 0; index < len(args); index++ {
+		if index+1 >= len(args) {
+			return model.GlobalFindingFilter{}, fmt.Errorf("%s requires a value", args[index])
+		}
+		value := strings.TrimSpace(args[index+1])
+		switch args[index] {
+		case "--severity":
+			filter.Severity = value
+		case "--template":
+			filter.TemplateID = value
+		case "--product":
+			filter.ProductKey = value
+		case "--cidr":
+			filter.CIDR = value
+		case "--state":
+			filter.State = value
+		case "--since":
+			filter.FirstSeenSince = value
+		case "--until":
+			filter.FirstSeenUntil = value
+		default:
+			return model.GlobalFindingFilter{}, fmt.Errorf("unsupported flag: %s", args[index])
+		}
+		index++
+	}
+	return filter, nil
+}
+
+// writeGlobalFindings prints one line per finding. runs lists the
+// "task/run" pairs whose latest successful run currently reports it.
+func writeGlobalFindings(output io.Writer, findings []model.GlobalFinding) error {
+	for _, finding := range findings {
+		severity := finding.Severity
+		if severity == "" {
+			severity = "unknown"
+		}
+		template := finding.TemplateID
+		if template == "" {
+			template = finding.FindingKey
+		}
+		line := fmt.Sprintf("%s %s %s state=%s first_seen=%s", severity, template, finding.Target, finding.State, finding.FirstSeenAt)
+		if len(finding.Products) > 0 {
+			line += " products=" + strings.Join(finding.Products, ",")
+		}
+		sources := make([]string, 0, len(finding.Sources))
+		for _, source := range finding.Sources {
+			sources = append(sources, fmt.Sprintf("%d/%d", source.ScanTaskID, source.ScanTaskRunID))
+		}
+		line += " runs=" + strings.Join(sources, ",")
+		if _, err := fmt.Fprintln(output, line); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(output, "%d findings\n", len(findings))
+	return err
+}
+
+func writeTriage(output io.Writer, triage model.FindingTriage) error {
+	line := fmt.Sprintf("finding %s state=%s", triage.FindingKey, triage.State)
+	if triage.Assignee != "" {
+		line += " assignee=" + triage.Assignee
+	}
+	if triage.RiskAcceptedUntil != "" {
+		line += " until=" + triage.RiskAcceptedUntil
+	}
+	if _, err := fmt.Fprintln(output, line); err != nil {
+		return err
+	}
+	for _, event := range triage.Events {
+		entry := fmt.Sprintf("  %s %s", event.CreatedAt, event.Kind)
+		if event.State != "" {
+			entry += " state=" + event.State
+		}
+		if event.Assignee != "" {
+			entry += " assignee=" + event.Assignee
+		}
+		if event.ScanTaskRunID > 0 {
+			entry += fmt.Sprintf(" run=%d", event.ScanTaskRunID)
+		}
+		if event.Author != "" {
+			entry += " by " + event.Author
+		}
+		if event.Comment != "" {
+			entry += fmt.Sprintf(" %q", event.Comment)
+		}
+		if _, err := fmt.Fprintln(output, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package finding
+
+import (
+	"strings"
+	"testing"
+
+	"golandproject/yscan/internal/model"
+)
+
+func TestParseTriageArgsDistinguishesClearedAssignee(t *testing.T) {
+	update, err := parseTriageArgs("key", []string{"--state", "risk_accepted", "--until", "2027-01-02", "--assignee", "", "--comment", "compensating control"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.State != model.FindingStateRiskAccepted || update.RiskAcceptedUntil != "2027-01-02" || update.Assignee == nil || *update.Assignee != "" || update.Comment != "compensating control" {
+		t.Fatalf("update = %#v", update)
+	}
+	if update, err := parseTriageArgs("key", []string{"--state", "confirmed"}); err != nil || update.Assignee != nil {
+		t.Fatalf("assignee must stay untouched when not given: %#v err=%v", update, err)
+	}
+	for _, args := range [][]string{{"--state"}, {"--owner", "ops"}} {
+		if _, err := parseTriageArgs("key", args); err == nil {
+			t.Fatalf("args %q were accepted", args)
+		}
+	}
+	if !IsCommand("triage") || IsCommand("12") {
+		t.Fatal("IsCommand must separate subcommands from scan task IDs")
+	}
+}
+
+func TestWriteTriageListsHistory(t *testing.T) {
+	var output strings.Builder
+	err := writeTriage(&output, model.FindingTriage{FindingKey: "redis-unauth|10.0.0.5:6379", State: model.FindingStateOpen, Assignee: "ops", Events: []model.FindingTriageEvent{
+		{Kind: model.FindingTriageEventState, State: model.FindingStateFixedPendingVerification, Author: "alice", CreatedAt: "2026-10-01 08:00:00"},
+		{Kind: model.FindingTriageEventReopened, State: model.FindingStateOpen, ScanTaskRunID: 12, Comment: "observed again after being marked fixed", CreatedAt: "2026-10-02 02:00:00"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "finding redis-unauth|10.0.0.5:6379 state=open assignee=ops\n" +
+		"  2026-10-01 08:00:00 state state=fixed_pending_verification by alice\n" +
+		"  2026-10-02 02:00:00 reopened state=open run=12 \"observed again after being marked fixed\"\n"
+	if output.String() != expected {
+		t.Fatalf("output = %q", output.String())
+	}
+}
+
+func TestWriteGlobalFindingsListsReportingRuns(t *testing.T) {
+	filter, err := parseGlobalFilterArgs([]string{"--severity", "high", "--cidr", "10.0.0.0/24", "--since", "2026-08-01"})
+	if err != nil || filter.Severity != "high" || filter.CIDR != "10.0.0.0/24" || filter.FirstSeenSince != "2026-08-01" {
+		t.Fatalf("filter = %#v err=%v", filter, err)
+	}
+	if _, err := parseGlobalFilterArgs([]string{"--task", "1"}); err == nil {
+		t.Fatal("unsupported filter flag was accepted")
+	}
+	var output strings.Builder
+	err = writeGlobalFindings(&output, []model.GlobalFinding{{
+		ScanTaskRunVulnerability: model.ScanTaskRunVulnerability{FindingKey: "redis-unauth|10.0.0.5:6379", TemplateID: "redis-unauth", Severity: "high", Target: "10.0.0.5:6379", State: model.FindingStateOpen},
+		Products:                 []string{"redis"},
+		Sources:                  []model.GlobalFindingSource{{ScanTaskID: 1, ScanTaskRunID: 4}, {ScanTaskID: 3, ScanTaskRunID: 7}},
+		FirstSeenAt:              "2026-08-01 02:00:00",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "high redis-unauth 10.0.0.5:6379 state=open first_seen=2026-08-01 02:00:00 products=redis runs=1/4,3/7\n1 findings\n"
+	if output.String() != expected {
+		t.Fatalf("output = %q", output.String())
+	}
+}
@@ -29,23 +29,42 @@ type sourceSpec struct {
 }
 
 func main() {
-	var fingerprintHubRoot, whatWebRoot, wappalyzerRoot, output string
+	var fingerprintHubRoot, whatWebRoot, wappalyzerRoot, recogRoot, recogCommit, output string
 	flag.StringVar(&fingerprintHubRoot, "fingerprinthub", "", "FingerprintHub fixed-revision root")
 	flag.StringVar(&whatWebRoot, "whatweb", "", "WhatWeb fixed-revision root")
 	flag.StringVar(&wappalyzerRoot, "wappalyzer", "", "Wappalyzer fixed-revision root")
+	flag.StringVar(&recogRoot, "recog", "", "Rapid7 Recog fixed-revision root (optional)")
+	flag.StringVar(&recogCommit, "recog-commit", "", "commit the Recog root is checked out at")
 	flag.StringVar(&output, "output", "internal/fingerprint/snapshots", "snapshot output directory")
 	flag.Parse()
-	if fingerprintHubRoot == "" || whatWebRoot == "" || wappalyzerRoot == "" {
+	thirdParty := fingerprintHubRoot != "" || whatWebRoot != "" || wappalyzerRoot != ""
+	if thirdParty && (fingerprintHubRoot == "" || whatWebRoot == "" || wappalyzerRoot == "") {
 		fatalf("all three source roots are required")
 	}
+	if !thirdParty && recogRoot == "" {
+		fatalf("no source roots given")
+	}
+	if recogRoot != "" && recogCommit == "" {
+		fatalf("-recog-commit is required with -recog")
+	}
 
 	existing, err := readExistingManifest(filepath.Join(output, "manifest.json"))
 	if err != nil {
 		fatalf("read existing manifest: %v", err)
 	}
-	generated, err := sourceSpecs(fingerprintHubRoot, whatWebRoot, wappalyzerRoot)
-	if err != nil {
-		fatalf("enumerate sources: %v", err)
+	var generated []sourceSpec
+	if thirdParty {
+		generated, err = sourceSpecs(fingerprintHubRoot, whatWebRoot, wappalyzerRoot)
+		if err != nil {
+			fatalf("enumerate sources: %v", err)
+		}
+	}
+	if recogRoot != "" {
+		spec, err := recogSpec(recogRoot, recogCommit)
+		if err != nil {
+			fatalf("enumerate recog: %v", err)
+		}
+		generated = append(generated, spec)
 	}
 	if err := os.MkdirAll(output, 0o755); err != nil {
 		fatalf("create output: %v", err)
@@ -108,6 +127,32 @@ func sourceSpecs(fingerprintHubRoot, whatWebRoot, wappalyzerRoot string) ([]sour
 	}, nil
 }
 
+// recogSpec counts its expected stats by adapting the checkout, since Recog
+// revisions move too often to pin the totals here.
+func recogSpec(root, commit string) (sourceSpec, error) {
+	xmlFiles, err := directoryFiles(root, "xml", func(name string) bool { return strings.HasSuffix(name, ".xml") })
+	if err != nil {
+		return sourceSpec{}, err
+	}
+	files := append([]sourceFile{{source: filepath.Join(root, "LICENSE"), target: "LICENSE"}}, xmlFiles...)
+	contents := make(map[string][]byte, len(files))
+	for _, file := range files {
+		content, err := os.ReadFile(file.source)
+		if err != nil {
+			return sourceSpec{}, err
+		}
+		contents[file.target] = content
+	}
+	stats, err := fingerprint.SnapshotRuleStats("recog", contents)
+	if err != nil {
+		return sourceSpec{}, err
+	}
+	return sourceSpec{
+		manifest: fingerprint.SourceManifest{SourceKey: "recog", RepositoryURL: "https://github.com/rapid7/recog", License: "BSD-2-Clause", Commit: commit, ArchivePath: "snapshots/recog.tar.gz", ExpectedStats: stats},
+		files:    files,
+	}, nil
+}
+
 func directoryFiles(root, relative string, include func(string) bool) ([]sourceFile, error) {
 	base := filepath.Join(root, filepath.FromSlash(relative))
 	files := make([]sourceFile, 0)
@@ -46,6 +46,8 @@ type Registry struct {
 	Manifest         Manifest
 	EmbeddedArchives map[string][]byte
 	Adapters         map[string]SourceAdapter
+	// LocalRulesDir is the YAML rule directory imported as the local source.
+	LocalRulesDir string
 }
 
 func NewRegistry(db *sql.DB, manifest Manifest, embeddedArchives map[string][]byte, adapters []SourceAdapter) *Registry {
@@ -62,6 +64,12 @@ func (registry *Registry) Import(ctx context.Context, sourceKey, recoveryArchive
 	if err := ctx.Err(); err != nil {
 		return model.FingerprintImport{}, err
 	}
+	if strings.TrimSpace(sourceKey) == LocalSourceKey {
+		if strings.TrimSpace(recoveryArchive) != "" {
+			return model.FingerprintImport{}, errors.New("the local fingerprint source is read from its rule directory and takes no recovery archive")
+		}
+		return registry.importLocal(ctx)
+	}
 	source, ok := registry.source(strings.TrimSpace(sourceKey))
 	if !ok {
 		return model.FingerprintImport{}, fmt.Errorf("no embedded fingerprint source revision for %q", sourceKey)
@@ -169,11 +177,34 @@ func RunCLI(ctx context.Context, registry *Registry, args []string, output io.Wr
 			}
 			_, _ = fmt.Fprintf(output, "Fingerprint source %s revision %s active (import %d)\n", source.SourceKey, fingerprintImport.Commit, fingerprintImport.ID)
 		}
+		// The local source joins a full upgrade only once its directory
+		// holds rules; most homes never author any.
+		if info, err := os.Stat(registry.LocalRulesDir); strings.TrimSpace(registry.LocalRulesDir) != "" && err == nil && info.IsDir() {
+			fingerprintImport, err := registry.Import(ctx, LocalSourceKey, "")
+			if errors.Is(err, ErrNoLocalRules) {
+				_, _ = fmt.Fprintf(output, "Fingerprint source %s skipped: %v\n", LocalSourceKey, err)
+			} else if err != nil {
+				_, _ = fmt.Fprintf(output, "Fingerprint source %s upgrade failed: %v\n", LocalSourceKey, err)
+				failures = append(failures, fmt.Errorf("%s: %w", LocalSourceKey, err))
+			} else {
+				_, _ = fmt.Fprintf(output, "Fingerprint source %s revision %s active (import %d)\n", LocalSourceKey, fingerprintImport.Commit, fingerprintImport.ID)
+			}
+		}
 		return errors.Join(failures...)
 	case "mapping":
 		return runMappingCLI(registry.DB, args[1:], output)
 	case "cleanup":
 		return runCleanupCLI(registry.DB, args[1:], output)
+	case "test":
+		return runRuleTestCLI(registry.DB, args[1:], output)
+	case "override":
+		return runOverrideCLI(registry.DB, args[1:], output)
+	case "diff":
+		return runDiffCLI(registry.DB, args[1:], output)
+	case "reevaluate":
+		return runReevaluateCLI(registry.DB, args[1:], output)
+	case "stats":
+		return runStatsCLI(registry.DB, args[1:], output)
 	default:
 		writeUsage(output)
 		return nil
@@ -239,7 +270,7 @@ type mappingManifest struct {
 
 func runMappingCLI(db *sql.DB, args []string, output io.Writer) error {
 	if len(args) == 0 {
-		return errors.New("usage: yscan fingerprint mapping list|import|disable")
+		return errors.New("usage: yscan fingerprint mapping list|import|suggest|disable")
 	}
 	switch args[0] {
 	case "list":
@@ -267,8 +298,10 @@ func runMappingCLI(db *sql.DB, args []string, output io.Writer) error {
 			return errors.New("usage: yscan fingerprint mapping import --manifest <path> --templates <root>")
 		}
 		return importMappingManifest(db, args[2], args[4], output)
+	case "suggest":
+		return runMappingSuggestCLI(db, args[1:], output)
 	default:
-		return errors.New("usage: yscan fingerprint mapping list|import|disable")
+		return errors.New("usage: yscan fingerprint mapping list|import|suggest|disable")
 	}
 }
 
@@ -291,18 +324,8 @@ func importMappingManifest(db *sql.DB, manifestPath, templatesRoot string, outpu
 	for index := range manifest.Mappings {
 		mapping := &manifest.Mappings[index]
 		mapping.TemplateSetRevision = manifest.TemplateSetRevision
-		path := filepath.Join(root, filepath.Clean(mapping.TemplatePath))
-		if !strings.HasPrefix(path, root+string(os.PathSeparator)) {
-			return fmt.Errorf("mapping %d template path escapes root", index)
-		}
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return fmt.Errorf("read template %s: %w", mapping.TemplatePath, err)
-		}
-		sum := sha256.Sum256(content)
-		actual := hex.EncodeToString(sum[:])
-		if !strings.EqualFold(actual, strings.TrimSpace(mapping.TemplateSHA256)) {
-			return fmt.Errorf("template SHA-256 mismatch for %s", mapping.TemplatePath)
+		if _, err := verifyMappingTemplate(root, *mapping); err != nil {
+			return fmt.Errorf("mapping %d: %w", index, err)
 		}
 	}
 	sum := sha256.Sum256(raw)
@@ -314,6 +337,24 @@ func importMappingManifest(db *sql.DB, manifestPath, templatesRoot string, outpu
 	return err
 }
 
+// verifyMappingTemplate checks that a mapping names a file inside root whose
+// content still has the pinned SHA-256, and returns that content.
+func verifyMappingTemplate(root string, mapping model.FingerprintTemplateMapping) ([]byte, error) {
+	path := filepath.Join(root, filepath.Clean(mapping.TemplatePath))
+	if !strings.HasPrefix(path, root+string(os.PathSeparator)) {
+		return nil, errors.New("template path escapes root")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read template %s: %w", mapping.TemplatePath, err)
+	}
+	sum := sha256.Sum256(content)
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), strings.TrimSpace(mapping.TemplateSHA256)) {
+		return nil, fmt.Errorf("template SHA-256 mismatch for %s", mapping.TemplatePath)
+	}
+	return content, nil
+}
+
 func parseImportArgs(args []string) (string, string, error) {
 	var sourceKey, recovery string
 	for index := 0; index < len(args); index++ {
@@ -381,8 +422,18 @@ func writeUsage(output io.Writer) {
 	fmt.Fprintln(output, "usage: yscan fingerprint list")
 	fmt.Fprintln(output, "       yscan fingerprint import --source <source_key> [--recovery-archive <path>]")
 	fmt.Fprintln(output, "       yscan fingerprint upgrade --source <source_key> [--recovery-archive <path>]")
+	fmt.Fprintln(output, "       yscan fingerprint import --source local")
 	fmt.Fprintln(output, "       yscan fingerprint mapping list")
 	fmt.Fprintln(output, "       yscan fingerprint mapping import --manifest <path> --templates <root>")
+	fmt.Fprintln(output, "       yscan fingerprint mapping suggest --templates <root> [--product <key>] [--output <path>]")
 	fmt.Fprintln(output, "       yscan fingerprint mapping disable --id <id>")
 	fmt.Fprintln(output, "       yscan fingerprint cleanup [--apply]")
+	fmt.Fprintln(output, "       yscan fingerprint diff <import_a> <import_b> [--impact] [--json]")
+	fmt.Fprintln(output, "       yscan fingerprint reevaluate <scan_task_id> <run_id>")
+	fmt.Fprintln(output, "       yscan fingerprint stats [--source <source_key>] [--limit <n>] [--json]")
+	fmt.Fprintln(output, "       yscan fingerprint override list [--all]")
+	fmt.Fprintln(output, "       yscan fingerprint override suppress [--product <key>] [--rule <source_key>:<rule_id>] [--ip <ip>] [--port <port>] --reason <text> [--expires <time>]")
+	fmt.Fprintln(output, "       yscan fingerprint override assert --ip <ip> --port <port> --product <key> [--protocol <name>] [--version <v>] [--cpe <cpe>] --reason <text> [--expires <time>]")
+	fmt.Fprintln(output, "       yscan fingerprint override revoke --id <id>")
+	fmt.Fprintln(output, "       yscan fingerprint test --banner-file <path> [--port <port>] [--probe <name>] | --http-response-file <path> [--url <url>] [--source <source_key> | --import-id <id>] [--json]")
 }
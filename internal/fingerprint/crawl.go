@@ -0,0 +1,381 @@
+package fingerprint
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+)
+
+const (
+	defaultCrawlDepth  = 2
+	defaultCrawlPages  = 16
+	defaultCrawlBytes  = 2 << 20
+	defaultCrawlBudget = 12 * time.Second
+
+	maxWebAssets      = 24
+	maxWebAssetBytes  = 512 << 10
+	maxCrawlPathBytes = 256
+
+	crawlAssetKindKey = "yscan_asset_kind"
+)
+
+// Kinds of linked resources recorded as web assets.
+const (
+	WebAssetScript = "script"
+	WebAssetStyle  = "style"
+)
+
+// unsafeCrawlPath skips links whose names suggest a state change even over
+// GET, such as logout or delete actions exposed as plain anchors.
+var unsafeCrawlPath = regexp.MustCompile(`(?i)(^|[/_.=&?-])(logout|logoff|log-out|signout|sign-out|delete|remove|destroy|shutdown|reboot|restart|reset)([/_.=&?-]|$)`)
+
+// skippedCrawlExtensions are downloads that never carry page markup.
+var skippedCrawlExtensions = map[string]struct{}{
+	".7z": {}, ".apk": {}, ".bin": {}, ".bmp": {}, ".dmg": {}, ".doc": {}, ".docx": {}, ".exe": {}, ".gif": {}, ".gz": {},
+	".ico": {}, ".img": {}, ".iso": {}, ".jpeg": {}, ".jpg": {}, ".mp3": {}, ".mp4": {}, ".msi": {}, ".pdf": {}, ".png": {},
+	".rar": {}, ".svg": {}, ".tar": {}, ".tgz": {}, ".webp": {}, ".woff": {}, ".woff2": {}, ".xls": {}, ".xlsx": {}, ".zip": {},
+}
+
+var (
+	assetVersionSegment = regexp.MustCompile(`^v?(\d+(?:\.\d+)+)$`)
+	assetFileVersion    = regexp.MustCompile(`(?i)^([a-z][a-z0-9]*(?:[_-][a-z][a-z0-9]*)*)[-._@]v?(\d+(?:\.\d+)+)`)
+	assetBannerVersion  = regexp.MustCompile(`(?s)^\s*/\*!?[\s*]*(?:@license\s+)?([A-Za-z][\w.-]*(?:[ \t][A-Za-z][\w.-]*){0,2})[ \t]+v?(\d+\.\d+(?:\.\d+)?)`)
+)
+
+// CrawlOptions bounds one endpoint crawl. Zero values select the defaults.
+type CrawlOptions struct {
+	MaxDepth int
+	MaxPages int
+	MaxBytes int
+	Budget   time.Duration
+}
+
+// CrawledPage is one additional page of the application. Evidence follows
+// the CollectWebEvidence capture contract and stays in memory.
+type CrawledPage struct {
+	Path     string
+	Evidence Evidence
+	Summary  string
+}
+
+// WebAsset is a script or stylesheet referenced by a crawled page. Only the
+// digest of the captured bytes and the versions it advertises are kept.
+type WebAsset struct {
+	Kind           string
+	Path           string
+	CapturedLength int
+	Truncated      bool
+	SHA256         string
+	Versions       []string
+}
+
+type CrawlResult struct {
+	Pages  []CrawledPage
+	Assets []WebAsset
+}
+
+// CrawlWebApplication follows links from a root response collected by
+// CollectWebEvidence. It issues only GET requests, never leaves the root's
+// authorized endpoint, and stops at the depth, page, byte and time limits.
+// Individual request failures are skipped; the result is always usable.
+func CrawlWebApplication(ctx context.Context, ip string, port int, root CollectedEvidence, options WebEvidenceOptions, crawl CrawlOptions) (CrawlResult, error) {
+	rootURL, err := url.Parse(root.Evidence.URL)
+	if err != nil || len(options.AllowedPorts) == 0 || !sameAuthorizedEndpoint(rootURL, ip, port, options.AllowedPorts) {
+		return CrawlResult{}, errors.New("crawl root is outside the authorized endpoint")
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 4 * time.Second
+	}
+	crawl = crawl.withDefaults()
+	crawlCtx, cancel := context.WithTimeout(ctx, crawl.Budget)
+	defer cancel()
+
+	result := CrawlResult{Pages: make([]CrawledPage, 0), Assets: make([]WebAsset, 0)}
+	protocol := strings.ToLower(rootURL.Scheme)
+	seen := map[string]struct{}{crawlKey(rootURL): {}}
+	fetchedBytes, requestedAssets := 0, 0
+
+	pages := colly.NewCollector(
+		colly.UserAgent("CAASM-fingerprint/2"),
+		colly.MaxDepth(crawl.MaxDepth),
+		colly.MaxBodySize(maxHTTPBodyBytes+1),
+		colly.StdlibContext(crawlCtx),
+		colly.ParseHTTPErrorResponse(),
+	)
+	pages.WithTransport(newWebTransport(options.Timeout))
+	pages.SetRequestTimeout(options.Timeout)
+	pages.SetRedirectHandler(func(request *http.Request, via []*http.Request) error {
+		if len(via) > 1 || !sameAuthorizedEndpoint(request.URL, ip, port, options.AllowedPorts) {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	})
+	assets := pages.Clone()
+	assets.MaxBodySize = maxWebAssetBytes + 1
+
+	admit := func(request *colly.Request) {
+		if crawlCtx.Err() != nil || fetchedBytes >= crawl.MaxBytes || !sameAuthorizedEndpoint(request.URL, ip, port, options.AllowedPorts) {
+			request.Abort()
+		}
+	}
+	pages.OnRequest(func(request *colly.Request) {
+		if len(result.Pages) >= crawl.MaxPages {
+			request.Abort()
+			return
+		}
+		admit(request)
+	})
+	assets.OnRequest(admit)
+	pages.OnResponse(func(response *colly.Response) {
+		fetchedBytes += len(response.Body)
+		result.Pages = append(result.Pages, crawledPage(response, protocol))
+	})
+	assets.OnResponse(func(response *colly.Response) {
+		fetchedBytes += len(response.Body)
+		result.Assets = append(result.Assets, webAsset(response))
+	})
+
+	// follow queues one discovered link; visit performs the page request at
+	// the right depth and is nil for links found on the root response.
+	follow := func(kind, link string, visit func(string) error) {
+		target, err := url.Parse(link)
+		if err != nil || target.User != nil || !sameAuthorizedEndpoint(target, ip, port, options.AllowedPorts) {
+			return
+		}
+		target.Fragment = ""
+		key := crawlKey(target)
+		if _, duplicate := seen[key]; duplicate {
+			return
+		}
+		if kind == "" && !crawlablePage(target) {
+			return
+		}
+		seen[key] = struct{}{}
+		if kind != "" {
+			if requestedAssets >= maxWebAssets {
+				return
+			}
+			requestedAssets++
+			assetContext := colly.NewContext()
+			assetContext.Put(crawlAssetKindKey, kind)
+			_ = assets.Request(http.MethodGet, target.String(), nil, assetContext, nil)
+			return
+		}
+		if visit == nil {
+			_ = pages.Visit(target.String())
+		} else {
+			_ = visit(target.String())
+		}
+	}
+	pages.OnHTML("html", func(element *colly.HTMLElement) {
+		for _, link := range crawlLinks(element.DOM, element.Request.URL) {
+			follow(link.kind, link.url, element.Request.Visit)
+		}
+	})
+
+	if document, err := goquery.NewDocumentFromReader(strings.NewReader(root.Evidence.Body)); err == nil {
+		for _, link := range crawlLinks(document.Selection, rootURL) {
+			follow(link.kind, link.url, nil)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func (crawl CrawlOptions) withDefaults() CrawlOptions {
+	if crawl.MaxDepth <= 0 {
+		crawl.MaxDepth = defaultCrawlDepth
+	}
+	if crawl.MaxPages <= 0 {
+		crawl.MaxPages = defaultCrawlPages
+	}
+	if crawl.MaxBytes <= 0 {
+		crawl.MaxBytes = defaultCrawlBytes
+	}
+	if crawl.Budget <= 0 {
+		crawl.Budget = defaultCrawlBudget
+	}
+	return crawl
+}
+
+type crawlLink struct {
+	kind string
+	url  string
+}
+
+// crawlLinks returns absolute anchor, script and stylesheet URLs in document
+// order. An empty kind marks a page link.
+func crawlLinks(selection *goquery.Selection, base *url.URL) []crawlLink {
+	if href, found := selection.Find("base[href]").First().Attr("href"); found {
+		if parsed, err := base.Parse(strings.TrimSpace(href)); err == nil {
+			base = parsed
+		}
+	}
+	links := make([]crawlLink, 0)
+	selection.Find("a[href], area[href], frame[src], iframe[src], script[src], link[href]").Each(func(_ int, element *goquery.Selection) {
+		kind, attribute := "", "href"
+		switch goquery.NodeName(element) {
+		case "script":
+			kind, attribute = WebAssetScript, "src"
+		case "frame", "iframe":
+			attribute = "src"
+		case "link":
+			rel := strings.Fields(strings.ToLower(element.AttrOr("rel", "")))
+			if !containsString(rel, "stylesheet") {
+				return
+			}
+			kind = WebAssetStyle
+		}
+		value := strings.TrimSpace(element.AttrOr(attribute, ""))
+		if value == "" || strings.HasPrefix(value, "#") {
+			return
+		}
+		resolved, err := base.Parse(value)
+		if err != nil || (resolved.Scheme != "http" && resolved.Scheme != "https") {
+			return
+		}
+		links = append(links, crawlLink{kind: kind, url: resolved.String()})
+	})
+	return links
+}
+
+func crawlablePage(target *url.URL) bool {
+	if _, skipped := skippedCrawlExtensions[strings.ToLower(path.Ext(target.Path))]; skipped {
+		return false
+	}
+	return !unsafeCrawlPath.MatchString(target.EscapedPath() + "?" + target.RawQuery)
+}
+
+func crawlKey(target *url.URL) string {
+	copied := *target
+	copied.Fragment = ""
+	if copied.Path == "" {
+		copied.Path = "/"
+	}
+	return copied.String()
+}
+
+// crawlPath is the request path and query as recorded in summaries and
+// protocol evidence, bounded so a long query cannot dominate a snapshot.
+func crawlPath(target *url.URL) string {
+	value := target.EscapedPath()
+	if value == "" {
+		value = "/"
+	}
+	if target.RawQuery != "" {
+		value += "?" + target.RawQuery
+	}
+	if len(value) > maxCrawlPathBytes {
+		value = value[:maxCrawlPathBytes]
+	}
+	return value
+}
+
+func crawledPage(response *colly.Response, protocol string) CrawledPage {
+	headers := http.Header{}
+	if response.Headers != nil {
+		headers = *response.Headers
+	}
+	captured, headerLength, headerTruncated, headerHash := cappedHeaders(headers)
+	body, bodyLength, bodyTruncated, bodyHash, binary, _ := cappedBody(bytes.NewReader(response.Body))
+	bodyText := ""
+	if !binary {
+		bodyText = string(body)
+	}
+	requestPath := crawlPath(response.Request.URL)
+	evidence := Evidence{
+		Protocol: protocol, Headers: captured, Meta: htmlMetadata(bodyText), Cookies: responseCookies(&http.Response{Header: headers}),
+		Body: bodyText, Title: htmlTitle(bodyText), URL: response.Request.URL.String(), StatusCode: response.StatusCode,
+		HeaderTruncated: headerTruncated, BodyTruncated: bodyTruncated,
+		HeaderCapturedLength: headerLength, HeaderCapturedSHA256: headerHash,
+		BodyCapturedLength: bodyLength, BodyCapturedSHA256: bodyHash,
+	}
+	summary := fmt.Sprintf("http crawl path=%s status=%d protocol=%s headers=%d%s header_sha256=%s body=%d%s body_sha256=%s", requestPath, response.StatusCode, protocol, headerLength, truncationSuffix(headerTruncated), headerHash, bodyLength, truncationSuffix(bodyTruncated), bodyHash)
+	return CrawledPage{Path: requestPath, Evidence: evidence, Summary: summary}
+}
+
+func webAsset(response *colly.Response) WebAsset {
+	content := response.Body
+	truncated := len(content) > maxWebAssetBytes
+	if truncated {
+		content = content[:maxWebAssetBytes]
+	}
+	sum := sha256.Sum256(content)
+	return WebAsset{
+		Kind:           response.Ctx.Get(crawlAssetKindKey),
+		Path:           crawlPath(response.Request.URL),
+		CapturedLength: len(content),
+		Truncated:      truncated,
+		SHA256:         hex.EncodeToString(sum[:]),
+		Versions:       assetVersions(response.Request.URL, content),
+	}
+}
+
+// assetVersions reads "name version" pairs from the places front-end builds
+// advertise them: the file name (jquery-3.6.0.min.js), a versioned directory
+// (/vue/2.6.14/vue.min.js), a cache-busting query (?v=1.2.3) and the license
+// banner at the top of the file (/*! jQuery v3.6.0).
+func assetVersions(target *url.URL, content []byte) []string {
+	versions := make([]string, 0, 2)
+	add := func(name, version string) {
+		name = strings.ToLower(strings.Trim(name, "._-"))
+		if name == "" || version == "" {
+			return
+		}
+		value := name + " " + version
+		if !containsString(versions, value) {
+			versions = append(versions, value)
+		}
+	}
+	segments := strings.Split(strings.Trim(target.Path, "/"), "/")
+	file := segments[len(segments)-1]
+	stem := strings.TrimSuffix(file, path.Ext(file))
+	for _, suffix := range []string{".min", ".slim", ".bundle", ".prod"} {
+		stem = strings.TrimSuffix(stem, suffix)
+	}
+	if groups := assetFileVersion.FindStringSubmatch(file); groups != nil {
+		add(groups[1], groups[2])
+	}
+	for index := 1; index < len(segments)-1; index++ {
+		if groups := assetVersionSegment.FindStringSubmatch(segments[index]); groups != nil {
+			add(segments[index-1], groups[1])
+		}
+	}
+	query := target.Query()
+	for _, key := range []string{"v", "ver", "version"} {
+		if groups := assetVersionSegment.FindStringSubmatch(query.Get(key)); groups != nil {
+			add(stem, groups[1])
+		}
+	}
+	head := content
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	if groups := assetBannerVersion.FindSubmatch(head); groups != nil {
+		add(string(groups[1]), string(groups[2]))
+	}
+	return versions
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package fingerprint
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCrawlWebApplicationStaysOnEndpointAndRecordsAssets(t *testing.T) {
+	foreign := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		t.Error("crawler left the authorized endpoint")
+	}))
+	defer foreign.Close()
+	var mu sync.Mutex
+	requested := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		mu.Lock()
+		requested = append(requested, request.Method+" "+request.URL.RequestURI())
+		mu.Unlock()
+		switch request.URL.Path {
+		case "/login":
+			writer.Header().Set("Content-Type", "text/html")
+			_, _ = writer.Write([]byte(`<html><title>Sign in</title><form action="/session" method="post"></form><a href="/api/">api</a></html>`))
+		case "/api/":
+			writer.Header().Set("Content-Type", "text/html")
+			_, _ = writer.Write([]byte(`<html><title>Fixture API</title><a href="/deep">deeper</a></html>`))
+		case "/static/jquery-3.6.0.min.js":
+			writer.Header().Set("Content-Type", "application/javascript")
+			_, _ = writer.Write([]byte("/*! jQuery v3.6.0 | (c) OpenJS Foundation */\n!function(){}"))
+		case "/css/app.css":
+			writer.Header().Set("Content-Type", "text/css")
+			_, _ = writer.Write([]byte("body{}"))
+		default:
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	ip, port := testServerEndpoint(t, server.URL)
+	root := CollectedEvidence{Protocol: "http", Evidence: Evidence{URL: server.URL + "/", Body: `<html>
+		<a href="/login">login</a> <a href="/logout">logout</a> <a href="/manual.pdf">manual</a>
+		<a href="` + foreign.URL + `/elsewhere">foreign</a> <a href="/#top">top</a>
+		<script src="/static/jquery-3.6.0.min.js"></script>
+		<link rel="stylesheet" href="/css/app.css?v=2.1.0">
+	</html>`}}
+
+	result, err := CrawlWebApplication(context.Background(), ip, port, root, WebEvidenceOptions{AllowedPorts: map[int]struct{}{port: {}}}, CrawlOptions{MaxDepth: 2})
+	if err != nil {
+		t.Fatalf("crawl: %v", err)
+	}
+	paths := make([]string, 0, len(result.Pages))
+	for _, page := range result.Pages {
+		paths = append(paths, page.Path)
+		if page.Evidence.BodyCapturedSHA256 == "" || !strings.Contains(page.Summary, "path="+page.Path) {
+			t.Fatalf("page evidence contract missing: %#v", page)
+		}
+	}
+	if strings.Join(paths, ",") != "/login,/api/" {
+		t.Fatalf("crawled pages = %v", paths)
+	}
+	if result.Pages[0].Evidence.Title != "Sign in" || result.Pages[1].Evidence.Title != "Fixture API" {
+		t.Fatalf("page titles = %#v", result.Pages)
+	}
+	for _, request := range requested {
+		if !strings.HasPrefix(request, "GET ") || strings.Contains(request, "logout") || strings.Contains(request, "session") || strings.Contains(request, ".pdf") || strings.Contains(request, "/deep") {
+			t.Fatalf("unexpected request %q in %v", request, requested)
+		}
+	}
+	if len(result.Assets) != 2 {
+		t.Fatalf("assets = %#v", result.Assets)
+	}
+	byPath := map[string]WebAsset{}
+	for _, asset := range result.Assets {
+		byPath[asset.Path] = asset
+	}
+	script := byPath["/static/jquery-3.6.0.min.js"]
+	if script.Kind != WebAssetScript || len(script.SHA256) != 64 || strings.Join(script.Versions, ",") != "jquery 3.6.0" {
+		t.Fatalf("script asset = %#v", script)
+	}
+	style := byPath["/css/app.css?v=2.1.0"]
+	if style.Kind != WebAssetStyle || strings.Join(style.Versions, ",") != "app 2.1.0" {
+		t.Fatalf("style asset = %#v", style)
+	}
+}
+
+func TestCrawlWebApplicationHonorsPageLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "text/html")
+		_, _ = writer.Write([]byte(`<html><a href="` + request.URL.Path + `x">next</a><a href="` + request.URL.Path + `y">other</a></html>`))
+	}))
+	defer server.Close()
+	ip, port := testServerEndpoint(t, server.URL)
+	root := CollectedEvidence{Protocol: "http", Evidence: Evidence{URL: server.URL + "/", Body: `<a href="/a">a</a><a href="/b">b</a><a href="/c">c</a>`}}
+	result, err := CrawlWebApplication(context.Background(), ip, port, root, WebEvidenceOptions{AllowedPorts: map[int]struct{}{port: {}}}, CrawlOptions{MaxDepth: 5, MaxPages: 3})
+	if err != nil {
+		t.Fatalf("crawl: %v", err)
+	}
+	if len(result.Pages) != 3 {
+		t.Fatalf("crawled %d pages, want 3", len(result.Pages))
+	}
+}
+
+func TestCrawlWebApplicationRejectsRootOutsideEndpoint(t *testing.T) {
+	root := CollectedEvidence{Protocol: "http", Evidence: Evidence{URL: "http://192.0.2.10:8080/"}}
+	if _, err := CrawlWebApplication(context.Background(), "192.0.2.11", 8080, root, WebEvidenceOptions{AllowedPorts: map[int]struct{}{8080: {}}}, CrawlOptions{}); err == nil {
+		t.Fatal("crawl accepted a root on another host")
+	}
+}
@@ -0,0 +1,118 @@
+package fingerprint
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golandproject/yscan/internal/model"
+	"golandproject/yscan/internal/storage"
+)
+
+const diffUsage = "usage: yscan fingerprint diff <import_a> <import_b> [--impact] [--json]"
+
+// runDiffCLI shows what a revision upgrade changes before or after it is
+// activated. It only reads the catalog and stored run results.
+func runDiffCLI(db *sql.DB, args []string, output io.Writer) error {
+	var ids []int64
+	impact, jsonOutput := false, false
+	for _, arg := range args {
+		switch arg {
+		case "--impact":
+			impact = true
+		case "--json":
+			jsonOutput = true
+		default:
+			id, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil || id <= 0 {
+				return errors.New(diffUsage)
+			}
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) != 2 {
+		return errors.New(diffUsage)
+	}
+	diff, err := storage.DiffFingerprintImports(db, ids[0], ids[1], impact)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		encoder := json.NewEncoder(output)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(diff)
+	}
+	return writeFingerprintDiff(output, diff)
+}
+
+func writeFingerprintDiff(output io.Writer, diff model.FingerprintImportDiff) error {
+	lines := []string{fmt.Sprintf("Diff source=%s import %d -> %d: %d added, %d removed, %d modified, %d unchanged",
+		diff.SourceKey, diff.FromImportID, diff.ToImportID, len(diff.Added), len(diff.Removed), len(diff.Modified), diff.Unchanged)}
+	for _, rule := range diff.Added {
+		lines = append(lines, fmt.Sprintf("+ %s product=%s status=%s%s", rule.RuleKey, valueOrDash(rule.ToProduct), rule.ToStatus, optionalField("cpe", rule.ToCPE)))
+	}
+	for _, rule := range diff.Removed {
+		lines = append(lines, fmt.Sprintf("- %s product=%s status=%s%s", rule.RuleKey, valueOrDash(rule.FromProduct), rule.FromStatus, optionalField("cpe", rule.FromCPE)))
+	}
+	for _, rule := range diff.Modified {
+		line := fmt.Sprintf("~ %s changes=%s", rule.RuleKey, strings.Join(rule.Changes, ","))
+		if rule.FromProduct != rule.ToProduct {
+			line += fmt.Sprintf(" product=%s->%s", valueOrDash(rule.FromProduct), valueOrDash(rule.ToProduct))
+		}
+		if rule.FromCPE != rule.ToCPE {
+			line += fmt.Sprintf(" cpe=%s->%s", valueOrDash(rule.FromCPE), valueOrDash(rule.ToCPE))
+		}
+		if rule.FromStatus != rule.ToStatus {
+			line += fmt.Sprintf(" status=%s->%s", rule.FromStatus, rule.ToStatus)
+		}
+		lines = append(lines, line)
+		for _, matcher := range rule.RemovedMatchers {
+			lines = append(lines, "    - "+matcher)
+		}
+		for _, matcher := range rule.AddedMatchers {
+			lines = append(lines, "    + "+matcher)
+		}
+	}
+	if diff.Impact != nil {
+		switch {
+		case diff.Impact.RunID == 0:
+			lines = append(lines, fmt.Sprintf("No successful run froze import %d; impact preview unavailable.", diff.FromImportID))
+		case len(diff.Impact.Endpoints) == 0:
+			lines = append(lines, fmt.Sprintf("Impact on run %d (loss-only, added rules not evaluated): no endpoint would change.", diff.Impact.RunID))
+		default:
+			lines = append(lines, fmt.Sprintf("Impact on run %d (loss-only, added rules not evaluated):", diff.Impact.RunID))
+			for _, endpoint := range diff.Impact.Endpoints {
+				line := fmt.Sprintf("IMPACT %s:%d/%s", endpoint.IP, endpoint.Port, endpoint.Protocol)
+				for _, field := range []struct {
+					name   string
+					values []string
+				}{{"lost", endpoint.Lost}, {"gained", endpoint.Gained}, {"review", endpoint.Review}} {
+					if len(field.values) > 0 {
+						line += " " + field.name + "=" + strings.Join(field.values, ",")
+					}
+				}
+				lines = append(lines, line)
+			}
+		}
+	}
+	_, err := fmt.Fprintln(output, strings.Join(lines, "\n"))
+	return err
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func optionalField(name, value string) string {
+	if value == "" {
+		return ""
+	}
+	return " " + name + "=" + value
+}
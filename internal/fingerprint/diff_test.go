@@ -0,0 +1,39 @@
+package fingerprint
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golandproject/yscan/internal/model"
+)
+
+func TestWriteFingerprintDiffListsRuleChangesAndImpact(t *testing.T) {
+	var output bytes.Buffer
+	err := writeFingerprintDiff(&output, model.FingerprintImportDiff{
+		FromImportID: 4, ToImportID: 7, SourceKey: "fingerprinthub", Unchanged: 12,
+		Added:    []model.FingerprintRuleDiff{{RuleKey: "tomcat-page", ToProduct: "tomcat", ToStatus: "executable"}},
+		Removed:  []model.FingerprintRuleDiff{{RuleKey: "php-generator", FromProduct: "php", FromStatus: "executable"}},
+		Modified: []model.FingerprintRuleDiff{{RuleKey: "apache-body", FromProduct: "apache", ToProduct: "apache httpd", FromStatus: "executable", ToStatus: "executable", Changes: []string{"product", "matchers"}, AddedMatchers: []string{`all: http_body[body] contains "Apache/"`}, RemovedMatchers: []string{`all: http_body[body] contains "Apache"`}}},
+		Impact:   &model.FingerprintDiffImpact{RunID: 9, Endpoints: []model.FingerprintDiffEndpointImpact{{IP: "10.0.0.5", Port: 80, Protocol: "http", Lost: []string{"apache"}, Gained: []string{"apache httpd"}, Review: []string{"apache httpd"}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Diff source=fingerprinthub import 4 -> 7: 1 added, 1 removed, 1 modified, 12 unchanged",
+		"+ tomcat-page product=tomcat status=executable",
+		"- php-generator product=php status=executable",
+		"~ apache-body changes=product,matchers product=apache->apache httpd",
+		`    - all: http_body[body] contains "Apache"`,
+		`    + all: http_body[body] contains "Apache/"`,
+		"IMPACT 10.0.0.5:80/http lost=apache gained=apache httpd review=apache httpd",
+	} {
+		if !strings.Contains(output.String(), want) {
+			t.Fatalf("diff output missing %q:\n%s", want, output.String())
+		}
+	}
+	if err := runDiffCLI(nil, []string{"4"}, &output); err == nil {
+		t.Fatal("single import id was accepted")
+	}
+}
@@ -34,10 +34,48 @@ func NewEmbeddedRegistry(db *sql.DB) (*Registry, error) {
 		}
 		archives[source.SourceKey] = archive
 	}
-	return NewRegistry(db, manifest, archives, []SourceAdapter{
+	registry := NewRegistry(db, manifest, archives, embeddedAdapters())
+	registry.LocalRulesDir = configuredLocalRulesDirectory()
+	return registry, nil
+}
+
+func embeddedAdapters() []SourceAdapter {
+	return []SourceAdapter{
 		fingerprintHubV3Adapter{}, fingerprintHubV4Adapter{}, eHoleAdapter{}, fscanAdapter{}, nmapAdapter{},
 		fingerprintHubWebYAMLAdapter(), fingerprintHubServiceYAMLAdapter(), whatWebAdapter(), wappalyzerAdapter{},
-	}), nil
+		recogAdapter{},
+	}
+}
+
+// SnapshotRuleStats adapts files with the adapter registered for sourceKey
+// and counts the outcome, giving snapshot generators the expected stats an
+// import of the same files must reproduce.
+func SnapshotRuleStats(sourceKey string, files map[string][]byte) (RuleStats, error) {
+	for _, adapter := range embeddedAdapters() {
+		if adapter.SourceKey() != sourceKey {
+			continue
+		}
+		rules, err := adapter.Adapt(VerifiedSnapshot{Manifest: SourceManifest{SourceKey: sourceKey}, Files: files})
+		if err != nil {
+			return RuleStats{}, err
+		}
+		if _, err := projectExecutableRules(adapter, rules); err != nil {
+			return RuleStats{}, err
+		}
+		stats := RuleStats{RuleTotal: len(rules)}
+		for _, rule := range rules {
+			switch rule.ImportStatus {
+			case "executable":
+				stats.ExecutableTotal++
+			case "unsupported":
+				stats.UnsupportedTotal++
+			default:
+				stats.ImportErrorTotal++
+			}
+		}
+		return stats, nil
+	}
+	return RuleStats{}, fmt.Errorf("no fingerprint adapter registered for %q", sourceKey)
 }
 
 func BootstrapEmbeddedSources(ctx context.Context, db *sql.DB) error {
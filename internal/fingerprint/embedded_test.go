@@ -196,3 +196,34 @@ func TestMissingManifestSourceRecoversOnNextInitialization(t *testing.T) {
 		t.Fatalf("recovered sources=%#v first=%d/%d imports=%d err=%v", sources, firstImportID, currentFirstID, imports, err)
 	}
 }
+
+func TestEmbeddedRecogSnapshotLoads(t *testing.T) {
+	db := openFingerprintTestDB(t)
+	registry, err := NewEmbeddedRegistry(db)
+	if err != nil {
+		t.Fatalf("load embedded registry: %v", err)
+	}
+	var pinned *SourceManifest
+	for index := range registry.Manifest.Sources {
+		if registry.Manifest.Sources[index].SourceKey == recogSourceKey {
+			pinned = &registry.Manifest.Sources[index]
+		}
+	}
+	if pinned == nil {
+		t.Fatal("manifest has no recog snapshot; pin one with snapshotgen -recog <checkout> -recog-commit <sha>")
+	}
+	if _, err := registry.Import(context.Background(), recogSourceKey, ""); err != nil {
+		t.Fatalf("import recog: %v", err)
+	}
+	var total, executable int
+	if err := db.QueryRow(`
+		SELECT rule_total, executable_total
+		FROM fingerprint_imports AS fingerprint_import
+		JOIN fingerprint_sources AS source ON source.id = fingerprint_import.fingerprint_source_id
+		WHERE source.source_key = ? AND fingerprint_import.is_active = 1`, recogSourceKey).Scan(&total, &executable); err != nil {
+		t.Fatalf("read recog import: %v", err)
+	}
+	if total != pinned.ExpectedStats.RuleTotal || executable != pinned.ExpectedStats.ExecutableTotal || executable == 0 {
+		t.Fatalf("recog import total=%d executable=%d, manifest %#v", total, executable, pinned.ExpectedStats)
+	}
+}
@@ -41,6 +41,9 @@ type Evidence struct {
 	HeaderCapturedSHA256 string
 	BodyCapturedLength   int
 	BodyCapturedSHA256   string
+	TLSFingerprint       string
+	JA4S                 string
+	SNMPSysDescr         string
 }
 
 type MatcherHit = model.FingerprintMatchEvidence
@@ -115,6 +118,23 @@ func LoadActiveLegacyBannerEngine(db *sql.DB) (*Engine, error) {
 	return loadEngine(db, `fingerprint_import.is_active = 1 AND source.source_key = ?`, []any{legacyBannerSourceKey})
 }
 
+// LoadSourceEngine loads the active import of one source, so a rule author
+// can test a single catalog without the others shadowing it.
+func LoadSourceEngine(db *sql.DB, sourceKey string) (*Engine, error) {
+	if strings.TrimSpace(sourceKey) == "" {
+		return nil, fmt.Errorf("fingerprint source key is required")
+	}
+	return loadEngine(db, `fingerprint_import.is_active = 1 AND source.source_key = ?`, []any{strings.TrimSpace(sourceKey)})
+}
+
+// LoadImportEngine loads one import revision whether or not it is active.
+func LoadImportEngine(db *sql.DB, importID int64) (*Engine, error) {
+	if importID <= 0 {
+		return nil, fmt.Errorf("fingerprint import ID is required")
+	}
+	return loadEngine(db, `fingerprint_import.id = ?`, []any{importID})
+}
+
 // LoadRunEngine loads only the immutable import revisions frozen when the run
 // was created or claimed. Active imports may change while a run is executing.
 func LoadRunEngine(db *sql.DB, runID int64) (*Engine, error) {
@@ -455,6 +475,12 @@ func availableEvidenceTypes(evidence Evidence) []string {
 	if evidence.FaviconHash != "" || evidence.FaviconMD5 != "" || evidence.FaviconMMH3 != "" || evidence.FaviconSHA256 != "" {
 		types["favicon_hash"] = struct{}{}
 	}
+	if evidence.TLSFingerprint != "" || evidence.JA4S != "" {
+		types["tls_fingerprint"] = struct{}{}
+	}
+	if evidence.SNMPSysDescr != "" {
+		types["snmp_sysdescr"] = struct{}{}
+	}
 	result := make([]string, 0, len(types))
 	for evidenceType := range types {
 		result = append(result, evidenceType)
@@ -468,8 +494,14 @@ func matchCompiledRule(rule *compiledRule, evidence Evidence) (Match, bool) {
 	if !outcome.matched {
 		return Match{}, false
 	}
+	cpe := firstNonEmpty(outcome.cpe, rule.cpe)
+	if unexpandedCaptureTemplate.MatchString(cpe) {
+		// Only a regex matcher expands captures; a CPE still naming one would
+		// otherwise be reported verbatim.
+		cpe = ""
+	}
 	return Match{
-		Product: rule.product, SourceProduct: rule.sourceProduct, ProductRole: rule.productRole, ExclusiveGroup: rule.exclusiveGroup, Version: firstNonEmpty(outcome.version, rule.versionTemplate), CPE: firstNonEmpty(outcome.cpe, rule.cpe),
+		Product: rule.product, SourceProduct: rule.sourceProduct, ProductRole: rule.productRole, ExclusiveGroup: rule.exclusiveGroup, Version: firstNonEmpty(outcome.version, rule.versionTemplate), CPE: cpe,
 		Tags: append([]string(nil), rule.tags...), Soft: rule.soft, SourceKey: rule.source, SourceRuleID: rule.sourceID,
 		FingerprintImportID: rule.importID, FingerprintSourceRuleID: rule.sourceRuleID, MatcherHits: outcome.hits,
 	}, true
@@ -718,6 +750,15 @@ func normalizedEvidenceValue(matcher compiledMatcher, evidence Evidence) (string
 		case "sha256":
 			return firstNonEmpty(evidence.FaviconSHA256, legacyFaviconValue(evidence.FaviconHash, 64)), false
 		}
+	case "tls_fingerprint":
+		switch matcher.target {
+		case "", "jarm":
+			return evidence.TLSFingerprint, false
+		case "ja4s":
+			return evidence.JA4S, false
+		}
+	case "snmp_sysdescr":
+		return evidence.SNMPSysDescr, false
 	default:
 		return evidence.Body, evidence.BodyTruncated
 	}
@@ -758,10 +799,11 @@ func expandRegexTemplate(expression *regexp.Regexp, template, observed string, i
 }
 
 var (
-	wappConditionalTemplate = regexp.MustCompile(`\$WAPP\(([0-9]+),"((?:\\.|[^"])*)","((?:\\.|[^"])*)"\)`)
-	nmapSubstTemplate       = regexp.MustCompile(`\$SUBST\(([0-9]+),"((?:\\.|[^"])*)","((?:\\.|[^"])*)"\)`)
-	nmapPrintableTemplate   = regexp.MustCompile(`\$P\(([0-9]+)\)`)
-	nmapSimpleCapture       = regexp.MustCompile(`\$[0-9]+`)
+	wappConditionalTemplate   = regexp.MustCompile(`\$WAPP\(([0-9]+),"((?:\\.|[^"])*)","((?:\\.|[^"])*)"\)`)
+	nmapSubstTemplate         = regexp.MustCompile(`\$SUBST\(([0-9]+),"((?:\\.|[^"])*)","((?:\\.|[^"])*)"\)`)
+	nmapPrintableTemplate     = regexp.MustCompile(`\$P\(([0-9]+)\)`)
+	unexpandedCaptureTemplate = regexp.MustCompile(`\$\{[0-9]+\}`)
+	nmapSimpleCapture         = regexp.MustCompile(`\$[0-9]+`)
 )
 
 func nmapTemplateSupported(template string) bool {
@@ -323,14 +323,16 @@ func TestRuleDoesNotInheritCPEFromSharedProductCatalog(t *testing.T) {
 	db := openFingerprintTestDB(t)
 	_, err := storage.ImportFingerprintBatch(db, storage.FingerprintImportBatch{
 		Source: model.FingerprintSource{SourceKey: "fixture-cpe", RepositoryURL: "local://fixture", Status: "enabled"},
-		Import: model.FingerprintImport{Commit: "fixture", ContentSHA256: "fixture", ManifestJSON: `{}`, RuleTotal: 2, ExecutableTotal: 2},
+		Import: model.FingerprintImport{Commit: "fixture", ContentSHA256: "fixture", ManifestJSON: `{}`, RuleTotal: 3, ExecutableTotal: 3},
 		Rules: []model.FingerprintSourceRule{
 			{SourceRuleID: "generic", SourcePath: "generic", ContentSHA256: "generic", RawContent: "generic", ImportStatus: "executable"},
 			{SourceRuleID: "specific", SourcePath: "specific", ContentSHA256: "specific", RawContent: "specific", ImportStatus: "executable"},
+			{SourceRuleID: "captured", SourcePath: "captured", ContentSHA256: "captured", RawContent: "captured", ImportStatus: "executable"},
 		},
 		Projections: []model.FingerprintRuleProjection{
 			{SourcePath: "generic", ContentSHA256: "generic", Product: model.FingerprintProduct{CanonicalName: "http"}, Protocol: "http", Root: model.FingerprintMatchGroupProjection{Operator: "all", Matchers: []model.FingerprintMatcher{{EvidenceType: "http_header", Target: "server", Operator: "contains_ci", Value: "generic"}}}},
 			{SourcePath: "specific", ContentSHA256: "specific", Product: model.FingerprintProduct{CanonicalName: "http", CPE: "cpe:/a:golang:go"}, Protocol: "http", CPE: "cpe:/a:golang:go", Root: model.FingerprintMatchGroupProjection{Operator: "all", Matchers: []model.FingerprintMatcher{{EvidenceType: "http_header", Target: "server", Operator: "contains_ci", Value: "go-http-server"}}}},
+			{SourcePath: "captured", ContentSHA256: "captured", Product: model.FingerprintProduct{CanonicalName: "acme"}, Protocol: "http", CPE: "cpe:/a:acme:server:${1}", Root: model.FingerprintMatchGroupProjection{Operator: "all", Matchers: []model.FingerprintMatcher{{EvidenceType: "http_header", Target: "server", Operator: "contains_ci", Value: "acme"}}}},
 		},
 	})
 	if err != nil {
@@ -344,6 +346,11 @@ func TestRuleDoesNotInheritCPEFromSharedProductCatalog(t *testing.T) {
 	if len(matches) != 1 || matches[0].CPE != "" {
 		t.Fatalf("generic rule inherited catalog CPE: %#v", matches)
 	}
+	// Without a regex matcher the capture is never expanded.
+	matches = engine.Match(Evidence{Protocol: "http", Headers: map[string]string{"Server": "Acme"}})
+	if len(matches) != 1 || matches[0].CPE != "" {
+		t.Fatalf("unexpanded CPE template was reported: %#v", matches)
+	}
 }
 
 func TestProjectionRevisionIsImmutableAndRunsKeepFrozenSemantics(t *testing.T) {
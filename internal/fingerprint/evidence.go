@@ -1,6 +1,8 @@
 package fingerprint
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"crypto/md5"
 	"crypto/sha256"
@@ -41,6 +43,55 @@ func NewBannerEvidence(banner string, truncated bool) Evidence {
 	}
 }
 
+// NewSNMPSysDescrEvidence wraps the sysDescr an SNMP inventory read so rules
+// written against it can conclude the device or operating system.
+func NewSNMPSysDescrEvidence(sysDescr string) Evidence {
+	return Evidence{Protocol: "snmp", SNMPSysDescr: sysDescr}
+}
+
+// NewWebHeaderEvidence builds web evidence from a response head observed
+// outside the collector, such as an imported scanner result. The header
+// capture contract matches CollectWebEvidence; no body is available.
+func NewWebHeaderEvidence(protocol, rawURL string, statusCode int, headers map[string]string, title string) Evidence {
+	values := make(http.Header, len(headers))
+	for name, value := range headers {
+		values[http.CanonicalHeaderKey(name)] = []string{value}
+	}
+	captured, length, truncated, hash := cappedHeaders(values)
+	return Evidence{
+		Protocol: strings.ToLower(strings.TrimSpace(protocol)), Headers: captured, Title: strings.TrimSpace(title),
+		URL: rawURL, StatusCode: statusCode, HeaderTruncated: truncated,
+		HeaderCapturedLength: length, HeaderCapturedSHA256: hash,
+	}
+}
+
+// NewHTTPResponseEvidence builds web evidence from a saved raw HTTP response
+// (status line, headers and body). Headers and body are captured under the
+// same limits as CollectWebEvidence; no favicon is fetched.
+func NewHTTPResponseEvidence(protocol, rawURL string, raw []byte) (Evidence, error) {
+	response, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(raw)), nil)
+	if err != nil {
+		return Evidence{}, fmt.Errorf("parse HTTP response: %w", err)
+	}
+	defer response.Body.Close()
+	headers, headerLength, headerTruncated, headerHash := cappedHeaders(response.Header)
+	body, bodyLength, bodyTruncated, bodyHash, binary, err := cappedBody(response.Body)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return Evidence{}, fmt.Errorf("read HTTP response body: %w", err)
+	}
+	if binary {
+		body = nil
+	}
+	bodyText := string(body)
+	return Evidence{
+		Protocol: strings.ToLower(strings.TrimSpace(protocol)), Headers: headers, Meta: htmlMetadata(bodyText), Cookies: responseCookies(response),
+		Body: bodyText, Title: htmlTitle(bodyText), URL: rawURL, StatusCode: response.StatusCode,
+		HeaderTruncated: headerTruncated, BodyTruncated: bodyTruncated,
+		HeaderCapturedLength: headerLength, HeaderCapturedSHA256: headerHash,
+		BodyCapturedLength: bodyLength, BodyCapturedSHA256: bodyHash,
+	}, nil
+}
+
 // WebEvidenceOptions makes the network boundary explicit. AllowedPorts is the
 // port policy for the current run, not a rule supplied destination list.
 type WebEvidenceOptions struct {
@@ -89,14 +140,7 @@ func CollectWebEvidence(ctx context.Context, ip string, port int, service string
 }
 
 func collectHTTP(ctx context.Context, scheme, ip string, port int, options WebEvidenceOptions) (CollectedEvidence, error) {
-	dialer := &net.Dialer{Timeout: options.Timeout}
-	transport := &http.Transport{
-		DialContext:            dialer.DialContext,
-		TLSHandshakeTimeout:    options.Timeout,
-		MaxResponseHeaderBytes: maxHTTPHeaderBytes,
-		TLSClientConfig:        &tls.Config{InsecureSkipVerify: true}, // internal endpoints commonly use private certificates.
-	}
-	client := &http.Client{Transport: transport}
+	client := &http.Client{Transport: newWebTransport(options.Timeout)}
 	client.CheckRedirect = func(request *http.Request, via []*http.Request) error {
 		if len(via) > 1 || !sameAuthorizedEndpoint(request.URL, ip, port, options.AllowedPorts) {
 			return http.ErrUseLastResponse
@@ -145,6 +189,16 @@ func collectHTTP(ctx context.Context, scheme, ip string, port int, options WebEv
 	return CollectedEvidence{Evidence: evidence, Protocol: protocol, Summary: summary}, nil
 }
 
+func newWebTransport(timeout time.Duration) *http.Transport {
+	dialer := &net.Dialer{Timeout: timeout}
+	return &http.Transport{
+		DialContext:            dialer.DialContext,
+		TLSHandshakeTimeout:    timeout,
+		MaxResponseHeaderBytes: maxHTTPHeaderBytes,
+		TLSClientConfig:        &tls.Config{InsecureSkipVerify: true}, // internal endpoints commonly use private certificates.
+	}
+}
+
 func responseCookies(response *http.Response) map[string]string {
 	values := make(map[string]string)
 	if response == nil {
@@ -0,0 +1,447 @@
+package fingerprint
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golandproject/yscan/internal/model"
+	"golandproject/yscan/internal/storage"
+)
+
+const ruleTestUsage = "usage: yscan fingerprint test --banner-file <path> [--port <port>] [--probe <name>] | --http-response-file <path> [--url <url>] [--source <source_key> | --import-id <id>] [--json]"
+
+// maxRuleTestObserved bounds the observed excerpt printed per matcher; the
+// length and SHA-256 in the hit still describe the whole value.
+const maxRuleTestObserved = 160
+
+// RuleTestReport is the offline harness output. Its JSON form is stable so
+// rule authors can keep expected reports beside their saved responses.
+type RuleTestReport struct {
+	Input           string              `json:"input"`
+	Port            int                 `json:"port,omitempty"`
+	Probes          []string            `json:"probes,omitempty"`
+	Matches         []RuleTestMatch     `json:"matches"`
+	Products        []RuleTestProduct   `json:"products"`
+	EndpointProduct string              `json:"endpoint_product,omitempty"`
+	Evidence        RuleTestEvidenceRef `json:"evidence"`
+}
+
+type RuleTestEvidenceRef struct {
+	Protocol       string `json:"protocol"`
+	StatusCode     int    `json:"status_code,omitempty"`
+	Title          string `json:"title,omitempty"`
+	CapturedLength int    `json:"captured_length"`
+	CapturedSHA256 string `json:"captured_sha256"`
+	Truncated      bool   `json:"truncated,omitempty"`
+}
+
+type RuleTestMatch struct {
+	Product        string            `json:"product"`
+	SourceProduct  string            `json:"source_product,omitempty"`
+	Role           string            `json:"role"`
+	ExclusiveGroup string            `json:"exclusive_group,omitempty"`
+	Version        string            `json:"version,omitempty"`
+	CPE            string            `json:"cpe,omitempty"`
+	Tags           []string          `json:"tags,omitempty"`
+	Soft           bool              `json:"soft"`
+	Source         string            `json:"source"`
+	SourceRuleID   string            `json:"source_rule_id,omitempty"`
+	ImportID       int64             `json:"import_id"`
+	Probe          string            `json:"probe,omitempty"`
+	Matchers       []RuleTestMatcher `json:"matchers"`
+}
+
+type RuleTestMatcher struct {
+	ID             int64  `json:"id"`
+	EvidenceType   string `json:"evidence_type"`
+	Target         string `json:"target,omitempty"`
+	Operator       string `json:"operator"`
+	Pattern        string `json:"pattern,omitempty"`
+	Observed       string `json:"observed,omitempty"`
+	ObservedLength int    `json:"observed_length"`
+	ObservedSHA256 string `json:"observed_sha256,omitempty"`
+	Truncated      bool   `json:"truncated,omitempty"`
+}
+
+// RuleTestProduct mirrors the per-endpoint conclusion a scan would persist:
+// hard matches resolve to matched, corroborated or conflicted, while products
+// seen only through soft rules stay soft and never become conclusions.
+type RuleTestProduct struct {
+	Product        string   `json:"product"`
+	Role           string   `json:"role"`
+	ExclusiveGroup string   `json:"exclusive_group,omitempty"`
+	Status         string   `json:"status"`
+	Sources        []string `json:"sources"`
+	ImportIDs      []int64  `json:"import_ids"`
+}
+
+type ruleTestOptions struct {
+	bannerFile, responseFile, rawURL, probe, source string
+	port                                            int
+	importID                                        int64
+	json                                            bool
+}
+
+func runRuleTestCLI(db *sql.DB, args []string, output io.Writer) error {
+	options, err := parseRuleTestArgs(args)
+	if err != nil {
+		return err
+	}
+	engine, err := loadRuleTestEngine(db, options)
+	if err != nil {
+		return err
+	}
+	report, err := runRuleTest(engine, options)
+	if err != nil {
+		return err
+	}
+	if options.json {
+		encoder := json.NewEncoder(output)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	return writeRuleTestReport(output, report)
+}
+
+func parseRuleTestArgs(args []string) (ruleTestOptions, error) {
+	var options ruleTestOptions
+	for index := 0; index < len(args); index++ {
+		flag := strings.TrimSpace(args[index])
+		if flag == "--json" {
+			options.json = true
+			continue
+		}
+		if index+1 >= len(args) {
+			return ruleTestOptions{}, fmt.Errorf("%s requires a value", flag)
+		}
+		value := strings.TrimSpace(args[index+1])
+		index++
+		switch flag {
+		case "--banner-file":
+			options.bannerFile = value
+		case "--http-response-file":
+			options.responseFile = value
+		case "--url":
+			options.rawURL = value
+		case "--probe":
+			options.probe = value
+		case "--source":
+			options.source = value
+		case "--port":
+			port, err := strconv.Atoi(value)
+			if err != nil || port < 1 || port > 65535 {
+				return ruleTestOptions{}, errors.New("--port must be between 1 and 65535")
+			}
+			options.port = port
+		case "--import-id":
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || id <= 0 {
+				return ruleTestOptions{}, errors.New("--import-id must be a positive integer")
+			}
+			options.importID = id
+		default:
+			return ruleTestOptions{}, fmt.Errorf("unsupported flag: %s", flag)
+		}
+	}
+	if (options.bannerFile == "") == (options.responseFile == "") {
+		return ruleTestOptions{}, errors.New(ruleTestUsage)
+	}
+	if options.source != "" && options.importID > 0 {
+		return ruleTestOptions{}, errors.New("--source and --import-id are mutually exclusive")
+	}
+	if options.responseFile != "" && (options.probe != "" || options.port > 0) {
+		return ruleTestOptions{}, errors.New("--probe and --port apply only to --banner-file")
+	}
+	return options, nil
+}
+
+func loadRuleTestEngine(db *sql.DB, options ruleTestOptions) (*Engine, error) {
+	switch {
+	case options.importID > 0:
+		return LoadImportEngine(db, options.importID)
+	case options.source != "":
+		return LoadSourceEngine(db, options.source)
+	default:
+		return LoadActiveEngine(db)
+	}
+}
+
+// runRuleTest evaluates one saved response against engine. Banner files go
+// through passive matching and, when a probe or port is named, through the
+// same Nmap probe rules an active scan would apply to that response.
+func runRuleTest(engine *Engine, options ruleTestOptions) (RuleTestReport, error) {
+	if engine == nil {
+		return RuleTestReport{}, errors.New("fingerprint engine is required")
+	}
+	report := RuleTestReport{Port: options.port}
+	var evidence Evidence
+	var matches []Match
+	probeOf := make(map[int64]string)
+	if options.responseFile != "" {
+		raw, err := os.ReadFile(options.responseFile)
+		if err != nil {
+			return RuleTestReport{}, fmt.Errorf("read HTTP response file: %w", err)
+		}
+		// https candidates include the plain http rules, so a saved response
+		// is tested against every web rule regardless of how it was captured.
+		evidence, err = NewHTTPResponseEvidence("https", options.rawURL, raw)
+		if err != nil {
+			return RuleTestReport{}, err
+		}
+		report.Input = "http_response"
+		report.Evidence = RuleTestEvidenceRef{
+			Protocol: evidence.Protocol, StatusCode: evidence.StatusCode, Title: evidence.Title,
+			CapturedLength: evidence.BodyCapturedLength, CapturedSHA256: evidence.BodyCapturedSHA256,
+			Truncated: evidence.HeaderTruncated || evidence.BodyTruncated,
+		}
+		matches = engine.Match(evidence)
+	} else {
+		raw, err := os.ReadFile(options.bannerFile)
+		if err != nil {
+			return RuleTestReport{}, fmt.Errorf("read banner file: %w", err)
+		}
+		if len(raw) > maxNmapProbeRead {
+			raw = raw[:maxNmapProbeRead]
+		}
+		evidence = NewBannerEvidence(string(raw), len(raw) >= maxNmapProbeRead)
+		report.Input = "banner"
+		report.Evidence = RuleTestEvidenceRef{
+			Protocol: evidence.Protocol, CapturedLength: evidence.BannerCapturedLength,
+			CapturedSHA256: evidence.BannerCapturedSHA256, Truncated: evidence.BannerTruncated,
+		}
+		matches = engine.Match(evidence)
+		seen := make(map[int64]struct{}, len(matches))
+		for _, match := range matches {
+			seen[match.FingerprintSourceRuleID] = struct{}{}
+		}
+		for _, probe := range ruleTestProbes(engine, options) {
+			report.Probes = append(report.Probes, probe)
+			for _, match := range engine.MatchNmapTCPProbeResponse(probe, raw) {
+				if _, exists := seen[match.FingerprintSourceRuleID]; exists {
+					continue
+				}
+				seen[match.FingerprintSourceRuleID] = struct{}{}
+				probeOf[match.FingerprintSourceRuleID] = probe
+				matches = append(matches, match)
+			}
+		}
+	}
+	matchers := engine.matchersByID()
+	report.Matches = make([]RuleTestMatch, 0, len(matches))
+	for _, match := range matches {
+		report.Matches = append(report.Matches, ruleTestMatch(match, probeOf[match.FingerprintSourceRuleID], matchers, evidence))
+	}
+	report.Products = resolveRuleTestProducts(matches)
+	report.EndpointProduct = ruleTestEndpointProduct(report.Products)
+	return report, nil
+}
+
+func ruleTestProbes(engine *Engine, options ruleTestOptions) []string {
+	if options.probe != "" {
+		return []string{options.probe}
+	}
+	if options.port == 0 {
+		return nil
+	}
+	probes := engine.NmapTCPProbesForEndpoint(options.port, "")
+	names := make([]string, 0, len(probes))
+	for _, probe := range probes {
+		names = append(names, probe.Name)
+	}
+	return names
+}
+
+func (engine *Engine) matchersByID() map[int64]compiledMatcher {
+	result := make(map[int64]compiledMatcher)
+	var walk func(*compiledGroup)
+	walk = func(group *compiledGroup) {
+		if group == nil {
+			return
+		}
+		for _, matcher := range group.matchers {
+			result[matcher.id] = matcher
+		}
+		for _, child := range group.children {
+			walk(child)
+		}
+	}
+	for _, rule := range engine.rules {
+		walk(rule.root)
+	}
+	return result
+}
+
+func ruleTestMatch(match Match, probe string, matchers map[int64]compiledMatcher, evidence Evidence) RuleTestMatch {
+	result := RuleTestMatch{
+		Product: match.Product, SourceProduct: match.SourceProduct, Role: match.ProductRole, ExclusiveGroup: match.ExclusiveGroup,
+		Version: match.Version, CPE: match.CPE, Tags: match.Tags, Soft: match.Soft, Source: match.SourceKey,
+		SourceRuleID: match.SourceRuleID, ImportID: match.FingerprintImportID, Probe: probe,
+		Matchers: make([]RuleTestMatcher, 0, len(match.MatcherHits)),
+	}
+	for _, hit := range match.MatcherHits {
+		explained := RuleTestMatcher{
+			ID: hit.MatcherID, EvidenceType: hit.EvidenceType, Target: hit.Target, Operator: hit.Operator,
+			ObservedLength: hit.ObservedLength, ObservedSHA256: hit.ObservedSHA256, Truncated: hit.Truncated,
+		}
+		if matcher, ok := matchers[hit.MatcherID]; ok {
+			explained.Pattern = matcher.value
+			if observed, _ := normalizedEvidenceValue(matcher, evidence); observed != "" {
+				explained.Observed = ruleTestExcerpt(observed)
+			}
+		}
+		result.Matchers = append(result.Matchers, explained)
+	}
+	return result
+}
+
+func ruleTestExcerpt(value string) string {
+	value = strings.ToValidUTF8(value, "�")
+	if utf8.RuneCountInString(value) > maxRuleTestObserved {
+		value = string([]rune(value)[:maxRuleTestObserved]) + "..."
+	}
+	return strconv.Quote(value)
+}
+
+type ruleTestProductState struct {
+	product RuleTestProduct
+	hard    bool
+	sources map[string]struct{}
+	imports map[int64]struct{}
+}
+
+// resolveRuleTestProducts takes hard product statuses from storage so the
+// harness concludes exactly as a saved run would.
+func resolveRuleTestProducts(matches []Match) []RuleTestProduct {
+	states := make(map[string]*ruleTestProductState)
+	runMatches := make([]model.FingerprintRunMatch, 0, len(matches))
+	for _, match := range matches {
+		runMatches = append(runMatches, match.RunMatch("", 0, "", ""))
+		state := states[match.Product]
+		if state == nil {
+			state = &ruleTestProductState{
+				product: RuleTestProduct{Product: match.Product, Role: match.ProductRole, ExclusiveGroup: match.ExclusiveGroup},
+				sources: make(map[string]struct{}), imports: make(map[int64]struct{}),
+			}
+			states[match.Product] = state
+		}
+		if match.Soft {
+			continue
+		}
+		state.hard = true
+		state.sources[match.SourceKey] = struct{}{}
+		state.imports[match.FingerprintImportID] = struct{}{}
+	}
+	statuses := storage.FingerprintProductStatuses(runMatches)
+	products := make([]RuleTestProduct, 0, len(states))
+	for name, state := range states {
+		product := state.product
+		product.Status = "soft"
+		if state.hard {
+			product.Status = statuses[strings.ToLower(strings.TrimSpace(name))]
+		}
+		product.Sources = make([]string, 0, len(state.sources))
+		for source := range state.sources {
+			product.Sources = append(product.Sources, source)
+		}
+		sort.Strings(product.Sources)
+		product.ImportIDs = make([]int64, 0, len(state.imports))
+		for importID := range state.imports {
+			product.ImportIDs = append(product.ImportIDs, importID)
+		}
+		sort.Slice(product.ImportIDs, func(i, j int) bool { return product.ImportIDs[i] < product.ImportIDs[j] })
+		products = append(products, product)
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].Product < products[j].Product })
+	return products
+}
+
+// ruleTestEndpointProduct follows the workflow: the endpoint gets a product
+// only when exactly one hard web server or network service matched.
+func ruleTestEndpointProduct(products []RuleTestProduct) string {
+	endpoint := ""
+	for _, product := range products {
+		if product.Status == "soft" || (product.Role != "web_server" && product.Role != "network_service") {
+			continue
+		}
+		if endpoint != "" {
+			return ""
+		}
+		endpoint = product.Product
+	}
+	return endpoint
+}
+
+func writeRuleTestReport(output io.Writer, report RuleTestReport) error {
+	header := fmt.Sprintf("Input %s %s %d bytes sha256=%s", report.Input, report.Evidence.Protocol, report.Evidence.CapturedLength, report.Evidence.CapturedSHA256)
+	if report.Evidence.StatusCode > 0 {
+		header += fmt.Sprintf(" status=%d", report.Evidence.StatusCode)
+	}
+	if report.Evidence.Truncated {
+		header += " truncated"
+	}
+	if _, err := fmt.Fprintln(output, header); err != nil {
+		return err
+	}
+	if len(report.Probes) > 0 {
+		if _, err := fmt.Fprintf(output, "Probes %s\n", strings.Join(report.Probes, ", ")); err != nil {
+			return err
+		}
+	}
+	if len(report.Matches) == 0 {
+		_, err := fmt.Fprintln(output, "No fingerprint rules matched.")
+		return err
+	}
+	for _, match := range report.Matches {
+		kind := "HARD"
+		if match.Soft {
+			kind = "SOFT"
+		}
+		line := fmt.Sprintf("%s %s role=%s source=%s rule=%s import=%d", kind, match.Product, match.Role, match.Source, match.SourceRuleID, match.ImportID)
+		if match.ExclusiveGroup != "" {
+			line += " group=" + match.ExclusiveGroup
+		}
+		if match.Version != "" {
+			line += " version=" + match.Version
+		}
+		if match.CPE != "" {
+			line += " cpe=" + match.CPE
+		}
+		if match.Probe != "" {
+			line += " probe=" + match.Probe
+		}
+		if _, err := fmt.Fprintln(output, line); err != nil {
+			return err
+		}
+		for _, matcher := range match.Matchers {
+			target := matcher.EvidenceType
+			if matcher.Target != "" {
+				target += "[" + matcher.Target + "]"
+			}
+			if _, err := fmt.Fprintf(output, "  matcher %d %s %s %s observed=%s (%d bytes)\n", matcher.ID, target, matcher.Operator, strconv.Quote(matcher.Pattern), firstNonEmpty(matcher.Observed, "-"), matcher.ObservedLength); err != nil {
+				return err
+			}
+		}
+	}
+	for _, product := range report.Products {
+		line := fmt.Sprintf("PRODUCT %s %s role=%s", product.Product, product.Status, product.Role)
+		if product.ExclusiveGroup != "" {
+			line += " group=" + product.ExclusiveGroup
+		}
+		if len(product.Sources) > 0 {
+			line += " sources=" + strings.Join(product.Sources, ",")
+		}
+		if _, err := fmt.Fprintln(output, line); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(output, "ENDPOINT %s\n", firstNonEmpty(report.EndpointProduct, "-"))
+	return err
+}
@@ -0,0 +1,143 @@
+package fingerprint
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golandproject/yscan/internal/model"
+	"golandproject/yscan/internal/storage"
+)
+
+const harnessResponse = "HTTP/1.1 200 OK\r\nServer: nginx/1.25.3\r\nX-Powered-By: PHP/8.2\r\nContent-Type: text/html\r\n\r\n<html><head><title>Welcome</title></head><body>Apache fallback page</body></html>"
+
+func importHarnessFixture(t *testing.T, db *sql.DB, sourceKey, product string, soft bool, matcher model.FingerprintMatcher) model.FingerprintImport {
+	t.Helper()
+	value, err := storage.ImportFingerprintBatch(db, storage.FingerprintImportBatch{
+		Source:      model.FingerprintSource{SourceKey: sourceKey, RepositoryURL: "local://" + sourceKey, Status: "enabled"},
+		Import:      model.FingerprintImport{Commit: sourceKey, ContentSHA256: sourceKey, ManifestJSON: `{}`, RuleTotal: 1, ExecutableTotal: 1},
+		Rules:       []model.FingerprintSourceRule{{SourceRuleID: sourceKey + ":1", SourcePath: sourceKey, ContentSHA256: sourceKey, RawContent: sourceKey, ImportStatus: "executable"}},
+		Projections: []model.FingerprintRuleProjection{{SourcePath: sourceKey, ContentSHA256: sourceKey, Product: model.FingerprintProduct{CanonicalName: product}, Protocol: "http", SoftMatch: soft, Root: model.FingerprintMatchGroupProjection{Operator: "all", Matchers: []model.FingerprintMatcher{matcher}}}},
+	})
+	if err != nil {
+		t.Fatalf("import %s: %v", sourceKey, err)
+	}
+	return value
+}
+
+func TestRuleTestExplainsMatchersAndExclusiveGroupConflicts(t *testing.T) {
+	db := openFingerprintTestDB(t)
+	importHarnessFixture(t, db, "servers", "nginx", false, model.FingerprintMatcher{EvidenceType: "http_header", Target: "Server", Operator: "regex", Value: `nginx/([0-9.]+)`, VersionCapture: "$1"})
+	apache := importHarnessFixture(t, db, "body-hints", "apache", false, model.FingerprintMatcher{EvidenceType: "http_body", Operator: "contains", Value: "Apache"})
+	importHarnessFixture(t, db, "runtimes", "php", true, model.FingerprintMatcher{EvidenceType: "http_header", Target: "X-Powered-By", Operator: "contains_ci", Value: "php"})
+	path := filepath.Join(t.TempDir(), "response.http")
+	if err := os.WriteFile(path, []byte(harnessResponse), 0600); err != nil {
+		t.Fatal(err)
+	}
+	registry := NewRegistry(db, Manifest{}, nil, nil)
+
+	var output bytes.Buffer
+	if err := RunCLI(context.Background(), registry, []string{"test", "--http-response-file", path, "--json"}, &output); err != nil {
+		t.Fatalf("run rule test: %v", err)
+	}
+	var report RuleTestReport
+	if err := json.Unmarshal(output.Bytes(), &report); err != nil {
+		t.Fatalf("decode report %q: %v", output.String(), err)
+	}
+	if report.Input != "http_response" || report.Evidence.StatusCode != 200 || report.Evidence.Title != "Welcome" || len(report.Matches) != 3 {
+		t.Fatalf("report = %#v", report)
+	}
+	statuses := make(map[string]string)
+	for _, product := range report.Products {
+		statuses[product.Product] = product.Status
+	}
+	if statuses["nginx"] != "conflicted" || statuses["apache"] != "conflicted" || statuses["php"] != "soft" || report.EndpointProduct != "" {
+		t.Fatalf("products = %#v endpoint=%q", report.Products, report.EndpointProduct)
+	}
+	for _, match := range report.Matches {
+		if match.Product != "nginx" {
+			continue
+		}
+		if match.Version != "1.25.3" || len(match.Matchers) != 1 || match.Matchers[0].Pattern != `nginx/([0-9.]+)` || match.Matchers[0].Observed != `"nginx/1.25.3"` {
+			t.Fatalf("nginx explanation = %#v", match)
+		}
+	}
+
+	output.Reset()
+	if err := RunCLI(context.Background(), registry, []string{"test", "--http-response-file", path, "--source", "servers"}, &output); err != nil {
+		t.Fatal(err)
+	}
+	text := output.String()
+	if !strings.Contains(text, "HARD nginx role=web_server source=servers") || !strings.Contains(text, `http_header[Server] regex "nginx/([0-9.]+)" observed="nginx/1.25.3"`) ||
+		!strings.Contains(text, "PRODUCT nginx matched") || !strings.Contains(text, "ENDPOINT nginx") || strings.Contains(text, "apache") {
+		t.Fatalf("source-scoped text report:\n%s", text)
+	}
+
+	output.Reset()
+	if err := RunCLI(context.Background(), registry, []string{"test", "--http-response-file", path, "--import-id", strconv.FormatInt(apache.ID, 10)}, &output); err != nil {
+		t.Fatal(err)
+	}
+	if text := output.String(); !strings.Contains(text, "HARD apache") || strings.Contains(text, "HARD nginx") {
+		t.Fatalf("import-scoped text report:\n%s", text)
+	}
+}
+
+func TestRuleTestMatchesSavedBannerAgainstLocalRules(t *testing.T) {
+	db := openFingerprintTestDB(t)
+	directory := t.TempDir()
+	if err := os.WriteFile(filepath.Join(directory, "acme.yaml"), []byte(localPortalRules), 0600); err != nil {
+		t.Fatal(err)
+	}
+	registry := NewRegistry(db, Manifest{}, nil, nil)
+	registry.LocalRulesDir = directory
+	if _, err := registry.Import(context.Background(), LocalSourceKey, ""); err != nil {
+		t.Fatal(err)
+	}
+	banner := filepath.Join(t.TempDir(), "agent.banner")
+	if err := os.WriteFile(banner, []byte("ACME-AGENT 7.0\r\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var output bytes.Buffer
+	if err := RunCLI(context.Background(), registry, []string{"test", "--banner-file", banner, "--source", LocalSourceKey}, &output); err != nil {
+		t.Fatal(err)
+	}
+	text := output.String()
+	if !strings.Contains(text, "Input banner tcp 16 bytes") || !strings.Contains(text, "source=local rule=acme-agent") || !strings.Contains(text, "version=7.0") ||
+		!strings.Contains(text, `tcp_banner regex "^ACME-AGENT ([0-9.]+)"`) {
+		t.Fatalf("banner report:\n%s", text)
+	}
+}
+
+func TestRuleTestRejectsAmbiguousInputs(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"--banner-file", "a", "--http-response-file", "b"},
+		{"--banner-file", "a", "--source", "s", "--import-id", "1"},
+		{"--http-response-file", "b", "--probe", "GetRequest"},
+		{"--http-response-file", "b", "--port", "8080"},
+		{"--banner-file", "a", "--port", "70000"},
+	} {
+		if _, err := parseRuleTestArgs(args); err == nil {
+			t.Fatalf("args %q were accepted", args)
+		}
+	}
+}
+
+func TestHTTPResponseEvidenceCapturesHeadersAndBody(t *testing.T) {
+	evidence, err := NewHTTPResponseEvidence("http", "http://example.test/", []byte(harnessResponse))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evidence.StatusCode != 200 || evidence.Headers["Server"] != "nginx/1.25.3" || evidence.Title != "Welcome" || !strings.Contains(evidence.Body, "Apache") || evidence.BodyCapturedSHA256 == "" {
+		t.Fatalf("evidence = %#v", evidence)
+	}
+	if _, err := NewHTTPResponseEvidence("http", "", []byte("not a response")); err == nil {
+		t.Fatal("malformed response was accepted")
+	}
+}
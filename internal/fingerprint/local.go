@@ -0,0 +1,379 @@
+package fingerprint
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"golandproject/yscan/internal/model"
+	"golandproject/yscan/internal/storage"
+)
+
+// LocalSourceKey names the user-authored rule source read from the yscan
+// home instead of an embedded snapshot.
+const LocalSourceKey = "local"
+
+const maxLocalRuleFileBytes = 1 << 20
+
+// ErrNoLocalRules reports a local rule directory without .yaml files.
+var ErrNoLocalRules = errors.New("no .yaml rule files")
+
+var localRulesDirectory struct {
+	sync.RWMutex
+	path string
+}
+
+// ConfigureLocalRulesDirectory sets the directory embedded registries import
+// the local source from, normally fingerprints/local in the yscan home.
+func ConfigureLocalRulesDirectory(directory string) {
+	localRulesDirectory.Lock()
+	if strings.TrimSpace(directory) == "" {
+		localRulesDirectory.path = ""
+	} else {
+		localRulesDirectory.path = filepath.Clean(directory)
+	}
+	localRulesDirectory.Unlock()
+}
+
+func configuredLocalRulesDirectory() string {
+	localRulesDirectory.RLock()
+	defer localRulesDirectory.RUnlock()
+	return localRulesDirectory.path
+}
+
+var (
+	localRuleProtocols = map[string]struct{}{"http": {}, "tcp": {}, "tls": {}}
+	localRuleOperators = map[string]struct{}{"equals": {}, "contains": {}, "contains_ci": {}, "like": {}, "regex": {}, "regex_ci": {}}
+	// localRuleTargets lists the evidence types a local matcher may use and
+	// the targets each accepts; nil means the target is a free-form name and
+	// is required.
+	localRuleTargets = map[string][]string{
+		"http_body":       {""},
+		"http_header":     nil,
+		"html_meta":       nil,
+		"http_cookie":     nil,
+		"http_url":        {""},
+		"html_title":      {""},
+		"status_code":     {""},
+		"tcp_banner":      {""},
+		"favicon_hash":    {"md5", "mmh3", "sha256"},
+		"tls_fingerprint": {"", "jarm", "ja4s"},
+	}
+	localRuleID = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+)
+
+// localRuleFile is one YAML file of the local rule directory. Each entry of
+// rules becomes its own source rule, so a file can group an application's
+// variants.
+type localRuleFile struct {
+	Rules []localRule `yaml:"rules"`
+}
+
+type localRule struct {
+	ID        string             `yaml:"id"`
+	Product   string             `yaml:"product"`
+	Vendor    string             `yaml:"vendor,omitempty"`
+	CPE       string             `yaml:"cpe,omitempty"`
+	Protocol  string             `yaml:"protocol"`
+	Role      string             `yaml:"role,omitempty"`
+	Tags      []string           `yaml:"tags,omitempty"`
+	Soft      bool               `yaml:"soft,omitempty"`
+	Condition string             `yaml:"condition,omitempty"`
+	Matchers  []localRuleMatcher `yaml:"matchers,omitempty"`
+	Groups    []localRuleGroup   `yaml:"groups,omitempty"`
+}
+
+type localRuleGroup struct {
+	Condition string             `yaml:"condition,omitempty"`
+	Matchers  []localRuleMatcher `yaml:"matchers,omitempty"`
+	Groups    []localRuleGroup   `yaml:"groups,omitempty"`
+}
+
+type localRuleMatcher struct {
+	Type     string `yaml:"type"`
+	Target   string `yaml:"target,omitempty"`
+	Operator string `yaml:"operator"`
+	Value    string `yaml:"value"`
+	Version  string `yaml:"version,omitempty"`
+}
+
+type localAdapter struct{}
+
+func (localAdapter) SourceKey() string      { return LocalSourceKey }
+func (localAdapter) AdapterVersion() string { return "local-yaml-v1" }
+
+// Adapt splits every file into one source rule per entry. Unlike upstream
+// sources, a local rule that cannot be projected fails the whole import:
+// the author is present to fix it, and a silently skipped rule would look
+// like a product that is simply absent.
+func (localAdapter) Adapt(snapshot VerifiedSnapshot) ([]model.FingerprintSourceRule, error) {
+	paths := make([]string, 0, len(snapshot.Files))
+	for sourcePath := range snapshot.Files {
+		paths = append(paths, sourcePath)
+	}
+	sort.Strings(paths)
+	rules := make([]model.FingerprintSourceRule, 0)
+	seen := make(map[string]string)
+	var problems []error
+	for _, sourcePath := range paths {
+		var file localRuleFile
+		if err := yaml.Unmarshal(snapshot.Files[sourcePath], &file); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", sourcePath, err))
+			continue
+		}
+		if len(file.Rules) == 0 {
+			problems = append(problems, fmt.Errorf("%s: no rules", sourcePath))
+			continue
+		}
+		for index, entry := range file.Rules {
+			id := strings.TrimSpace(entry.ID)
+			if previous, duplicate := seen[id]; duplicate && id != "" {
+				problems = append(problems, fmt.Errorf("%s: rule %s already defined in %s", sourcePath, id, previous))
+				continue
+			}
+			seen[id] = sourcePath
+			raw, err := yaml.Marshal(entry)
+			if err != nil {
+				return nil, err
+			}
+			rule := model.FingerprintSourceRule{
+				SourceRuleID: id, SourcePath: sourcePath + "#" + id, ContentSHA256: sha256Hex(raw),
+				RawContent: string(raw), RawStructure: string(raw), ImportStatus: "executable",
+			}
+			if _, err := projectLocalRule(rule); err != nil {
+				problems = append(problems, fmt.Errorf("%s: rule %d (%s): %w", sourcePath, index+1, id, err))
+				continue
+			}
+			rules = append(rules, rule)
+		}
+	}
+	if err := errors.Join(problems...); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (localAdapter) Project(rule model.FingerprintSourceRule) (model.FingerprintRuleProjection, error) {
+	return projectLocalRule(rule)
+}
+
+func projectLocalRule(source model.FingerprintSourceRule) (model.FingerprintRuleProjection, error) {
+	var rule localRule
+	if err := yaml.Unmarshal([]byte(source.RawContent), &rule); err != nil {
+		return model.FingerprintRuleProjection{}, err
+	}
+	if !localRuleID.MatchString(strings.TrimSpace(rule.ID)) {
+		return model.FingerprintRuleProjection{}, errors.New("id must be 1-128 letters, digits, dots, dashes or underscores")
+	}
+	if strings.TrimSpace(rule.Product) == "" {
+		return model.FingerprintRuleProjection{}, errors.New("product is required")
+	}
+	protocol := strings.ToLower(strings.TrimSpace(rule.Protocol))
+	if _, ok := localRuleProtocols[protocol]; !ok {
+		return model.FingerprintRuleProjection{}, fmt.Errorf("protocol %q is not one of http, tcp, tls", rule.Protocol)
+	}
+	root, err := projectLocalGroup(localRuleGroup{Condition: rule.Condition, Matchers: rule.Matchers, Groups: rule.Groups}, protocol)
+	if err != nil {
+		return model.FingerprintRuleProjection{}, err
+	}
+	product := model.FingerprintProduct{CanonicalName: rule.Product, Vendor: rule.Vendor, CPE: rule.CPE, Role: strings.ToLower(strings.TrimSpace(rule.Role))}
+	return model.FingerprintRuleProjection{Product: product, Protocol: protocol, SoftMatch: rule.Soft, CPE: rule.CPE, Tags: rule.Tags, Root: root}, nil
+}
+
+func projectLocalGroup(group localRuleGroup, protocol string) (model.FingerprintMatchGroupProjection, error) {
+	condition := strings.ToLower(strings.TrimSpace(group.Condition))
+	switch condition {
+	case "", "all", "and":
+		condition = "all"
+	case "any", "or":
+		condition = "any"
+	default:
+		return model.FingerprintMatchGroupProjection{}, fmt.Errorf("condition %q is not all or any", group.Condition)
+	}
+	projected := model.FingerprintMatchGroupProjection{Operator: condition}
+	for index, upstream := range group.Matchers {
+		projectedMatcher, err := projectLocalMatcher(upstream, protocol)
+		if err != nil {
+			return model.FingerprintMatchGroupProjection{}, fmt.Errorf("matcher %d: %w", index+1, err)
+		}
+		projected.Matchers = append(projected.Matchers, projectedMatcher)
+	}
+	for _, child := range group.Groups {
+		projectedChild, err := projectLocalGroup(child, protocol)
+		if err != nil {
+			return model.FingerprintMatchGroupProjection{}, err
+		}
+		projected.Children = append(projected.Children, projectedChild)
+	}
+	if len(projected.Matchers) == 0 && len(projected.Children) == 0 {
+		return model.FingerprintMatchGroupProjection{}, errors.New("group has no matchers")
+	}
+	return projected, nil
+}
+
+func projectLocalMatcher(upstream localRuleMatcher, protocol string) (model.FingerprintMatcher, error) {
+	evidenceType := strings.ToLower(strings.TrimSpace(upstream.Type))
+	targets, known := localRuleTargets[evidenceType]
+	if !known {
+		return model.FingerprintMatcher{}, fmt.Errorf("unknown evidence type %q", upstream.Type)
+	}
+	if localEvidenceProtocol(evidenceType) != protocol {
+		return model.FingerprintMatcher{}, fmt.Errorf("evidence type %s is not collected for %s rules", evidenceType, protocol)
+	}
+	target := strings.ToLower(strings.TrimSpace(upstream.Target))
+	if targets == nil && target == "" {
+		return model.FingerprintMatcher{}, fmt.Errorf("evidence type %s requires a target", evidenceType)
+	}
+	if targets != nil && !containsString(targets, target) {
+		return model.FingerprintMatcher{}, fmt.Errorf("evidence type %s does not accept target %q", evidenceType, upstream.Target)
+	}
+	operator := strings.ToLower(strings.TrimSpace(upstream.Operator))
+	if _, ok := localRuleOperators[operator]; !ok {
+		return model.FingerprintMatcher{}, fmt.Errorf("unsupported operator %q", upstream.Operator)
+	}
+	if upstream.Value == "" {
+		return model.FingerprintMatcher{}, errors.New("value is required")
+	}
+	if operator == "regex" || operator == "regex_ci" {
+		if _, err := regexp.Compile(upstream.Value); err != nil {
+			return model.FingerprintMatcher{}, fmt.Errorf("invalid regex: %w", err)
+		}
+	} else if upstream.Version != "" {
+		return model.FingerprintMatcher{}, errors.New("version capture requires a regex operator")
+	}
+	projected := matcher(evidenceType, target, operator, upstream.Value)
+	projected.VersionCapture = upstream.Version
+	return projected, nil
+}
+
+func localEvidenceProtocol(evidenceType string) string {
+	switch evidenceType {
+	case "tcp_banner":
+		return "tcp"
+	case "tls_fingerprint":
+		return "tls"
+	default:
+		return "http"
+	}
+}
+
+// readLocalRuleSnapshot reads the .yaml and .yml files below directory into
+// a snapshot whose manifest describes their paths and digests. The content
+// digest over that list is the revision identity, so an unchanged directory
+// imports as the same revision.
+func readLocalRuleSnapshot(directory string) (VerifiedSnapshot, error) {
+	if strings.TrimSpace(directory) == "" {
+		return VerifiedSnapshot{}, errors.New("local fingerprint rule directory is not configured")
+	}
+	root, err := filepath.Abs(directory)
+	if err != nil {
+		return VerifiedSnapshot{}, err
+	}
+	files := make(map[string][]byte)
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if entry.IsDir() {
+			if path != root && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		extension := strings.ToLower(filepath.Ext(entry.Name()))
+		if !entry.Type().IsRegular() || (extension != ".yaml" && extension != ".yml") {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() > maxLocalRuleFileBytes {
+			return fmt.Errorf("local rule file %s exceeds %d bytes", path, maxLocalRuleFileBytes)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(relative)] = content
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return VerifiedSnapshot{}, fmt.Errorf("local fingerprint rule directory %s does not exist", root)
+	}
+	if err != nil {
+		return VerifiedSnapshot{}, fmt.Errorf("read local fingerprint rules: %w", err)
+	}
+	if len(files) == 0 {
+		return VerifiedSnapshot{}, fmt.Errorf("%w in %s", ErrNoLocalRules, root)
+	}
+	manifest := SourceManifest{SourceKey: LocalSourceKey, RepositoryURL: "file://" + filepath.ToSlash(root), License: "local"}
+	for path, content := range files {
+		manifest.Files = append(manifest.Files, ManifestFile{Path: path, SHA256: sha256Hex(content)})
+	}
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+	digest := sha256.New()
+	for _, file := range manifest.Files {
+		fmt.Fprintf(digest, "%s\x00%s\n", file.Path, file.SHA256)
+	}
+	manifest.ArchiveSHA256 = hex.EncodeToString(digest.Sum(nil))
+	manifest.Commit = "local-" + manifest.ArchiveSHA256[:12]
+	return VerifiedSnapshot{Manifest: manifest, Files: files}, nil
+}
+
+// importLocal turns the current local rule directory into a fingerprint
+// import revision. It shares projection, storage and activation with the
+// embedded sources; only the snapshot comes from disk.
+func (registry *Registry) importLocal(ctx context.Context) (model.FingerprintImport, error) {
+	if err := ctx.Err(); err != nil {
+		return model.FingerprintImport{}, err
+	}
+	snapshot, err := readLocalRuleSnapshot(registry.LocalRulesDir)
+	if err != nil {
+		return model.FingerprintImport{}, err
+	}
+	adapter := localAdapter{}
+	rules, err := adapter.Adapt(snapshot)
+	if err != nil {
+		return model.FingerprintImport{}, fmt.Errorf("invalid local fingerprint rules:\n%w", err)
+	}
+	projections, err := projectExecutableRules(adapter, rules)
+	if err != nil {
+		return model.FingerprintImport{}, fmt.Errorf("normalize fingerprint source %s: %w", LocalSourceKey, err)
+	}
+	source := snapshot.Manifest
+	source.ExpectedStats = RuleStats{RuleTotal: len(rules), ExecutableTotal: len(rules)}
+	manifestJSON, err := CanonicalManifestJSON(source)
+	if err != nil {
+		return model.FingerprintImport{}, err
+	}
+	return storage.ImportFingerprintBatch(registry.DB, storage.FingerprintImportBatch{
+		Source: model.FingerprintSource{SourceKey: LocalSourceKey, RepositoryURL: source.RepositoryURL, License: source.License, Status: "enabled"},
+		Import: model.FingerprintImport{
+			Commit:                source.Commit,
+			ContentSHA256:         source.ArchiveSHA256,
+			UpstreamContentSHA256: source.ArchiveSHA256,
+			AdapterVersion:        adapterVersion(adapter),
+			ManifestJSON:          manifestJSON,
+			RuleTotal:             len(rules),
+			ExecutableTotal:       len(rules),
+		},
+		Rules:       rules,
+		Projections: projections,
+	})
+}
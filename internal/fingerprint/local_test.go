@@ -0,0 +1,127 @@
+package fingerprint
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const localPortalRules = `rules:
+  - id: acme-portal
+    product: Acme Portal
+    vendor: Acme
+    protocol: http
+    tags: [internal]
+    condition: any
+    matchers:
+      - type: html_title
+        operator: contains
+        value: Acme Portal
+      - type: http_header
+        target: X-Acme-Version
+        operator: regex
+        value: '^([0-9.]+)$'
+        version: '$1'
+  - id: acme-agent
+    product: Acme Agent
+    protocol: tcp
+    matchers:
+      - type: tcp_banner
+        operator: regex
+        value: '^ACME-AGENT ([0-9.]+)'
+        version: '$1'
+`
+
+func TestLocalSourceImportsUserRulesAsVersionedRevisions(t *testing.T) {
+	db := openFingerprintTestDB(t)
+	directory := t.TempDir()
+	if err := os.WriteFile(filepath.Join(directory, "acme.yaml"), []byte(localPortalRules), 0600); err != nil {
+		t.Fatal(err)
+	}
+	registry := NewRegistry(db, Manifest{}, nil, nil)
+	registry.LocalRulesDir = directory
+	var output bytes.Buffer
+	if err := RunCLI(context.Background(), registry, []string{"import", "--source", LocalSourceKey}, &output); err != nil {
+		t.Fatalf("import local source: %v", err)
+	}
+	first, err := registry.Import(context.Background(), LocalSourceKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output.String(), "Fingerprint source local revision "+first.Commit) || first.RuleTotal != 2 || first.ExecutableTotal != 2 {
+		t.Fatalf("import=%#v output=%q", first, output.String())
+	}
+
+	engine, err := LoadActiveEngine(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches := engine.Match(Evidence{Protocol: "https", Headers: map[string]string{"X-Acme-Version": "4.2.1"}})
+	if len(matches) != 1 || matches[0].Product != "acme portal" || matches[0].Version != "4.2.1" || matches[0].SourceKey != LocalSourceKey {
+		t.Fatalf("HTTP matches = %#v", matches)
+	}
+	if matches := engine.Match(NewBannerEvidence("ACME-AGENT 7.0\r\n", false)); !hasProduct(matches, "acme agent") {
+		t.Fatalf("TCP matches = %#v", matches)
+	}
+
+	if err := os.WriteFile(filepath.Join(directory, "acme.yaml"), []byte(strings.Replace(localPortalRules, "Acme Portal\n", "Acme Portal NG\n", 1)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	second, err := registry.Import(context.Background(), LocalSourceKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ID == first.ID || second.Commit == first.Commit {
+		t.Fatalf("edited rules reused revision %s", first.Commit)
+	}
+}
+
+func TestLocalSourceRejectsInvalidRulesWithTheirLocation(t *testing.T) {
+	db := openFingerprintTestDB(t)
+	directory := t.TempDir()
+	invalid := `rules:
+  - id: broken
+    product: Broken
+    protocol: http
+    matchers:
+      - type: tcp_banner
+        operator: contains
+        value: nope
+`
+	if err := os.WriteFile(filepath.Join(directory, "broken.yml"), []byte(invalid), 0600); err != nil {
+		t.Fatal(err)
+	}
+	registry := NewRegistry(db, Manifest{}, nil, nil)
+	registry.LocalRulesDir = directory
+	_, err := registry.Import(context.Background(), LocalSourceKey, "")
+	if err == nil || !strings.Contains(err.Error(), "broken.yml: rule 1 (broken)") || !strings.Contains(err.Error(), "not collected for http rules") {
+		t.Fatalf("err = %v", err)
+	}
+	if _, err := registry.Import(context.Background(), LocalSourceKey, "recovery.tar.gz"); err == nil {
+		t.Fatal("local source accepted a recovery archive")
+	}
+	registry.LocalRulesDir = filepath.Join(directory, "missing")
+	if _, err := registry.Import(context.Background(), LocalSourceKey, ""); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("missing directory err = %v", err)
+	}
+}
+
+func TestFullUpgradeSkipsAnEmptyLocalDirectory(t *testing.T) {
+	db := openFingerprintTestDB(t)
+	registry := NewRegistry(db, Manifest{}, nil, nil)
+	registry.LocalRulesDir = t.TempDir()
+	var output bytes.Buffer
+	if err := RunCLI(context.Background(), registry, []string{"upgrade"}, &output); err != nil {
+		t.Fatalf("full upgrade with an empty local directory: %v", err)
+	}
+	if !strings.Contains(output.String(), "Fingerprint source local skipped: no .yaml rule files in "+registry.LocalRulesDir) {
+		t.Fatalf("output = %q", output.String())
+	}
+	if _, err := registry.Import(context.Background(), LocalSourceKey, ""); !errors.Is(err, ErrNoLocalRules) {
+		t.Fatalf("explicit local import err = %v", err)
+	}
+}
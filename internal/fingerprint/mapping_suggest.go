@@ -0,0 +1,202 @@
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"golandproject/yscan/internal/model"
+	"golandproject/yscan/internal/planner"
+	"golandproject/yscan/internal/storage"
+)
+
+const (
+	mappingSuggestUsage = "usage: yscan fingerprint mapping suggest --templates <root> [--product <key>] [--output <path>]"
+	// mappingReviewPending marks a suggestion nobody has reviewed yet. Import
+	// accepts only approved mappings, so a reviewer must flip each one.
+	mappingReviewPending = "pending"
+)
+
+// suggestedMappingManifest has the layout `mapping import` reads; Rejected
+// and the per-mapping review fields are ignored on import.
+type suggestedMappingManifest struct {
+	Revision            string                     `json:"revision"`
+	TemplateSetRevision string                     `json:"template_set_revision"`
+	Mappings            []suggestedTemplateMapping `json:"mappings"`
+	Rejected            []rejectedTemplateMapping  `json:"rejected,omitempty"`
+}
+
+type suggestedTemplateMapping struct {
+	ProductKey        string   `json:"product_key"`
+	SourceKey         string   `json:"source_key,omitempty"`
+	SourceRuleID      string   `json:"source_rule_id,omitempty"`
+	TemplateID        string   `json:"template_id"`
+	TemplatePath      string   `json:"template_path"`
+	TemplateSHA256    string   `json:"template_sha256"`
+	SideEffect        string   `json:"side_effect"`
+	ReviewStatus      string   `json:"review_status"`
+	Protocols         []string `json:"protocols,omitempty"`
+	RequestSummary    string   `json:"request_summary"`
+	ObservedEndpoints int      `json:"observed_endpoints"`
+	CarriedOver       bool     `json:"carried_over,omitempty"`
+}
+
+type rejectedTemplateMapping struct {
+	ProductKey   string `json:"product_key"`
+	TemplateID   string `json:"template_id"`
+	TemplatePath string `json:"template_path"`
+	Reason       string `json:"reason"`
+}
+
+func runMappingSuggestCLI(db *sql.DB, args []string, output io.Writer) error {
+	var templatesRoot, productKey, outputPath string
+	for index := 0; index < len(args); index++ {
+		if index+1 >= len(args) {
+			return errors.New(mappingSuggestUsage)
+		}
+		value := strings.TrimSpace(args[index+1])
+		switch args[index] {
+		case "--templates":
+			templatesRoot = value
+		case "--product":
+			productKey = value
+		case "--output":
+			outputPath = value
+		default:
+			return errors.New(mappingSuggestUsage)
+		}
+		index++
+	}
+	if templatesRoot == "" {
+		return errors.New(mappingSuggestUsage)
+	}
+	manifest, err := suggestTemplateMappings(db, templatesRoot, productKey)
+	if err != nil {
+		return err
+	}
+	if outputPath == "" {
+		encoder := json.NewEncoder(output)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(manifest)
+	}
+	raw, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputPath, append(raw, '\n'), 0644); err != nil {
+		return fmt.Errorf("write mapping manifest: %w", err)
+	}
+	pending, carried := 0, 0
+	for _, mapping := range manifest.Mappings {
+		if mapping.CarriedOver {
+			carried++
+		} else {
+			pending++
+		}
+	}
+	_, err = fmt.Fprintf(output, "Wrote %s: %d suggested, %d carried over, %d rejected; set review_status to approved on reviewed mappings before import\n", outputPath, pending, carried, len(manifest.Rejected))
+	return err
+}
+
+// suggestTemplateMappings proposes a mapping for every safe indexed template
+// that selects a product concluded by a past run. The enabled mappings of the
+// active revision are carried over, because importing the manifest replaces
+// that revision; any whose template changed since review is rejected instead.
+func suggestTemplateMappings(db *sql.DB, templatesRoot, productKey string) (suggestedMappingManifest, error) {
+	index, err := planner.BuildNucleiTemplateIndex(templatesRoot)
+	if err != nil {
+		return suggestedMappingManifest{}, err
+	}
+	observed, err := storage.ListObservedFingerprintProducts(db, productKey)
+	if err != nil {
+		return suggestedMappingManifest{}, err
+	}
+	if len(observed) == 0 {
+		if strings.TrimSpace(productKey) != "" {
+			return suggestedMappingManifest{}, fmt.Errorf("product %s has not been concluded by any run", productKey)
+		}
+		return suggestedMappingManifest{}, errors.New("no fingerprint conclusions recorded yet")
+	}
+	active, err := storage.ListActiveTemplateMappings(db)
+	if err != nil {
+		return suggestedMappingManifest{}, err
+	}
+
+	manifest := suggestedMappingManifest{TemplateSetRevision: index.Revision, Mappings: make([]suggestedTemplateMapping, 0)}
+	byKey := make(map[string]int)
+	rejected := make(map[string]struct{})
+	key := func(product, path string) string { return strings.ToLower(product) + "\x00" + path }
+	reject := func(product, templateID, path, reason string) {
+		if _, duplicate := rejected[key(product, path)]; duplicate {
+			return
+		}
+		if _, mapped := byKey[key(product, path)]; mapped {
+			return
+		}
+		rejected[key(product, path)] = struct{}{}
+		manifest.Rejected = append(manifest.Rejected, rejectedTemplateMapping{ProductKey: product, TemplateID: templateID, TemplatePath: path, Reason: reason})
+	}
+	for _, mapping := range active {
+		content, err := verifyMappingTemplate(index.Root, mapping)
+		if err != nil {
+			reject(mapping.ProductKey, mapping.TemplateID, mapping.TemplatePath, err.Error())
+			continue
+		}
+		byKey[key(mapping.ProductKey, mapping.TemplatePath)] = len(manifest.Mappings)
+		manifest.Mappings = append(manifest.Mappings, suggestedTemplateMapping{
+			ProductKey: mapping.ProductKey, SourceKey: mapping.SourceKey, SourceRuleID: mapping.SourceRuleID,
+			TemplateID: mapping.TemplateID, TemplatePath: mapping.TemplatePath, TemplateSHA256: mapping.TemplateSHA256,
+			SideEffect: mapping.SideEffect, ReviewStatus: mapping.ReviewStatus,
+			RequestSummary: planner.SummarizeNucleiTemplateRequests(content), CarriedOver: true,
+		})
+	}
+	for _, product := range observed {
+		for _, entry := range index.Select(product.ProductKey, product.CPE, product.Protocol) {
+			if existing, ok := byKey[key(product.ProductKey, entry.Path)]; ok {
+				manifest.Mappings[existing].ObservedEndpoints += product.Endpoints
+				continue
+			}
+			candidate := model.FingerprintTemplateMapping{ProductKey: product.ProductKey, TemplateID: entry.TemplateID, TemplatePath: entry.Path, TemplateSHA256: entry.SHA256}
+			content, err := verifyMappingTemplate(index.Root, candidate)
+			if err != nil {
+				reject(product.ProductKey, entry.TemplateID, entry.Path, err.Error())
+				continue
+			}
+			byKey[key(product.ProductKey, entry.Path)] = len(manifest.Mappings)
+			manifest.Mappings = append(manifest.Mappings, suggestedTemplateMapping{
+				ProductKey: product.ProductKey, TemplateID: entry.TemplateID, TemplatePath: entry.Path, TemplateSHA256: entry.SHA256,
+				SideEffect: "read_only", ReviewStatus: mappingReviewPending, Protocols: entry.Protocols,
+				RequestSummary: planner.SummarizeNucleiTemplateRequests(content), ObservedEndpoints: product.Endpoints,
+			})
+		}
+		for _, filtered := range index.FilteredTemplates(product.ProductKey, product.CPE, product.Protocol) {
+			reject(product.ProductKey, filtered.TemplateID, filtered.Path, filtered.Reason)
+		}
+	}
+	sort.SliceStable(manifest.Mappings, func(left, right int) bool {
+		if manifest.Mappings[left].ProductKey != manifest.Mappings[right].ProductKey {
+			return manifest.Mappings[left].ProductKey < manifest.Mappings[right].ProductKey
+		}
+		return manifest.Mappings[left].TemplatePath < manifest.Mappings[right].TemplatePath
+	})
+	sort.SliceStable(manifest.Rejected, func(left, right int) bool {
+		if manifest.Rejected[left].ProductKey != manifest.Rejected[right].ProductKey {
+			return manifest.Rejected[left].ProductKey < manifest.Rejected[right].ProductKey
+		}
+		return manifest.Rejected[left].TemplatePath < manifest.Rejected[right].TemplatePath
+	})
+	digest, err := json.Marshal(manifest.Mappings)
+	if err != nil {
+		return suggestedMappingManifest{}, err
+	}
+	sum := sha256.Sum256(append([]byte(index.Revision+"\n"), digest...))
+	manifest.Revision = "suggested-" + hex.EncodeToString(sum[:6])
+	return manifest, nil
+}
@@ -0,0 +1,174 @@
+package fingerprint
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golandproject/yscan/internal/model"
+	"golandproject/yscan/internal/planner"
+	"golandproject/yscan/internal/storage"
+)
+
+func TestMappingSuggestWritesReviewReadyManifestForObservedProducts(t *testing.T) {
+	root := t.TempDir()
+	for relative, content := range map[string]string{
+		"http/jenkins-api.yaml": `
+id: jenkins-api-exposure
+info:
+  name: Jenkins API Exposure
+  severity: medium
+  metadata: {vendor: jenkins, product: jenkins}
+  tags: jenkins,exposure
+http:
+  - method: GET
+    path: ["{{BaseURL}}/api/json"]
+`,
+		"unsafe/jenkins-script.yaml": `
+id: jenkins-script-console
+info:
+  name: Jenkins Script Console
+  severity: critical
+  metadata: {product: jenkins}
+  tags: jenkins,vuln
+http:
+  - method: POST
+    path: ["{{BaseURL}}/script"]
+`,
+		"http/wordpress-readme.yaml": `
+id: wordpress-readme
+info:
+  name: WordPress Readme
+  severity: info
+  metadata: {product: wordpress}
+  tags: wordpress,exposure
+http:
+  - method: GET
+    path: ["{{BaseURL}}/readme.html"]
+`,
+		"network/redis.yaml": `
+id: exposed-redis
+info:
+  name: Redis Exposure
+  severity: high
+  tags: network,redis,unauth,exposure
+tcp:
+  - inputs:
+      - data: "info\r\nquit\r\n"
+`,
+	} {
+		path := filepath.Join(root, filepath.FromSlash(relative))
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db, err := storage.InitDBAt(filepath.Join(t.TempDir(), "suggest.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	registry := &Registry{DB: db}
+	var output bytes.Buffer
+	if err := RunCLI(context.Background(), registry, []string{"mapping", "suggest", "--templates", root}, &output); err == nil || !strings.Contains(err.Error(), "no fingerprint conclusions") {
+		t.Fatalf("suggest without conclusions err=%v", err)
+	}
+
+	task, err := storage.CreateScanTask(db, model.ScanTask{Target: "192.168.92.0/24", ScanType: model.ScanTypeIP, Mode: model.ScanTaskModeOnce})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run, err := storage.CreateScanTaskRun(db, model.ScanTaskRun{ScanTaskID: task.ID, ScheduledFor: "2026-08-07T01:00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, conclusion := range []struct {
+		ip, protocol, product, status string
+		port                          int
+	}{
+		{"192.168.92.10", "http", "jenkins", "matched", 8080},
+		{"192.168.92.11", "http", "jenkins", "corroborated", 8080},
+		{"192.168.92.12", "tcp", "redis", "matched", 6379},
+		{"192.168.92.13", "http", "wordpress", "conflicted", 80},
+	} {
+		if _, err := db.Exec(`INSERT INTO asset_fingerprint_conclusions (scan_task_run_id, ip, port, protocol, product_key, conclusion_status, product_status) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			run.ID, conclusion.ip, conclusion.port, conclusion.protocol, conclusion.product, conclusion.status, conclusion.status); err != nil {
+			t.Fatal(err)
+		}
+	}
+	redisContent, err := os.ReadFile(filepath.Join(root, "network", "redis.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.ImportTemplateMappingBatch(db, storage.TemplateMappingBatch{
+		Import: model.TemplateMappingImport{Revision: "reviewed-v1", ContentSHA256: sha256Hex([]byte("reviewed-v1")), ManifestJSON: `{"revision":"reviewed-v1"}`},
+		Mappings: []model.FingerprintTemplateMapping{{
+			ProductKey: "redis", TemplateID: "exposed-redis", TemplatePath: "network/redis.yaml", TemplateSHA256: sha256Hex(redisContent),
+			TemplateSetRevision: "reviewed-v1", SideEffect: "read_only", ReviewStatus: "approved",
+		}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	manifestPath := filepath.Join(t.TempDir(), "suggested.json")
+	output.Reset()
+	if err := RunCLI(context.Background(), registry, []string{"mapping", "suggest", "--templates", root, "--output", manifestPath}, &output); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output.String(), "1 suggested, 1 carried over, 1 rejected") {
+		t.Fatalf("suggest output=%q", output.String())
+	}
+	raw, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest suggestedMappingManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	index, err := planner.BuildNucleiTemplateIndex(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(manifest.Revision, "suggested-") || manifest.TemplateSetRevision != index.Revision || len(manifest.Mappings) != 2 {
+		t.Fatalf("manifest=%s", raw)
+	}
+	jenkins, redis := manifest.Mappings[0], manifest.Mappings[1]
+	jenkinsContent, _ := os.ReadFile(filepath.Join(root, "http", "jenkins-api.yaml"))
+	if jenkins.ProductKey != "jenkins" || jenkins.TemplatePath != "http/jenkins-api.yaml" || jenkins.TemplateSHA256 != sha256Hex(jenkinsContent) ||
+		jenkins.ReviewStatus != mappingReviewPending || jenkins.SideEffect != "read_only" || jenkins.RequestSummary != "GET /api/json" || jenkins.ObservedEndpoints != 2 || jenkins.CarriedOver {
+		t.Fatalf("jenkins suggestion=%#v", jenkins)
+	}
+	if redis.ProductKey != "redis" || redis.ReviewStatus != "approved" || !redis.CarriedOver || redis.ObservedEndpoints != 1 || redis.RequestSummary != `TCP send "info\r\nquit\r\n"` {
+		t.Fatalf("redis carried mapping=%#v", redis)
+	}
+	if len(manifest.Rejected) != 1 || manifest.Rejected[0].TemplateID != "jenkins-script-console" || manifest.Rejected[0].Reason != planner.NucleiFilterReasonUnsupportedStructure {
+		t.Fatalf("rejected=%#v", manifest.Rejected)
+	}
+
+	if err := RunCLI(context.Background(), registry, []string{"mapping", "import", "--manifest", manifestPath, "--templates", root}, &output); err == nil {
+		t.Fatal("unreviewed suggestion was imported")
+	}
+	reviewed := strings.Replace(string(raw), `"review_status": "pending"`, `"review_status": "approved"`, 1)
+	if err := os.WriteFile(manifestPath, []byte(reviewed), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := RunCLI(context.Background(), registry, []string{"mapping", "import", "--manifest", manifestPath, "--templates", root}, &output); err != nil {
+		t.Fatal(err)
+	}
+	active, err := storage.ListActiveTemplateMappings(db)
+	if err != nil || len(active) != 2 || active[0].TemplateSetRevision != index.Revision {
+		t.Fatalf("active mappings=%#v err=%v", active, err)
+	}
+
+	output.Reset()
+	if err := RunCLI(context.Background(), registry, []string{"mapping", "suggest", "--templates", root, "--product", "wordpress"}, &output); err == nil || !strings.Contains(err.Error(), "has not been concluded") {
+		t.Fatalf("conflicted-only product err=%v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package fingerprint
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"golandproject/yscan/internal/model"
+	"golandproject/yscan/internal/storage"
+)
+
+const overrideUsage = "usage: yscan fingerprint override list [--all] | suppress [--product <key>] [--rule <source_key>:<rule_id>] [--ip <ip>] [--port <port>] --reason <text> [--expires <time>] | assert --ip <ip> --port <port> --product <key> [--protocol <name>] [--version <v>] [--cpe <cpe>] --reason <text> [--expires <time>] | revoke --id <id>"
+
+// runOverrideCLI manages operator suppressions and assertions. Overrides
+// shape conclusions of later runs only; finished runs are never rewritten.
+func runOverrideCLI(db *sql.DB, args []string, output io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(overrideUsage)
+	}
+	switch args[0] {
+	case "list":
+		includeInactive := false
+		if len(args) > 1 {
+			if len(args) != 2 || args[1] != "--all" {
+				return errors.New(overrideUsage)
+			}
+			includeInactive = true
+		}
+		overrides, err := storage.ListFingerprintOverrides(db, includeInactive)
+		if err != nil {
+			return err
+		}
+		if len(overrides) == 0 {
+			_, err := fmt.Fprintln(output, "No fingerprint overrides.")
+			return err
+		}
+		for _, override := range overrides {
+			if _, err := fmt.Fprintln(output, formatOverride(override)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "suppress", "assert":
+		override, err := parseOverrideArgs(args[0], args[1:], time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		override, err = storage.CreateFingerprintOverride(db, override)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(output, "Created %s\n", formatOverride(override))
+		return err
+	case "revoke":
+		if len(args) != 3 || args[1] != "--id" {
+			return errors.New("usage: yscan fingerprint override revoke --id <id>")
+		}
+		id, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil || id <= 0 {
+			return errors.New("override id must be a positive integer")
+		}
+		override, err := storage.RevokeFingerprintOverride(db, id)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(output, "Revoked %s\n", formatOverride(override))
+		return err
+	default:
+		return errors.New(overrideUsage)
+	}
+}
+
+func parseOverrideArgs(kind string, args []string, now time.Time) (model.FingerprintOverride, error) {
+	override := model.FingerprintOverride{Kind: kind}
+	for index := 0; index < len(args); index++ {
+		if index+1 >= len(args) {
+			return model.FingerprintOverride{}, fmt.Errorf("%s requires a value", args[index])
+		}
+		value := strings.TrimSpace(args[index+1])
+		switch args[index] {
+		case "--product":
+			override.ProductKey = value
+		case "--rule":
+			sourceKey, ruleID, ok := strings.Cut(value, ":")
+			if !ok || strings.TrimSpace(sourceKey) == "" || strings.TrimSpace(ruleID) == "" {
+				return model.FingerprintOverride{}, errors.New("--rule must be <source_key>:<rule_id>")
+			}
+			override.SourceKey, override.SourceRuleID = sourceKey, ruleID
+		case "--ip":
+			override.IP = value
+		case "--port":
+			port, err := strconv.Atoi(value)
+			if err != nil || port < 1 || port > 65535 {
+				return model.FingerprintOverride{}, errors.New("--port must be between 1 and 65535")
+			}
+			override.Port = port
+		case "--protocol":
+			override.Protocol = value
+		case "--version":
+			override.Version = value
+		case "--cpe":
+			override.CPE = value
+		case "--reason":
+			override.Reason = value
+		case "--expires":
+			expires, err := parseOverrideExpiry(value, now)
+			if err != nil {
+				return model.FingerprintOverride{}, err
+			}
+			override.ExpiresAt = expires
+		default:
+			return model.FingerprintOverride{}, fmt.Errorf("unsupported flag: %s", args[index])
+		}
+		index++
+	}
+	return override, nil
+}
+
+// parseOverrideExpiry accepts an RFC 3339 time, a calendar date (expiring at
+// its start in UTC) or a duration from now such as 720h.
+func parseOverrideExpiry(value string, now time.Time) (string, error) {
+	if expires, err := time.Parse(time.RFC3339, value); err == nil {
+		return expires.UTC().Format(time.RFC3339), nil
+	}
+	if expires, err := time.Parse(time.DateOnly, value); err == nil {
+		return expires.UTC().Format(time.RFC3339), nil
+	}
+	if duration, err := time.ParseDuration(value); err == nil && duration > 0 {
+		return now.Add(duration).UTC().Format(time.RFC3339), nil
+	}
+	return "", errors.New("--expires must be an RFC 3339 time, a YYYY-MM-DD date or a positive duration")
+}
+
+func formatOverride(override model.FingerprintOverride) string {
+	scope := "all endpoints"
+	switch {
+	case override.IP != "" && override.Port > 0:
+		scope = fmt.Sprintf("%s:%d", override.IP, override.Port)
+	case override.IP != "":
+		scope = override.IP
+	case override.Port > 0:
+		scope = fmt.Sprintf("port %d", override.Port)
+	}
+	subject := override.ProductKey
+	if override.SourceRuleID != "" {
+		subject = strings.TrimSpace(subject + " rule=" + override.SourceKey + ":" + override.SourceRuleID)
+	}
+	if override.Version != "" {
+		subject += " version=" + override.Version
+	}
+	line := fmt.Sprintf("override %d %s %s on %s reason=%q", override.ID, override.Kind, subject, scope, override.Reason)
+	if override.ExpiresAt != "" {
+		line += " expires=" + override.ExpiresAt
+	}
+	if override.RevokedAt != "" {
+		line += " revoked=" + override.RevokedAt
+	}
+	return line
+}
@@ -0,0 +1,35 @@
+package fingerprint
+
+import (
+	"testing"
+	"time"
+
+	"golandproject/yscan/internal/model"
+)
+
+func TestParseOverrideArgsAcceptsRuleScopeAndRelativeExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	override, err := parseOverrideArgs(model.FingerprintOverrideSuppress, []string{"--rule", "nuclei:weblogic-detect", "--ip", "10.0.0.5", "--port", "7001", "--reason", "portal error page", "--expires", "720h"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if override.SourceKey != "nuclei" || override.SourceRuleID != "weblogic-detect" || override.Port != 7001 || override.ExpiresAt != "2026-10-31T08:00:00Z" {
+		t.Fatalf("override = %#v", override)
+	}
+	if expires, err := parseOverrideExpiry("2027-01-02", now); err != nil || expires != "2027-01-02T00:00:00Z" {
+		t.Fatalf("date expiry = %q err=%v", expires, err)
+	}
+	for _, args := range [][]string{
+		{"--rule", "weblogic-detect", "--reason", "x"},
+		{"--port", "0", "--reason", "x"},
+		{"--expires", "-1h"},
+		{"--reason"},
+	} {
+		if _, err := parseOverrideArgs(model.FingerprintOverrideSuppress, args, now); err == nil {
+			t.Fatalf("args %q were accepted", args)
+		}
+	}
+	if line := formatOverride(model.FingerprintOverride{ID: 3, Kind: model.FingerprintOverrideAssert, ProductKey: "acme portal", Version: "2", IP: "10.0.0.9", Port: 8080, Reason: "internal app"}); line != `override 3 assert acme portal version=2 on 10.0.0.9:8080 reason="internal app"` {
+		t.Fatalf("formatted override = %q", line)
+	}
+}
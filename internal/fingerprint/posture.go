@@ -0,0 +1,236 @@
+package fingerprint
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Web posture checks. They describe configuration weaknesses of a web
+// endpoint, not vulnerabilities, and never trigger additional requests.
+const (
+	WeaknessMissingHSTS               = "missing_hsts"
+	WeaknessMissingCSP                = "missing_csp"
+	WeaknessMissingFrameOptions       = "missing_x_frame_options"
+	WeaknessMissingContentTypeOptions = "missing_x_content_type_options"
+	WeaknessCookieWithoutSecure       = "cookie_without_secure"
+	WeaknessCookieWithoutHTTPOnly     = "cookie_without_httponly"
+	WeaknessCookieWithoutSameSite     = "cookie_without_samesite"
+	WeaknessDirectoryListing          = "directory_listing"
+	WeaknessDefaultPage               = "default_page"
+	WeaknessServerVersionDisclosure   = "server_version_disclosure"
+
+	WeaknessSeverityInfo   = "info"
+	WeaknessSeverityLow    = "low"
+	WeaknessSeverityMedium = "medium"
+)
+
+// minimumHSTSMaxAge is 180 days, the shortest max-age browsers' preload
+// lists and most baselines accept.
+const minimumHSTSMaxAge = 180 * 24 * 60 * 60
+
+// Weakness is one posture finding. Subject distinguishes repeated checks on
+// the same endpoint (a cookie or header name, a default page product) and is
+// part of the finding identity; Detail is display-only.
+type Weakness struct {
+	Check    string
+	Subject  string
+	Severity string
+	Path     string
+	Detail   string
+}
+
+var (
+	setCookieBoundary = regexp.MustCompile(`,\s*[!#$%&'*+\-.^_|~0-9A-Za-z]+=`)
+	versionToken      = regexp.MustCompile(`\d+\.\d+`)
+	directoryListing  = []string{"index of /", "directory listing for /", "[to parent directory]"}
+	versionHeaders    = []string{"Server", "X-Powered-By", "X-AspNet-Version", "X-AspNetMvc-Version", "X-Generator"}
+)
+
+// defaultPages maps a title or body marker of a stock installation page to
+// the product that serves it.
+var defaultPages = []struct {
+	marker  string
+	product string
+}{
+	{"welcome to nginx!", "nginx"},
+	{"apache2 ubuntu default page", "apache"},
+	{"apache2 debian default page", "apache"},
+	{"test page for the apache http server", "apache"},
+	{"<h1>it works!</h1>", "apache"},
+	{"iis windows server", "iis"},
+	{"if you're seeing this, you've successfully installed tomcat", "tomcat"},
+	{"welcome to jboss", "jboss"},
+	{"welcome to wildfly", "wildfly"},
+	{"welcome to caddy", "caddy"},
+	{"lighttpd placeholder page", "lighttpd"},
+}
+
+// AnalyzeWebPosture evaluates security headers, cookie flags, directory
+// listings, stock welcome pages and version-bearing headers in one captured
+// response. Header checks are skipped when the capture was truncated, since
+// an absent header may simply not have been kept.
+func AnalyzeWebPosture(protocol, path string, evidence Evidence) []Weakness {
+	protocol = strings.ToLower(strings.TrimSpace(protocol))
+	if (protocol != "http" && protocol != "https") || evidence.StatusCode == 0 {
+		return nil
+	}
+	if path == "" {
+		path = "/"
+	}
+	weaknesses := make([]Weakness, 0)
+	add := func(check, subject, severity, detail string) {
+		weaknesses = append(weaknesses, Weakness{Check: check, Subject: subject, Severity: severity, Path: path, Detail: detail})
+	}
+	success := evidence.StatusCode >= 200 && evidence.StatusCode < 300
+	html := isHTMLResponse(evidence)
+	if !evidence.HeaderTruncated {
+		if protocol == "https" && evidence.StatusCode < 500 {
+			value := headerValue(evidence.Headers, "Strict-Transport-Security")
+			if value == "" {
+				add(WeaknessMissingHSTS, "", WeaknessSeverityLow, "Strict-Transport-Security header absent")
+			} else if maxAge, ok := hstsMaxAge(value); !ok || maxAge < minimumHSTSMaxAge {
+				add(WeaknessMissingHSTS, "", WeaknessSeverityLow, "Strict-Transport-Security max-age below 180 days")
+			}
+		}
+		csp := headerValue(evidence.Headers, "Content-Security-Policy")
+		if success && html {
+			if csp == "" {
+				add(WeaknessMissingCSP, "", WeaknessSeverityInfo, "Content-Security-Policy header absent")
+			}
+			if headerValue(evidence.Headers, "X-Frame-Options") == "" && !strings.Contains(strings.ToLower(csp), "frame-ancestors") {
+				add(WeaknessMissingFrameOptions, "", WeaknessSeverityLow, "neither X-Frame-Options nor CSP frame-ancestors is set")
+			}
+		}
+		if success && !strings.EqualFold(strings.TrimSpace(headerValue(evidence.Headers, "X-Content-Type-Options")), "nosniff") {
+			add(WeaknessMissingContentTypeOptions, "", WeaknessSeverityInfo, "X-Content-Type-Options: nosniff absent")
+		}
+		for _, name := range versionHeaders {
+			value := headerValue(evidence.Headers, name)
+			if value != "" && versionToken.MatchString(value) {
+				add(WeaknessServerVersionDisclosure, strings.ToLower(name), WeaknessSeverityLow, name+": "+value)
+			}
+		}
+		for _, cookie := range setCookies(headerValue(evidence.Headers, "Set-Cookie")) {
+			if protocol == "https" && !cookie.Secure {
+				add(WeaknessCookieWithoutSecure, cookie.Name, WeaknessSeverityLow, "cookie "+cookie.Name+" lacks Secure")
+			}
+			if !cookie.HttpOnly {
+				add(WeaknessCookieWithoutHTTPOnly, cookie.Name, WeaknessSeverityInfo, "cookie "+cookie.Name+" lacks HttpOnly")
+			}
+			if cookie.SameSite == 0 || cookie.SameSite == http.SameSiteDefaultMode {
+				add(WeaknessCookieWithoutSameSite, cookie.Name, WeaknessSeverityInfo, "cookie "+cookie.Name+" lacks SameSite")
+			}
+		}
+	}
+	if success && html {
+		title := strings.ToLower(evidence.Title)
+		body := strings.ToLower(evidence.Body)
+		for _, marker := range directoryListing {
+			if strings.HasPrefix(title, marker) || strings.Contains(body, "<h1>"+marker) || (marker == "[to parent directory]" && strings.Contains(body, marker)) {
+				add(WeaknessDirectoryListing, path, WeaknessSeverityMedium, "directory index is browsable")
+				break
+			}
+		}
+		for _, page := range defaultPages {
+			if strings.Contains(title, page.marker) || strings.Contains(body, page.marker) {
+				add(WeaknessDefaultPage, page.product, WeaknessSeverityLow, "stock "+page.product+" welcome page is served")
+				break
+			}
+		}
+	}
+	return weaknesses
+}
+
+// MergeWeaknesses keeps the first observation of each check and subject, so
+// a header missing on every crawled page is reported once per endpoint.
+func MergeWeaknesses(sets ...[]Weakness) []Weakness {
+	merged := make([]Weakness, 0)
+	seen := make(map[string]struct{})
+	for _, set := range sets {
+		for _, weakness := range set {
+			key := weakness.Check + "\x00" + weakness.Subject
+			if _, duplicate := seen[key]; duplicate {
+				continue
+			}
+			seen[key] = struct{}{}
+			merged = append(merged, weakness)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].Check != merged[j].Check {
+			return merged[i].Check < merged[j].Check
+		}
+		return merged[i].Subject < merged[j].Subject
+	})
+	return merged
+}
+
+// AnalyzeEndpointPosture evaluates the root response and every crawled page
+// of one endpoint and merges the findings.
+func AnalyzeEndpointPosture(root CollectedEvidence, pages []CrawledPage) []Weakness {
+	rootPath := "/"
+	if parsed, err := url.Parse(root.Evidence.URL); err == nil {
+		rootPath = crawlPath(parsed)
+	}
+	sets := make([][]Weakness, 0, len(pages)+1)
+	sets = append(sets, AnalyzeWebPosture(root.Protocol, rootPath, root.Evidence))
+	for _, page := range pages {
+		sets = append(sets, AnalyzeWebPosture(root.Protocol, page.Path, page.Evidence))
+	}
+	return MergeWeaknesses(sets...)
+}
+
+func isHTMLResponse(evidence Evidence) bool {
+	contentType := strings.ToLower(headerValue(evidence.Headers, "Content-Type"))
+	if contentType != "" {
+		return strings.Contains(contentType, "html")
+	}
+	return strings.HasPrefix(strings.TrimSpace(evidence.Body), "<")
+}
+
+func hstsMaxAge(value string) (int, bool) {
+	for _, directive := range strings.Split(value, ";") {
+		name, argument, found := strings.Cut(strings.TrimSpace(directive), "=")
+		if !found || !strings.EqualFold(strings.TrimSpace(name), "max-age") {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.Trim(strings.TrimSpace(argument), `"`))
+		return seconds, err == nil
+	}
+	return 0, false
+}
+
+// setCookies splits the comma-joined Set-Cookie capture back into cookies.
+// A comma only starts a new cookie when a name=value pair follows, so the
+// comma inside an Expires date stays with its cookie.
+func setCookies(joined string) []*http.Cookie {
+	if strings.TrimSpace(joined) == "" {
+		return nil
+	}
+	lines := make([]string, 0, 1)
+	start := 0
+	for _, boundary := range setCookieBoundary.FindAllStringIndex(joined, -1) {
+		lines = append(lines, joined[start:boundary[0]])
+		start = boundary[0] + 1
+	}
+	lines = append(lines, joined[start:])
+	cookies := make([]*http.Cookie, 0, len(lines))
+	seen := make(map[string]struct{}, len(lines))
+	for _, line := range lines {
+		cookie, err := http.ParseSetCookie(strings.TrimSpace(line))
+		if err != nil {
+			continue
+		}
+		cookie.Name = strings.ToLower(cookie.Name)
+		if _, duplicate := seen[cookie.Name]; duplicate {
+			continue
+		}
+		seen[cookie.Name] = struct{}{}
+		cookies = append(cookies, cookie)
+	}
+	return cookies
+}
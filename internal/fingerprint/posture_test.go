@@ -0,0 +1,99 @@
+package fingerprint
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeWebPostureFlagsHeadersCookiesAndVersions(t *testing.T) {
+	evidence := Evidence{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type":              "text/html; charset=utf-8",
+			"Server":                    "Apache/2.4.41 (Ubuntu)",
+			"X-Powered-By":              "PHP/7.4.3",
+			"Strict-Transport-Security": "max-age=3600",
+			"Set-Cookie":                "PHPSESSID=abc; path=/, theme=dark; Expires=Wed, 21 Oct 2026 07:28:00 GMT; Secure; HttpOnly; SameSite=Lax",
+		},
+		Body: "<html><title>Portal</title></html>", Title: "Portal",
+	}
+	got := postureKeys(AnalyzeWebPosture("https", "/", evidence))
+	for _, expected := range []string{
+		"missing_hsts/", "missing_csp/", "missing_x_frame_options/", "missing_x_content_type_options/",
+		"server_version_disclosure/server", "server_version_disclosure/x-powered-by",
+		"cookie_without_secure/phpsessid", "cookie_without_httponly/phpsessid", "cookie_without_samesite/phpsessid",
+	} {
+		if !got[expected] {
+			t.Fatalf("missing %s in %v", expected, got)
+		}
+	}
+	for key := range got {
+		if strings.HasSuffix(key, "/theme") {
+			t.Fatalf("fully flagged cookie reported: %v", got)
+		}
+	}
+
+	hardened := Evidence{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type":              "text/html",
+			"Server":                    "nginx",
+			"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+			"Content-Security-Policy":   "default-src 'self'; frame-ancestors 'none'",
+			"X-Content-Type-Options":    "nosniff",
+		},
+		Body: "<html></html>",
+	}
+	if weaknesses := AnalyzeWebPosture("https", "/", hardened); len(weaknesses) != 0 {
+		t.Fatalf("hardened response flagged: %#v", weaknesses)
+	}
+}
+
+func TestAnalyzeWebPostureDetectsListingsAndDefaultPages(t *testing.T) {
+	listing := Evidence{StatusCode: 200, Headers: map[string]string{"Content-Type": "text/html"}, Title: "Index of /backup", Body: "<h1>Index of /backup</h1>"}
+	if !postureKeys(AnalyzeWebPosture("http", "/backup/", listing))["directory_listing//backup/"] {
+		t.Fatal("directory listing not detected")
+	}
+	welcome := Evidence{StatusCode: 200, Headers: map[string]string{"Content-Type": "text/html"}, Title: "Welcome to nginx!", Body: "<h1>Welcome to nginx!</h1>"}
+	weaknesses := AnalyzeWebPosture("http", "/", welcome)
+	if !postureKeys(weaknesses)["default_page/nginx"] {
+		t.Fatalf("default page not detected: %#v", weaknesses)
+	}
+	for _, weakness := range weaknesses {
+		if weakness.Check == WeaknessMissingHSTS || weakness.Check == WeaknessCookieWithoutSecure {
+			t.Fatalf("HTTPS-only check applied to HTTP: %#v", weakness)
+		}
+	}
+}
+
+func TestAnalyzeWebPostureSkipsHeaderChecksOnTruncatedCapture(t *testing.T) {
+	evidence := Evidence{StatusCode: 200, HeaderTruncated: true, Headers: map[string]string{"Content-Type": "text/html"}, Body: "<html></html>"}
+	if weaknesses := AnalyzeWebPosture("https", "/", evidence); len(weaknesses) != 0 {
+		t.Fatalf("truncated headers produced findings: %#v", weaknesses)
+	}
+}
+
+func TestAnalyzeEndpointPostureReportsEachCheckOnce(t *testing.T) {
+	page := Evidence{StatusCode: 200, Headers: map[string]string{"Content-Type": "text/html"}, Body: "<html></html>"}
+	root := CollectedEvidence{Protocol: "http", Evidence: page}
+	root.Evidence.URL = "http://192.0.2.5:8080/"
+	weaknesses := AnalyzeEndpointPosture(root, []CrawledPage{{Path: "/login", Evidence: page}, {Path: "/api/", Evidence: page}})
+	seen := map[string]int{}
+	for _, weakness := range weaknesses {
+		seen[weakness.Check]++
+		if weakness.Path != "/" {
+			t.Fatalf("first observation path lost: %#v", weakness)
+		}
+	}
+	if seen[WeaknessMissingCSP] != 1 || seen[WeaknessMissingFrameOptions] != 1 {
+		t.Fatalf("weaknesses = %#v", weaknesses)
+	}
+}
+
+func postureKeys(weaknesses []Weakness) map[string]bool {
+	keys := make(map[string]bool, len(weaknesses))
+	for _, weakness := range weaknesses {
+		keys[weakness.Check+"/"+weakness.Subject] = true
+	}
+	return keys
+}
@@ -0,0 +1,157 @@
+package fingerprint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golandproject/yscan/internal/model"
+	appRuntime "golandproject/yscan/internal/runtime"
+)
+
+const (
+	// RawEvidenceKindEvidence replays collected banner, web or TLS evidence
+	// through Engine.Match.
+	RawEvidenceKindEvidence = "evidence"
+	// RawEvidenceKindNmapProbe replays one nmap probe response through the
+	// probe-scoped matcher.
+	RawEvidenceKindNmapProbe = "nmap_probe"
+
+	// MaxRawEvidencePerEndpoint bounds how many inputs one endpoint retains;
+	// every input is already capped by its collector.
+	MaxRawEvidencePerEndpoint = 24
+
+	rawEvidenceLabelPrefix = "yscan raw evidence v1 "
+)
+
+var ErrEvidenceVaultUnavailable = errors.New("raw evidence vault is not configured")
+
+// RawEvidence is one fingerprint input exactly as the engine consumed it.
+type RawEvidence struct {
+	Kind      string   `json:"kind"`
+	ProbeName string   `json:"probe_name,omitempty"`
+	Evidence  Evidence `json:"evidence"`
+	Response  []byte   `json:"response,omitempty"`
+}
+
+// NewRawEvidence records evidence passed to Engine.Match.
+func NewRawEvidence(evidence Evidence) RawEvidence {
+	return RawEvidence{Kind: RawEvidenceKindEvidence, Evidence: evidence}
+}
+
+// NewRawProbeEvidence records a response passed to MatchNmapTCPProbeResponse.
+func NewRawProbeEvidence(probeName string, response []byte) RawEvidence {
+	return RawEvidence{Kind: RawEvidenceKindNmapProbe, ProbeName: probeName, Response: append([]byte(nil), response...)}
+}
+
+// Protocol is the evidence protocol the replayed matches are recorded under.
+func (raw RawEvidence) Protocol() string {
+	if raw.Kind == RawEvidenceKindNmapProbe || strings.TrimSpace(raw.Evidence.Protocol) == "" {
+		return "tcp"
+	}
+	return raw.Evidence.Protocol
+}
+
+// MatchRawEvidence replays one retained input through this engine.
+func (engine *Engine) MatchRawEvidence(raw RawEvidence) []Match {
+	switch raw.Kind {
+	case RawEvidenceKindNmapProbe:
+		return engine.MatchNmapTCPProbeResponse(raw.ProbeName, raw.Response)
+	case RawEvidenceKindEvidence:
+		return engine.Match(raw.Evidence)
+	}
+	return nil
+}
+
+// RunMatch converts an engine match into the record persisted for a run.
+func (match Match) RunMatch(ip string, port int, protocol, summary string) model.FingerprintRunMatch {
+	return model.FingerprintRunMatch{
+		FingerprintImportID:     match.FingerprintImportID,
+		FingerprintSourceRuleID: match.FingerprintSourceRuleID,
+		SourceKey:               match.SourceKey,
+		SourceRuleID:            match.SourceRuleID,
+		IP:                      ip,
+		Port:                    port,
+		Protocol:                protocol,
+		Product:                 match.Product,
+		SourceProduct:           match.SourceProduct,
+		ProductRole:             match.ProductRole,
+		ExclusiveGroup:          match.ExclusiveGroup,
+		Version:                 match.Version,
+		CPE:                     match.CPE,
+		Tags:                    match.Tags,
+		Soft:                    match.Soft,
+		EvidenceSummary:         summary,
+		Evidence:                append([]model.FingerprintMatchEvidence(nil), match.MatcherHits...),
+	}
+}
+
+var evidenceVaultPaths struct {
+	sync.RWMutex
+	paths *appRuntime.HomePaths
+}
+
+// ConfigureEvidenceVault selects the yscan home whose secret key seals raw
+// evidence retained by opted-in tasks.
+func ConfigureEvidenceVault(paths appRuntime.HomePaths) {
+	evidenceVaultPaths.Lock()
+	evidenceVaultPaths.paths = &paths
+	evidenceVaultPaths.Unlock()
+}
+
+// EvidenceVault seals and opens retained raw evidence. Each record's label
+// binds it to its endpoint and position so rows cannot be swapped.
+type EvidenceVault struct {
+	box *appRuntime.SecretBox
+}
+
+// OpenEvidenceVault opens the configured home key, creating it when create
+// is true.
+func OpenEvidenceVault(create bool) (*EvidenceVault, error) {
+	evidenceVaultPaths.RLock()
+	paths := evidenceVaultPaths.paths
+	evidenceVaultPaths.RUnlock()
+	if paths == nil {
+		return nil, ErrEvidenceVaultUnavailable
+	}
+	box, err := appRuntime.OpenSecretBox(*paths, create)
+	if err != nil {
+		return nil, err
+	}
+	return &EvidenceVault{box: box}, nil
+}
+
+// Seal encrypts one input into the record a run snapshot stores.
+func (vault *EvidenceVault) Seal(ip string, port, sequence int, summary string, raw RawEvidence) (model.ScanTaskRunRawEvidence, error) {
+	plaintext, err := json.Marshal(raw)
+	if err != nil {
+		return model.ScanTaskRunRawEvidence{}, err
+	}
+	sealed, err := vault.box.Seal(plaintext, rawEvidenceLabel(ip, port, sequence))
+	if err != nil {
+		return model.ScanTaskRunRawEvidence{}, err
+	}
+	return model.ScanTaskRunRawEvidence{
+		IP: ip, Port: port, Sequence: sequence, Protocol: raw.Protocol(), Kind: raw.Kind,
+		ProbeName: raw.ProbeName, Summary: summary, Sealed: sealed,
+	}, nil
+}
+
+// Open decrypts one stored record.
+func (vault *EvidenceVault) Open(record model.ScanTaskRunRawEvidence) (RawEvidence, error) {
+	plaintext, err := vault.box.Open(record.Sealed, rawEvidenceLabel(record.IP, record.Port, record.Sequence))
+	if err != nil {
+		return RawEvidence{}, fmt.Errorf("raw evidence %s:%d#%d: %w", record.IP, record.Port, record.Sequence, err)
+	}
+	var raw RawEvidence
+	if err := json.Unmarshal(plaintext, &raw); err != nil {
+		return RawEvidence{}, fmt.Errorf("raw evidence %s:%d#%d: %w", record.IP, record.Port, record.Sequence, err)
+	}
+	return raw, nil
+}
+
+func rawEvidenceLabel(ip string, port, sequence int) string {
+	return fmt.Sprintf("%s%s:%d#%d", rawEvidenceLabelPrefix, ip, port, sequence)
+}
@@ -0,0 +1,216 @@
+package fingerprint
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golandproject/yscan/internal/model"
+	appRuntime "golandproject/yscan/internal/runtime"
+	"golandproject/yscan/internal/storage"
+)
+
+func TestReevaluateReplaysRetainedEvidenceThroughNewRules(t *testing.T) {
+	secrets := t.TempDir()
+	ConfigureEvidenceVault(appRuntime.HomePaths{SecretsDir: secrets, SecretKey: filepath.Join(secrets, "secret.key")})
+	t.Cleanup(func() { evidenceVaultPaths.Lock(); evidenceVaultPaths.paths = nil; evidenceVaultPaths.Unlock() })
+	vault, err := OpenEvidenceVault(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := NewRawEvidence(Evidence{Protocol: "http", Title: "Acme Portal", Headers: map[string]string{"X-Acme-Version": "4.2.1"}})
+	probe := NewRawProbeEvidence("GetRequest", []byte("ACME-AGENT 7.0\r\n"))
+	sealedPage, err := vault.Seal("192.168.92.10", 8080, 1, "http status=200", page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opened, err := vault.Open(sealedPage); err != nil || opened.Evidence.Title != "Acme Portal" || sealedPage.Protocol != "http" || bytes.Contains(sealedPage.Sealed, []byte("Acme")) {
+		t.Fatalf("opened=%#v record=%#v err=%v", opened, sealedPage, err)
+	}
+	moved := sealedPage
+	moved.Sequence = 2
+	if _, err := vault.Open(moved); err == nil {
+		t.Fatal("a record moved to another position must not open")
+	}
+	sealedProbe, err := vault.Seal("192.168.92.10", 8080, 2, "tcp probe=GetRequest", probe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := storage.InitDBAt(filepath.Join(t.TempDir(), "reevaluate.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	task, err := storage.CreateScanTask(db, model.ScanTask{Target: "192.168.92.10", ScanType: model.ScanTypeIP, Mode: model.ScanTaskModeOnce, Config: model.ScanTaskConfig{RetainRawEvidence: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run, err := storage.CreateScanTaskRun(db, model.ScanTaskRun{ScanTaskID: task.ID, ScheduledFor: "2026-08-07T01:00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE scan_task_runs SET status = 'running' WHERE id = ?`, run.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{
+		RunID:       run.ID,
+		Ports:       []model.ScanTaskRunPort{{IP: "192.168.92.10", Port: 8080, ServiceType: "http"}},
+		RawEvidence: []model.ScanTaskRunRawEvidence{sealedPage, sealedProbe},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE scan_task_runs SET status = ? WHERE id = ?`, model.ScanTaskRunStatusSuccess, run.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	directory := t.TempDir()
+	if err := os.WriteFile(filepath.Join(directory, "acme.yaml"), []byte(localPortalRules), 0600); err != nil {
+		t.Fatal(err)
+	}
+	registry := NewRegistry(db, Manifest{}, nil, nil)
+	registry.LocalRulesDir = directory
+	if _, err := registry.Import(context.Background(), LocalSourceKey, ""); err != nil {
+		t.Fatal(err)
+	}
+	var output bytes.Buffer
+	if err := RunCLI(context.Background(), registry, []string{"reevaluate", strconv.FormatInt(task.ID, 10), strconv.FormatInt(run.ID, 10)}, &output); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output.String(), "from 2 retained evidence records") {
+		t.Fatalf("output = %q", output.String())
+	}
+	runs, err := storage.ListScanTaskRuns(db, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var derived model.ScanTaskRun
+	for _, candidate := range runs {
+		if candidate.Trigger == model.ScanTaskRunTriggerReevaluate {
+			derived = candidate
+		}
+	}
+	var products []string
+	rows, err := db.Query(`SELECT product_key FROM asset_fingerprint_conclusions WHERE scan_task_run_id = ? ORDER BY product_key`, derived.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var product string
+		if err := rows.Scan(&product); err != nil {
+			t.Fatal(err)
+		}
+		products = append(products, product)
+	}
+	rows.Close()
+	if derived.ID == 0 || strings.Join(products, ",") != "acme portal" {
+		t.Fatalf("derived run %#v concluded %q", derived, products)
+	}
+	if err := RunCLI(context.Background(), registry, []string{"reevaluate", strconv.FormatInt(task.ID+1, 10), strconv.FormatInt(run.ID, 10)}, &output); err == nil {
+		t.Fatal("a run of another task must be rejected")
+	}
+}
+
+func TestReevaluateKeepsSNMPConclusionsOfTheSourceRun(t *testing.T) {
+	secrets := t.TempDir()
+	ConfigureEvidenceVault(appRuntime.HomePaths{SecretsDir: secrets, SecretKey: filepath.Join(secrets, "secret.key")})
+	t.Cleanup(func() { evidenceVaultPaths.Lock(); evidenceVaultPaths.paths = nil; evidenceVaultPaths.Unlock() })
+	vault, err := OpenEvidenceVault(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealedPage, err := vault.Seal("192.168.93.20", 8080, 1, "http status=200", NewRawEvidence(Evidence{Protocol: "http", Title: "Acme Portal"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := storage.InitDBAt(filepath.Join(t.TempDir(), "reevaluate-snmp.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if _, err := storage.ImportFingerprintBatch(db, storage.FingerprintImportBatch{
+		Source: model.FingerprintSource{SourceKey: "snmp-fixture", RepositoryURL: "local://snmp", Status: "enabled"},
+		Import: model.FingerprintImport{Commit: "snmp-v1", ContentSHA256: "snmp-v1", UpstreamContentSHA256: "snmp-v1", AdapterVersion: "snmp-v1", ManifestJSON: `{}`, RuleTotal: 1, ExecutableTotal: 1},
+		Rules:  []model.FingerprintSourceRule{{SourceRuleID: "acme-switch", SourcePath: "acme-switch", ContentSHA256: "acme-switch", RawContent: "acme switch", ImportStatus: "executable"}},
+		Projections: []model.FingerprintRuleProjection{{
+			SourcePath: "acme-switch", ContentSHA256: "acme-switch", Product: model.FingerprintProduct{CanonicalName: "acme switch os"}, Protocol: "snmp",
+			Root: model.FingerprintMatchGroupProjection{Operator: "all", Matchers: []model.FingerprintMatcher{{EvidenceType: "snmp_sysdescr", Operator: "contains", Value: "Acme Switch OS"}}},
+		}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	engine, err := LoadActiveEngine(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task, err := storage.CreateScanTask(db, model.ScanTask{Target: "192.168.93.20", ScanType: model.ScanTypeIP, Mode: model.ScanTaskModeOnce, Config: model.ScanTaskConfig{RetainRawEvidence: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run, err := storage.CreateScanTaskRun(db, model.ScanTaskRun{ScanTaskID: task.ID, ScheduledFor: "2026-08-08T01:00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE scan_task_runs SET status = 'running' WHERE id = ?`, run.ID); err != nil {
+		t.Fatal(err)
+	}
+	const sysDescr = "Acme Switch OS 9.1, Cisco IOS XE Software, Version 17.03.04"
+	sourceMatches := make([]model.FingerprintRunMatch, 0)
+	for _, match := range engine.Match(NewSNMPSysDescrEvidence(sysDescr)) {
+		sourceMatches = append(sourceMatches, match.RunMatch("192.168.93.20", 161, "snmp", "snmp sysdescr_bytes="+strconv.Itoa(len(sysDescr))))
+	}
+	if len(sourceMatches) != 1 {
+		t.Fatalf("sysDescr matches = %#v", sourceMatches)
+	}
+	if err := storage.SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{
+		RunID:              run.ID,
+		Ports:              []model.ScanTaskRunPort{{IP: "192.168.93.20", Port: 8080, ServiceType: "http"}},
+		RawEvidence:        []model.ScanTaskRunRawEvidence{sealedPage},
+		FingerprintMatches: sourceMatches,
+		SNMPSystems: []model.ScanTaskRunSNMPSystem{{
+			IP: "192.168.93.20", Port: 161, Credential: "core", SysDescr: sysDescr,
+			Vendor: "cisco", Product: "cisco ios xe", Version: "17.03.04", CPE: "cpe:2.3:o:cisco:ios_xe:17.03.04:*:*:*:*:*:*:*",
+		}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE scan_task_runs SET status = ? WHERE id = ?`, model.ScanTaskRunStatusSuccess, run.ID); err != nil {
+		t.Fatal(err)
+	}
+	source, err := storage.GetScanTaskRun(db, run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	derived, _, err := ReevaluateRun(db, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snmpConclusions := func(runID int64) []string {
+		t.Helper()
+		conclusions, err := storage.ListFingerprintRunConclusions(db, runID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values := make([]string, 0)
+		for _, conclusion := range conclusions {
+			if conclusion["port"] != 161 {
+				continue
+			}
+			values = append(values, strings.Join([]string{
+				conclusion["protocol"].(string), conclusion["product_key"].(string), conclusion["version"].(string), conclusion["cpe"].(string),
+			}, "|"))
+		}
+		return values
+	}
+	want, got := snmpConclusions(run.ID), snmpConclusions(derived.ID)
+	if len(want) != 2 || strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("reevaluated SNMP conclusions = %q, source = %q", got, want)
+	}
+}
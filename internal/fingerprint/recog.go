@@ -0,0 +1,323 @@
+package fingerprint
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golandproject/yscan/internal/model"
+)
+
+const (
+	recogSourceKey               = "recog"
+	unsupportedRecogReason       = "recog_semantics_not_supported"
+	unsupportedRecogSourceReason = "recog_evidence_not_collected"
+)
+
+// recogEvidenceFamily describes how one Recog `matches` key maps onto the
+// evidence yscan collects. Recog strips protocol framing (the SSH version
+// prefix, FTP/SMTP reply codes) before matching; prefix restores it so an
+// anchored upstream pattern still matches the raw banner.
+type recogEvidenceFamily struct {
+	protocol     string
+	evidenceType string
+	target       string
+	prefix       string
+}
+
+var recogEvidenceFamilies = map[string]recogEvidenceFamily{
+	"ssh.banner":           {protocol: "tcp", evidenceType: "tcp_banner", prefix: `SSH-[0-9.]+-`},
+	"ftp.banner":           {protocol: "tcp", evidenceType: "tcp_banner", prefix: `220[- ]`},
+	"smtp.banner":          {protocol: "tcp", evidenceType: "tcp_banner", prefix: `220[- ]`},
+	"pop.banner":           {protocol: "tcp", evidenceType: "tcp_banner", prefix: `\+OK ?`},
+	"imap4.banner":         {protocol: "tcp", evidenceType: "tcp_banner", prefix: `\* OK ?`},
+	"http_header.server":   {protocol: "http", evidenceType: "http_header", target: "server"},
+	"html_title":           {protocol: "http", evidenceType: "html_title"},
+	"snmp.sys_description": {protocol: "snmp", evidenceType: "snmp_sysdescr"},
+}
+
+// recogParamFamilies orders the identity a fingerprint concludes: a service
+// product wins over the operating system or hardware it runs on.
+var recogParamFamilies = []string{"service", "os", "hw"}
+
+type recogAdapter struct{}
+
+func (recogAdapter) SourceKey() string      { return recogSourceKey }
+func (recogAdapter) AdapterVersion() string { return "recog-xml-v1" }
+
+type recogDatabase struct {
+	Matches      string `json:"matches"`
+	Protocol     string `json:"protocol,omitempty"`
+	DatabaseType string `json:"database_type,omitempty"`
+}
+
+type recogFingerprint struct {
+	Pattern     string         `xml:"pattern,attr"`
+	Flags       string         `xml:"flags,attr"`
+	Certainty   string         `xml:"certainty,attr"`
+	Description string         `xml:"description"`
+	Examples    []recogExample `xml:"example"`
+	Params      []recogParam   `xml:"param"`
+}
+
+type recogExample struct {
+	Encoding   string     `xml:"_encoding,attr"`
+	Attributes []xml.Attr `xml:",any,attr"`
+	Value      string     `xml:",chardata"`
+}
+
+type recogParam struct {
+	Position int    `xml:"pos,attr"`
+	Name     string `xml:"name,attr"`
+	Value    string `xml:"value,attr"`
+}
+
+// Adapt keeps every <fingerprint> element as its own source rule. The raw
+// element is preserved verbatim and the enclosing database attributes travel
+// in RawStructure, so each rule projects without its file.
+func (recogAdapter) Adapt(snapshot VerifiedSnapshot) ([]model.FingerprintSourceRule, error) {
+	paths := make([]string, 0, len(snapshot.Files))
+	for sourcePath := range snapshot.Files {
+		if strings.HasPrefix(sourcePath, "xml/") && strings.HasSuffix(sourcePath, ".xml") {
+			paths = append(paths, sourcePath)
+		}
+	}
+	sort.Strings(paths)
+	rules := make([]model.FingerprintSourceRule, 0)
+	for _, sourcePath := range paths {
+		fileRules, err := adaptRecogFile(sourcePath, snapshot.Files[sourcePath])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", sourcePath, err)
+		}
+		rules = append(rules, fileRules...)
+	}
+	return rules, nil
+}
+
+func adaptRecogFile(sourcePath string, raw []byte) ([]model.FingerprintSourceRule, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(raw))
+	stem := strings.TrimSuffix(path.Base(sourcePath), ".xml")
+	var database recogDatabase
+	rules := make([]model.FingerprintSourceRule, 0)
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "fingerprints":
+			database = recogDatabase{}
+			for _, attribute := range start.Attr {
+				switch attribute.Name.Local {
+				case "matches":
+					database.Matches = attribute.Value
+				case "protocol":
+					database.Protocol = attribute.Value
+				case "database_type":
+					database.DatabaseType = attribute.Value
+				}
+			}
+		case "fingerprint":
+			var fingerprint recogFingerprint
+			if err := decoder.DecodeElement(&fingerprint, &start); err != nil {
+				return nil, err
+			}
+			content := raw[offset:decoder.InputOffset()]
+			structure, err := json.Marshal(database)
+			if err != nil {
+				return nil, err
+			}
+			rule := model.FingerprintSourceRule{
+				SourceRuleID: fmt.Sprintf("%s:%d", stem, len(rules)+1), SourcePath: fmt.Sprintf("%s:%d", sourcePath, len(rules)+1),
+				ContentSHA256: sha256Hex(content), RawContent: string(content), RawStructure: string(structure), ImportStatus: "executable",
+			}
+			if _, known := recogEvidenceFamilies[database.Matches]; !known {
+				rule.ImportStatus, rule.ImportError = "unsupported", unsupportedRecogSourceReason+": "+database.Matches
+			} else if _, err := projectRecog(rule); err != nil {
+				rule.ImportStatus, rule.ImportError = "unsupported", unsupportedRecogReason+": "+err.Error()
+			}
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func (recogAdapter) Project(rule model.FingerprintSourceRule) (model.FingerprintRuleProjection, error) {
+	return projectRecog(rule)
+}
+
+func projectRecog(source model.FingerprintSourceRule) (model.FingerprintRuleProjection, error) {
+	var database recogDatabase
+	if err := json.Unmarshal([]byte(source.RawStructure), &database); err != nil {
+		return model.FingerprintRuleProjection{}, err
+	}
+	family, ok := recogEvidenceFamilies[database.Matches]
+	if !ok {
+		return model.FingerprintRuleProjection{}, fmt.Errorf("match key %q is not collected", database.Matches)
+	}
+	var fingerprint recogFingerprint
+	if err := xml.Unmarshal([]byte(source.RawContent), &fingerprint); err != nil {
+		return model.FingerprintRuleProjection{}, err
+	}
+	pattern, err := recogGoPattern(fingerprint.Pattern, fingerprint.Flags, family.prefix)
+	if err != nil {
+		return model.FingerprintRuleProjection{}, err
+	}
+	expression, err := regexp.Compile(pattern)
+	if err != nil {
+		return model.FingerprintRuleProjection{}, err
+	}
+	params := make(map[string]recogParam, len(fingerprint.Params))
+	for _, param := range fingerprint.Params {
+		if param.Position < 0 || param.Position > expression.NumSubexp() {
+			return model.FingerprintRuleProjection{}, fmt.Errorf("param %s captures missing group %d", param.Name, param.Position)
+		}
+		params[param.Name] = param
+	}
+	prefix := ""
+	for _, candidate := range recogParamFamilies {
+		if _, exists := params[candidate+".product"]; exists {
+			prefix = candidate
+			break
+		}
+	}
+	if prefix == "" {
+		return model.FingerprintRuleProjection{}, errors.New("fingerprint names no product")
+	}
+	product, err := recogStaticValue(params, prefix+".product")
+	if err != nil {
+		return model.FingerprintRuleProjection{}, err
+	}
+	vendor, _ := recogStaticValue(params, prefix+".vendor")
+	condition := matcher(family.evidenceType, family.target, "regex", pattern)
+	projection := model.FingerprintRuleProjection{
+		Product:  model.FingerprintProduct{CanonicalName: product, Vendor: vendor},
+		Protocol: family.protocol,
+		Tags:     []string{"recog", database.Matches},
+	}
+	if version, exists := params[prefix+".version"]; exists {
+		template := "${" + strconv.Itoa(version.Position) + "}"
+		if version.Position == 0 {
+			if template, err = recogTemplate(version.Value, params); err != nil {
+				return model.FingerprintRuleProjection{}, err
+			}
+		}
+		// Captured versions expand per match; constant ones apply to every hit.
+		if strings.Contains(template, "${") {
+			condition.VersionCapture = template
+		} else {
+			projection.VersionTemplate = template
+		}
+	}
+	if cpe, exists := params[prefix+".cpe23"]; exists && cpe.Position == 0 {
+		if projection.CPE, err = recogTemplate(cpe.Value, params); err != nil {
+			return model.FingerprintRuleProjection{}, err
+		}
+		// A captured CPE differs per match; the shared product keeps only a
+		// constant one.
+		if !strings.Contains(projection.CPE, "${") {
+			projection.Product.CPE = projection.CPE
+		}
+	}
+	if certainty, err := strconv.ParseFloat(strings.TrimSpace(fingerprint.Certainty), 64); err == nil && certainty < 1 {
+		projection.SoftMatch = true
+	}
+	projection.Root = model.FingerprintMatchGroupProjection{Operator: "all", Matchers: []model.FingerprintMatcher{condition}}
+	return projection, nil
+}
+
+// recogGoPattern translates Recog's regex flags into Go inline flags and
+// restores stripped protocol framing for banner families.
+func recogGoPattern(pattern, flags, prefix string) (string, error) {
+	if pattern == "" {
+		return "", errors.New("empty pattern")
+	}
+	inline := ""
+	for _, flag := range strings.FieldsFunc(flags, func(r rune) bool { return r == ',' || r == '|' || r == ' ' }) {
+		var letter string
+		switch flag {
+		case "REG_ICASE":
+			letter = "i"
+		case "REG_DOT_NEWLINE":
+			letter = "s"
+		case "REG_MULTILINE", "REG_LINE_ANY_CRLF":
+			letter = "m"
+		default:
+			return "", fmt.Errorf("unsupported regex flag %s", flag)
+		}
+		if !strings.Contains(inline, letter) {
+			inline += letter
+		}
+	}
+	if prefix != "" {
+		// Raw banners keep their line terminator; Recog matched the stripped line.
+		if strings.HasSuffix(pattern, "$") && !strings.HasSuffix(pattern, `\$`) {
+			pattern = strings.TrimSuffix(pattern, "$") + `\s*$`
+		}
+		if strings.HasPrefix(pattern, "^") {
+			pattern = "^" + prefix + "(?:" + strings.TrimPrefix(pattern, "^") + ")"
+		}
+	}
+	if inline != "" {
+		pattern = "(?" + inline + ")" + pattern
+	}
+	return pattern, nil
+}
+
+var recogInterpolation = regexp.MustCompile(`\{([A-Za-z0-9_.]+)\}`)
+
+// recogTemplate rewrites {param} references into regex capture templates for
+// captured params and literal text for constant ones.
+func recogTemplate(value string, params map[string]recogParam) (string, error) {
+	var failure error
+	expanded := recogInterpolation.ReplaceAllStringFunc(value, func(reference string) string {
+		name := recogInterpolation.FindStringSubmatch(reference)[1]
+		param, exists := params[name]
+		switch {
+		case !exists:
+			failure = fmt.Errorf("template references unknown param %s", name)
+		case param.Position > 0:
+			return "${" + strconv.Itoa(param.Position) + "}"
+		case recogInterpolation.MatchString(param.Value):
+			failure = fmt.Errorf("nested template in param %s", name)
+		default:
+			return param.Value
+		}
+		return ""
+	})
+	return expanded, failure
+}
+
+func recogStaticValue(params map[string]recogParam, name string) (string, error) {
+	param, exists := params[name]
+	if !exists {
+		return "", fmt.Errorf("param %s is missing", name)
+	}
+	if param.Position > 0 {
+		return "", fmt.Errorf("param %s is captured from the response", name)
+	}
+	value, err := recogTemplate(param.Value, params)
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(value, "${") || strings.TrimSpace(value) == "" {
+		return "", fmt.Errorf("param %s is not a constant", name)
+	}
+	return strings.TrimSpace(value), nil
+}
@@ -0,0 +1,175 @@
+package fingerprint
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golandproject/yscan/internal/model"
+)
+
+// recogExampleFraming restores the framing Recog strips before matching, so
+// each built-in example reaches the engine the way a scan would collect it.
+var recogExampleFraming = map[string]string{
+	"ssh.banner": "SSH-2.0-", "ftp.banner": "220 ", "smtp.banner": "220 ", "pop.banner": "+OK ", "imap4.banner": "* OK ",
+}
+
+func recogFixtureSnapshot(t *testing.T) VerifiedSnapshot {
+	t.Helper()
+	paths, err := filepath.Glob("testdata/recog/xml/*.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string][]byte, len(paths))
+	for _, fixture := range paths {
+		raw, err := os.ReadFile(fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files["xml/"+filepath.Base(fixture)] = raw
+	}
+	return VerifiedSnapshot{Manifest: SourceManifest{SourceKey: recogSourceKey}, Files: files}
+}
+
+func recogExampleEvidence(t *testing.T, matches string, example recogExample) Evidence {
+	t.Helper()
+	text := example.Value
+	if example.Encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		text = string(decoded)
+	}
+	switch matches {
+	case "http_header.server":
+		return Evidence{Protocol: "http", Headers: map[string]string{"Server": text}}
+	case "html_title":
+		return Evidence{Protocol: "http", Title: text}
+	case "snmp.sys_description":
+		return NewSNMPSysDescrEvidence(text)
+	}
+	return NewBannerEvidence(recogExampleFraming[matches]+text+"\r\n", false)
+}
+
+func TestRecogAdapterMatchesBuiltInExamples(t *testing.T) {
+	adapter := recogAdapter{}
+	snapshot := recogFixtureSnapshot(t)
+	rules, err := adapter.Adapt(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats, err := SnapshotRuleStats(recogSourceKey, snapshot.Files); err != nil || stats != (RuleStats{RuleTotal: 12, ExecutableTotal: 9, UnsupportedTotal: 3}) {
+		t.Fatalf("snapshot stats = %#v err=%v", stats, err)
+	}
+	statuses := make(map[string]string, len(rules))
+	for _, rule := range rules {
+		statuses[rule.SourceRuleID] = rule.ImportStatus + " " + rule.ImportError
+	}
+	for id, want := range map[string]string{
+		"ssh_banners:3":     "unsupported " + unsupportedRecogReason,
+		"ftp_banners:2":     "unsupported " + unsupportedRecogReason + ": unsupported regex flag REG_UNKNOWN",
+		"dns_versionbind:1": "unsupported " + unsupportedRecogSourceReason + ": dns.versionbind",
+		"snmp_sysdescr:1":   "executable ",
+		"html_title:1":      "executable ",
+	} {
+		if !strings.HasPrefix(statuses[id], want) {
+			t.Fatalf("%s status = %q, want prefix %q", id, statuses[id], want)
+		}
+	}
+
+	executable := make([]model.FingerprintSourceRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.ImportStatus == "executable" {
+			executable = append(executable, rule)
+		}
+	}
+	engine := projectedRulesEngine(t, recogSourceKey, adapter, executable)
+	examples := 0
+	for _, rule := range executable {
+		var database recogDatabase
+		if err := json.Unmarshal([]byte(rule.RawStructure), &database); err != nil {
+			t.Fatal(err)
+		}
+		var fingerprint recogFingerprint
+		if err := xml.Unmarshal([]byte(rule.RawContent), &fingerprint); err != nil {
+			t.Fatal(err)
+		}
+		for _, example := range fingerprint.Examples {
+			examples++
+			var matched *Match
+			for _, match := range engine.Match(recogExampleEvidence(t, database.Matches, example)) {
+				if match.SourceRuleID == rule.SourceRuleID {
+					matched = &match
+					break
+				}
+			}
+			if matched == nil {
+				t.Fatalf("%s did not match its example %q", rule.SourceRuleID, example.Value)
+			}
+			for _, attribute := range example.Attributes {
+				if attribute.Name.Local == "service.version" && matched.Version != attribute.Value {
+					t.Fatalf("%s version = %q, want %q", rule.SourceRuleID, matched.Version, attribute.Value)
+				}
+			}
+		}
+	}
+	if examples < 9 {
+		t.Fatalf("exercised %d examples", examples)
+	}
+
+	openSSH, ok := productMatch(engine.Match(NewBannerEvidence("SSH-2.0-OpenSSH_9.6\r\n", false)), "openssh")
+	if !ok || openSSH.Version != "9.6" || openSSH.CPE != "cpe:/a:openbsd:openssh:9.6" || openSSH.Soft {
+		t.Fatalf("openssh = %#v ok=%v", openSSH, ok)
+	}
+	dropbear, ok := productMatch(engine.Match(NewBannerEvidence("SSH-2.0-dropbear_2020.81\r\n", false)), "dropbear ssh")
+	if !ok || !dropbear.Soft {
+		t.Fatalf("dropbear = %#v ok=%v", dropbear, ok)
+	}
+	if matches := engine.Match(NewBannerEvidence("SSH-2.0-OpenSSH_9.6 Ubuntu-3\r\n", false)); hasProduct(matches, "openssh") {
+		t.Fatalf("anchored pattern matched trailing text: %#v", matches)
+	}
+	if matches := engine.Match(NewBannerEvidence("Linux host 5.15.0\r\n", false)); hasProduct(matches, "linux") {
+		t.Fatalf("sysDescr rule matched a TCP banner: %#v", matches)
+	}
+}
+
+func TestRecogProjectionMapsParams(t *testing.T) {
+	rule := model.FingerprintSourceRule{
+		RawStructure: `{"matches":"http_header.server"}`,
+		RawContent: `<fingerprint pattern="^Acme/(\d+)\.(\d+)$" flags="REG_ICASE">
+  <param pos="0" name="service.vendor" value="Acme"/>
+  <param pos="0" name="service.product" value="Acme Server"/>
+  <param pos="0" name="service.version" value="{service.version.major}.{service.version.minor}"/>
+  <param pos="1" name="service.version.major"/>
+  <param pos="2" name="service.version.minor"/>
+  <param pos="0" name="service.cpe23" value="cpe:/a:acme:server:{service.version.major}.{service.version.minor}"/>
+</fingerprint>`,
+	}
+	projection, err := recogAdapter{}.Project(rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	condition := projection.Root.Matchers[0]
+	if projection.Product.CanonicalName != "Acme Server" || projection.Product.Vendor != "Acme" || projection.Protocol != "http" ||
+		projection.VersionTemplate != "" || condition.VersionCapture != "${1}.${2}" || projection.CPE != "cpe:/a:acme:server:${1}.${2}" ||
+		condition.EvidenceType != "http_header" || condition.Target != "server" || condition.Value != `(?i)^Acme/(\d+)\.(\d+)$` {
+		t.Fatalf("projection = %#v", projection)
+	}
+	if projection.Product.CPE != "" {
+		t.Fatalf("captured CPE reached the shared product: %q", projection.Product.CPE)
+	}
+
+	rule.RawContent = `<fingerprint pattern="^Acme$"><param pos="0" name="service.product" value="{service.family}"/></fingerprint>`
+	if _, err := (recogAdapter{}).Project(rule); err == nil {
+		t.Fatal("an unresolved template must not project")
+	}
+	rule.RawContent = `<fingerprint pattern="^Acme$"><param pos="2" name="service.product"/></fingerprint>`
+	if _, err := (recogAdapter{}).Project(rule); err == nil {
+		t.Fatal("a param capturing a missing group must not project")
+	}
+}
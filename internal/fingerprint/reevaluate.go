@@ -0,0 +1,96 @@
+package fingerprint
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golandproject/yscan/internal/model"
+	"golandproject/yscan/internal/storage"
+)
+
+const reevaluateUsage = "usage: yscan fingerprint reevaluate <scan_task_id> <run_id>"
+
+// runReevaluateCLI replays a run's retained raw evidence through the active
+// engine and records the new conclusions as a derived run. Nothing is sent to
+// the scanned hosts.
+func runReevaluateCLI(db *sql.DB, args []string, output io.Writer) error {
+	if len(args) != 2 {
+		return errors.New(reevaluateUsage)
+	}
+	taskID, err := strconv.ParseInt(strings.TrimSpace(args[0]), 10, 64)
+	if err != nil || taskID <= 0 {
+		return errors.New("invalid scan task id")
+	}
+	runID, err := strconv.ParseInt(strings.TrimSpace(args[1]), 10, 64)
+	if err != nil || runID <= 0 {
+		return errors.New("invalid scan task run id")
+	}
+	source, err := storage.GetScanTaskRun(db, runID)
+	if err != nil {
+		return err
+	}
+	if source.ScanTaskID != taskID {
+		return storage.ErrScanTaskRunNotFound
+	}
+	derived, replayed, err := ReevaluateRun(db, source)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(output, "Reevaluated run %d from %d retained evidence records as run %d (sequence %d)\n", source.ID, replayed, derived.ID, derived.Sequence)
+	return err
+}
+
+// ReevaluateRun matches every retained record and SNMP sysDescr of source
+// with the currently active rules. It returns the derived run and the number
+// of replayed retained records.
+func ReevaluateRun(db *sql.DB, source model.ScanTaskRun) (model.ScanTaskRun, int, error) {
+	records, err := storage.ListScanTaskRunRawEvidence(db, source.ID)
+	if err != nil {
+		return model.ScanTaskRun{}, 0, err
+	}
+	if len(records) == 0 {
+		return model.ScanTaskRun{}, 0, fmt.Errorf("run %d retained no raw evidence; enable retain_raw_evidence on the task and scan again", source.ID)
+	}
+	snapshot, err := storage.GetScanTaskRunSnapshot(db, source.ID)
+	if err != nil {
+		return model.ScanTaskRun{}, 0, err
+	}
+	vault, err := OpenEvidenceVault(false)
+	if err != nil {
+		return model.ScanTaskRun{}, 0, err
+	}
+	engine, err := LoadActiveEngine(db)
+	if err != nil {
+		return model.ScanTaskRun{}, 0, err
+	}
+	matches := make([]model.FingerprintRunMatch, 0)
+	for _, record := range records {
+		raw, err := vault.Open(record)
+		if err != nil {
+			return model.ScanTaskRun{}, 0, err
+		}
+		for _, match := range engine.MatchRawEvidence(raw) {
+			matches = append(matches, match.RunMatch(record.IP, record.Port, record.Protocol, record.Summary))
+		}
+	}
+	// The SNMP inventory is copied to the derived run in plain text, so its
+	// sysDescr is replayed like the workflow matches it during a scan.
+	for _, system := range snapshot.SNMPSystems {
+		if strings.TrimSpace(system.SysDescr) == "" {
+			continue
+		}
+		summary := "snmp sysdescr_bytes=" + strconv.Itoa(len(system.SysDescr))
+		for _, match := range engine.Match(NewSNMPSysDescrEvidence(system.SysDescr)) {
+			matches = append(matches, match.RunMatch(system.IP, system.Port, "snmp", summary))
+		}
+	}
+	derived, err := storage.CreateReevaluatedScanTaskRun(db, source.ID, matches)
+	if err != nil {
+		return model.ScanTaskRun{}, 0, err
+	}
+	return derived, len(records), nil
+}
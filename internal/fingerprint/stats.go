@@ -0,0 +1,81 @@
+package fingerprint
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"golandproject/yscan/internal/model"
+	"golandproject/yscan/internal/storage"
+)
+
+const statsUsage = "usage: yscan fingerprint stats [--source <source_key>] [--limit <n>] [--json]"
+
+// runStatsCLI reports which rules and sources actually fire, from the matches
+// recorded by past runs. Sources are always listed so silent ones stand out.
+func runStatsCLI(db *sql.DB, args []string, output io.Writer) error {
+	query := storage.FingerprintRuleStatsQuery{}
+	jsonOutput := false
+	for index := 0; index < len(args); index++ {
+		switch args[index] {
+		case "--json":
+			jsonOutput = true
+		case "--source", "--limit":
+			if index+1 >= len(args) {
+				return errors.New(statsUsage)
+			}
+			index++
+			if args[index-1] == "--source" {
+				query.SourceKey = args[index]
+				continue
+			}
+			limit, err := strconv.Atoi(args[index])
+			if err != nil || limit <= 0 {
+				return errors.New("stats limit must be a positive integer")
+			}
+			query.Limit = limit
+		default:
+			return errors.New(statsUsage)
+		}
+	}
+	stats, err := storage.FingerprintRuleStatistics(db, query)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		encoder := json.NewEncoder(output)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+	return writeFingerprintStats(output, stats)
+}
+
+func writeFingerprintStats(output io.Writer, stats model.FingerprintRuleStats) error {
+	lines := make([]string, 0, len(stats.Sources)+len(stats.Rules)+len(stats.Products))
+	for _, source := range stats.Sources {
+		lines = append(lines, fmt.Sprintf("source=%s %s rules_fired=%d/%d", source.SourceKey, formatHitStats(source.FingerprintHitStats), source.RulesFired, source.ExecutableRules))
+	}
+	for _, rule := range stats.Rules {
+		lines = append(lines, fmt.Sprintf("rule=%s:%s product=%s %s", rule.SourceKey, rule.RuleKey, valueOrDash(rule.ProductKey), formatHitStats(rule)))
+	}
+	for _, product := range stats.Products {
+		lines = append(lines, fmt.Sprintf("product=%s %s", valueOrDash(product.ProductKey), formatHitStats(product)))
+	}
+	if len(stats.Rules) == 0 {
+		lines = append(lines, "No fingerprint matches recorded yet.")
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(output, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatHitStats(stats model.FingerprintHitStats) string {
+	return fmt.Sprintf("hits=%d endpoints=%d soft=%.0f%% conflicted=%.0f%% last_matched=%s",
+		stats.Hits, stats.Endpoints, stats.SoftShare*100, stats.ConflictRate*100, valueOrDash(stats.LastMatchedAt))
+}
@@ -0,0 +1,37 @@
+package fingerprint
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golandproject/yscan/internal/model"
+)
+
+func TestWriteFingerprintStatsListsSourcesRulesAndProducts(t *testing.T) {
+	var output bytes.Buffer
+	err := writeFingerprintStats(&output, model.FingerprintRuleStats{
+		Sources: []model.FingerprintSourceHitStats{
+			{FingerprintHitStats: model.FingerprintHitStats{SourceKey: "wappalyzer", Hits: 4, Endpoints: 3, SoftHits: 1, SoftShare: 0.25, LastMatchedAt: "2026-08-10 02:00:00"}, ExecutableRules: 2514, RulesFired: 2},
+			{FingerprintHitStats: model.FingerprintHitStats{SourceKey: "recog"}, ExecutableRules: 40},
+		},
+		Rules:    []model.FingerprintHitStats{{SourceKey: "wappalyzer", RuleKey: "Nginx", ProductKey: "nginx", Hits: 3, Endpoints: 3, ConflictedHits: 1, ConflictRate: 1.0 / 3, LastMatchedAt: "2026-08-10 02:00:00"}},
+		Products: []model.FingerprintHitStats{{ProductKey: "nginx", Hits: 3, Endpoints: 3}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"source=wappalyzer hits=4 endpoints=3 soft=25% conflicted=0% last_matched=2026-08-10 02:00:00 rules_fired=2/2514",
+		"source=recog hits=0 endpoints=0 soft=0% conflicted=0% last_matched=- rules_fired=0/40",
+		"rule=wappalyzer:Nginx product=nginx hits=3 endpoints=3 soft=0% conflicted=33%",
+		"product=nginx hits=3",
+	} {
+		if !strings.Contains(output.String(), want) {
+			t.Fatalf("stats output missing %q:\n%s", want, output.String())
+		}
+	}
+	if err := runStatsCLI(nil, []string{"--limit", "0"}, &output); err == nil {
+		t.Fatal("zero limit was accepted")
+	}
+}
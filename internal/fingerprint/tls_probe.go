@@ -0,0 +1,442 @@
+package fingerprint
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoTLSResponse means no ClientHello variant received a ServerHello.
+var ErrNoTLSResponse = errors.New("endpoint did not answer any TLS ClientHello")
+
+const (
+	defaultTLSProbeTimeout = time.Second
+	defaultTLSProbeBudget  = 6 * time.Second
+	maxTLSServerHelloRead  = 16 << 10
+
+	tlsVersion10 = 0x0301
+	tlsVersion11 = 0x0302
+	tlsVersion12 = 0x0303
+	tlsVersion13 = 0x0304
+)
+
+// tlsProbeCiphers is the fixed cipher catalog the variants reorder. A
+// suite's position in this list is its code in the fingerprint.
+var tlsProbeCiphers = []uint16{
+	0x1301, 0x1302, 0x1303, // TLS 1.3 AES-128-GCM, AES-256-GCM, CHACHA20
+	0xc02b, 0xc02f, 0xc02c, 0xc030, 0xcca9, 0xcca8, // ECDHE GCM and CHACHA20
+	0xc009, 0xc013, 0xc00a, 0xc014, 0xc023, 0xc027, 0xc024, 0xc028, // ECDHE CBC
+	0x009c, 0x009d, 0x002f, 0x0035, 0x003c, 0x003d, // RSA key exchange
+	0x009e, 0x009f, 0x0033, 0x0039, // DHE
+	0x000a, 0xc012, 0x0005, 0x0004, // 3DES and RC4 for legacy stacks
+}
+
+type tlsCipherOrder int
+
+const (
+	tlsCiphersForward tlsCipherOrder = iota
+	tlsCiphersReverse
+	tlsCiphersTopHalf
+	tlsCiphersBottomHalf
+	tlsCiphersMiddleOut
+)
+
+// tlsHelloVariant is one ClientHello shape. The set follows the JARM idea:
+// vary the offered versions, cipher order and extensions, then record how
+// the server's choices shift.
+type tlsHelloVariant struct {
+	version           uint16
+	supportedVersions []uint16
+	ciphers           tlsCipherOrder
+	alpn              []string
+	reverseExtensions bool
+}
+
+var tlsHelloVariants = []tlsHelloVariant{
+	{version: tlsVersion12, ciphers: tlsCiphersForward, alpn: []string{"h2", "http/1.1"}},
+	{version: tlsVersion12, ciphers: tlsCiphersReverse, alpn: []string{"h2", "http/1.1"}},
+	{version: tlsVersion12, ciphers: tlsCiphersTopHalf},
+	{version: tlsVersion12, ciphers: tlsCiphersBottomHalf, alpn: []string{"http/1.1"}},
+	{version: tlsVersion12, ciphers: tlsCiphersMiddleOut, alpn: []string{"h2", "http/1.1"}, reverseExtensions: true},
+	{version: tlsVersion11, ciphers: tlsCiphersForward, alpn: []string{"h2", "http/1.1"}},
+	{version: tlsVersion13, supportedVersions: []uint16{tlsVersion13, tlsVersion12}, ciphers: tlsCiphersForward, alpn: []string{"h2", "http/1.1"}},
+	{version: tlsVersion13, supportedVersions: []uint16{tlsVersion13, tlsVersion12}, ciphers: tlsCiphersReverse, alpn: []string{"h2", "http/1.1"}},
+	{version: tlsVersion13, supportedVersions: []uint16{tlsVersion13}, ciphers: tlsCiphersForward},
+	{version: tlsVersion13, supportedVersions: []uint16{tlsVersion13, tlsVersion12}, ciphers: tlsCiphersMiddleOut, alpn: []string{"http/1.1"}, reverseExtensions: true},
+}
+
+// TLSFingerprintOptions bounds one endpoint's probe set. ServerName is sent
+// as SNI only when set; IP endpoints normally leave it empty.
+type TLSFingerprintOptions struct {
+	Timeout    time.Duration
+	Budget     time.Duration
+	ServerName string
+}
+
+// TLSFingerprint summarizes the ServerHellos of one endpoint. Fingerprint is
+// a JARM-style 62 character value: three characters per variant for the
+// chosen cipher and version, then a truncated SHA-256 of every variant's
+// extension list and ALPN. The variant set is yscan's own, so values are not
+// interchangeable with published JARM hashes. JA4S describes the answer to
+// the first TLS 1.3 variant that got one.
+type TLSFingerprint struct {
+	Fingerprint    string
+	JA4S           string
+	Responses      int
+	Variants       int
+	CapturedLength int
+	CapturedSHA256 string
+}
+
+type tlsServerHello struct {
+	version    uint16
+	cipher     uint16
+	extensions []uint16
+	alpn       string
+	raw        []byte
+}
+
+// CollectTLSFingerprint sends each ClientHello variant on its own
+// connection and closes it after the ServerHello; no handshake completes
+// and no application data is sent.
+func CollectTLSFingerprint(ctx context.Context, ip string, port int, options TLSFingerprintOptions) (TLSFingerprint, error) {
+	if net.ParseIP(ip) == nil || port < 1 || port > 65535 {
+		return TLSFingerprint{}, errors.New("invalid TLS fingerprint endpoint")
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = defaultTLSProbeTimeout
+	}
+	if options.Budget <= 0 {
+		options.Budget = defaultTLSProbeBudget
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, options.Budget)
+	defer cancel()
+	address := net.JoinHostPort(ip, strconv.Itoa(port))
+	hellos := make([]*tlsServerHello, len(tlsHelloVariants))
+	for index, variant := range tlsHelloVariants {
+		if probeCtx.Err() != nil {
+			break
+		}
+		hello, err := exchangeTLSHello(probeCtx, address, variant, options)
+		if err == nil {
+			hellos[index] = hello
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return TLSFingerprint{}, err
+	}
+	return tlsFingerprintFromHellos(hellos)
+}
+
+func tlsFingerprintFromHellos(hellos []*tlsServerHello) (TLSFingerprint, error) {
+	result := TLSFingerprint{Variants: len(hellos)}
+	var codes strings.Builder
+	var extensionText strings.Builder
+	captured := sha256.New()
+	for index, hello := range hellos {
+		if index > 0 {
+			extensionText.WriteByte(',')
+		}
+		if hello == nil {
+			codes.WriteString("000")
+			continue
+		}
+		result.Responses++
+		result.CapturedLength += len(hello.raw)
+		captured.Write(hello.raw)
+		fmt.Fprintf(&codes, "%02x%c", tlsCipherCode(hello.cipher), tlsVersionCode(hello.version))
+		extensionText.WriteString(hello.alpn)
+		for _, extension := range hello.extensions {
+			fmt.Fprintf(&extensionText, "-%04x", extension)
+		}
+		if result.JA4S == "" && tlsHelloVariants[index].version == tlsVersion13 {
+			result.JA4S = hello.ja4s()
+		}
+	}
+	if result.Responses == 0 {
+		return TLSFingerprint{}, ErrNoTLSResponse
+	}
+	if result.JA4S == "" {
+		for _, hello := range hellos {
+			if hello != nil {
+				result.JA4S = hello.ja4s()
+				break
+			}
+		}
+	}
+	extensionHash := sha256.Sum256([]byte(extensionText.String()))
+	result.Fingerprint = codes.String() + hex.EncodeToString(extensionHash[:])[:32]
+	result.CapturedSHA256 = hex.EncodeToString(captured.Sum(nil))
+	return result, nil
+}
+
+func tlsCipherCode(cipher uint16) int {
+	for index, candidate := range tlsProbeCiphers {
+		if candidate == cipher {
+			return index + 1
+		}
+	}
+	return 0xff
+}
+
+func tlsVersionCode(version uint16) byte {
+	if version >= 0x0300 && version <= tlsVersion13 {
+		return byte('a' + version - 0x0300)
+	}
+	return 'z'
+}
+
+// ja4s follows the FoxIO JA4S layout: transport, version, extension count
+// and ALPN edge characters, the chosen cipher, and a truncated hash of the
+// extensions in server order.
+func (hello *tlsServerHello) ja4s() string {
+	version := map[uint16]string{0x0300: "s3", tlsVersion10: "10", tlsVersion11: "11", tlsVersion12: "12", tlsVersion13: "13"}[hello.version]
+	if version == "" {
+		version = "00"
+	}
+	alpn := "00"
+	if hello.alpn != "" {
+		alpn = string(hello.alpn[0]) + string(hello.alpn[len(hello.alpn)-1])
+	}
+	extensions := make([]string, 0, len(hello.extensions))
+	for _, extension := range hello.extensions {
+		extensions = append(extensions, fmt.Sprintf("%04x", extension))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(extensions, ",")))
+	count := len(hello.extensions)
+	if count > 99 {
+		count = 99
+	}
+	return fmt.Sprintf("t%s%02d%s_%04x_%s", version, count, alpn, hello.cipher, hex.EncodeToString(sum[:])[:12])
+}
+
+func exchangeTLSHello(ctx context.Context, address string, variant tlsHelloVariant, options TLSFingerprintOptions) (*tlsServerHello, error) {
+	hello, err := buildClientHello(variant, options.ServerName)
+	if err != nil {
+		return nil, err
+	}
+	dialCtx, cancel := context.WithTimeout(ctx, options.Timeout)
+	defer cancel()
+	conn, err := (&net.Dialer{}).DialContext(dialCtx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	deadline, _ := dialCtx.Deadline()
+	_ = conn.SetDeadline(deadline)
+	if _, err := conn.Write(hello); err != nil {
+		return nil, err
+	}
+	return readServerHello(io.LimitReader(conn, maxTLSServerHelloRead))
+}
+
+func buildClientHello(variant tlsHelloVariant, serverName string) ([]byte, error) {
+	random := make([]byte, 32)
+	sessionID := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(sessionID); err != nil {
+		return nil, err
+	}
+	tls13 := len(variant.supportedVersions) > 0
+	ciphers := orderedTLSCiphers(variant.ciphers, tls13)
+	extensions := make([][]byte, 0, 12)
+	if serverName != "" {
+		name := []byte(serverName)
+		entry := append([]byte{0}, uint16Bytes(len(name))...)
+		entry = append(entry, name...)
+		extensions = append(extensions, tlsExtension(0x0000, append(uint16Bytes(len(entry)), entry...)))
+	}
+	extensions = append(extensions,
+		tlsExtension(0x0017, nil),
+		tlsExtension(0xff01, []byte{0}),
+		tlsExtension(0x000a, []byte{0x00, 0x06, 0x00, 0x1d, 0x00, 0x17, 0x00, 0x18}),
+		tlsExtension(0x000b, []byte{0x01, 0x00}),
+		tlsExtension(0x0023, nil),
+		tlsExtension(0x000d, []byte{0x00, 0x14, 0x04, 0x03, 0x05, 0x03, 0x06, 0x03, 0x08, 0x04, 0x08, 0x05, 0x08, 0x06, 0x04, 0x01, 0x05, 0x01, 0x06, 0x01, 0x02, 0x01}),
+	)
+	if len(variant.alpn) > 0 {
+		var protocols []byte
+		for _, protocol := range variant.alpn {
+			protocols = append(protocols, byte(len(protocol)))
+			protocols = append(protocols, protocol...)
+		}
+		extensions = append(extensions, tlsExtension(0x0010, append(uint16Bytes(len(protocols)), protocols...)))
+	}
+	if tls13 {
+		versions := []byte{byte(len(variant.supportedVersions) * 2)}
+		for _, version := range variant.supportedVersions {
+			versions = append(versions, uint16Bytes(int(version))...)
+		}
+		key, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		public := key.PublicKey().Bytes()
+		share := append([]byte{0x00, 0x1d}, uint16Bytes(len(public))...)
+		share = append(share, public...)
+		extensions = append(extensions,
+			tlsExtension(0x002b, versions),
+			tlsExtension(0x002d, []byte{0x01, 0x01}),
+			tlsExtension(0x0033, append(uint16Bytes(len(share)), share...)),
+		)
+	}
+	if variant.reverseExtensions {
+		for left, right := 0, len(extensions)-1; left < right; left, right = left+1, right-1 {
+			extensions[left], extensions[right] = extensions[right], extensions[left]
+		}
+	}
+
+	legacyVersion := variant.version
+	if legacyVersion > tlsVersion12 {
+		legacyVersion = tlsVersion12
+	}
+	var body bytes.Buffer
+	body.Write(uint16Bytes(int(legacyVersion)))
+	body.Write(random)
+	body.WriteByte(byte(len(sessionID)))
+	body.Write(sessionID)
+	body.Write(uint16Bytes(len(ciphers) * 2))
+	for _, cipher := range ciphers {
+		body.Write(uint16Bytes(int(cipher)))
+	}
+	body.Write([]byte{0x01, 0x00})
+	extensionBytes := bytes.Join(extensions, nil)
+	body.Write(uint16Bytes(len(extensionBytes)))
+	body.Write(extensionBytes)
+
+	handshake := append([]byte{0x01, byte(body.Len() >> 16), byte(body.Len() >> 8), byte(body.Len())}, body.Bytes()...)
+	record := append([]byte{0x16, 0x03, 0x01}, uint16Bytes(len(handshake))...)
+	return append(record, handshake...), nil
+}
+
+func orderedTLSCiphers(order tlsCipherOrder, tls13 bool) []uint16 {
+	ciphers := make([]uint16, 0, len(tlsProbeCiphers))
+	for _, cipher := range tlsProbeCiphers {
+		if tls13 || cipher>>8 != 0x13 {
+			ciphers = append(ciphers, cipher)
+		}
+	}
+	switch order {
+	case tlsCiphersReverse:
+		for left, right := 0, len(ciphers)-1; left < right; left, right = left+1, right-1 {
+			ciphers[left], ciphers[right] = ciphers[right], ciphers[left]
+		}
+	case tlsCiphersTopHalf:
+		ciphers = ciphers[:len(ciphers)/2]
+	case tlsCiphersBottomHalf:
+		ciphers = ciphers[len(ciphers)/2:]
+	case tlsCiphersMiddleOut:
+		middle := len(ciphers) / 2
+		reordered := []uint16{ciphers[middle]}
+		for offset := 1; middle-offset >= 0 || middle+offset < len(ciphers); offset++ {
+			if middle+offset < len(ciphers) {
+				reordered = append(reordered, ciphers[middle+offset])
+			}
+			if middle-offset >= 0 {
+				reordered = append(reordered, ciphers[middle-offset])
+			}
+		}
+		ciphers = reordered
+	}
+	return ciphers
+}
+
+func tlsExtension(extensionType uint16, data []byte) []byte {
+	extension := append(uint16Bytes(int(extensionType)), uint16Bytes(len(data))...)
+	return append(extension, data...)
+}
+
+func uint16Bytes(value int) []byte {
+	return []byte{byte(value >> 8), byte(value)}
+}
+
+// readServerHello reassembles handshake records until the first message is
+// complete. An alert or any other first message means the variant was
+// refused.
+func readServerHello(reader io.Reader) (*tlsServerHello, error) {
+	var handshake []byte
+	header := make([]byte, 5)
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return nil, err
+		}
+		length := int(binary.BigEndian.Uint16(header[3:5]))
+		fragment := make([]byte, length)
+		if _, err := io.ReadFull(reader, fragment); err != nil {
+			return nil, err
+		}
+		if header[0] != 0x16 {
+			return nil, errors.New("server refused TLS ClientHello")
+		}
+		handshake = append(handshake, fragment...)
+		if len(handshake) < 4 {
+			continue
+		}
+		if handshake[0] != 0x02 {
+			return nil, errors.New("first TLS handshake message is not a ServerHello")
+		}
+		size := int(handshake[1])<<16 | int(handshake[2])<<8 | int(handshake[3])
+		if len(handshake) >= 4+size {
+			return parseServerHello(handshake[4 : 4+size])
+		}
+	}
+}
+
+func parseServerHello(message []byte) (*tlsServerHello, error) {
+	invalid := errors.New("malformed TLS ServerHello")
+	if len(message) < 38 {
+		return nil, invalid
+	}
+	hello := &tlsServerHello{version: binary.BigEndian.Uint16(message[0:2]), raw: append([]byte(nil), message...)}
+	offset := 34
+	sessionLength := int(message[offset])
+	offset += 1 + sessionLength
+	if len(message) < offset+3 {
+		return nil, invalid
+	}
+	hello.cipher = binary.BigEndian.Uint16(message[offset : offset+2])
+	offset += 3
+	if len(message) < offset+2 {
+		return hello, nil
+	}
+	end := offset + 2 + int(binary.BigEndian.Uint16(message[offset:offset+2]))
+	offset += 2
+	if end > len(message) {
+		return nil, invalid
+	}
+	for offset+4 <= end {
+		extensionType := binary.BigEndian.Uint16(message[offset : offset+2])
+		length := int(binary.BigEndian.Uint16(message[offset+2 : offset+4]))
+		offset += 4
+		if offset+length > end {
+			return nil, invalid
+		}
+		data := message[offset : offset+length]
+		hello.extensions = append(hello.extensions, extensionType)
+		switch extensionType {
+		case 0x002b:
+			if len(data) == 2 {
+				hello.version = binary.BigEndian.Uint16(data)
+			}
+		case 0x0010:
+			if len(data) > 3 && int(data[2])+3 <= len(data) {
+				hello.alpn = string(data[3 : 3+int(data[2])])
+			}
+		}
+		offset += length
+	}
+	return hello, nil
+}
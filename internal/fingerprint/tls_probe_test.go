@@ -0,0 +1,102 @@
+package fingerprint
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golandproject/yscan/internal/model"
+	"golandproject/yscan/internal/storage"
+)
+
+func TestCollectTLSFingerprintIsStableForOneServer(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Error("fingerprint probe completed a request")
+	}))
+	server.EnableHTTP2 = true
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+	ip, port := testServerEndpoint(t, server.URL)
+
+	first, err := CollectTLSFingerprint(context.Background(), ip, port, TLSFingerprintOptions{})
+	if err != nil {
+		t.Fatalf("first probe: %v", err)
+	}
+	second, err := CollectTLSFingerprint(context.Background(), ip, port, TLSFingerprintOptions{})
+	if err != nil {
+		t.Fatalf("second probe: %v", err)
+	}
+	if first.Fingerprint != second.Fingerprint || first.JA4S != second.JA4S {
+		t.Fatalf("fingerprint changed between probes: %#v %#v", first, second)
+	}
+	if len(first.Fingerprint) != 62 || first.Responses == 0 || first.Variants != len(tlsHelloVariants) || len(first.CapturedSHA256) != 64 {
+		t.Fatalf("fingerprint = %#v", first)
+	}
+	if !strings.HasPrefix(first.JA4S, "t13") || !strings.Contains(first.JA4S, "_130") {
+		t.Fatalf("JA4S = %q", first.JA4S)
+	}
+	// The TLS 1.1 variant is refused by Go's default server configuration.
+	if first.Fingerprint[15:18] != "000" {
+		t.Fatalf("TLS 1.1 variant answered: %q", first.Fingerprint)
+	}
+}
+
+func TestCollectTLSFingerprintRejectsPlainTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = conn.Write([]byte("SSH-2.0-OpenSSH_9.6\r\n"))
+			_ = conn.Close()
+		}
+	}()
+	address := listener.Addr().(*net.TCPAddr)
+	_, err = CollectTLSFingerprint(context.Background(), "127.0.0.1", address.Port, TLSFingerprintOptions{})
+	if !errors.Is(err, ErrNoTLSResponse) {
+		t.Fatalf("err = %v, want ErrNoTLSResponse", err)
+	}
+}
+
+func TestEngineMatchesTLSFingerprintEvidence(t *testing.T) {
+	db := openFingerprintTestDB(t)
+	_, err := storage.ImportFingerprintBatch(db, storage.FingerprintImportBatch{
+		Source: model.FingerprintSource{SourceKey: "tls-fixture", RepositoryURL: "local://tls", Status: "enabled"},
+		Import: model.FingerprintImport{Commit: "tls", ContentSHA256: "tls", ManifestJSON: `{}`, RuleTotal: 2, ExecutableTotal: 2},
+		Rules: []model.FingerprintSourceRule{
+			{SourceRuleID: "ja4s", SourcePath: "ja4s", ContentSHA256: "ja4s", RawContent: "ja4s", ImportStatus: "executable"},
+			{SourceRuleID: "jarm", SourcePath: "jarm", ContentSHA256: "jarm", RawContent: "jarm", ImportStatus: "executable"},
+		},
+		Projections: []model.FingerprintRuleProjection{
+			{SourcePath: "ja4s", ContentSHA256: "ja4s", Product: model.FingerprintProduct{CanonicalName: "go-tls"}, Protocol: "tls", Root: model.FingerprintMatchGroupProjection{Operator: "all", Matchers: []model.FingerprintMatcher{{EvidenceType: "tls_fingerprint", Target: "ja4s", Operator: "equals", Value: "t130200_1301_234ea6891581"}}}},
+			{SourcePath: "jarm", ContentSHA256: "jarm", Product: model.FingerprintProduct{CanonicalName: "cobalt-fixture"}, Protocol: "tls", Root: model.FingerprintMatchGroupProjection{Operator: "all", Matchers: []model.FingerprintMatcher{{EvidenceType: "tls_fingerprint", Target: "jarm", Operator: "contains", Value: "00000"}}}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine, err := LoadActiveEngine(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches := engine.Match(Evidence{Protocol: "tls", JA4S: "t130200_1301_234ea6891581", TLSFingerprint: strings.Repeat("2ad", 10) + strings.Repeat("ab", 16)})
+	if !hasProduct(matches, "go-tls") || hasProduct(matches, "cobalt-fixture") {
+		t.Fatalf("matches = %#v", matches)
+	}
+	if hasProduct(engine.Match(Evidence{Protocol: "http", Body: "t130200_1301_234ea6891581"}), "go-tls") {
+		t.Fatal("TLS rule matched HTTP body evidence")
+	}
+}
@@ -0,0 +1,298 @@
+package hostid
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SourceSMB   = "smb"
+	SourceRDP   = "rdp"
+	SourceHTTP  = "http"
+	SourceWinRM = "winrm"
+
+	// EndpointBudget bounds one challenge exchange including connect and TLS.
+	EndpointBudget = 5 * time.Second
+	maxResponse    = 64 << 10
+)
+
+// Endpoint is one open TCP port offered to the collector.
+type Endpoint struct {
+	Port    int
+	Service string
+}
+
+// Observation is the identity disclosed by one endpoint.
+type Observation struct {
+	Port     int
+	Source   string
+	Identity Identity
+}
+
+// Dialer opens the TCP connection for one exchange. Tests replace it.
+type Dialer func(ctx context.Context, network, address string) (net.Conn, error)
+
+// Collect runs the challenge step on every eligible endpoint of ip and
+// returns the identities disclosed. Endpoints that refuse, time out or do not
+// offer NTLM are skipped; the collector never sends credentials.
+func Collect(ctx context.Context, ip string, endpoints []Endpoint) []Observation {
+	dialer := &net.Dialer{Timeout: EndpointBudget}
+	return collectWithDialer(ctx, ip, endpoints, dialer.DialContext)
+}
+
+func collectWithDialer(ctx context.Context, ip string, endpoints []Endpoint, dial Dialer) []Observation {
+	observations := make([]Observation, 0)
+	for _, endpoint := range endpoints {
+		source := EndpointSource(endpoint)
+		if source == "" {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		endpointCtx, cancel := context.WithTimeout(ctx, EndpointBudget)
+		identity, err := challengeEndpoint(endpointCtx, dial, ip, endpoint, source)
+		cancel()
+		if err != nil || identity.Empty() {
+			continue
+		}
+		observations = append(observations, Observation{Port: endpoint.Port, Source: source, Identity: identity})
+	}
+	return observations
+}
+
+// EndpointSource selects the challenge protocol for an endpoint, or returns
+// an empty string for endpoints the collector must leave alone.
+func EndpointSource(endpoint Endpoint) string {
+	service := strings.ToLower(strings.TrimSpace(endpoint.Service))
+	switch {
+	case endpoint.Port == 445 || service == "microsoft-ds" || service == "smb":
+		return SourceSMB
+	case endpoint.Port == 3389 || service == "ms-wbt-server" || service == "rdp":
+		return SourceRDP
+	case endpoint.Port == 5985 || endpoint.Port == 5986 || service == "winrm" || service == "wsman":
+		return SourceWinRM
+	case service == "http" || service == "https":
+		return SourceHTTP
+	default:
+		return ""
+	}
+}
+
+func challengeEndpoint(ctx context.Context, dial Dialer, ip string, endpoint Endpoint, source string) (Identity, error) {
+	conn, err := dial(ctx, "tcp", net.JoinHostPort(ip, strconv.Itoa(endpoint.Port)))
+	if err != nil {
+		return Identity{}, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+	switch source {
+	case SourceSMB:
+		return smbChallenge(conn)
+	case SourceRDP:
+		return rdpChallenge(conn)
+	case SourceWinRM:
+		useTLS := endpoint.Port == 5986 || strings.EqualFold(endpoint.Service, "https")
+		return httpChallenge(conn, ip, endpoint.Port, useTLS, "POST", "/wsman", "Negotiate")
+	case SourceHTTP:
+		return httpChallenge(conn, ip, endpoint.Port, strings.EqualFold(endpoint.Service, "https"), "GET", "/", "NTLM")
+	default:
+		return Identity{}, fmt.Errorf("unsupported identity source: %s", source)
+	}
+}
+
+// smbChallenge negotiates SMB 2.0.2/2.1 and sends one SESSION_SETUP carrying
+// the NTLM negotiate token. The server's MORE_PROCESSING_REQUIRED response
+// holds the challenge; the session is then abandoned.
+func smbChallenge(conn net.Conn) (Identity, error) {
+	negotiate := make([]byte, 36+4)
+	binary.LittleEndian.PutUint16(negotiate, 36)
+	binary.LittleEndian.PutUint16(negotiate[2:], 2)
+	binary.LittleEndian.PutUint16(negotiate[4:], 1)
+	if _, err := rand.Read(negotiate[12:28]); err != nil {
+		return Identity{}, err
+	}
+	binary.LittleEndian.PutUint16(negotiate[36:], 0x0202)
+	binary.LittleEndian.PutUint16(negotiate[38:], 0x0210)
+	if err := writeSMB2(conn, 0, 0, negotiate); err != nil {
+		return Identity{}, err
+	}
+	response, err := readNetBIOSFrame(conn)
+	if err != nil {
+		return Identity{}, err
+	}
+	if len(response) < 64 || string(response[:4]) != "\xfeSMB" || binary.LittleEndian.Uint32(response[8:]) != 0 {
+		return Identity{}, errors.New("SMB2 negotiate was rejected")
+	}
+	token := spnegoNegTokenInit(ntlmNegotiateMessage())
+	setup := make([]byte, 24, 24+len(token))
+	binary.LittleEndian.PutUint16(setup, 25)
+	setup[3] = 1
+	binary.LittleEndian.PutUint16(setup[12:], 64+24)
+	binary.LittleEndian.PutUint16(setup[14:], uint16(len(token)))
+	if err := writeSMB2(conn, 1, 1, append(setup, token...)); err != nil {
+		return Identity{}, err
+	}
+	response, err = readNetBIOSFrame(conn)
+	if err != nil {
+		return Identity{}, err
+	}
+	return findNTLMChallenge(response)
+}
+
+func writeSMB2(conn net.Conn, command uint16, messageID uint64, body []byte) error {
+	header := make([]byte, 64)
+	copy(header, "\xfeSMB")
+	binary.LittleEndian.PutUint16(header[4:], 64)
+	binary.LittleEndian.PutUint16(header[12:], command)
+	binary.LittleEndian.PutUint16(header[14:], 1)
+	binary.LittleEndian.PutUint64(header[24:], messageID)
+	packet := append(header, body...)
+	frame := []byte{0, byte(len(packet) >> 16), byte(len(packet) >> 8), byte(len(packet))}
+	_, err := conn.Write(append(frame, packet...))
+	return err
+}
+
+func readNetBIOSFrame(conn net.Conn) ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, err
+	}
+	length := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+	if header[0] != 0 || length > maxResponse {
+		return nil, errors.New("invalid NetBIOS session frame")
+	}
+	frame := make([]byte, length)
+	_, err := io.ReadFull(conn, frame)
+	return frame, err
+}
+
+// rdpChallenge requests CredSSP, completes TLS and sends a TSRequest with the
+// NTLM negotiate token. The server's TSRequest reply carries the challenge.
+func rdpChallenge(conn net.Conn) (Identity, error) {
+	// X.224 Connection Request with RDP_NEG_REQ for TLS | CredSSP.
+	request := []byte{0x03, 0x00, 0x00, 0x13, 0x0e, 0xe0, 0, 0, 0, 0, 0, 0x01, 0x00, 0x08, 0x00, 0x03, 0x00, 0x00, 0x00}
+	if _, err := conn.Write(request); err != nil {
+		return Identity{}, err
+	}
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return Identity{}, err
+	}
+	length := int(binary.BigEndian.Uint16(header[2:]))
+	if header[0] != 0x03 || length < 11 || length > 512 {
+		return Identity{}, errors.New("invalid RDP connection confirm")
+	}
+	confirm := make([]byte, length-4)
+	if _, err := io.ReadFull(conn, confirm); err != nil {
+		return Identity{}, err
+	}
+	if len(confirm) < 15 || confirm[7] != 0x02 {
+		return Identity{}, errors.New("RDP server did not select CredSSP")
+	}
+	if selected := binary.LittleEndian.Uint32(confirm[11:]); selected&0x0a == 0 {
+		return Identity{}, errors.New("RDP server did not select CredSSP")
+	}
+	// The challenge precedes any credential, so the RDP certificate is not
+	// authenticated; nothing secret is sent over this channel.
+	secure := tls.Client(conn, &tls.Config{InsecureSkipVerify: true}) //nolint:gosec
+	if err := secure.Handshake(); err != nil {
+		return Identity{}, err
+	}
+	if _, err := secure.Write(credSSPNegotiate(ntlmNegotiateMessage())); err != nil {
+		return Identity{}, err
+	}
+	response := make([]byte, maxResponse)
+	read, err := io.ReadAtLeast(secure, response, 2)
+	if err != nil && read == 0 {
+		return Identity{}, err
+	}
+	return findNTLMChallenge(response[:read])
+}
+
+// httpChallenge sends one request carrying the negotiate token and reads the
+// 401 challenge from WWW-Authenticate. The connection is closed afterwards,
+// which abandons the NTLM handshake.
+func httpChallenge(conn net.Conn, ip string, port int, useTLS bool, method, path, scheme string) (Identity, error) {
+	if useTLS {
+		secure := tls.Client(conn, &tls.Config{InsecureSkipVerify: true}) //nolint:gosec
+		if err := secure.Handshake(); err != nil {
+			return Identity{}, err
+		}
+		conn = secure
+	}
+	token := base64.StdEncoding.EncodeToString(ntlmNegotiateMessage())
+	request := fmt.Sprintf("%s %s HTTP/1.1\r\nHost: %s\r\nAuthorization: %s %s\r\nContent-Length: 0\r\nConnection: close\r\nUser-Agent: yscan\r\n\r\n",
+		method, path, net.JoinHostPort(ip, strconv.Itoa(port)), scheme, token)
+	if _, err := io.WriteString(conn, request); err != nil {
+		return Identity{}, err
+	}
+	response, err := http.ReadResponse(bufio.NewReader(io.LimitReader(conn, maxResponse)), nil)
+	if err != nil {
+		return Identity{}, err
+	}
+	_ = response.Body.Close()
+	for _, value := range response.Header.Values("WWW-Authenticate") {
+		name, encoded, found := strings.Cut(strings.TrimSpace(value), " ")
+		if !found || (!strings.EqualFold(name, "NTLM") && !strings.EqualFold(name, "Negotiate")) {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			continue
+		}
+		if identity, err := findNTLMChallenge(decoded); err == nil {
+			return identity, nil
+		}
+	}
+	return Identity{}, ErrNoChallenge
+}
+
+// spnegoNegTokenInit wraps an NTLM token in the GSS-API SPNEGO envelope SMB
+// servers expect.
+func spnegoNegTokenInit(token []byte) []byte {
+	spnegoOID := []byte{0x06, 0x06, 0x2b, 0x06, 0x01, 0x05, 0x05, 0x02}
+	ntlmOID := []byte{0x06, 0x0a, 0x2b, 0x06, 0x01, 0x04, 0x01, 0x82, 0x37, 0x02, 0x02, 0x0a}
+	mechTypes := derElement(0xa0, derElement(0x30, ntlmOID))
+	mechToken := derElement(0xa2, derElement(0x04, token))
+	negTokenInit := derElement(0xa0, derElement(0x30, append(mechTypes, mechToken...)))
+	return derElement(0x60, append(spnegoOID, negTokenInit...))
+}
+
+// credSSPNegotiate builds TSRequest { version 6, negoTokens { { token } } }.
+func credSSPNegotiate(token []byte) []byte {
+	version := derElement(0xa0, derElement(0x02, []byte{0x06}))
+	negoToken := derElement(0x30, derElement(0xa0, derElement(0x04, token)))
+	negoTokens := derElement(0xa1, derElement(0x30, negoToken))
+	return derElement(0x30, append(version, negoTokens...))
+}
+
+func derElement(tag byte, content []byte) []byte {
+	length := len(content)
+	var encoded []byte
+	switch {
+	case length < 0x80:
+		encoded = []byte{tag, byte(length)}
+	case length < 0x100:
+		encoded = []byte{tag, 0x81, byte(length)}
+	default:
+		encoded = []byte{tag, 0x82, byte(length >> 8), byte(length)}
+	}
+	return append(encoded, content...)
+}
@@ -0,0 +1,171 @@
+// Package hostid collects host identity that services disclose before any
+// credential is presented. Collectors stop after the first server challenge
+// or key-exchange reply and never complete an authentication exchange.
+package hostid
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	ntlmNegotiateUnicode       = 0x00000001
+	ntlmNegotiateOEM           = 0x00000002
+	ntlmRequestTarget          = 0x00000004
+	ntlmNegotiateNTLM          = 0x00000200
+	ntlmNegotiateAlwaysSign    = 0x00008000
+	ntlmNegotiateExtendedSec   = 0x00080000
+	ntlmNegotiateTargetInfo    = 0x00800000
+	ntlmNegotiateVersion       = 0x02000000
+	ntlmNegotiate128           = 0x20000000
+	ntlmNegotiateKeyExchange   = 0x40000000
+	ntlmNegotiate56            = 0x80000000
+	ntlmMessageNegotiate       = 1
+	ntlmMessageChallenge       = 2
+	ntlmAvEOL                  = 0
+	ntlmAvNbComputerName       = 1
+	ntlmAvNbDomainName         = 2
+	ntlmAvDNSComputerName      = 3
+	ntlmAvDNSDomainName        = 4
+	ntlmAvDNSTreeName          = 5
+	ntlmChallengeMinimumLength = 48
+	maxIdentityField           = 256
+)
+
+var (
+	ntlmSignature = []byte("NTLMSSP\x00")
+	// ErrNoChallenge reports a service that answered without an NTLM
+	// challenge, for example because NTLM is disabled.
+	ErrNoChallenge = errors.New("no NTLM challenge in response")
+)
+
+// Identity is the host naming a Windows service disclosed in its challenge.
+type Identity struct {
+	NetBIOSComputerName string
+	NetBIOSDomainName   string
+	DNSComputerName     string
+	DNSDomainName       string
+	DNSTreeName         string
+	OSVersion           string
+}
+
+// Empty reports whether the challenge carried no usable identity.
+func (identity Identity) Empty() bool {
+	return identity == Identity{}
+}
+
+// ntlmNegotiateMessage is an anonymous NEGOTIATE_MESSAGE without domain or
+// workstation names.
+func ntlmNegotiateMessage() []byte {
+	message := make([]byte, 40)
+	copy(message, ntlmSignature)
+	binary.LittleEndian.PutUint32(message[8:], ntlmMessageNegotiate)
+	flags := uint32(ntlmNegotiateUnicode | ntlmNegotiateOEM | ntlmRequestTarget | ntlmNegotiateNTLM |
+		ntlmNegotiateAlwaysSign | ntlmNegotiateExtendedSec | ntlmNegotiateVersion |
+		ntlmNegotiate128 | ntlmNegotiateKeyExchange | ntlmNegotiate56)
+	binary.LittleEndian.PutUint32(message[12:], flags)
+	// Version: 6.1 build 7601, NTLM revision 15.
+	message[32], message[33] = 6, 1
+	binary.LittleEndian.PutUint16(message[34:], 7601)
+	message[39] = 15
+	return message
+}
+
+// findNTLMChallenge locates and parses the first CHALLENGE_MESSAGE inside a
+// protocol response. SPNEGO, CredSSP and SMB framing are skipped by searching
+// for the NTLMSSP signature rather than decoding every wrapper.
+func findNTLMChallenge(response []byte) (Identity, error) {
+	for offset := 0; offset < len(response); {
+		index := bytes.Index(response[offset:], ntlmSignature)
+		if index < 0 {
+			break
+		}
+		start := offset + index
+		if identity, err := parseNTLMChallenge(response[start:]); err == nil {
+			return identity, nil
+		}
+		offset = start + len(ntlmSignature)
+	}
+	return Identity{}, ErrNoChallenge
+}
+
+func parseNTLMChallenge(message []byte) (Identity, error) {
+	if len(message) < ntlmChallengeMinimumLength || !bytes.Equal(message[:8], ntlmSignature) {
+		return Identity{}, errors.New("truncated NTLM challenge")
+	}
+	if binary.LittleEndian.Uint32(message[8:]) != ntlmMessageChallenge {
+		return Identity{}, errors.New("not an NTLM challenge")
+	}
+	flags := binary.LittleEndian.Uint32(message[20:])
+	var identity Identity
+	infoLength := int(binary.LittleEndian.Uint16(message[40:]))
+	infoOffset := int(binary.LittleEndian.Uint32(message[44:]))
+	if flags&ntlmNegotiateVersion != 0 && len(message) >= 56 && (infoLength == 0 || infoOffset >= 56) {
+		major, minor := message[48], message[49]
+		build := binary.LittleEndian.Uint16(message[50:])
+		if major > 0 {
+			identity.OSVersion = fmt.Sprintf("%d.%d.%d", major, minor, build)
+		}
+	}
+	if infoLength > 0 {
+		if infoOffset < ntlmChallengeMinimumLength || infoOffset > len(message) || infoLength > len(message)-infoOffset {
+			return Identity{}, errors.New("invalid NTLM target info")
+		}
+		if err := parseNTLMTargetInfo(message[infoOffset:infoOffset+infoLength], &identity); err != nil {
+			return Identity{}, err
+		}
+	}
+	return identity, nil
+}
+
+func parseNTLMTargetInfo(info []byte, identity *Identity) error {
+	for len(info) >= 4 {
+		id := binary.LittleEndian.Uint16(info)
+		length := int(binary.LittleEndian.Uint16(info[2:]))
+		if id == ntlmAvEOL {
+			return nil
+		}
+		if length > len(info)-4 {
+			return errors.New("invalid NTLM AV pair")
+		}
+		value := info[4 : 4+length]
+		info = info[4+length:]
+		switch id {
+		case ntlmAvNbComputerName:
+			identity.NetBIOSComputerName = decodeUTF16Field(value)
+		case ntlmAvNbDomainName:
+			identity.NetBIOSDomainName = decodeUTF16Field(value)
+		case ntlmAvDNSComputerName:
+			identity.DNSComputerName = strings.ToLower(decodeUTF16Field(value))
+		case ntlmAvDNSDomainName:
+			identity.DNSDomainName = strings.ToLower(decodeUTF16Field(value))
+		case ntlmAvDNSTreeName:
+			identity.DNSTreeName = strings.ToLower(decodeUTF16Field(value))
+		}
+	}
+	return nil
+}
+
+// decodeUTF16Field decodes a bounded UTF-16LE value and drops control
+// characters so disclosed names are safe to render.
+func decodeUTF16Field(value []byte) string {
+	units := make([]uint16, 0, len(value)/2)
+	for index := 0; index+1 < len(value); index += 2 {
+		units = append(units, binary.LittleEndian.Uint16(value[index:]))
+	}
+	decoded := strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, string(utf16.Decode(units)))
+	decoded = strings.TrimSpace(decoded)
+	if len(decoded) > maxIdentityField {
+		decoded = strings.ToValidUTF8(decoded[:maxIdentityField], "")
+	}
+	return decoded
+}
@@ -0,0 +1,169 @@
+package hostid
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestFindNTLMChallengeParsesTargetInfo(t *testing.T) {
+	challenge := testNTLMChallenge(map[uint16]string{
+		ntlmAvNbComputerName:  "FILES01",
+		ntlmAvNbDomainName:    "CORP",
+		ntlmAvDNSComputerName: "Files01.Corp.Example",
+		ntlmAvDNSDomainName:   "Corp.Example",
+		ntlmAvDNSTreeName:     "Example\x07",
+	})
+	identity, err := findNTLMChallenge(append([]byte("\xa1\x81\x9f\x30\x00 wrapper "), challenge...))
+	if err != nil {
+		t.Fatalf("find challenge: %v", err)
+	}
+	want := Identity{
+		NetBIOSComputerName: "FILES01",
+		NetBIOSDomainName:   "CORP",
+		DNSComputerName:     "files01.corp.example",
+		DNSDomainName:       "corp.example",
+		DNSTreeName:         "example",
+		OSVersion:           "10.0.20348",
+	}
+	if identity != want {
+		t.Fatalf("identity = %#v, want %#v", identity, want)
+	}
+}
+
+func TestFindNTLMChallengeRejectsMalformedMessages(t *testing.T) {
+	truncated := testNTLMChallenge(map[uint16]string{ntlmAvNbComputerName: "HOST"})
+	binary.LittleEndian.PutUint16(truncated[40:], 0x4000)
+	for name, response := range map[string][]byte{
+		"empty":       nil,
+		"negotiate":   ntlmNegotiateMessage(),
+		"short":       append([]byte(nil), ntlmSignature...),
+		"target info": truncated,
+	} {
+		if _, err := findNTLMChallenge(response); !errors.Is(err, ErrNoChallenge) {
+			t.Fatalf("%s: error = %v, want ErrNoChallenge", name, err)
+		}
+	}
+}
+
+func TestCollectReadsHTTPAndWinRMChallenges(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	challenge := base64.StdEncoding.EncodeToString(testNTLMChallenge(map[uint16]string{ntlmAvNbComputerName: "WEB01", ntlmAvNbDomainName: "CORP"}))
+	requests := make(chan string, 2)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			request, err := http.ReadRequest(bufio.NewReader(conn))
+			if err != nil {
+				_ = conn.Close()
+				continue
+			}
+			requests <- request.Method + " " + request.URL.Path + " " + strings.Fields(request.Header.Get("Authorization"))[0]
+			scheme := "NTLM"
+			if request.URL.Path == "/wsman" {
+				scheme = "Negotiate"
+			}
+			_, _ = io.WriteString(conn, "HTTP/1.1 401 Unauthorized\r\nWWW-Authenticate: "+scheme+" "+challenge+"\r\nContent-Length: 0\r\n\r\n")
+			_ = conn.Close()
+		}
+	}()
+	dial := func(ctx context.Context, network, _ string) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, network, listener.Addr().String())
+	}
+
+	observations := collectWithDialer(context.Background(), "192.168.10.10", []Endpoint{
+		{Port: 22, Service: "ssh"},
+		{Port: 80, Service: "http"},
+		{Port: 5985, Service: "http"},
+	}, dial)
+	if len(observations) != 2 {
+		t.Fatalf("observations = %#v, want HTTP and WinRM", observations)
+	}
+	if observations[0].Source != SourceHTTP || observations[0].Port != 80 || observations[1].Source != SourceWinRM || observations[1].Port != 5985 {
+		t.Fatalf("observation sources = %#v", observations)
+	}
+	if observations[1].Identity.NetBIOSComputerName != "WEB01" || observations[1].Identity.NetBIOSDomainName != "CORP" {
+		t.Fatalf("identity = %#v", observations[1].Identity)
+	}
+	if first, second := <-requests, <-requests; first != "GET / NTLM" || second != "POST /wsman Negotiate" {
+		t.Fatalf("requests = %q, %q", first, second)
+	}
+}
+
+func TestCollectReadsSMBChallenge(t *testing.T) {
+	client, server := net.Pipe()
+	challenge := testNTLMChallenge(map[uint16]string{ntlmAvNbComputerName: "DC01", ntlmAvDNSTreeName: "corp.example"})
+	go func() {
+		defer server.Close()
+		for command := uint16(0); command < 2; command++ {
+			request, err := readNetBIOSFrame(server)
+			if err != nil || len(request) < 64 || binary.LittleEndian.Uint16(request[12:]) != command {
+				return
+			}
+			response := make([]byte, 64)
+			copy(response, "\xfeSMB")
+			binary.LittleEndian.PutUint16(response[12:], command)
+			if command == 1 {
+				binary.LittleEndian.PutUint32(response[8:], 0xc0000016)
+				response = append(response, challenge...)
+			}
+			frame := []byte{0, byte(len(response) >> 16), byte(len(response) >> 8), byte(len(response))}
+			if _, err := server.Write(append(frame, response...)); err != nil {
+				return
+			}
+		}
+	}()
+	dial := func(context.Context, string, string) (net.Conn, error) { return client, nil }
+
+	observations := collectWithDialer(context.Background(), "192.168.10.20", []Endpoint{{Port: 445, Service: "microsoft-ds"}}, dial)
+	if len(observations) != 1 || observations[0].Source != SourceSMB {
+		t.Fatalf("observations = %#v", observations)
+	}
+	if identity := observations[0].Identity; identity.NetBIOSComputerName != "DC01" || identity.DNSTreeName != "corp.example" {
+		t.Fatalf("identity = %#v", identity)
+	}
+}
+
+func testNTLMChallenge(pairs map[uint16]string) []byte {
+	info := make([]byte, 0)
+	for _, id := range []uint16{ntlmAvNbComputerName, ntlmAvNbDomainName, ntlmAvDNSComputerName, ntlmAvDNSDomainName, ntlmAvDNSTreeName} {
+		value, found := pairs[id]
+		if !found {
+			continue
+		}
+		encoded := make([]byte, 0)
+		for _, unit := range utf16.Encode([]rune(value)) {
+			encoded = binary.LittleEndian.AppendUint16(encoded, unit)
+		}
+		info = binary.LittleEndian.AppendUint16(info, id)
+		info = binary.LittleEndian.AppendUint16(info, uint16(len(encoded)))
+		info = append(info, encoded...)
+	}
+	info = append(info, 0, 0, 0, 0)
+	message := make([]byte, 56)
+	copy(message, ntlmSignature)
+	binary.LittleEndian.PutUint32(message[8:], ntlmMessageChallenge)
+	binary.LittleEndian.PutUint32(message[20:], ntlmNegotiateVersion|ntlmNegotiateTargetInfo|ntlmNegotiateUnicode)
+	binary.LittleEndian.PutUint16(message[40:], uint16(len(info)))
+	binary.LittleEndian.PutUint16(message[42:], uint16(len(info)))
+	binary.LittleEndian.PutUint32(message[44:], 56)
+	message[48], message[49] = 10, 0
+	binary.LittleEndian.PutUint16(message[50:], 20348)
+	return append(message, info...)
+}
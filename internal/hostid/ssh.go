@@ -0,0 +1,399 @@
+package hostid
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	sshMsgDisconnect = 1
+	sshMsgIgnore     = 2
+	sshMsgDebug      = 4
+	sshMsgKexInit    = 20
+	sshMsgKexInitKey = 30
+	sshMsgKexReply   = 31
+
+	maxSSHPacket      = 35000
+	maxSSHVersionScan = 8 << 10
+	// maxSSHHandshakes bounds the key-exchange attempts per endpoint: one
+	// to learn the offered algorithms, then one per remaining key family.
+	maxSSHHandshakes = 5
+)
+
+// sshKexAlgorithms lists the exchanges the collector can start. The shared
+// secret is never derived, so the exchange only has to reach the server's
+// reply carrying its host key.
+var sshKexAlgorithms = []string{
+	"curve25519-sha256", "curve25519-sha256@libssh.org",
+	"ecdh-sha2-nistp256", "ecdh-sha2-nistp384", "ecdh-sha2-nistp521",
+	"diffie-hellman-group14-sha256", "diffie-hellman-group14-sha1",
+}
+
+// sshHostKeyFamilies groups signature algorithms by the key they present.
+// RSA keys are requested through any of their signature names.
+var sshHostKeyFamilies = [][]string{
+	{"ssh-ed25519"},
+	{"ecdsa-sha2-nistp256"},
+	{"ecdsa-sha2-nistp384"},
+	{"ecdsa-sha2-nistp521"},
+	{"rsa-sha2-512", "rsa-sha2-256", "ssh-rsa"},
+	{"ssh-dss"},
+}
+
+// RFC 3526 group 14.
+var sshGroup14Prime, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74020BBEA63B139B22514A08798E3404DDEF9519B3CD3A431B302B0A6DF25F14374FE1356D6D51C245E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7EDEE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3DC2007CB8A163BF0598DA48361C55D39A69163FA8FD24CF5F83655D23DCA3AD961C62F356208552BB9ED529077096966D670C354E4ABC9804F1746C08CA18217C32905E462E36CE3BE39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9DE2BCBF6955817183995497CEA956AE515D2261898FA051015728E5A8AACAA68FFFFFFFFFFFFFFFF", 16)
+
+var errSSHNoCommonAlgorithm = errors.New("SSH server offers no supported key exchange")
+
+// SSHHostKey is one public host key presented during key exchange.
+type SSHHostKey struct {
+	Algorithm string
+	// Fingerprint is the OpenSSH form: SHA256 over the key blob, unpadded
+	// base64, prefixed with "SHA256:".
+	Fingerprint string
+	Bits        int
+}
+
+// IsSSHEndpoint reports whether the collector should ask the endpoint for
+// host keys.
+func IsSSHEndpoint(endpoint Endpoint) bool {
+	return endpoint.Port == 22 || strings.EqualFold(strings.TrimSpace(endpoint.Service), "ssh")
+}
+
+// CollectSSHHostKeys performs key-exchange-only handshakes against one SSH
+// endpoint and returns every host key family the server offers. No user
+// authentication request is ever sent.
+func CollectSSHHostKeys(ctx context.Context, ip string, port int) ([]SSHHostKey, error) {
+	dialer := &net.Dialer{Timeout: EndpointBudget}
+	return collectSSHHostKeysWithDialer(ctx, ip, port, dialer.DialContext)
+}
+
+func collectSSHHostKeysWithDialer(ctx context.Context, ip string, port int, dial Dialer) ([]SSHHostKey, error) {
+	address := net.JoinHostPort(ip, strconv.Itoa(port))
+	first, offered, err := sshHostKeyHandshake(ctx, dial, address, sshAllHostKeyAlgorithms())
+	if err != nil {
+		return nil, err
+	}
+	keys := []SSHHostKey{first}
+	collected := map[string]struct{}{sshHostKeyFamily(first.Algorithm): {}}
+	attempts := 1
+	for _, family := range sshHostKeyFamilies {
+		if attempts >= maxSSHHandshakes || ctx.Err() != nil {
+			break
+		}
+		if _, done := collected[family[0]]; done || !sshOffersAny(offered, family) {
+			continue
+		}
+		attempts++
+		key, _, err := sshHostKeyHandshake(ctx, dial, address, family)
+		if err != nil {
+			continue
+		}
+		if _, duplicate := collected[sshHostKeyFamily(key.Algorithm)]; duplicate {
+			continue
+		}
+		collected[sshHostKeyFamily(key.Algorithm)] = struct{}{}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func sshAllHostKeyAlgorithms() []string {
+	algorithms := make([]string, 0)
+	for _, family := range sshHostKeyFamilies {
+		algorithms = append(algorithms, family...)
+	}
+	return algorithms
+}
+
+func sshHostKeyFamily(keyType string) string {
+	for _, family := range sshHostKeyFamilies {
+		for _, algorithm := range family {
+			if algorithm == keyType {
+				return family[0]
+			}
+		}
+	}
+	return keyType
+}
+
+func sshOffersAny(offered []string, wanted []string) bool {
+	for _, algorithm := range wanted {
+		for _, candidate := range offered {
+			if algorithm == candidate {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sshHostKeyHandshake runs one exchange up to the server's KEX reply and
+// returns the presented host key with the server's offered host key
+// algorithms.
+func sshHostKeyHandshake(ctx context.Context, dial Dialer, address string, hostKeyAlgorithms []string) (SSHHostKey, []string, error) {
+	handshakeCtx, cancel := context.WithTimeout(ctx, EndpointBudget)
+	defer cancel()
+	conn, err := dial(handshakeCtx, "tcp", address)
+	if err != nil {
+		return SSHHostKey{}, nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := handshakeCtx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(handshakeCtx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if _, err := io.WriteString(conn, "SSH-2.0-yscan\r\n"); err != nil {
+		return SSHHostKey{}, nil, err
+	}
+	reader := bufio.NewReaderSize(conn, 4096)
+	if err := readSSHVersion(reader); err != nil {
+		return SSHHostKey{}, nil, err
+	}
+	if err := writeSSHPacket(conn, sshClientKexInit(hostKeyAlgorithms)); err != nil {
+		return SSHHostKey{}, nil, err
+	}
+	serverKexInit, err := readSSHMessage(reader, sshMsgKexInit)
+	if err != nil {
+		return SSHHostKey{}, nil, err
+	}
+	serverKex, serverHostKeys, err := parseSSHKexInit(serverKexInit)
+	if err != nil {
+		return SSHHostKey{}, nil, err
+	}
+	kex := sshNegotiate(sshKexAlgorithms, serverKex)
+	if kex == "" || sshNegotiate(hostKeyAlgorithms, serverHostKeys) == "" {
+		return SSHHostKey{}, serverHostKeys, errSSHNoCommonAlgorithm
+	}
+	initMessage, err := sshKexInitMessage(kex)
+	if err != nil {
+		return SSHHostKey{}, serverHostKeys, err
+	}
+	if err := writeSSHPacket(conn, initMessage); err != nil {
+		return SSHHostKey{}, serverHostKeys, err
+	}
+	reply, err := readSSHMessage(reader, sshMsgKexReply)
+	if err != nil {
+		return SSHHostKey{}, serverHostKeys, err
+	}
+	blob, _, ok := readSSHString(reply[1:])
+	if !ok {
+		return SSHHostKey{}, serverHostKeys, errors.New("invalid SSH KEX reply")
+	}
+	key, err := parseSSHHostKey(blob)
+	return key, serverHostKeys, err
+}
+
+func readSSHVersion(reader *bufio.Reader) error {
+	consumed := 0
+	for consumed < maxSSHVersionScan {
+		line, err := reader.ReadString('\n')
+		consumed += len(line)
+		if strings.HasPrefix(line, "SSH-") {
+			if strings.HasPrefix(line, "SSH-2.0-") || strings.HasPrefix(line, "SSH-1.99-") {
+				return nil
+			}
+			return fmt.Errorf("unsupported SSH protocol version: %q", strings.TrimSpace(line))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return errors.New("SSH version line not found")
+}
+
+func sshClientKexInit(hostKeyAlgorithms []string) []byte {
+	message := []byte{sshMsgKexInit}
+	cookie := make([]byte, 16)
+	_, _ = rand.Read(cookie)
+	message = append(message, cookie...)
+	ciphers := "aes128-ctr,aes256-ctr,aes128-gcm@openssh.com,aes256-gcm@openssh.com,chacha20-poly1305@openssh.com,aes128-cbc,aes256-cbc,3des-cbc"
+	macs := "hmac-sha2-256,hmac-sha2-512,hmac-sha1,hmac-sha2-256-etm@openssh.com,hmac-sha2-512-etm@openssh.com"
+	for _, list := range []string{
+		strings.Join(sshKexAlgorithms, ","), strings.Join(hostKeyAlgorithms, ","),
+		ciphers, ciphers, macs, macs, "none,zlib@openssh.com,zlib", "none,zlib@openssh.com,zlib", "", "",
+	} {
+		message = appendSSHString(message, []byte(list))
+	}
+	return append(message, 0, 0, 0, 0, 0)
+}
+
+func parseSSHKexInit(message []byte) ([]string, []string, error) {
+	if len(message) < 17 {
+		return nil, nil, errors.New("truncated SSH KEXINIT")
+	}
+	rest := message[17:]
+	lists := make([][]string, 0, 2)
+	for index := 0; index < 2; index++ {
+		value, next, ok := readSSHString(rest)
+		if !ok {
+			return nil, nil, errors.New("truncated SSH KEXINIT")
+		}
+		lists = append(lists, strings.Split(string(value), ","))
+		rest = next
+	}
+	return lists[0], lists[1], nil
+}
+
+func sshNegotiate(client, server []string) string {
+	for _, algorithm := range client {
+		for _, offered := range server {
+			if algorithm == offered {
+				return algorithm
+			}
+		}
+	}
+	return ""
+}
+
+func sshKexInitMessage(kex string) ([]byte, error) {
+	message := []byte{sshMsgKexInitKey}
+	var curve ecdh.Curve
+	switch kex {
+	case "curve25519-sha256", "curve25519-sha256@libssh.org":
+		curve = ecdh.X25519()
+	case "ecdh-sha2-nistp256":
+		curve = ecdh.P256()
+	case "ecdh-sha2-nistp384":
+		curve = ecdh.P384()
+	case "ecdh-sha2-nistp521":
+		curve = ecdh.P521()
+	case "diffie-hellman-group14-sha256", "diffie-hellman-group14-sha1":
+		exponent, err := rand.Int(rand.Reader, new(big.Int).Sub(sshGroup14Prime, big.NewInt(2)))
+		if err != nil {
+			return nil, err
+		}
+		exponent.Add(exponent, big.NewInt(1))
+		public := new(big.Int).Exp(big.NewInt(2), exponent, sshGroup14Prime)
+		return appendSSHMPInt(message, public), nil
+	default:
+		return nil, errSSHNoCommonAlgorithm
+	}
+	private, err := curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return appendSSHString(message, private.PublicKey().Bytes()), nil
+}
+
+// parseSSHHostKey reads the key type and, for RSA and DSA, the modulus size
+// from an SSH public key blob.
+func parseSSHHostKey(blob []byte) (SSHHostKey, error) {
+	keyType, rest, ok := readSSHString(blob)
+	if !ok || len(keyType) == 0 || len(keyType) > 64 {
+		return SSHHostKey{}, errors.New("invalid SSH host key")
+	}
+	digest := sha256.Sum256(blob)
+	key := SSHHostKey{
+		Algorithm:   string(keyType),
+		Fingerprint: "SHA256:" + base64.RawStdEncoding.EncodeToString(digest[:]),
+	}
+	switch key.Algorithm {
+	case "ssh-rsa":
+		if _, rest, ok = readSSHString(rest); ok {
+			if modulus, _, ok := readSSHString(rest); ok {
+				key.Bits = new(big.Int).SetBytes(modulus).BitLen()
+			}
+		}
+	case "ssh-dss":
+		if prime, _, ok := readSSHString(rest); ok {
+			key.Bits = new(big.Int).SetBytes(prime).BitLen()
+		}
+	case "ssh-ed25519":
+		key.Bits = 256
+	case "ecdsa-sha2-nistp256":
+		key.Bits = 256
+	case "ecdsa-sha2-nistp384":
+		key.Bits = 384
+	case "ecdsa-sha2-nistp521":
+		key.Bits = 521
+	}
+	return key, nil
+}
+
+func writeSSHPacket(conn net.Conn, payload []byte) error {
+	padding := 8 - (5+len(payload))%8
+	if padding < 4 {
+		padding += 8
+	}
+	packet := make([]byte, 5, 5+len(payload)+padding)
+	binary.BigEndian.PutUint32(packet, uint32(1+len(payload)+padding))
+	packet[4] = byte(padding)
+	packet = append(packet, payload...)
+	packet = append(packet, make([]byte, padding)...)
+	_, err := conn.Write(packet)
+	return err
+}
+
+// readSSHMessage returns the next unencrypted message of the wanted type,
+// skipping IGNORE and DEBUG messages.
+func readSSHMessage(reader io.Reader, want byte) ([]byte, error) {
+	for {
+		header := make([]byte, 5)
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return nil, err
+		}
+		length := int(binary.BigEndian.Uint32(header))
+		padding := int(header[4])
+		if length < 2 || length > maxSSHPacket || padding >= length {
+			return nil, errors.New("invalid SSH packet")
+		}
+		body := make([]byte, length-1)
+		if _, err := io.ReadFull(reader, body); err != nil {
+			return nil, err
+		}
+		payload := body[:len(body)-padding]
+		if len(payload) == 0 {
+			return nil, errors.New("empty SSH packet")
+		}
+		switch payload[0] {
+		case want:
+			return payload, nil
+		case sshMsgIgnore, sshMsgDebug:
+			continue
+		case sshMsgDisconnect:
+			return nil, errors.New("SSH server disconnected during key exchange")
+		default:
+			return nil, fmt.Errorf("unexpected SSH message %d", payload[0])
+		}
+	}
+}
+
+func readSSHString(data []byte) ([]byte, []byte, bool) {
+	if len(data) < 4 {
+		return nil, nil, false
+	}
+	length := binary.BigEndian.Uint32(data)
+	if uint64(length) > uint64(len(data)-4) {
+		return nil, nil, false
+	}
+	return data[4 : 4+length], data[4+length:], true
+}
+
+func appendSSHString(data, value []byte) []byte {
+	data = binary.BigEndian.AppendUint32(data, uint32(len(value)))
+	return append(data, value...)
+}
+
+func appendSSHMPInt(data []byte, value *big.Int) []byte {
+	bytes := value.Bytes()
+	if len(bytes) > 0 && bytes[0]&0x80 != 0 {
+		bytes = append([]byte{0}, bytes...)
+	}
+	return appendSSHString(data, bytes)
+}
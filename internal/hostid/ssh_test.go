@@ -0,0 +1,103 @@
+package hostid
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCollectSSHHostKeysRequestsEachOfferedFamily(t *testing.T) {
+	ed25519Blob := appendSSHString(appendSSHString(nil, []byte("ssh-ed25519")), make([]byte, 32))
+	modulus := append([]byte{0x00, 0xc0}, make([]byte, 255)...)
+	rsaBlob := appendSSHString(appendSSHString(appendSSHString(nil, []byte("ssh-rsa")), []byte{1, 0, 1}), modulus)
+	server := &fakeSSHServer{
+		hostKeyAlgorithms: []string{"rsa-sha2-512", "ssh-ed25519", "ssh-rsa"},
+		keys:              map[string][]byte{"ssh-ed25519": ed25519Blob, "rsa-sha2-512": rsaBlob, "ssh-rsa": rsaBlob},
+	}
+	keys, err := collectSSHHostKeysWithDialer(context.Background(), "192.168.10.40", 22, server.dial)
+	if err != nil {
+		t.Fatalf("collect host keys: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("keys = %#v, want ed25519 and RSA", keys)
+	}
+	edDigest := sha256.Sum256(ed25519Blob)
+	if keys[0].Algorithm != "ssh-ed25519" || keys[0].Fingerprint != "SHA256:"+base64.RawStdEncoding.EncodeToString(edDigest[:]) || keys[0].Bits != 256 {
+		t.Fatalf("ed25519 key = %#v", keys[0])
+	}
+	if keys[1].Algorithm != "ssh-rsa" || keys[1].Bits != 2048 {
+		t.Fatalf("RSA key = %#v", keys[1])
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.handshakes != 2 || !strings.HasPrefix(server.kex[0], "curve25519-sha256") {
+		t.Fatalf("handshakes = %d, kex offers = %#v", server.handshakes, server.kex)
+	}
+}
+
+func TestCollectSSHHostKeysRejectsNonSSHService(t *testing.T) {
+	client, peer := net.Pipe()
+	go func() { _, _ = io.Copy(io.Discard, peer) }()
+	go func() {
+		_, _ = peer.Write([]byte("HTTP/1.1 400 Bad Request\r\n\r\n"))
+		_ = peer.Close()
+	}()
+	dial := func(context.Context, string, string) (net.Conn, error) { return client, nil }
+	if _, err := collectSSHHostKeysWithDialer(context.Background(), "192.168.10.41", 22, dial); err == nil {
+		t.Fatal("non-SSH service returned host keys")
+	}
+}
+
+// fakeSSHServer answers the key exchange far enough to present a host key,
+// choosing the key by the first host key algorithm both sides support.
+type fakeSSHServer struct {
+	hostKeyAlgorithms []string
+	keys              map[string][]byte
+	mu                sync.Mutex
+	handshakes        int
+	kex               []string
+}
+
+func (server *fakeSSHServer) dial(context.Context, string, string) (net.Conn, error) {
+	client, peer := net.Pipe()
+	go server.serve(peer)
+	return client, nil
+}
+
+func (server *fakeSSHServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	go func() { _, _ = conn.Write([]byte("SSH-2.0-OpenSSH_9.6\r\n")) }()
+	if line, err := reader.ReadString('\n'); err != nil || !strings.HasPrefix(line, "SSH-2.0-") {
+		return
+	}
+	clientKexInit, err := readSSHMessage(reader, sshMsgKexInit)
+	if err != nil {
+		return
+	}
+	clientKex, clientHostKeys, err := parseSSHKexInit(clientKexInit)
+	if err != nil {
+		return
+	}
+	selected := sshNegotiate(clientHostKeys, server.hostKeyAlgorithms)
+	server.mu.Lock()
+	server.handshakes++
+	server.kex = append(server.kex, strings.Join(clientKex, ","))
+	server.mu.Unlock()
+	go func() {
+		_ = writeSSHPacket(conn, sshClientKexInit(server.hostKeyAlgorithms))
+	}()
+	if _, err := readSSHMessage(reader, sshMsgKexInitKey); err != nil {
+		return
+	}
+	reply := appendSSHString([]byte{sshMsgKexReply}, server.keys[selected])
+	reply = appendSSHString(reply, make([]byte, 32))
+	reply = appendSSHString(reply, []byte("signature"))
+	_ = writeSSHPacket(conn, reply)
+}
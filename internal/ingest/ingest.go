@@ -0,0 +1,310 @@
+package ingest
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"net"
+	"sort"
+	"strings"
+)
+
+const (
+	FormatNmapXML     = "nmap-xml"
+	FormatMasscanJSON = "masscan-json"
+	// FormatPcap labels observations reconstructed from a packet capture.
+	FormatPcap = "pcap"
+
+	// MaxInputBytes bounds one imported result file. Larger exports should be
+	// split per segment so a single run snapshot stays reviewable.
+	MaxInputBytes = 256 << 20
+	// maxFieldBytes matches the active banner and probe capture limit.
+	maxFieldBytes = 16 << 10
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported import format")
+
+// Result is the externally collected observation set for one import. Only
+// IPv4 hosts and open TCP ports are retained; raw text fields are bounded and
+// never persisted directly, matching locally collected evidence.
+type Result struct {
+	Format string
+	Hosts  []Host
+	// ScannedPorts is the TCP port coverage declared by the scanner. It is
+	// empty when the format does not record coverage.
+	ScannedPorts []int
+}
+
+type Host struct {
+	IP       string
+	Services []Service
+}
+
+type Service struct {
+	Port            int
+	Name            string
+	Tunnel          string
+	Product         string
+	Version         string
+	ExtraInfo       string
+	CPEs            []string
+	Banner          string
+	BannerTruncated bool
+	HTTPServer      string
+	HTTPTitle       string
+	HTTPHeaders     map[string]string
+	HTTPStatus      int
+	// HTTPResponse is the raw response head reported by the scanner. Its
+	// parsed values populate the HTTP fields; the text itself is not stored.
+	HTTPResponse string
+	Scripts      []Script
+	// TLS fields come from captured handshakes. The certificate itself is
+	// reduced to its subject, DNS names and digest.
+	TLSServerName         string
+	TLSCertificateSubject string
+	TLSCertificateNames   []string
+	TLSCertificateSHA256  string
+	TLSCertificateLength  int
+}
+
+type Script struct {
+	ID     string
+	Output string
+}
+
+// Parse decodes one supported scanner export.
+func Parse(format string, input io.Reader) (Result, error) {
+	data, err := readBoundedInput(input)
+	if err != nil {
+		return Result{}, err
+	}
+	var result Result
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case FormatNmapXML:
+		result, err = parseNmapXML(data)
+	case FormatMasscanJSON:
+		result, err = parseMasscanJSON(data)
+	default:
+		return Result{}, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	result.Hosts = normalizeHosts(result.Hosts)
+	return result, nil
+}
+
+// WithinTarget keeps hosts inside one normalized run target and reports how
+// many were discarded. Imported files often cover more than one task scope.
+func (result Result) WithinTarget(target string) (Result, int) {
+	contains := targetMatcher(target)
+	filtered := result
+	filtered.Hosts = make([]Host, 0, len(result.Hosts))
+	skipped := 0
+	for _, host := range result.Hosts {
+		if contains(host.IP) {
+			filtered.Hosts = append(filtered.Hosts, host)
+			continue
+		}
+		skipped++
+	}
+	return filtered, skipped
+}
+
+// TargetContains reports whether an IPv4 address lies inside an IP or CIDR
+// run target.
+func TargetContains(target, ip string) bool {
+	return targetMatcher(target)(ip)
+}
+
+// CoveringTarget returns the smallest IP or CIDR target containing every
+// host, or false when there are no hosts.
+func (result Result) CoveringTarget() (string, bool) {
+	var first, last uint32
+	seen := false
+	for _, host := range result.Hosts {
+		ip := net.ParseIP(host.IP).To4()
+		if ip == nil {
+			continue
+		}
+		value := binary.BigEndian.Uint32(ip)
+		if !seen || value < first {
+			first = value
+		}
+		if !seen || value > last {
+			last = value
+		}
+		seen = true
+	}
+	if !seen {
+		return "", false
+	}
+	if first == last {
+		return ipv4FromUint32(first).String(), true
+	}
+	prefix := bits.LeadingZeros32(first ^ last)
+	network := &net.IPNet{IP: ipv4FromUint32(first), Mask: net.CIDRMask(prefix, 32)}
+	network.IP = network.IP.Mask(network.Mask)
+	return network.String(), true
+}
+
+func ipv4FromUint32(value uint32) net.IP {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, value)
+	return ip
+}
+
+func targetMatcher(target string) func(string) bool {
+	target = strings.TrimSpace(target)
+	if _, network, err := net.ParseCIDR(target); err == nil {
+		return func(ip string) bool {
+			parsed := net.ParseIP(ip).To4()
+			return parsed != nil && network.Contains(parsed)
+		}
+	}
+	single := net.ParseIP(target).To4()
+	return func(ip string) bool {
+		parsed := net.ParseIP(ip).To4()
+		return single != nil && parsed != nil && parsed.Equal(single)
+	}
+}
+
+// WebProtocol returns http or https for services whose observations describe
+// a web endpoint, and an empty string otherwise.
+func (service Service) WebProtocol() string {
+	name := strings.ToLower(strings.TrimSpace(service.Name))
+	tls := strings.EqualFold(strings.TrimSpace(service.Tunnel), "ssl") || strings.HasPrefix(name, "ssl/")
+	name = strings.TrimPrefix(name, "ssl/")
+	switch {
+	case name == "https" || name == "https-alt":
+		return "https"
+	case name == "http" || strings.HasPrefix(name, "http-") || service.HTTPResponse != "" || service.HTTPServer != "" || service.HTTPTitle != "":
+		if tls {
+			return "https"
+		}
+		return "http"
+	default:
+		return ""
+	}
+}
+
+// ServiceType is the run port service label derived from scanner naming.
+func (service Service) ServiceType() string {
+	if protocol := service.WebProtocol(); protocol != "" {
+		return protocol
+	}
+	name := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(service.Name, "ssl/")))
+	if name == "" || name == "tcpwrapped" {
+		return "unknown"
+	}
+	return name
+}
+
+// ProductLabel is the scanner-reported product summary kept as evidence. It
+// is never used as a product conclusion; only the run engine concludes.
+func (service Service) ProductLabel() string {
+	parts := make([]string, 0, 3)
+	for _, value := range []string{service.Product, service.Version} {
+		if value = strings.TrimSpace(value); value != "" {
+			parts = append(parts, value)
+		}
+	}
+	if extra := strings.TrimSpace(service.ExtraInfo); extra != "" {
+		parts = append(parts, "("+extra+")")
+	}
+	return strings.Join(parts, " ")
+}
+
+func normalizeHosts(hosts []Host) []Host {
+	byIP := make(map[string]*Host, len(hosts))
+	for _, host := range hosts {
+		ip := net.ParseIP(strings.TrimSpace(host.IP)).To4()
+		if ip == nil {
+			continue
+		}
+		current := byIP[ip.String()]
+		if current == nil {
+			current = &Host{IP: ip.String()}
+			byIP[current.IP] = current
+		}
+		current.Services = mergeServices(current.Services, host.Services)
+	}
+	result := make([]Host, 0, len(byIP))
+	for _, host := range byIP {
+		sort.Slice(host.Services, func(i, j int) bool { return host.Services[i].Port < host.Services[j].Port })
+		result = append(result, *host)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		left, right := net.ParseIP(result[i].IP).To4(), net.ParseIP(result[j].IP).To4()
+		return string(left) < string(right)
+	})
+	return result
+}
+
+func mergeServices(current, additional []Service) []Service {
+	for _, service := range additional {
+		if service.Port < 1 || service.Port > 65535 {
+			continue
+		}
+		merged := false
+		for index := range current {
+			if current[index].Port == service.Port {
+				current[index] = mergeService(current[index], service)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			current = append(current, service)
+		}
+	}
+	return current
+}
+
+func mergeService(current, service Service) Service {
+	if current.Name == "" || current.Name == "unknown" {
+		current.Name = service.Name
+	}
+	current.Tunnel = firstNonEmpty(current.Tunnel, service.Tunnel)
+	current.Product = firstNonEmpty(current.Product, service.Product)
+	current.Version = firstNonEmpty(current.Version, service.Version)
+	current.ExtraInfo = firstNonEmpty(current.ExtraInfo, service.ExtraInfo)
+	current.CPEs = append(current.CPEs, service.CPEs...)
+	if current.Banner == "" {
+		current.Banner, current.BannerTruncated = service.Banner, service.BannerTruncated
+	}
+	current.HTTPServer = firstNonEmpty(current.HTTPServer, service.HTTPServer)
+	current.HTTPTitle = firstNonEmpty(current.HTTPTitle, service.HTTPTitle)
+	current.HTTPResponse = firstNonEmpty(current.HTTPResponse, service.HTTPResponse)
+	if current.HTTPStatus == 0 {
+		current.HTTPStatus = service.HTTPStatus
+	}
+	if len(current.HTTPHeaders) == 0 {
+		current.HTTPHeaders = service.HTTPHeaders
+	}
+	current.Scripts = append(current.Scripts, service.Scripts...)
+	current.TLSServerName = firstNonEmpty(current.TLSServerName, service.TLSServerName)
+	if current.TLSCertificateSHA256 == "" {
+		current.TLSCertificateSubject, current.TLSCertificateNames = service.TLSCertificateSubject, service.TLSCertificateNames
+		current.TLSCertificateSHA256, current.TLSCertificateLength = service.TLSCertificateSHA256, service.TLSCertificateLength
+	}
+	return current
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func boundedText(value string) (string, bool) {
+	if len(value) <= maxFieldBytes {
+		return value, false
+	}
+	return value[:maxFieldBytes], true
+}
@@ -0,0 +1,116 @@
+package ingest
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const nmapSample = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE nmaprun>
+<?xml-stylesheet href="file:///usr/share/nmap/nmap.xsl" type="text/xsl"?>
+<nmaprun scanner="nmap" args="nmap -sV -oX - 10.0.0.0/24">
+<scaninfo type="syn" protocol="tcp" numservices="3" services="22,80,443"/>
+<host><status state="up"/><address addr="10.0.0.5" addrtype="ipv4"/>
+<ports>
+<port protocol="tcp" portid="80"><state state="open"/><service name="http" product="nginx" version="1.24.0"><cpe>cpe:/a:igor_sysoev:nginx:1.24.0</cpe></service>
+<script id="http-title" output="Welcome &amp; hello"><elem key="title">Welcome &amp; hello</elem></script>
+<script id="http-server-header" output="nginx/1.24.0"><elem>nginx/1.24.0</elem></script>
+</port>
+<port protocol="tcp" portid="22"><state state="open"/><service name="ssh"/><script id="banner" output="SSH-2.0-OpenSSH_9.6"/></port>
+<port protocol="tcp" portid="443"><state state="closed"/><service name="https"/></port>
+<port protocol="udp" portid="161"><state state="open"/><service name="snmp"/></port>
+</ports></host>
+<host><status state="down"/><address addr="10.0.0.6" addrtype="ipv4"/></host>
+<host><status state="up"/><address addr="10.0.0.7" addrtype="ipv4"/><ports><port protocol="tcp" portid="443"><state state="open"/><service name="http" tunnel="ssl"/><script id="http-title" output="Site doesn't have a title (text/html)."/></port></ports></host>
+</nmaprun>`
+
+func TestParseNmapXMLKeepsOpenTCPObservations(t *testing.T) {
+	result, err := Parse(FormatNmapXML, strings.NewReader(nmapSample))
+	if err != nil {
+		t.Fatalf("parse nmap xml: %v", err)
+	}
+	if want := []int{22, 80, 443}; !reflect.DeepEqual(result.ScannedPorts, want) {
+		t.Fatalf("scanned ports = %v, want %v", result.ScannedPorts, want)
+	}
+	if len(result.Hosts) != 2 || result.Hosts[0].IP != "10.0.0.5" || result.Hosts[1].IP != "10.0.0.7" {
+		t.Fatalf("hosts = %#v", result.Hosts)
+	}
+	services := result.Hosts[0].Services
+	if len(services) != 2 || services[0].Port != 22 || services[1].Port != 80 {
+		t.Fatalf("services = %#v", services)
+	}
+	if services[0].Banner != "SSH-2.0-OpenSSH_9.6" || services[0].ServiceType() != "ssh" {
+		t.Fatalf("ssh service = %#v", services[0])
+	}
+	web := services[1]
+	if web.HTTPTitle != "Welcome & hello" || web.HTTPServer != "nginx/1.24.0" || web.WebProtocol() != "http" || web.ProductLabel() != "nginx 1.24.0" || len(web.CPEs) != 1 {
+		t.Fatalf("web service = %#v", web)
+	}
+	tls := result.Hosts[1].Services[0]
+	if tls.WebProtocol() != "https" || tls.HTTPTitle != "" {
+		t.Fatalf("tls service = %#v", tls)
+	}
+}
+
+func TestParseMasscanJSONArrayWithTrailingComma(t *testing.T) {
+	input := `[
+{ "ip": "10.0.0.9", "timestamp": "1700000000", "ports": [ {"port": 80, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 64} ] },
+{ "ip": "10.0.0.9", "timestamp": "1700000001", "ports": [ {"port": 80, "proto": "tcp", "service": {"name": "http", "banner": "HTTP/1.1 200 OK\r\nServer: Apache/2.4.58\r\nContent-Type: text/html\r\n\r\n"} } ] },
+{ "ip": "10.0.0.9", "timestamp": "1700000002", "ports": [ {"port": 80, "proto": "tcp", "service": {"name": "title", "banner": "Portal"} } ] },
+{ "ip": "10.0.0.1", "timestamp": "1700000003", "ports": [ {"port": 53, "proto": "udp", "status": "open"} ] },
+]`
+	result, err := Parse(FormatMasscanJSON, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parse masscan json: %v", err)
+	}
+	if len(result.ScannedPorts) != 0 {
+		t.Fatalf("masscan coverage = %v", result.ScannedPorts)
+	}
+	if len(result.Hosts) != 2 || result.Hosts[0].IP != "10.0.0.1" || len(result.Hosts[0].Services) != 0 {
+		t.Fatalf("hosts = %#v", result.Hosts)
+	}
+	services := result.Hosts[1].Services
+	if len(services) != 1 {
+		t.Fatalf("services = %#v", services)
+	}
+	service := services[0]
+	if service.Port != 80 || service.WebProtocol() != "http" || service.HTTPStatus != 200 || service.HTTPServer != "Apache/2.4.58" || service.HTTPTitle != "Portal" {
+		t.Fatalf("merged service = %#v", service)
+	}
+}
+
+func TestParseMasscanLineRecordsAndTargetFilter(t *testing.T) {
+	input := `{"ip":"10.0.0.9","ports":[{"port":22,"proto":"tcp","status":"open"}]}
+{"ip":"10.0.0.9","ports":[{"port":22,"proto":"tcp","service":{"name":"ssh","banner":"SSH-2.0-dropbear"}}]}
+{"ip":"192.168.1.1","ports":[{"port":80,"proto":"tcp","status":"open"}]}
+`
+	result, err := Parse(FormatMasscanJSON, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parse masscan lines: %v", err)
+	}
+	filtered, skipped := result.WithinTarget("10.0.0.0/24")
+	if skipped != 1 || len(filtered.Hosts) != 1 {
+		t.Fatalf("filtered=%#v skipped=%d", filtered.Hosts, skipped)
+	}
+	service := filtered.Hosts[0].Services[0]
+	if service.ServiceType() != "ssh" || service.Banner != "SSH-2.0-dropbear" {
+		t.Fatalf("ssh service = %#v", service)
+	}
+	if !TargetContains("10.0.0.9", "10.0.0.9") || TargetContains("10.0.0.9", "10.0.0.10") {
+		t.Fatal("single IP target matching is wrong")
+	}
+}
+
+func TestParseRejectsUnsupportedFormatAndMalformedInput(t *testing.T) {
+	if _, err := Parse("csv", strings.NewReader("")); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("unsupported format err = %v", err)
+	}
+	if _, err := Parse(FormatNmapXML, strings.NewReader("<nmaprun><host>")); err == nil {
+		t.Fatal("truncated nmap xml was accepted")
+	}
+	if _, err := Parse(FormatMasscanJSON, strings.NewReader("{not json}")); err == nil {
+		t.Fatal("malformed masscan line was accepted")
+	}
+}
@@ -0,0 +1,128 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+type masscanRecord struct {
+	IP    string        `json:"ip"`
+	Ports []masscanPort `json:"ports"`
+}
+
+type masscanPort struct {
+	Port    int             `json:"port"`
+	Proto   string          `json:"proto"`
+	Status  string          `json:"status"`
+	Service *masscanService `json:"service"`
+}
+
+type masscanService struct {
+	Name   string `json:"name"`
+	Banner string `json:"banner"`
+}
+
+// masscan -oJ has historically ended its array with a dangling comma.
+var masscanTrailingComma = regexp.MustCompile(`,\s*\]\s*$`)
+
+// parseMasscanJSON accepts both `masscan -oJ` arrays and `-oD` line
+// delimited records. Port records and banner records for one endpoint are
+// separate entries and are merged here.
+func parseMasscanJSON(data []byte) (Result, error) {
+	trimmed := bytes.TrimSpace(data)
+	records := make([]masscanRecord, 0)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		trimmed = masscanTrailingComma.ReplaceAll(trimmed, []byte("]"))
+		if err := json.Unmarshal(trimmed, &records); err != nil {
+			return Result{}, fmt.Errorf("parse masscan JSON: %w", err)
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+		scanner.Buffer(make([]byte, 0, 64<<10), MaxInputBytes)
+		line := 0
+		for scanner.Scan() {
+			line++
+			text := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(scanner.Text()), ","))
+			if text == "" {
+				continue
+			}
+			var record masscanRecord
+			if err := json.Unmarshal([]byte(text), &record); err != nil {
+				return Result{}, fmt.Errorf("parse masscan JSON line %d: %w", line, err)
+			}
+			records = append(records, record)
+		}
+		if err := scanner.Err(); err != nil {
+			return Result{}, fmt.Errorf("parse masscan JSON: %w", err)
+		}
+	}
+	result := Result{Format: FormatMasscanJSON, Hosts: make([]Host, 0, len(records))}
+	for _, record := range records {
+		host := Host{IP: strings.TrimSpace(record.IP)}
+		for _, port := range record.Ports {
+			if proto := strings.ToLower(strings.TrimSpace(port.Proto)); proto != "" && proto != "tcp" {
+				continue
+			}
+			if status := strings.ToLower(strings.TrimSpace(port.Status)); status != "" && status != "open" {
+				continue
+			}
+			if port.Status == "" && port.Service == nil {
+				continue
+			}
+			host.Services = append(host.Services, masscanServiceObservation(port))
+		}
+		result.Hosts = append(result.Hosts, host)
+	}
+	return result, nil
+}
+
+func masscanServiceObservation(port masscanPort) Service {
+	service := Service{Port: port.Port}
+	if port.Service == nil {
+		return service
+	}
+	name := strings.ToLower(strings.TrimSpace(port.Service.Name))
+	banner, truncated := boundedText(port.Service.Banner)
+	switch name {
+	case "":
+	case "title":
+		service.HTTPTitle = strings.TrimSpace(banner)
+	case "http.server":
+		service.HTTPServer = strings.TrimSpace(banner)
+	case "http":
+		service.Name = "http"
+		service.HTTPResponse = banner
+		service.HTTPHeaders, service.HTTPStatus = parseResponseHead(banner)
+		service.HTTPServer = headerLookup(service.HTTPHeaders, "Server")
+	case "ssl", "x509":
+		service.Tunnel = "ssl"
+	default:
+		service.Name = name
+		service.Banner, service.BannerTruncated = banner, truncated
+	}
+	return service
+}
+
+func parseResponseHead(head string) (map[string]string, int) {
+	head = strings.ReplaceAll(head, "\r\n", "\n")
+	if !strings.HasSuffix(head, "\n\n") {
+		head = strings.TrimRight(head, "\n") + "\n\n"
+	}
+	response, err := http.ReadResponse(bufio.NewReader(strings.NewReader(strings.ReplaceAll(head, "\n", "\r\n"))), nil)
+	if err != nil {
+		return nmapHTTPHeaders(head)
+	}
+	defer response.Body.Close()
+	headers := make(map[string]string, len(response.Header))
+	for name, values := range response.Header {
+		if len(values) > 0 {
+			headers[name] = values[0]
+		}
+	}
+	return headers, response.StatusCode
+}
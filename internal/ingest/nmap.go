@@ -0,0 +1,206 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golandproject/yscan/internal/scan"
+)
+
+type nmapRun struct {
+	XMLName  xml.Name       `xml:"nmaprun"`
+	ScanInfo []nmapScanInfo `xml:"scaninfo"`
+	Hosts    []nmapHost     `xml:"host"`
+}
+
+type nmapScanInfo struct {
+	Protocol string `xml:"protocol,attr"`
+	Services string `xml:"services,attr"`
+}
+
+type nmapHost struct {
+	Status    nmapStatus    `xml:"status"`
+	Addresses []nmapAddress `xml:"address"`
+	Ports     []nmapPort    `xml:"ports>port"`
+}
+
+type nmapStatus struct {
+	State string `xml:"state,attr"`
+}
+
+type nmapAddress struct {
+	Addr     string `xml:"addr,attr"`
+	AddrType string `xml:"addrtype,attr"`
+}
+
+type nmapPort struct {
+	Protocol string       `xml:"protocol,attr"`
+	PortID   int          `xml:"portid,attr"`
+	State    nmapStatus   `xml:"state"`
+	Service  nmapService  `xml:"service"`
+	Scripts  []nmapScript `xml:"script"`
+}
+
+type nmapService struct {
+	Name      string   `xml:"name,attr"`
+	Product   string   `xml:"product,attr"`
+	Version   string   `xml:"version,attr"`
+	ExtraInfo string   `xml:"extrainfo,attr"`
+	Tunnel    string   `xml:"tunnel,attr"`
+	CPEs      []string `xml:"cpe"`
+}
+
+type nmapScript struct {
+	ID       string          `xml:"id,attr"`
+	Output   string          `xml:"output,attr"`
+	Elements []nmapScriptKey `xml:"elem"`
+}
+
+type nmapScriptKey struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// parseNmapXML reads `nmap -oX` output. Hosts reported down are ignored;
+// hosts reported up are kept even without open ports so discovery-only
+// exports still update host presence.
+func parseNmapXML(data []byte) (Result, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	// Nmap declares a DOCTYPE and an XSL stylesheet; neither is resolved.
+	decoder.Entity = xml.HTMLEntity
+	var run nmapRun
+	if err := decoder.Decode(&run); err != nil {
+		return Result{}, fmt.Errorf("parse nmap XML: %w", err)
+	}
+	result := Result{Format: FormatNmapXML, Hosts: make([]Host, 0, len(run.Hosts))}
+	for _, info := range run.ScanInfo {
+		if !strings.EqualFold(info.Protocol, "tcp") || strings.TrimSpace(info.Services) == "" {
+			continue
+		}
+		ports, err := scan.ParsePortSpec(info.Services)
+		if err != nil {
+			return Result{}, fmt.Errorf("parse nmap scaninfo services: %w", err)
+		}
+		result.ScannedPorts = append(result.ScannedPorts, ports...)
+	}
+	if len(result.ScannedPorts) > 0 {
+		result.ScannedPorts, _ = scan.ParsePortSpec(scan.FormatPortSpec(result.ScannedPorts))
+	}
+	for _, host := range run.Hosts {
+		if !strings.EqualFold(host.Status.State, "up") {
+			continue
+		}
+		ip := ""
+		for _, address := range host.Addresses {
+			if strings.EqualFold(address.AddrType, "ipv4") {
+				ip = strings.TrimSpace(address.Addr)
+				break
+			}
+		}
+		if ip == "" {
+			continue
+		}
+		imported := Host{IP: ip}
+		for _, port := range host.Ports {
+			if !strings.EqualFold(port.Protocol, "tcp") || !strings.EqualFold(port.State.State, "open") {
+				continue
+			}
+			imported.Services = append(imported.Services, nmapServiceObservation(port))
+		}
+		result.Hosts = append(result.Hosts, imported)
+	}
+	return result, nil
+}
+
+func nmapServiceObservation(port nmapPort) Service {
+	service := Service{
+		Port: port.PortID, Name: strings.TrimSpace(port.Service.Name), Tunnel: strings.TrimSpace(port.Service.Tunnel),
+		Product: strings.TrimSpace(port.Service.Product), Version: strings.TrimSpace(port.Service.Version),
+		ExtraInfo: strings.TrimSpace(port.Service.ExtraInfo),
+	}
+	for _, cpe := range port.Service.CPEs {
+		if cpe = strings.TrimSpace(cpe); cpe != "" {
+			service.CPEs = append(service.CPEs, cpe)
+		}
+	}
+	for _, script := range port.Scripts {
+		id := strings.TrimSpace(script.ID)
+		output, _ := boundedText(script.Output)
+		if id == "" {
+			continue
+		}
+		service.Scripts = append(service.Scripts, Script{ID: id, Output: output})
+		switch id {
+		case "banner":
+			service.Banner, service.BannerTruncated = boundedText(script.Output)
+		case "http-server-header":
+			service.HTTPServer = firstNonEmpty(nmapScriptElement(script, ""), strings.TrimSpace(script.Output))
+		case "http-title":
+			service.HTTPTitle = firstNonEmpty(nmapScriptElement(script, "title"), nmapTitleOutput(script.Output))
+		case "http-headers":
+			service.HTTPHeaders, service.HTTPStatus = nmapHTTPHeaders(script.Output)
+			if service.HTTPServer == "" {
+				service.HTTPServer = headerLookup(service.HTTPHeaders, "Server")
+			}
+		}
+	}
+	return service
+}
+
+func nmapScriptElement(script nmapScript, key string) string {
+	for _, element := range script.Elements {
+		if element.Key == key {
+			return strings.TrimSpace(element.Value)
+		}
+	}
+	return ""
+}
+
+// nmapTitleOutput ignores the placeholder nmap prints for untitled pages.
+func nmapTitleOutput(output string) string {
+	output = strings.TrimSpace(output)
+	if strings.HasPrefix(output, "Site doesn't have a title") {
+		return ""
+	}
+	return output
+}
+
+// nmapHTTPHeaders reads the indented "Name: value" lines of the http-headers
+// script, which prints the response head followed by a request summary.
+func nmapHTTPHeaders(output string) (map[string]string, int) {
+	headers := make(map[string]string)
+	status := 0
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "(") {
+			continue
+		}
+		if strings.HasPrefix(line, "HTTP/") {
+			fields := strings.Fields(line)
+			if len(fields) >= 2 {
+				status, _ = strconv.Atoi(fields[1])
+			}
+			continue
+		}
+		name, value, found := strings.Cut(line, ":")
+		if !found || strings.ContainsAny(name, " \t") {
+			continue
+		}
+		if _, exists := headers[name]; !exists {
+			headers[name] = strings.TrimSpace(value)
+		}
+	}
+	return headers, status
+}
+
+func headerLookup(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,551 @@
+package ingest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	pcapMagicMicro        = 0xa1b2c3d4
+	pcapMagicNano         = 0xa1b23c4d
+	pcapngSectionHeader   = 0x0a0d0d0a
+	pcapngByteOrderMagic  = 0x1a2b3c4d
+	pcapngInterfaceBlock  = 0x00000001
+	pcapngSimplePacket    = 0x00000003
+	pcapngEnhancedPacket  = 0x00000006
+	linkTypeNull          = 0
+	linkTypeEthernet      = 1
+	linkTypeRaw           = 101
+	linkTypeLoop          = 108
+	linkTypeLinuxSLL      = 113
+	linkTypeIPv4          = 228
+	linkTypeLinuxSLL2     = 276
+	tcpFlagSYN            = 0x02
+	tcpFlagACK            = 0x10
+	tcpFlagRST            = 0x04
+	passiveStreamMaxBytes = 64 << 10
+)
+
+// ParsePcap reads a libpcap or pcapng capture and reconstructs the passive
+// observations it contains. Only RFC1918 IPv4 hosts are kept; a TCP service
+// is reported only when its SYN/ACK was captured, so mid-stream traffic never
+// invents a listener.
+func ParsePcap(input io.Reader) (Result, error) {
+	data, err := readBoundedInput(input)
+	if err != nil {
+		return Result{}, err
+	}
+	capture := newPassiveCapture()
+	if err := readCapturePackets(data, capture.observe); err != nil {
+		return Result{}, err
+	}
+	result := capture.result()
+	result.Hosts = normalizeHosts(result.Hosts)
+	return result, nil
+}
+
+func readCapturePackets(data []byte, observe func(linkType uint32, frame []byte)) error {
+	if len(data) < 4 {
+		return errors.New("parse pcap: capture is too short")
+	}
+	if binary.BigEndian.Uint32(data) == pcapngSectionHeader {
+		return readPcapng(data, observe)
+	}
+	return readPcap(data, observe)
+}
+
+func readPcap(data []byte, observe func(uint32, []byte)) error {
+	if len(data) < 24 {
+		return errors.New("parse pcap: truncated global header")
+	}
+	var order binary.ByteOrder
+	switch magic := binary.LittleEndian.Uint32(data); magic {
+	case pcapMagicMicro, pcapMagicNano:
+		order = binary.LittleEndian
+	default:
+		if magic = binary.BigEndian.Uint32(data); magic != pcapMagicMicro && magic != pcapMagicNano {
+			return errors.New("parse pcap: unrecognized capture format")
+		}
+		order = binary.BigEndian
+	}
+	linkType := order.Uint32(data[20:]) & 0x0fffffff
+	for offset := 24; offset < len(data); {
+		if len(data)-offset < 16 {
+			return errors.New("parse pcap: truncated record header")
+		}
+		captured := int(order.Uint32(data[offset+8:]))
+		offset += 16
+		if captured < 0 || captured > len(data)-offset {
+			return errors.New("parse pcap: truncated record")
+		}
+		observe(linkType, data[offset:offset+captured])
+		offset += captured
+	}
+	return nil
+}
+
+// readPcapng walks the section, interface and packet blocks. Each section
+// declares its own byte order and interface table.
+func readPcapng(data []byte, observe func(uint32, []byte)) error {
+	var order binary.ByteOrder = binary.LittleEndian
+	interfaces := make([]uint32, 0)
+	for offset := 0; offset < len(data); {
+		if len(data)-offset < 12 {
+			return errors.New("parse pcapng: truncated block header")
+		}
+		if binary.BigEndian.Uint32(data[offset:]) == pcapngSectionHeader {
+			switch {
+			case binary.LittleEndian.Uint32(data[offset+8:]) == pcapngByteOrderMagic:
+				order = binary.LittleEndian
+			case binary.BigEndian.Uint32(data[offset+8:]) == pcapngByteOrderMagic:
+				order = binary.BigEndian
+			default:
+				return errors.New("parse pcapng: invalid byte order magic")
+			}
+			interfaces = interfaces[:0]
+		}
+		blockType := order.Uint32(data[offset:])
+		length := int(order.Uint32(data[offset+4:]))
+		if length < 12 || length%4 != 0 || length > len(data)-offset {
+			return errors.New("parse pcapng: invalid block length")
+		}
+		body := data[offset+8 : offset+length-4]
+		switch blockType {
+		case pcapngInterfaceBlock:
+			if len(body) < 2 {
+				return errors.New("parse pcapng: truncated interface block")
+			}
+			interfaces = append(interfaces, uint32(order.Uint16(body)))
+		case pcapngEnhancedPacket:
+			if len(body) < 20 {
+				return errors.New("parse pcapng: truncated packet block")
+			}
+			index := int(order.Uint32(body))
+			captured := int(order.Uint32(body[12:]))
+			if index >= len(interfaces) || captured < 0 || captured > len(body)-20 {
+				return errors.New("parse pcapng: invalid packet block")
+			}
+			observe(interfaces[index], body[20:20+captured])
+		case pcapngSimplePacket:
+			if len(body) < 4 || len(interfaces) == 0 {
+				return errors.New("parse pcapng: invalid simple packet block")
+			}
+			captured := min(int(order.Uint32(body)), len(body)-4)
+			observe(interfaces[0], body[4:4+captured])
+		}
+		offset += length
+	}
+	return nil
+}
+
+// ipv4Payload strips the link layer and returns the IPv4 packet, if any.
+func ipv4Payload(linkType uint32, frame []byte) []byte {
+	switch linkType {
+	case linkTypeEthernet:
+		if len(frame) < 14 {
+			return nil
+		}
+		etherType, offset := binary.BigEndian.Uint16(frame[12:]), 14
+		for (etherType == 0x8100 || etherType == 0x88a8) && len(frame) >= offset+4 {
+			etherType, offset = binary.BigEndian.Uint16(frame[offset+2:]), offset+4
+		}
+		if etherType != 0x0800 {
+			return nil
+		}
+		return frame[offset:]
+	case linkTypeRaw, linkTypeIPv4:
+		return frame
+	case linkTypeLinuxSLL:
+		if len(frame) < 16 || binary.BigEndian.Uint16(frame[14:]) != 0x0800 {
+			return nil
+		}
+		return frame[16:]
+	case linkTypeLinuxSLL2:
+		if len(frame) < 20 || binary.BigEndian.Uint16(frame) != 0x0800 {
+			return nil
+		}
+		return frame[20:]
+	case linkTypeNull, linkTypeLoop:
+		if len(frame) < 4 {
+			return nil
+		}
+		// DLT_NULL stores the address family in host byte order.
+		if family := binary.LittleEndian.Uint32(frame); family != 2 && binary.BigEndian.Uint32(frame) != 2 {
+			return nil
+		}
+		return frame[4:]
+	default:
+		return nil
+	}
+}
+
+type tcpSegment struct {
+	source, destination string
+	sourcePort          int
+	destinationPort     int
+	seq, ack            uint32
+	flags               byte
+	payload             []byte
+}
+
+// decodeIPv4 returns the source address and, for unfragmented TCP, the
+// decoded segment.
+func decodeIPv4(packet []byte) (net.IP, *tcpSegment) {
+	if len(packet) < 20 || packet[0]>>4 != 4 {
+		return nil, nil
+	}
+	headerLength := int(packet[0]&0x0f) * 4
+	totalLength := int(binary.BigEndian.Uint16(packet[2:]))
+	if headerLength < 20 || len(packet) < headerLength {
+		return nil, nil
+	}
+	if totalLength >= headerLength && totalLength < len(packet) {
+		packet = packet[:totalLength]
+	}
+	source := net.IP(append([]byte(nil), packet[12:16]...))
+	fragment := binary.BigEndian.Uint16(packet[6:])
+	if packet[9] != 6 || fragment&0x3fff != 0 {
+		return source, nil
+	}
+	segment := packet[headerLength:]
+	if len(segment) < 20 {
+		return source, nil
+	}
+	offset := int(segment[12]>>4) * 4
+	if offset < 20 || offset > len(segment) {
+		return source, nil
+	}
+	return source, &tcpSegment{
+		source: source.String(), destination: net.IP(packet[16:20]).String(),
+		sourcePort: int(binary.BigEndian.Uint16(segment)), destinationPort: int(binary.BigEndian.Uint16(segment[2:])),
+		seq: binary.BigEndian.Uint32(segment[4:]), ack: binary.BigEndian.Uint32(segment[8:]),
+		flags: segment[13], payload: segment[offset:],
+	}
+}
+
+func isRFC1918(ip net.IP) bool {
+	ip = ip.To4()
+	return ip != nil && (ip[0] == 10 || (ip[0] == 172 && ip[1]&0xf0 == 16) || (ip[0] == 192 && ip[1] == 168))
+}
+
+type passiveEndpoint struct {
+	ip   string
+	port int
+}
+
+type passiveConnection struct {
+	server, client passiveEndpoint
+	serverBase     uint32
+	clientBase     uint32
+	serverFirst    bool
+	toClient       streamSegments
+	toServer       streamSegments
+}
+
+type streamSegments struct {
+	segments []streamSegment
+	bytes    int
+}
+
+type streamSegment struct {
+	seq     uint32
+	payload []byte
+}
+
+type passiveCapture struct {
+	packet      int
+	hosts       map[string]struct{}
+	listeners   map[passiveEndpoint]struct{}
+	connections map[[2]passiveEndpoint]*passiveConnection
+	order       [][2]passiveEndpoint
+	// pending keeps payloads seen before a connection's SYN/ACK so capture
+	// ordering quirks do not drop the first response.
+	pending map[[2]passiveEndpoint][]tcpSegment
+}
+
+func newPassiveCapture() *passiveCapture {
+	return &passiveCapture{
+		hosts:       make(map[string]struct{}),
+		listeners:   make(map[passiveEndpoint]struct{}),
+		connections: make(map[[2]passiveEndpoint]*passiveConnection),
+		pending:     make(map[[2]passiveEndpoint][]tcpSegment),
+	}
+}
+
+func (capture *passiveCapture) observe(linkType uint32, frame []byte) {
+	capture.packet++
+	source, segment := decodeIPv4(ipv4Payload(linkType, frame))
+	if source == nil {
+		return
+	}
+	if isRFC1918(source) {
+		capture.hosts[source.String()] = struct{}{}
+	}
+	if segment == nil {
+		return
+	}
+	from := passiveEndpoint{ip: segment.source, port: segment.sourcePort}
+	to := passiveEndpoint{ip: segment.destination, port: segment.destinationPort}
+	if segment.flags&(tcpFlagSYN|tcpFlagACK|tcpFlagRST) == tcpFlagSYN|tcpFlagACK {
+		key := [2]passiveEndpoint{from, to}
+		if _, exists := capture.connections[key]; !exists {
+			capture.listeners[from] = struct{}{}
+			capture.connections[key] = &passiveConnection{server: from, client: to, serverBase: segment.seq + 1, clientBase: segment.ack}
+			capture.order = append(capture.order, key)
+			for _, held := range capture.pending[key] {
+				capture.appendPayload(capture.connections[key], held, true)
+			}
+			for _, held := range capture.pending[[2]passiveEndpoint{to, from}] {
+				capture.appendPayload(capture.connections[key], held, false)
+			}
+			delete(capture.pending, key)
+			delete(capture.pending, [2]passiveEndpoint{to, from})
+		}
+		return
+	}
+	if len(segment.payload) == 0 {
+		return
+	}
+	if connection := capture.connections[[2]passiveEndpoint{from, to}]; connection != nil {
+		capture.appendPayload(connection, *segment, true)
+		return
+	}
+	if connection := capture.connections[[2]passiveEndpoint{to, from}]; connection != nil {
+		capture.appendPayload(connection, *segment, false)
+		return
+	}
+	key := [2]passiveEndpoint{from, to}
+	if held := capture.pending[key]; len(held) < 8 {
+		segment.payload = append([]byte(nil), segment.payload...)
+		capture.pending[key] = append(held, *segment)
+	}
+}
+
+func (capture *passiveCapture) appendPayload(connection *passiveConnection, segment tcpSegment, fromServer bool) {
+	stream := &connection.toServer
+	if fromServer {
+		stream = &connection.toClient
+		if len(connection.toServer.segments) == 0 && len(connection.toClient.segments) == 0 {
+			connection.serverFirst = true
+		}
+	}
+	if stream.bytes >= passiveStreamMaxBytes {
+		return
+	}
+	payload := segment.payload
+	if remaining := passiveStreamMaxBytes - stream.bytes; len(payload) > remaining {
+		payload = payload[:remaining]
+	}
+	stream.segments = append(stream.segments, streamSegment{seq: segment.seq, payload: append([]byte(nil), payload...)})
+	stream.bytes += len(payload)
+}
+
+// reassemble orders segments from the initial sequence number and stops at
+// the first gap; retransmitted bytes are skipped.
+func (stream streamSegments) reassemble(base uint32) []byte {
+	segments := append([]streamSegment(nil), stream.segments...)
+	sort.SliceStable(segments, func(i, j int) bool { return segments[i].seq-base < segments[j].seq-base })
+	var buffer bytes.Buffer
+	next := uint32(0)
+	for _, segment := range segments {
+		relative := segment.seq - base
+		end := relative + uint32(len(segment.payload))
+		if relative > next || relative >= 1<<31 {
+			break
+		}
+		if end <= next {
+			continue
+		}
+		buffer.Write(segment.payload[next-relative:])
+		next = end
+	}
+	return buffer.Bytes()
+}
+
+func (capture *passiveCapture) result() Result {
+	result := Result{Format: FormatPcap}
+	services := make(map[string][]Service)
+	for _, key := range capture.order {
+		connection := capture.connections[key]
+		if !isRFC1918(net.ParseIP(connection.server.ip)) {
+			continue
+		}
+		service := passiveServiceObservation(connection)
+		services[connection.server.ip] = append(services[connection.server.ip], service)
+	}
+	for ip := range capture.hosts {
+		result.Hosts = append(result.Hosts, Host{IP: ip, Services: services[ip]})
+	}
+	return result
+}
+
+var htmlTitlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+func passiveServiceObservation(connection *passiveConnection) Service {
+	service := Service{Port: connection.server.port}
+	response := connection.toClient.reassemble(connection.serverBase)
+	request := connection.toServer.reassemble(connection.clientBase)
+	if serverName, ok := tlsClientHelloServerName(request); ok {
+		service.Tunnel, service.TLSServerName = "ssl", serverName
+	}
+	switch {
+	case bytes.HasPrefix(response, []byte("SSH-")):
+		line, _, _ := bytes.Cut(response, []byte("\n"))
+		service.Name = "ssh"
+		service.Banner, service.BannerTruncated = boundedText(strings.TrimRight(string(line), "\r"))
+	case bytes.HasPrefix(response, []byte("HTTP/")):
+		head, body, _ := bytes.Cut(response, []byte("\r\n\r\n"))
+		service.Name = "http"
+		service.HTTPHeaders, service.HTTPStatus = parseResponseHead(string(head))
+		service.HTTPServer = headerLookup(service.HTTPHeaders, "Server")
+		if encoding := headerLookup(service.HTTPHeaders, "Content-Encoding"); encoding == "" || strings.EqualFold(encoding, "identity") {
+			if match := htmlTitlePattern.FindSubmatch(body); match != nil {
+				title, _ := boundedText(strings.Join(strings.Fields(string(match[1])), " "))
+				service.HTTPTitle = title
+			}
+		}
+	case isTLSHandshake(response):
+		service.Tunnel = "ssl"
+		if certificate := tlsServerCertificate(response); certificate != nil {
+			sum := sha256.Sum256(certificate.Raw)
+			service.TLSCertificateSubject = certificate.Subject.String()
+			service.TLSCertificateNames = append([]string(nil), certificate.DNSNames...)
+			service.TLSCertificateSHA256 = hex.EncodeToString(sum[:])
+			service.TLSCertificateLength = len(certificate.Raw)
+		}
+	case connection.serverFirst && len(response) > 0:
+		service.Banner, service.BannerTruncated = boundedText(string(response))
+	}
+	return service
+}
+
+func isTLSHandshake(stream []byte) bool {
+	return len(stream) >= 5 && stream[0] == 0x16 && stream[1] == 0x03
+}
+
+// tlsHandshakeMessages joins the handshake records at the start of a stream.
+// Encrypted TLS 1.3 handshakes stop after the ServerHello.
+func tlsHandshakeMessages(stream []byte) []byte {
+	var handshake bytes.Buffer
+	for len(stream) >= 5 && stream[0] == 0x16 && stream[1] == 0x03 {
+		length := int(binary.BigEndian.Uint16(stream[3:]))
+		if length > len(stream)-5 {
+			handshake.Write(stream[5:])
+			break
+		}
+		handshake.Write(stream[5 : 5+length])
+		stream = stream[5+length:]
+	}
+	return handshake.Bytes()
+}
+
+func tlsServerCertificate(stream []byte) *x509.Certificate {
+	messages := tlsHandshakeMessages(stream)
+	for len(messages) >= 4 {
+		messageType := messages[0]
+		length := int(messages[1])<<16 | int(messages[2])<<8 | int(messages[3])
+		if length > len(messages)-4 {
+			return nil
+		}
+		body := messages[4 : 4+length]
+		messages = messages[4+length:]
+		if messageType != 11 || len(body) < 6 {
+			continue
+		}
+		certificateLength := int(body[3])<<16 | int(body[4])<<8 | int(body[5])
+		if certificateLength > len(body)-6 {
+			return nil
+		}
+		certificate, err := x509.ParseCertificate(body[6 : 6+certificateLength])
+		if err != nil {
+			return nil
+		}
+		return certificate
+	}
+	return nil
+}
+
+// tlsClientHelloServerName extracts the SNI host name from a ClientHello.
+func tlsClientHelloServerName(stream []byte) (string, bool) {
+	if !isTLSHandshake(stream) {
+		return "", false
+	}
+	messages := tlsHandshakeMessages(stream)
+	if len(messages) < 4 || messages[0] != 1 {
+		return "", false
+	}
+	hello := messages[4:]
+	// client_version(2) random(32)
+	if len(hello) < 35 {
+		return "", true
+	}
+	hello = hello[34:]
+	for _, width := range []int{1, 2, 1} {
+		if len(hello) < width {
+			return "", true
+		}
+		length := int(hello[0])
+		if width == 2 {
+			length = int(binary.BigEndian.Uint16(hello))
+		}
+		if length > len(hello)-width {
+			return "", true
+		}
+		hello = hello[width+length:]
+	}
+	if len(hello) < 2 {
+		return "", true
+	}
+	extensions := hello[2:]
+	if declared := int(binary.BigEndian.Uint16(hello)); declared < len(extensions) {
+		extensions = extensions[:declared]
+	}
+	for len(extensions) >= 4 {
+		extensionType := binary.BigEndian.Uint16(extensions)
+		length := int(binary.BigEndian.Uint16(extensions[2:]))
+		if length > len(extensions)-4 {
+			break
+		}
+		data := extensions[4 : 4+length]
+		extensions = extensions[4+length:]
+		if extensionType != 0 || len(data) < 5 {
+			continue
+		}
+		list := data[2:]
+		for len(list) >= 3 {
+			nameType, nameLength := list[0], int(binary.BigEndian.Uint16(list[1:]))
+			if nameLength > len(list)-3 {
+				break
+			}
+			if nameType == 0 {
+				return strings.ToLower(string(list[3 : 3+nameLength])), true
+			}
+			list = list[3+nameLength:]
+		}
+	}
+	return "", true
+}
+
+func readBoundedInput(input io.Reader) ([]byte, error) {
+	if input == nil {
+		return nil, errors.New("import input is required")
+	}
+	data, err := io.ReadAll(io.LimitReader(input, MaxInputBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxInputBytes {
+		return nil, fmt.Errorf("import input exceeds %d bytes", MaxInputBytes)
+	}
+	return data, nil
+}
@@ -0,0 +1,194 @@
+package ingest
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+type testPacket struct {
+	src, dst         string
+	srcPort, dstPort uint16
+	seq, ack         uint32
+	flags            byte
+	payload          []byte
+}
+
+func ethernetFrame(packet testPacket) []byte {
+	tcp := make([]byte, 20)
+	binary.BigEndian.PutUint16(tcp, packet.srcPort)
+	binary.BigEndian.PutUint16(tcp[2:], packet.dstPort)
+	binary.BigEndian.PutUint32(tcp[4:], packet.seq)
+	binary.BigEndian.PutUint32(tcp[8:], packet.ack)
+	tcp[12], tcp[13] = 5<<4, packet.flags
+	tcp = append(tcp, packet.payload...)
+	ip := make([]byte, 20)
+	ip[0], ip[8], ip[9] = 0x45, 64, 6
+	binary.BigEndian.PutUint16(ip[2:], uint16(20+len(tcp)))
+	copy(ip[12:], net.ParseIP(packet.src).To4())
+	copy(ip[16:], net.ParseIP(packet.dst).To4())
+	frame := make([]byte, 12, 14)
+	frame = append(frame, 0x08, 0x00)
+	return append(append(frame, ip...), tcp...)
+}
+
+func pcapFile(frames [][]byte) []byte {
+	var buffer bytes.Buffer
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint32(header, pcapMagicMicro)
+	binary.LittleEndian.PutUint16(header[4:], 2)
+	binary.LittleEndian.PutUint16(header[6:], 4)
+	binary.LittleEndian.PutUint32(header[16:], 65535)
+	binary.LittleEndian.PutUint32(header[20:], linkTypeEthernet)
+	buffer.Write(header)
+	for _, frame := range frames {
+		record := make([]byte, 16)
+		binary.LittleEndian.PutUint32(record[8:], uint32(len(frame)))
+		binary.LittleEndian.PutUint32(record[12:], uint32(len(frame)))
+		buffer.Write(record)
+		buffer.Write(frame)
+	}
+	return buffer.Bytes()
+}
+
+func pcapngFile(frames [][]byte) []byte {
+	var buffer bytes.Buffer
+	block := func(blockType uint32, body []byte) {
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+		length := uint32(12 + len(body))
+		_ = binary.Write(&buffer, binary.LittleEndian, blockType)
+		_ = binary.Write(&buffer, binary.LittleEndian, length)
+		buffer.Write(body)
+		_ = binary.Write(&buffer, binary.LittleEndian, length)
+	}
+	section := make([]byte, 16)
+	binary.LittleEndian.PutUint32(section, pcapngByteOrderMagic)
+	binary.LittleEndian.PutUint16(section[4:], 1)
+	binary.LittleEndian.PutUint64(section[8:], ^uint64(0))
+	block(pcapngSectionHeader, section)
+	iface := make([]byte, 8)
+	binary.LittleEndian.PutUint16(iface, linkTypeEthernet)
+	block(pcapngInterfaceBlock, iface)
+	for _, frame := range frames {
+		packet := make([]byte, 20)
+		binary.LittleEndian.PutUint32(packet[12:], uint32(len(frame)))
+		binary.LittleEndian.PutUint32(packet[16:], uint32(len(frame)))
+		block(pcapngEnhancedPacket, append(packet, frame...))
+	}
+	return buffer.Bytes()
+}
+
+func tlsRecord(handshakeType byte, body []byte) []byte {
+	message := append([]byte{handshakeType, byte(len(body) >> 16), byte(len(body) >> 8), byte(len(body))}, body...)
+	return append([]byte{0x16, 0x03, 0x03, byte(len(message) >> 8), byte(len(message))}, message...)
+}
+
+func clientHello(serverName string) []byte {
+	name := append([]byte{0, byte(len(serverName) >> 8), byte(len(serverName))}, serverName...)
+	list := append([]byte{byte(len(name) >> 8), byte(len(name))}, name...)
+	extension := append([]byte{0, 0, byte(len(list) >> 8), byte(len(list))}, list...)
+	body := append([]byte{0x03, 0x03}, make([]byte, 32)...)
+	body = append(body, 0, 0, 2, 0x13, 0x01, 1, 0)
+	body = append(body, byte(len(extension)>>8), byte(len(extension)))
+	return tlsRecord(1, append(body, extension...))
+}
+
+func certificateMessage(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(7), Subject: pkix.Name{CommonName: "intranet.example"},
+		DNSNames: []string{"intranet.example"}, NotBefore: time.Unix(0, 0), NotAfter: time.Unix(1<<32, 0),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	entry := append([]byte{byte(len(der) >> 16), byte(len(der) >> 8), byte(len(der))}, der...)
+	body := append([]byte{byte(len(entry) >> 16), byte(len(entry) >> 8), byte(len(entry))}, entry...)
+	return tlsRecord(11, body), der
+}
+
+func captureFrames(t *testing.T) [][]byte {
+	const server, client = "192.168.1.10", "192.168.1.20"
+	certificate, _ := certificateMessage(t)
+	hello := clientHello("intranet.example")
+	response := []byte("HTTP/1.1 200 OK\r\nServer: nginx/1.24.0\r\nContent-Type: text/html\r\n\r\n<html><title>Intranet  Portal</title>")
+	packets := []testPacket{
+		{src: server, dst: client, srcPort: 22, dstPort: 50000, seq: 999, ack: 101, flags: tcpFlagSYN | tcpFlagACK},
+		{src: server, dst: client, srcPort: 22, dstPort: 50000, seq: 1000, ack: 101, flags: tcpFlagACK, payload: []byte("SSH-2.0-OpenSSH_9.6\r\n")},
+		{src: server, dst: client, srcPort: 80, dstPort: 50001, seq: 4999, ack: 201, flags: tcpFlagSYN | tcpFlagACK},
+		{src: client, dst: server, srcPort: 50001, dstPort: 80, seq: 201, ack: 5000, flags: tcpFlagACK, payload: []byte("GET / HTTP/1.1\r\nHost: portal\r\n\r\n")},
+		// The response arrives out of order; reassembly restores it.
+		{src: server, dst: client, srcPort: 80, dstPort: 50001, seq: 5000 + 40, ack: 233, flags: tcpFlagACK, payload: response[40:]},
+		{src: server, dst: client, srcPort: 80, dstPort: 50001, seq: 5000, ack: 233, flags: tcpFlagACK, payload: response[:40]},
+		{src: server, dst: client, srcPort: 443, dstPort: 50002, seq: 7999, ack: 301, flags: tcpFlagSYN | tcpFlagACK},
+		{src: client, dst: server, srcPort: 50002, dstPort: 443, seq: 301, ack: 8000, flags: tcpFlagACK, payload: hello},
+		{src: server, dst: client, srcPort: 443, dstPort: 50002, seq: 8000, ack: 301 + uint32(len(hello)), flags: tcpFlagACK, payload: certificate},
+		// Mid-stream traffic without a SYN/ACK and public hosts are ignored.
+		{src: server, dst: client, srcPort: 8080, dstPort: 50003, seq: 1, ack: 1, flags: tcpFlagACK, payload: []byte("HTTP/1.1 200 OK\r\n\r\n")},
+		{src: "8.8.8.8", dst: client, srcPort: 443, dstPort: 50004, seq: 1, ack: 1, flags: tcpFlagSYN | tcpFlagACK},
+	}
+	frames := make([][]byte, 0, len(packets))
+	for _, packet := range packets {
+		frames = append(frames, ethernetFrame(packet))
+	}
+	return frames
+}
+
+func TestParsePcapReconstructsPassiveServices(t *testing.T) {
+	for name, data := range map[string][]byte{"pcap": pcapFile(captureFrames(t)), "pcapng": pcapngFile(captureFrames(t))} {
+		t.Run(name, func(t *testing.T) {
+			result, err := ParsePcap(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("parse capture: %v", err)
+			}
+			if result.Format != FormatPcap || len(result.Hosts) != 2 || result.Hosts[0].IP != "192.168.1.10" || result.Hosts[1].IP != "192.168.1.20" {
+				t.Fatalf("hosts = %#v", result.Hosts)
+			}
+			if len(result.Hosts[1].Services) != 0 {
+				t.Fatalf("client reported services %#v", result.Hosts[1].Services)
+			}
+			services := result.Hosts[0].Services
+			if len(services) != 3 {
+				t.Fatalf("services = %#v", services)
+			}
+			if ssh := services[0]; ssh.Port != 22 || ssh.ServiceType() != "ssh" || ssh.Banner != "SSH-2.0-OpenSSH_9.6" {
+				t.Fatalf("ssh = %#v", ssh)
+			}
+			if web := services[1]; web.Port != 80 || web.WebProtocol() != "http" || web.HTTPStatus != 200 || web.HTTPServer != "nginx/1.24.0" || web.HTTPTitle != "Intranet Portal" {
+				t.Fatalf("web = %#v", web)
+			}
+			tls := services[2]
+			if tls.Port != 443 || tls.Tunnel != "ssl" || tls.TLSServerName != "intranet.example" || tls.TLSCertificateSubject != "CN=intranet.example" || len(tls.TLSCertificateNames) != 1 || len(tls.TLSCertificateSHA256) != 64 {
+				t.Fatalf("tls = %#v", tls)
+			}
+			if target, ok := result.CoveringTarget(); !ok || target != "192.168.1.0/27" {
+				t.Fatalf("covering target = %q %v", target, ok)
+			}
+		})
+	}
+}
+
+func TestParsePcapRejectsUnknownAndTruncatedCaptures(t *testing.T) {
+	if _, err := ParsePcap(bytes.NewReader([]byte("not a capture file"))); err == nil {
+		t.Fatal("unknown capture format was accepted")
+	}
+	data := pcapFile(captureFrames(t))
+	if _, err := ParsePcap(bytes.NewReader(data[:len(data)-3])); err == nil {
+		t.Fatal("truncated capture was accepted")
+	}
+}
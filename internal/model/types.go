@@ -45,6 +45,10 @@ const (
 	ScanTaskRunTriggerInitial        = "initial"
 	ScanTaskRunTriggerManual         = "manual"
 	ScanTaskRunTriggerScheduled      = "scheduled"
+	ScanTaskRunTriggerImport         = "import"
+	ScanTaskRunTriggerPassive        = "passive"
+	ScanTaskRunTriggerReevaluate     = "reevaluate"
+	ScanTaskRunTriggerRevalidate     = "revalidate"
 
 	ScanTaskRunStageQueued     = "queued"
 	ScanTaskRunStageStarting   = "starting"
@@ -70,7 +74,15 @@ const (
 	ValidationReasonTemplateDirectory   = "template_directory_missing"
 	ValidationReasonPolicyFiltered      = "policy_filtered"
 	ValidationReasonExecutionFailed     = "execution_failed"
+	ValidationReasonExecutionTimeout    = "execution_timeout"
+	ValidationReasonMemoryLimit         = "memory_limit_exceeded"
 	ValidationReasonRunFailure          = "skipped_run_failure"
+	ValidationReasonPassiveSource       = "passive_source"
+
+	// ValidatorNuclei runs reviewed Nuclei templates and is the default;
+	// ValidatorNative runs the built-in read-only checks of internal/vuln.
+	ValidatorNuclei = "nuclei"
+	ValidatorNative = "native"
 )
 
 type Scanner struct {
@@ -94,6 +106,10 @@ type ScanResult struct {
 	Banner            string
 	BannerTruncated   bool
 	ProtocolEvidence  []ScanTaskRunProtocolEvidence
+	WebAssets         []ScanTaskRunWebAsset
+	Weaknesses        []ScanTaskRunWeakness
+	TLSFingerprints   []ScanTaskRunTLSFingerprint
+	RawEvidence       []ScanTaskRunRawEvidence
 }
 
 type Task struct {
@@ -119,6 +135,15 @@ type ScanTaskConfig struct {
 	DNSResolveMode  string   `json:"dns_resolve_mode,omitempty"`
 	DNSDenyCIDRs    []string `json:"dns_deny_cidrs,omitempty"`
 	TemplateVersion string   `json:"template_version,omitempty"`
+	SNMPCredential  string   `json:"snmp_credential,omitempty"`
+	// RetainRawEvidence keeps bounded raw responses, sealed with the home
+	// secret key, so runs can be re-fingerprinted without rescanning.
+	RetainRawEvidence bool `json:"retain_raw_evidence,omitempty"`
+	// TLSFingerprint sends the fixed ClientHello variants to every TLS
+	// endpoint to compute JARM-style and JA4S server fingerprints.
+	TLSFingerprint bool `json:"tls_fingerprint,omitempty"`
+	// Validator selects the validation engine; empty means Nuclei.
+	Validator string `json:"validator,omitempty"`
 }
 
 // ScanTask is the user-managed logical task. It is separate from the v1 Task,
@@ -401,6 +426,16 @@ const (
 	ProtocolEvidencePassiveBanner = "passive_banner"
 	ProtocolEvidenceActiveProbe   = "active_probe"
 	ProtocolEvidenceWeb           = "web"
+	// ProtocolEvidenceImported records an observation made by an external
+	// scanner. ProbeName identifies the source format and field or script.
+	ProtocolEvidenceImported = "imported"
+	// ProtocolEvidenceWebPage records one page reached by the same-origin
+	// crawler. ProbeName holds the request path and query.
+	ProtocolEvidenceWebPage = "web_page"
+	// ProtocolEvidenceTLSFingerprint records the ClientHello variant set sent
+	// to a TLS endpoint. The banner digest covers the ServerHellos received;
+	// the derived hashes live in ScanTaskRunTLSFingerprint.
+	ProtocolEvidenceTLSFingerprint = "tls_fingerprint"
 
 	ProtocolProbeOutcomeResponded      = "responded"
 	ProtocolProbeOutcomeNoResponse     = "no_response"
@@ -447,6 +482,134 @@ type ScanTaskRunVulnerability struct {
 	MatchedAt   string `json:"matched_at,omitempty"`
 	Description string `json:"description,omitempty"`
 	Evidence    string `json:"-"`
+	// State, Assignee and RiskAcceptedUntil are the finding's current triage,
+	// shared by every run that observed the same FindingKey. They are read
+	// when the snapshot is loaded and are not part of the run record.
+	State             string `json:"state"`
+	Assignee          string `json:"assignee,omitempty"`
+	RiskAcceptedUntil string `json:"risk_accepted_until,omitempty"`
+}
+
+const (
+	FindingStateOpen                     = "open"
+	FindingStateConfirmed                = "confirmed"
+	FindingStateFalsePositive            = "false_positive"
+	FindingStateRiskAccepted             = "risk_accepted"
+	FindingStateFixedPendingVerification = "fixed_pending_verification"
+
+	FindingTriageEventState    = "state"
+	FindingTriageEventComment  = "comment"
+	FindingTriageEventReopened = "reopened"
+	FindingTriageEventExpired  = "expired"
+)
+
+// FindingStates lists triage states in workflow order.
+var FindingStates = []string{
+	FindingStateOpen,
+	FindingStateConfirmed,
+	FindingStateFalsePositive,
+	FindingStateRiskAccepted,
+	FindingStateFixedPendingVerification,
+}
+
+// ValidFindingState reports whether state is one of FindingStates.
+func ValidFindingState(state string) bool {
+	for _, candidate := range FindingStates {
+		if state == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// FindingStateActionable reports whether a finding in state still needs
+// attention. False positives and accepted risks are set aside.
+func FindingStateActionable(state string) bool {
+	return state != FindingStateFalsePositive && state != FindingStateRiskAccepted
+}
+
+// SeverityRank orders finding and weakness severities from critical (5) down
+// to info (1); an unknown severity ranks 0.
+func SeverityRank(severity string) int {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "critical":
+		return 5
+	case "high":
+		return 4
+	case "medium":
+		return 3
+	case "low":
+		return 2
+	case "info":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// FindingTriage is the operator decision about one FindingKey. It outlives
+// individual runs: a fixed finding that a later run observes again is
+// reopened, and a risk acceptance lapses back to open after its date.
+type FindingTriage struct {
+	FindingKey        string               `json:"finding_key"`
+	State             string               `json:"state"`
+	Assignee          string               `json:"assignee,omitempty"`
+	RiskAcceptedUntil string               `json:"risk_accepted_until,omitempty"`
+	UpdatedAt         string               `json:"updated_at,omitempty"`
+	Events            []FindingTriageEvent `json:"events"`
+}
+
+// FindingTriageEvent is one entry of a finding's triage history. Reopened
+// and expired events are written by yscan and carry no author.
+type FindingTriageEvent struct {
+	ID            int64  `json:"id"`
+	Kind          string `json:"kind"`
+	State         string `json:"state,omitempty"`
+	Assignee      string `json:"assignee,omitempty"`
+	Author        string `json:"author,omitempty"`
+	Comment       string `json:"comment,omitempty"`
+	ScanTaskRunID int64  `json:"scan_task_run_id,omitempty"`
+	CreatedAt     string `json:"created_at"`
+}
+
+// FindingTriageUpdate changes the triage of one finding. Empty State and a
+// nil Assignee keep the current values; an empty Assignee clears it.
+type FindingTriageUpdate struct {
+	FindingKey        string  `json:"finding_key"`
+	State             string  `json:"state,omitempty"`
+	Assignee          *string `json:"assignee,omitempty"`
+	RiskAcceptedUntil string  `json:"risk_accepted_until,omitempty"`
+	Author            string  `json:"author,omitempty"`
+	Comment           string  `json:"comment,omitempty"`
+}
+
+// GlobalFinding is one template on one endpoint as currently observed by the
+// latest successful run of every scan task that reports it. Overlapping tasks
+// contribute a single entry.
+type GlobalFinding struct {
+	ScanTaskRunVulnerability
+	Products    []string              `json:"products"`
+	Sources     []GlobalFindingSource `json:"sources"`
+	FirstSeenAt string                `json:"first_seen_at"`
+}
+
+// GlobalFindingSource names a scan task run that currently reports a finding.
+type GlobalFindingSource struct {
+	ScanTaskID    int64  `json:"scan_task_id"`
+	ScanTaskRunID int64  `json:"scan_task_run_id"`
+	FindingKey    string `json:"finding_key"`
+}
+
+// GlobalFindingFilter narrows the global findings register. Empty fields do
+// not filter. FirstSeenSince and FirstSeenUntil are inclusive UTC bounds.
+type GlobalFindingFilter struct {
+	Severity       string
+	TemplateID     string
+	ProductKey     string
+	CIDR           string
+	State          string
+	FirstSeenSince string
+	FirstSeenUntil string
 }
 
 // ScanTaskRunValidation records what vulnerability verification actually did.
@@ -500,6 +663,57 @@ type ScanTaskRunTemplateCandidate struct {
 	Executed            bool   `json:"executed"`
 }
 
+// ValidationPlan is what vulnerability validation would do for a scan task,
+// derived from the endpoints and fingerprint conclusions of one successful
+// run. Building it never starts Nuclei or contacts the hosts.
+type ValidationPlan struct {
+	ScanTaskID          int64                      `json:"scan_task_id"`
+	ScanTaskRunID       int64                      `json:"scan_task_run_id"`
+	VulnerabilityOn     bool                       `json:"vulnerability_on"`
+	Validator           string                     `json:"validator,omitempty"`
+	TemplatesRoot       string                     `json:"templates_root,omitempty"`
+	TemplateSetRevision string                     `json:"template_set_revision,omitempty"`
+	Error               string                     `json:"error,omitempty"`
+	Endpoints           []ValidationPlanEndpoint   `json:"endpoints"`
+	Invocations         []ValidationPlanInvocation `json:"invocations"`
+}
+
+// ValidationPlanEndpoint lists the selected and policy-filtered templates of
+// one endpoint. Reason uses the ValidationReason values and is empty when at
+// least one template would run.
+type ValidationPlanEndpoint struct {
+	IP         string                           `json:"ip"`
+	Port       int                              `json:"port"`
+	Protocol   string                           `json:"protocol"`
+	Products   []string                         `json:"products"`
+	Reason     string                           `json:"reason,omitempty"`
+	Error      string                           `json:"error,omitempty"`
+	Candidates []ScanTaskRunTemplateCandidate   `json:"candidates"`
+	Filtered   []ValidationPlanFilteredTemplate `json:"filtered"`
+}
+
+// ValidationPlanFilteredTemplate is a template matching the endpoint that the
+// safety policy would not run.
+type ValidationPlanFilteredTemplate struct {
+	TemplateID string `json:"template_id"`
+	Path       string `json:"path"`
+	ProductKey string `json:"product_key"`
+	Reason     string `json:"reason"`
+}
+
+// ValidationPlanInvocation is one Nuclei process validation would start.
+// Templates are materialized by content hash into a private snapshot
+// directory, shown as <template-snapshot>; the target list file is shown as
+// <targets-file>. For the native validator it is one batch of built-in
+// checks, named in TemplateIDs, and Command is empty.
+type ValidationPlanInvocation struct {
+	IP          string   `json:"ip"`
+	Port        int      `json:"port"`
+	Targets     []string `json:"targets"`
+	TemplateIDs []string `json:"template_ids"`
+	Command     []string `json:"command"`
+}
+
 type FingerprintMatchEvidence struct {
 	MatcherID      int64  `json:"matcher_id"`
 	EvidenceType   string `json:"evidence_type"`
@@ -533,6 +747,378 @@ type FingerprintRunMatch struct {
 	Evidence                []FingerprintMatchEvidence `json:"evidence"`
 }
 
+const (
+	FingerprintOverrideSuppress = "suppress"
+	FingerprintOverrideAssert   = "assert"
+
+	FingerprintOverrideEffectSuppressed = "suppressed"
+	FingerprintOverrideEffectAsserted   = "asserted"
+	FingerprintOverrideEffectSuperseded = "superseded"
+)
+
+// FingerprintOverride is an operator decision the conclusion builder applies
+// to every later run. A suppression drops hard matches by source rule and/or
+// product, optionally scoped to one host or endpoint; an assertion pins a
+// product on one endpoint. Raw matches are always kept so evidence survives.
+type FingerprintOverride struct {
+	ID           int64  `json:"id"`
+	Kind         string `json:"kind"`
+	SourceKey    string `json:"source_key,omitempty"`
+	SourceRuleID string `json:"source_rule_id,omitempty"`
+	ProductKey   string `json:"product_key,omitempty"`
+	IP           string `json:"ip,omitempty"`
+	Port         int    `json:"port,omitempty"`
+	Protocol     string `json:"protocol,omitempty"`
+	Version      string `json:"version,omitempty"`
+	CPE          string `json:"cpe,omitempty"`
+	Reason       string `json:"reason"`
+	ExpiresAt    string `json:"expires_at,omitempty"`
+	CreatedAt    string `json:"created_at,omitempty"`
+	RevokedAt    string `json:"revoked_at,omitempty"`
+}
+
+// ScanTaskRunFingerprintOverride records one override the conclusion builder
+// applied in a run, so past runs keep explaining themselves after revocation.
+type ScanTaskRunFingerprintOverride struct {
+	OverrideID int64  `json:"override_id"`
+	Kind       string `json:"kind"`
+	IP         string `json:"ip"`
+	Port       int    `json:"port"`
+	Protocol   string `json:"protocol"`
+	ProductKey string `json:"product_key"`
+	Effect     string `json:"effect"`
+	Reason     string `json:"reason"`
+}
+
+// FingerprintImportDiff compares two revisions of one source by stable
+// source rule ID, falling back to the source path for rules without one.
+type FingerprintImportDiff struct {
+	FromImportID int64                  `json:"from_import_id"`
+	ToImportID   int64                  `json:"to_import_id"`
+	SourceKey    string                 `json:"source_key"`
+	Added        []FingerprintRuleDiff  `json:"added"`
+	Removed      []FingerprintRuleDiff  `json:"removed"`
+	Modified     []FingerprintRuleDiff  `json:"modified"`
+	Unchanged    int                    `json:"unchanged"`
+	Impact       *FingerprintDiffImpact `json:"impact,omitempty"`
+}
+
+type FingerprintRuleDiff struct {
+	RuleKey         string   `json:"rule_key"`
+	SourcePath      string   `json:"source_path"`
+	FromProduct     string   `json:"from_product,omitempty"`
+	ToProduct       string   `json:"to_product,omitempty"`
+	FromCPE         string   `json:"from_cpe,omitempty"`
+	ToCPE           string   `json:"to_cpe,omitempty"`
+	FromStatus      string   `json:"from_status,omitempty"`
+	ToStatus        string   `json:"to_status,omitempty"`
+	Changes         []string `json:"changes,omitempty"`
+	AddedMatchers   []string `json:"added_matchers,omitempty"`
+	RemovedMatchers []string `json:"removed_matchers,omitempty"`
+}
+
+// FingerprintDiffImpactLossOnly marks a preview that only replays stored
+// matches: it shows conclusions the newer revision would drop or rename, but
+// cannot predict products that added or changed matchers would newly detect.
+const FingerprintDiffImpactLossOnly = "loss_only"
+
+// FingerprintDiffImpact replays the stored hard matches of the last
+// successful run that froze the older revision. Rules whose matchers changed
+// are listed for review instead of re-matched; Gained only covers products a
+// modified rule renames.
+type FingerprintDiffImpact struct {
+	RunID     int64                           `json:"run_id"`
+	Mode      string                          `json:"mode"`
+	Endpoints []FingerprintDiffEndpointImpact `json:"endpoints"`
+}
+
+type FingerprintDiffEndpointImpact struct {
+	IP       string   `json:"ip"`
+	Port     int      `json:"port"`
+	Protocol string   `json:"protocol"`
+	Lost     []string `json:"lost,omitempty"`
+	Gained   []string `json:"gained,omitempty"`
+	Review   []string `json:"review,omitempty"`
+}
+
+// FingerprintRuleStats summarises recorded run matches per source, rule and
+// product. Rules and products are ordered by hits and truncated to the
+// requested limit; sources are always complete.
+type FingerprintRuleStats struct {
+	Sources  []FingerprintSourceHitStats `json:"sources"`
+	Rules    []FingerprintHitStats       `json:"rules"`
+	Products []FingerprintHitStats       `json:"products"`
+}
+
+// FingerprintHitStats counts the matches of one group. A hit is conflicted
+// when its endpoint's conclusion for the product is conflicted; SoftShare and
+// ConflictRate are fractions of Hits.
+type FingerprintHitStats struct {
+	SourceKey      string  `json:"source_key,omitempty"`
+	RuleKey        string  `json:"rule_key,omitempty"`
+	ProductKey     string  `json:"product_key,omitempty"`
+	Hits           int     `json:"hits"`
+	Endpoints      int     `json:"endpoints"`
+	SoftHits       int     `json:"soft_hits"`
+	ConflictedHits int     `json:"conflicted_hits"`
+	SoftShare      float64 `json:"soft_share"`
+	ConflictRate   float64 `json:"conflict_rate"`
+	LastMatchedAt  string  `json:"last_matched_at,omitempty"`
+}
+
+// FingerprintSourceHitStats adds how much of the active revision has ever
+// fired. Rules are identified across revisions by their stable rule key.
+type FingerprintSourceHitStats struct {
+	FingerprintHitStats
+	ActiveImportID  int64 `json:"active_import_id,omitempty"`
+	ExecutableRules int   `json:"executable_rules"`
+	RulesFired      int   `json:"rules_fired"`
+}
+
+const (
+	HostIdentitySourceSMB   = "smb"
+	HostIdentitySourceRDP   = "rdp"
+	HostIdentitySourceHTTP  = "http"
+	HostIdentitySourceWinRM = "winrm"
+)
+
+// ScanTaskRunHostIdentity is the naming a host disclosed in an anonymous NTLM
+// challenge on one endpoint. DNSTreeName is the Active Directory forest.
+type ScanTaskRunHostIdentity struct {
+	IP              string `json:"ip"`
+	Port            int    `json:"port"`
+	Source          string `json:"source"`
+	ComputerName    string `json:"computer_name,omitempty"`
+	DomainName      string `json:"domain_name,omitempty"`
+	DNSComputerName string `json:"dns_computer_name,omitempty"`
+	DNSDomainName   string `json:"dns_domain_name,omitempty"`
+	DNSTreeName     string `json:"dns_tree_name,omitempty"`
+	OSVersion       string `json:"os_version,omitempty"`
+}
+
+// ScanTaskRunSSHHostKey is one public host key an SSH endpoint presented
+// during a key-exchange-only handshake. Fingerprint uses the OpenSSH
+// "SHA256:<base64>" form.
+type ScanTaskRunSSHHostKey struct {
+	IP          string `json:"ip"`
+	Port        int    `json:"port"`
+	Algorithm   string `json:"algorithm"`
+	Fingerprint string `json:"fingerprint"`
+	Bits        int    `json:"bits,omitempty"`
+}
+
+// ScanTaskRunSNMPSystem is the read-only MIB-II inventory a device returned
+// to the task's SNMP credential. Product, Version and CPE are the mapping of
+// sysDescr and sysObjectID; they stay empty for unrecognized devices.
+type ScanTaskRunSNMPSystem struct {
+	IP          string                     `json:"ip"`
+	Port        int                        `json:"port"`
+	Credential  string                     `json:"credential"`
+	SysDescr    string                     `json:"sys_descr,omitempty"`
+	SysObjectID string                     `json:"sys_object_id,omitempty"`
+	SysName     string                     `json:"sys_name,omitempty"`
+	SysLocation string                     `json:"sys_location,omitempty"`
+	Vendor      string                     `json:"vendor,omitempty"`
+	Product     string                     `json:"product_key,omitempty"`
+	Version     string                     `json:"version,omitempty"`
+	CPE         string                     `json:"cpe,omitempty"`
+	Interfaces  []ScanTaskRunSNMPInterface `json:"interfaces,omitempty"`
+}
+
+// ScanTaskRunSNMPInterface is one ifTable row. Speed is in bits per second.
+type ScanTaskRunSNMPInterface struct {
+	Index       int    `json:"index"`
+	Description string `json:"description,omitempty"`
+	Type        int    `json:"type,omitempty"`
+	Speed       int64  `json:"speed,omitempty"`
+	PhysAddress string `json:"phys_address,omitempty"`
+	AdminStatus string `json:"admin_status,omitempty"`
+	OperStatus  string `json:"oper_status,omitempty"`
+}
+
+// ScanTaskRunWebAsset is a script or stylesheet referenced by a crawled web
+// page. Only the digest of the captured bytes and the advertised library
+// versions ("jquery 3.6.0") are kept.
+type ScanTaskRunWebAsset struct {
+	IP             string   `json:"ip"`
+	Port           int      `json:"port"`
+	Protocol       string   `json:"protocol"`
+	Kind           string   `json:"kind"`
+	Path           string   `json:"path"`
+	CapturedLength int      `json:"captured_length"`
+	SHA256         string   `json:"sha256"`
+	Truncated      bool     `json:"truncated,omitempty"`
+	Versions       []string `json:"versions,omitempty"`
+}
+
+// ScanTaskRunWeakness is a non-CVE web posture finding, such as a missing
+// security header or an insecure cookie. Check and Subject identify it on
+// its endpoint across runs; Path is the page where it was first observed.
+type ScanTaskRunWeakness struct {
+	IP       string `json:"ip"`
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+	Check    string `json:"check"`
+	Subject  string `json:"subject,omitempty"`
+	Severity string `json:"severity"`
+	Path     string `json:"path"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// ScanTaskRunPotentialVulnerability is a CVE whose NVD configuration covers
+// the CPE and version concluded for an endpoint. It is inferred from imported
+// data rather than exercised against the target, so it is reported apart
+// from validated Vulnerabilities. CVSS and KEV values are those imported when
+// the run was correlated.
+type ScanTaskRunPotentialVulnerability struct {
+	IP             string  `json:"ip"`
+	Port           int     `json:"port"`
+	Protocol       string  `json:"protocol"`
+	ProductKey     string  `json:"product_key"`
+	CPE            string  `json:"cpe"`
+	Version        string  `json:"version"`
+	CVEID          string  `json:"cve_id"`
+	Criteria       string  `json:"criteria"`
+	VersionRange   string  `json:"version_range,omitempty"`
+	CVSSVersion    string  `json:"cvss_version,omitempty"`
+	CVSSScore      float64 `json:"cvss_score,omitempty"`
+	Severity       string  `json:"severity,omitempty"`
+	KnownExploited bool    `json:"known_exploited"`
+	KEVDateAdded   string  `json:"kev_date_added,omitempty"`
+}
+
+// CVERecord is one CVE of an NVD CVE JSON 2.0 feed, reduced to the primary
+// CVSS metric and the vulnerable CPE match criteria of its configurations.
+type CVERecord struct {
+	ID           string
+	Published    string
+	LastModified string
+	Description  string
+	CVSSVersion  string
+	CVSSScore    float64
+	CVSSSeverity string
+	CVSSVector   string
+	CPEMatches   []CVECPEMatch
+}
+
+// CVECPEMatch is a vulnerable cpeMatch entry. The bounds are empty when the
+// criteria names a single version.
+type CVECPEMatch struct {
+	Criteria              string
+	VersionStartIncluding string
+	VersionStartExcluding string
+	VersionEndIncluding   string
+	VersionEndExcluding   string
+}
+
+// KnownExploitedCVE is one entry of the CISA Known Exploited Vulnerabilities
+// catalog.
+type KnownExploitedCVE struct {
+	CVEID             string
+	VendorProject     string
+	Product           string
+	VulnerabilityName string
+	DateAdded         string
+	DueDate           string
+	KnownRansomware   string
+}
+
+// ScanTaskRunTLSFingerprint is the server fingerprint derived from the
+// ServerHellos of one TLS endpoint. Fingerprint is yscan's JARM-style value
+// and JA4S follows the FoxIO layout; both feed the tls_fingerprint evidence
+// type of the fingerprint engine.
+type ScanTaskRunTLSFingerprint struct {
+	IP          string `json:"ip"`
+	Port        int    `json:"port"`
+	Fingerprint string `json:"fingerprint"`
+	JA4S        string `json:"ja4s,omitempty"`
+	Responses   int    `json:"responses"`
+	Variants    int    `json:"variants"`
+}
+
+// ScanTaskRunRisk prioritises the endpoints and hosts of one run. Every
+// score is the sum of its listed factors, capped at 100, so an operator can
+// see why an asset ranks where it does. The task score is the highest host
+// score.
+type ScanTaskRunRisk struct {
+	Score     int              `json:"score"`
+	Level     string           `json:"level"`
+	Hosts     []AssetRiskScore `json:"hosts"`
+	Endpoints []AssetRiskScore `json:"endpoints"`
+}
+
+// AssetRiskScore is the risk of one endpoint, or of one host when Port is 0.
+type AssetRiskScore struct {
+	IP      string       `json:"ip"`
+	Port    int          `json:"port,omitempty"`
+	Score   int          `json:"score"`
+	Level   string       `json:"level"`
+	Factors []RiskFactor `json:"factors"`
+}
+
+// RiskFactor is one contribution to a risk score. Criticality factors carry
+// the points added, or removed, by the asset criticality multiplier.
+type RiskFactor struct {
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
+	Points int    `json:"points"`
+}
+
+const (
+	RiskLevelNone     = "none"
+	RiskLevelLow      = "low"
+	RiskLevelMedium   = "medium"
+	RiskLevelHigh     = "high"
+	RiskLevelCritical = "critical"
+
+	RiskFactorFinding        = "finding"
+	RiskFactorKnownExploited = "known_exploited"
+	RiskFactorCVSS           = "cvss"
+	RiskFactorExposure       = "exposure"
+	RiskFactorAge            = "age"
+	RiskFactorCriticality    = "criticality"
+	RiskFactorEndpoint       = "endpoint"
+	RiskFactorSpread         = "spread"
+)
+
+// RiskLevelForScore buckets a 0-100 risk score.
+func RiskLevelForScore(score int) string {
+	switch {
+	case score >= 80:
+		return RiskLevelCritical
+	case score >= 60:
+		return RiskLevelHigh
+	case score >= 30:
+		return RiskLevelMedium
+	case score > 0:
+		return RiskLevelLow
+	default:
+		return RiskLevelNone
+	}
+}
+
+// AssetCriticalityLabel marks how much an address range matters to the
+// business. The most specific label covering an endpoint scales its risk
+// score when a run is saved; changing a label does not rescore past runs.
+type AssetCriticalityLabel struct {
+	ID        int64  `json:"id"`
+	CIDR      string `json:"cidr"`
+	Level     string `json:"level"`
+	Note      string `json:"note,omitempty"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// IsAssetCriticality reports whether level is a criticality label level.
+func IsAssetCriticality(level string) bool {
+	switch level {
+	case RiskLevelLow, RiskLevelMedium, RiskLevelHigh, RiskLevelCritical:
+		return true
+	default:
+		return false
+	}
+}
+
 type ScanTaskRunSnapshot struct {
 	RunID               int64                           `json:"run_id"`
 	Hosts               []ScanTaskRunHost               `json:"hosts"`
@@ -543,6 +1129,32 @@ type ScanTaskRunSnapshot struct {
 	Vulnerabilities     []ScanTaskRunVulnerability      `json:"vulnerabilities"`
 	TemplateCandidates  []ScanTaskRunTemplateCandidate  `json:"template_candidates"`
 	FingerprintMatches  []FingerprintRunMatch           `json:"fingerprint_matches,omitempty"`
+	HostIdentities      []ScanTaskRunHostIdentity       `json:"host_identities,omitempty"`
+	SSHHostKeys         []ScanTaskRunSSHHostKey         `json:"ssh_host_keys,omitempty"`
+	SNMPSystems         []ScanTaskRunSNMPSystem         `json:"snmp_systems,omitempty"`
+	WebAssets           []ScanTaskRunWebAsset           `json:"web_assets,omitempty"`
+	Weaknesses          []ScanTaskRunWeakness           `json:"weaknesses,omitempty"`
+	TLSFingerprints     []ScanTaskRunTLSFingerprint     `json:"tls_fingerprints,omitempty"`
+	// PotentialVulnerabilities are derived from the fingerprint conclusions
+	// when the snapshot is saved; values supplied by the caller are ignored.
+	PotentialVulnerabilities []ScanTaskRunPotentialVulnerability `json:"potential_vulnerabilities,omitempty"`
+	// Risk is likewise scored when the snapshot is saved. It is nil for runs
+	// saved before risk scoring existed.
+	Risk        *ScanTaskRunRisk         `json:"risk,omitempty"`
+	RawEvidence []ScanTaskRunRawEvidence `json:"-"`
+}
+
+// ScanTaskRunRawEvidence is one sealed fingerprint input retained by a run
+// whose task opted in. Only the summary is readable without the home key.
+type ScanTaskRunRawEvidence struct {
+	IP        string `json:"ip"`
+	Port      int    `json:"port"`
+	Sequence  int    `json:"sequence"`
+	Protocol  string `json:"protocol"`
+	Kind      string `json:"kind"`
+	ProbeName string `json:"probe_name,omitempty"`
+	Summary   string `json:"summary"`
+	Sealed    []byte `json:"-"`
 }
 
 // LegacyTaskSummary exposes v1 task records as read-only history. They never
@@ -624,6 +1236,10 @@ type HostInventory struct {
 	LastSeen   string `json:"last_seen"`
 	LastScan   string `json:"last_scan,omitempty"`
 	IsActive   bool   `json:"is_active"`
+	// RiskScore is the highest host score of the latest successful run of
+	// each task that scored the host.
+	RiskScore int    `json:"risk_score"`
+	RiskLevel string `json:"risk_level"`
 }
 
 // HostScopeMembership records how one scan scope observes one host. It is
@@ -661,6 +1277,8 @@ type AssetPort struct {
 	EndpointValidations []ScanTaskRunEndpointValidation `json:"endpoint_validations"`
 	UnresolvedReasons   []string                        `json:"unresolved_reasons"`
 	LastSeenAt          string                          `json:"last_seen_at"`
+	// FingerprintOverrides lists operator overrides applied in the observation run.
+	FingerprintOverrides []ScanTaskRunFingerprintOverride `json:"fingerprint_overrides"`
 }
 
 type AssetTechnologySource struct {
@@ -693,6 +1311,8 @@ type AssetTechnology struct {
 	Sources            []AssetTechnologySource `json:"sources"`
 	EvidenceSummaries  []string                `json:"evidence_summaries"`
 	ConflictCandidates []string                `json:"conflict_candidates"`
+	// OverrideID is set when an operator assertion produced this product.
+	OverrideID int64 `json:"override_id,omitempty"`
 }
 
 type AssetValidationSummary struct {
@@ -722,6 +1342,8 @@ type AssetDetail struct {
 	Host   HostInventory         `json:"host"`
 	Scopes []HostScopeMembership `json:"scopes"`
 	Ports  []AssetPort           `json:"ports"`
+	// Identities come from the latest run that collected any for the host.
+	Identities []ScanTaskRunHostIdentity `json:"identities"`
 }
 
 type HostChanges struct {
@@ -729,6 +1351,49 @@ type HostChanges struct {
 	InactiveHosts []string `json:"inactive_hosts"`
 }
 
+// HostIdentityChange is one identity field that differs between two runs
+// which both observed the host's identity.
+type HostIdentityChange struct {
+	IP     string `json:"ip"`
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// SSHHostKeyChange is an SSH endpoint that presented a different key of the
+// same algorithm than in an earlier run.
+type SSHHostKeyChange struct {
+	IP            string `json:"ip"`
+	Port          int    `json:"port"`
+	Algorithm     string `json:"algorithm"`
+	Before        string `json:"before"`
+	After         string `json:"after"`
+	BaselineRunID int64  `json:"baseline_run_id,omitempty"`
+	CurrentRunID  int64  `json:"current_run_id,omitempty"`
+}
+
+// SSHHostKeyClone is one host key presented by more than one IP, which
+// usually means the machines were cloned from a template without
+// regenerating their keys.
+type SSHHostKeyClone struct {
+	Algorithm   string               `json:"algorithm"`
+	Fingerprint string               `json:"fingerprint"`
+	Endpoints   []SSHHostKeyEndpoint `json:"endpoints"`
+}
+
+type SSHHostKeyEndpoint struct {
+	IP    string `json:"ip"`
+	Port  int    `json:"port"`
+	RunID int64  `json:"run_id,omitempty"`
+}
+
+// SSHHostKeyReport lists cloned and changed host keys across the latest
+// observation of every SSH endpoint.
+type SSHHostKeyReport struct {
+	Clones  []SSHHostKeyClone  `json:"clones"`
+	Changes []SSHHostKeyChange `json:"changes"`
+}
+
 type PortChange struct {
 	IP   string `json:"ip"`
 	Port int    `json:"port"`
@@ -752,13 +1417,48 @@ type VulnerabilityChanges struct {
 }
 
 type ScanTaskRunChanges struct {
-	ScanTaskID           int64                `json:"scan_task_id"`
-	BaselineRunID        int64                `json:"baseline_run_id,omitempty"`
-	CurrentRunID         int64                `json:"current_run_id"`
-	ConfigChanged        bool                 `json:"config_changed"`
+	ScanTaskID    int64 `json:"scan_task_id"`
+	BaselineRunID int64 `json:"baseline_run_id,omitempty"`
+	CurrentRunID  int64 `json:"current_run_id"`
+	ConfigChanged bool  `json:"config_changed"`
+	// FindingsOnly marks a revalidation run compared with its source run:
+	// the observations are shared, so only vulnerability changes are reported.
+	FindingsOnly         bool                 `json:"findings_only,omitempty"`
 	HostChanges          HostChanges          `json:"host_changes"`
 	PortChanges          PortChanges          `json:"port_changes"`
 	VulnerabilityChanges VulnerabilityChanges `json:"vulnerability_changes"`
+	IdentityChanges      []HostIdentityChange `json:"identity_changes,omitempty"`
+	SSHHostKeyChanges    []SSHHostKeyChange   `json:"ssh_host_key_changes,omitempty"`
+	WeaknessChanges      WeaknessChanges      `json:"weakness_changes"`
+	// RiskChanges is nil when either run predates risk scoring.
+	RiskChanges *RiskChanges `json:"risk_changes,omitempty"`
+}
+
+// RiskChanges is the trend of the task risk score and the hosts whose score
+// moved between the two runs.
+type RiskChanges struct {
+	Before      int              `json:"before"`
+	After       int              `json:"after"`
+	Delta       int              `json:"delta"`
+	LevelBefore string           `json:"level_before"`
+	LevelAfter  string           `json:"level_after"`
+	Hosts       []HostRiskChange `json:"hosts"`
+}
+
+type HostRiskChange struct {
+	IP          string `json:"ip"`
+	Before      int    `json:"before"`
+	After       int    `json:"after"`
+	Delta       int    `json:"delta"`
+	LevelBefore string `json:"level_before"`
+	LevelAfter  string `json:"level_after"`
+}
+
+// WeaknessChanges lists posture findings that appeared on, or disappeared
+// from, endpoints present in both runs.
+type WeaknessChanges struct {
+	New      []ScanTaskRunWeakness `json:"new"`
+	Resolved []ScanTaskRunWeakness `json:"resolved"`
 }
 
 type TaskChangeSummary struct {
@@ -44,3 +44,12 @@ func TestFingerprintProductClassificationSeparatesWebStackLayers(t *testing.T) {
 		}
 	}
 }
+
+func TestSeverityRankOrdersFindingSeverities(t *testing.T) {
+	ordered := []string{"Critical", "high", "medium", "low", "info", "unknown"}
+	for index := 1; index < len(ordered); index++ {
+		if SeverityRank(ordered[index-1]) <= SeverityRank(ordered[index]) {
+			t.Fatalf("%s does not rank above %s", ordered[index-1], ordered[index])
+		}
+	}
+}
@@ -59,6 +59,7 @@ type NucleiTemplateIndexEntry struct {
 	CPEConstraints      []NucleiCPEConstraint
 	ReviewedTagProducts []string
 	SelectionNames      []string
+	filterReason        string
 }
 
 type NucleiTemplateIndex struct {
@@ -69,6 +70,20 @@ type NucleiTemplateIndex struct {
 	filteredBySelector map[string][]NucleiTemplateIndexEntry
 }
 
+// NucleiFilteredTemplate names an actionable template the strict execution
+// projection refused, and why. It is reported for planning only.
+type NucleiFilteredTemplate struct {
+	TemplateID string
+	Path       string
+	Reason     string
+}
+
+const (
+	NucleiFilterReasonUnsupportedStructure = "template uses fields outside the reviewed safe subset"
+	NucleiFilterReasonUnsafeRequest        = "template protocol, method or tag is not read-only"
+	NucleiFilterReasonUnsafeIdentity       = "template ID or path names an intrusive check"
+)
+
 type nucleiIndexDocument struct {
 	ID   string `yaml:"id"`
 	Info struct {
@@ -126,6 +141,13 @@ func BuildNucleiTemplateIndex(root string) (*NucleiTemplateIndex, error) {
 	}
 	entries := make([]NucleiTemplateIndexEntry, 0)
 	filteredBySelector := make(map[string][]NucleiTemplateIndexEntry)
+	filter := func(document nucleiIndexDocument, path, reason string) {
+		relative, err := filepath.Rel(base, path)
+		if err != nil {
+			relative = path
+		}
+		markPolicyFilteredSelectors(filteredBySelector, document, filepath.ToSlash(relative), reason)
+	}
 	fileCount := 0
 	totalBytes := int64(0)
 	err = filepath.WalkDir(base, func(path string, entry fs.DirEntry, walkErr error) error {
@@ -182,12 +204,12 @@ func BuildNucleiTemplateIndex(root string) (*NucleiTemplateIndex, error) {
 			return nil
 		}
 		if !safeAutomaticNucleiTemplate(rootNode) {
-			markPolicyFilteredSelectors(filteredBySelector, document)
+			filter(document, path, NucleiFilterReasonUnsupportedStructure)
 			return nil
 		}
 		projection, ok := projectNucleiTemplateForIndex(document)
 		if !ok {
-			markPolicyFilteredSelectors(filteredBySelector, document)
+			filter(document, path, NucleiFilterReasonUnsafeRequest)
 			return nil
 		}
 		relative, err := filepath.Rel(base, path)
@@ -195,7 +217,7 @@ func BuildNucleiTemplateIndex(root string) (*NucleiTemplateIndex, error) {
 			return errors.New("nuclei template escaped index root")
 		}
 		if unsafeAutomaticTemplateIdentity(document.ID, filepath.ToSlash(relative)) {
-			markPolicyFilteredSelectors(filteredBySelector, document)
+			filter(document, path, NucleiFilterReasonUnsafeIdentity)
 			return nil
 		}
 		digest := sha256.Sum256(content)
@@ -281,8 +303,15 @@ func readBoundedNucleiTemplate(path string, remainingBytes int64) ([]byte, int64
 // exact product/protocol was excluded by the strict execution projection. It
 // never exposes or executes the rejected template.
 func (index *NucleiTemplateIndex) PolicyFiltered(product, cpe, protocol string) bool {
+	return len(index.FilteredTemplates(product, cpe, protocol)) > 0
+}
+
+// FilteredTemplates lists the actionable templates for this exact
+// product/protocol that the strict execution projection excluded, ordered by
+// template ID and path. The rejected content is never retained.
+func (index *NucleiTemplateIndex) FilteredTemplates(product, cpe, protocol string) []NucleiFilteredTemplate {
 	if index == nil {
-		return false
+		return nil
 	}
 	selectors := productAliasesForTemplateIndex(product)
 	endpointCPE, hasEndpointCPE := parseNucleiCPE(cpe)
@@ -293,21 +322,37 @@ func (index *NucleiTemplateIndex) PolicyFiltered(product, cpe, protocol string)
 	if protocol == "https" {
 		protocol = "http"
 	}
+	seen := make(map[string]struct{})
+	result := make([]NucleiFilteredTemplate, 0)
 	for _, selector := range normalizedIndexValues(selectors) {
 		for _, entry := range index.filteredBySelector[selector+"\x00"+protocol] {
-			if templateProtocolCompatible(entry.Protocols, protocol) && templateProductCompatible(entry, product, endpointCPE, hasEndpointCPE) {
-				return true
+			if !templateProtocolCompatible(entry.Protocols, protocol) || !templateProductCompatible(entry, product, endpointCPE, hasEndpointCPE) {
+				continue
+			}
+			if _, duplicate := seen[entry.Path]; duplicate {
+				continue
 			}
+			seen[entry.Path] = struct{}{}
+			result = append(result, NucleiFilteredTemplate{TemplateID: entry.TemplateID, Path: entry.Path, Reason: entry.filterReason})
 		}
 	}
-	return false
+	sort.Slice(result, func(left, right int) bool {
+		if result[left].TemplateID != result[right].TemplateID {
+			return result[left].TemplateID < result[right].TemplateID
+		}
+		return result[left].Path < result[right].Path
+	})
+	return result
 }
 
-func markPolicyFilteredSelectors(filtered map[string][]NucleiTemplateIndexEntry, document nucleiIndexDocument) {
+func markPolicyFilteredSelectors(filtered map[string][]NucleiTemplateIndexEntry, document nucleiIndexDocument, path, reason string) {
 	entry, ok := projectNucleiTemplateSelectors(document)
 	if !ok {
 		return
 	}
+	entry.TemplateID = strings.TrimSpace(document.ID)
+	entry.Path = path
+	entry.filterReason = reason
 	for _, selector := range entry.SelectionNames {
 		for _, protocol := range entry.Protocols {
 			key := selector + "\x00" + protocol
@@ -162,6 +162,14 @@ http:
 	if !index.PolicyFiltered("php", "", "https") {
 		t.Fatal("unsafe PHP payload/auth templates were not recorded as policy-filtered")
 	}
+	filtered := index.FilteredTemplates("php", "", "https")
+	reasons := make(map[string]string, len(filtered))
+	for _, template := range filtered {
+		reasons[template.Path] = template.Reason
+	}
+	if len(filtered) != 5 || reasons["unsafe/payload-auth.yaml"] != NucleiFilterReasonUnsupportedStructure || reasons["unsafe/header-auth.yaml"] != NucleiFilterReasonUnsupportedStructure {
+		t.Fatalf("filtered PHP templates=%#v", filtered)
+	}
 	if index.PolicyFiltered("python", "", "http") {
 		t.Fatal("unreviewed runtime tag must not become a rejected product selector")
 	}
@@ -7,7 +7,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 //go:embed reviewed_nuclei_templates.json
@@ -91,3 +94,60 @@ func ValidateReviewedNucleiTemplateMatrix(index *NucleiTemplateIndex) (ReviewedN
 	}
 	return matrix, nil
 }
+
+const maxNucleiRequestSummaryParts = 8
+
+// SummarizeNucleiTemplateRequests describes what a template sends, one part
+// per HTTP path, raw request line or TCP input, for reviewers of a mapping.
+func SummarizeNucleiTemplateRequests(content []byte) string {
+	var document struct {
+		HTTP []struct {
+			Method string   `yaml:"method"`
+			Path   []string `yaml:"path"`
+			Raw    []string `yaml:"raw"`
+		} `yaml:"http"`
+		TCP []struct {
+			Inputs []struct {
+				Data string `yaml:"data"`
+			} `yaml:"inputs"`
+		} `yaml:"tcp"`
+	}
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return ""
+	}
+	parts := make([]string, 0)
+	for _, request := range document.HTTP {
+		method := strings.ToUpper(strings.TrimSpace(request.Method))
+		if method == "" {
+			method = "GET"
+		}
+		for _, path := range request.Path {
+			path = strings.TrimSpace(path)
+			for _, prefix := range []string{"{{BaseURL}}", "{{RootURL}}", "{{Hostname}}"} {
+				path = strings.TrimPrefix(path, prefix)
+			}
+			if path == "" {
+				path = "/"
+			}
+			parts = append(parts, method+" "+path)
+		}
+		for _, raw := range request.Raw {
+			if line := strings.TrimSpace(strings.SplitN(strings.TrimSpace(raw), "\n", 2)[0]); line != "" {
+				parts = append(parts, line)
+			}
+		}
+	}
+	for _, request := range document.TCP {
+		for _, input := range request.Inputs {
+			data := input.Data
+			if len(data) > 64 {
+				data = data[:64] + "..."
+			}
+			parts = append(parts, "TCP send "+strconv.Quote(data))
+		}
+	}
+	if len(parts) > maxNucleiRequestSummaryParts {
+		parts = append(parts[:maxNucleiRequestSummaryParts], fmt.Sprintf("%d more", len(parts)-maxNucleiRequestSummaryParts))
+	}
+	return strings.Join(parts, "; ")
+}
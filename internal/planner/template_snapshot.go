@@ -31,8 +31,9 @@ func (entry NucleiTemplateIndexEntry) PinnedTemplate() PinnedNucleiTemplate {
 // private, read-only, content-addressed directory. Nuclei never receives an
 // upstream path that can change after indexing.
 func MaterializePinnedNucleiTemplates(templates []PinnedNucleiTemplate) (*NucleiTemplateSnapshot, error) {
-	if len(templates) == 0 {
-		return nil, errors.New("at least one pinned nuclei template is required")
+	digests, unique, err := uniquePinnedTemplates(templates)
+	if err != nil {
+		return nil, err
 	}
 	directory, err := os.MkdirTemp("", "caasm-nuclei-snapshot-*")
 	if err != nil {
@@ -43,20 +44,6 @@ func MaterializePinnedNucleiTemplates(templates []PinnedNucleiTemplate) (*Nuclei
 		_ = snapshot.Close()
 		return nil, err
 	}
-	unique := make(map[string]PinnedNucleiTemplate)
-	for _, template := range templates {
-		digest := sha256.Sum256(template.Content)
-		actual := hex.EncodeToString(digest[:])
-		if len(template.Content) == 0 || !strings.EqualFold(strings.TrimSpace(template.SHA256), actual) {
-			return cleanupOnError(fmt.Errorf("%w: hash mismatch: %s", ErrPinnedTemplateMissing, template.Path))
-		}
-		unique[actual] = template
-	}
-	digests := make([]string, 0, len(unique))
-	for digest := range unique {
-		digests = append(digests, digest)
-	}
-	sort.Strings(digests)
 	for _, digest := range digests {
 		path := filepath.Join(directory, digest+".yaml")
 		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0400)
@@ -83,6 +70,35 @@ func MaterializePinnedNucleiTemplates(templates []PinnedNucleiTemplate) (*Nuclei
 	return snapshot, nil
 }
 
+// VerifyPinnedNucleiTemplates checks templates the way
+// MaterializePinnedNucleiTemplates does without writing them, and returns the
+// sorted digests that name the snapshot files.
+func VerifyPinnedNucleiTemplates(templates []PinnedNucleiTemplate) ([]string, error) {
+	digests, _, err := uniquePinnedTemplates(templates)
+	return digests, err
+}
+
+func uniquePinnedTemplates(templates []PinnedNucleiTemplate) ([]string, map[string]PinnedNucleiTemplate, error) {
+	if len(templates) == 0 {
+		return nil, nil, errors.New("at least one pinned nuclei template is required")
+	}
+	unique := make(map[string]PinnedNucleiTemplate)
+	for _, template := range templates {
+		digest := sha256.Sum256(template.Content)
+		actual := hex.EncodeToString(digest[:])
+		if len(template.Content) == 0 || !strings.EqualFold(strings.TrimSpace(template.SHA256), actual) {
+			return nil, nil, fmt.Errorf("%w: hash mismatch: %s", ErrPinnedTemplateMissing, template.Path)
+		}
+		unique[actual] = template
+	}
+	digests := make([]string, 0, len(unique))
+	for digest := range unique {
+		digests = append(digests, digest)
+	}
+	sort.Strings(digests)
+	return digests, unique, nil
+}
+
 func (snapshot *NucleiTemplateSnapshot) Close() error {
 	if snapshot == nil || snapshot.directory == "" {
 		return nil
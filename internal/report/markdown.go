@@ -1,6 +1,7 @@
 package report
 
 import (
+	"bytes"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -15,6 +16,7 @@ import (
 	"golandproject/yscan/internal/diff"
 	"golandproject/yscan/internal/model"
 	"golandproject/yscan/internal/storage"
+	"golandproject/yscan/internal/vuln"
 )
 
 var runtimeReportDirectory struct {
@@ -96,7 +98,13 @@ type ScanTaskRunReport struct {
 	FingerprintImports     []model.FingerprintImport
 	FingerprintMatches     []map[string]interface{}
 	FingerprintConclusions []map[string]interface{}
-	GeneratedAt            time.Time
+	// FingerprintOverrides are the operator overrides applied when the
+	// run's conclusions were written.
+	FingerprintOverrides []model.ScanTaskRunFingerprintOverride
+	// SSHHostKeyClones lists keys of this run that more than one IP presents,
+	// within the run or in the latest observation of other endpoints.
+	SSHHostKeyClones []model.SSHHostKeyClone
+	GeneratedAt      time.Time
 }
 
 func GenerateScanTaskRunReport(db *sql.DB, scanTaskID, runID int64, directory string) (string, error) {
@@ -151,11 +159,25 @@ func GenerateScanTaskRunReport(db *sql.DB, scanTaskID, runID int64, directory st
 	if err != nil && !isMissingFingerprintReportTable(err) {
 		return "", err
 	}
+	fingerprintOverrides, err := storage.ListScanTaskRunFingerprintOverrides(db, run.ID)
+	if err != nil {
+		return "", err
+	}
+
+	var hostKeyReport model.SSHHostKeyReport
+	if len(snapshot.SSHHostKeys) > 0 {
+		if hostKeyReport, err = storage.GetSSHHostKeyReport(db); err != nil {
+			return "", err
+		}
+	}
 
 	transaction, err := prepareScanTaskRunReport(directory, ScanTaskRunReport{
 		Task: task, Run: reportRun, Changes: changes, Snapshot: snapshot,
 		FingerprintImports: frozenImports, FingerprintMatches: fingerprintMatches,
-		FingerprintConclusions: fingerprintConclusions, GeneratedAt: time.Now().UTC(),
+		FingerprintConclusions: fingerprintConclusions,
+		FingerprintOverrides:   fingerprintOverrides,
+		SSHHostKeyClones:       runSSHHostKeyClones(run.ID, snapshot.SSHHostKeys, hostKeyReport.Clones),
+		GeneratedAt:            time.Now().UTC(),
 	})
 	if err != nil {
 		return "", err
@@ -516,20 +538,44 @@ func RenderScanTaskRunMarkdown(report ScanTaskRunReport) string {
 	fmt.Fprintf(&builder, "| Run ID | %d |\n", report.Run.ID)
 	fmt.Fprintf(&builder, "| Target | %s |\n", markdownCell(report.Run.Target))
 	fmt.Fprintf(&builder, "| Run Status | %s |\n", markdownCell(report.Run.Status))
+	if report.Snapshot.Risk != nil {
+		fmt.Fprintf(&builder, "| Risk Score | %d (%s) |\n", report.Snapshot.Risk.Score, markdownCell(report.Snapshot.Risk.Level))
+	}
 	fmt.Fprintf(&builder, "| Generated | %s |\n\n", generatedAt.Format(time.RFC3339))
 
+	writeRunRisk(&builder, report.Snapshot.Risk)
 	writeRunValidation(&builder, report.Snapshot.Validation, report.Snapshot.Vulnerabilities)
+	writeRunPotentialVulnerabilities(&builder, report.Snapshot.PotentialVulnerabilities)
 	writeRunEndpointProfiles(&builder, report)
+	writeRunHostIdentities(&builder, report.Snapshot.HostIdentities)
+	writeRunSSHHostKeys(&builder, report.Snapshot.SSHHostKeys, report.SSHHostKeyClones)
+	writeRunSNMPSystems(&builder, report.Snapshot.SNMPSystems)
+	writeRunTLSFingerprints(&builder, report.Snapshot.TLSFingerprints)
+	writeRunWebAssets(&builder, report.Snapshot.WebAssets)
+	writeRunWeaknesses(&builder, report.Snapshot.Weaknesses)
 
 	builder.WriteString("## Asset Changes\n\n")
 	fmt.Fprintf(&builder, "Baseline run: %d. Configuration changed: %t.\n\n", report.Changes.BaselineRunID, report.Changes.ConfigChanged)
+	if report.Changes.FindingsOnly {
+		builder.WriteString("This run revalidated the endpoints of the baseline run; only vulnerability findings are compared.\n\n")
+		writeStringList(&builder, "New findings", vulnerabilityChangeLabels(report.Changes.VulnerabilityChanges.New))
+		writeStringList(&builder, "Resolved findings", vulnerabilityChangeLabels(report.Changes.VulnerabilityChanges.Resolved))
+		writeRiskChanges(&builder, report.Changes.RiskChanges)
+		return builder.String()
+	}
 	writeStringList(&builder, "New hosts", report.Changes.HostChanges.NewHosts)
 	writeStringList(&builder, "Inactive hosts", report.Changes.HostChanges.InactiveHosts)
 	writePortChanges(&builder, "Opened ports", report.Changes.PortChanges.Opened)
 	writePortChanges(&builder, "Closed ports", report.Changes.PortChanges.Closed)
+	writeIdentityChanges(&builder, report.Changes.IdentityChanges)
+	writeSSHHostKeyChanges(&builder, report.Changes.SSHHostKeyChanges)
+	writeWeaknessChanges(&builder, report.Changes.WeaknessChanges)
+	writeRiskChanges(&builder, report.Changes.RiskChanges)
 	return builder.String()
 }
 
+// writeRunValidation lists every finding of the run. The report is written
+// once per run, so triage state splits the table rather than filtering it.
 func writeRunValidation(builder *strings.Builder, validation model.ScanTaskRunValidation, findings []model.ScanTaskRunVulnerability) {
 	builder.WriteString("## Vulnerability Validation\n\n")
 	status := validation.Status
@@ -577,13 +623,51 @@ func writeRunValidation(builder *strings.Builder, validation model.ScanTaskRunVa
 		builder.WriteString("No vulnerability findings were recorded.\n\n")
 		return
 	}
-	builder.WriteString("| Severity | Name | Endpoint | Template | Matched at | Summary |\n| --- | --- | --- | --- | --- | --- |\n")
+	actionable := make([]model.ScanTaskRunVulnerability, 0, len(findings))
+	setAside := make([]model.ScanTaskRunVulnerability, 0)
 	for _, finding := range findings {
-		fmt.Fprintf(builder, "| %s | %s | %s | %s | %s | %s |\n", markdownCell(finding.Severity), markdownCell(finding.Name), markdownCell(finding.Target), markdownCell(finding.TemplateID), markdownCell(finding.MatchedAt), markdownCell(finding.Description))
+		if model.FindingStateActionable(finding.State) {
+			actionable = append(actionable, finding)
+		} else {
+			setAside = append(setAside, finding)
+		}
+	}
+	if len(actionable) == 0 {
+		builder.WriteString("Every finding has been triaged as a false positive or an accepted risk.\n\n")
+	} else {
+		builder.WriteString("| Severity | State | Name | Endpoint | Template | Matched at | Summary |\n| --- | --- | --- | --- | --- | --- | --- |\n")
+		for _, finding := range actionable {
+			fmt.Fprintf(builder, "| %s | %s | %s | %s | %s | %s | %s |\n", markdownCell(finding.Severity), markdownCell(findingStateLabel(finding)), markdownCell(finding.Name), markdownCell(finding.Target), markdownCell(finding.TemplateID), markdownCell(finding.MatchedAt), markdownCell(finding.Description))
+		}
+		builder.WriteString("\n")
+	}
+	if len(setAside) == 0 {
+		return
+	}
+	builder.WriteString("### Set Aside by Triage\n\n")
+	builder.WriteString("| Severity | State | Name | Endpoint | Template |\n| --- | --- | --- | --- | --- |\n")
+	for _, finding := range setAside {
+		fmt.Fprintf(builder, "| %s | %s | %s | %s | %s |\n", markdownCell(finding.Severity), markdownCell(findingStateLabel(finding)), markdownCell(finding.Name), markdownCell(finding.Target), markdownCell(finding.TemplateID))
 	}
 	builder.WriteString("\n")
 }
 
+// findingStateLabel shows the triage state as of report generation, with the
+// assignee and the end of a risk acceptance when present.
+func findingStateLabel(finding model.ScanTaskRunVulnerability) string {
+	label := finding.State
+	if label == "" {
+		label = model.FindingStateOpen
+	}
+	if finding.RiskAcceptedUntil != "" {
+		label += " until " + finding.RiskAcceptedUntil
+	}
+	if finding.Assignee != "" {
+		label += " (" + finding.Assignee + ")"
+	}
+	return label
+}
+
 func writeRunEndpointProfiles(builder *strings.Builder, report ScanTaskRunReport) {
 	builder.WriteString("## Endpoint Profiles\n\n")
 	if len(report.Snapshot.Ports) == 0 {
@@ -809,7 +893,16 @@ func uniqueReportStrings(values []string) []string {
 
 func protocolEvidenceSummary(evidence []model.ScanTaskRunProtocolEvidence) string {
 	parts := make([]string, 0, len(evidence))
+	crawledPages := 0
 	for _, item := range evidence {
+		if item.EvidenceType == model.ProtocolEvidenceWebPage {
+			// Individual pages are listed in the snapshot; the summary
+			// only says how much of the application was read.
+			if item.Responded {
+				crawledPages++
+			}
+			continue
+		}
 		if item.EvidenceType == model.ProtocolEvidencePassiveBanner || (item.EvidenceType == "" && item.Protocol == "tcp") {
 			if item.Responded {
 				parts = append(parts, fmt.Sprintf("TCP banner %d bytes", item.BannerCapturedLength))
@@ -828,6 +921,29 @@ func protocolEvidenceSummary(evidence []model.ScanTaskRunProtocolEvidence) strin
 			}
 			continue
 		}
+		if item.EvidenceType == model.ProtocolEvidenceTLSFingerprint {
+			if item.Responded {
+				parts = append(parts, fmt.Sprintf("TLS hellos %d bytes", item.BannerCapturedLength))
+			}
+			continue
+		}
+		if item.EvidenceType == model.ProtocolEvidenceImported {
+			if !item.Responded {
+				continue
+			}
+			value := "Imported " + item.ProbeName
+			if item.Server != "" {
+				value += " server=" + item.Server
+			}
+			if item.Title != "" {
+				value += " title=" + item.Title
+			}
+			if item.BannerCapturedLength > 0 {
+				value += fmt.Sprintf(" %d bytes", item.BannerCapturedLength)
+			}
+			parts = append(parts, value)
+			continue
+		}
 		if !item.Responded {
 			continue
 		}
@@ -844,6 +960,9 @@ func protocolEvidenceSummary(evidence []model.ScanTaskRunProtocolEvidence) strin
 		value += fmt.Sprintf(" response=%d bytes", item.BodyCapturedLength)
 		parts = append(parts, value)
 	}
+	if crawledPages > 0 {
+		parts = append(parts, fmt.Sprintf("crawled %d pages", crawledPages))
+	}
 	if len(parts) == 0 {
 		return "No protocol response summary; passive banner unavailable"
 	}
@@ -865,21 +984,28 @@ func RenderScanTaskRunAuditMarkdown(report ScanTaskRunReport) string {
 	fmt.Fprintf(&builder, "| Sequence | %d |\n", report.Run.Sequence)
 	fmt.Fprintf(&builder, "| Target | %s |\n", markdownCell(report.Run.Target))
 	fmt.Fprintf(&builder, "| Scan Type | %s |\n", markdownCell(report.Run.ScanType))
+	fmt.Fprintf(&builder, "| Trigger | %s |\n", markdownCell(report.Run.Trigger))
 	fmt.Fprintf(&builder, "| Status | %s |\n", markdownCell(report.Run.Status))
 	fmt.Fprintf(&builder, "| Scheduled For | %s |\n", markdownCell(report.Run.ScheduledFor))
 	fmt.Fprintf(&builder, "| Started | %s |\n", markdownCell(report.Run.StartedAt))
 	fmt.Fprintf(&builder, "| Finished | %s |\n", markdownCell(report.Run.FinishedAt))
 	fmt.Fprintf(&builder, "| Baseline Run ID | %d |\n", report.Changes.BaselineRunID)
 	fmt.Fprintf(&builder, "| Config Changed | %t |\n", report.Changes.ConfigChanged)
+	if report.Changes.FindingsOnly {
+		builder.WriteString("| Findings Only | true |\n")
+	}
 	fmt.Fprintf(&builder, "| Generated | %s |\n\n", generatedAt.Format(time.RFC3339))
 
 	builder.WriteString("## Host Changes\n\n")
 	writeStringList(&builder, "New hosts", report.Changes.HostChanges.NewHosts)
 	writeStringList(&builder, "Inactive hosts", report.Changes.HostChanges.InactiveHosts)
+	writeIdentityChanges(&builder, report.Changes.IdentityChanges)
+	writeSSHHostKeyChanges(&builder, report.Changes.SSHHostKeyChanges)
 
 	builder.WriteString("## Port Changes\n\n")
 	writePortChanges(&builder, "Opened ports", report.Changes.PortChanges.Opened)
 	writePortChanges(&builder, "Closed ports", report.Changes.PortChanges.Closed)
+	writeWeaknessChanges(&builder, report.Changes.WeaknessChanges)
 
 	builder.WriteString("## Frozen Fingerprint Revisions\n\n")
 	if len(report.FingerprintImports) == 0 {
@@ -908,6 +1034,19 @@ func RenderScanTaskRunAuditMarkdown(report ScanTaskRunReport) string {
 		builder.WriteString("\n")
 	}
 
+	builder.WriteString("## Operator Overrides\n\n")
+	if len(report.FingerprintOverrides) == 0 {
+		builder.WriteString("No operator overrides applied to this run.\n\n")
+	} else {
+		builder.WriteString("| Endpoint | Product | Effect | Override | Reason |\n| --- | --- | --- | --- | --- |\n")
+		for _, override := range report.FingerprintOverrides {
+			fmt.Fprintf(&builder, "| %s:%d/%s | %s | %s | #%d %s | %s |\n",
+				markdownCell(override.IP), override.Port, markdownCell(override.Protocol), markdownCell(override.ProductKey),
+				markdownCell(override.Effect), override.OverrideID, markdownCell(override.Kind), markdownCell(override.Reason))
+		}
+		builder.WriteString("\n")
+	}
+
 	builder.WriteString("## Fingerprint Evidence\n\n")
 	if len(report.FingerprintMatches) == 0 {
 		builder.WriteString("No fingerprint matcher evidence was recorded.\n\n")
@@ -954,9 +1093,49 @@ func RenderScanTaskRunAuditMarkdown(report ScanTaskRunReport) string {
 			markdownCell(finding.Name),
 		)
 	}
+	writeAuditFindingEvidence(&builder, report.Snapshot.Vulnerabilities)
 	return builder.String()
 }
 
+// auditEvidenceExcerptBytes bounds each finding's evidence in the audit
+// report; the full redacted request and response stay behind the evidence API.
+const auditEvidenceExcerptBytes = 1024
+
+const auditFindingEvidenceHeading = "\n## Finding Evidence\n\n"
+
+func writeAuditFindingEvidence(builder *strings.Builder, findings []model.ScanTaskRunVulnerability) {
+	builder.WriteString(auditFindingEvidenceHeading)
+	redaction := vuln.ConfiguredEvidenceRedaction()
+	written := 0
+	for _, finding := range findings {
+		if strings.TrimSpace(finding.Evidence) == "" {
+			continue
+		}
+		label := finding.TemplateID
+		if label == "" {
+			label = finding.FindingKey
+		}
+		evidence := vuln.RedactFindingEvidence(finding.Evidence, redaction)
+		excerpt := strings.ReplaceAll(evidence.Excerpt(auditEvidenceExcerptBytes), "```", "'''")
+		fmt.Fprintf(builder, "### %s · %s\n\n%d value(s) redacted.\n\n```text\n%s\n```\n\n", markdownCell(label), markdownCell(finding.Target), evidence.Redactions, excerpt)
+		written++
+	}
+	if written == 0 {
+		builder.WriteString("No finding evidence was stored for this run.\n")
+	}
+}
+
+// WithoutAuditFindingEvidence replaces the evidence excerpts of a rendered
+// audit report with a note, for readers without the evidence permission.
+func WithoutAuditFindingEvidence(content []byte) []byte {
+	index := bytes.LastIndex(content, []byte(auditFindingEvidenceHeading))
+	if index < 0 {
+		return content
+	}
+	stripped := append([]byte(nil), content[:index+len(auditFindingEvidenceHeading)]...)
+	return append(stripped, "Finding evidence requires the evidence permission (YSCAN_EVIDENCE_CIDRS).\n"...)
+}
+
 func reportMapString(value map[string]interface{}, key string) string {
 	if value[key] == nil {
 		return ""
@@ -1041,6 +1220,10 @@ func emptyRunChanges(scanTaskID, runID int64) model.ScanTaskRunChanges {
 			New:      make([]model.VulnerabilityChange, 0),
 			Resolved: make([]model.VulnerabilityChange, 0),
 		},
+		WeaknessChanges: model.WeaknessChanges{
+			New:      make([]model.ScanTaskRunWeakness, 0),
+			Resolved: make([]model.ScanTaskRunWeakness, 0),
+		},
 	}
 }
 
@@ -1068,8 +1251,320 @@ func writePortChanges(builder *strings.Builder, title string, changes []model.Po
 	builder.WriteString("\n")
 }
 
+// writeRunHostIdentities lists the names hosts disclosed in NTLM challenges.
+// Runs that reached no Windows endpoint omit the section.
+func writeRunHostIdentities(builder *strings.Builder, identities []model.ScanTaskRunHostIdentity) {
+	if len(identities) == 0 {
+		return
+	}
+	builder.WriteString("## Host Identities\n\n")
+	builder.WriteString("| Endpoint | Source | Computer | Domain | DNS Name | Forest | OS Version |\n| --- | --- | --- | --- | --- | --- | --- |\n")
+	for _, identity := range identities {
+		fmt.Fprintf(builder, "| %s:%d | %s | %s | %s | %s | %s | %s |\n", markdownCell(identity.IP), identity.Port, markdownCell(identity.Source),
+			markdownCell(identity.ComputerName), markdownCell(identity.DomainName), markdownCell(identity.DNSComputerName),
+			markdownCell(identity.DNSTreeName), markdownCell(identity.OSVersion))
+	}
+	builder.WriteString("\n")
+}
+
+func writeIdentityChanges(builder *strings.Builder, changes []model.HostIdentityChange) {
+	if len(changes) == 0 {
+		return
+	}
+	builder.WriteString("### Identity changes\n\n")
+	for _, change := range changes {
+		fmt.Fprintf(builder, "- `%s` %s: `%s` → `%s`\n", markdownCell(change.IP), markdownCell(change.Field), markdownCell(change.Before), markdownCell(change.After))
+	}
+	builder.WriteString("\n")
+}
+
+// writeRunSSHHostKeys lists the host keys of the run and flags keys shared
+// by several IPs, which usually indicates machines cloned from one template.
+func writeRunSSHHostKeys(builder *strings.Builder, keys []model.ScanTaskRunSSHHostKey, clones []model.SSHHostKeyClone) {
+	if len(keys) == 0 {
+		return
+	}
+	shared := make(map[string]int, len(clones))
+	for _, clone := range clones {
+		shared[clone.Fingerprint] = len(clone.Endpoints)
+	}
+	builder.WriteString("## SSH Host Keys\n\n")
+	builder.WriteString("| Endpoint | Algorithm | Bits | Fingerprint | Shared |\n| --- | --- | --- | --- | --- |\n")
+	for _, key := range keys {
+		sharedWith := "-"
+		if count := shared[key.Fingerprint]; count > 0 {
+			sharedWith = fmt.Sprintf("%d endpoints", count)
+		}
+		fmt.Fprintf(builder, "| %s:%d | %s | %d | `%s` | %s |\n", markdownCell(key.IP), key.Port, markdownCell(key.Algorithm), key.Bits, markdownCell(key.Fingerprint), sharedWith)
+	}
+	builder.WriteString("\n")
+	if len(clones) == 0 {
+		return
+	}
+	builder.WriteString("### Shared host keys\n\n")
+	for _, clone := range clones {
+		endpoints := make([]string, 0, len(clone.Endpoints))
+		for _, endpoint := range clone.Endpoints {
+			endpoints = append(endpoints, fmt.Sprintf("`%s:%d`", markdownCell(endpoint.IP), endpoint.Port))
+		}
+		fmt.Fprintf(builder, "- %s `%s`: %s\n", markdownCell(clone.Algorithm), markdownCell(clone.Fingerprint), strings.Join(endpoints, ", "))
+	}
+	builder.WriteString("\n")
+}
+
+// writeRunSNMPSystems lists the devices that answered the task's SNMP
+// credential with the product their system group maps to.
+func writeRunSNMPSystems(builder *strings.Builder, systems []model.ScanTaskRunSNMPSystem) {
+	if len(systems) == 0 {
+		return
+	}
+	builder.WriteString("## SNMP Inventory\n\n")
+	builder.WriteString("| Device | sysName | Product | Version | sysObjectID | Location | Interfaces (up) |\n| --- | --- | --- | --- | --- | --- | --- |\n")
+	for _, system := range systems {
+		product := system.Product
+		if product == "" {
+			product = system.Vendor
+		}
+		up := 0
+		for _, entry := range system.Interfaces {
+			if entry.OperStatus == "up" {
+				up++
+			}
+		}
+		fmt.Fprintf(builder, "| %s | %s | %s | %s | `%s` | %s | %d (%d) |\n", markdownCell(system.IP), markdownCell(system.SysName), markdownCell(product),
+			markdownCell(system.Version), markdownCell(system.SysObjectID), markdownCell(system.SysLocation), len(system.Interfaces), up)
+	}
+	builder.WriteString("\n")
+}
+
+// writeRunTLSFingerprints lists the server fingerprints of TLS endpoints so
+// identical stacks behind different addresses can be spotted.
+func writeRunTLSFingerprints(builder *strings.Builder, fingerprints []model.ScanTaskRunTLSFingerprint) {
+	if len(fingerprints) == 0 {
+		return
+	}
+	builder.WriteString("## TLS Fingerprints\n\n")
+	builder.WriteString("| Endpoint | Fingerprint | JA4S | Answered |\n| --- | --- | --- | --- |\n")
+	for _, fingerprint := range fingerprints {
+		fmt.Fprintf(builder, "| %s:%d | `%s` | `%s` | %d/%d |\n", markdownCell(fingerprint.IP), fingerprint.Port,
+			markdownCell(fingerprint.Fingerprint), markdownCell(fingerprint.JA4S), fingerprint.Responses, fingerprint.Variants)
+	}
+	builder.WriteString("\n")
+}
+
+// writeRunWebAssets lists crawled scripts and stylesheets that advertise a
+// library version; unversioned assets stay in the snapshot only.
+func writeRunWebAssets(builder *strings.Builder, assets []model.ScanTaskRunWebAsset) {
+	versioned := 0
+	for _, asset := range assets {
+		if len(asset.Versions) > 0 {
+			versioned++
+		}
+	}
+	if versioned == 0 {
+		return
+	}
+	builder.WriteString("## Web Assets\n\n")
+	builder.WriteString("| Endpoint | Kind | Path | Versions | SHA-256 |\n| --- | --- | --- | --- | --- |\n")
+	for _, asset := range assets {
+		if len(asset.Versions) == 0 {
+			continue
+		}
+		fmt.Fprintf(builder, "| %s:%d | %s | `%s` | %s | `%s` |\n", markdownCell(asset.IP), asset.Port, markdownCell(asset.Kind),
+			markdownCell(asset.Path), markdownCell(strings.Join(asset.Versions, ", ")), markdownCell(asset.SHA256))
+	}
+	builder.WriteString("\n")
+}
+
+// writeRunWeaknesses lists web posture findings, most severe first. They are
+// configuration weaknesses, so they stay apart from validated findings.
+func writeRunWeaknesses(builder *strings.Builder, weaknesses []model.ScanTaskRunWeakness) {
+	if len(weaknesses) == 0 {
+		return
+	}
+	ordered := append([]model.ScanTaskRunWeakness(nil), weaknesses...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return model.SeverityRank(ordered[i].Severity) > model.SeverityRank(ordered[j].Severity)
+	})
+	builder.WriteString("## Web Weaknesses\n\n")
+	builder.WriteString("| Severity | Endpoint | Check | Path | Detail |\n| --- | --- | --- | --- | --- |\n")
+	for _, weakness := range ordered {
+		fmt.Fprintf(builder, "| %s | %s:%d | %s | `%s` | %s |\n", markdownCell(weakness.Severity), markdownCell(weakness.IP), weakness.Port,
+			markdownCell(weakness.Check), markdownCell(weakness.Path), markdownCell(weakness.Detail))
+	}
+	builder.WriteString("\n")
+}
+
+// writeRunRisk lists scored hosts and endpoints, riskiest first, with the
+// factors each score adds up from.
+func writeRunRisk(builder *strings.Builder, risk *model.ScanTaskRunRisk) {
+	if risk == nil || len(risk.Endpoints) == 0 {
+		return
+	}
+	builder.WriteString("## Risk Priorities\n\n")
+	builder.WriteString("Scores add up the listed factors and are capped at 100.\n\n")
+	for _, group := range []struct {
+		title  string
+		scores []model.AssetRiskScore
+	}{{"Hosts", risk.Hosts}, {"Endpoints", risk.Endpoints}} {
+		fmt.Fprintf(builder, "### %s\n\n", group.title)
+		builder.WriteString("| Score | Level | Asset | Factors |\n| --- | --- | --- | --- |\n")
+		for _, score := range group.scores {
+			asset := score.IP
+			if score.Port > 0 {
+				asset = fmt.Sprintf("%s:%d", score.IP, score.Port)
+			}
+			factors := make([]string, 0, len(score.Factors))
+			for _, factor := range score.Factors {
+				factors = append(factors, fmt.Sprintf("%s %s %+d", factor.Kind, factor.Detail, factor.Points))
+			}
+			fmt.Fprintf(builder, "| %d | %s | %s | %s |\n", score.Score, markdownCell(score.Level), markdownCell(asset), markdownCell(strings.Join(factors, "; ")))
+		}
+		builder.WriteString("\n")
+	}
+}
+
+func writeRiskChanges(builder *strings.Builder, changes *model.RiskChanges) {
+	if changes == nil {
+		return
+	}
+	builder.WriteString("### Risk trend\n\n")
+	fmt.Fprintf(builder, "Task risk: %d (%s) → %d (%s), %+d.\n\n", changes.Before, markdownCell(changes.LevelBefore), changes.After, markdownCell(changes.LevelAfter), changes.Delta)
+	if len(changes.Hosts) == 0 {
+		return
+	}
+	for _, host := range changes.Hosts {
+		fmt.Fprintf(builder, "- `%s` %d (%s) → %d (%s), %+d\n", markdownCell(host.IP), host.Before, markdownCell(host.LevelBefore), host.After, markdownCell(host.LevelAfter), host.Delta)
+	}
+	builder.WriteString("\n")
+}
+
+// writeRunPotentialVulnerabilities lists CVEs inferred from endpoint CPEs and
+// versions. Known exploited CVEs come first, then by CVSS score; none of them
+// has been validated against the target.
+func writeRunPotentialVulnerabilities(builder *strings.Builder, items []model.ScanTaskRunPotentialVulnerability) {
+	if len(items) == 0 {
+		return
+	}
+	ordered := append([]model.ScanTaskRunPotentialVulnerability(nil), items...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].KnownExploited != ordered[j].KnownExploited {
+			return ordered[i].KnownExploited
+		}
+		return ordered[i].CVSSScore > ordered[j].CVSSScore
+	})
+	builder.WriteString("## Potential Vulnerabilities\n\n")
+	builder.WriteString("Matched from fingerprinted CPE and version against imported NVD data; not validated against the target.\n\n")
+	builder.WriteString("| CVE | Endpoint | Product | Version | Matched Range | CVSS | Severity | KEV |\n| --- | --- | --- | --- | --- | --- | --- | --- |\n")
+	for _, item := range ordered {
+		cvss := "-"
+		if item.CVSSVersion != "" {
+			cvss = fmt.Sprintf("%.1f (v%s)", item.CVSSScore, item.CVSSVersion)
+		}
+		kev := "no"
+		if item.KnownExploited {
+			kev = "yes"
+			if item.KEVDateAdded != "" {
+				kev += " (" + item.KEVDateAdded + ")"
+			}
+		}
+		fmt.Fprintf(builder, "| %s | %s:%d/%s | %s | %s | %s | %s | %s | %s |\n", markdownCell(item.CVEID), markdownCell(item.IP), item.Port,
+			markdownCell(item.Protocol), markdownCell(item.ProductKey), markdownCell(item.Version), markdownCell(item.VersionRange),
+			cvss, markdownCell(item.Severity), markdownCell(kev))
+	}
+	builder.WriteString("\n")
+}
+
+func writeWeaknessChanges(builder *strings.Builder, changes model.WeaknessChanges) {
+	for _, group := range []struct {
+		title      string
+		weaknesses []model.ScanTaskRunWeakness
+	}{{"New weaknesses", changes.New}, {"Resolved weaknesses", changes.Resolved}} {
+		if len(group.weaknesses) == 0 {
+			continue
+		}
+		fmt.Fprintf(builder, "### %s\n\n", group.title)
+		for _, weakness := range group.weaknesses {
+			subject := ""
+			if weakness.Subject != "" {
+				subject = " " + markdownCell(weakness.Subject)
+			}
+			fmt.Fprintf(builder, "- `%s:%d` %s%s (%s)\n", markdownCell(weakness.IP), weakness.Port, markdownCell(weakness.Check), subject, markdownCell(weakness.Severity))
+		}
+		builder.WriteString("\n")
+	}
+}
+
+func writeSSHHostKeyChanges(builder *strings.Builder, changes []model.SSHHostKeyChange) {
+	if len(changes) == 0 {
+		return
+	}
+	builder.WriteString("### SSH host key changes\n\n")
+	for _, change := range changes {
+		fmt.Fprintf(builder, "- `%s:%d` %s: `%s` → `%s`\n", markdownCell(change.IP), change.Port, markdownCell(change.Algorithm), markdownCell(change.Before), markdownCell(change.After))
+	}
+	builder.WriteString("\n")
+}
+
+// runSSHHostKeyClones groups the run's keys by fingerprint and adds other
+// IPs whose latest observation presents the same key.
+func runSSHHostKeyClones(runID int64, keys []model.ScanTaskRunSSHHostKey, latest []model.SSHHostKeyClone) []model.SSHHostKeyClone {
+	byFingerprint := make(map[string]*model.SSHHostKeyClone)
+	order := make([]string, 0)
+	seen := make(map[string]struct{})
+	add := func(algorithm, fingerprint string, endpoint model.SSHHostKeyEndpoint) {
+		clone, found := byFingerprint[fingerprint]
+		if !found {
+			clone = &model.SSHHostKeyClone{Algorithm: algorithm, Fingerprint: fingerprint}
+			byFingerprint[fingerprint] = clone
+			order = append(order, fingerprint)
+		}
+		key := fmt.Sprintf("%s\x00%s:%d", fingerprint, endpoint.IP, endpoint.Port)
+		if _, duplicate := seen[key]; duplicate {
+			return
+		}
+		seen[key] = struct{}{}
+		clone.Endpoints = append(clone.Endpoints, endpoint)
+	}
+	for _, key := range keys {
+		add(key.Algorithm, key.Fingerprint, model.SSHHostKeyEndpoint{IP: key.IP, Port: key.Port, RunID: runID})
+	}
+	for _, clone := range latest {
+		if _, found := byFingerprint[clone.Fingerprint]; !found {
+			continue
+		}
+		for _, endpoint := range clone.Endpoints {
+			add(clone.Algorithm, clone.Fingerprint, endpoint)
+		}
+	}
+	clones := make([]model.SSHHostKeyClone, 0)
+	for _, fingerprint := range order {
+		clone := byFingerprint[fingerprint]
+		ips := make(map[string]struct{}, len(clone.Endpoints))
+		for _, endpoint := range clone.Endpoints {
+			ips[endpoint.IP] = struct{}{}
+		}
+		if len(ips) > 1 {
+			clones = append(clones, *clone)
+		}
+	}
+	return clones
+}
+
 func markdownCell(value string) string {
 	value = strings.ReplaceAll(value, "|", "\\|")
 	value = strings.ReplaceAll(value, "\n", " ")
 	return strings.TrimSpace(value)
 }
+
+func vulnerabilityChangeLabels(changes []model.VulnerabilityChange) []string {
+	labels := make([]string, 0, len(changes))
+	for _, change := range changes {
+		template := change.TemplateID
+		if template == "" {
+			template = change.FindingKey
+		}
+		labels = append(labels, fmt.Sprintf("%s %s (%s)", template, change.Target, change.Severity))
+	}
+	return labels
+}
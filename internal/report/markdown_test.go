@@ -149,8 +149,9 @@ func TestRunReportRendersFrozenRevisionConclusionsEvidenceAndMapping(t *testing.
 		FingerprintConclusions: []map[string]interface{}{{"ip": "192.168.75.1", "port": 443, "protocol": "https", "product_key": "openssh", "version": "9.6", "cpe": "cpe:/a:openbsd:openssh:9.6", "tags": []string{"ssh"}, "conclusion_status": "corroborated", "product_status": "corroborated", "product_source_count": 2, "version_status": "matched", "version_source_count": 1, "cpe_status": "matched", "cpe_source_count": 1}},
 		FingerprintMatches:     []map[string]interface{}{{"ip": "192.168.75.1", "port": 443, "protocol": "https", "product_key": "openssh", "source_key": "nmap", "source_rule_id": "fixture", "matcher_evidence": []map[string]interface{}{{"summary": "tcp_banner banner bytes=16 sha256=redacted"}}}},
 		Snapshot:               model.ScanTaskRunSnapshot{TemplateCandidates: []model.ScanTaskRunTemplateCandidate{{TemplateID: "openssh-check", Path: "tcp/openssh.yaml", Source: "fingerprint_mapping", Reason: "approved mapping", IP: "192.168.75.1", Port: 443, Protocol: "https", TemplateSetRevision: "templates-r1", TemplateSHA256: "template-sha"}}},
+		FingerprintOverrides:   []model.ScanTaskRunFingerprintOverride{{OverrideID: 4, Kind: model.FingerprintOverrideSuppress, IP: "192.168.75.1", Port: 443, Protocol: "https", ProductKey: "weblogic", Effect: model.FingerprintOverrideEffectSuppressed, Reason: "portal error page"}},
 	})
-	for _, expected := range []string{"## Frozen Fingerprint Revisions", "adapter-v2", "projection-sha", "## Fingerprint Conclusions", "Product evidence status", "Version evidence status", "CPE evidence status", "corroborated (2 sources)", "9.6 / matched (1 sources)", "cpe:/a:openbsd:openssh:9.6 / matched (1 sources)", "## Fingerprint Evidence", "tcp_banner banner bytes=16 sha256=redacted", "192.168.75.1:443/https", "templates-r1", "template-sha"} {
+	for _, expected := range []string{"## Frozen Fingerprint Revisions", "adapter-v2", "projection-sha", "## Fingerprint Conclusions", "Product evidence status", "Version evidence status", "CPE evidence status", "corroborated (2 sources)", "9.6 / matched (1 sources)", "cpe:/a:openbsd:openssh:9.6 / matched (1 sources)", "## Operator Overrides", "| 192.168.75.1:443/https | weblogic | suppressed | #4 suppress | portal error page |", "## Fingerprint Evidence", "tcp_banner banner bytes=16 sha256=redacted", "192.168.75.1:443/https", "templates-r1", "template-sha"} {
 		if !strings.Contains(content, expected) {
 			t.Fatalf("report missing %q:\n%s", expected, content)
 		}
@@ -200,6 +201,50 @@ func TestRunUserReportLeadsWithValidationAndKeepsAuditDetailsOut(t *testing.T) {
 	}
 }
 
+func TestRunReportFlagsSharedAndChangedSSHHostKeys(t *testing.T) {
+	keys := []model.ScanTaskRunSSHHostKey{
+		{IP: "192.168.75.10", Port: 22, Algorithm: "ssh-ed25519", Fingerprint: "SHA256:template", Bits: 256},
+		{IP: "192.168.75.11", Port: 22, Algorithm: "ssh-ed25519", Fingerprint: "SHA256:unique", Bits: 256},
+	}
+	latest := []model.SSHHostKeyClone{
+		{Algorithm: "ssh-ed25519", Fingerprint: "SHA256:template", Endpoints: []model.SSHHostKeyEndpoint{{IP: "192.168.75.10", Port: 22, RunID: 8}, {IP: "192.168.80.5", Port: 2222, RunID: 4}}},
+		{Algorithm: "ssh-rsa", Fingerprint: "SHA256:elsewhere", Endpoints: []model.SSHHostKeyEndpoint{{IP: "192.168.80.6", Port: 22}, {IP: "192.168.80.7", Port: 22}}},
+	}
+	clones := runSSHHostKeyClones(9, keys, latest)
+	if len(clones) != 1 || clones[0].Fingerprint != "SHA256:template" || len(clones[0].Endpoints) != 2 || clones[0].Endpoints[1].IP != "192.168.80.5" {
+		t.Fatalf("run clones = %#v", clones)
+	}
+	content := RenderScanTaskRunMarkdown(ScanTaskRunReport{
+		Task: model.ScanTask{ID: 7}, Run: model.ScanTaskRun{ID: 9, ScanTaskID: 7, Target: "192.168.75.0/24", Status: model.ScanTaskRunStatusSuccess},
+		Snapshot:         model.ScanTaskRunSnapshot{SSHHostKeys: keys},
+		SSHHostKeyClones: clones,
+		Changes: model.ScanTaskRunChanges{SSHHostKeyChanges: []model.SSHHostKeyChange{
+			{IP: "192.168.75.11", Port: 22, Algorithm: "ssh-ed25519", Before: "SHA256:previous", After: "SHA256:unique"},
+		}},
+	})
+	for _, expected := range []string{"## SSH Host Keys", "| 192.168.75.10:22 | ssh-ed25519 | 256 | `SHA256:template` | 2 endpoints |", "### Shared host keys", "`192.168.80.5:2222`", "### SSH host key changes", "`SHA256:previous` → `SHA256:unique`"} {
+		if !strings.Contains(content, expected) {
+			t.Fatalf("user report missing %q:\n%s", expected, content)
+		}
+	}
+}
+
+func TestRunReportListsSNMPInventory(t *testing.T) {
+	content := RenderScanTaskRunMarkdown(ScanTaskRunReport{
+		Task: model.ScanTask{ID: 7}, Run: model.ScanTaskRun{ID: 9, ScanTaskID: 7, Target: "192.168.75.0/24", Status: model.ScanTaskRunStatusSuccess},
+		Snapshot: model.ScanTaskRunSnapshot{SNMPSystems: []model.ScanTaskRunSNMPSystem{{
+			IP: "192.168.75.1", Port: 161, Credential: "core", SysName: "core-sw1", SysObjectID: "1.3.6.1.4.1.9.1.2494", SysLocation: "DC1 | rack 4",
+			Vendor: "cisco", Product: "cisco ios xe", Version: "17.3.4",
+			Interfaces: []model.ScanTaskRunSNMPInterface{{Index: 1, OperStatus: "up"}, {Index: 2, OperStatus: "down"}},
+		}}},
+	})
+	for _, expected := range []string{"## SNMP Inventory", "| 192.168.75.1 | core-sw1 | cisco ios xe | 17.3.4 | `1.3.6.1.4.1.9.1.2494` | DC1 \\| rack 4 | 2 (1) |"} {
+		if !strings.Contains(content, expected) {
+			t.Fatalf("user report missing %q:\n%s", expected, content)
+		}
+	}
+}
+
 func TestEndpointReportMarksRunSuccessWithoutEndpointCandidatesAsUnmapped(t *testing.T) {
 	port := model.ScanTaskRunPort{IP: "192.168.75.2", Port: 22222, ServiceType: "ssh"}
 	snapshot := model.ScanTaskRunSnapshot{Validation: model.ScanTaskRunValidation{Status: model.ScanTaskRunValidationSuccess}}
@@ -412,3 +457,139 @@ func completeRunReportTest(t *testing.T, db *sql.DB, taskID int64, scheduledFor
 	}
 	return run
 }
+
+func TestRunReportListsVersionedWebAssetsAndCrawlSummary(t *testing.T) {
+	digest := strings.Repeat("cd", 32)
+	content := RenderScanTaskRunMarkdown(ScanTaskRunReport{
+		Task: model.ScanTask{ID: 7}, Run: model.ScanTaskRun{ID: 9, ScanTaskID: 7, Target: "192.168.75.0/24", Status: model.ScanTaskRunStatusSuccess},
+		Snapshot: model.ScanTaskRunSnapshot{WebAssets: []model.ScanTaskRunWebAsset{
+			{IP: "192.168.75.20", Port: 8080, Protocol: "http", Kind: "script", Path: "/static/jquery-3.6.0.min.js", SHA256: digest, Versions: []string{"jquery 3.6.0"}},
+			{IP: "192.168.75.20", Port: 8080, Protocol: "http", Kind: "style", Path: "/css/site.css", SHA256: digest},
+		}},
+	})
+	if !strings.Contains(content, "## Web Assets") || !strings.Contains(content, "| 192.168.75.20:8080 | script | `/static/jquery-3.6.0.min.js` | jquery 3.6.0 | `"+digest+"` |") {
+		t.Fatalf("user report missing web assets:\n%s", content)
+	}
+	if strings.Contains(content, "/css/site.css") {
+		t.Fatalf("unversioned asset listed:\n%s", content)
+	}
+	summary := protocolEvidenceSummary([]model.ScanTaskRunProtocolEvidence{
+		{EvidenceType: model.ProtocolEvidenceWeb, Protocol: "http", Responded: true, StatusCode: 200, BodyCapturedLength: 10},
+		{EvidenceType: model.ProtocolEvidenceWebPage, Protocol: "http", ProbeName: "/login", Responded: true},
+		{EvidenceType: model.ProtocolEvidenceWebPage, Protocol: "http", ProbeName: "/api/", Responded: true},
+	})
+	if summary != "HTTP 200 response=10 bytes; crawled 2 pages" {
+		t.Fatalf("summary = %q", summary)
+	}
+}
+
+func TestRunReportListsWeaknessesAndTheirChanges(t *testing.T) {
+	weaknesses := []model.ScanTaskRunWeakness{
+		{IP: "192.168.75.20", Port: 443, Protocol: "https", Check: "missing_csp", Severity: "info", Path: "/", Detail: "Content-Security-Policy header absent"},
+		{IP: "192.168.75.20", Port: 443, Protocol: "https", Check: "directory_listing", Subject: "/files/", Severity: "medium", Path: "/files/", Detail: "directory index is browsable"},
+	}
+	content := RenderScanTaskRunMarkdown(ScanTaskRunReport{
+		Task: model.ScanTask{ID: 7}, Run: model.ScanTaskRun{ID: 9, ScanTaskID: 7, Target: "192.168.75.0/24", Status: model.ScanTaskRunStatusSuccess},
+		Snapshot: model.ScanTaskRunSnapshot{Weaknesses: weaknesses},
+		Changes:  model.ScanTaskRunChanges{WeaknessChanges: model.WeaknessChanges{New: weaknesses[1:], Resolved: []model.ScanTaskRunWeakness{{IP: "192.168.75.20", Port: 443, Check: "missing_hsts", Severity: "low"}}}},
+	})
+	listing := strings.Index(content, "| medium | 192.168.75.20:443 | directory_listing | `/files/` |")
+	csp := strings.Index(content, "| info | 192.168.75.20:443 | missing_csp | `/` |")
+	if !strings.Contains(content, "## Web Weaknesses") || listing < 0 || csp < listing {
+		t.Fatalf("user report weaknesses missing or unordered:\n%s", content)
+	}
+	for _, expected := range []string{"### New weaknesses", "- `192.168.75.20:443` directory_listing /files/ (medium)", "### Resolved weaknesses", "- `192.168.75.20:443` missing_hsts (low)"} {
+		if !strings.Contains(content, expected) {
+			t.Fatalf("user report missing %q:\n%s", expected, content)
+		}
+	}
+}
+
+func TestRunReportListsRiskPrioritiesAndTrend(t *testing.T) {
+	content := RenderScanTaskRunMarkdown(ScanTaskRunReport{
+		Task: model.ScanTask{ID: 7}, Run: model.ScanTaskRun{ID: 9, ScanTaskID: 7, Target: "192.168.77.0/24", Status: model.ScanTaskRunStatusSuccess},
+		Snapshot: model.ScanTaskRunSnapshot{Risk: &model.ScanTaskRunRisk{
+			Score: 65, Level: model.RiskLevelHigh,
+			Hosts: []model.AssetRiskScore{{IP: "192.168.77.10", Score: 65, Level: model.RiskLevelHigh, Factors: []model.RiskFactor{
+				{Kind: model.RiskFactorEndpoint, Detail: "192.168.77.10:8080", Points: 65},
+			}}},
+			Endpoints: []model.AssetRiskScore{{IP: "192.168.77.10", Port: 8080, Score: 65, Level: model.RiskLevelHigh, Factors: []model.RiskFactor{
+				{Kind: model.RiskFactorFinding, Detail: "critical CVE-2024-1111", Points: 40},
+				{Kind: model.RiskFactorKnownExploited, Detail: "CVE-2024-1111 validated", Points: 25},
+			}}},
+		}},
+		Changes: model.ScanTaskRunChanges{RiskChanges: &model.RiskChanges{
+			Before: 15, After: 65, Delta: 50, LevelBefore: model.RiskLevelLow, LevelAfter: model.RiskLevelHigh,
+			Hosts: []model.HostRiskChange{{IP: "192.168.77.10", Before: 15, After: 65, Delta: 50, LevelBefore: model.RiskLevelLow, LevelAfter: model.RiskLevelHigh}},
+		}},
+	})
+	for _, expected := range []string{
+		"| Risk Score | 65 (high) |",
+		"## Risk Priorities",
+		"| 65 | high | 192.168.77.10:8080 | finding critical CVE-2024-1111 +40; known_exploited CVE-2024-1111 validated +25 |",
+		"Task risk: 15 (low) → 65 (high), +50.",
+		"- `192.168.77.10` 15 (low) → 65 (high), +50",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Fatalf("user report missing %q:\n%s", expected, content)
+		}
+	}
+}
+
+func TestRunReportListsPotentialVulnerabilitiesApartFromValidatedFindings(t *testing.T) {
+	content := RenderScanTaskRunMarkdown(ScanTaskRunReport{
+		Task: model.ScanTask{ID: 7}, Run: model.ScanTaskRun{ID: 9, ScanTaskID: 7, Target: "192.168.76.0/24", Status: model.ScanTaskRunStatusSuccess},
+		Snapshot: model.ScanTaskRunSnapshot{PotentialVulnerabilities: []model.ScanTaskRunPotentialVulnerability{
+			{IP: "192.168.76.20", Port: 80, Protocol: "http", ProductKey: "nginx", Version: "1.18.0", CVEID: "CVE-2021-23017", VersionRange: ">=0.6.18 <1.20.1", CVSSVersion: "3.1", CVSSScore: 7.7, Severity: "high"},
+			{IP: "192.168.76.20", Port: 80, Protocol: "http", ProductKey: "nginx", Version: "1.18.0", CVEID: "CVE-2020-0001", VersionRange: "=1.18.0", CVSSVersion: "3.1", CVSSScore: 5.3, Severity: "medium", KnownExploited: true, KEVDateAdded: "2021-11-03"},
+		}},
+	})
+	kev := strings.Index(content, "| CVE-2020-0001 | 192.168.76.20:80/http | nginx | 1.18.0 | =1.18.0 | 5.3 (v3.1) | medium | yes (2021-11-03) |")
+	ranged := strings.Index(content, "| CVE-2021-23017 | 192.168.76.20:80/http | nginx | 1.18.0 | >=0.6.18 <1.20.1 | 7.7 (v3.1) | high | no |")
+	if !strings.Contains(content, "## Potential Vulnerabilities") || kev < 0 || ranged < kev {
+		t.Fatalf("potential vulnerabilities missing or unordered:\n%s", content)
+	}
+	if !strings.Contains(content, "not validated against the target") {
+		t.Fatalf("potential vulnerabilities must be marked as unvalidated:\n%s", content)
+	}
+}
+
+func TestRunReportSetsAsideFalsePositivesAndAcceptedRisks(t *testing.T) {
+	content := RenderScanTaskRunMarkdown(ScanTaskRunReport{
+		Task: model.ScanTask{ID: 7}, Run: model.ScanTaskRun{ID: 9, ScanTaskID: 7, Target: "192.168.77.0/24", Status: model.ScanTaskRunStatusSuccess},
+		Snapshot: model.ScanTaskRunSnapshot{
+			Validation: model.ScanTaskRunValidation{Status: model.ScanTaskRunValidationSuccess, FindingCount: 2},
+			Vulnerabilities: []model.ScanTaskRunVulnerability{
+				{FindingKey: "a", Severity: "high", Name: "Redis unauthenticated", Target: "192.168.77.10:6379", TemplateID: "redis-unauth", State: model.FindingStateConfirmed, Assignee: "ops"},
+				{FindingKey: "b", Severity: "medium", Name: "Git config exposed", Target: "http://192.168.77.11/.git/config", TemplateID: "git-config", State: model.FindingStateRiskAccepted, RiskAcceptedUntil: "2099-01-01T00:00:00Z"},
+			},
+		},
+	})
+	confirmed := strings.Index(content, "| high | confirmed (ops) | Redis unauthenticated |")
+	setAside := strings.Index(content, "### Set Aside by Triage")
+	accepted := strings.Index(content, "| medium | risk_accepted until 2099-01-01T00:00:00Z | Git config exposed |")
+	if confirmed < 0 || setAside < confirmed || accepted < setAside {
+		t.Fatalf("triaged findings missing or not separated:\n%s", content)
+	}
+}
+
+func TestRunAuditReportIncludesRedactedEvidenceExcerpt(t *testing.T) {
+	evidence := `{"template-id":"exposed-panel","matcher-name":"login","request":"GET /admin HTTP/1.1\r\nHost: 192.168.75.1\r\nAuthorization: Bearer abcdefghijklmnop\r\n\r\n","response":"HTTP/1.1 200 OK\r\n\r\n{\"password\":\"hunter2\"}"}`
+	content := RenderScanTaskRunAuditMarkdown(ScanTaskRunReport{
+		Task: model.ScanTask{ID: 7}, Run: model.ScanTaskRun{ID: 9, ScanTaskID: 7},
+		Snapshot: model.ScanTaskRunSnapshot{Vulnerabilities: []model.ScanTaskRunVulnerability{
+			{FindingKey: "panel", TemplateID: "exposed-panel", Severity: "medium", Target: "http://192.168.75.1/admin", Evidence: evidence},
+			{FindingKey: "native", TemplateID: "redis-unauth-info", Severity: "high", Target: "192.168.75.1:6379"},
+		}},
+	})
+	for _, expected := range []string{"## Finding Evidence", "### exposed-panel · http://192.168.75.1/admin", "2 value(s) redacted.", "matcher: login", "Authorization: [REDACTED]", `"password":"[REDACTED]"`} {
+		if !strings.Contains(content, expected) {
+			t.Fatalf("audit report missing %q:\n%s", expected, content)
+		}
+	}
+	for _, forbidden := range []string{"abcdefghijklmnop", "hunter2", "### redis-unauth-info"} {
+		if strings.Contains(content, forbidden) {
+			t.Fatalf("audit report exposed %q:\n%s", forbidden, content)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Package risk shows the risk scores stored with each scan task run and
+// manages the asset criticality labels that weight them.
+package risk
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golandproject/yscan/internal/model"
+	"golandproject/yscan/internal/storage"
+)
+
+const usage = "usage: yscan risk show <scan_task_id> <run_id>\n" +
+	"       yscan risk label list\n" +
+	"       yscan risk label set --cidr <cidr> --level low|medium|high|critical [--note <text>]\n" +
+	"       yscan risk label remove --cidr <cidr>"
+
+// RunCLI prints run risk scores and maintains criticality labels for
+// "yscan risk". Labels apply to runs saved after they change.
+func RunCLI(db *sql.DB, args []string, output io.Writer) error {
+	if db == nil || output == nil {
+		return errors.New("risk CLI database and output are required")
+	}
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "show":
+		if len(args) != 3 {
+			return errors.New(usage)
+		}
+		return showRunRisk(db, args[1], args[2], output)
+	case "label":
+		if len(args) < 2 {
+			return errors.New(usage)
+		}
+		return runLabelCommand(db, args[1], args[2:], output)
+	default:
+		return errors.New(usage)
+	}
+}
+
+func showRunRisk(db *sql.DB, taskArg, runArg string, output io.Writer) error {
+	taskID, err := strconv.ParseInt(strings.TrimSpace(taskArg), 10, 64)
+	if err != nil || taskID <= 0 {
+		return errors.New("invalid scan task id")
+	}
+	runID, err := strconv.ParseInt(strings.TrimSpace(runArg), 10, 64)
+	if err != nil || runID <= 0 {
+		return errors.New("invalid scan task run id")
+	}
+	run, err := storage.GetScanTaskRun(db, runID)
+	if err != nil {
+		return err
+	}
+	if run.ScanTaskID != taskID {
+		return storage.ErrScanTaskRunNotFound
+	}
+	snapshot, err := storage.GetScanTaskRunSnapshot(db, run.ID)
+	if err != nil {
+		return err
+	}
+	if snapshot.Risk == nil {
+		return fmt.Errorf("scan task run %d was saved before risk scoring", run.ID)
+	}
+	return writeRunRisk(output, *snapshot.Risk)
+}
+
+func runLabelCommand(db *sql.DB, action string, args []string, output io.Writer) error {
+	switch action {
+	case "list":
+		if len(args) != 0 {
+			return errors.New(usage)
+		}
+		labels, err := storage.ListAssetCriticalityLabels(db)
+		if err != nil {
+			return err
+		}
+		return writeLabels(output, labels)
+	case "set":
+		cidr, level, note, err := parseLabelArgs(args, true)
+		if err != nil {
+			return err
+		}
+		label, err := storage.SetAssetCriticalityLabel(db, cidr, level, note)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(output, "criticality label %s set to %s\n", label.CIDR, label.Level)
+		return err
+	case "remove":
+		cidr, _, _, err := parseLabelArgs(args, false)
+		if err != nil {
+			return err
+		}
+		if err := storage.RemoveAssetCriticalityLabel(db, cidr); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(output, "criticality label %s removed\n", cidr)
+		return err
+	default:
+		return errors.New(usage)
+	}
+}
+
+// parseLabelArgs reads --cidr and, when levels are allowed, --level and
+// --note. The level is validated by storage.
+func parseLabelArgs(args []string, withLevel bool) (string, string, string, error) {
+	var cidr, level, note string
+	for index := 0; index < len(args); index++ {
+		if index+1 >= len(args) {
+			return "", "", "", fmt.Errorf("%s requires a value", args[index])
+		}
+		value := strings.TrimSpace(args[index+1])
+		switch {
+		case args[index] == "--cidr":
+			cidr = value
+		case args[index] == "--level" && withLevel:
+			level = value
+		case args[index] == "--note" && withLevel:
+			note = value
+		default:
+			return "", "", "", fmt.Errorf("unsupported flag: %s", args[index])
+		}
+		index++
+	}
+	if cidr == "" {
+		return "", "", "", errors.New("--cidr is required")
+	}
+	if withLevel && level == "" {
+		return "", "", "", errors.New("--level is required")
+	}
+	return cidr, level, note, nil
+}
+
+// writeRunRisk prints the task score, then hosts and endpoints from the
+// highest score down with the factors that make up each score.
+func writeRunRisk(output io.Writer, risk model.ScanTaskRunRisk) error {
+	if _, err := fmt.Fprintf(output, "risk %d (%s)\n", risk.Score, risk.Level); err != nil {
+		return err
+	}
+	for _, score := range append(append([]model.AssetRiskScore(nil), risk.Hosts...), risk.Endpoints...) {
+		asset := "host " + score.IP
+		if score.Port > 0 {
+			asset = fmt.Sprintf("endpoint %s:%d", score.IP, score.Port)
+		}
+		factors := make([]string, 0, len(score.Factors))
+		for _, factor := range score.Factors {
+			factors = append(factors, fmt.Sprintf("%s %s %+d", factor.Kind, factor.Detail, factor.Points))
+		}
+		if _, err := fmt.Fprintf(output, "%s %d (%s) %s\n", asset, score.Score, score.Level, strings.Join(factors, "; ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeLabels(output io.Writer, labels []model.AssetCriticalityLabel) error {
+	for _, label := range labels {
+		line := fmt.Sprintf("%s %s", label.CIDR, label.Level)
+		if label.Note != "" {
+			line += fmt.Sprintf(" %q", label.Note)
+		}
+		if _, err := fmt.Fprintln(output, line); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(output, "%d criticality labels\n", len(labels))
+	return err
+}
@@ -0,0 +1,47 @@
+package risk
+
+import (
+	"strings"
+	"testing"
+
+	"golandproject/yscan/internal/model"
+)
+
+func TestParseLabelArgsRequiresCIDRAndLevel(t *testing.T) {
+	cidr, level, note, err := parseLabelArgs([]string{"--cidr", "10.0.0.0/24", "--level", "high", "--note", "payments"}, true)
+	if err != nil || cidr != "10.0.0.0/24" || level != "high" || note != "payments" {
+		t.Fatalf("cidr=%q level=%q note=%q err=%v", cidr, level, note, err)
+	}
+	for _, args := range [][]string{{"--level", "high"}, {"--cidr", "10.0.0.0/24"}, {"--cidr"}, {"--owner", "ops"}} {
+		if _, _, _, err := parseLabelArgs(args, true); err == nil {
+			t.Fatalf("args %q were accepted", args)
+		}
+	}
+	if _, _, _, err := parseLabelArgs([]string{"--cidr", "10.0.0.5", "--level", "high"}, false); err == nil {
+		t.Fatal("remove accepted --level")
+	}
+}
+
+func TestWriteRunRiskListsHostsThenEndpoints(t *testing.T) {
+	var output strings.Builder
+	err := writeRunRisk(&output, model.ScanTaskRunRisk{
+		Score: 27, Level: model.RiskLevelLow,
+		Hosts: []model.AssetRiskScore{{IP: "10.0.0.5", Score: 27, Level: model.RiskLevelLow, Factors: []model.RiskFactor{
+			{Kind: model.RiskFactorEndpoint, Detail: "10.0.0.5:3306", Points: 25},
+			{Kind: model.RiskFactorSpread, Detail: "1 more scored endpoint", Points: 2},
+		}}},
+		Endpoints: []model.AssetRiskScore{{IP: "10.0.0.5", Port: 3306, Score: 25, Level: model.RiskLevelLow, Factors: []model.RiskFactor{
+			{Kind: model.RiskFactorExposure, Detail: "database (mysql)", Points: 20},
+			{Kind: model.RiskFactorCriticality, Detail: "high (10.0.0.5/32)", Points: 5},
+		}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "risk 27 (low)\n" +
+		"host 10.0.0.5 27 (low) endpoint 10.0.0.5:3306 +25; spread 1 more scored endpoint +2\n" +
+		"endpoint 10.0.0.5:3306 25 (low) exposure database (mysql) +20; criticality high (10.0.0.5/32) +5\n"
+	if output.String() != expected {
+		t.Fatalf("output = %q", output.String())
+	}
+}
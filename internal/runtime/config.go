@@ -25,6 +25,14 @@ const (
 	ConfigLogMaxFiles       = "YSCAN_LOG_MAX_FILES"
 	ConfigNucleiBinary      = "YSCAN_NUCLEI_BINARY"
 	ConfigNucleiTemplates   = "YSCAN_NUCLEI_TEMPLATES"
+	ConfigNucleiTimeout     = "YSCAN_NUCLEI_TIMEOUT"
+	ConfigNucleiMaxMemoryMB = "YSCAN_NUCLEI_MAX_MEMORY_MB"
+	ConfigNucleiNice        = "YSCAN_NUCLEI_NICE"
+	ConfigNucleiIOClass     = "YSCAN_NUCLEI_IONICE"
+	ConfigNucleiCgroup      = "YSCAN_NUCLEI_CGROUP"
+	ConfigEvidenceCIDRs     = "YSCAN_EVIDENCE_CIDRS"
+	ConfigEvidenceRedact    = "YSCAN_EVIDENCE_REDACT"
+	ConfigEvidenceMaxBody   = "YSCAN_EVIDENCE_MAX_BODY_BYTES"
 )
 
 type Config struct {
@@ -36,6 +44,14 @@ type Config struct {
 	LogMaxFiles       int
 	NucleiBinary      string
 	NucleiTemplates   string
+	NucleiTimeout     time.Duration
+	NucleiMaxMemoryMB int
+	NucleiNice        int
+	NucleiIOClass     string
+	NucleiCgroup      string
+	EvidenceCIDRs     []string
+	EvidenceRedact    []string
+	EvidenceMaxBody   int
 }
 
 type ConfigOverrides map[string]string
@@ -50,6 +66,14 @@ func LoadConfig(paths HomePaths, overrides ConfigOverrides, lookupEnv func(strin
 		ConfigLogMaxFiles:       "3",
 		ConfigNucleiBinary:      "nuclei",
 		ConfigNucleiTemplates:   "",
+		ConfigNucleiTimeout:     "30m",
+		ConfigNucleiMaxMemoryMB: "2048",
+		ConfigNucleiNice:        "10",
+		ConfigNucleiIOClass:     "best-effort",
+		ConfigNucleiCgroup:      "none",
+		ConfigEvidenceCIDRs:     "",
+		ConfigEvidenceRedact:    "",
+		ConfigEvidenceMaxBody:   "2048",
 	}
 
 	fileValues, err := readEnvFile(paths.EnvFile)
@@ -213,6 +237,49 @@ func parseConfigValues(values map[string]string) (Config, error) {
 	if config.NucleiBinary == "" {
 		return Config{}, fmt.Errorf("%s cannot be empty", ConfigNucleiBinary)
 	}
+	config.NucleiTimeout, err = time.ParseDuration(values[ConfigNucleiTimeout])
+	if err != nil || config.NucleiTimeout < 0 || config.NucleiTimeout > 24*time.Hour {
+		return Config{}, fmt.Errorf("%s must be a duration between 0 (no limit) and 24h", ConfigNucleiTimeout)
+	}
+	config.NucleiMaxMemoryMB, err = parseBoundedInt(values[ConfigNucleiMaxMemoryMB], 0, 1<<20)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", ConfigNucleiMaxMemoryMB, err)
+	}
+	config.NucleiNice, err = parseBoundedInt(values[ConfigNucleiNice], 0, 19)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", ConfigNucleiNice, err)
+	}
+	config.NucleiIOClass = strings.ToLower(strings.TrimSpace(values[ConfigNucleiIOClass]))
+	switch config.NucleiIOClass {
+	case "none", "best-effort", "idle":
+	default:
+		return Config{}, fmt.Errorf("%s must be none, best-effort or idle", ConfigNucleiIOClass)
+	}
+	config.NucleiCgroup = strings.ToLower(strings.TrimSpace(values[ConfigNucleiCgroup]))
+	switch config.NucleiCgroup {
+	case "none", "delegate":
+	default:
+		return Config{}, fmt.Errorf("%s must be none or delegate", ConfigNucleiCgroup)
+	}
+	for _, value := range strings.Split(values[ConfigEvidenceCIDRs], ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(value); err != nil {
+			return Config{}, fmt.Errorf("%s contains invalid CIDR %q", ConfigEvidenceCIDRs, value)
+		}
+		config.EvidenceCIDRs = append(config.EvidenceCIDRs, value)
+	}
+	for _, value := range strings.Split(values[ConfigEvidenceRedact], ",") {
+		if value = strings.ToLower(strings.TrimSpace(value)); value != "" {
+			config.EvidenceRedact = append(config.EvidenceRedact, value)
+		}
+	}
+	config.EvidenceMaxBody, err = parseBoundedInt(values[ConfigEvidenceMaxBody], 64, 1<<20)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", ConfigEvidenceMaxBody, err)
+	}
 	return config, nil
 }
 
@@ -227,7 +294,9 @@ func parseBoundedInt(value string, minimum, maximum int) (int, error) {
 func knownConfigKey(key string) bool {
 	switch key {
 	case ConfigListenAddress, ConfigAllowCIDRs, ConfigMaxConcurrency, ConfigSQLiteBusyTimeout,
-		ConfigLogMaxBytes, ConfigLogMaxFiles, ConfigNucleiBinary, ConfigNucleiTemplates:
+		ConfigLogMaxBytes, ConfigLogMaxFiles, ConfigNucleiBinary, ConfigNucleiTemplates,
+		ConfigNucleiTimeout, ConfigNucleiMaxMemoryMB, ConfigNucleiNice, ConfigNucleiIOClass,
+		ConfigNucleiCgroup, ConfigEvidenceCIDRs, ConfigEvidenceRedact, ConfigEvidenceMaxBody:
 		return true
 	default:
 		return false
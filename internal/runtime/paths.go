@@ -8,7 +8,7 @@ import (
 )
 
 const (
-	defaultEnvFile = "# yscan configuration\nYSCAN_LISTEN_ADDR=127.0.0.1:8080\nYSCAN_MAX_CONCURRENCY=2\nYSCAN_SQLITE_BUSY_TIMEOUT=5s\nYSCAN_LOG_MAX_BYTES=10485760\nYSCAN_LOG_MAX_FILES=3\nYSCAN_NUCLEI_BINARY=nuclei\nYSCAN_NUCLEI_TEMPLATES=\nYSCAN_ALLOW_CIDRS=\n"
+	defaultEnvFile = "# yscan configuration\nYSCAN_LISTEN_ADDR=127.0.0.1:8080\nYSCAN_MAX_CONCURRENCY=2\nYSCAN_SQLITE_BUSY_TIMEOUT=5s\nYSCAN_LOG_MAX_BYTES=10485760\nYSCAN_LOG_MAX_FILES=3\nYSCAN_NUCLEI_BINARY=nuclei\nYSCAN_NUCLEI_TEMPLATES=\nYSCAN_NUCLEI_TIMEOUT=30m\nYSCAN_NUCLEI_MAX_MEMORY_MB=2048\nYSCAN_NUCLEI_NICE=10\nYSCAN_NUCLEI_IONICE=best-effort\nYSCAN_EVIDENCE_CIDRS=\nYSCAN_EVIDENCE_REDACT=\nYSCAN_EVIDENCE_MAX_BODY_BYTES=2048\nYSCAN_ALLOW_CIDRS=\n"
 )
 
 type DatabaseMode string
@@ -20,17 +20,23 @@ const (
 )
 
 type HomePaths struct {
-	Home           string
-	Executable     string
-	EnvFile        string
-	DataDir        string
-	Database       string
-	LegacyDatabase string
-	ReportsDir     string
-	LogsDir        string
-	RunLogsDir     string
-	RunDir         string
-	ServerState    string
+	Home            string
+	Executable      string
+	EnvFile         string
+	DataDir         string
+	Database        string
+	LegacyDatabase  string
+	ReportsDir      string
+	LogsDir         string
+	RunLogsDir      string
+	RunDir          string
+	ServerState     string
+	SecretsDir      string
+	SecretKey       string
+	SNMPCredentials string
+	// FingerprintRules holds user-authored YAML rules imported as the local
+	// fingerprint source.
+	FingerprintRules string
 }
 
 type DatabaseSelection struct {
@@ -60,17 +66,21 @@ func ResolveHome(executablePath, override string) (HomePaths, error) {
 	}
 
 	paths := HomePaths{
-		Home:           home,
-		Executable:     executablePath,
-		EnvFile:        filepath.Join(home, ".env"),
-		DataDir:        filepath.Join(home, "data"),
-		Database:       filepath.Join(home, "data", "asm.db"),
-		LegacyDatabase: filepath.Join(home, "asm.db"),
-		ReportsDir:     filepath.Join(home, "reports"),
-		LogsDir:        filepath.Join(home, "logs"),
-		RunLogsDir:     filepath.Join(home, "logs", "runs"),
-		RunDir:         filepath.Join(home, "run"),
-		ServerState:    filepath.Join(home, "run", "server.state"),
+		Home:             home,
+		Executable:       executablePath,
+		EnvFile:          filepath.Join(home, ".env"),
+		DataDir:          filepath.Join(home, "data"),
+		Database:         filepath.Join(home, "data", "asm.db"),
+		LegacyDatabase:   filepath.Join(home, "asm.db"),
+		ReportsDir:       filepath.Join(home, "reports"),
+		LogsDir:          filepath.Join(home, "logs"),
+		RunLogsDir:       filepath.Join(home, "logs", "runs"),
+		RunDir:           filepath.Join(home, "run"),
+		ServerState:      filepath.Join(home, "run", "server.state"),
+		SecretsDir:       filepath.Join(home, "secrets"),
+		SecretKey:        filepath.Join(home, "secrets", "secret.key"),
+		SNMPCredentials:  filepath.Join(home, "secrets", "snmp-credentials.enc"),
+		FingerprintRules: filepath.Join(home, "fingerprints", "local"),
 	}
 	return paths, nil
 }
@@ -286,3 +286,42 @@ func TestLoadConfigResolvesRelativeNucleiPathsFromHome(t *testing.T) {
 		t.Fatalf("PATH binary = %q", config.NucleiBinary)
 	}
 }
+
+func TestLoadConfigParsesNucleiExecutionLimits(t *testing.T) {
+	paths := HomePaths{EnvFile: filepath.Join(t.TempDir(), "missing.env")}
+	noEnvironment := func(string) (string, bool) { return "", false }
+	config, err := LoadConfig(paths, nil, noEnvironment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.NucleiTimeout != 30*time.Minute || config.NucleiMaxMemoryMB != 2048 || config.NucleiNice != 10 || config.NucleiIOClass != "best-effort" || config.NucleiCgroup != "none" {
+		t.Fatalf("default limits = %#v", config)
+	}
+	config, err = LoadConfig(paths, ConfigOverrides{ConfigNucleiTimeout: "0", ConfigNucleiMaxMemoryMB: "0", ConfigNucleiNice: "0", ConfigNucleiIOClass: "Idle", ConfigNucleiCgroup: "Delegate"}, noEnvironment)
+	if err != nil || config.NucleiTimeout != 0 || config.NucleiMaxMemoryMB != 0 || config.NucleiNice != 0 || config.NucleiIOClass != "idle" || config.NucleiCgroup != "delegate" {
+		t.Fatalf("disabled limits = %#v err=%v", config, err)
+	}
+	for key, value := range map[string]string{ConfigNucleiTimeout: "-1s", ConfigNucleiMaxMemoryMB: "lots", ConfigNucleiNice: "20", ConfigNucleiIOClass: "realtime", ConfigNucleiCgroup: "root"} {
+		if _, err := LoadConfig(paths, ConfigOverrides{key: value}, noEnvironment); err == nil || !strings.Contains(err.Error(), key) {
+			t.Fatalf("%s=%s error = %v", key, value, err)
+		}
+	}
+}
+
+func TestLoadConfigParsesEvidencePermissionAndRedaction(t *testing.T) {
+	paths := HomePaths{EnvFile: filepath.Join(t.TempDir(), "missing.env")}
+	noEnvironment := func(string) (string, bool) { return "", false }
+	config, err := LoadConfig(paths, nil, noEnvironment)
+	if err != nil || len(config.EvidenceCIDRs) != 0 || len(config.EvidenceRedact) != 0 || config.EvidenceMaxBody != 2048 {
+		t.Fatalf("default evidence config = %#v err=%v", config, err)
+	}
+	config, err = LoadConfig(paths, ConfigOverrides{ConfigEvidenceCIDRs: "10.0.5.0/24, 127.0.0.1/32", ConfigEvidenceRedact: "X-Tenant, ssn", ConfigEvidenceMaxBody: "512"}, noEnvironment)
+	if err != nil || strings.Join(config.EvidenceCIDRs, ",") != "10.0.5.0/24,127.0.0.1/32" || strings.Join(config.EvidenceRedact, ",") != "x-tenant,ssn" || config.EvidenceMaxBody != 512 {
+		t.Fatalf("evidence config = %#v err=%v", config, err)
+	}
+	for key, value := range map[string]string{ConfigEvidenceCIDRs: "10.0.5.0", ConfigEvidenceMaxBody: "1"} {
+		if _, err := LoadConfig(paths, ConfigOverrides{key: value}, noEnvironment); err == nil || !strings.Contains(err.Error(), key) {
+			t.Fatalf("%s=%s error = %v", key, value, err)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package runtime
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const secretKeySize = 32
+
+// ErrSecretKeyMissing is returned when sealed content exists but the home
+// key that protects it does not.
+var ErrSecretKeyMissing = errors.New("yscan secret key is missing")
+
+// SecretBox seals small secrets kept under the yscan home with the home's
+// AES-256-GCM key. The key never leaves the secrets directory, so copying a
+// sealed file without it discloses nothing.
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+// OpenSecretBox loads the home secret key, creating it on first use when
+// create is true.
+func OpenSecretBox(paths HomePaths, create bool) (*SecretBox, error) {
+	key, err := os.ReadFile(paths.SecretKey)
+	if errors.Is(err, os.ErrNotExist) && create {
+		key, err = createSecretKey(paths)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrSecretKeyMissing
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read yscan secret key: %w", err)
+	}
+	if len(key) != secretKeySize {
+		return nil, fmt.Errorf("invalid yscan secret key: %s", paths.SecretKey)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretBox{aead: aead}, nil
+}
+
+// Seal encrypts plaintext. The label binds the ciphertext to its purpose so
+// one sealed file cannot be substituted for another.
+func (box *SecretBox) Seal(plaintext []byte, label string) ([]byte, error) {
+	nonce := make([]byte, box.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("create secret nonce: %w", err)
+	}
+	return box.aead.Seal(nonce, nonce, plaintext, []byte(label)), nil
+}
+
+func (box *SecretBox) Open(sealed []byte, label string) ([]byte, error) {
+	if len(sealed) < box.aead.NonceSize() {
+		return nil, errors.New("sealed secret is truncated")
+	}
+	nonce, ciphertext := sealed[:box.aead.NonceSize()], sealed[box.aead.NonceSize():]
+	plaintext, err := box.aead.Open(nil, nonce, ciphertext, []byte(label))
+	if err != nil {
+		return nil, errors.New("sealed secret cannot be decrypted with this home's key")
+	}
+	return plaintext, nil
+}
+
+// WriteSecretFile atomically replaces a file under the secrets directory with
+// owner-only permissions.
+func WriteSecretFile(paths HomePaths, path string, content []byte) error {
+	if err := os.MkdirAll(paths.SecretsDir, 0700); err != nil {
+		return fmt.Errorf("create yscan secrets directory: %w", err)
+	}
+	temporary, err := os.CreateTemp(filepath.Dir(path), ".secret.tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temporary secret file: %w", err)
+	}
+	temporaryPath := temporary.Name()
+	defer os.Remove(temporaryPath)
+	if err := temporary.Chmod(0600); err != nil {
+		_ = temporary.Close()
+		return err
+	}
+	if _, err := temporary.Write(content); err != nil {
+		_ = temporary.Close()
+		return err
+	}
+	if err := temporary.Sync(); err != nil {
+		_ = temporary.Close()
+		return err
+	}
+	if err := temporary.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(temporaryPath, path); err != nil {
+		return fmt.Errorf("publish %s: %w", path, err)
+	}
+	return syncDirectory(filepath.Dir(path))
+}
+
+func createSecretKey(paths HomePaths) ([]byte, error) {
+	if err := os.MkdirAll(paths.SecretsDir, 0700); err != nil {
+		return nil, fmt.Errorf("create yscan secrets directory: %w", err)
+	}
+	key := make([]byte, secretKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("create yscan secret key: %w", err)
+	}
+	temporary, err := os.CreateTemp(paths.SecretsDir, ".secret.key.tmp-*")
+	if err != nil {
+		return nil, fmt.Errorf("create temporary secret key: %w", err)
+	}
+	temporaryPath := temporary.Name()
+	defer os.Remove(temporaryPath)
+	if err := temporary.Chmod(0600); err != nil {
+		_ = temporary.Close()
+		return nil, err
+	}
+	if _, err := temporary.Write(key); err != nil {
+		_ = temporary.Close()
+		return nil, err
+	}
+	if err := temporary.Sync(); err != nil {
+		_ = temporary.Close()
+		return nil, err
+	}
+	if err := temporary.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Link(temporaryPath, paths.SecretKey); errors.Is(err, os.ErrExist) {
+		// Another process published a key first; every caller must use it.
+		return os.ReadFile(paths.SecretKey)
+	} else if err != nil {
+		return nil, fmt.Errorf("publish yscan secret key: %w", err)
+	}
+	return key, syncDirectory(paths.SecretsDir)
+}
@@ -184,6 +184,14 @@ func ParseCreateCLIArgs(args []string, config CLIConfig) (model.ScanTask, error)
 			task.Config.VulnerabilityOn = true
 			continue
 		}
+		if flag == "--retain-raw-evidence" {
+			task.Config.RetainRawEvidence = true
+			continue
+		}
+		if flag == "--tls-fingerprint" {
+			task.Config.TLSFingerprint = true
+			continue
+		}
 		if index+1 >= len(args) {
 			return model.ScanTask{}, fmt.Errorf("%s requires a value", flag)
 		}
@@ -204,6 +212,10 @@ func ParseCreateCLIArgs(args []string, config CLIConfig) (model.ScanTask, error)
 			task.Config.PortSpec = value
 		case "--template-version":
 			task.Config.TemplateVersion = value
+		case "--snmp-credential":
+			task.Config.SNMPCredential = value
+		case "--validator":
+			task.Config.Validator = value
 		default:
 			return model.ScanTask{}, fmt.Errorf("unsupported flag: %s", flag)
 		}
@@ -275,7 +287,11 @@ func writeTask(output io.Writer, db *sql.DB, taskID int64) error {
 	if portSpec == "" {
 		portSpec = "default"
 	}
-	_, err = fmt.Fprintf(output, "  Config   : ports=%s vulnerability=%t templates=%s\n", portSpec, task.Config.VulnerabilityOn, task.Config.NucleiTemplates)
+	validator := task.Config.Validator
+	if validator == "" {
+		validator = model.ValidatorNuclei
+	}
+	_, err = fmt.Fprintf(output, "  Config   : ports=%s vulnerability=%t validator=%s templates=%s\n", portSpec, task.Config.VulnerabilityOn, validator, task.Config.NucleiTemplates)
 	return err
 }
 
@@ -296,8 +312,8 @@ func writeTaskRuns(output io.Writer, db *sql.DB, taskID int64) error {
 }
 
 func writeUsage(output io.Writer) {
-	fmt.Fprintln(output, "usage: yscan schedule create --target <internal-ip-or-cidr> --scan-type ip|subnet --mode once|scheduled [--cron '0 2 * * *' --timezone Asia/Shanghai] [--vuln]")
-	fmt.Fprintln(output, "       yscan schedule update <scan_task_id> --target <internal-ip-or-cidr> --scan-type ip|subnet --mode once|scheduled [--cron '0 2 * * *' --timezone Asia/Shanghai] [--vuln]")
+	fmt.Fprintln(output, "usage: yscan schedule create --target <internal-ip-or-cidr> --scan-type ip|subnet --mode once|scheduled [--cron '0 2 * * *' --timezone Asia/Shanghai] [--vuln] [--validator nuclei|native] [--snmp-credential <name>] [--retain-raw-evidence] [--tls-fingerprint]")
+	fmt.Fprintln(output, "       yscan schedule update <scan_task_id> --target <internal-ip-or-cidr> --scan-type ip|subnet --mode once|scheduled [--cron '0 2 * * *' --timezone Asia/Shanghai] [--vuln] [--validator nuclei|native] [--snmp-credential <name>] [--retain-raw-evidence] [--tls-fingerprint]")
 	fmt.Fprintln(output, "       yscan schedule list|show|runs|run|pause|resume|archive <scan_task_id>")
 	fmt.Fprintln(output, "       yscan schedule run-show|cancel|changes|findings|report <scan_task_id> <run_id>")
 	fmt.Fprintln(output, "       yscan schedule asset <internal_ip>")
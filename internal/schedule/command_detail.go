@@ -80,10 +80,21 @@ func runChangesCommand(output io.Writer, db *sql.DB, args []string) error {
 }
 
 func runFindingsCommand(output io.Writer, db *sql.DB, args []string) error {
-	taskID, runID, err := parseTaskRunIDs(args, "usage: yscan schedule findings <scan_task_id> <run_id>")
+	const usage = "usage: yscan schedule findings <scan_task_id> <run_id> [--state <state>]"
+	taskID, runID, err := parseTaskRunIDs(args, usage)
 	if err != nil {
 		return writeCommandError(output, err)
 	}
+	state := ""
+	switch {
+	case len(args) == 5 && args[3] == "--state":
+		state = strings.ToLower(strings.TrimSpace(args[4]))
+		if !model.ValidFindingState(state) {
+			return writeCommandError(output, fmt.Errorf("--state must be one of %s", strings.Join(model.FindingStates, ", ")))
+		}
+	case len(args) != 3:
+		return writeCommandError(output, errors.New(usage))
+	}
 	if _, err := ownedRun(db, taskID, runID); err != nil {
 		return err
 	}
@@ -91,11 +102,17 @@ func runFindingsCommand(output io.Writer, db *sql.DB, args []string) error {
 	if err != nil {
 		return err
 	}
+	findings := make([]model.ScanTaskRunVulnerability, 0, len(snapshot.Vulnerabilities))
+	for _, finding := range snapshot.Vulnerabilities {
+		if state == "" || finding.State == state {
+			findings = append(findings, finding)
+		}
+	}
 	return writeJSONValue(output, struct {
 		Validation model.ScanTaskRunValidation           `json:"validation"`
 		Endpoints  []model.ScanTaskRunEndpointValidation `json:"endpoints"`
 		Findings   []model.ScanTaskRunVulnerability      `json:"findings"`
-	}{snapshot.Validation, snapshot.EndpointValidations, snapshot.Vulnerabilities})
+	}{snapshot.Validation, snapshot.EndpointValidations, findings})
 }
 
 func runReportCommand(output io.Writer, db *sql.DB, args []string) error {
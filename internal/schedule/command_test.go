@@ -20,7 +20,9 @@ func TestParseCreateCLIArgs(t *testing.T) {
 		"--cron", "0 2 * * *",
 		"--timezone", "Asia/Shanghai",
 		"--vuln",
+		"--validator", "native",
 		"--port-spec", "80,443",
+		"--tls-fingerprint",
 	}, CLIConfig{NucleiTemplates: "/templates", DNSResolveMode: "internal", DNSDenyCIDRs: []string{"10.0.0.0/8"}})
 	if err != nil {
 		t.Fatalf("parse create args: %v", err)
@@ -28,7 +30,7 @@ func TestParseCreateCLIArgs(t *testing.T) {
 	if task.Target != "192.168.10.0/24" || task.ScanType != model.ScanTypeSubnet || task.Mode != model.ScanTaskModeScheduled || task.Cron != "0 2 * * *" || task.Timezone != "Asia/Shanghai" {
 		t.Fatalf("parsed task = %#v", task)
 	}
-	if !task.Config.VulnerabilityOn || task.Config.NucleiTemplates != "/templates" || task.Config.PortSpec != "80,443" || task.Config.DNSResolveMode != "internal" {
+	if !task.Config.VulnerabilityOn || task.Config.NucleiTemplates != "/templates" || task.Config.PortSpec != "80,443" || task.Config.DNSResolveMode != "internal" || task.Config.Validator != model.ValidatorNative || !task.Config.TLSFingerprint || task.Config.RetainRawEvidence {
 		t.Fatalf("parsed config = %#v", task.Config)
 	}
 }
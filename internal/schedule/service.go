@@ -11,6 +11,7 @@ import (
 
 	"golandproject/yscan/internal/model"
 	"golandproject/yscan/internal/scan"
+	"golandproject/yscan/internal/snmp"
 	"golandproject/yscan/internal/storage"
 )
 
@@ -58,6 +59,12 @@ func (service *TaskService) Create(ctx context.Context, task model.ScanTask) (mo
 	if len(ports) > 0 {
 		task.Config.PortSpec = scan.FormatPortSpec(ports)
 	}
+	if err := validateSNMPCredential(&task.Config); err != nil {
+		return model.ScanTask{}, nil, err
+	}
+	if err := normalizeValidator(&task.Config); err != nil {
+		return model.ScanTask{}, nil, err
+	}
 	if task.Mode == model.ScanTaskModeScheduled {
 		if _, err := ParseCron(task.Cron, task.Timezone); err != nil {
 			return model.ScanTask{}, nil, err
@@ -120,6 +127,12 @@ func (service *TaskService) Update(ctx context.Context, task model.ScanTask) (mo
 	if len(ports) > 0 {
 		task.Config.PortSpec = scan.FormatPortSpec(ports)
 	}
+	if err := validateSNMPCredential(&task.Config); err != nil {
+		return model.ScanTask{}, err
+	}
+	if err := normalizeValidator(&task.Config); err != nil {
+		return model.ScanTask{}, err
+	}
 	if task.Mode == model.ScanTaskModeScheduled {
 		if _, err := ParseCron(task.Cron, task.Timezone); err != nil {
 			return model.ScanTask{}, err
@@ -134,6 +147,33 @@ func (service *TaskService) applyConfigDefaults(config *model.ScanTaskConfig) {
 	}
 }
 
+// validateSNMPCredential admits only credential names present in the home
+// credential store, so a typo fails at creation instead of at every run.
+func validateSNMPCredential(config *model.ScanTaskConfig) error {
+	config.SNMPCredential = strings.TrimSpace(config.SNMPCredential)
+	if config.SNMPCredential == "" {
+		return nil
+	}
+	if _, err := snmp.LookupCredential(config.SNMPCredential); err != nil {
+		return fmt.Errorf("invalid snmp_credential: %w", err)
+	}
+	return nil
+}
+
+// normalizeValidator admits the known validation engines. Nuclei is stored as
+// the empty default so existing configuration hashes do not change.
+func normalizeValidator(config *model.ScanTaskConfig) error {
+	switch strings.ToLower(strings.TrimSpace(config.Validator)) {
+	case "", model.ValidatorNuclei:
+		config.Validator = ""
+	case model.ValidatorNative:
+		config.Validator = model.ValidatorNative
+	default:
+		return fmt.Errorf("invalid validator %q: use %s or %s", config.Validator, model.ValidatorNuclei, model.ValidatorNative)
+	}
+	return nil
+}
+
 // RunNow materializes an explicitly requested occurrence. The boolean tells
 // the caller whether it should launch the returned queued run in this process.
 func (service *TaskService) RunNow(ctx context.Context, taskID int64) (model.ScanTaskRun, bool, error) {
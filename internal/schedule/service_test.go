@@ -203,6 +203,22 @@ func TestTaskServiceRejectsInvalidPortSpecOnCreateAndUpdate(t *testing.T) {
 	}
 }
 
+func TestTaskServiceNormalizesValidatorOnCreateAndUpdate(t *testing.T) {
+	db := openRunnerTestDB(t)
+	service := NewTaskService(db, ClockFunc(func() time.Time { return time.Date(2026, 7, 24, 2, 0, 0, 0, time.UTC) }))
+	if _, _, err := service.Create(context.Background(), model.ScanTask{Target: "192.168.30.10", ScanType: model.ScanTypeIP, Mode: model.ScanTaskModeOnce, Config: model.ScanTaskConfig{Validator: "zap"}}); err == nil {
+		t.Fatal("unknown validator was accepted")
+	}
+	task, _, err := service.Create(context.Background(), model.ScanTask{Target: "192.168.30.0/24", ScanType: model.ScanTypeSubnet, Mode: model.ScanTaskModeScheduled, Cron: "0 2 * * *", Timezone: "UTC", Config: model.ScanTaskConfig{Validator: " Nuclei "}})
+	if err != nil || task.Config.Validator != "" {
+		t.Fatalf("nuclei validator must be stored as the default: task=%#v err=%v", task, err)
+	}
+	task.Config.Validator = model.ValidatorNative
+	if task, err = service.Update(context.Background(), task); err != nil || task.Config.Validator != model.ValidatorNative {
+		t.Fatalf("updated task=%#v err=%v", task, err)
+	}
+}
+
 func TestTaskServiceKeepsFullPortRangeCompactInTaskAndRun(t *testing.T) {
 	db := openRunnerTestDB(t)
 	service := NewTaskService(db, ClockFunc(func() time.Time { return time.Date(2026, 7, 24, 2, 0, 0, 0, time.UTC) }))
@@ -0,0 +1,287 @@
+package snmp
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	tagInteger        byte = 0x02
+	tagOctetString    byte = 0x04
+	tagNull           byte = 0x05
+	tagOID            byte = 0x06
+	tagSequence       byte = 0x30
+	tagIPAddress      byte = 0x40
+	tagCounter32      byte = 0x41
+	tagGauge32        byte = 0x42
+	tagTimeTicks      byte = 0x43
+	tagCounter64      byte = 0x46
+	tagNoSuchObject   byte = 0x80
+	tagNoSuchInstance byte = 0x81
+	tagEndOfMibView   byte = 0x82
+
+	pduGetRequest     byte = 0xa0
+	pduGetNextRequest byte = 0xa1
+	pduResponse       byte = 0xa2
+	pduReport         byte = 0xa8
+)
+
+var errMalformed = errors.New("malformed SNMP message")
+
+// Variable is one variable binding. Value holds the undecoded BER content so
+// callers choose the interpretation the MIB object calls for.
+type Variable struct {
+	OID   string
+	Type  byte
+	Value []byte
+}
+
+// Exception reports noSuchObject, noSuchInstance and endOfMibView bindings.
+func (variable Variable) Exception() bool {
+	return variable.Type == tagNoSuchObject || variable.Type == tagNoSuchInstance || variable.Type == tagEndOfMibView
+}
+
+// String renders OCTET STRING and OBJECT IDENTIFIER values. Binary octet
+// strings are rendered as colon-separated hex, the way MAC addresses are
+// conventionally displayed.
+func (variable Variable) String() string {
+	switch variable.Type {
+	case tagOctetString:
+		if utf8.Valid(variable.Value) && printable(variable.Value) {
+			return strings.TrimSpace(string(variable.Value))
+		}
+		return hexString(variable.Value)
+	case tagOID:
+		oid, err := decodeOID(variable.Value)
+		if err != nil {
+			return ""
+		}
+		return oid
+	case tagIPAddress:
+		if len(variable.Value) == 4 {
+			return fmt.Sprintf("%d.%d.%d.%d", variable.Value[0], variable.Value[1], variable.Value[2], variable.Value[3])
+		}
+	}
+	return ""
+}
+
+// Int decodes INTEGER and the unsigned application integer types.
+func (variable Variable) Int() int64 {
+	switch variable.Type {
+	case tagInteger:
+		value, _ := decodeInteger(variable.Value)
+		return value
+	case tagCounter32, tagGauge32, tagTimeTicks, tagCounter64:
+		var value uint64
+		for _, octet := range variable.Value {
+			value = value<<8 | uint64(octet)
+		}
+		return int64(value)
+	}
+	return 0
+}
+
+func printable(value []byte) bool {
+	for _, character := range string(value) {
+		if character < 0x20 && character != '\t' && character != '\r' && character != '\n' {
+			return false
+		}
+	}
+	return true
+}
+
+func hexString(value []byte) string {
+	parts := make([]string, len(value))
+	for index, octet := range value {
+		parts[index] = fmt.Sprintf("%02x", octet)
+	}
+	return strings.Join(parts, ":")
+}
+
+func appendTLV(out []byte, tag byte, content []byte) []byte {
+	out = append(out, tag)
+	out = appendLength(out, len(content))
+	return append(out, content...)
+}
+
+func appendLength(out []byte, length int) []byte {
+	if length < 0x80 {
+		return append(out, byte(length))
+	}
+	encoded := make([]byte, 0, 4)
+	for value := length; value > 0; value >>= 8 {
+		encoded = append([]byte{byte(value)}, encoded...)
+	}
+	out = append(out, 0x80|byte(len(encoded)))
+	return append(out, encoded...)
+}
+
+func appendInteger(out []byte, value int64) []byte {
+	content := make([]byte, 0, 8)
+	for {
+		content = append([]byte{byte(value)}, content...)
+		if (value >= -0x80 && value < 0x80) || len(content) == 8 {
+			break
+		}
+		value >>= 8
+	}
+	return appendTLV(out, tagInteger, content)
+}
+
+func appendOctetString(out []byte, value []byte) []byte {
+	return appendTLV(out, tagOctetString, value)
+}
+
+func encodeOID(oid string) ([]byte, error) {
+	fields := strings.Split(strings.TrimPrefix(strings.TrimSpace(oid), "."), ".")
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("invalid OID: %s", oid)
+	}
+	arcs := make([]uint64, len(fields))
+	for index, field := range fields {
+		arc, err := strconv.ParseUint(field, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OID: %s", oid)
+		}
+		arcs[index] = arc
+	}
+	if arcs[0] > 2 || (arcs[0] < 2 && arcs[1] >= 40) {
+		return nil, fmt.Errorf("invalid OID: %s", oid)
+	}
+	content := appendBase128(nil, arcs[0]*40+arcs[1])
+	for _, arc := range arcs[2:] {
+		content = appendBase128(content, arc)
+	}
+	return content, nil
+}
+
+func appendBase128(out []byte, value uint64) []byte {
+	encoded := []byte{byte(value & 0x7f)}
+	for value >>= 7; value > 0; value >>= 7 {
+		encoded = append([]byte{byte(value&0x7f) | 0x80}, encoded...)
+	}
+	return append(out, encoded...)
+}
+
+func decodeOID(content []byte) (string, error) {
+	if len(content) == 0 {
+		return "", errMalformed
+	}
+	arcs := make([]string, 0, len(content)+1)
+	var value uint64
+	for index, octet := range content {
+		if value > 1<<56 {
+			return "", errMalformed
+		}
+		value = value<<7 | uint64(octet&0x7f)
+		if octet&0x80 != 0 {
+			if index == len(content)-1 {
+				return "", errMalformed
+			}
+			continue
+		}
+		if len(arcs) == 0 {
+			first := value / 40
+			if first > 2 {
+				first = 2
+			}
+			arcs = append(arcs, strconv.FormatUint(first, 10), strconv.FormatUint(value-first*40, 10))
+		} else {
+			arcs = append(arcs, strconv.FormatUint(value, 10))
+		}
+		value = 0
+	}
+	return strings.Join(arcs, "."), nil
+}
+
+func decodeInteger(content []byte) (int64, error) {
+	if len(content) == 0 || len(content) > 8 {
+		return 0, errMalformed
+	}
+	value := int64(int8(content[0]))
+	for _, octet := range content[1:] {
+		value = value<<8 | int64(octet)
+	}
+	return value, nil
+}
+
+// compareOIDs orders OIDs arc by arc, the order GETNEXT walks a MIB.
+func compareOIDs(left, right string) int {
+	leftArcs, rightArcs := strings.Split(left, "."), strings.Split(right, ".")
+	for index := 0; index < len(leftArcs) && index < len(rightArcs); index++ {
+		leftArc, _ := strconv.ParseUint(leftArcs[index], 10, 64)
+		rightArc, _ := strconv.ParseUint(rightArcs[index], 10, 64)
+		if leftArc != rightArc {
+			if leftArc < rightArc {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(leftArcs) - len(rightArcs)
+}
+
+// element is one decoded TLV. Offsets are absolute positions in the buffer
+// being decoded, which USM needs to authenticate the message in place.
+type element struct {
+	tag     byte
+	content []byte
+	at      int
+	start   int
+	end     int
+}
+
+func readElement(data []byte, offset int) (element, error) {
+	if offset+2 > len(data) {
+		return element{}, errMalformed
+	}
+	tag, length, position := data[offset], int(data[offset+1]), offset+2
+	if length&0x80 != 0 {
+		octets := length & 0x7f
+		if octets == 0 || octets > 4 || position+octets > len(data) {
+			return element{}, errMalformed
+		}
+		length = 0
+		for _, octet := range data[position : position+octets] {
+			length = length<<8 | int(octet)
+		}
+		position += octets
+	}
+	if length < 0 || position+length > len(data) {
+		return element{}, errMalformed
+	}
+	return element{tag: tag, content: data[position : position+length], at: offset, start: position, end: position + length}, nil
+}
+
+// children decodes the TLVs nested in a constructed element.
+func children(data []byte, parent element) ([]element, error) {
+	nested := make([]element, 0, 6)
+	for offset := parent.start; offset < parent.end; {
+		child, err := readElement(data[:parent.end], offset)
+		if err != nil {
+			return nil, err
+		}
+		nested = append(nested, child)
+		offset = child.end
+	}
+	return nested, nil
+}
+
+func expectChildren(data []byte, parent element, tags ...byte) ([]element, error) {
+	nested, err := children(data, parent)
+	if err != nil {
+		return nil, err
+	}
+	if len(nested) < len(tags) {
+		return nil, errMalformed
+	}
+	for index, tag := range tags {
+		if nested[index].tag != tag {
+			return nil, errMalformed
+		}
+	}
+	return nested, nil
+}
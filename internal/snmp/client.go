@@ -0,0 +1,328 @@
+package snmp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+)
+
+const (
+	requestTimeout = 2 * time.Second
+	requestRetries = 1
+)
+
+// Report OIDs of the USM statistics an agent returns instead of a response.
+const (
+	oidUnsupportedSecLevels = "1.3.6.1.6.3.15.1.1.1.0"
+	oidNotInTimeWindows     = "1.3.6.1.6.3.15.1.1.2.0"
+	oidUnknownUserNames     = "1.3.6.1.6.3.15.1.1.3.0"
+	oidUnknownEngineIDs     = "1.3.6.1.6.3.15.1.1.4.0"
+	oidWrongDigests         = "1.3.6.1.6.3.15.1.1.5.0"
+	oidDecryptionErrors     = "1.3.6.1.6.3.15.1.1.6.0"
+)
+
+var reportErrors = map[string]string{
+	oidUnsupportedSecLevels: "unsupported security level",
+	oidUnknownUserNames:     "unknown user name",
+	oidUnknownEngineIDs:     "unknown engine ID",
+	oidWrongDigests:         "wrong digest",
+	oidDecryptionErrors:     "decryption error",
+}
+
+// ErrNoResponse is returned when the agent stays silent, which is what a
+// host without SNMP or with a different community looks like.
+var ErrNoResponse = errors.New("no SNMP response")
+
+// Client issues read-only requests to one agent. It never sends SET.
+type Client struct {
+	conn       net.Conn
+	credential Credential
+	nextID     int32
+	engine     *engineState
+}
+
+// engineState is the authoritative engine learned through RFC 3414
+// discovery, with the credential keys localized to it.
+type engineState struct {
+	id           []byte
+	boots        int32
+	time         int32
+	discoveredAt time.Time
+	authKey      []byte
+	privKey      []byte
+	salt         uint64
+}
+
+// Dial opens a UDP association with the agent at address (host:port).
+func Dial(ctx context.Context, address string, credential Credential) (*Client, error) {
+	if err := credential.Validate(); err != nil {
+		return nil, err
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", address)
+	if err != nil {
+		return nil, err
+	}
+	var seed [4]byte
+	_, _ = rand.Read(seed[:])
+	return &Client{conn: conn, credential: credential, nextID: int32(binary.BigEndian.Uint32(seed[:]) & 0x3fffffff)}, nil
+}
+
+func (client *Client) Close() error {
+	return client.conn.Close()
+}
+
+// Get returns one binding per OID; missing objects come back as exceptions.
+func (client *Client) Get(ctx context.Context, oids ...string) ([]Variable, error) {
+	return client.request(ctx, pduGetRequest, oids)
+}
+
+// GetNext returns the lexicographic successor of every OID.
+func (client *Client) GetNext(ctx context.Context, oids ...string) ([]Variable, error) {
+	return client.request(ctx, pduGetNextRequest, oids)
+}
+
+func (client *Client) request(ctx context.Context, pduType byte, oids []string) ([]Variable, error) {
+	request := pdu{Type: pduType}
+	for _, oid := range oids {
+		request.Variables = append(request.Variables, Variable{OID: oid})
+	}
+	var response pdu
+	var err error
+	if client.credential.Version == VersionV3 {
+		response, err = client.exchangeV3(ctx, request)
+	} else {
+		response, err = client.exchangeV2c(ctx, request)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if response.ErrorStatus != 0 {
+		return nil, fmt.Errorf("SNMP error status %d at index %d", response.ErrorStatus, response.ErrorIndex)
+	}
+	if len(response.Variables) != len(oids) {
+		return nil, errMalformed
+	}
+	return response.Variables, nil
+}
+
+func (client *Client) exchangeV2c(ctx context.Context, request pdu) (pdu, error) {
+	request.RequestID = client.requestID()
+	encoded, err := encodeCommunityMessage(client.credential.Community, request)
+	if err != nil {
+		return pdu{}, err
+	}
+	var response pdu
+	err = client.roundTrip(ctx, encoded, func(data []byte) bool {
+		decoded, err := decodeMessage(data)
+		if err != nil || decoded.Version != versionV2c || decoded.Community != client.credential.Community ||
+			decoded.PDU.RequestID != request.RequestID || decoded.PDU.Type != pduResponse {
+			return false
+		}
+		response = decoded.PDU
+		return true
+	})
+	return response, err
+}
+
+func (client *Client) exchangeV3(ctx context.Context, request pdu) (pdu, error) {
+	if client.engine == nil {
+		if err := client.discover(ctx); err != nil {
+			return pdu{}, err
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		response, report, err := client.sendV3(ctx, request)
+		if err != nil {
+			return pdu{}, err
+		}
+		if report == "" {
+			return response, nil
+		}
+		if report == oidNotInTimeWindows && attempt == 0 {
+			// The report carried the engine's current clock; retry once.
+			continue
+		}
+		if reason, found := reportErrors[report]; found {
+			return pdu{}, fmt.Errorf("SNMPv3 request rejected: %s", reason)
+		}
+		return pdu{}, fmt.Errorf("SNMPv3 request rejected by report %s", report)
+	}
+}
+
+// discover learns the authoritative engine ID, boots and time with an empty
+// unauthenticated request, as RFC 3414 section 4 describes.
+func (client *Client) discover(ctx context.Context) error {
+	msgID := client.requestID()
+	scoped, err := encodeScopedPDU(nil, pdu{Type: pduGetRequest, RequestID: client.requestID()})
+	if err != nil {
+		return err
+	}
+	encoded, _ := encodeV3Message(msgID, flagReportable, usmParameters{}, scoped)
+	var discovered usmParameters
+	err = client.roundTrip(ctx, encoded, func(data []byte) bool {
+		decoded, err := decodeMessage(data)
+		if err != nil || decoded.Version != versionV3 || decoded.MsgID != msgID || len(decoded.Security.EngineID) == 0 {
+			return false
+		}
+		discovered = decoded.Security
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	engine := &engineState{
+		id:           append([]byte(nil), discovered.EngineID...),
+		boots:        discovered.Boots,
+		time:         discovered.Time,
+		discoveredAt: time.Now(),
+	}
+	var salt [8]byte
+	_, _ = rand.Read(salt[:])
+	engine.salt = binary.BigEndian.Uint64(salt[:])
+	if protocol, found := authProtocols[client.credential.AuthProtocol]; found {
+		engine.authKey = localizedKey(protocol, client.credential.AuthPassword, engine.id)
+		if client.credential.PrivProtocol != "" {
+			engine.privKey = localizedKey(protocol, client.credential.PrivPassword, engine.id)
+		}
+	}
+	client.engine = engine
+	return nil
+}
+
+// sendV3 performs one authenticated exchange. A non-empty report OID means
+// the agent answered with a USM report instead of a response.
+func (client *Client) sendV3(ctx context.Context, request pdu) (pdu, string, error) {
+	engine, credential := client.engine, client.credential
+	request.RequestID = client.requestID()
+	msgID := client.requestID()
+	engineTime := engine.time + int32(time.Since(engine.discoveredAt)/time.Second)
+	scoped, err := encodeScopedPDU(engine.id, request)
+	if err != nil {
+		return pdu{}, "", err
+	}
+	flags := flagReportable
+	security := usmParameters{EngineID: engine.id, Boots: engine.boots, Time: engineTime, UserName: credential.UserName}
+	protocol, authenticated := authProtocols[credential.AuthProtocol]
+	if authenticated {
+		flags |= flagAuth
+		security.AuthParams = make([]byte, protocol.length)
+	}
+	msgData := scoped
+	if credential.PrivProtocol != "" {
+		flags |= flagPriv
+		engine.salt++
+		ciphertext, parameters, err := encryptScopedPDU(credential.PrivProtocol, engine.privKey, engine.boots, engineTime, engine.salt, scoped)
+		if err != nil {
+			return pdu{}, "", err
+		}
+		security.PrivParams = parameters
+		msgData = appendOctetString(nil, ciphertext)
+	}
+	encoded, authOffset := encodeV3Message(msgID, flags, security, msgData)
+	if authenticated {
+		protocol.signMessage(engine.authKey, encoded, authOffset)
+	}
+
+	var response pdu
+	var report string
+	err = client.roundTrip(ctx, encoded, func(data []byte) bool {
+		decoded, err := decodeMessage(data)
+		if err != nil || decoded.Version != versionV3 || decoded.MsgID != msgID {
+			return false
+		}
+		if decoded.Flags&flagAuth != 0 {
+			if !authenticated || !protocol.verifyMessage(engine.authKey, data, decoded.authOffset) {
+				return false
+			}
+		} else if decoded.Encrypted != nil {
+			return false
+		}
+		scopedData := decoded.scoped
+		if decoded.Encrypted != nil {
+			if credential.PrivProtocol == "" {
+				return false
+			}
+			scopedData, err = decryptScopedPDU(credential.PrivProtocol, engine.privKey, decoded.Security.Boots, decoded.Security.Time, decoded.Security.PrivParams, decoded.Encrypted)
+			if err != nil {
+				return false
+			}
+		}
+		decodedPDU, err := decodeScopedPDU(scopedData)
+		if err != nil {
+			return false
+		}
+		if decodedPDU.Type == pduReport {
+			// Reports about timeliness are authenticated, so the clock they
+			// carry can be trusted; other reports may arrive unauthenticated.
+			if len(decodedPDU.Variables) == 0 {
+				return false
+			}
+			report = decodedPDU.Variables[0].OID
+			if report == oidNotInTimeWindows && decoded.Flags&flagAuth != 0 {
+				engine.boots, engine.time, engine.discoveredAt = decoded.Security.Boots, decoded.Security.Time, time.Now()
+			}
+			return true
+		}
+		if decoded.Flags&flagAuth == 0 && authenticated {
+			return false
+		}
+		if decodedPDU.Type != pduResponse || decodedPDU.RequestID != request.RequestID {
+			return false
+		}
+		response = decodedPDU
+		return true
+	})
+	return response, report, err
+}
+
+// roundTrip sends request and reads datagrams until accept takes one,
+// retransmitting once after a silent interval.
+func (client *Client) roundTrip(ctx context.Context, request []byte, accept func([]byte) bool) error {
+	buffer := make([]byte, maxMessageSize)
+	for attempt := 0; attempt <= requestRetries; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		deadline := time.Now().Add(requestTimeout)
+		if contextDeadline, ok := ctx.Deadline(); ok && contextDeadline.Before(deadline) {
+			deadline = contextDeadline
+		}
+		if err := client.conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+		if _, err := client.conn.Write(request); err != nil {
+			return err
+		}
+		for {
+			count, err := client.conn.Read(buffer)
+			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					break
+				}
+				if errors.Is(err, syscall.ECONNREFUSED) {
+					return ErrNoResponse
+				}
+				return err
+			}
+			if accept(append([]byte(nil), buffer[:count]...)) {
+				return nil
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return ErrNoResponse
+}
+
+func (client *Client) requestID() int32 {
+	client.nextID = (client.nextID + 1) & 0x7fffffff
+	return client.nextID
+}
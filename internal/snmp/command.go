@@ -0,0 +1,125 @@
+package snmp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Environment variables that carry secrets into "credential add", so they
+// never appear in the process list or shell history.
+const (
+	EnvCommunity    = "YSCAN_SNMP_COMMUNITY"
+	EnvAuthPassword = "YSCAN_SNMP_AUTH_PASSWORD"
+	EnvPrivPassword = "YSCAN_SNMP_PRIV_PASSWORD"
+)
+
+// RunCLI manages the credential store for "yscan snmp".
+func RunCLI(store *CredentialStore, args []string, lookupEnv func(string) (string, bool), output io.Writer) error {
+	if store == nil || lookupEnv == nil || output == nil {
+		return errors.New("SNMP CLI store, environment and output are required")
+	}
+	if len(args) < 2 || strings.ToLower(strings.TrimSpace(args[0])) != "credential" {
+		writeUsage(output)
+		return nil
+	}
+	switch strings.ToLower(strings.TrimSpace(args[1])) {
+	case "list":
+		return writeCredentials(output, store)
+	case "add":
+		credential, err := parseCredentialArgs(args[2:], lookupEnv)
+		if err != nil {
+			return err
+		}
+		if err := store.Put(credential); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(output, "SNMP credential %s saved (%s, %s)\n", credential.Name, credential.Version, credential.SecurityLevel())
+		return err
+	case "remove":
+		if len(args) != 3 {
+			return errors.New("usage: yscan snmp credential remove <name>")
+		}
+		name := strings.TrimSpace(args[2])
+		if err := store.Remove(name); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(output, "SNMP credential %s removed\n", name)
+		return err
+	default:
+		writeUsage(output)
+		return fmt.Errorf("unsupported snmp credential command: %s", args[1])
+	}
+}
+
+func parseCredentialArgs(args []string, lookupEnv func(string) (string, bool)) (Credential, error) {
+	credential := Credential{}
+	for index := 0; index < len(args); index++ {
+		flag := strings.TrimSpace(args[index])
+		if !strings.HasPrefix(flag, "--") {
+			if credential.Name != "" {
+				return Credential{}, fmt.Errorf("unexpected argument: %s", flag)
+			}
+			credential.Name = flag
+			continue
+		}
+		if index+1 >= len(args) {
+			return Credential{}, fmt.Errorf("%s requires a value", flag)
+		}
+		value := strings.TrimSpace(args[index+1])
+		index++
+		switch flag {
+		case "--version":
+			credential.Version = strings.ToLower(value)
+		case "--user":
+			credential.UserName = value
+		case "--auth":
+			credential.AuthProtocol = strings.ToLower(value)
+		case "--priv":
+			credential.PrivProtocol = strings.ToLower(value)
+		default:
+			return Credential{}, fmt.Errorf("unsupported flag: %s", flag)
+		}
+	}
+	if credential.Name == "" || credential.Version == "" {
+		return Credential{}, errors.New("usage: yscan snmp credential add <name> --version v2c|v3 [--user <name> --auth md5|sha|sha256|sha512 --priv aes|des]")
+	}
+	if credential.Version == VersionV2c {
+		credential.Community, _ = lookupEnv(EnvCommunity)
+	}
+	if credential.AuthProtocol != "" {
+		credential.AuthPassword, _ = lookupEnv(EnvAuthPassword)
+	}
+	if credential.PrivProtocol != "" {
+		credential.PrivPassword, _ = lookupEnv(EnvPrivPassword)
+	}
+	return credential, nil
+}
+
+func writeCredentials(output io.Writer, store *CredentialStore) error {
+	summaries, err := store.List()
+	if err != nil {
+		return err
+	}
+	if len(summaries) == 0 {
+		_, err := fmt.Fprintln(output, "No SNMP credentials stored.")
+		return err
+	}
+	if _, err := fmt.Fprintln(output, "NAME                 VERSION  SECURITY      USER"); err != nil {
+		return err
+	}
+	for _, summary := range summaries {
+		if _, err := fmt.Fprintf(output, "%-20s %-8s %-13s %s\n", summary.Name, summary.Version, summary.SecurityLevel, summary.UserName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeUsage(output io.Writer) {
+	fmt.Fprintln(output, "usage: yscan snmp credential list")
+	fmt.Fprintln(output, "       yscan snmp credential add <name> --version v2c|v3 [--user <name> --auth md5|sha|sha256|sha512 --priv aes|des]")
+	fmt.Fprintln(output, "       yscan snmp credential remove <name>")
+	fmt.Fprintf(output, "       secrets are read from %s, %s and %s\n", EnvCommunity, EnvAuthPassword, EnvPrivPassword)
+}
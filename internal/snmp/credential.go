@@ -0,0 +1,265 @@
+package snmp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	appRuntime "golandproject/yscan/internal/runtime"
+)
+
+const (
+	VersionV2c = "v2c"
+	VersionV3  = "v3"
+
+	credentialStoreLabel = "yscan snmp credentials v1"
+)
+
+var (
+	ErrCredentialNotFound         = errors.New("SNMP credential not found")
+	ErrCredentialStoreUnavailable = errors.New("SNMP credential store is not configured")
+
+	credentialNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+)
+
+// Credential is one named set of read-only SNMP credentials. Scan tasks only
+// ever reference the name; the secrets stay in the sealed home store.
+type Credential struct {
+	Name         string `json:"name"`
+	Version      string `json:"version"`
+	Community    string `json:"community,omitempty"`
+	UserName     string `json:"user_name,omitempty"`
+	AuthProtocol string `json:"auth_protocol,omitempty"`
+	AuthPassword string `json:"auth_password,omitempty"`
+	PrivProtocol string `json:"priv_protocol,omitempty"`
+	PrivPassword string `json:"priv_password,omitempty"`
+}
+
+// SecurityLevel is the USM level a v3 credential requests; v2c credentials
+// report "community".
+func (credential Credential) SecurityLevel() string {
+	switch {
+	case credential.Version != VersionV3:
+		return "community"
+	case credential.PrivProtocol != "":
+		return "authPriv"
+	case credential.AuthProtocol != "":
+		return "authNoPriv"
+	}
+	return "noAuthNoPriv"
+}
+
+func (credential Credential) Validate() error {
+	if !ValidCredentialName(credential.Name) {
+		return fmt.Errorf("invalid SNMP credential name: %q", credential.Name)
+	}
+	switch credential.Version {
+	case VersionV2c:
+		if credential.Community == "" {
+			return errors.New("SNMP v2c credential requires a community")
+		}
+		if credential.UserName != "" || credential.AuthProtocol != "" || credential.PrivProtocol != "" {
+			return errors.New("SNMP v2c credential cannot carry v3 settings")
+		}
+	case VersionV3:
+		if credential.Community != "" {
+			return errors.New("SNMP v3 credential cannot carry a community")
+		}
+		if credential.UserName == "" {
+			return errors.New("SNMP v3 credential requires a user name")
+		}
+		if credential.AuthProtocol != "" {
+			if _, found := authProtocols[credential.AuthProtocol]; !found {
+				return fmt.Errorf("unsupported SNMP v3 auth protocol: %s", credential.AuthProtocol)
+			}
+			// RFC 3414 requires at least eight octets before key localization.
+			if len(credential.AuthPassword) < 8 {
+				return errors.New("SNMP v3 auth password must be at least 8 characters")
+			}
+		} else if credential.AuthPassword != "" || credential.PrivProtocol != "" {
+			return errors.New("SNMP v3 privacy requires an auth protocol")
+		}
+		if credential.PrivProtocol != "" {
+			if credential.PrivProtocol != PrivAES && credential.PrivProtocol != PrivDES {
+				return fmt.Errorf("unsupported SNMP v3 privacy protocol: %s", credential.PrivProtocol)
+			}
+			if len(credential.PrivPassword) < 8 {
+				return errors.New("SNMP v3 privacy password must be at least 8 characters")
+			}
+		} else if credential.PrivPassword != "" {
+			return errors.New("SNMP v3 privacy password requires a privacy protocol")
+		}
+	default:
+		return fmt.Errorf("unsupported SNMP version: %q", credential.Version)
+	}
+	return nil
+}
+
+func ValidCredentialName(name string) bool {
+	return credentialNamePattern.MatchString(name)
+}
+
+// CredentialSummary is the secret-free view of a stored credential.
+type CredentialSummary struct {
+	Name          string `json:"name"`
+	Version       string `json:"version"`
+	SecurityLevel string `json:"security_level"`
+	UserName      string `json:"user_name,omitempty"`
+}
+
+// CredentialStore keeps credentials in one file under the home secrets
+// directory, sealed with the home secret key.
+type CredentialStore struct {
+	Paths appRuntime.HomePaths
+	mu    sync.Mutex
+}
+
+func NewCredentialStore(paths appRuntime.HomePaths) *CredentialStore {
+	return &CredentialStore{Paths: paths}
+}
+
+func (store *CredentialStore) List() ([]CredentialSummary, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	credentials, err := store.read()
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]CredentialSummary, 0, len(credentials))
+	for _, credential := range credentials {
+		summaries = append(summaries, CredentialSummary{Name: credential.Name, Version: credential.Version, SecurityLevel: credential.SecurityLevel(), UserName: credential.UserName})
+	}
+	return summaries, nil
+}
+
+func (store *CredentialStore) Get(name string) (Credential, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	credentials, err := store.read()
+	if err != nil {
+		return Credential{}, err
+	}
+	for _, credential := range credentials {
+		if credential.Name == name {
+			return credential, nil
+		}
+	}
+	return Credential{}, fmt.Errorf("%w: %s", ErrCredentialNotFound, name)
+}
+
+// Put adds or replaces the credential with the same name.
+func (store *CredentialStore) Put(credential Credential) error {
+	if err := credential.Validate(); err != nil {
+		return err
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	credentials, err := store.read()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for index := range credentials {
+		if credentials[index].Name == credential.Name {
+			credentials[index], replaced = credential, true
+		}
+	}
+	if !replaced {
+		credentials = append(credentials, credential)
+	}
+	return store.write(credentials)
+}
+
+func (store *CredentialStore) Remove(name string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	credentials, err := store.read()
+	if err != nil {
+		return err
+	}
+	kept := credentials[:0]
+	for _, credential := range credentials {
+		if credential.Name != name {
+			kept = append(kept, credential)
+		}
+	}
+	if len(kept) == len(credentials) {
+		return fmt.Errorf("%w: %s", ErrCredentialNotFound, name)
+	}
+	return store.write(kept)
+}
+
+func (store *CredentialStore) read() ([]Credential, error) {
+	sealed, err := os.ReadFile(store.Paths.SNMPCredentials)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read SNMP credentials: %w", err)
+	}
+	box, err := appRuntime.OpenSecretBox(store.Paths, false)
+	if err != nil {
+		return nil, fmt.Errorf("open SNMP credentials: %w", err)
+	}
+	plaintext, err := box.Open(sealed, credentialStoreLabel)
+	if err != nil {
+		return nil, fmt.Errorf("open SNMP credentials: %w", err)
+	}
+	var credentials []Credential
+	if err := json.Unmarshal(plaintext, &credentials); err != nil {
+		return nil, fmt.Errorf("decode SNMP credentials: %w", err)
+	}
+	return credentials, nil
+}
+
+func (store *CredentialStore) write(credentials []Credential) error {
+	sort.Slice(credentials, func(left, right int) bool { return credentials[left].Name < credentials[right].Name })
+	plaintext, err := json.Marshal(credentials)
+	if err != nil {
+		return err
+	}
+	box, err := appRuntime.OpenSecretBox(store.Paths, true)
+	if err != nil {
+		return err
+	}
+	sealed, err := box.Seal(plaintext, credentialStoreLabel)
+	if err != nil {
+		return err
+	}
+	return appRuntime.WriteSecretFile(store.Paths, store.Paths.SNMPCredentials, sealed)
+}
+
+var configuredCredentialStore struct {
+	sync.RWMutex
+	store *CredentialStore
+}
+
+// ConfigureCredentialStore selects the store that scan tasks resolve
+// credential names against.
+func ConfigureCredentialStore(store *CredentialStore) {
+	configuredCredentialStore.Lock()
+	configuredCredentialStore.store = store
+	configuredCredentialStore.Unlock()
+}
+
+// ConfiguredCredentialStore returns the store selected for this process, or
+// nil before ConfigureCredentialStore.
+func ConfiguredCredentialStore() *CredentialStore {
+	configuredCredentialStore.RLock()
+	defer configuredCredentialStore.RUnlock()
+	return configuredCredentialStore.store
+}
+
+// LookupCredential resolves a credential name referenced by a scan task.
+func LookupCredential(name string) (Credential, error) {
+	store := ConfiguredCredentialStore()
+	if store == nil {
+		return Credential{}, ErrCredentialStoreUnavailable
+	}
+	return store.Get(strings.TrimSpace(name))
+}
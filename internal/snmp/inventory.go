@@ -0,0 +1,165 @@
+package snmp
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	oidSysDescr    = "1.3.6.1.2.1.1.1.0"
+	oidSysObjectID = "1.3.6.1.2.1.1.2.0"
+	oidSysName     = "1.3.6.1.2.1.1.5.0"
+	oidSysLocation = "1.3.6.1.2.1.1.6.0"
+
+	oidIfEntry = "1.3.6.1.2.1.2.2.1"
+
+	// Port is the agent port the collector queries.
+	Port = 161
+	// DeviceBudget bounds every request made to one device.
+	DeviceBudget = 15 * time.Second
+	// maxInterfaces bounds the ifTable walk on chassis with many ports.
+	maxInterfaces = 1024
+)
+
+// ifTable columns read from every row, in walk order.
+var interfaceColumns = []struct {
+	column int
+	assign func(*Interface, Variable)
+}{
+	{2, func(row *Interface, value Variable) { row.Description = value.String() }},
+	{3, func(row *Interface, value Variable) { row.Type = int(value.Int()) }},
+	{5, func(row *Interface, value Variable) { row.Speed = value.Int() }},
+	{6, func(row *Interface, value Variable) { row.PhysAddress = hexString(value.Value) }},
+	{7, func(row *Interface, value Variable) { row.AdminStatus = interfaceStatus(value.Int()) }},
+	{8, func(row *Interface, value Variable) { row.OperStatus = interfaceStatus(value.Int()) }},
+}
+
+// System is the read-only MIB-II system group and interface table of one
+// device.
+type System struct {
+	Description string
+	ObjectID    string
+	Name        string
+	Location    string
+	Interfaces  []Interface
+}
+
+type Interface struct {
+	Index       int
+	Description string
+	Type        int
+	Speed       int64
+	PhysAddress string
+	AdminStatus string
+	OperStatus  string
+}
+
+// CollectSystem reads the system group and ifTable from the agent at
+// address. A device that answers the system group but not the interface
+// table still yields its system identity.
+func CollectSystem(ctx context.Context, address string, credential Credential) (System, error) {
+	ctx, cancel := context.WithTimeout(ctx, DeviceBudget)
+	defer cancel()
+	client, err := Dial(ctx, address, credential)
+	if err != nil {
+		return System{}, err
+	}
+	defer client.Close()
+
+	values, err := client.Get(ctx, oidSysDescr, oidSysObjectID, oidSysName, oidSysLocation)
+	if err != nil {
+		return System{}, err
+	}
+	system := System{
+		Description: values[0].String(),
+		ObjectID:    values[1].String(),
+		Name:        values[2].String(),
+		Location:    values[3].String(),
+	}
+	if system.Description == "" && system.ObjectID == "" && system.Name == "" {
+		return System{}, errors.New("SNMP agent returned an empty system group")
+	}
+	system.Interfaces, _ = walkInterfaces(ctx, client)
+	return system, nil
+}
+
+// walkInterfaces walks the selected ifTable columns in lockstep, one GETNEXT
+// per row, and stops at the end of the table or the interface limit.
+func walkInterfaces(ctx context.Context, client *Client) ([]Interface, error) {
+	rows := make(map[int]*Interface)
+	order := make([]int, 0)
+	cursors := make([]string, len(interfaceColumns))
+	prefixes := make([]string, len(interfaceColumns))
+	for index, column := range interfaceColumns {
+		prefixes[index] = oidIfEntry + "." + strconv.Itoa(column.column) + "."
+		cursors[index] = strings.TrimSuffix(prefixes[index], ".")
+	}
+	active := make([]int, len(interfaceColumns))
+	for index := range active {
+		active[index] = index
+	}
+	for len(active) > 0 && len(order) <= maxInterfaces {
+		oids := make([]string, len(active))
+		for position, column := range active {
+			oids[position] = cursors[column]
+		}
+		values, err := client.GetNext(ctx, oids...)
+		if err != nil {
+			return interfaceRows(rows, order), err
+		}
+		next := active[:0]
+		for position, column := range active {
+			value := values[position]
+			if value.Exception() || !strings.HasPrefix(value.OID, prefixes[column]) || compareOIDs(value.OID, cursors[column]) <= 0 {
+				continue
+			}
+			cursors[column] = value.OID
+			index, err := strconv.Atoi(strings.TrimPrefix(value.OID, prefixes[column]))
+			if err != nil {
+				continue
+			}
+			row := rows[index]
+			if row == nil {
+				row = &Interface{Index: index}
+				rows[index] = row
+				order = append(order, index)
+			}
+			interfaceColumns[column].assign(row, value)
+			next = append(next, column)
+		}
+		active = next
+	}
+	return interfaceRows(rows, order), nil
+}
+
+func interfaceRows(rows map[int]*Interface, order []int) []Interface {
+	if len(order) > maxInterfaces {
+		order = order[:maxInterfaces]
+	}
+	interfaces := make([]Interface, 0, len(order))
+	for _, index := range order {
+		interfaces = append(interfaces, *rows[index])
+	}
+	return interfaces
+}
+
+func interfaceStatus(value int64) string {
+	switch value {
+	case 1:
+		return "up"
+	case 2:
+		return "down"
+	case 3:
+		return "testing"
+	case 5:
+		return "dormant"
+	case 6:
+		return "notPresent"
+	case 7:
+		return "lowerLayerDown"
+	}
+	return "unknown"
+}
@@ -0,0 +1,243 @@
+package snmp
+
+const (
+	versionV2c = 1
+	versionV3  = 3
+
+	securityModelUSM = 3
+	maxMessageSize   = 65507
+
+	flagAuth       byte = 0x01
+	flagPriv       byte = 0x02
+	flagReportable byte = 0x04
+)
+
+type pdu struct {
+	Type        byte
+	RequestID   int32
+	ErrorStatus int64
+	ErrorIndex  int64
+	Variables   []Variable
+}
+
+func encodePDU(request pdu) ([]byte, error) {
+	bindings := make([]byte, 0, 32*len(request.Variables))
+	for _, variable := range request.Variables {
+		oid, err := encodeOID(variable.OID)
+		if err != nil {
+			return nil, err
+		}
+		valueType := variable.Type
+		if valueType == 0 {
+			valueType = tagNull
+		}
+		binding := appendTLV(nil, tagOID, oid)
+		binding = appendTLV(binding, valueType, variable.Value)
+		bindings = appendTLV(bindings, tagSequence, binding)
+	}
+	content := appendInteger(nil, int64(request.RequestID))
+	content = appendInteger(content, request.ErrorStatus)
+	content = appendInteger(content, request.ErrorIndex)
+	content = appendTLV(content, tagSequence, bindings)
+	return appendTLV(nil, request.Type, content), nil
+}
+
+func decodePDU(data []byte, encoded element) (pdu, error) {
+	fields, err := expectChildren(data, encoded, tagInteger, tagInteger, tagInteger, tagSequence)
+	if err != nil {
+		return pdu{}, err
+	}
+	requestID, err := decodeInteger(fields[0].content)
+	if err != nil {
+		return pdu{}, err
+	}
+	decoded := pdu{Type: encoded.tag, RequestID: int32(requestID)}
+	if decoded.ErrorStatus, err = decodeInteger(fields[1].content); err != nil {
+		return pdu{}, err
+	}
+	if decoded.ErrorIndex, err = decodeInteger(fields[2].content); err != nil {
+		return pdu{}, err
+	}
+	bindings, err := children(data, fields[3])
+	if err != nil {
+		return pdu{}, err
+	}
+	for _, binding := range bindings {
+		pair, err := expectChildren(data, binding, tagOID)
+		if err != nil || len(pair) != 2 {
+			return pdu{}, errMalformed
+		}
+		oid, err := decodeOID(pair[0].content)
+		if err != nil {
+			return pdu{}, err
+		}
+		decoded.Variables = append(decoded.Variables, Variable{OID: oid, Type: pair[1].tag, Value: pair[1].content})
+	}
+	return decoded, nil
+}
+
+func encodeCommunityMessage(community string, request pdu) ([]byte, error) {
+	encoded, err := encodePDU(request)
+	if err != nil {
+		return nil, err
+	}
+	content := appendInteger(nil, versionV2c)
+	content = appendOctetString(content, []byte(community))
+	return appendTLV(nil, tagSequence, append(content, encoded...)), nil
+}
+
+// usmParameters is the User-based Security Model header of RFC 3414.
+type usmParameters struct {
+	EngineID   []byte
+	Boots      int32
+	Time       int32
+	UserName   string
+	AuthParams []byte
+	PrivParams []byte
+}
+
+// message is a decoded SNMP message of either version. For v3 the scoped PDU
+// stays encrypted until the caller has authenticated the message.
+type message struct {
+	Version   int64
+	Community string
+	PDU       pdu
+
+	MsgID      int32
+	Flags      byte
+	Security   usmParameters
+	authOffset int
+	Encrypted  []byte
+	scoped     []byte
+}
+
+// encodeV3Message assembles a v3 message around an already encoded msgData
+// (a plaintext scoped PDU or an encrypted OCTET STRING) and returns the
+// offset of the authentication parameters so they can be signed in place.
+func encodeV3Message(msgID int32, flags byte, security usmParameters, msgData []byte) ([]byte, int) {
+	header := appendInteger(nil, int64(msgID))
+	header = appendInteger(header, maxMessageSize)
+	header = appendOctetString(header, []byte{flags})
+	header = appendInteger(header, securityModelUSM)
+
+	parameters := appendOctetString(nil, security.EngineID)
+	parameters = appendInteger(parameters, int64(security.Boots))
+	parameters = appendInteger(parameters, int64(security.Time))
+	parameters = appendOctetString(parameters, []byte(security.UserName))
+	authStart := len(appendOctetString(parameters, security.AuthParams)) - len(security.AuthParams)
+	parameters = appendOctetString(parameters, security.AuthParams)
+	parameters = appendOctetString(parameters, security.PrivParams)
+	encodedParameters := appendTLV(nil, tagSequence, parameters)
+	authStart += len(encodedParameters) - len(parameters)
+
+	content := appendInteger(nil, versionV3)
+	content = appendTLV(content, tagSequence, header)
+	securityField := appendOctetString(nil, encodedParameters)
+	authStart += len(content) + len(securityField) - len(encodedParameters)
+	content = append(content, securityField...)
+	content = append(content, msgData...)
+	encoded := appendTLV(nil, tagSequence, content)
+	return encoded, authStart + len(encoded) - len(content)
+}
+
+func encodeScopedPDU(contextEngineID []byte, request pdu) ([]byte, error) {
+	encoded, err := encodePDU(request)
+	if err != nil {
+		return nil, err
+	}
+	content := appendOctetString(nil, contextEngineID)
+	content = appendOctetString(content, nil)
+	return appendTLV(nil, tagSequence, append(content, encoded...)), nil
+}
+
+func decodeScopedPDU(data []byte) (pdu, error) {
+	scoped, err := readElement(data, 0)
+	if err != nil || scoped.tag != tagSequence {
+		return pdu{}, errMalformed
+	}
+	fields, err := expectChildren(data, scoped, tagOctetString, tagOctetString)
+	if err != nil || len(fields) != 3 {
+		return pdu{}, errMalformed
+	}
+	return decodePDU(data, fields[2])
+}
+
+func decodeMessage(data []byte) (message, error) {
+	outer, err := readElement(data, 0)
+	if err != nil || outer.tag != tagSequence {
+		return message{}, errMalformed
+	}
+	fields, err := expectChildren(data, outer, tagInteger)
+	if err != nil {
+		return message{}, err
+	}
+	version, err := decodeInteger(fields[0].content)
+	if err != nil {
+		return message{}, err
+	}
+	decoded := message{Version: version}
+	switch version {
+	case versionV2c:
+		if len(fields) != 3 || fields[1].tag != tagOctetString {
+			return message{}, errMalformed
+		}
+		decoded.Community = string(fields[1].content)
+		decoded.PDU, err = decodePDU(data, fields[2])
+		return decoded, err
+	case versionV3:
+		if len(fields) != 4 || fields[1].tag != tagSequence || fields[2].tag != tagOctetString {
+			return message{}, errMalformed
+		}
+	default:
+		return message{}, errMalformed
+	}
+
+	header, err := expectChildren(data, fields[1], tagInteger, tagInteger, tagOctetString, tagInteger)
+	if err != nil || len(header[2].content) != 1 {
+		return message{}, errMalformed
+	}
+	msgID, err := decodeInteger(header[0].content)
+	if err != nil {
+		return message{}, err
+	}
+	decoded.MsgID, decoded.Flags = int32(msgID), header[2].content[0]
+	if model, err := decodeInteger(header[3].content); err != nil || model != securityModelUSM {
+		return message{}, errMalformed
+	}
+
+	security, err := readElement(data[:fields[2].end], fields[2].start)
+	if err != nil || security.tag != tagSequence || security.end != fields[2].end {
+		return message{}, errMalformed
+	}
+	parameters, err := expectChildren(data, security, tagOctetString, tagInteger, tagInteger, tagOctetString, tagOctetString, tagOctetString)
+	if err != nil {
+		return message{}, err
+	}
+	boots, err := decodeInteger(parameters[1].content)
+	if err != nil {
+		return message{}, err
+	}
+	engineTime, err := decodeInteger(parameters[2].content)
+	if err != nil {
+		return message{}, err
+	}
+	decoded.Security = usmParameters{
+		EngineID:   parameters[0].content,
+		Boots:      int32(boots),
+		Time:       int32(engineTime),
+		UserName:   string(parameters[3].content),
+		AuthParams: parameters[4].content,
+		PrivParams: parameters[5].content,
+	}
+	decoded.authOffset = parameters[4].start
+
+	switch fields[3].tag {
+	case tagOctetString:
+		decoded.Encrypted = fields[3].content
+	case tagSequence:
+		decoded.scoped = data[fields[3].at:fields[3].end]
+	default:
+		return message{}, errMalformed
+	}
+	return decoded, nil
+}
@@ -0,0 +1,140 @@
+package snmp
+
+import (
+	"regexp"
+	"strings"
+)
+
+const enterprisesPrefix = "1.3.6.1.4.1."
+
+// Product is the operating system a device reports through sysDescr and
+// sysObjectID, in the product keys fingerprint conclusions use.
+type Product struct {
+	Vendor  string
+	Key     string
+	Version string
+	CPE     string
+}
+
+// enterpriseVendors maps IANA private enterprise numbers under
+// 1.3.6.1.4.1 to vendor names.
+var enterpriseVendors = map[string]string{
+	"9":     "cisco",
+	"11":    "hp",
+	"311":   "microsoft",
+	"2011":  "huawei",
+	"2636":  "juniper",
+	"6876":  "vmware",
+	"8072":  "net-snmp",
+	"12356": "fortinet",
+	"14988": "mikrotik",
+	"25461": "paloaltonetworks",
+	"25506": "h3c",
+	"30065": "arista",
+	"47196": "aruba",
+}
+
+// productRule recognises one product in sysDescr. The first capture group,
+// when present, is the version.
+type productRule struct {
+	vendor  string
+	key     string
+	cpe     string
+	pattern *regexp.Regexp
+	version func([]string) string
+}
+
+var productRules = []productRule{
+	{vendor: "cisco", key: "cisco ios xe", cpe: "cpe:2.3:o:cisco:ios_xe", pattern: regexp.MustCompile(`(?is)IOS[ -]?XE.*?Version:?\s*([0-9][^\s,]*)`)},
+	{vendor: "cisco", key: "cisco nx-os", cpe: "cpe:2.3:o:cisco:nx-os", pattern: regexp.MustCompile(`(?is)Cisco NX-OS.*?Version\s*([0-9][^\s,]*)`)},
+	{vendor: "cisco", key: "cisco asa", cpe: "cpe:2.3:o:cisco:adaptive_security_appliance_software", pattern: regexp.MustCompile(`(?i)Cisco Adaptive Security Appliance Version\s*([0-9][^\s,]*)`)},
+	{vendor: "cisco", key: "cisco ios", cpe: "cpe:2.3:o:cisco:ios", pattern: regexp.MustCompile(`(?is)Cisco (?:IOS|Internetwork Operating System) Software.*?Version\s*([0-9][^\s,]*)`)},
+	{vendor: "juniper", key: "juniper junos", cpe: "cpe:2.3:o:juniper:junos", pattern: regexp.MustCompile(`(?i)JUNOS\s+([0-9][^\s,\]]*)`)},
+	{vendor: "huawei", key: "huawei vrp", cpe: "cpe:2.3:o:huawei:vrp", pattern: regexp.MustCompile(`(?is)Huawei Versatile Routing Platform.*?Version\s*([0-9.]+)(?:\s*\(([^)]*)\))?`), version: huaweiVersion},
+	{vendor: "h3c", key: "h3c comware", cpe: "cpe:2.3:o:h3c:comware", pattern: regexp.MustCompile(`(?is)Comware (?:Platform )?Software,?\s*Version\s*([0-9.]+)`)},
+	{vendor: "arista", key: "arista eos", cpe: "cpe:2.3:o:arista:eos", pattern: regexp.MustCompile(`(?i)Arista Networks EOS version\s*([0-9][^\s,]*)`)},
+	{vendor: "mikrotik", key: "mikrotik routeros", cpe: "cpe:2.3:o:mikrotik:routeros", pattern: regexp.MustCompile(`(?i)^RouterOS\b`)},
+	{vendor: "hp", key: "hp procurve", cpe: "cpe:2.3:o:hp:procurve_switch_software", pattern: regexp.MustCompile(`(?i)(?:ProCurve|Switch).*?revision\s+([A-Z]{1,2}\.[0-9.]+)`)},
+	{vendor: "vmware", key: "vmware esxi", cpe: "cpe:2.3:o:vmware:esxi", pattern: regexp.MustCompile(`(?i)VMware ESXi\s+([0-9.]+)`)},
+	{vendor: "microsoft", key: "windows", cpe: "cpe:2.3:o:microsoft:windows", pattern: regexp.MustCompile(`(?i)Software: Windows.*?Version\s+([0-9.]+)\s*\(Build\s+([0-9]+)`), version: windowsVersion},
+	{vendor: "linux", key: "linux", cpe: "cpe:2.3:o:linux:linux_kernel", pattern: regexp.MustCompile(`^Linux\s+\S+\s+([0-9][^\s]*)`)},
+	{vendor: "freebsd", key: "freebsd", cpe: "cpe:2.3:o:freebsd:freebsd", pattern: regexp.MustCompile(`^FreeBSD\s+\S+\s+([0-9][^\s]*)`)},
+}
+
+var huaweiReleasePattern = regexp.MustCompile(`^V\d{3}R\d{3}`)
+
+// enterpriseProducts covers vendors whose sysDescr carries only a model
+// name, so the product is known from sysObjectID alone.
+var enterpriseProducts = map[string]productRule{
+	"12356": {vendor: "fortinet", key: "fortinet fortios", cpe: "cpe:2.3:o:fortinet:fortios"},
+	"25461": {vendor: "paloaltonetworks", key: "paloaltonetworks pan-os", cpe: "cpe:2.3:o:paloaltonetworks:pan-os"},
+	"14988": {vendor: "mikrotik", key: "mikrotik routeros", cpe: "cpe:2.3:o:mikrotik:routeros"},
+}
+
+// IdentifyProduct maps sysDescr and sysObjectID to a product. The vendor
+// alone is returned when the description matches no known product.
+func IdentifyProduct(system System) (Product, bool) {
+	enterprise := ""
+	if strings.HasPrefix(system.ObjectID, enterprisesPrefix) {
+		enterprise, _, _ = strings.Cut(strings.TrimPrefix(system.ObjectID, enterprisesPrefix), ".")
+	}
+	description := strings.TrimSpace(system.Description)
+	for _, rule := range productRules {
+		groups := rule.pattern.FindStringSubmatch(description)
+		if groups == nil {
+			continue
+		}
+		product := Product{Vendor: rule.vendor, Key: rule.key}
+		if rule.version != nil {
+			product.Version = rule.version(groups)
+		} else if len(groups) > 1 {
+			product.Version = strings.TrimRight(groups[1], ".,;")
+		}
+		product.CPE = productCPE(rule.cpe, product.Version)
+		return product, true
+	}
+	if rule, found := enterpriseProducts[enterprise]; found {
+		return Product{Vendor: rule.vendor, Key: rule.key, CPE: productCPE(rule.cpe, "")}, true
+	}
+	if vendor := enterpriseVendors[enterprise]; vendor != "" {
+		return Product{Vendor: vendor}, false
+	}
+	return Product{}, false
+}
+
+// productCPE completes a CPE 2.3 prefix with the escaped version, leaving
+// the version wildcarded when the device did not report one.
+func productCPE(prefix, version string) string {
+	if prefix == "" {
+		return ""
+	}
+	if version == "" {
+		return prefix + ":*:*:*:*:*:*:*:*"
+	}
+	var escaped strings.Builder
+	for _, character := range strings.ToLower(version) {
+		if !(character >= 'a' && character <= 'z' || character >= '0' && character <= '9' || character == '.' || character == '_' || character == '-') {
+			escaped.WriteByte('\\')
+		}
+		escaped.WriteRune(character)
+	}
+	return prefix + ":" + escaped.String() + ":*:*:*:*:*:*:*"
+}
+
+// huaweiVersion prefers the VRP release train (V200R011C10SPC500) in the
+// parentheses over the platform version number.
+func huaweiVersion(groups []string) string {
+	if len(groups) > 2 {
+		fields := strings.Fields(groups[2])
+		if len(fields) > 0 && huaweiReleasePattern.MatchString(fields[len(fields)-1]) {
+			return fields[len(fields)-1]
+		}
+	}
+	return groups[1]
+}
+
+// windowsVersion joins the kernel version and build the way NTLM reports
+// them, such as 6.3.17763.
+func windowsVersion(groups []string) string {
+	return groups[1] + "." + groups[2]
+}
@@ -0,0 +1,342 @@
+package snmp
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	appRuntime "golandproject/yscan/internal/runtime"
+)
+
+func TestLocalizedKeyMatchesRFC3414Vectors(t *testing.T) {
+	engineID, _ := hex.DecodeString("000000000000000000000002")
+	for protocol, want := range map[string]string{
+		AuthMD5: "526f5eed9fcce26f8964c2930787d82b",
+		AuthSHA: "6695febc9288e36282235fc7151f128497b38f3f",
+	} {
+		if got := hex.EncodeToString(localizedKey(authProtocols[protocol], "maplesyrup", engineID)); got != want {
+			t.Fatalf("%s localized key = %s, want %s", protocol, got, want)
+		}
+	}
+}
+
+func TestCollectSystemOverV2c(t *testing.T) {
+	agent := startTestAgent(t, Credential{Name: "lab", Version: VersionV2c, Community: "inventory-ro"}, testDeviceMIB())
+	system, err := CollectSystem(context.Background(), agent.address(), Credential{Name: "lab", Version: VersionV2c, Community: "inventory-ro"})
+	if err != nil {
+		t.Fatalf("collect system: %v", err)
+	}
+	assertTestDevice(t, system)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if _, err := CollectSystem(ctx, agent.address(), Credential{Name: "lab", Version: VersionV2c, Community: "public"}); err == nil {
+		t.Fatal("agent answered an unknown community")
+	}
+}
+
+func TestCollectSystemOverV3(t *testing.T) {
+	for _, credential := range []Credential{
+		{Name: "core", Version: VersionV3, UserName: "inventory", AuthProtocol: AuthSHA256, AuthPassword: "auth-secret", PrivProtocol: PrivAES, PrivPassword: "priv-secret"},
+		{Name: "legacy", Version: VersionV3, UserName: "inventory", AuthProtocol: AuthMD5, AuthPassword: "auth-secret", PrivProtocol: PrivDES, PrivPassword: "priv-secret"},
+		{Name: "signed", Version: VersionV3, UserName: "inventory", AuthProtocol: AuthSHA, AuthPassword: "auth-secret"},
+	} {
+		agent := startTestAgent(t, credential, testDeviceMIB())
+		system, err := CollectSystem(context.Background(), agent.address(), credential)
+		if err != nil {
+			t.Fatalf("%s: collect system: %v", credential.Name, err)
+		}
+		assertTestDevice(t, system)
+
+		wrong := credential
+		wrong.AuthPassword = "other-secret"
+		if _, err := CollectSystem(context.Background(), agent.address(), wrong); err == nil || !strings.Contains(err.Error(), "wrong digest") {
+			t.Fatalf("%s: wrong password error = %v", credential.Name, err)
+		}
+	}
+}
+
+func TestIdentifyProductMapsNetworkGear(t *testing.T) {
+	for _, test := range []struct {
+		system System
+		want   Product
+	}{
+		{System{Description: "Cisco IOS Software, C2960 Software (C2960-LANBASEK9-M), Version 12.2(55)SE, RELEASE SOFTWARE (fc2)", ObjectID: "1.3.6.1.4.1.9.1.1208"},
+			Product{Vendor: "cisco", Key: "cisco ios", Version: "12.2(55)SE", CPE: `cpe:2.3:o:cisco:ios:12.2\(55\)se:*:*:*:*:*:*:*`}},
+		{System{Description: "Cisco IOS Software [Gibraltar], Catalyst L3 Switch Software (CAT9K_IOSXE), Version 16.12.4, RELEASE SOFTWARE (fc5)"},
+			Product{Vendor: "cisco", Key: "cisco ios xe", Version: "16.12.4", CPE: "cpe:2.3:o:cisco:ios_xe:16.12.4:*:*:*:*:*:*:*"}},
+		{System{Description: "Juniper Networks, Inc. ex2300-24t Ethernet Switch, kernel JUNOS 20.4R3-S2.6, Build date: 2022-01-29"},
+			Product{Vendor: "juniper", Key: "juniper junos", Version: "20.4R3-S2.6", CPE: "cpe:2.3:o:juniper:junos:20.4r3-s2.6:*:*:*:*:*:*:*"}},
+		{System{Description: "Huawei Versatile Routing Platform Software\r\nVRP (R) software, Version 5.170 (S5700 V200R011C10SPC500)\r\nCopyright (C) 2000-2018 HUAWEI TECH CO., LTD"},
+			Product{Vendor: "huawei", Key: "huawei vrp", Version: "V200R011C10SPC500", CPE: "cpe:2.3:o:huawei:vrp:v200r011c10spc500:*:*:*:*:*:*:*"}},
+		{System{Description: "Hardware: Intel64 Family 6 Model 85 Stepping 7 AT/AT COMPATIBLE - Software: Windows Version 6.3 (Build 17763 Multiprocessor Free)"},
+			Product{Vendor: "microsoft", Key: "windows", Version: "6.3.17763", CPE: "cpe:2.3:o:microsoft:windows:6.3.17763:*:*:*:*:*:*:*"}},
+		{System{Description: "FGT60F", ObjectID: "1.3.6.1.4.1.12356.101.1.1004"},
+			Product{Vendor: "fortinet", Key: "fortinet fortios", CPE: "cpe:2.3:o:fortinet:fortios:*:*:*:*:*:*:*:*"}},
+	} {
+		product, found := IdentifyProduct(test.system)
+		if !found || product != test.want {
+			t.Fatalf("IdentifyProduct(%q) = %#v, %v; want %#v", test.system.Description, product, found, test.want)
+		}
+	}
+	if product, found := IdentifyProduct(System{Description: "Aruba switch", ObjectID: "1.3.6.1.4.1.47196.4.1.1"}); found || product.Vendor != "aruba" {
+		t.Fatalf("vendor-only product = %#v, %v", product, found)
+	}
+}
+
+func TestCredentialStoreSealsSecretsUnderHome(t *testing.T) {
+	paths, err := appRuntime.ResolveHome(os.Args[0], t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewCredentialStore(paths)
+	if err := store.Put(Credential{Name: "bad name", Version: VersionV2c, Community: "x"}); err == nil {
+		t.Fatal("invalid credential name was stored")
+	}
+	if err := store.Put(Credential{Name: "short", Version: VersionV3, UserName: "u", AuthProtocol: AuthSHA, AuthPassword: "short"}); err == nil {
+		t.Fatal("short v3 password was stored")
+	}
+	if err := store.Put(Credential{Name: "campus", Version: VersionV2c, Community: "campus-community-ro"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(Credential{Name: "core", Version: VersionV3, UserName: "inventory", AuthProtocol: AuthSHA256, AuthPassword: "auth-secret", PrivProtocol: PrivAES, PrivPassword: "priv-secret"}); err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := os.ReadFile(paths.SNMPCredentials)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, []byte("campus-community-ro")) || bytes.Contains(sealed, []byte("auth-secret")) {
+		t.Fatal("credential store contains plaintext secrets")
+	}
+	if info, err := os.Stat(paths.SNMPCredentials); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("credential file mode = %v, %v", info, err)
+	}
+
+	ConfigureCredentialStore(store)
+	t.Cleanup(func() { ConfigureCredentialStore(nil) })
+	credential, err := LookupCredential("core")
+	if err != nil || credential.PrivPassword != "priv-secret" || credential.SecurityLevel() != "authPriv" {
+		t.Fatalf("lookup = %#v, %v", credential, err)
+	}
+	summaries, err := store.List()
+	if err != nil || len(summaries) != 2 || summaries[0].Name != "campus" || summaries[1].SecurityLevel != "authPriv" {
+		t.Fatalf("summaries = %#v, %v", summaries, err)
+	}
+	if err := store.Remove("campus"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LookupCredential("campus"); !errors.Is(err, ErrCredentialNotFound) {
+		t.Fatalf("removed credential lookup error = %v", err)
+	}
+
+	environment := map[string]string{EnvAuthPassword: "edge-auth-secret"}
+	lookupEnv := func(name string) (string, bool) { value, found := environment[name]; return value, found }
+	var output bytes.Buffer
+	if err := RunCLI(store, []string{"credential", "add", "edge", "--version", "v3", "--user", "edge-ro", "--auth", "SHA"}, lookupEnv, &output); err != nil {
+		t.Fatalf("credential add: %v", err)
+	}
+	if credential, err := store.Get("edge"); err != nil || credential.AuthPassword != "edge-auth-secret" || credential.SecurityLevel() != "authNoPriv" {
+		t.Fatalf("CLI credential = %#v, %v", credential, err)
+	}
+	output.Reset()
+	if err := RunCLI(store, []string{"credential", "list"}, lookupEnv, &output); err != nil || strings.Contains(output.String(), "secret") || !strings.Contains(output.String(), "edge-ro") {
+		t.Fatalf("credential list = %q, %v", output.String(), err)
+	}
+
+	if err := os.WriteFile(paths.SecretKey, bytes.Repeat([]byte{7}, 32), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.List(); err == nil {
+		t.Fatal("credential store opened with another home's key")
+	}
+}
+
+func assertTestDevice(t *testing.T, system System) {
+	t.Helper()
+	if system.Name != "core-sw01" || system.Location != "DC1 row 4" || system.ObjectID != "1.3.6.1.4.1.9.1.1208" {
+		t.Fatalf("system = %#v", system)
+	}
+	if len(system.Interfaces) != 2 {
+		t.Fatalf("interfaces = %#v", system.Interfaces)
+	}
+	uplink := system.Interfaces[1]
+	if uplink.Index != 10101 || uplink.Description != "GigabitEthernet1/0/1" || uplink.Type != 6 || uplink.Speed != 1000000000 ||
+		uplink.PhysAddress != "00:1b:54:aa:bb:01" || uplink.AdminStatus != "up" || uplink.OperStatus != "down" {
+		t.Fatalf("uplink = %#v", uplink)
+	}
+	if product, found := IdentifyProduct(system); !found || product.Key != "cisco ios" || product.Version != "15.2(4)M3" {
+		t.Fatalf("product = %#v, %v", product, found)
+	}
+}
+
+func testDeviceMIB() []Variable {
+	octets := func(value []byte) Variable { return Variable{Type: tagOctetString, Value: value} }
+	integer := func(value int64) Variable { return Variable{Type: tagInteger, Value: appendInteger(nil, value)[2:]} }
+	gauge := func(value int64) Variable { return Variable{Type: tagGauge32, Value: appendInteger(nil, value)[2:]} }
+	objectID, _ := encodeOID("1.3.6.1.4.1.9.1.1208")
+	mib := make([]Variable, 0)
+	add := func(oid string, value Variable) {
+		value.OID = oid
+		mib = append(mib, value)
+	}
+	add(oidSysDescr, octets([]byte("Cisco IOS Software, C3750E Software (C3750E-UNIVERSALK9-M), Version 15.2(4)M3, RELEASE SOFTWARE (fc1)")))
+	add(oidSysObjectID, Variable{Type: tagOID, Value: objectID})
+	add("1.3.6.1.2.1.1.3.0", Variable{Type: tagTimeTicks, Value: []byte{0x01, 0x00}})
+	add(oidSysName, octets([]byte("core-sw01")))
+	add(oidSysLocation, octets([]byte("DC1 row 4")))
+	for _, row := range []struct {
+		index         string
+		description   string
+		ifType, speed int64
+		mac           []byte
+		admin, oper   int64
+	}{
+		{"1", "Vlan1", 53, 0, []byte{0x00, 0x1b, 0x54, 0xaa, 0xbb, 0x00}, 1, 1},
+		{"10101", "GigabitEthernet1/0/1", 6, 1000000000, []byte{0x00, 0x1b, 0x54, 0xaa, 0xbb, 0x01}, 1, 2},
+	} {
+		add(oidIfEntry+".1."+row.index, integer(1))
+		add(oidIfEntry+".2."+row.index, octets([]byte(row.description)))
+		add(oidIfEntry+".3."+row.index, integer(row.ifType))
+		add(oidIfEntry+".4."+row.index, integer(1500))
+		add(oidIfEntry+".5."+row.index, gauge(row.speed))
+		add(oidIfEntry+".6."+row.index, octets(row.mac))
+		add(oidIfEntry+".7."+row.index, integer(row.admin))
+		add(oidIfEntry+".8."+row.index, integer(row.oper))
+	}
+	add("1.3.6.1.2.1.2.2.1.9.1", Variable{Type: tagTimeTicks, Value: []byte{0}})
+	add("1.3.6.1.2.1.31.1.1.1.1.1", octets([]byte("Vl1")))
+	sort.Slice(mib, func(left, right int) bool { return compareOIDs(mib[left].OID, mib[right].OID) < 0 })
+	return mib
+}
+
+// testAgent is an in-process stand-in for a read-only SNMP agent. It answers
+// GET and GETNEXT from a fixed MIB for one v2c community or one USM user.
+type testAgent struct {
+	conn       *net.UDPConn
+	credential Credential
+	engineID   []byte
+	mib        []Variable
+}
+
+func startTestAgent(t *testing.T, credential Credential, mib []Variable) *testAgent {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	agent := &testAgent{conn: conn, credential: credential, engineID: []byte("\x80\x00\x00\x09\x03test-agent"), mib: mib}
+	t.Cleanup(func() { _ = conn.Close() })
+	go agent.serve()
+	return agent
+}
+
+func (agent *testAgent) address() string {
+	return agent.conn.LocalAddr().String()
+}
+
+func (agent *testAgent) serve() {
+	buffer := make([]byte, maxMessageSize)
+	for {
+		count, peer, err := agent.conn.ReadFromUDP(buffer)
+		if err != nil {
+			return
+		}
+		if reply := agent.answer(append([]byte(nil), buffer[:count]...)); reply != nil {
+			_, _ = agent.conn.WriteToUDP(reply, peer)
+		}
+	}
+}
+
+func (agent *testAgent) answer(data []byte) []byte {
+	received, err := decodeMessage(data)
+	if err != nil {
+		return nil
+	}
+	if received.Version == versionV2c {
+		if agent.credential.Version != VersionV2c || received.Community != agent.credential.Community {
+			return nil
+		}
+		reply, _ := encodeCommunityMessage(received.Community, agent.respond(received.PDU))
+		return reply
+	}
+	if agent.credential.Version != VersionV3 {
+		return nil
+	}
+	const boots, engineTime = 3, 4200
+	security := usmParameters{EngineID: agent.engineID, Boots: boots, Time: engineTime}
+	if len(received.Security.EngineID) == 0 {
+		return agent.report(received.MsgID, 0, security, oidUnknownEngineIDs)
+	}
+	protocol := authProtocols[agent.credential.AuthProtocol]
+	if received.Flags&flagAuth != 0 {
+		key := localizedKey(protocol, agent.credential.AuthPassword, agent.engineID)
+		if received.Security.UserName != agent.credential.UserName || !protocol.verifyMessage(key, data, received.authOffset) {
+			return agent.report(received.MsgID, 0, security, oidWrongDigests)
+		}
+	}
+	var privKey []byte
+	scoped := received.scoped
+	if received.Encrypted != nil {
+		privKey = localizedKey(protocol, agent.credential.PrivPassword, agent.engineID)
+		scoped, err = decryptScopedPDU(agent.credential.PrivProtocol, privKey, received.Security.Boots, received.Security.Time, received.Security.PrivParams, received.Encrypted)
+		if err != nil {
+			return agent.report(received.MsgID, 0, security, oidDecryptionErrors)
+		}
+	}
+	request, err := decodeScopedPDU(scoped)
+	if err != nil {
+		return nil
+	}
+	responseScoped, _ := encodeScopedPDU(agent.engineID, agent.respond(request))
+	security.UserName = agent.credential.UserName
+	flags := received.Flags &^ flagReportable
+	msgData := responseScoped
+	if privKey != nil {
+		ciphertext, parameters, err := encryptScopedPDU(agent.credential.PrivProtocol, privKey, boots, engineTime, 99, responseScoped)
+		if err != nil {
+			return nil
+		}
+		security.PrivParams, msgData = parameters, appendOctetString(nil, ciphertext)
+	}
+	if flags&flagAuth != 0 {
+		security.AuthParams = make([]byte, protocol.length)
+	}
+	reply, authOffset := encodeV3Message(received.MsgID, flags, security, msgData)
+	if flags&flagAuth != 0 {
+		protocol.signMessage(localizedKey(protocol, agent.credential.AuthPassword, agent.engineID), reply, authOffset)
+	}
+	return reply
+}
+
+func (agent *testAgent) report(msgID int32, flags byte, security usmParameters, oid string) []byte {
+	scoped, _ := encodeScopedPDU(agent.engineID, pdu{Type: pduReport, Variables: []Variable{{OID: oid, Type: tagCounter32, Value: []byte{1}}}})
+	reply, _ := encodeV3Message(msgID, flags, security, scoped)
+	return reply
+}
+
+func (agent *testAgent) respond(request pdu) pdu {
+	response := pdu{Type: pduResponse, RequestID: request.RequestID}
+	for _, requested := range request.Variables {
+		value := Variable{OID: requested.OID, Type: tagNoSuchObject}
+		if request.Type == pduGetNextRequest {
+			value.Type = tagEndOfMibView
+		}
+		for _, candidate := range agent.mib {
+			order := compareOIDs(candidate.OID, requested.OID)
+			if (request.Type == pduGetRequest && order == 0) || (request.Type == pduGetNextRequest && order > 0) {
+				value = candidate
+				break
+			}
+		}
+		response.Variables = append(response.Variables, value)
+	}
+	return response
+}
@@ -0,0 +1,167 @@
+package snmp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"hash"
+)
+
+const (
+	AuthMD5    = "md5"
+	AuthSHA    = "sha"
+	AuthSHA256 = "sha256"
+	AuthSHA512 = "sha512"
+
+	PrivDES = "des"
+	PrivAES = "aes"
+)
+
+// authProtocol describes one HMAC authentication protocol of RFC 3414 and
+// RFC 7860 by its digest and the truncated MAC length carried on the wire.
+type authProtocol struct {
+	digest func() hash.Hash
+	length int
+}
+
+var authProtocols = map[string]authProtocol{
+	AuthMD5:    {digest: md5.New, length: 12},
+	AuthSHA:    {digest: sha1.New, length: 12},
+	AuthSHA256: {digest: sha256.New, length: 24},
+	AuthSHA512: {digest: sha512.New, length: 48},
+}
+
+var errAuthentication = errors.New("SNMPv3 message authentication failed")
+
+// localizedKey derives the engine-specific key of RFC 3414 A.2: the password
+// is repeated to one megabyte, hashed, and hashed again around the engine ID.
+func localizedKey(protocol authProtocol, password string, engineID []byte) []byte {
+	digest := protocol.digest()
+	repeated := make([]byte, 64)
+	for offset := 0; offset < 1048576; offset += len(repeated) {
+		for index := range repeated {
+			repeated[index] = password[(offset+index)%len(password)]
+		}
+		digest.Write(repeated)
+	}
+	master := digest.Sum(nil)
+	digest.Reset()
+	digest.Write(master)
+	digest.Write(engineID)
+	digest.Write(master)
+	return digest.Sum(nil)
+}
+
+func (protocol authProtocol) sign(key, message []byte) []byte {
+	mac := hmac.New(protocol.digest, key)
+	mac.Write(message)
+	return mac.Sum(nil)[:protocol.length]
+}
+
+// signMessage fills the zeroed authentication parameters at offset with the
+// message MAC.
+func (protocol authProtocol) signMessage(key, message []byte, offset int) {
+	copy(message[offset:offset+protocol.length], protocol.sign(key, message))
+}
+
+// verifyMessage recomputes the MAC of a received message with its
+// authentication parameters zeroed.
+func (protocol authProtocol) verifyMessage(key, message []byte, offset int) bool {
+	if offset < 0 || offset+protocol.length > len(message) {
+		return false
+	}
+	received := append([]byte(nil), message[offset:offset+protocol.length]...)
+	zeroed := append([]byte(nil), message...)
+	for index := offset; index < offset+protocol.length; index++ {
+		zeroed[index] = 0
+	}
+	return hmac.Equal(received, protocol.sign(key, zeroed))
+}
+
+// encryptScopedPDU applies CBC-DES (RFC 3414 8.1.1) or CFB-AES-128 (RFC 3826)
+// and returns the ciphertext with the salt sent as privacy parameters.
+func encryptScopedPDU(privacy string, key []byte, boots, engineTime int32, salt uint64, plaintext []byte) ([]byte, []byte, error) {
+	switch privacy {
+	case PrivAES:
+		if len(key) < 16 {
+			return nil, nil, errors.New("AES privacy key is too short")
+		}
+		parameters := binary.BigEndian.AppendUint64(nil, salt)
+		block, err := aes.NewCipher(key[:16])
+		if err != nil {
+			return nil, nil, err
+		}
+		ciphertext := make([]byte, len(plaintext))
+		cipher.NewCFBEncrypter(block, aesIV(boots, engineTime, parameters)).XORKeyStream(ciphertext, plaintext)
+		return ciphertext, parameters, nil
+	case PrivDES:
+		if len(key) < 16 {
+			return nil, nil, errors.New("DES privacy key is too short")
+		}
+		parameters := binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, uint32(boots)), uint32(salt))
+		block, err := des.NewCipher(key[:8])
+		if err != nil {
+			return nil, nil, err
+		}
+		padded := append([]byte(nil), plaintext...)
+		for len(padded)%des.BlockSize != 0 {
+			padded = append(padded, 0)
+		}
+		ciphertext := make([]byte, len(padded))
+		cipher.NewCBCEncrypter(block, desIV(key, parameters)).CryptBlocks(ciphertext, padded)
+		return ciphertext, parameters, nil
+	}
+	return nil, nil, errors.New("unsupported SNMPv3 privacy protocol")
+}
+
+func decryptScopedPDU(privacy string, key []byte, boots, engineTime int32, parameters, ciphertext []byte) ([]byte, error) {
+	if len(parameters) != 8 {
+		return nil, errors.New("invalid SNMPv3 privacy parameters")
+	}
+	switch privacy {
+	case PrivAES:
+		if len(key) < 16 {
+			return nil, errors.New("AES privacy key is too short")
+		}
+		block, err := aes.NewCipher(key[:16])
+		if err != nil {
+			return nil, err
+		}
+		plaintext := make([]byte, len(ciphertext))
+		cipher.NewCFBDecrypter(block, aesIV(boots, engineTime, parameters)).XORKeyStream(plaintext, ciphertext)
+		return plaintext, nil
+	case PrivDES:
+		if len(key) < 16 || len(ciphertext)%des.BlockSize != 0 {
+			return nil, errors.New("invalid DES ciphertext")
+		}
+		block, err := des.NewCipher(key[:8])
+		if err != nil {
+			return nil, err
+		}
+		plaintext := make([]byte, len(ciphertext))
+		cipher.NewCBCDecrypter(block, desIV(key, parameters)).CryptBlocks(plaintext, ciphertext)
+		return plaintext, nil
+	}
+	return nil, errors.New("unsupported SNMPv3 privacy protocol")
+}
+
+func aesIV(boots, engineTime int32, salt []byte) []byte {
+	iv := binary.BigEndian.AppendUint32(nil, uint32(boots))
+	iv = binary.BigEndian.AppendUint32(iv, uint32(engineTime))
+	return append(iv, salt...)
+}
+
+func desIV(key, salt []byte) []byte {
+	iv := make([]byte, des.BlockSize)
+	for index := range iv {
+		iv[index] = key[8+index] ^ salt[index]
+	}
+	return iv
+}
@@ -13,7 +13,7 @@ import (
 )
 
 const (
-	CurrentSchemaVersion = 1
+	CurrentSchemaVersion = 12
 	MinimumSchemaVersion = 1
 )
 
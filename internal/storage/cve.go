@@ -0,0 +1,471 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"golandproject/yscan/internal/model"
+)
+
+// CVEDataSummary counts the imported NVD and KEV rows used for correlation.
+type CVEDataSummary struct {
+	Records        int    `json:"records"`
+	CPEMatches     int    `json:"cpe_matches"`
+	KnownExploited int    `json:"known_exploited"`
+	LastModified   string `json:"last_modified,omitempty"`
+}
+
+// ImportCVERecords upserts NVD records and returns the number of CPE match
+// criteria stored. The criteria of a record are replaced as a whole, so a
+// re-imported feed drops configurations NVD has since withdrawn.
+func ImportCVERecords(db *sql.DB, records []model.CVERecord) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	upsert, err := tx.Prepare(`
+		INSERT INTO cve_records (cve_id, published, last_modified, description, cvss_version, cvss_score, cvss_severity, cvss_vector, imported_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))
+		ON CONFLICT(cve_id) DO UPDATE SET
+			published = excluded.published, last_modified = excluded.last_modified, description = excluded.description,
+			cvss_version = excluded.cvss_version, cvss_score = excluded.cvss_score, cvss_severity = excluded.cvss_severity,
+			cvss_vector = excluded.cvss_vector, imported_at = excluded.imported_at`)
+	if err != nil {
+		return 0, err
+	}
+	defer upsert.Close()
+	remove, err := tx.Prepare(`DELETE FROM cve_cpe_matches WHERE cve_id = ?`)
+	if err != nil {
+		return 0, err
+	}
+	defer remove.Close()
+	insert, err := tx.Prepare(`
+		INSERT INTO cve_cpe_matches
+			(cve_id, criteria, part, vendor, product, version, version_update,
+			 version_start_including, version_start_excluding, version_end_including, version_end_excluding)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, err
+	}
+	defer insert.Close()
+	stored := 0
+	for _, record := range records {
+		id := strings.ToUpper(strings.TrimSpace(record.ID))
+		if !strings.HasPrefix(id, "CVE-") {
+			return 0, fmt.Errorf("invalid CVE ID %q", record.ID)
+		}
+		if _, err := upsert.Exec(id, record.Published, record.LastModified, record.Description, record.CVSSVersion,
+			record.CVSSScore, strings.ToLower(record.CVSSSeverity), record.CVSSVector); err != nil {
+			return 0, err
+		}
+		if _, err := remove.Exec(id); err != nil {
+			return 0, err
+		}
+		for _, match := range record.CPEMatches {
+			name, ok := parseCPEName(match.Criteria)
+			if !ok {
+				continue
+			}
+			if _, err := insert.Exec(id, match.Criteria, name.part, name.vendor, name.product, name.version, name.update,
+				match.VersionStartIncluding, match.VersionStartExcluding, match.VersionEndIncluding, match.VersionEndExcluding); err != nil {
+				return 0, err
+			}
+			stored++
+		}
+	}
+	return stored, tx.Commit()
+}
+
+// ReplaceKnownExploitedCVEs swaps in a complete CISA KEV catalog. The catalog
+// is published whole, so entries missing from the new copy are removed.
+func ReplaceKnownExploitedCVEs(db *sql.DB, entries []model.KnownExploitedCVE) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.Exec(`DELETE FROM cve_known_exploited`); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		id := strings.ToUpper(strings.TrimSpace(entry.CVEID))
+		if !strings.HasPrefix(id, "CVE-") {
+			return fmt.Errorf("invalid KEV CVE ID %q", entry.CVEID)
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO cve_known_exploited (cve_id, vendor_project, product, vulnerability_name, date_added, due_date, known_ransomware)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(cve_id) DO NOTHING`,
+			id, entry.VendorProject, entry.Product, entry.VulnerabilityName, entry.DateAdded, entry.DueDate, entry.KnownRansomware); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetCVEDataSummary reports how much correlation data has been imported.
+func GetCVEDataSummary(db *sql.DB) (CVEDataSummary, error) {
+	var summary CVEDataSummary
+	err := db.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM cve_records), (SELECT COUNT(*) FROM cve_cpe_matches),
+			(SELECT COUNT(*) FROM cve_known_exploited), COALESCE((SELECT MAX(last_modified) FROM cve_records), '')`).
+		Scan(&summary.Records, &summary.CPEMatches, &summary.KnownExploited, &summary.LastModified)
+	return summary, err
+}
+
+type cveCandidate struct {
+	cveID, criteria, version, update                           string
+	startIncluding, startExcluding, endIncluding, endExcluding string
+	cvssVersion, severity, kevDateAdded                        string
+	cvssScore                                                  float64
+	knownExploited                                             bool
+}
+
+type cveConclusion struct {
+	ip, protocol, productKey, cpe, version string
+	port                                   int
+	name                                   cpeName
+}
+
+// correlateScanTaskRunCVEsTx matches the run's fingerprint conclusions that
+// carry a CPE and a version against the imported NVD configurations. Conflicted
+// products are skipped, as are endpoints whose version is unknown: every
+// version range would otherwise match them.
+func correlateScanTaskRunCVEsTx(tx *sql.Tx, runID int64) error {
+	rows, err := tx.Query(`
+		SELECT ip, port, protocol, product_key, COALESCE(cpe, ''), COALESCE(version, '')
+		FROM asset_fingerprint_conclusions
+		WHERE scan_task_run_id = ? AND product_status <> 'conflicted' AND COALESCE(cpe, '') <> ''
+		ORDER BY ip, port, protocol, product_key`, runID)
+	if isMissingCVETable(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	conclusions := make([]cveConclusion, 0)
+	for rows.Next() {
+		var conclusion cveConclusion
+		if err := rows.Scan(&conclusion.ip, &conclusion.port, &conclusion.protocol, &conclusion.productKey, &conclusion.cpe, &conclusion.version); err != nil {
+			rows.Close()
+			return err
+		}
+		name, ok := parseCPEName(conclusion.cpe)
+		if !ok {
+			continue
+		}
+		if conclusion.version == "" && !cpeWildcard(name.version) {
+			conclusion.version = name.version
+		}
+		if conclusion.version == "" {
+			continue
+		}
+		conclusion.name = name
+		conclusions = append(conclusions, conclusion)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	candidates := make(map[string][]cveCandidate)
+	for _, conclusion := range conclusions {
+		key := conclusion.name.part + ":" + conclusion.name.vendor + ":" + conclusion.name.product
+		if _, loaded := candidates[key]; !loaded {
+			loaded, err := loadCVECandidatesTx(tx, conclusion.name)
+			if isMissingCVETable(err) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			candidates[key] = loaded
+		}
+		for _, candidate := range candidates[key] {
+			versionRange, covered := cveCandidateCovers(candidate, conclusion.version)
+			if !covered {
+				continue
+			}
+			if _, err := tx.Exec(`
+				INSERT INTO scan_task_run_cve_matches
+					(scan_task_run_id, ip, port, protocol, product_key, cpe, version, cve_id, criteria, version_range,
+					 cvss_version, cvss_score, severity, known_exploited, kev_date_added)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT DO NOTHING`,
+				runID, conclusion.ip, conclusion.port, conclusion.protocol, conclusion.productKey, conclusion.cpe, conclusion.version,
+				candidate.cveID, candidate.criteria, versionRange, candidate.cvssVersion, candidate.cvssScore, candidate.severity,
+				boolToInt(candidate.knownExploited), candidate.kevDateAdded); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func loadCVECandidatesTx(tx *sql.Tx, name cpeName) ([]cveCandidate, error) {
+	rows, err := tx.Query(`
+		SELECT m.cve_id, m.criteria, m.version, m.version_update,
+			m.version_start_including, m.version_start_excluding, m.version_end_including, m.version_end_excluding,
+			r.cvss_version, r.cvss_score, r.cvss_severity, COALESCE(k.date_added, ''), k.cve_id IS NOT NULL
+		FROM cve_cpe_matches AS m
+		JOIN cve_records AS r ON r.cve_id = m.cve_id
+		LEFT JOIN cve_known_exploited AS k ON k.cve_id = m.cve_id
+		WHERE m.part = ? AND m.vendor = ? AND m.product = ?
+		ORDER BY m.cve_id, m.criteria`, name.part, name.vendor, name.product)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	candidates := make([]cveCandidate, 0)
+	for rows.Next() {
+		var candidate cveCandidate
+		if err := rows.Scan(&candidate.cveID, &candidate.criteria, &candidate.version, &candidate.update,
+			&candidate.startIncluding, &candidate.startExcluding, &candidate.endIncluding, &candidate.endExcluding,
+			&candidate.cvssVersion, &candidate.cvssScore, &candidate.severity, &candidate.kevDateAdded, &candidate.knownExploited); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, rows.Err()
+}
+
+// cveCandidateCovers reports whether version falls in the criteria and
+// describes the range that matched. Criteria without bounds name a single
+// version, or every version when the version is a wildcard.
+func cveCandidateCovers(candidate cveCandidate, version string) (string, bool) {
+	bounds := []struct {
+		value, operator string
+		accepts         func(int) bool
+	}{
+		{candidate.startIncluding, ">=", func(order int) bool { return order >= 0 }},
+		{candidate.startExcluding, ">", func(order int) bool { return order > 0 }},
+		{candidate.endIncluding, "<=", func(order int) bool { return order <= 0 }},
+		{candidate.endExcluding, "<", func(order int) bool { return order < 0 }},
+	}
+	described := make([]string, 0, 2)
+	for _, bound := range bounds {
+		if bound.value == "" {
+			continue
+		}
+		if !bound.accepts(compareVersions(version, bound.value)) {
+			return "", false
+		}
+		described = append(described, bound.operator+bound.value)
+	}
+	if len(described) > 0 {
+		return strings.Join(described, " "), true
+	}
+	switch {
+	case candidate.version == "-":
+		return "", false
+	case cpeWildcard(candidate.version):
+		return "*", true
+	}
+	exact := candidate.version
+	if !cpeWildcard(candidate.update) && candidate.update != "-" {
+		exact += candidate.update
+	}
+	if compareVersions(version, exact) != 0 {
+		return "", false
+	}
+	return "=" + exact, true
+}
+
+type cpeName struct {
+	part, vendor, product, version, update string
+}
+
+// parseCPEName reads the identifying fields of a CPE 2.3 formatted string or a
+// CPE 2.2 URI. Vendor and product are lowercased as NVD publishes them.
+func parseCPEName(value string) (cpeName, bool) {
+	value = strings.TrimSpace(value)
+	var fields []string
+	switch {
+	case strings.HasPrefix(value, "cpe:2.3:"):
+		fields = splitCPEFields(strings.TrimPrefix(value, "cpe:2.3:"))
+	case strings.HasPrefix(value, "cpe:/"):
+		fields = strings.Split(strings.TrimPrefix(value, "cpe:/"), ":")
+	default:
+		return cpeName{}, false
+	}
+	for len(fields) < 5 {
+		fields = append(fields, "")
+	}
+	name := cpeName{
+		part:    strings.ToLower(fields[0]),
+		vendor:  strings.ToLower(fields[1]),
+		product: strings.ToLower(fields[2]),
+		version: fields[3],
+		update:  fields[4],
+	}
+	if name.part == "" || cpeWildcard(name.vendor) || cpeWildcard(name.product) {
+		return cpeName{}, false
+	}
+	return name, true
+}
+
+// splitCPEFields splits on unescaped colons and drops the escapes of the
+// formatted string binding.
+func splitCPEFields(value string) []string {
+	fields := make([]string, 0, 11)
+	var field strings.Builder
+	for index := 0; index < len(value); index++ {
+		switch value[index] {
+		case '\\':
+			if index+1 < len(value) {
+				index++
+				field.WriteByte(value[index])
+			}
+		case ':':
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteByte(value[index])
+		}
+	}
+	return append(fields, field.String())
+}
+
+func cpeWildcard(value string) bool {
+	return value == "" || value == "*"
+}
+
+// compareVersions orders dotted versions segment by segment: digit runs
+// numerically and letter runs alphabetically. Missing trailing numbers count
+// as zero, so 2.4 equals 2.4.0. A trailing pre-release marker such as rc or
+// beta sorts before the release, while other suffixes, like the p1 of OpenSSH
+// or the k of OpenSSL, sort after it.
+func compareVersions(left, right string) int {
+	leftSegments, rightSegments := versionSegments(left), versionSegments(right)
+	for index := 0; index < len(leftSegments) || index < len(rightSegments); index++ {
+		if index >= len(leftSegments) {
+			return -versionTailOrder(rightSegments[index:])
+		}
+		if index >= len(rightSegments) {
+			return versionTailOrder(leftSegments[index:])
+		}
+		if order := compareVersionSegment(leftSegments[index], rightSegments[index]); order != 0 {
+			return order
+		}
+	}
+	return 0
+}
+
+func versionSegments(version string) []string {
+	version = strings.ToLower(strings.TrimSpace(version))
+	segments := make([]string, 0, 4)
+	start := -1
+	kind := 0
+	for index, char := range version {
+		current := 0
+		switch {
+		case char >= '0' && char <= '9':
+			current = 1
+		case char >= 'a' && char <= 'z':
+			current = 2
+		}
+		if current != kind && start >= 0 {
+			segments = append(segments, version[start:index])
+			start = -1
+		}
+		if current != 0 && start < 0 {
+			start = index
+		}
+		kind = current
+	}
+	if start >= 0 {
+		segments = append(segments, version[start:])
+	}
+	return segments
+}
+
+func compareVersionSegment(left, right string) int {
+	leftNumeric, rightNumeric := isDigits(left), isDigits(right)
+	switch {
+	case leftNumeric && rightNumeric:
+		left, right = strings.TrimLeft(left, "0"), strings.TrimLeft(right, "0")
+		if len(left) != len(right) {
+			if len(left) < len(right) {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(left, right)
+	case leftNumeric:
+		return 1
+	case rightNumeric:
+		return -1
+	}
+	return strings.Compare(left, right)
+}
+
+// versionTailOrder is the order of a version that continues with tail
+// against the same version without it. Zero segments are skipped; the first
+// other segment decides.
+func versionTailOrder(tail []string) int {
+	for _, segment := range tail {
+		if isDigits(segment) {
+			if strings.Trim(segment, "0") == "" {
+				continue
+			}
+			return 1
+		}
+		switch segment {
+		case "a", "alpha", "b", "beta", "dev", "m", "pre", "preview", "rc", "snapshot":
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
+
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, char := range value {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func loadScanTaskRunPotentialVulnerabilities(db *sql.DB, snapshot *model.ScanTaskRunSnapshot) error {
+	rows, err := db.Query(`
+		SELECT ip, port, protocol, product_key, cpe, version, cve_id, criteria, version_range,
+			cvss_version, cvss_score, severity, known_exploited, kev_date_added
+		FROM scan_task_run_cve_matches
+		WHERE scan_task_run_id = ?
+		ORDER BY ip ASC, port ASC, protocol ASC, product_key ASC, cve_id ASC`, snapshot.RunID)
+	if isMissingCVETable(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var item model.ScanTaskRunPotentialVulnerability
+		if err := rows.Scan(&item.IP, &item.Port, &item.Protocol, &item.ProductKey, &item.CPE, &item.Version, &item.CVEID,
+			&item.Criteria, &item.VersionRange, &item.CVSSVersion, &item.CVSSScore, &item.Severity, &item.KnownExploited, &item.KEVDateAdded); err != nil {
+			return err
+		}
+		snapshot.PotentialVulnerabilities = append(snapshot.PotentialVulnerabilities, item)
+	}
+	return rows.Err()
+}
+
+func isMissingCVETable(err error) bool {
+	if err == nil {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "no such table: cve_") || strings.Contains(message, "no such table: scan_task_run_cve_matches") ||
+		strings.Contains(message, "no such table: asset_fingerprint_conclusions")
+}
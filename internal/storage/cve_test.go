@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"testing"
+
+	"golandproject/yscan/internal/model"
+)
+
+func TestSaveScanTaskRunSnapshotCorrelatesFingerprintCPEsWithImportedCVEs(t *testing.T) {
+	db := openTestDB(t)
+	if err := initSQLiteSchema(db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+	stored, err := ImportCVERecords(db, []model.CVERecord{
+		{ID: "CVE-2021-23017", CVSSVersion: "3.1", CVSSScore: 7.7, CVSSSeverity: "HIGH", CPEMatches: []model.CVECPEMatch{
+			{Criteria: "cpe:2.3:a:f5:nginx:*:*:*:*:*:*:*:*", VersionStartIncluding: "0.6.18", VersionEndExcluding: "1.20.1"},
+		}},
+		{ID: "CVE-2019-20372", CVSSVersion: "3.1", CVSSScore: 5.3, CVSSSeverity: "MEDIUM", CPEMatches: []model.CVECPEMatch{
+			{Criteria: "cpe:2.3:a:f5:nginx:*:*:*:*:*:*:*:*", VersionEndExcluding: "1.17.7"},
+		}},
+		{ID: "CVE-2020-0001", CVSSVersion: "3.1", CVSSScore: 9.8, CVSSSeverity: "CRITICAL", CPEMatches: []model.CVECPEMatch{
+			{Criteria: "cpe:2.3:a:f5:nginx:1.18.0:*:*:*:*:*:*:*"},
+			{Criteria: "cpe:2.3:a:apache:http_server:2.4.49:*:*:*:*:*:*:*"},
+		}},
+	})
+	if err != nil || stored != 4 {
+		t.Fatalf("import CVE records stored=%d err=%v", stored, err)
+	}
+	if err := ReplaceKnownExploitedCVEs(db, []model.KnownExploitedCVE{{CVEID: "CVE-2020-0001", DateAdded: "2021-11-03"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	fingerprintImport, err := ImportFingerprintBatch(db, fingerprintDiffBatchFixture("v1", map[string]model.FingerprintRuleProjection{
+		"nginx-body": fingerprintDiffProjection("nginx", "cpe:2.3:a:f5:nginx", "nginx"),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sourceRuleID, matcherID int64
+	if err := db.QueryRow(`
+		SELECT source_rule.id, matcher.id FROM fingerprint_source_rules AS source_rule
+		JOIN fingerprint_rules AS rule ON rule.fingerprint_source_rule_id = source_rule.id
+		JOIN fingerprint_match_groups AS match_group ON match_group.fingerprint_rule_id = rule.id
+		JOIN fingerprint_matchers AS matcher ON matcher.fingerprint_match_group_id = match_group.id
+		WHERE source_rule.fingerprint_import_id = ?`, fingerprintImport.ID).Scan(&sourceRuleID, &matcherID); err != nil {
+		t.Fatal(err)
+	}
+	task := createScheduledTaskForTest(t, db, "192.168.141.0/24")
+	run := createRunningTaskRun(t, db, task.ID, "2026-08-11T02:00:00Z")
+	match := func(ip, version, cpe string) model.FingerprintRunMatch {
+		return model.FingerprintRunMatch{FingerprintImportID: fingerprintImport.ID, FingerprintSourceRuleID: sourceRuleID, IP: ip, Port: 80, Protocol: "http",
+			Product: "nginx", Version: version, CPE: cpe, EvidenceSummary: "nginx-body", Evidence: fixtureMatchEvidence(matcherID)}
+	}
+	if err := SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{
+		RunID: run.ID,
+		Ports: []model.ScanTaskRunPort{
+			{IP: "192.168.141.10", Port: 80, ServiceType: "http", Product: "nginx"},
+			{IP: "192.168.141.11", Port: 80, ServiceType: "http", Product: "nginx"},
+		},
+		FingerprintMatches: []model.FingerprintRunMatch{
+			match("192.168.141.10", "1.18.0", "cpe:2.3:a:f5:nginx:1.18.0:*:*:*:*:*:*:*"),
+			match("192.168.141.11", "", "cpe:2.3:a:f5:nginx:*:*:*:*:*:*:*:*"),
+		},
+	}); err != nil {
+		t.Fatalf("save snapshot: %v", err)
+	}
+
+	snapshot, err := GetScanTaskRunSnapshot(db, run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := make(map[string]model.ScanTaskRunPotentialVulnerability)
+	for _, item := range snapshot.PotentialVulnerabilities {
+		if item.IP != "192.168.141.10" {
+			t.Fatalf("endpoint without a version must not be correlated: %#v", item)
+		}
+		found[item.CVEID] = item
+	}
+	if len(found) != 2 {
+		t.Fatalf("potential vulnerabilities = %#v", snapshot.PotentialVulnerabilities)
+	}
+	ranged := found["CVE-2021-23017"]
+	if ranged.VersionRange != ">=0.6.18 <1.20.1" || ranged.Severity != "high" || ranged.KnownExploited {
+		t.Fatalf("ranged match = %#v", ranged)
+	}
+	exact := found["CVE-2020-0001"]
+	if exact.VersionRange != "=1.18.0" || !exact.KnownExploited || exact.KEVDateAdded != "2021-11-03" || exact.CVSSScore != 9.8 {
+		t.Fatalf("exact KEV match = %#v", exact)
+	}
+}
+
+func TestCompareVersionsOrdersReleaseSuffixes(t *testing.T) {
+	for _, test := range []struct {
+		left, right string
+		want        int
+	}{
+		{"1.20.1", "1.9.9", 1},
+		{"1.18.0", "1.18", 0},
+		{"2.4", "2.4.0", 0},
+		{"2.4", "2.4.0.1", -1},
+		{"3.0", "3.0.0-rc1", 1},
+		{"2.4.49", "2.4.49", 0},
+		{"7.4p1", "7.4", 1},
+		{"1.1.1k", "1.1.1l", -1},
+		{"3.0.0-rc1", "3.0.0", -1},
+		{"8.9", "8.10", -1},
+	} {
+		if got := compareVersions(test.left, test.right); got != test.want {
+			t.Fatalf("compareVersions(%q, %q) = %d, want %d", test.left, test.right, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golandproject/yscan/internal/model"
+)
+
+var ErrFindingNotFound = errors.New("finding not found")
+
+// GetFindingTriage returns the current triage and history of a finding. A
+// finding that was observed but never triaged is open.
+func GetFindingTriage(db *sql.DB, findingKey string) (model.FindingTriage, error) {
+	findingKey = strings.TrimSpace(findingKey)
+	triage, found, err := readFindingTriage(db, findingKey)
+	if err != nil {
+		return model.FindingTriage{}, err
+	}
+	if !found {
+		observed, err := findingObserved(db, findingKey)
+		if err != nil {
+			return model.FindingTriage{}, err
+		}
+		if !observed {
+			return model.FindingTriage{}, ErrFindingNotFound
+		}
+	}
+	triage.State, triage.RiskAcceptedUntil = effectiveFindingState(triage.State, triage.RiskAcceptedUntil, time.Now().UTC())
+	triage.Events, err = listFindingTriageEvents(db, findingKey)
+	return triage, err
+}
+
+// UpdateFindingTriage applies an operator state, assignee or comment change
+// and records it in the finding's history.
+func UpdateFindingTriage(db *sql.DB, update model.FindingTriageUpdate) (model.FindingTriage, error) {
+	now := time.Now().UTC()
+	update.FindingKey = strings.TrimSpace(update.FindingKey)
+	update.State = strings.ToLower(strings.TrimSpace(update.State))
+	update.Author = strings.TrimSpace(update.Author)
+	update.Comment = strings.TrimSpace(update.Comment)
+	if update.FindingKey == "" {
+		return model.FindingTriage{}, errors.New("finding key is required")
+	}
+	if update.State != "" && !model.ValidFindingState(update.State) {
+		return model.FindingTriage{}, fmt.Errorf("unsupported finding state %q", update.State)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return model.FindingTriage{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	current, found, err := readFindingTriage(tx, update.FindingKey)
+	if err != nil {
+		return model.FindingTriage{}, err
+	}
+	if !found {
+		observed, err := findingObserved(tx, update.FindingKey)
+		if err != nil {
+			return model.FindingTriage{}, err
+		}
+		if !observed {
+			return model.FindingTriage{}, ErrFindingNotFound
+		}
+	}
+	current.State, current.RiskAcceptedUntil = effectiveFindingState(current.State, current.RiskAcceptedUntil, now)
+
+	next := current
+	if update.State != "" {
+		next.State = update.State
+	}
+	if update.Assignee != nil {
+		next.Assignee = strings.TrimSpace(*update.Assignee)
+	}
+	if next.State == model.FindingStateRiskAccepted {
+		if strings.TrimSpace(update.RiskAcceptedUntil) != "" || update.State == model.FindingStateRiskAccepted {
+			until, err := parseRiskAcceptedUntil(update.RiskAcceptedUntil, now)
+			if err != nil {
+				return model.FindingTriage{}, err
+			}
+			next.RiskAcceptedUntil = until
+		}
+	} else {
+		if strings.TrimSpace(update.RiskAcceptedUntil) != "" {
+			return model.FindingTriage{}, errors.New("a risk acceptance date needs the risk_accepted state")
+		}
+		next.RiskAcceptedUntil = ""
+	}
+	changed := next.State != current.State || next.Assignee != current.Assignee || next.RiskAcceptedUntil != current.RiskAcceptedUntil
+	if !changed && update.Comment == "" {
+		return model.FindingTriage{}, errors.New("finding triage update changes nothing")
+	}
+	if changed {
+		if _, err := tx.Exec(`
+			INSERT INTO finding_triage (finding_key, state, assignee, risk_accepted_until)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT(finding_key) DO UPDATE SET
+				state = excluded.state, assignee = excluded.assignee,
+				risk_accepted_until = excluded.risk_accepted_until, updated_at = datetime('now')`,
+			update.FindingKey, next.State, next.Assignee, next.RiskAcceptedUntil); err != nil {
+			return model.FindingTriage{}, err
+		}
+	}
+	event := model.FindingTriageEvent{Kind: model.FindingTriageEventComment, Author: update.Author, Comment: update.Comment}
+	if changed {
+		event.Kind, event.State, event.Assignee = model.FindingTriageEventState, next.State, next.Assignee
+	}
+	if err := insertFindingTriageEvent(tx, update.FindingKey, event); err != nil {
+		return model.FindingTriage{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return model.FindingTriage{}, err
+	}
+	return GetFindingTriage(db, update.FindingKey)
+}
+
+// AddFindingComment appends a comment without changing the triage state.
+func AddFindingComment(db *sql.DB, findingKey, author, comment string) (model.FindingTriage, error) {
+	if strings.TrimSpace(comment) == "" {
+		return model.FindingTriage{}, errors.New("finding comment is required")
+	}
+	return UpdateFindingTriage(db, model.FindingTriageUpdate{FindingKey: findingKey, Author: author, Comment: comment})
+}
+
+// applyFindingTriageTx moves the triage of findings a run observed: a
+// finding marked fixed is reopened, and a lapsed risk acceptance returns to
+// open. Both transitions are recorded against the run.
+func applyFindingTriageTx(tx *sql.Tx, runID int64, findings []model.ScanTaskRunVulnerability) error {
+	now := time.Now().UTC()
+	seen := make(map[string]struct{}, len(findings))
+	for _, finding := range findings {
+		if _, duplicate := seen[finding.FindingKey]; duplicate {
+			continue
+		}
+		seen[finding.FindingKey] = struct{}{}
+		triage, found, err := readFindingTriage(tx, finding.FindingKey)
+		if isMissingFindingTriageTable(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+		var kind, comment string
+		switch effective, _ := effectiveFindingState(triage.State, triage.RiskAcceptedUntil, now); {
+		case triage.State == model.FindingStateFixedPendingVerification:
+			kind, comment = model.FindingTriageEventReopened, "observed again after being marked fixed"
+		case triage.State == model.FindingStateRiskAccepted && effective == model.FindingStateOpen:
+			kind, comment = model.FindingTriageEventExpired, "risk acceptance lapsed on "+triage.RiskAcceptedUntil
+		default:
+			continue
+		}
+		if _, err := tx.Exec(`
+			UPDATE finding_triage SET state = ?, risk_accepted_until = '', updated_at = datetime('now')
+			WHERE finding_key = ?`, model.FindingStateOpen, finding.FindingKey); err != nil {
+			return err
+		}
+		if err := insertFindingTriageEvent(tx, finding.FindingKey, model.FindingTriageEvent{
+			Kind: kind, State: model.FindingStateOpen, Assignee: triage.Assignee, Comment: comment, ScanTaskRunID: runID,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadFindingTriageStates copies the current triage onto the findings of a
+// loaded run.
+func loadFindingTriageStates(db *sql.DB, runID int64, findings []model.ScanTaskRunVulnerability) error {
+	now := time.Now().UTC()
+	for index := range findings {
+		findings[index].State = model.FindingStateOpen
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+	rows, err := db.Query(`
+		SELECT triage.finding_key, triage.state, triage.assignee, triage.risk_accepted_until
+		FROM finding_triage AS triage
+		JOIN scan_task_run_vulnerabilities AS finding ON finding.finding_key = triage.finding_key
+		WHERE finding.scan_task_run_id = ?`, runID)
+	if isMissingFindingTriageTable(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	byKey := make(map[string]model.FindingTriage)
+	for rows.Next() {
+		var triage model.FindingTriage
+		if err := rows.Scan(&triage.FindingKey, &triage.State, &triage.Assignee, &triage.RiskAcceptedUntil); err != nil {
+			return err
+		}
+		byKey[triage.FindingKey] = triage
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for index := range findings {
+		triage, found := byKey[findings[index].FindingKey]
+		if !found {
+			continue
+		}
+		findings[index].State, findings[index].RiskAcceptedUntil = effectiveFindingState(triage.State, triage.RiskAcceptedUntil, now)
+		findings[index].Assignee = triage.Assignee
+	}
+	return nil
+}
+
+// effectiveFindingState treats a risk acceptance past its date as open.
+func effectiveFindingState(state, riskAcceptedUntil string, now time.Time) (string, string) {
+	if state == "" {
+		return model.FindingStateOpen, ""
+	}
+	if state != model.FindingStateRiskAccepted {
+		return state, ""
+	}
+	until, err := time.Parse(time.RFC3339, riskAcceptedUntil)
+	if err != nil || !until.After(now) {
+		return model.FindingStateOpen, ""
+	}
+	return state, riskAcceptedUntil
+}
+
+// parseRiskAcceptedUntil accepts an RFC 3339 time or a calendar date, which
+// lapses at its start in UTC.
+func parseRiskAcceptedUntil(value string, now time.Time) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", errors.New("risk acceptance needs an until date")
+	}
+	until, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		until, err = time.Parse(time.DateOnly, value)
+	}
+	if err != nil {
+		return "", errors.New("risk acceptance until must be an RFC 3339 time or a YYYY-MM-DD date")
+	}
+	if !until.After(now) {
+		return "", errors.New("risk acceptance until must be in the future")
+	}
+	return until.UTC().Format(time.RFC3339), nil
+}
+
+type findingTriageQueryer interface {
+	QueryRow(string, ...interface{}) *sql.Row
+}
+
+func readFindingTriage(queryer findingTriageQueryer, findingKey string) (model.FindingTriage, bool, error) {
+	triage := model.FindingTriage{FindingKey: findingKey, State: model.FindingStateOpen, Events: make([]model.FindingTriageEvent, 0)}
+	err := queryer.QueryRow(`
+		SELECT state, assignee, risk_accepted_until, updated_at FROM finding_triage WHERE finding_key = ?`, findingKey).
+		Scan(&triage.State, &triage.Assignee, &triage.RiskAcceptedUntil, &triage.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return triage, false, nil
+	}
+	if err != nil {
+		return model.FindingTriage{}, false, err
+	}
+	return triage, true, nil
+}
+
+func findingObserved(queryer findingTriageQueryer, findingKey string) (bool, error) {
+	var observed int
+	err := queryer.QueryRow(`SELECT 1 FROM scan_task_run_vulnerabilities WHERE finding_key = ? LIMIT 1`, findingKey).Scan(&observed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func insertFindingTriageEvent(tx *sql.Tx, findingKey string, event model.FindingTriageEvent) error {
+	var runID interface{}
+	if event.ScanTaskRunID > 0 {
+		runID = event.ScanTaskRunID
+	}
+	_, err := tx.Exec(`
+		INSERT INTO finding_triage_events (finding_key, kind, state, assignee, author, comment, scan_task_run_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		findingKey, event.Kind, event.State, event.Assignee, event.Author, event.Comment, runID)
+	return err
+}
+
+func listFindingTriageEvents(db *sql.DB, findingKey string) ([]model.FindingTriageEvent, error) {
+	rows, err := db.Query(`
+		SELECT id, kind, state, assignee, author, comment, COALESCE(scan_task_run_id, 0), created_at
+		FROM finding_triage_events
+		WHERE finding_key = ?
+		ORDER BY id ASC`, findingKey)
+	if isMissingFindingTriageTable(err) {
+		return make([]model.FindingTriageEvent, 0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	events := make([]model.FindingTriageEvent, 0)
+	for rows.Next() {
+		var event model.FindingTriageEvent
+		if err := rows.Scan(&event.ID, &event.Kind, &event.State, &event.Assignee, &event.Author, &event.Comment, &event.ScanTaskRunID, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func isMissingFindingTriageTable(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "no such table: finding_triage")
+}
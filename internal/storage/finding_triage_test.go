@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"golandproject/yscan/internal/model"
+)
+
+func TestFindingTriagePersistsAcrossRunsAndReopensFixedFindings(t *testing.T) {
+	db := openTestDB(t)
+	if err := initSQLiteSchema(db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+	task := createScheduledTaskForTest(t, db, "192.168.142.0/24")
+	finding := model.ScanTaskRunVulnerability{FindingKey: "redis-unauth|192.168.142.10:6379|192.168.142.10|6379", TemplateID: "redis-unauth", Severity: "high", Target: "192.168.142.10:6379", TargetIP: "192.168.142.10", TargetPort: 6379}
+	first := createRunningTaskRun(t, db, task.ID, "2026-08-12T02:00:00Z")
+	if err := SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{RunID: first.ID, Vulnerabilities: []model.ScanTaskRunVulnerability{finding}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := UpdateFindingTriage(db, model.FindingTriageUpdate{FindingKey: "never-observed", State: model.FindingStateConfirmed}); !errors.Is(err, ErrFindingNotFound) {
+		t.Fatalf("triaging an unknown finding err = %v", err)
+	}
+	if _, err := UpdateFindingTriage(db, model.FindingTriageUpdate{FindingKey: finding.FindingKey, State: model.FindingStateRiskAccepted}); err == nil {
+		t.Fatal("risk acceptance without an until date must be rejected")
+	}
+	assignee := "ops"
+	triage, err := UpdateFindingTriage(db, model.FindingTriageUpdate{FindingKey: finding.FindingKey, State: model.FindingStateConfirmed, Assignee: &assignee, Author: "alice", Comment: "reproduced"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if triage.State != model.FindingStateConfirmed || triage.Assignee != "ops" || len(triage.Events) != 1 || triage.Events[0].Comment != "reproduced" {
+		t.Fatalf("confirmed triage = %#v", triage)
+	}
+	if _, err := AddFindingComment(db, finding.FindingKey, "bob", "patch scheduled"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UpdateFindingTriage(db, model.FindingTriageUpdate{FindingKey: finding.FindingKey, State: model.FindingStateFixedPendingVerification}); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := GetScanTaskRunSnapshot(db, first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Vulnerabilities[0].State != model.FindingStateFixedPendingVerification || snapshot.Vulnerabilities[0].Assignee != "ops" {
+		t.Fatalf("loaded finding triage = %#v", snapshot.Vulnerabilities[0])
+	}
+
+	second := createRunningTaskRun(t, db, task.ID, "2026-08-13T02:00:00Z")
+	if err := SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{RunID: second.ID, Vulnerabilities: []model.ScanTaskRunVulnerability{finding}}); err != nil {
+		t.Fatal(err)
+	}
+	triage, err = GetFindingTriage(db, finding.FindingKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := triage.Events[len(triage.Events)-1]
+	if triage.State != model.FindingStateOpen || triage.Assignee != "ops" || len(triage.Events) != 4 ||
+		last.Kind != model.FindingTriageEventReopened || last.ScanTaskRunID != second.ID {
+		t.Fatalf("reopened triage = %#v", triage)
+	}
+}
+
+func TestFindingRiskAcceptanceLapsesToOpen(t *testing.T) {
+	db := openTestDB(t)
+	if err := initSQLiteSchema(db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+	task := createScheduledTaskForTest(t, db, "192.168.143.0/24")
+	finding := model.ScanTaskRunVulnerability{FindingKey: "git-config|http://192.168.143.10/.git/config|192.168.143.10|80", Severity: "medium", Target: "http://192.168.143.10/.git/config"}
+	first := createRunningTaskRun(t, db, task.ID, "2026-08-12T02:00:00Z")
+	if err := SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{RunID: first.ID, Vulnerabilities: []model.ScanTaskRunVulnerability{finding}}); err != nil {
+		t.Fatal(err)
+	}
+	triage, err := UpdateFindingTriage(db, model.FindingTriageUpdate{FindingKey: finding.FindingKey, State: model.FindingStateRiskAccepted, RiskAcceptedUntil: "2099-01-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if triage.State != model.FindingStateRiskAccepted || triage.RiskAcceptedUntil != "2099-01-01T00:00:00Z" {
+		t.Fatalf("accepted triage = %#v", triage)
+	}
+	if _, err := db.Exec(`UPDATE finding_triage SET risk_accepted_until = '2026-01-01T00:00:00Z' WHERE finding_key = ?`, finding.FindingKey); err != nil {
+		t.Fatal(err)
+	}
+	if triage, err = GetFindingTriage(db, finding.FindingKey); err != nil || triage.State != model.FindingStateOpen || triage.RiskAcceptedUntil != "" {
+		t.Fatalf("lapsed triage = %#v err=%v", triage, err)
+	}
+
+	second := createRunningTaskRun(t, db, task.ID, "2026-08-13T02:00:00Z")
+	if err := SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{RunID: second.ID, Vulnerabilities: []model.ScanTaskRunVulnerability{finding}}); err != nil {
+		t.Fatal(err)
+	}
+	triage, err = GetFindingTriage(db, finding.FindingKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := triage.Events[len(triage.Events)-1]; last.Kind != model.FindingTriageEventExpired || last.ScanTaskRunID != second.ID {
+		t.Fatalf("expiry was not recorded: %#v", triage.Events)
+	}
+}
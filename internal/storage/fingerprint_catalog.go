@@ -11,6 +11,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"golandproject/yscan/internal/model"
 )
@@ -633,6 +634,74 @@ func ListFingerprintRunMatches(db *sql.DB, runID int64) ([]map[string]interface{
 	return matches, evidenceRows.Err()
 }
 
+// LoadFingerprintRunMatches returns the stored matches of a run, with their
+// matcher evidence, in the form validation consumes during the run itself.
+func LoadFingerprintRunMatches(db *sql.DB, runID int64) ([]model.FingerprintRunMatch, error) {
+	rows, err := db.Query(`
+		SELECT m.id, m.fingerprint_import_id, m.fingerprint_source_rule_id, source.source_key, COALESCE(r.source_rule_id, ''),
+			m.ip, m.port, m.protocol, m.product_key, m.source_product_name, m.product_role, m.exclusive_group,
+			COALESCE(m.version, ''), COALESCE(m.cpe, ''), m.tags_json, m.is_soft, m.evidence_summary
+		FROM asset_fingerprint_matches m
+		JOIN fingerprint_source_rules r ON r.id = m.fingerprint_source_rule_id
+		JOIN fingerprint_imports fingerprint_import ON fingerprint_import.id = r.fingerprint_import_id
+		JOIN fingerprint_sources source ON source.id = fingerprint_import.fingerprint_source_id
+		WHERE m.scan_task_run_id = ? ORDER BY m.id`, runID)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]model.FingerprintRunMatch, 0)
+	indexByID := make(map[int64]int)
+	for rows.Next() {
+		var match model.FingerprintRunMatch
+		var matchID int64
+		var tagsJSON string
+		var soft int
+		if err := rows.Scan(&matchID, &match.FingerprintImportID, &match.FingerprintSourceRuleID, &match.SourceKey, &match.SourceRuleID,
+			&match.IP, &match.Port, &match.Protocol, &match.Product, &match.SourceProduct, &match.ProductRole, &match.ExclusiveGroup,
+			&match.Version, &match.CPE, &tagsJSON, &soft, &match.EvidenceSummary); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		_ = json.Unmarshal([]byte(tagsJSON), &match.Tags)
+		match.Soft = soft != 0
+		match.Evidence = make([]model.FingerprintMatchEvidence, 0)
+		indexByID[matchID] = len(matches)
+		matches = append(matches, match)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	evidenceRows, err := db.Query(`
+		SELECT evidence.asset_fingerprint_match_id, evidence.fingerprint_matcher_id, evidence.evidence_type,
+			COALESCE(evidence.target, ''), evidence.operator, evidence.observed_sha256,
+			evidence.observed_length, evidence.truncated, evidence.summary
+		FROM asset_fingerprint_match_evidence AS evidence
+		JOIN asset_fingerprint_matches AS match ON match.id = evidence.asset_fingerprint_match_id
+		WHERE match.scan_task_run_id = ?
+		ORDER BY evidence.asset_fingerprint_match_id, evidence.fingerprint_matcher_id`, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer evidenceRows.Close()
+	for evidenceRows.Next() {
+		var matchID int64
+		var evidence model.FingerprintMatchEvidence
+		var truncated int
+		if err := evidenceRows.Scan(&matchID, &evidence.MatcherID, &evidence.EvidenceType, &evidence.Target, &evidence.Operator, &evidence.ObservedSHA256, &evidence.ObservedLength, &truncated, &evidence.Summary); err != nil {
+			return nil, err
+		}
+		evidence.Truncated = truncated != 0
+		if index, ok := indexByID[matchID]; ok {
+			matches[index].Evidence = append(matches[index].Evidence, evidence)
+		}
+	}
+	return matches, evidenceRows.Err()
+}
+
 func ListFingerprintRunConclusions(db *sql.DB, runID int64) ([]map[string]interface{}, error) {
 	rows, err := db.Query(`SELECT ip, port, protocol, product_key, COALESCE(product_role, ''), COALESCE(exclusive_group, ''), COALESCE(version, ''), COALESCE(cpe, ''), tags_json, conclusion_status,
 		product_status, product_source_count, version_status, version_source_count, cpe_status, cpe_source_count, created_at
@@ -891,6 +960,71 @@ func DisableFingerprintTemplateMapping(db *sql.DB, id int64) error {
 	return nil
 }
 
+// ObservedFingerprintProduct is one product identity concluded on at least
+// one endpoint by a past run, grouped by protocol and CPE.
+type ObservedFingerprintProduct struct {
+	ProductKey string
+	Protocol   string
+	CPE        string
+	Endpoints  int
+}
+
+// ListObservedFingerprintProducts groups every non-conflicted conclusion by
+// product, protocol and CPE. An empty productKey lists all products.
+func ListObservedFingerprintProducts(db *sql.DB, productKey string) ([]ObservedFingerprintProduct, error) {
+	productKey = strings.TrimSpace(productKey)
+	rows, err := db.Query(`
+		SELECT product_key, protocol, COALESCE(cpe, ''), COUNT(DISTINCT ip || ':' || port)
+		FROM asset_fingerprint_conclusions
+		WHERE product_status <> 'conflicted' AND (? = '' OR LOWER(product_key) = LOWER(?))
+		GROUP BY product_key, protocol, COALESCE(cpe, '')
+		ORDER BY product_key, protocol, COALESCE(cpe, '')`, productKey, productKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make([]ObservedFingerprintProduct, 0)
+	for rows.Next() {
+		var product ObservedFingerprintProduct
+		if err := rows.Scan(&product.ProductKey, &product.Protocol, &product.CPE, &product.Endpoints); err != nil {
+			return nil, err
+		}
+		result = append(result, product)
+	}
+	return result, rows.Err()
+}
+
+// ListActiveTemplateMappings returns the enabled mappings of the active
+// mapping revision, the set a newly imported revision replaces.
+func ListActiveTemplateMappings(db *sql.DB) ([]model.FingerprintTemplateMapping, error) {
+	rows, err := db.Query(`
+		SELECT m.id, m.template_mapping_import_id, m.product_key, COALESCE(m.source_key, ''), COALESCE(m.source_rule_id, ''),
+			m.template_id, m.template_path, m.template_sha256, m.template_set_revision, m.side_effect,
+			m.review_status, m.enabled, m.created_at, COALESCE(m.disabled_at, '')
+		FROM fingerprint_template_mappings AS m
+		JOIN template_mapping_imports AS mapping_import ON mapping_import.id = m.template_mapping_import_id AND mapping_import.is_active = 1
+		WHERE m.enabled = 1
+		ORDER BY m.id`)
+	if isMissingTemplateMappingTable(err) {
+		return []model.FingerprintTemplateMapping{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make([]model.FingerprintTemplateMapping, 0)
+	for rows.Next() {
+		var mapping model.FingerprintTemplateMapping
+		var enabled int
+		if err := rows.Scan(&mapping.ID, &mapping.TemplateMappingImportID, &mapping.ProductKey, &mapping.SourceKey, &mapping.SourceRuleID, &mapping.TemplateID, &mapping.TemplatePath, &mapping.TemplateSHA256, &mapping.TemplateSetRevision, &mapping.SideEffect, &mapping.ReviewStatus, &enabled, &mapping.CreatedAt, &mapping.DisabledAt); err != nil {
+			return nil, err
+		}
+		mapping.Enabled = enabled != 0
+		result = append(result, mapping)
+	}
+	return result, rows.Err()
+}
+
 // ListApprovedTemplateMappingsForRun returns only active reviewed mappings
 // justified by conclusions from this immutable run and endpoint.
 func ListApprovedTemplateMappingsForRun(db *sql.DB, runID int64, ip string, port int, protocol string) ([]model.FingerprintTemplateMapping, error) {
@@ -1119,6 +1253,8 @@ type fingerprintProductConclusion struct {
 	versions       map[string]map[int64]struct{}
 	cpes           map[string]map[int64]struct{}
 	tags           map[string]struct{}
+	// override is the operator assertion that pinned this product, if any.
+	override *model.FingerprintOverride
 }
 
 func saveFingerprintRunMatchesTx(tx *sql.Tx, runID int64, matches []model.FingerprintRunMatch) error {
@@ -1126,6 +1262,17 @@ func saveFingerprintRunMatchesTx(tx *sql.Tx, runID int64, matches []model.Finger
 		return errors.New("scan task run ID is required")
 	}
 	conclusions := make(map[string]map[string]*fingerprintProductConclusion)
+	overrides, err := activeFingerprintOverrides(tx, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	appliedOverrides := make(map[string]model.ScanTaskRunFingerprintOverride)
+	recordOverride := func(override model.FingerprintOverride, ip string, port int, protocol, product, effect string) {
+		key := fmt.Sprintf("%d\x00%s\x00%d\x00%s\x00%s", override.ID, ip, port, protocol, product)
+		appliedOverrides[key] = model.ScanTaskRunFingerprintOverride{
+			OverrideID: override.ID, Kind: override.Kind, IP: ip, Port: port, Protocol: protocol, ProductKey: product, Effect: effect, Reason: override.Reason,
+		}
+	}
 	for _, match := range matches {
 		match.IP = strings.TrimSpace(match.IP)
 		match.Protocol = strings.TrimSpace(match.Protocol)
@@ -1150,7 +1297,13 @@ func saveFingerprintRunMatchesTx(tx *sql.Tx, runID int64, matches []model.Finger
 			return err
 		}
 		var ruleImportID int64
-		if err := tx.QueryRow(`SELECT fingerprint_import_id FROM fingerprint_source_rules WHERE id = ?`, match.FingerprintSourceRuleID).Scan(&ruleImportID); err != nil {
+		var sourceKey, sourceRuleID string
+		if err := tx.QueryRow(`
+			SELECT source_rule.fingerprint_import_id, source.source_key, COALESCE(source_rule.source_rule_id, '')
+			FROM fingerprint_source_rules AS source_rule
+			JOIN fingerprint_imports AS fingerprint_import ON fingerprint_import.id = source_rule.fingerprint_import_id
+			JOIN fingerprint_sources AS source ON source.id = fingerprint_import.fingerprint_source_id
+			WHERE source_rule.id = ?`, match.FingerprintSourceRuleID).Scan(&ruleImportID, &sourceKey, &sourceRuleID); err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				return fmt.Errorf("fingerprint source rule %d not found", match.FingerprintSourceRuleID)
 			}
@@ -1220,46 +1373,37 @@ func saveFingerprintRunMatchesTx(tx *sql.Tx, runID int64, matches []model.Finger
 		if match.Soft {
 			continue
 		}
+		if override, suppressed := suppressingFingerprintOverride(overrides, match, sourceKey, sourceRuleID); suppressed {
+			recordOverride(override, match.IP, match.Port, match.Protocol, match.Product, model.FingerprintOverrideEffectSuppressed)
+			continue
+		}
 		endpointKey := fmt.Sprintf("%s\x00%d\x00%s", match.IP, match.Port, match.Protocol)
 		if conclusions[endpointKey] == nil {
 			conclusions[endpointKey] = map[string]*fingerprintProductConclusion{}
 		}
-		if conclusions[endpointKey][match.Product] == nil {
-			conclusions[endpointKey][match.Product] = &fingerprintProductConclusion{
-				role: match.ProductRole, exclusiveGroup: match.ExclusiveGroup,
-				sourceImports: map[int64]struct{}{}, versions: map[string]map[int64]struct{}{}, cpes: map[string]map[int64]struct{}{}, tags: map[string]struct{}{},
-			}
-		}
-		conclusion := conclusions[endpointKey][match.Product]
-		conclusion.sourceImports[match.FingerprintImportID] = struct{}{}
-		if match.Version != "" {
-			if conclusion.versions[match.Version] == nil {
-				conclusion.versions[match.Version] = map[int64]struct{}{}
-			}
-			conclusion.versions[match.Version][match.FingerprintImportID] = struct{}{}
-		}
-		if match.CPE != "" {
-			if conclusion.cpes[match.CPE] == nil {
-				conclusion.cpes[match.CPE] = map[int64]struct{}{}
-			}
-			conclusion.cpes[match.CPE][match.FingerprintImportID] = struct{}{}
-		}
-		for _, tag := range match.Tags {
-			conclusion.tags[tag] = struct{}{}
-		}
+		addFingerprintProductConclusion(conclusions[endpointKey], match)
+	}
+	if err := applyFingerprintAssertionsTx(tx, runID, overrides, conclusions, recordOverride); err != nil {
+		return err
 	}
 	for endpointKey, products := range conclusions {
 		parts := strings.Split(endpointKey, "\x00")
 		port, _ := strconv.Atoi(parts[1])
 		for product, conclusion := range products {
-			productStatus := "matched"
-			if fingerprintProductConflicted(product, conclusion, products) {
-				productStatus = "conflicted"
-			} else if len(conclusion.sourceImports) > 1 {
-				productStatus = "corroborated"
-			}
+			productStatus := fingerprintProductStatus(product, conclusion, products)
 			version, versionStatus, versionSources := metadataConclusion(conclusion.versions)
 			cpe, cpeStatus, cpeSources := metadataConclusion(conclusion.cpes)
+			if conclusion.override != nil {
+				// The operator has the last word; detected values stay in
+				// the raw matches for the audit trail.
+				productStatus = "matched"
+				if conclusion.override.Version != "" {
+					version, versionStatus, versionSources = conclusion.override.Version, "matched", 1
+				}
+				if conclusion.override.CPE != "" {
+					cpe, cpeStatus, cpeSources = conclusion.override.CPE, "matched", 1
+				}
+			}
 			tags := make([]string, 0, len(conclusion.tags))
 			for tag := range conclusion.tags {
 				tags = append(tags, tag)
@@ -1287,7 +1431,75 @@ func saveFingerprintRunMatchesTx(tx *sql.Tx, runID int64, matches []model.Finger
 			}
 		}
 	}
-	return nil
+	applied := make([]model.ScanTaskRunFingerprintOverride, 0, len(appliedOverrides))
+	for _, value := range appliedOverrides {
+		applied = append(applied, value)
+	}
+	return saveScanTaskRunFingerprintOverridesTx(tx, runID, applied)
+}
+
+// FingerprintProductStatuses returns, per product key, the status a run would
+// persist for the hard matches of one endpoint. Soft matches are ignored and
+// operator overrides are not applied.
+func FingerprintProductStatuses(matches []model.FingerprintRunMatch) map[string]string {
+	products := make(map[string]*fingerprintProductConclusion)
+	for _, match := range matches {
+		if match.Soft {
+			continue
+		}
+		match.Product = strings.ToLower(strings.TrimSpace(match.Product))
+		match.Version = strings.TrimSpace(match.Version)
+		match.CPE = strings.TrimSpace(match.CPE)
+		match.Tags = normalizedStringSet(match.Tags)
+		if strings.TrimSpace(match.ProductRole) == "" {
+			match.ProductRole, match.ExclusiveGroup = model.FingerprintProductClassification(match.Product, match.Tags)
+		}
+		match.ExclusiveGroup = strings.TrimSpace(match.ExclusiveGroup)
+		addFingerprintProductConclusion(products, match)
+	}
+	statuses := make(map[string]string, len(products))
+	for product, conclusion := range products {
+		statuses[product] = fingerprintProductStatus(product, conclusion, products)
+	}
+	return statuses
+}
+
+// addFingerprintProductConclusion folds one hard match into the products
+// concluded for its endpoint.
+func addFingerprintProductConclusion(products map[string]*fingerprintProductConclusion, match model.FingerprintRunMatch) {
+	if products[match.Product] == nil {
+		products[match.Product] = &fingerprintProductConclusion{
+			role: match.ProductRole, exclusiveGroup: match.ExclusiveGroup,
+			sourceImports: map[int64]struct{}{}, versions: map[string]map[int64]struct{}{}, cpes: map[string]map[int64]struct{}{}, tags: map[string]struct{}{},
+		}
+	}
+	conclusion := products[match.Product]
+	conclusion.sourceImports[match.FingerprintImportID] = struct{}{}
+	if match.Version != "" {
+		if conclusion.versions[match.Version] == nil {
+			conclusion.versions[match.Version] = map[int64]struct{}{}
+		}
+		conclusion.versions[match.Version][match.FingerprintImportID] = struct{}{}
+	}
+	if match.CPE != "" {
+		if conclusion.cpes[match.CPE] == nil {
+			conclusion.cpes[match.CPE] = map[int64]struct{}{}
+		}
+		conclusion.cpes[match.CPE][match.FingerprintImportID] = struct{}{}
+	}
+	for _, tag := range match.Tags {
+		conclusion.tags[tag] = struct{}{}
+	}
+}
+
+func fingerprintProductStatus(product string, conclusion *fingerprintProductConclusion, products map[string]*fingerprintProductConclusion) string {
+	if fingerprintProductConflicted(product, conclusion, products) {
+		return "conflicted"
+	}
+	if len(conclusion.sourceImports) > 1 {
+		return "corroborated"
+	}
+	return "matched"
 }
 
 func fingerprintProductConflicted(product string, conclusion *fingerprintProductConclusion, products map[string]*fingerprintProductConclusion) bool {
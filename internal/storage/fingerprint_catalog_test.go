@@ -2,6 +2,7 @@ package storage
 
 import (
 	"database/sql"
+	"fmt"
 	"regexp"
 	"strings"
 	"testing"
@@ -661,3 +662,137 @@ func fingerprintBatchFixture(commit, archiveSHA, ruleSHA string) FingerprintImpo
 		}},
 	}
 }
+
+func TestFingerprintOverridesShapeLaterRunConclusions(t *testing.T) {
+	db := openTestDB(t)
+	if err := initSQLiteSchema(db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+	first, err := ImportFingerprintBatch(db, fingerprintBatchFixture("source-a", "archive-a", "rule-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondBatch := fingerprintBatchFixture("source-b", "archive-b", "rule-b")
+	secondBatch.Source.SourceKey = "fixture-source-b"
+	secondBatch.Import.ManifestJSON = `{"source_key":"fixture-source-b"}`
+	secondBatch.Rules[0].SourceRuleID = "weblogic-hint"
+	second, err := ImportFingerprintBatch(db, secondBatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var firstRule, secondRule, firstMatcher, secondMatcher int64
+	for _, target := range []struct {
+		importID      int64
+		rule, matcher *int64
+	}{{first.ID, &firstRule, &firstMatcher}, {second.ID, &secondRule, &secondMatcher}} {
+		if err := db.QueryRow(`
+			SELECT source_rule.id, matcher.id FROM fingerprint_source_rules AS source_rule
+			JOIN fingerprint_rules AS rule ON rule.fingerprint_source_rule_id = source_rule.id
+			JOIN fingerprint_match_groups AS match_group ON match_group.fingerprint_rule_id = rule.id
+			JOIN fingerprint_matchers AS matcher ON matcher.fingerprint_match_group_id = match_group.id
+			WHERE source_rule.fingerprint_import_id = ?`, target.importID).Scan(target.rule, target.matcher); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, override := range []model.FingerprintOverride{
+		{Kind: model.FingerprintOverrideSuppress, ProductKey: "Apache", IP: "192.168.122.11", Reason: "reverse proxy error page"},
+		{Kind: model.FingerprintOverrideSuppress, SourceKey: "fixture-source-b", SourceRuleID: "weblogic-hint", Reason: "rule fires on our portal"},
+		{Kind: model.FingerprintOverrideAssert, IP: "192.168.122.13", Port: 80, ProductKey: "caddy", Version: "2.7", Reason: "managed by platform team"},
+		{Kind: model.FingerprintOverrideAssert, IP: "192.168.122.20", Port: 8080, ProductKey: "acme portal", Version: "2", Reason: "internal app"},
+		{Kind: model.FingerprintOverrideAssert, IP: "192.168.122.21", Port: 8080, ProductKey: "acme portal", Reason: "endpoint not scanned"},
+	} {
+		if _, err := CreateFingerprintOverride(db, override); err != nil {
+			t.Fatalf("create override %#v: %v", override, err)
+		}
+	}
+	revoked, err := CreateFingerprintOverride(db, model.FingerprintOverride{Kind: model.FingerprintOverrideSuppress, ProductKey: "nginx", Reason: "mistake", ExpiresAt: "2999-01-01T00:00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RevokeFingerprintOverride(db, revoked.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RevokeFingerprintOverride(db, revoked.ID); err == nil {
+		t.Fatal("second revocation succeeded")
+	}
+	for _, invalid := range []model.FingerprintOverride{
+		{Kind: model.FingerprintOverrideSuppress, Reason: "no target"},
+		{Kind: model.FingerprintOverrideAssert, ProductKey: "caddy", Reason: "no endpoint"},
+		{Kind: model.FingerprintOverrideSuppress, ProductKey: "nginx"},
+		{Kind: model.FingerprintOverrideSuppress, ProductKey: "nginx", Reason: "past", ExpiresAt: "2000-01-01T00:00:00Z"},
+	} {
+		if _, err := CreateFingerprintOverride(db, invalid); err == nil {
+			t.Fatalf("invalid override accepted: %#v", invalid)
+		}
+	}
+	if active, err := ListFingerprintOverrides(db, false); err != nil || len(active) != 5 {
+		t.Fatalf("active overrides = %#v err=%v", active, err)
+	}
+
+	task := createScheduledTaskForTest(t, db, "192.168.122.0/24")
+	run, err := CreateScanTaskRun(db, model.ScanTaskRun{ScanTaskID: task.ID, ScheduledFor: "2026-07-30T04:00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO scan_task_run_ports (scan_task_run_id, ip, port, service_type) VALUES (?, '192.168.122.20', 8080, 'http')`, run.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveFingerprintRunMatches(db, run.ID, []FingerprintRunMatch{
+		{FingerprintImportID: first.ID, FingerprintSourceRuleID: firstRule, IP: "192.168.122.11", Port: 8443, Protocol: "https", Product: "nginx", EvidenceSummary: "header: server", Evidence: fixtureMatchEvidence(firstMatcher)},
+		{FingerprintImportID: first.ID, FingerprintSourceRuleID: firstRule, IP: "192.168.122.11", Port: 8443, Protocol: "https", Product: "apache", EvidenceSummary: "body: apache", Evidence: fixtureMatchEvidence(firstMatcher)},
+		{FingerprintImportID: second.ID, FingerprintSourceRuleID: secondRule, IP: "192.168.122.12", Port: 7001, Protocol: "http", Product: "weblogic", EvidenceSummary: "body: weblogic", Evidence: fixtureMatchEvidence(secondMatcher)},
+		{FingerprintImportID: first.ID, FingerprintSourceRuleID: firstRule, IP: "192.168.122.13", Port: 80, Protocol: "http", Product: "nginx", Version: "1.25", EvidenceSummary: "header: nginx", Evidence: fixtureMatchEvidence(firstMatcher)},
+	}); err != nil {
+		t.Fatalf("save matches: %v", err)
+	}
+	conclusions, err := ListFingerprintRunConclusions(db, run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, conclusion := range conclusions {
+		got[fmt.Sprintf("%s:%d/%s", conclusion["ip"], conclusion["port"], conclusion["product_key"])] = fmt.Sprintf("%s %s", conclusion["product_status"], conclusion["version"])
+	}
+	want := map[string]string{
+		"192.168.122.11:8443/nginx":       "matched ",
+		"192.168.122.13:80/caddy":         "matched 2.7",
+		"192.168.122.20:8080/acme portal": "matched 2",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("conclusions = %#v, want %#v", got, want)
+	}
+	var rawMatches int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM asset_fingerprint_matches WHERE scan_task_run_id = ?`, run.ID).Scan(&rawMatches); err != nil || rawMatches != 4 {
+		t.Fatalf("raw matches = %d err=%v", rawMatches, err)
+	}
+	applied, err := ListScanTaskRunFingerprintOverrides(db, run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	effects := map[string]string{}
+	for _, value := range applied {
+		effects[fmt.Sprintf("%s:%d/%s", value.IP, value.Port, value.ProductKey)] = value.Effect
+	}
+	if len(applied) != 5 || effects["192.168.122.11:8443/apache"] != "suppressed" || effects["192.168.122.12:7001/weblogic"] != "suppressed" ||
+		effects["192.168.122.13:80/nginx"] != "superseded" || effects["192.168.122.13:80/caddy"] != "asserted" || effects["192.168.122.20:8080/acme portal"] != "asserted" {
+		t.Fatalf("applied overrides = %#v", applied)
+	}
+}
+
+func TestFingerprintSuppressionHonoursProtocol(t *testing.T) {
+	match := model.FingerprintRunMatch{IP: "192.168.122.30", Port: 161, Protocol: "tcp", Product: "net-snmp"}
+	udp := model.FingerprintOverride{ID: 1, Kind: model.FingerprintOverrideSuppress, ProductKey: "net-snmp", IP: "192.168.122.30", Port: 161, Protocol: "udp"}
+	if _, suppressed := suppressingFingerprintOverride([]model.FingerprintOverride{udp}, match, "", ""); suppressed {
+		t.Fatal("udp suppression hid the tcp product on the same port")
+	}
+	match.Protocol = "udp"
+	if override, suppressed := suppressingFingerprintOverride([]model.FingerprintOverride{udp}, match, "", ""); !suppressed || override.ID != 1 {
+		t.Fatalf("udp suppression did not hide the udp product: %#v %v", override, suppressed)
+	}
+	anyProtocol := udp
+	anyProtocol.Protocol = ""
+	match.Protocol = "tcp"
+	if _, suppressed := suppressingFingerprintOverride([]model.FingerprintOverride{anyProtocol}, match, "", ""); !suppressed {
+		t.Fatal("suppression without a protocol must cover every protocol")
+	}
+}
@@ -0,0 +1,358 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golandproject/yscan/internal/model"
+)
+
+// ErrFingerprintImportSourceMismatch rejects a diff between revisions of two
+// different sources.
+var ErrFingerprintImportSourceMismatch = errors.New("fingerprint imports belong to different sources")
+
+type fingerprintDiffRule struct {
+	key             string
+	sourcePath      string
+	contentSHA256   string
+	product         string
+	cpe             string
+	versionTemplate string
+	protocol        string
+	softMatch       bool
+	status          string
+	matchers        []string
+}
+
+type fingerprintDiffSnapshot struct {
+	rules map[string]*fingerprintDiffRule
+	// keys maps fingerprint_source_rules.id to the stable rule key so stored
+	// run matches can be replayed against the other revision.
+	keys map[int64]string
+}
+
+// DiffFingerprintImports lists rules added, removed and modified between two
+// revisions of the same source. With impact set it also previews how the
+// last successful run that froze fromID would change under toID.
+func DiffFingerprintImports(db *sql.DB, fromID, toID int64, impact bool) (model.FingerprintImportDiff, error) {
+	from, err := GetFingerprintImport(db, fromID)
+	if err != nil {
+		return model.FingerprintImportDiff{}, err
+	}
+	to, err := GetFingerprintImport(db, toID)
+	if err != nil {
+		return model.FingerprintImportDiff{}, err
+	}
+	if from.FingerprintSourceID != to.FingerprintSourceID {
+		return model.FingerprintImportDiff{}, fmt.Errorf("%w: %d and %d", ErrFingerprintImportSourceMismatch, fromID, toID)
+	}
+	source, err := GetFingerprintSource(db, from.FingerprintSourceID)
+	if err != nil {
+		return model.FingerprintImportDiff{}, err
+	}
+	before, err := loadFingerprintDiffSnapshot(db, fromID)
+	if err != nil {
+		return model.FingerprintImportDiff{}, err
+	}
+	after, err := loadFingerprintDiffSnapshot(db, toID)
+	if err != nil {
+		return model.FingerprintImportDiff{}, err
+	}
+
+	diff := model.FingerprintImportDiff{
+		FromImportID: fromID, ToImportID: toID, SourceKey: source.SourceKey,
+		Added: make([]model.FingerprintRuleDiff, 0), Removed: make([]model.FingerprintRuleDiff, 0), Modified: make([]model.FingerprintRuleDiff, 0),
+	}
+	for key, old := range before.rules {
+		current, ok := after.rules[key]
+		if !ok {
+			diff.Removed = append(diff.Removed, model.FingerprintRuleDiff{RuleKey: key, SourcePath: old.sourcePath, FromProduct: old.product, FromCPE: old.cpe, FromStatus: old.status})
+			continue
+		}
+		if change, modified := fingerprintRuleChange(old, current); modified {
+			diff.Modified = append(diff.Modified, change)
+		} else {
+			diff.Unchanged++
+		}
+	}
+	for key, current := range after.rules {
+		if _, ok := before.rules[key]; !ok {
+			diff.Added = append(diff.Added, model.FingerprintRuleDiff{RuleKey: key, SourcePath: current.sourcePath, ToProduct: current.product, ToCPE: current.cpe, ToStatus: current.status})
+		}
+	}
+	for _, items := range [][]model.FingerprintRuleDiff{diff.Added, diff.Removed, diff.Modified} {
+		sort.Slice(items, func(i, j int) bool { return items[i].RuleKey < items[j].RuleKey })
+	}
+	if impact {
+		preview, err := previewFingerprintDiffImpact(db, fromID, before, after)
+		if err != nil {
+			return model.FingerprintImportDiff{}, err
+		}
+		diff.Impact = &preview
+	}
+	return diff, nil
+}
+
+func loadFingerprintDiffSnapshot(db *sql.DB, importID int64) (fingerprintDiffSnapshot, error) {
+	snapshot := fingerprintDiffSnapshot{rules: make(map[string]*fingerprintDiffRule), keys: make(map[int64]string)}
+	rows, err := db.Query(`
+		SELECT source_rule.id, COALESCE(source_rule.source_rule_id, ''), source_rule.source_path, source_rule.content_sha256, source_rule.import_status,
+			COALESCE(rule.id, 0), COALESCE(product.canonical_name, ''), COALESCE(rule.cpe, ''), COALESCE(rule.version_template, ''),
+			COALESCE(rule.protocol, ''), COALESCE(rule.soft_match, 0), COALESCE(rule.status, '')
+		FROM fingerprint_source_rules AS source_rule
+		LEFT JOIN fingerprint_rules AS rule ON rule.fingerprint_source_rule_id = source_rule.id
+		LEFT JOIN fingerprint_products AS product ON product.id = rule.fingerprint_product_id
+		WHERE source_rule.fingerprint_import_id = ?
+		ORDER BY source_rule.id`, importID)
+	if err != nil {
+		return fingerprintDiffSnapshot{}, err
+	}
+	byRuleID := make(map[int64]*fingerprintDiffRule)
+	for rows.Next() {
+		var sourceRuleID, ruleID int64
+		var stableID, importStatus, ruleStatus string
+		var softMatch int
+		rule := &fingerprintDiffRule{}
+		if err := rows.Scan(&sourceRuleID, &stableID, &rule.sourcePath, &rule.contentSHA256, &importStatus, &ruleID, &rule.product, &rule.cpe, &rule.versionTemplate, &rule.protocol, &softMatch, &ruleStatus); err != nil {
+			rows.Close()
+			return fingerprintDiffSnapshot{}, err
+		}
+		rule.softMatch = softMatch == 1
+		rule.status = ruleStatus
+		if rule.status == "" {
+			rule.status = importStatus
+		}
+		// Upstream rule IDs are not guaranteed unique within a revision;
+		// duplicates keep their import order as a suffix.
+		base := strings.TrimSpace(stableID)
+		if base == "" {
+			base = rule.sourcePath
+		}
+		rule.key = base
+		for index := 2; snapshot.rules[rule.key] != nil; index++ {
+			rule.key = base + "#" + strconv.Itoa(index)
+		}
+		snapshot.rules[rule.key] = rule
+		snapshot.keys[sourceRuleID] = rule.key
+		if ruleID > 0 {
+			byRuleID[ruleID] = rule
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return fingerprintDiffSnapshot{}, err
+	}
+
+	matchers, err := db.Query(`
+		SELECT match_group.fingerprint_rule_id, match_group.operator, matcher.evidence_type, COALESCE(matcher.target, ''), matcher.operator, matcher.value, COALESCE(matcher.version_capture, '')
+		FROM fingerprint_matchers AS matcher
+		JOIN fingerprint_match_groups AS match_group ON match_group.id = matcher.fingerprint_match_group_id
+		JOIN fingerprint_rules AS rule ON rule.id = match_group.fingerprint_rule_id
+		JOIN fingerprint_source_rules AS source_rule ON source_rule.id = rule.fingerprint_source_rule_id
+		WHERE source_rule.fingerprint_import_id = ?`, importID)
+	if err != nil {
+		return fingerprintDiffSnapshot{}, err
+	}
+	defer matchers.Close()
+	for matchers.Next() {
+		var ruleID int64
+		var groupOperator, evidenceType, target, operator, value, capture string
+		if err := matchers.Scan(&ruleID, &groupOperator, &evidenceType, &target, &operator, &value, &capture); err != nil {
+			return fingerprintDiffSnapshot{}, err
+		}
+		rule := byRuleID[ruleID]
+		if rule == nil {
+			continue
+		}
+		description := evidenceType
+		if target != "" {
+			description += "[" + target + "]"
+		}
+		description = fmt.Sprintf("%s: %s %s %q", groupOperator, description, operator, value)
+		if capture != "" {
+			description += " version=" + capture
+		}
+		rule.matchers = append(rule.matchers, description)
+	}
+	if err := matchers.Err(); err != nil {
+		return fingerprintDiffSnapshot{}, err
+	}
+	for _, rule := range snapshot.rules {
+		sort.Strings(rule.matchers)
+	}
+	return snapshot, nil
+}
+
+func fingerprintRuleChange(old, current *fingerprintDiffRule) (model.FingerprintRuleDiff, bool) {
+	change := model.FingerprintRuleDiff{
+		RuleKey: current.key, SourcePath: current.sourcePath,
+		FromProduct: old.product, ToProduct: current.product, FromCPE: old.cpe, ToCPE: current.cpe, FromStatus: old.status, ToStatus: current.status,
+	}
+	for _, field := range []struct {
+		name     string
+		modified bool
+	}{
+		{"product", old.product != current.product},
+		{"cpe", old.cpe != current.cpe},
+		{"version", old.versionTemplate != current.versionTemplate},
+		{"protocol", old.protocol != current.protocol},
+		{"soft_match", old.softMatch != current.softMatch},
+		{"status", old.status != current.status},
+	} {
+		if field.modified {
+			change.Changes = append(change.Changes, field.name)
+		}
+	}
+	change.AddedMatchers = stringsMissingFrom(current.matchers, old.matchers)
+	change.RemovedMatchers = stringsMissingFrom(old.matchers, current.matchers)
+	if len(change.AddedMatchers) > 0 || len(change.RemovedMatchers) > 0 {
+		change.Changes = append(change.Changes, "matchers")
+	}
+	if len(change.Changes) == 0 && old.contentSHA256 != current.contentSHA256 {
+		change.Changes = append(change.Changes, "content")
+	}
+	return change, len(change.Changes) > 0
+}
+
+// stringsMissingFrom returns the values of left absent from right, counting
+// duplicates so a repeated matcher is reported once per extra copy.
+func stringsMissingFrom(left, right []string) []string {
+	remaining := make(map[string]int, len(right))
+	for _, value := range right {
+		remaining[value]++
+	}
+	var missing []string
+	for _, value := range left {
+		if remaining[value] > 0 {
+			remaining[value]--
+			continue
+		}
+		missing = append(missing, value)
+	}
+	return missing
+}
+
+func previewFingerprintDiffImpact(db *sql.DB, fromID int64, before, after fingerprintDiffSnapshot) (model.FingerprintDiffImpact, error) {
+	preview := model.FingerprintDiffImpact{Mode: model.FingerprintDiffImpactLossOnly, Endpoints: make([]model.FingerprintDiffEndpointImpact, 0)}
+	err := db.QueryRow(`
+		SELECT run.id FROM scan_task_runs AS run
+		JOIN scan_task_run_fingerprint_imports AS run_import ON run_import.scan_task_run_id = run.id
+		WHERE run_import.fingerprint_import_id = ? AND run.status = ?
+		ORDER BY COALESCE(run.finished_at, run.created_at) DESC, run.id DESC LIMIT 1`, fromID, model.ScanTaskRunStatusSuccess).Scan(&preview.RunID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return preview, nil
+	}
+	if err != nil {
+		return model.FingerprintDiffImpact{}, err
+	}
+
+	type endpointState struct {
+		impact     model.FingerprintDiffEndpointImpact
+		concluded  map[string]bool
+		supporters map[string]int
+		remaining  map[string]int
+		review     map[string]bool
+	}
+	endpoints := make(map[string]*endpointState)
+	endpoint := func(ip string, port int, protocol string) *endpointState {
+		key := ip + "\x00" + strconv.Itoa(port) + "\x00" + protocol
+		if endpoints[key] == nil {
+			endpoints[key] = &endpointState{
+				impact:    model.FingerprintDiffEndpointImpact{IP: ip, Port: port, Protocol: protocol},
+				concluded: make(map[string]bool), supporters: make(map[string]int), remaining: make(map[string]int), review: make(map[string]bool),
+			}
+		}
+		return endpoints[key]
+	}
+
+	conclusions, err := db.Query(`SELECT ip, port, protocol, product_key FROM asset_fingerprint_conclusions WHERE scan_task_run_id = ?`, preview.RunID)
+	if err != nil {
+		return model.FingerprintDiffImpact{}, err
+	}
+	for conclusions.Next() {
+		var ip, protocol, product string
+		var port int
+		if err := conclusions.Scan(&ip, &port, &protocol, &product); err != nil {
+			conclusions.Close()
+			return model.FingerprintDiffImpact{}, err
+		}
+		endpoint(ip, port, protocol).concluded[product] = true
+	}
+	if err := conclusions.Close(); err != nil {
+		return model.FingerprintDiffImpact{}, err
+	}
+
+	matches, err := db.Query(`
+		SELECT ip, port, protocol, product_key, fingerprint_import_id, fingerprint_source_rule_id
+		FROM asset_fingerprint_matches WHERE scan_task_run_id = ? AND is_soft = 0`, preview.RunID)
+	if err != nil {
+		return model.FingerprintDiffImpact{}, err
+	}
+	defer matches.Close()
+	for matches.Next() {
+		var ip, protocol, product string
+		var port int
+		var importID, sourceRuleID int64
+		if err := matches.Scan(&ip, &port, &protocol, &product, &importID, &sourceRuleID); err != nil {
+			return model.FingerprintDiffImpact{}, err
+		}
+		state := endpoint(ip, port, protocol)
+		state.supporters[product]++
+		if importID != fromID {
+			state.remaining[product]++
+			continue
+		}
+		old := before.rules[before.keys[sourceRuleID]]
+		current := after.rules[before.keys[sourceRuleID]]
+		if old == nil || current == nil || current.status != "executable" || current.softMatch {
+			continue
+		}
+		if current.product != old.product {
+			product = current.product
+		}
+		state.remaining[product]++
+		if len(stringsMissingFrom(current.matchers, old.matchers)) > 0 || len(stringsMissingFrom(old.matchers, current.matchers)) > 0 {
+			state.review[product] = true
+		}
+	}
+	if err := matches.Err(); err != nil {
+		return model.FingerprintDiffImpact{}, err
+	}
+
+	for _, state := range endpoints {
+		for product := range state.concluded {
+			if state.supporters[product] > 0 && state.remaining[product] == 0 {
+				state.impact.Lost = append(state.impact.Lost, product)
+			}
+		}
+		for product := range state.remaining {
+			if !state.concluded[product] && state.supporters[product] == 0 {
+				state.impact.Gained = append(state.impact.Gained, product)
+			}
+		}
+		for product := range state.review {
+			state.impact.Review = append(state.impact.Review, product)
+		}
+		if len(state.impact.Lost)+len(state.impact.Gained)+len(state.impact.Review) == 0 {
+			continue
+		}
+		sort.Strings(state.impact.Lost)
+		sort.Strings(state.impact.Gained)
+		sort.Strings(state.impact.Review)
+		preview.Endpoints = append(preview.Endpoints, state.impact)
+	}
+	sort.Slice(preview.Endpoints, func(i, j int) bool {
+		left, right := preview.Endpoints[i], preview.Endpoints[j]
+		if left.IP != right.IP {
+			return left.IP < right.IP
+		}
+		if left.Port != right.Port {
+			return left.Port < right.Port
+		}
+		return left.Protocol < right.Protocol
+	})
+	return preview, nil
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"testing"
+
+	"golandproject/yscan/internal/model"
+)
+
+func fingerprintDiffBatchFixture(commit string, rules map[string]model.FingerprintRuleProjection) FingerprintImportBatch {
+	batch := fingerprintBatchFixture(commit, "archive-"+commit, "")
+	batch.Rules, batch.Projections = nil, nil
+	for id, projection := range rules {
+		path := "rules/" + id + ".json"
+		sha := id + "-" + projection.Product.CanonicalName + "-" + projection.Root.Matchers[0].Value
+		batch.Rules = append(batch.Rules, model.FingerprintSourceRule{SourceRuleID: id, SourcePath: path, ContentSHA256: sha, RawContent: sha, ImportStatus: "executable"})
+		projection.SourcePath, projection.ContentSHA256, projection.Protocol = path, sha, "http"
+		batch.Projections = append(batch.Projections, projection)
+	}
+	batch.Import.RuleTotal, batch.Import.ExecutableTotal = len(rules), len(rules)
+	return batch
+}
+
+func fingerprintDiffProjection(product, cpe, value string) model.FingerprintRuleProjection {
+	return model.FingerprintRuleProjection{
+		Product: model.FingerprintProduct{CanonicalName: product}, CPE: cpe,
+		Root: model.FingerprintMatchGroupProjection{Operator: "all", Matchers: []model.FingerprintMatcher{{EvidenceType: "http_body", Target: "body", Operator: "contains", Value: value}}},
+	}
+}
+
+func TestDiffFingerprintImportsReportsRuleChangesAndRunImpact(t *testing.T) {
+	db := openTestDB(t)
+	if err := initSQLiteSchema(db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+	older, err := ImportFingerprintBatch(db, fingerprintDiffBatchFixture("v1", map[string]model.FingerprintRuleProjection{
+		"nginx-header":  fingerprintDiffProjection("nginx", "", "nginx"),
+		"apache-body":   fingerprintDiffProjection("apache", "", "Apache"),
+		"php-generator": fingerprintDiffProjection("php", "", "PHP"),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newer, err := ImportFingerprintBatch(db, fingerprintDiffBatchFixture("v2", map[string]model.FingerprintRuleProjection{
+		"nginx-header": fingerprintDiffProjection("nginx", "", "nginx"),
+		"apache-body":  fingerprintDiffProjection("apache httpd", "cpe:2.3:a:apache:http_server", "Apache/"),
+		"tomcat-page":  fingerprintDiffProjection("tomcat", "", "Tomcat"),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task := createScheduledTaskForTest(t, db, "192.168.122.0/24")
+	run, err := CreateScanTaskRun(db, model.ScanTaskRun{ScanTaskID: task.ID, ScheduledFor: "2026-07-30T04:00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := AssociateFingerprintImportWithRun(db, run.ID, older.ID); err != nil {
+		t.Fatal(err)
+	}
+	sourceRules := make(map[string][2]int64)
+	rows, err := db.Query(`
+		SELECT source_rule.source_rule_id, source_rule.id, matcher.id FROM fingerprint_source_rules AS source_rule
+		JOIN fingerprint_rules AS rule ON rule.fingerprint_source_rule_id = source_rule.id
+		JOIN fingerprint_match_groups AS match_group ON match_group.fingerprint_rule_id = rule.id
+		JOIN fingerprint_matchers AS matcher ON matcher.fingerprint_match_group_id = match_group.id
+		WHERE source_rule.fingerprint_import_id = ?`, older.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var id string
+		var ids [2]int64
+		if err := rows.Scan(&id, &ids[0], &ids[1]); err != nil {
+			t.Fatal(err)
+		}
+		sourceRules[id] = ids
+	}
+	rows.Close()
+	var matches []FingerprintRunMatch
+	for _, item := range []struct{ ip, rule, product string }{{"192.168.122.10", "nginx-header", "nginx"}, {"192.168.122.11", "apache-body", "apache"}, {"192.168.122.12", "php-generator", "php"}} {
+		ids := sourceRules[item.rule]
+		matches = append(matches, FingerprintRunMatch{FingerprintImportID: older.ID, FingerprintSourceRuleID: ids[0], IP: item.ip, Port: 80, Protocol: "http", Product: item.product, EvidenceSummary: item.rule, Evidence: fixtureMatchEvidence(ids[1])})
+	}
+	if err := SaveFingerprintRunMatches(db, run.ID, matches); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE scan_task_runs SET status = ?, finished_at = datetime('now') WHERE id = ?`, model.ScanTaskRunStatusSuccess, run.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := DiffFingerprintImports(db, older.ID, newer.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.SourceKey != "fixture-source" || diff.Unchanged != 1 || len(diff.Added) != 1 || diff.Added[0].RuleKey != "tomcat-page" || len(diff.Removed) != 1 || diff.Removed[0].FromProduct != "php" {
+		t.Fatalf("diff = %#v", diff)
+	}
+	if len(diff.Modified) != 1 {
+		t.Fatalf("modified = %#v", diff.Modified)
+	}
+	modified := diff.Modified[0]
+	if modified.RuleKey != "apache-body" || modified.ToProduct != "apache httpd" || modified.ToCPE != "cpe:2.3:a:apache:http_server" ||
+		len(modified.AddedMatchers) != 1 || modified.AddedMatchers[0] != `all: http_body[body] contains "Apache/"` || len(modified.RemovedMatchers) != 1 {
+		t.Fatalf("modified rule = %#v", modified)
+	}
+	if diff.Impact == nil || diff.Impact.RunID != run.ID || diff.Impact.Mode != model.FingerprintDiffImpactLossOnly || len(diff.Impact.Endpoints) != 2 {
+		t.Fatalf("impact = %#v", diff.Impact)
+	}
+	renamed, removed := diff.Impact.Endpoints[0], diff.Impact.Endpoints[1]
+	if renamed.IP != "192.168.122.11" || len(renamed.Lost) != 1 || renamed.Lost[0] != "apache" || len(renamed.Gained) != 1 || renamed.Gained[0] != "apache httpd" || len(renamed.Review) != 1 {
+		t.Fatalf("renamed endpoint = %#v", renamed)
+	}
+	if removed.IP != "192.168.122.12" || len(removed.Lost) != 1 || removed.Lost[0] != "php" || len(removed.Gained) != 0 {
+		t.Fatalf("removed endpoint = %#v", removed)
+	}
+
+	reverse, err := DiffFingerprintImports(db, newer.ID, older.ID, true)
+	if err != nil || reverse.Impact == nil || len(reverse.Impact.Endpoints) != 0 || len(reverse.Added) != 1 || reverse.Added[0].RuleKey != "php-generator" {
+		t.Fatalf("reverse diff = %#v err=%v", reverse, err)
+	}
+	if _, err := DiffFingerprintImports(db, older.ID, 999, false); err != ErrFingerprintImportNotFound {
+		t.Fatalf("missing import err = %v", err)
+	}
+}
@@ -0,0 +1,441 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"golandproject/yscan/internal/model"
+)
+
+var ErrFingerprintOverrideNotFound = errors.New("fingerprint override not found")
+
+// CreateFingerprintOverride stores an operator suppression or assertion. It
+// takes effect when the next run builds its conclusions; finished runs keep
+// the conclusions they were written with.
+func CreateFingerprintOverride(db *sql.DB, override model.FingerprintOverride) (model.FingerprintOverride, error) {
+	override, err := normalizedFingerprintOverride(override, time.Now().UTC())
+	if err != nil {
+		return model.FingerprintOverride{}, err
+	}
+	result, err := db.Exec(`
+		INSERT INTO fingerprint_overrides (kind, source_key, source_rule_id, product_key, ip, port, protocol, version, cpe, reason, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		override.Kind, override.SourceKey, override.SourceRuleID, override.ProductKey, override.IP, override.Port, override.Protocol,
+		override.Version, override.CPE, override.Reason, nullIfEmpty(override.ExpiresAt))
+	if err != nil {
+		return model.FingerprintOverride{}, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return model.FingerprintOverride{}, err
+	}
+	return GetFingerprintOverride(db, id)
+}
+
+func normalizedFingerprintOverride(override model.FingerprintOverride, now time.Time) (model.FingerprintOverride, error) {
+	override.Kind = strings.ToLower(strings.TrimSpace(override.Kind))
+	override.SourceKey = strings.TrimSpace(override.SourceKey)
+	override.SourceRuleID = strings.TrimSpace(override.SourceRuleID)
+	override.ProductKey = strings.ToLower(strings.TrimSpace(override.ProductKey))
+	override.IP = strings.TrimSpace(override.IP)
+	override.Protocol = strings.ToLower(strings.TrimSpace(override.Protocol))
+	override.Version = strings.TrimSpace(override.Version)
+	override.CPE = strings.TrimSpace(override.CPE)
+	override.Reason = strings.TrimSpace(override.Reason)
+	override.ExpiresAt = strings.TrimSpace(override.ExpiresAt)
+	if override.Reason == "" {
+		return model.FingerprintOverride{}, errors.New("fingerprint override reason is required")
+	}
+	if override.IP != "" {
+		parsed := net.ParseIP(override.IP)
+		if parsed == nil {
+			return model.FingerprintOverride{}, fmt.Errorf("invalid fingerprint override IP %q", override.IP)
+		}
+		override.IP = parsed.String()
+	}
+	if override.Port < 0 || override.Port > 65535 {
+		return model.FingerprintOverride{}, errors.New("fingerprint override port must be between 0 (any port) and 65535")
+	}
+	if override.ExpiresAt != "" {
+		expires, err := time.Parse(time.RFC3339, override.ExpiresAt)
+		if err != nil {
+			return model.FingerprintOverride{}, errors.New("fingerprint override expiry must be an RFC 3339 time")
+		}
+		if !expires.After(now) {
+			return model.FingerprintOverride{}, errors.New("fingerprint override expiry must be in the future")
+		}
+		override.ExpiresAt = expires.UTC().Format(time.RFC3339)
+	}
+	switch override.Kind {
+	case model.FingerprintOverrideSuppress:
+		if override.SourceRuleID != "" && override.SourceKey == "" {
+			return model.FingerprintOverride{}, errors.New("a suppressed source rule needs its source key")
+		}
+		if override.ProductKey == "" && override.SourceRuleID == "" {
+			return model.FingerprintOverride{}, errors.New("a suppression needs a product or a source rule")
+		}
+		if override.Version != "" || override.CPE != "" {
+			return model.FingerprintOverride{}, errors.New("a suppression takes no version or CPE")
+		}
+	case model.FingerprintOverrideAssert:
+		if override.IP == "" || override.Port == 0 || override.ProductKey == "" {
+			return model.FingerprintOverride{}, errors.New("an assertion needs an IP, port and product")
+		}
+		if override.SourceKey != "" || override.SourceRuleID != "" {
+			return model.FingerprintOverride{}, errors.New("an assertion cannot name a source rule")
+		}
+	default:
+		return model.FingerprintOverride{}, fmt.Errorf("unsupported fingerprint override kind %q", override.Kind)
+	}
+	return override, nil
+}
+
+func GetFingerprintOverride(db *sql.DB, id int64) (model.FingerprintOverride, error) {
+	override, err := scanFingerprintOverride(db.QueryRow(fingerprintOverrideSelect+` WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.FingerprintOverride{}, ErrFingerprintOverrideNotFound
+	}
+	return override, err
+}
+
+// ListFingerprintOverrides returns overrides newest first. Revoked and
+// expired entries are included only on request so the history stays visible.
+func ListFingerprintOverrides(db *sql.DB, includeInactive bool) ([]model.FingerprintOverride, error) {
+	rows, err := db.Query(fingerprintOverrideSelect + ` ORDER BY id DESC`)
+	if isMissingFingerprintOverrideTable(err) {
+		return make([]model.FingerprintOverride, 0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	now := time.Now().UTC()
+	overrides := make([]model.FingerprintOverride, 0)
+	for rows.Next() {
+		override, err := scanFingerprintOverride(rows)
+		if err != nil {
+			return nil, err
+		}
+		if includeInactive || fingerprintOverrideActive(override, now) {
+			overrides = append(overrides, override)
+		}
+	}
+	return overrides, rows.Err()
+}
+
+// RevokeFingerprintOverride stops an override from applying to later runs.
+// Revoking twice is rejected so callers notice a stale identifier.
+func RevokeFingerprintOverride(db *sql.DB, id int64) (model.FingerprintOverride, error) {
+	result, err := db.Exec(`UPDATE fingerprint_overrides SET revoked_at = datetime('now') WHERE id = ? AND revoked_at IS NULL`, id)
+	if err != nil {
+		return model.FingerprintOverride{}, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return model.FingerprintOverride{}, err
+	}
+	if affected == 0 {
+		override, err := GetFingerprintOverride(db, id)
+		if err != nil {
+			return model.FingerprintOverride{}, err
+		}
+		return override, fmt.Errorf("fingerprint override %d is already revoked", override.ID)
+	}
+	return GetFingerprintOverride(db, id)
+}
+
+const fingerprintOverrideSelect = `
+	SELECT id, kind, source_key, source_rule_id, product_key, ip, port, protocol, version, cpe, reason,
+		COALESCE(expires_at, ''), created_at, COALESCE(revoked_at, '')
+	FROM fingerprint_overrides`
+
+type fingerprintOverrideScanner interface {
+	Scan(...interface{}) error
+}
+
+func scanFingerprintOverride(row fingerprintOverrideScanner) (model.FingerprintOverride, error) {
+	var override model.FingerprintOverride
+	err := row.Scan(&override.ID, &override.Kind, &override.SourceKey, &override.SourceRuleID, &override.ProductKey, &override.IP, &override.Port,
+		&override.Protocol, &override.Version, &override.CPE, &override.Reason, &override.ExpiresAt, &override.CreatedAt, &override.RevokedAt)
+	return override, err
+}
+
+func fingerprintOverrideActive(override model.FingerprintOverride, now time.Time) bool {
+	if override.RevokedAt != "" {
+		return false
+	}
+	if override.ExpiresAt == "" {
+		return true
+	}
+	expires, err := time.Parse(time.RFC3339, override.ExpiresAt)
+	return err == nil && expires.After(now)
+}
+
+type fingerprintOverrideQueryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func activeFingerprintOverrides(queryer fingerprintOverrideQueryer, now time.Time) ([]model.FingerprintOverride, error) {
+	rows, err := queryer.Query(fingerprintOverrideSelect + ` WHERE revoked_at IS NULL ORDER BY id`)
+	if isMissingFingerprintOverrideTable(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var overrides []model.FingerprintOverride
+	for rows.Next() {
+		override, err := scanFingerprintOverride(rows)
+		if err != nil {
+			return nil, err
+		}
+		if fingerprintOverrideActive(override, now) {
+			overrides = append(overrides, override)
+		}
+	}
+	return overrides, rows.Err()
+}
+
+func fingerprintOverrideCoversEndpoint(override model.FingerprintOverride, ip string, port int) bool {
+	return (override.IP == "" || override.IP == ip) && (override.Port == 0 || override.Port == port)
+}
+
+// suppressingFingerprintOverride returns the first active suppression for a
+// hard match. A suppression naming both a rule and a product needs both, and
+// one naming a protocol only covers matches on that protocol.
+func suppressingFingerprintOverride(overrides []model.FingerprintOverride, match model.FingerprintRunMatch, sourceKey, sourceRuleID string) (model.FingerprintOverride, bool) {
+	for _, override := range overrides {
+		if override.Kind != model.FingerprintOverrideSuppress || !fingerprintOverrideCoversEndpoint(override, match.IP, match.Port) {
+			continue
+		}
+		if override.Protocol != "" && override.Protocol != strings.ToLower(match.Protocol) {
+			continue
+		}
+		if override.ProductKey != "" && override.ProductKey != match.Product {
+			continue
+		}
+		if override.SourceKey != "" && override.SourceKey != sourceKey {
+			continue
+		}
+		if override.SourceRuleID != "" && override.SourceRuleID != sourceRuleID {
+			continue
+		}
+		return override, true
+	}
+	return model.FingerprintOverride{}, false
+}
+
+// WithoutSuppressedFingerprintMatches drops the hard matches an active
+// suppression covers. Validation selects templates and native checks from
+// the result, so a product the operator ruled out is never tested.
+func WithoutSuppressedFingerprintMatches(db *sql.DB, matches []model.FingerprintRunMatch) ([]model.FingerprintRunMatch, error) {
+	overrides, err := activeFingerprintOverrides(db, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	suppressions := overrides[:0:0]
+	for _, override := range overrides {
+		if override.Kind == model.FingerprintOverrideSuppress {
+			suppressions = append(suppressions, override)
+		}
+	}
+	if len(suppressions) == 0 {
+		return matches, nil
+	}
+	type ruleSource struct{ key, ruleID string }
+	sources := make(map[int64]ruleSource)
+	kept := make([]model.FingerprintRunMatch, 0, len(matches))
+	for _, match := range matches {
+		if match.Soft {
+			kept = append(kept, match)
+			continue
+		}
+		source, known := sources[match.FingerprintSourceRuleID]
+		if !known {
+			err := db.QueryRow(`
+				SELECT source.source_key, COALESCE(source_rule.source_rule_id, '')
+				FROM fingerprint_source_rules AS source_rule
+				JOIN fingerprint_imports AS fingerprint_import ON fingerprint_import.id = source_rule.fingerprint_import_id
+				JOIN fingerprint_sources AS source ON source.id = fingerprint_import.fingerprint_source_id
+				WHERE source_rule.id = ?`, match.FingerprintSourceRuleID).Scan(&source.key, &source.ruleID)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return nil, err
+			}
+			sources[match.FingerprintSourceRuleID] = source
+		}
+		normalized := match
+		normalized.IP = strings.TrimSpace(match.IP)
+		normalized.Product = strings.ToLower(strings.TrimSpace(match.Product))
+		if _, suppressed := suppressingFingerprintOverride(suppressions, normalized, source.key, source.ruleID); suppressed {
+			continue
+		}
+		kept = append(kept, match)
+	}
+	return kept, nil
+}
+
+// applyFingerprintAssertionsTx pins asserted products on endpoints this run
+// observed. The asserted product replaces detected products of its exclusive
+// group, since the operator has said what the endpoint is.
+func applyFingerprintAssertionsTx(tx *sql.Tx, runID int64, overrides []model.FingerprintOverride, conclusions map[string]map[string]*fingerprintProductConclusion, record func(model.FingerprintOverride, string, int, string, string, string)) error {
+	for index := range overrides {
+		override := overrides[index]
+		if override.Kind != model.FingerprintOverrideAssert {
+			continue
+		}
+		endpointPrefix := fmt.Sprintf("%s\x00%d\x00", override.IP, override.Port)
+		endpointKeys := make([]string, 0)
+		for endpointKey := range conclusions {
+			if strings.HasPrefix(endpointKey, endpointPrefix) {
+				endpointKeys = append(endpointKeys, endpointKey)
+			}
+		}
+		sort.Strings(endpointKeys)
+		if len(endpointKeys) == 0 {
+			var observed int
+			err := tx.QueryRow(`SELECT 1 FROM scan_task_run_ports WHERE scan_task_run_id = ? AND ip = ? AND port = ?`, runID, override.IP, override.Port).Scan(&observed)
+			if errors.Is(err, sql.ErrNoRows) || (err != nil && strings.Contains(strings.ToLower(err.Error()), "no such table: scan_task_run_ports")) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+		protocol := override.Protocol
+		if protocol == "" && len(endpointKeys) > 0 {
+			protocol = strings.TrimPrefix(endpointKeys[0], endpointPrefix)
+		}
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		role, exclusiveGroup := model.FingerprintProductClassification(override.ProductKey, nil)
+		for _, endpointKey := range endpointKeys {
+			for product, conclusion := range conclusions[endpointKey] {
+				if product == override.ProductKey || exclusiveGroup == "" || conclusion.exclusiveGroup != exclusiveGroup {
+					continue
+				}
+				delete(conclusions[endpointKey], product)
+				record(override, override.IP, override.Port, strings.TrimPrefix(endpointKey, endpointPrefix), product, model.FingerprintOverrideEffectSuperseded)
+			}
+		}
+		endpointKey := endpointPrefix + protocol
+		if conclusions[endpointKey] == nil {
+			conclusions[endpointKey] = map[string]*fingerprintProductConclusion{}
+		}
+		conclusion := conclusions[endpointKey][override.ProductKey]
+		if conclusion == nil {
+			conclusion = &fingerprintProductConclusion{
+				role: role, exclusiveGroup: exclusiveGroup,
+				sourceImports: map[int64]struct{}{}, versions: map[string]map[int64]struct{}{}, cpes: map[string]map[int64]struct{}{}, tags: map[string]struct{}{},
+			}
+			conclusions[endpointKey][override.ProductKey] = conclusion
+		}
+		conclusion.override = &overrides[index]
+		record(override, override.IP, override.Port, protocol, override.ProductKey, model.FingerprintOverrideEffectAsserted)
+	}
+	return nil
+}
+
+func saveScanTaskRunFingerprintOverridesTx(tx *sql.Tx, runID int64, applied []model.ScanTaskRunFingerprintOverride) error {
+	for _, value := range applied {
+		if _, err := tx.Exec(`
+			INSERT INTO scan_task_run_fingerprint_overrides
+				(scan_task_run_id, fingerprint_override_id, ip, port, protocol, product_key, effect, reason)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(scan_task_run_id, fingerprint_override_id, ip, port, protocol, product_key) DO UPDATE SET
+				effect = excluded.effect, reason = excluded.reason`,
+			runID, value.OverrideID, value.IP, value.Port, value.Protocol, value.ProductKey, value.Effect, value.Reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListScanTaskRunFingerprintOverrides returns the overrides one run applied,
+// as recorded when its conclusions were written.
+func ListScanTaskRunFingerprintOverrides(db *sql.DB, runID int64) ([]model.ScanTaskRunFingerprintOverride, error) {
+	rows, err := db.Query(`
+		SELECT applied.fingerprint_override_id, override.kind, applied.ip, applied.port, applied.protocol,
+			applied.product_key, applied.effect, applied.reason
+		FROM scan_task_run_fingerprint_overrides AS applied
+		JOIN fingerprint_overrides AS override ON override.id = applied.fingerprint_override_id
+		WHERE applied.scan_task_run_id = ?
+		ORDER BY applied.ip, applied.port, applied.protocol, applied.product_key, applied.fingerprint_override_id`, runID)
+	if isMissingFingerprintOverrideTable(err) {
+		return make([]model.ScanTaskRunFingerprintOverride, 0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make([]model.ScanTaskRunFingerprintOverride, 0)
+	for rows.Next() {
+		var value model.ScanTaskRunFingerprintOverride
+		if err := rows.Scan(&value.OverrideID, &value.Kind, &value.IP, &value.Port, &value.Protocol, &value.ProductKey, &value.Effect, &value.Reason); err != nil {
+			return nil, err
+		}
+		applied = append(applied, value)
+	}
+	return applied, rows.Err()
+}
+
+func loadAssetPortOverrideSet(db *sql.DB, ip string, ports []model.AssetPort) error {
+	if len(ports) == 0 {
+		return nil
+	}
+	portIndexes := make(map[int]int, len(ports))
+	for index := range ports {
+		portIndexes[ports[index].Port] = index
+		ports[index].FingerprintOverrides = make([]model.ScanTaskRunFingerprintOverride, 0)
+	}
+	rows, err := db.Query(latestAssetPortRunsCTE+`
+		SELECT applied.fingerprint_override_id, override.kind, applied.port, applied.protocol,
+			applied.product_key, applied.effect, applied.reason
+		FROM scan_task_run_fingerprint_overrides AS applied
+		JOIN latest_port_runs AS latest ON latest.port = applied.port AND latest.scan_task_run_id = applied.scan_task_run_id
+		JOIN fingerprint_overrides AS override ON override.id = applied.fingerprint_override_id
+		WHERE applied.ip = ?
+		ORDER BY applied.port, applied.protocol, applied.product_key, applied.fingerprint_override_id`, ip, ip)
+	if isMissingFingerprintOverrideTable(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		value := model.ScanTaskRunFingerprintOverride{IP: ip}
+		if err := rows.Scan(&value.OverrideID, &value.Kind, &value.Port, &value.Protocol, &value.ProductKey, &value.Effect, &value.Reason); err != nil {
+			return err
+		}
+		index, ok := portIndexes[value.Port]
+		if !ok {
+			continue
+		}
+		ports[index].FingerprintOverrides = append(ports[index].FingerprintOverrides, value)
+		if value.Effect != model.FingerprintOverrideEffectAsserted {
+			continue
+		}
+		for technologyIndex := range ports[index].Technologies {
+			if ports[index].Technologies[technologyIndex].ProductKey == value.ProductKey {
+				ports[index].Technologies[technologyIndex].OverrideID = value.OverrideID
+			}
+		}
+	}
+	return rows.Err()
+}
+
+func isMissingFingerprintOverrideTable(err error) bool {
+	if err == nil {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "no such table: fingerprint_overrides") ||
+		strings.Contains(message, "no such table: scan_task_run_fingerprint_overrides")
+}
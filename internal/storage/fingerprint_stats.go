@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"golandproject/yscan/internal/model"
+)
+
+// FingerprintRuleStatsQuery narrows rule effectiveness statistics to one
+// source; Limit bounds the rule and product lists.
+type FingerprintRuleStatsQuery struct {
+	SourceKey string
+	Limit     int
+}
+
+// fingerprintRuleKeySQL is the stable rule identity across revisions, the
+// same base key fingerprint diffs use.
+const fingerprintRuleKeySQL = `COALESCE(NULLIF(TRIM(source_rule.source_rule_id), ''), source_rule.source_path)`
+
+const fingerprintHitStatsSQL = `
+	SELECT %s,
+		COUNT(*), COUNT(DISTINCT match.ip || ':' || match.port), COALESCE(SUM(match.is_soft), 0),
+		COALESCE(SUM(CASE WHEN conclusion.product_status = 'conflicted' THEN 1 ELSE 0 END), 0),
+		MAX(match.created_at)
+	FROM asset_fingerprint_matches AS match
+	JOIN fingerprint_source_rules AS source_rule ON source_rule.id = match.fingerprint_source_rule_id
+	JOIN fingerprint_imports AS fingerprint_import ON fingerprint_import.id = match.fingerprint_import_id
+	JOIN fingerprint_sources AS source ON source.id = fingerprint_import.fingerprint_source_id
+	LEFT JOIN asset_fingerprint_conclusions AS conclusion
+		ON conclusion.scan_task_run_id = match.scan_task_run_id AND conclusion.ip = match.ip AND conclusion.port = match.port
+		AND conclusion.protocol = match.protocol AND conclusion.product_key = match.product_key
+	WHERE (? = '' OR source.source_key = ?)
+		AND NOT EXISTS (SELECT 1 FROM scan_task_run_derivations AS derivation WHERE derivation.scan_task_run_id = match.scan_task_run_id)
+	GROUP BY %s
+	ORDER BY COUNT(*) DESC, %s`
+
+// FingerprintRuleStatistics computes hit counts, soft share, conflict rate
+// and last match time from the matches of scanned runs; reevaluated and
+// revalidated runs replay a source run's observations and are left out so
+// they do not count the same endpoint twice. Rules that never fired
+// have no match rows, so each source also reports how many executable rules
+// of its active revision have fired at least once.
+func FingerprintRuleStatistics(db *sql.DB, query FingerprintRuleStatsQuery) (model.FingerprintRuleStats, error) {
+	sourceKey := strings.TrimSpace(query.SourceKey)
+	limit := query.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	bySource, err := queryFingerprintHitStats(db, sourceKey, `source.source_key, '', ''`, `source.source_key`, 0)
+	if err != nil {
+		return model.FingerprintRuleStats{}, err
+	}
+	rules, err := queryFingerprintHitStats(db, sourceKey, `source.source_key, `+fingerprintRuleKeySQL+`, MAX(match.product_key)`, `source.source_key, `+fingerprintRuleKeySQL, 0)
+	if err != nil {
+		return model.FingerprintRuleStats{}, err
+	}
+	products, err := queryFingerprintHitStats(db, sourceKey, `'', '', match.product_key`, `match.product_key`, limit)
+	if err != nil {
+		return model.FingerprintRuleStats{}, err
+	}
+
+	fired := make(map[string]map[string]struct{})
+	for _, rule := range rules {
+		if fired[rule.SourceKey] == nil {
+			fired[rule.SourceKey] = make(map[string]struct{})
+		}
+		fired[rule.SourceKey][rule.RuleKey] = struct{}{}
+	}
+	sources, err := fingerprintActiveRuleCoverage(db, sourceKey, fired)
+	if err != nil {
+		return model.FingerprintRuleStats{}, err
+	}
+	hitsBySource := make(map[string]model.FingerprintHitStats, len(bySource))
+	for _, hits := range bySource {
+		hitsBySource[hits.SourceKey] = hits
+	}
+	for index := range sources {
+		if hits, ok := hitsBySource[sources[index].SourceKey]; ok {
+			sources[index].FingerprintHitStats = hits
+		}
+	}
+	if len(rules) > limit {
+		rules = rules[:limit]
+	}
+	return model.FingerprintRuleStats{Sources: sources, Rules: rules, Products: products}, nil
+}
+
+func queryFingerprintHitStats(db *sql.DB, sourceKey, columns, groupBy string, limit int) ([]model.FingerprintHitStats, error) {
+	statement := fmt.Sprintf(fingerprintHitStatsSQL, columns, groupBy, groupBy)
+	args := []interface{}{sourceKey, sourceKey}
+	if limit > 0 {
+		statement += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	rows, err := db.Query(statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	stats := make([]model.FingerprintHitStats, 0)
+	for rows.Next() {
+		var item model.FingerprintHitStats
+		var lastMatched sql.NullString
+		if err := rows.Scan(&item.SourceKey, &item.RuleKey, &item.ProductKey, &item.Hits, &item.Endpoints, &item.SoftHits, &item.ConflictedHits, &lastMatched); err != nil {
+			return nil, err
+		}
+		item.LastMatchedAt = lastMatched.String
+		if item.Hits > 0 {
+			item.SoftShare = float64(item.SoftHits) / float64(item.Hits)
+			item.ConflictRate = float64(item.ConflictedHits) / float64(item.Hits)
+		}
+		stats = append(stats, item)
+	}
+	return stats, rows.Err()
+}
+
+// fingerprintActiveRuleCoverage lists every source with the executable rule
+// count of its active revision and how many of those rule keys have fired in
+// any revision.
+func fingerprintActiveRuleCoverage(db *sql.DB, sourceKey string, fired map[string]map[string]struct{}) ([]model.FingerprintSourceHitStats, error) {
+	rows, err := db.Query(`
+		SELECT source.source_key, COALESCE(active_import.id, 0), `+fingerprintRuleKeySQL+`
+		FROM fingerprint_sources AS source
+		LEFT JOIN fingerprint_imports AS active_import ON active_import.fingerprint_source_id = source.id AND active_import.is_active = 1
+		LEFT JOIN fingerprint_source_rules AS source_rule ON source_rule.fingerprint_import_id = active_import.id AND source_rule.import_status = 'executable'
+		WHERE (? = '' OR source.source_key = ?)
+		ORDER BY source.source_key`, sourceKey, sourceKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	sources := make([]model.FingerprintSourceHitStats, 0)
+	for rows.Next() {
+		var key string
+		var importID int64
+		var ruleKey sql.NullString
+		if err := rows.Scan(&key, &importID, &ruleKey); err != nil {
+			return nil, err
+		}
+		if len(sources) == 0 || sources[len(sources)-1].SourceKey != key {
+			sources = append(sources, model.FingerprintSourceHitStats{FingerprintHitStats: model.FingerprintHitStats{SourceKey: key}, ActiveImportID: importID})
+		}
+		if !ruleKey.Valid {
+			continue
+		}
+		current := &sources[len(sources)-1]
+		current.ExecutableRules++
+		if _, ok := fired[key][ruleKey.String]; ok {
+			current.RulesFired++
+		}
+	}
+	return sources, rows.Err()
+}
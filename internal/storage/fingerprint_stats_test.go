@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"testing"
+
+	"golandproject/yscan/internal/model"
+)
+
+func TestFingerprintRuleStatisticsCountsHitsSoftShareAndConflicts(t *testing.T) {
+	db := openTestDB(t)
+	if err := initSQLiteSchema(db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+	fingerprintImport, err := ImportFingerprintBatch(db, fingerprintDiffBatchFixture("v1", map[string]model.FingerprintRuleProjection{
+		"nginx-header": fingerprintDiffProjection("nginx", "", "nginx"),
+		"apache-body":  fingerprintDiffProjection("apache", "", "Apache"),
+		"php-never":    fingerprintDiffProjection("php", "", "PHP"),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make(map[string][2]int64)
+	rows, err := db.Query(`
+		SELECT source_rule.source_rule_id, source_rule.id, matcher.id FROM fingerprint_source_rules AS source_rule
+		JOIN fingerprint_rules AS rule ON rule.fingerprint_source_rule_id = source_rule.id
+		JOIN fingerprint_match_groups AS match_group ON match_group.fingerprint_rule_id = rule.id
+		JOIN fingerprint_matchers AS matcher ON matcher.fingerprint_match_group_id = match_group.id
+		WHERE source_rule.fingerprint_import_id = ?`, fingerprintImport.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var key string
+		var pair [2]int64
+		if err := rows.Scan(&key, &pair[0], &pair[1]); err != nil {
+			t.Fatal(err)
+		}
+		ids[key] = pair
+	}
+	rows.Close()
+
+	task := createScheduledTaskForTest(t, db, "192.168.140.0/24")
+	run := createRunningTaskRun(t, db, task.ID, "2026-08-10T02:00:00Z")
+	var matches []FingerprintRunMatch
+	for _, item := range []struct {
+		ip, rule, product string
+		soft              bool
+	}{{"192.168.140.10", "nginx-header", "nginx", false}, {"192.168.140.11", "nginx-header", "nginx", true}, {"192.168.140.11", "apache-body", "apache", false}} {
+		pair := ids[item.rule]
+		matches = append(matches, FingerprintRunMatch{FingerprintImportID: fingerprintImport.ID, FingerprintSourceRuleID: pair[0], IP: item.ip, Port: 80, Protocol: "http", Product: item.product, Soft: item.soft, EvidenceSummary: item.rule, Evidence: fixtureMatchEvidence(pair[1])})
+	}
+	if err := SaveFingerprintRunMatches(db, run.ID, matches); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE asset_fingerprint_conclusions SET product_status = 'conflicted' WHERE scan_task_run_id = ? AND ip = '192.168.140.11'`, run.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// A reevaluated run repeats the source's matches and must not count twice.
+	derived := createRunningTaskRun(t, db, task.ID, "2026-08-11T02:00:00Z")
+	if err := SaveFingerprintRunMatches(db, derived.ID, matches); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO scan_task_run_derivations (scan_task_run_id, source_scan_task_run_id) VALUES (?, ?)`, derived.ID, run.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := FingerprintRuleStatistics(db, FingerprintRuleStatsQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var source model.FingerprintSourceHitStats
+	for _, candidate := range stats.Sources {
+		if candidate.SourceKey == "fixture-source" {
+			source = candidate
+		}
+	}
+	if source.SourceKey != "fixture-source" || source.Hits != 3 || source.Endpoints != 2 || source.SoftHits != 1 || source.ExecutableRules != 3 || source.RulesFired != 2 || source.ActiveImportID != fingerprintImport.ID || source.LastMatchedAt == "" {
+		t.Fatalf("source = %#v", source)
+	}
+	if len(stats.Rules) != 2 || stats.Rules[0].RuleKey != "nginx-header" || stats.Rules[0].Hits != 2 || stats.Rules[0].SoftShare != 0.5 || stats.Rules[0].ProductKey != "nginx" {
+		t.Fatalf("rules = %#v", stats.Rules)
+	}
+	if apache := stats.Rules[1]; apache.RuleKey != "apache-body" || apache.ConflictedHits != 1 || apache.ConflictRate != 1 {
+		t.Fatalf("apache rule = %#v", apache)
+	}
+	if len(stats.Products) != 2 || stats.Products[0].ProductKey != "nginx" || stats.Products[1].ConflictedHits != 1 {
+		t.Fatalf("products = %#v", stats.Products)
+	}
+
+	limited, err := FingerprintRuleStatistics(db, FingerprintRuleStatsQuery{SourceKey: "fixture-source", Limit: 1})
+	if err != nil || len(limited.Rules) != 1 || len(limited.Products) != 1 || len(limited.Sources) != 1 || limited.Sources[0].RulesFired != 2 {
+		t.Fatalf("limited stats = %#v err=%v", limited, err)
+	}
+	other, err := FingerprintRuleStatistics(db, FingerprintRuleStatsQuery{SourceKey: "missing"})
+	if err != nil || len(other.Sources) != 0 || len(other.Rules) != 0 {
+		t.Fatalf("other source stats = %#v err=%v", other, err)
+	}
+}
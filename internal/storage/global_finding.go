@@ -0,0 +1,270 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golandproject/yscan/internal/model"
+)
+
+var ErrInvalidGlobalFindingFilter = errors.New("invalid findings filter")
+
+// ListGlobalFindings aggregates the findings of the latest successful run of
+// every scan task that is not archived. The same template on the same
+// endpoint reported by overlapping tasks becomes one entry whose first-seen
+// time is the earliest successful run that ever reported it.
+func ListGlobalFindings(db *sql.DB, filter model.GlobalFindingFilter) ([]model.GlobalFinding, error) {
+	filter, network, err := normalizeGlobalFindingFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	runs, err := latestSuccessfulScanTaskRuns(db)
+	if err != nil {
+		return nil, err
+	}
+	firstSeen, err := globalFindingFirstSeen(db)
+	if err != nil {
+		return nil, err
+	}
+
+	byIdentity := make(map[string]*model.GlobalFinding)
+	order := make([]string, 0)
+	for _, run := range runs {
+		snapshot := model.ScanTaskRunSnapshot{RunID: run.id}
+		if err := loadScanTaskRunVulnerabilities(db, &snapshot); err != nil {
+			return nil, err
+		}
+		products, err := loadGlobalFindingProducts(db, run.id)
+		if err != nil {
+			return nil, err
+		}
+		for _, finding := range snapshot.Vulnerabilities {
+			identity := globalFindingIdentity(finding.TemplateID, finding.FindingKey, finding.TargetIP, finding.TargetPort, finding.Target)
+			entry, found := byIdentity[identity]
+			if !found {
+				finding.Evidence = ""
+				entry = &model.GlobalFinding{ScanTaskRunVulnerability: finding, Products: make([]string, 0), Sources: make([]model.GlobalFindingSource, 0), FirstSeenAt: firstSeen[identity]}
+				byIdentity[identity] = entry
+				order = append(order, identity)
+			}
+			entry.Sources = append(entry.Sources, model.GlobalFindingSource{ScanTaskID: run.scanTaskID, ScanTaskRunID: run.id, FindingKey: finding.FindingKey})
+			for _, product := range products[net.JoinHostPort(finding.TargetIP, strconv.Itoa(finding.TargetPort))] {
+				if !containsProductKey(entry.Products, product) {
+					entry.Products = append(entry.Products, product)
+				}
+			}
+		}
+	}
+
+	findings := make([]model.GlobalFinding, 0, len(order))
+	for _, identity := range order {
+		entry := byIdentity[identity]
+		sort.Strings(entry.Products)
+		if globalFindingMatches(*entry, filter, network) {
+			findings = append(findings, *entry)
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		left, right := model.SeverityRank(findings[i].Severity), model.SeverityRank(findings[j].Severity)
+		if left != right {
+			return left > right
+		}
+		if findings[i].Target != findings[j].Target {
+			return findings[i].Target < findings[j].Target
+		}
+		return findings[i].TemplateID < findings[j].TemplateID
+	})
+	return findings, nil
+}
+
+type globalFindingRun struct {
+	id         int64
+	scanTaskID int64
+}
+
+func latestSuccessfulScanTaskRuns(db *sql.DB) ([]globalFindingRun, error) {
+	rows, err := db.Query(`
+		SELECT run.id, run.scan_task_id
+		FROM scan_task_runs AS run
+		JOIN scan_tasks AS task ON task.id = run.scan_task_id
+		WHERE task.status <> ? AND run.id = (
+			SELECT latest.id FROM scan_task_runs AS latest
+			WHERE latest.scan_task_id = run.scan_task_id AND latest.status = ? AND latest.snapshot_written_at IS NOT NULL
+			ORDER BY latest.sequence DESC, latest.id DESC LIMIT 1
+		)
+		ORDER BY run.scan_task_id ASC`, model.ScanTaskStatusArchived, model.ScanTaskRunStatusSuccess)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	runs := make([]globalFindingRun, 0)
+	for rows.Next() {
+		var run globalFindingRun
+		if err := rows.Scan(&run.id, &run.scanTaskID); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+func globalFindingFirstSeen(db *sql.DB) (map[string]string, error) {
+	rows, err := db.Query(`
+		SELECT COALESCE(finding.template_id, ''), finding.finding_key, COALESCE(finding.target_ip, ''), COALESCE(finding.target_port, 0), finding.target,
+			MIN(datetime(COALESCE(run.started_at, run.scheduled_for)))
+		FROM scan_task_run_vulnerabilities AS finding
+		JOIN scan_task_runs AS run ON run.id = finding.scan_task_run_id
+		WHERE run.status = ?
+		GROUP BY finding.template_id, finding.finding_key, finding.target_ip, finding.target_port, finding.target`, model.ScanTaskRunStatusSuccess)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	firstSeen := make(map[string]string)
+	for rows.Next() {
+		var templateID, findingKey, targetIP, target string
+		var targetPort int
+		var seenAt sql.NullString
+		if err := rows.Scan(&templateID, &findingKey, &targetIP, &targetPort, &target, &seenAt); err != nil {
+			return nil, err
+		}
+		identity := globalFindingIdentity(templateID, findingKey, targetIP, targetPort, target)
+		if current, found := firstSeen[identity]; seenAt.Valid && (!found || seenAt.String < current) {
+			firstSeen[identity] = seenAt.String
+		}
+	}
+	return firstSeen, rows.Err()
+}
+
+// loadGlobalFindingProducts returns the concluded product keys of a run by
+// "ip:port". Conflicted products are left out because they are not an
+// identification.
+func loadGlobalFindingProducts(db *sql.DB, runID int64) (map[string][]string, error) {
+	rows, err := db.Query(`
+		SELECT DISTINCT ip, port, product_key FROM asset_fingerprint_conclusions
+		WHERE scan_task_run_id = ? AND product_status <> 'conflicted'
+		ORDER BY ip, port, product_key`, runID)
+	if isMissingCVETable(err) {
+		return map[string][]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	products := make(map[string][]string)
+	for rows.Next() {
+		var ip, productKey string
+		var port int
+		if err := rows.Scan(&ip, &port, &productKey); err != nil {
+			return nil, err
+		}
+		endpoint := net.JoinHostPort(ip, strconv.Itoa(port))
+		products[endpoint] = append(products[endpoint], productKey)
+	}
+	return products, rows.Err()
+}
+
+// globalFindingIdentity is the template and endpoint a finding reports.
+// Findings without a template or a resolved IP fall back to their finding key
+// and raw target so they are never merged with unrelated findings.
+func globalFindingIdentity(templateID, findingKey, targetIP string, targetPort int, target string) string {
+	template := templateID
+	if template == "" {
+		template = findingKey
+	}
+	endpoint := target
+	if targetIP != "" {
+		endpoint = net.JoinHostPort(targetIP, strconv.Itoa(targetPort))
+	}
+	return template + "|" + endpoint
+}
+
+func normalizeGlobalFindingFilter(filter model.GlobalFindingFilter) (model.GlobalFindingFilter, *net.IPNet, error) {
+	filter.Severity = strings.ToLower(strings.TrimSpace(filter.Severity))
+	filter.TemplateID = strings.TrimSpace(filter.TemplateID)
+	filter.ProductKey = strings.TrimSpace(filter.ProductKey)
+	filter.State = strings.ToLower(strings.TrimSpace(filter.State))
+	if filter.State != "" && !model.ValidFindingState(filter.State) {
+		return filter, nil, fmt.Errorf("%w: state must be one of %s", ErrInvalidGlobalFindingFilter, strings.Join(model.FindingStates, ", "))
+	}
+	var network *net.IPNet
+	if cidr := strings.TrimSpace(filter.CIDR); cidr != "" {
+		_, parsed, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return filter, nil, fmt.Errorf("%w: invalid cidr %s", ErrInvalidGlobalFindingFilter, cidr)
+		}
+		network = parsed
+	}
+	var err error
+	if filter.FirstSeenSince, err = normalizeFirstSeenBound(filter.FirstSeenSince, false); err != nil {
+		return filter, nil, err
+	}
+	if filter.FirstSeenUntil, err = normalizeFirstSeenBound(filter.FirstSeenUntil, true); err != nil {
+		return filter, nil, err
+	}
+	return filter, network, nil
+}
+
+// normalizeFirstSeenBound converts a YYYY-MM-DD date or RFC 3339 time to the
+// UTC "YYYY-MM-DD HH:MM:SS" form SQLite datetime() produces. A date used as
+// an upper bound covers the whole day.
+func normalizeFirstSeenBound(value string, upper bool) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	if bound, err := time.Parse(time.RFC3339, value); err == nil {
+		return bound.UTC().Format(time.DateTime), nil
+	}
+	bound, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return "", fmt.Errorf("%w: first-seen bound must be an RFC 3339 time or a YYYY-MM-DD date: %s", ErrInvalidGlobalFindingFilter, value)
+	}
+	if upper {
+		bound = bound.Add(24*time.Hour - time.Second)
+	}
+	return bound.Format(time.DateTime), nil
+}
+
+func globalFindingMatches(finding model.GlobalFinding, filter model.GlobalFindingFilter, network *net.IPNet) bool {
+	if filter.Severity != "" && strings.ToLower(finding.Severity) != filter.Severity {
+		return false
+	}
+	if filter.TemplateID != "" && finding.TemplateID != filter.TemplateID {
+		return false
+	}
+	if filter.ProductKey != "" && !containsProductKey(finding.Products, filter.ProductKey) {
+		return false
+	}
+	if filter.State != "" && finding.State != filter.State {
+		return false
+	}
+	if network != nil {
+		ip := net.ParseIP(finding.TargetIP)
+		if ip == nil || !network.Contains(ip) {
+			return false
+		}
+	}
+	if filter.FirstSeenSince != "" && finding.FirstSeenAt < filter.FirstSeenSince {
+		return false
+	}
+	if filter.FirstSeenUntil != "" && finding.FirstSeenAt > filter.FirstSeenUntil {
+		return false
+	}
+	return true
+}
+
+func containsProductKey(products []string, productKey string) bool {
+	for _, product := range products {
+		if product == productKey {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"golandproject/yscan/internal/model"
+)
+
+func TestListGlobalFindingsDeduplicatesOverlappingTasksAndFilters(t *testing.T) {
+	db := openTestDB(t)
+	if err := initSQLiteSchema(db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+	redis := func(key string) model.ScanTaskRunVulnerability {
+		return model.ScanTaskRunVulnerability{FindingKey: key, TemplateID: "redis-unauth", Severity: "high", Target: "192.168.144.10:6379", TargetIP: "192.168.144.10", TargetPort: 6379, Evidence: "secret"}
+	}
+	gitConfig := model.ScanTaskRunVulnerability{FindingKey: "git-config|http://192.168.145.20/.git/config", TemplateID: "git-config", Severity: "medium", Target: "http://192.168.145.20/.git/config", TargetIP: "192.168.145.20", TargetPort: 80}
+	saveSuccessfulRun := func(taskID int64, scheduledFor string, findings ...model.ScanTaskRunVulnerability) model.ScanTaskRun {
+		t.Helper()
+		run := createRunningTaskRun(t, db, taskID, scheduledFor)
+		if err := SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{RunID: run.ID, Vulnerabilities: findings}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(`UPDATE scan_task_runs SET status = 'success', started_at = ? WHERE id = ?`, scheduledFor, run.ID); err != nil {
+			t.Fatal(err)
+		}
+		return run
+	}
+
+	subnet := createScheduledTaskForTest(t, db, "192.168.144.0/23")
+	saveSuccessfulRun(subnet.ID, "2026-08-01T02:00:00Z", redis("redis-unauth|subnet"))
+	latest := saveSuccessfulRun(subnet.ID, "2026-08-02T02:00:00Z", redis("redis-unauth|subnet"), gitConfig)
+	if _, err := db.Exec(`
+		INSERT INTO asset_fingerprint_conclusions (scan_task_run_id, ip, port, protocol, product_key, conclusion_status)
+		VALUES (?, '192.168.144.10', 6379, 'redis', 'redis', 'matched')`, latest.ID); err != nil {
+		t.Fatal(err)
+	}
+	host := createScheduledTaskForTest(t, db, "192.168.144.10")
+	hostRun := saveSuccessfulRun(host.ID, "2026-08-03T02:00:00Z", redis("redis-unauth|host"))
+	archived := createScheduledTaskForTest(t, db, "192.168.146.0/24")
+	saveSuccessfulRun(archived.ID, "2026-08-03T02:00:00Z", model.ScanTaskRunVulnerability{FindingKey: "archived", TemplateID: "ftp-anon", Severity: "low", Target: "192.168.146.5:21", TargetIP: "192.168.146.5", TargetPort: 21})
+	if err := ArchiveScanTask(db, archived.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := ListGlobalFindings(db, model.GlobalFindingFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 2 || findings[0].TemplateID != "redis-unauth" || findings[1].TemplateID != "git-config" {
+		t.Fatalf("findings = %#v", findings)
+	}
+	merged := findings[0]
+	if len(merged.Sources) != 2 || merged.Sources[0].ScanTaskRunID != latest.ID || merged.Sources[1].ScanTaskRunID != hostRun.ID ||
+		merged.FirstSeenAt != "2026-08-01 02:00:00" || len(merged.Products) != 1 || merged.Products[0] != "redis" ||
+		merged.State != model.FindingStateOpen || merged.Evidence != "" {
+		t.Fatalf("merged finding = %#v", merged)
+	}
+
+	for name, filter := range map[string]model.GlobalFindingFilter{
+		"severity": {Severity: "HIGH"},
+		"template": {TemplateID: "redis-unauth"},
+		"product":  {ProductKey: "redis"},
+		"cidr":     {CIDR: "192.168.144.0/24"},
+		"until":    {FirstSeenUntil: "2026-08-01"},
+	} {
+		filtered, err := ListGlobalFindings(db, filter)
+		if err != nil || len(filtered) != 1 || filtered[0].TemplateID != "redis-unauth" {
+			t.Fatalf("%s filter = %#v err=%v", name, filtered, err)
+		}
+	}
+	if filtered, err := ListGlobalFindings(db, model.GlobalFindingFilter{FirstSeenSince: "2026-08-02T00:00:00Z"}); err != nil || len(filtered) != 1 || filtered[0].TemplateID != "git-config" {
+		t.Fatalf("first-seen since filter = %#v err=%v", filtered, err)
+	}
+	for _, filter := range []model.GlobalFindingFilter{{CIDR: "192.168.144.0"}, {FirstSeenSince: "yesterday"}, {State: "closed"}} {
+		if _, err := ListGlobalFindings(db, filter); !errors.Is(err, ErrInvalidGlobalFindingFilter) {
+			t.Fatalf("filter %#v err = %v", filter, err)
+		}
+	}
+}
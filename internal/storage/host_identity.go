@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"strings"
+
+	"golandproject/yscan/internal/model"
+)
+
+const hostIdentityColumns = `ip, port, source, computer_name, domain_name, dns_computer_name, dns_domain_name, dns_tree_name, os_version`
+
+func isHostIdentitySource(source string) bool {
+	switch source {
+	case model.HostIdentitySourceSMB, model.HostIdentitySourceRDP, model.HostIdentitySourceHTTP, model.HostIdentitySourceWinRM:
+		return true
+	default:
+		return false
+	}
+}
+
+func validateScanTaskRunHostIdentities(identities []model.ScanTaskRunHostIdentity) error {
+	seen := make(map[string]struct{}, len(identities))
+	for _, identity := range identities {
+		key := fmt.Sprintf("%s:%d/%s", strings.TrimSpace(identity.IP), identity.Port, identity.Source)
+		if net.ParseIP(strings.TrimSpace(identity.IP)) == nil || identity.Port < 1 || identity.Port > 65535 || !isHostIdentitySource(identity.Source) {
+			return fmt.Errorf("invalid snapshot host identity: %s", key)
+		}
+		if _, duplicate := seen[key]; duplicate {
+			return fmt.Errorf("duplicate snapshot host identity: %s", key)
+		}
+		seen[key] = struct{}{}
+	}
+	return nil
+}
+
+func saveScanTaskRunHostIdentitiesTx(tx *sql.Tx, runID int64, identities []model.ScanTaskRunHostIdentity) error {
+	for _, identity := range identities {
+		if _, err := tx.Exec(`
+			INSERT INTO scan_task_run_host_identities
+				(scan_task_run_id, `+hostIdentityColumns+`)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, runID,
+			strings.TrimSpace(identity.IP), identity.Port, identity.Source, identity.ComputerName, identity.DomainName,
+			identity.DNSComputerName, identity.DNSDomainName, identity.DNSTreeName, identity.OSVersion); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadScanTaskRunHostIdentities(db *sql.DB, snapshot *model.ScanTaskRunSnapshot) error {
+	rows, err := db.Query(`
+		SELECT `+hostIdentityColumns+`
+		FROM scan_task_run_host_identities
+		WHERE scan_task_run_id = ?
+		ORDER BY ip ASC, port ASC, source ASC`, snapshot.RunID)
+	if isMissingHostIdentityTable(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	identities, err := scanHostIdentities(rows)
+	if err != nil {
+		return err
+	}
+	if len(identities) > 0 {
+		snapshot.HostIdentities = identities
+	}
+	return nil
+}
+
+// loadAssetHostIdentities returns the identities of the latest completed run
+// that collected any for ip, so an endpoint that stopped answering does not
+// leave a stale name mixed with fresh ones.
+func loadAssetHostIdentities(db *sql.DB, ip string) ([]model.ScanTaskRunHostIdentity, error) {
+	rows, err := db.Query(`
+		SELECT `+hostIdentityColumns+`
+		FROM scan_task_run_host_identities
+		WHERE ip = ? AND scan_task_run_id = (
+			SELECT MAX(identity.scan_task_run_id)
+			FROM scan_task_run_host_identities AS identity
+			JOIN scan_task_runs AS run ON run.id = identity.scan_task_run_id
+			WHERE identity.ip = ? AND run.snapshot_written_at IS NOT NULL
+		)
+		ORDER BY port ASC, source ASC`, ip, ip)
+	if isMissingHostIdentityTable(err) {
+		return make([]model.ScanTaskRunHostIdentity, 0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return scanHostIdentities(rows)
+}
+
+func scanHostIdentities(rows *sql.Rows) ([]model.ScanTaskRunHostIdentity, error) {
+	defer rows.Close()
+	identities := make([]model.ScanTaskRunHostIdentity, 0)
+	for rows.Next() {
+		var identity model.ScanTaskRunHostIdentity
+		if err := rows.Scan(&identity.IP, &identity.Port, &identity.Source, &identity.ComputerName, &identity.DomainName,
+			&identity.DNSComputerName, &identity.DNSDomainName, &identity.DNSTreeName, &identity.OSVersion); err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+	return identities, rows.Err()
+}
+
+// hostIdentitySearchClause matches hosts whose IP or any collected computer,
+// domain or forest name contains the search text.
+func hostIdentitySearchClause(search string) (string, []interface{}) {
+	pattern := "%" + escapeLikePattern(strings.ToLower(search)) + "%"
+	clause := `(host_inventory.ip LIKE ? ESCAPE '\' OR EXISTS (
+			SELECT 1
+			FROM scan_task_run_host_identities AS identity_filter
+			WHERE identity_filter.ip = host_inventory.ip AND (
+				LOWER(identity_filter.computer_name) LIKE ? ESCAPE '\' OR
+				LOWER(identity_filter.domain_name) LIKE ? ESCAPE '\' OR
+				identity_filter.dns_computer_name LIKE ? ESCAPE '\' OR
+				identity_filter.dns_domain_name LIKE ? ESCAPE '\' OR
+				identity_filter.dns_tree_name LIKE ? ESCAPE '\'
+			)
+		))`
+	return clause, []interface{}{pattern, pattern, pattern, pattern, pattern, pattern}
+}
+
+func escapeLikePattern(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+func isMissingHostIdentityTable(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "no such table: scan_task_run_host_identities")
+}
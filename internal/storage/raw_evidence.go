@@ -0,0 +1,234 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"golandproject/yscan/internal/model"
+)
+
+// ErrReevaluationSourceNotLatest guards the asset view: a derived run copies
+// its source observations, so only the newest scan may be re-fingerprinted.
+var ErrReevaluationSourceNotLatest = errors.New("only the latest successful scan of a task can be reevaluated")
+
+// derivedRunObservationTables are copied unchanged from the source run. Raw
+// evidence stays with the source; fingerprint results, the SNMP operating
+// system conclusions and the endpoint products, CVE matches and risk scores
+// derived from them are recomputed.
+// Template candidates were selected from the source's conclusions and are
+// not carried over.
+var derivedRunObservationTables = []string{
+	"scan_task_run_hosts",
+	"scan_task_run_ports",
+	"scan_task_run_protocol_evidence",
+	"scan_task_run_validation",
+	"scan_task_run_endpoint_validation",
+	"scan_task_run_vulnerabilities",
+	"scan_task_run_host_identities",
+	"scan_task_run_ssh_host_keys",
+	"scan_task_run_snmp_systems",
+	"scan_task_run_snmp_interfaces",
+	"scan_task_run_web_assets",
+	"scan_task_run_weaknesses",
+	"scan_task_run_tls_fingerprints",
+}
+
+func validateScanTaskRunRawEvidence(records []model.ScanTaskRunRawEvidence) error {
+	seen := make(map[string]struct{}, len(records))
+	for _, record := range records {
+		key := fmt.Sprintf("%s:%d#%d", strings.TrimSpace(record.IP), record.Port, record.Sequence)
+		if net.ParseIP(strings.TrimSpace(record.IP)) == nil || record.Port < 1 || record.Port > 65535 || record.Sequence < 1 ||
+			strings.TrimSpace(record.Protocol) == "" || strings.TrimSpace(record.Kind) == "" || len(record.Sealed) == 0 {
+			return fmt.Errorf("invalid snapshot raw evidence: %s", key)
+		}
+		if _, duplicate := seen[key]; duplicate {
+			return fmt.Errorf("duplicate snapshot raw evidence: %s", key)
+		}
+		seen[key] = struct{}{}
+	}
+	return nil
+}
+
+func saveScanTaskRunRawEvidenceTx(tx *sql.Tx, runID int64, records []model.ScanTaskRunRawEvidence) error {
+	for _, record := range records {
+		if _, err := tx.Exec(`
+			INSERT INTO scan_task_run_raw_evidence
+				(scan_task_run_id, ip, port, sequence, protocol, kind, probe_name, summary, sealed)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			runID, strings.TrimSpace(record.IP), record.Port, record.Sequence, record.Protocol, record.Kind, record.ProbeName, record.Summary, record.Sealed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListScanTaskRunRawEvidence returns the sealed records retained by one run in
+// collection order. Runs without retention return an empty slice.
+func ListScanTaskRunRawEvidence(db *sql.DB, runID int64) ([]model.ScanTaskRunRawEvidence, error) {
+	rows, err := db.Query(`
+		SELECT ip, port, sequence, protocol, kind, probe_name, summary, sealed
+		FROM scan_task_run_raw_evidence
+		WHERE scan_task_run_id = ?
+		ORDER BY ip ASC, port ASC, sequence ASC`, runID)
+	if isMissingRawEvidenceTable(err) {
+		return make([]model.ScanTaskRunRawEvidence, 0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	records := make([]model.ScanTaskRunRawEvidence, 0)
+	for rows.Next() {
+		var record model.ScanTaskRunRawEvidence
+		if err := rows.Scan(&record.IP, &record.Port, &record.Sequence, &record.Protocol, &record.Kind, &record.ProbeName, &record.Summary, &record.Sealed); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// CreateReevaluatedScanTaskRun records fingerprint matches recomputed from a
+// run's retained evidence as a new successful run of the same task. The new
+// run freezes the currently active fingerprint imports, copies the source's
+// observations and derives conclusions exactly like a scanned run.
+func CreateReevaluatedScanTaskRun(db *sql.DB, sourceRunID int64, matches []model.FingerprintRunMatch) (model.ScanTaskRun, error) {
+	source, err := GetScanTaskRun(db, sourceRunID)
+	if err != nil {
+		return model.ScanTaskRun{}, err
+	}
+	if source.Status != model.ScanTaskRunStatusSuccess || source.SnapshotWrittenAt == "" || source.Trigger == model.ScanTaskRunTriggerReevaluate {
+		return model.ScanTaskRun{}, ErrReevaluationSourceNotLatest
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return model.ScanTaskRun{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var latestID int64
+	if err := tx.QueryRow(`
+		SELECT id FROM scan_task_runs
+		WHERE scan_task_id = ? AND status = ? AND snapshot_written_at IS NOT NULL AND trigger <> ?
+		ORDER BY sequence DESC, id DESC LIMIT 1`, source.ScanTaskID, model.ScanTaskRunStatusSuccess, model.ScanTaskRunTriggerReevaluate).Scan(&latestID); err != nil {
+		return model.ScanTaskRun{}, err
+	}
+	if latestID != source.ID {
+		return model.ScanTaskRun{}, ErrReevaluationSourceNotLatest
+	}
+	var sequence int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(sequence), 0) + 1 FROM scan_task_runs WHERE scan_task_id = ?`, source.ScanTaskID).Scan(&sequence); err != nil {
+		return model.ScanTaskRun{}, err
+	}
+	result, err := tx.Exec(`
+		INSERT INTO scan_task_runs
+			(scan_task_id, sequence, scheduled_for, status, trigger, stage, progress, target, scan_type, config_json, config_hash,
+			 started_at, finished_at, snapshot_written_at, created_at, updated_at)
+		SELECT scan_task_id, ?, ?, ?, ?, ?, 100, target, scan_type, config_json, config_hash,
+			datetime('now'), datetime('now'), datetime('now'), datetime('now'), datetime('now')
+		FROM scan_task_runs WHERE id = ?`, sequence, time.Now().UTC().Format(time.RFC3339Nano), model.ScanTaskRunStatusSuccess,
+		model.ScanTaskRunTriggerReevaluate, model.ScanTaskRunStageCompleted, source.ID)
+	if err != nil {
+		return model.ScanTaskRun{}, err
+	}
+	runID, err := result.LastInsertId()
+	if err != nil {
+		return model.ScanTaskRun{}, err
+	}
+	if err := FreezeActiveFingerprintImportsTx(tx, runID); err != nil {
+		return model.ScanTaskRun{}, fmt.Errorf("freeze active fingerprint imports: %w", err)
+	}
+	for _, table := range derivedRunObservationTables {
+		if err := copyScanTaskRunRowsTx(tx, table, source.ID, runID); err != nil {
+			return model.ScanTaskRun{}, fmt.Errorf("copy %s: %w", table, err)
+		}
+	}
+	if _, err := tx.Exec(`INSERT INTO scan_task_run_derivations (scan_task_run_id, source_scan_task_run_id) VALUES (?, ?)`, runID, source.ID); err != nil {
+		return model.ScanTaskRun{}, err
+	}
+	if err := saveFingerprintRunMatchesTx(tx, runID, matches); err != nil {
+		return model.ScanTaskRun{}, err
+	}
+	if err := concludeScanTaskRunSNMPSystemsTx(tx, runID); err != nil {
+		return model.ScanTaskRun{}, err
+	}
+	if err := refreshScanTaskRunPortProductsTx(tx, runID); err != nil {
+		return model.ScanTaskRun{}, err
+	}
+	if err := correlateScanTaskRunCVEsTx(tx, runID); err != nil {
+		return model.ScanTaskRun{}, err
+	}
+	if err := scoreScanTaskRunRiskTx(tx, runID); err != nil {
+		return model.ScanTaskRun{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return model.ScanTaskRun{}, err
+	}
+	return GetScanTaskRun(db, runID)
+}
+
+// GetScanTaskRunDerivationSource returns the run a reevaluated run was
+// derived from, or zero for scanned runs and deleted sources.
+func GetScanTaskRunDerivationSource(db *sql.DB, runID int64) (int64, error) {
+	var sourceID sql.NullInt64
+	err := db.QueryRow(`SELECT source_scan_task_run_id FROM scan_task_run_derivations WHERE scan_task_run_id = ?`, runID).Scan(&sourceID)
+	if errors.Is(err, sql.ErrNoRows) || isMissingRawEvidenceTable(err) {
+		return 0, nil
+	}
+	return sourceID.Int64, err
+}
+
+// refreshScanTaskRunPortProductsTx sets each endpoint's product from the run's
+// conclusions the way a scan does: only when exactly one web server or
+// network service was concluded on that port.
+func refreshScanTaskRunPortProductsTx(tx *sql.Tx, runID int64) error {
+	_, err := tx.Exec(`
+		UPDATE scan_task_run_ports SET product = (
+			SELECT CASE WHEN COUNT(DISTINCT conclusion.product_key) = 1 THEN MAX(conclusion.product_key) END
+			FROM asset_fingerprint_conclusions AS conclusion
+			WHERE conclusion.scan_task_run_id = scan_task_run_ports.scan_task_run_id
+				AND conclusion.ip = scan_task_run_ports.ip AND conclusion.port = scan_task_run_ports.port
+				AND conclusion.product_role IN ('web_server', 'network_service')
+		)
+		WHERE scan_task_run_id = ?`, runID)
+	return err
+}
+
+// copyScanTaskRunRowsTx duplicates one run-scoped table by column name so
+// older databases missing optional columns or tables copy what they have.
+func copyScanTaskRunRowsTx(tx *sql.Tx, table string, sourceRunID, targetRunID int64) error {
+	rows, err := tx.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return err
+	}
+	columns := make([]string, 0)
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			rows.Close()
+			return err
+		}
+		if column != "scan_task_run_id" {
+			columns = append(columns, column)
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+	list := strings.Join(columns, ", ")
+	_, err = tx.Exec(`INSERT INTO `+table+` (scan_task_run_id, `+list+`) SELECT ?, `+list+` FROM `+table+` WHERE scan_task_run_id = ?`, targetRunID, sourceRunID)
+	return err
+}
+
+func isMissingRawEvidenceTable(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "no such table") &&
+		(strings.Contains(err.Error(), "scan_task_run_raw_evidence") || strings.Contains(err.Error(), "scan_task_run_derivations"))
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"golandproject/yscan/internal/model"
+)
+
+func TestReevaluatedRunCopiesObservationsAndRecordsNewConclusions(t *testing.T) {
+	db := openTestDB(t)
+	if err := initSQLiteSchema(db); err != nil {
+		t.Fatal(err)
+	}
+	fingerprintImport, err := ImportFingerprintBatch(db, fingerprintBatchFixture("reevaluate", "reevaluate-archive", "reevaluate-rule"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sourceRuleID, matcherID int64
+	if err := db.QueryRow(`
+		SELECT source_rule.id, matcher.id FROM fingerprint_source_rules AS source_rule
+		JOIN fingerprint_rules AS rule ON rule.fingerprint_source_rule_id = source_rule.id
+		JOIN fingerprint_match_groups AS match_group ON match_group.fingerprint_rule_id = rule.id
+		JOIN fingerprint_matchers AS matcher ON matcher.fingerprint_match_group_id = match_group.id
+		WHERE source_rule.fingerprint_import_id = ?`, fingerprintImport.ID).Scan(&sourceRuleID, &matcherID); err != nil {
+		t.Fatal(err)
+	}
+	task := createScheduledTaskForTest(t, db, "192.168.77.10")
+	run := createRunningTaskRun(t, db, task.ID, "2026-08-01T02:00:00Z")
+	snapshot := model.ScanTaskRunSnapshot{
+		RunID:              run.ID,
+		Hosts:              []model.ScanTaskRunHost{{IP: "192.168.77.10", IsActive: true}},
+		Ports:              []model.ScanTaskRunPort{{IP: "192.168.77.10", Port: 8080, ServiceType: "http", Product: "nginx"}},
+		TemplateCandidates: []model.ScanTaskRunTemplateCandidate{{TemplateID: "nginx-version", Path: "http/nginx-version.yaml", ProductKey: "nginx", Source: "product", Reason: "nginx concluded", IP: "192.168.77.10", Port: 8080, Protocol: "http"}},
+		RawEvidence: []model.ScanTaskRunRawEvidence{
+			{IP: "192.168.77.10", Port: 8080, Sequence: 1, Protocol: "tcp", Kind: "evidence", Summary: "tcp banner=unavailable", Sealed: []byte("sealed-banner")},
+			{IP: "192.168.77.10", Port: 8080, Sequence: 2, Protocol: "http", Kind: "evidence", Summary: "http status=200", Sealed: []byte("sealed-root")},
+		},
+	}
+	if err := SaveScanTaskRunSnapshot(db, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE scan_task_runs SET status = ?, stage = ? WHERE id = ?`, model.ScanTaskRunStatusSuccess, model.ScanTaskRunStageCompleted, run.ID); err != nil {
+		t.Fatal(err)
+	}
+	var sourceCandidates int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM scan_task_run_template_candidates WHERE scan_task_run_id = ?`, run.ID).Scan(&sourceCandidates); err != nil || sourceCandidates != 1 {
+		t.Fatalf("source template candidates = %d err=%v", sourceCandidates, err)
+	}
+	records, err := ListScanTaskRunRawEvidence(db, run.ID)
+	if err != nil || len(records) != 2 || records[1].Protocol != "http" || string(records[1].Sealed) != "sealed-root" {
+		t.Fatalf("raw evidence = %#v err=%v", records, err)
+	}
+
+	matches := []model.FingerprintRunMatch{{
+		FingerprintImportID: fingerprintImport.ID, FingerprintSourceRuleID: sourceRuleID,
+		IP: "192.168.77.10", Port: 8080, Protocol: "http", Product: "fixture", EvidenceSummary: "http status=200",
+		Evidence: fixtureMatchEvidence(matcherID),
+	}}
+	derived, err := CreateReevaluatedScanTaskRun(db, run.ID, matches)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if derived.Trigger != model.ScanTaskRunTriggerReevaluate || derived.Status != model.ScanTaskRunStatusSuccess || derived.Sequence != run.Sequence+1 || derived.SnapshotWrittenAt == "" {
+		t.Fatalf("derived run = %#v", derived)
+	}
+	if source, err := GetScanTaskRunDerivationSource(db, derived.ID); err != nil || source != run.ID {
+		t.Fatalf("derivation source = %d err=%v", source, err)
+	}
+	for table, want := range map[string]int{"scan_task_run_ports": 1, "scan_task_run_hosts": 1, "scan_task_run_raw_evidence": 0, "asset_fingerprint_conclusions": 1, "scan_task_run_template_candidates": 0} {
+		var count int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM `+table+` WHERE scan_task_run_id = ?`, derived.ID).Scan(&count); err != nil || count != want {
+			t.Fatalf("%s rows = %d err=%v, want %d", table, count, err, want)
+		}
+	}
+	var product sql.NullString
+	if err := db.QueryRow(`SELECT product FROM scan_task_run_ports WHERE scan_task_run_id = ?`, derived.ID).Scan(&product); err != nil || product.Valid {
+		t.Fatalf("derived endpoint product = %#v err=%v, want none for a non-service conclusion", product, err)
+	}
+
+	if _, err := CreateReevaluatedScanTaskRun(db, derived.ID, nil); !errors.Is(err, ErrReevaluationSourceNotLatest) {
+		t.Fatalf("reevaluating a derived run err = %v", err)
+	}
+	newer := createRunningTaskRun(t, db, task.ID, "2026-08-02T02:00:00Z")
+	if err := SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{RunID: newer.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE scan_task_runs SET status = ? WHERE id = ?`, model.ScanTaskRunStatusSuccess, newer.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateReevaluatedScanTaskRun(db, run.ID, nil); !errors.Is(err, ErrReevaluationSourceNotLatest) {
+		t.Fatalf("reevaluating a superseded run err = %v", err)
+	}
+	invalid := createRunningTaskRun(t, db, task.ID, "2026-08-03T02:00:00Z")
+	duplicate := snapshot.RawEvidence[0]
+	if err := SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{RunID: invalid.ID, RawEvidence: []model.ScanTaskRunRawEvidence{duplicate, duplicate}}); err == nil {
+		t.Fatal("duplicate raw evidence must fail snapshot finalization")
+	}
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"golandproject/yscan/internal/model"
+)
+
+var (
+	// ErrRevalidationSourceUnavailable rejects sources without a complete
+	// snapshot to take endpoints and fingerprint matches from.
+	ErrRevalidationSourceUnavailable = errors.New("only a successful scan task run with a snapshot can be revalidated")
+	ErrRevalidationDisabled          = errors.New("vulnerability validation is disabled for this scan task")
+)
+
+// CreateRevalidationScanTaskRun queues a run that repeats only the validation
+// stage against the endpoints of sourceRunID. It keeps the source target and
+// fingerprint imports so the reused matches stay valid, takes the current task
+// configuration (and thus template set), and links itself to the source.
+func CreateRevalidationScanTaskRun(db *sql.DB, sourceRunID int64) (model.ScanTaskRun, error) {
+	source, err := GetScanTaskRun(db, sourceRunID)
+	if err != nil {
+		return model.ScanTaskRun{}, err
+	}
+	if source.Status != model.ScanTaskRunStatusSuccess || source.SnapshotWrittenAt == "" {
+		return model.ScanTaskRun{}, ErrRevalidationSourceUnavailable
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return model.ScanTaskRun{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	task, err := scanScanTask(tx.QueryRow(`
+		SELECT id, target, scan_type, mode, status, cron, timezone, config_json, config_hash, created_at, updated_at, archived_at
+		FROM scan_tasks
+		WHERE id = ?`, source.ScanTaskID))
+	if err != nil {
+		return model.ScanTaskRun{}, err
+	}
+	if task.Status != model.ScanTaskStatusEnabled {
+		return model.ScanTaskRun{}, fmt.Errorf("%w: %s", ErrScanTaskNotEnabled, task.Status)
+	}
+	if !task.Config.VulnerabilityOn {
+		return model.ScanTaskRun{}, ErrRevalidationDisabled
+	}
+	var sequence int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(sequence), 0) + 1 FROM scan_task_runs WHERE scan_task_id = ?`, task.ID).Scan(&sequence); err != nil {
+		return model.ScanTaskRun{}, err
+	}
+	configJSON, err := json.Marshal(task.Config)
+	if err != nil {
+		return model.ScanTaskRun{}, fmt.Errorf("marshal run config snapshot: %w", err)
+	}
+	result, err := tx.Exec(`
+		INSERT INTO scan_task_runs
+			(scan_task_id, sequence, scheduled_for, status, trigger, target, scan_type, config_json, config_hash, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))`,
+		task.ID, sequence, time.Now().UTC().Format(time.RFC3339Nano), model.ScanTaskRunStatusQueued, model.ScanTaskRunTriggerRevalidate,
+		source.Target, source.ScanType, string(configJSON), task.ConfigHash)
+	if err != nil {
+		return model.ScanTaskRun{}, err
+	}
+	runID, err := result.LastInsertId()
+	if err != nil {
+		return model.ScanTaskRun{}, err
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO scan_task_run_fingerprint_imports (scan_task_run_id, fingerprint_import_id)
+		SELECT ?, fingerprint_import_id FROM scan_task_run_fingerprint_imports WHERE scan_task_run_id = ?`, runID, source.ID); err != nil && !isMissingFingerprintCatalogTable(err) {
+		return model.ScanTaskRun{}, fmt.Errorf("freeze source fingerprint imports: %w", err)
+	}
+	if _, err := tx.Exec(`INSERT INTO scan_task_run_derivations (scan_task_run_id, source_scan_task_run_id) VALUES (?, ?)`, runID, source.ID); err != nil {
+		return model.ScanTaskRun{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return model.ScanTaskRun{}, err
+	}
+	return GetScanTaskRun(db, runID)
+}
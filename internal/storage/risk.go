@@ -0,0 +1,587 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strings"
+
+	"golandproject/yscan/internal/model"
+)
+
+var ErrAssetCriticalityLabelNotFound = errors.New("asset criticality label not found")
+
+// Risk points. The most severe actionable finding of an endpoint sets its
+// base and each further finding adds a little; the remaining factors are
+// counted once per endpoint. Criticality labels scale the sum.
+var riskSeverityPoints = map[string]int{"critical": 40, "high": 25, "medium": 12, "low": 4}
+
+var riskCriticalityPercent = map[string]int{
+	model.RiskLevelCritical: 150,
+	model.RiskLevelHigh:     125,
+	model.RiskLevelMedium:   100,
+	model.RiskLevelLow:      75,
+}
+
+const (
+	riskExtraFindingPoints      = 2
+	riskExtraFindingMaxPoints   = 10
+	riskKnownExploitedPoints    = 25
+	riskPotentialKnownExploited = 15
+	riskCVSSMaxPoints           = 10
+	riskAgedFindingDays         = 30
+	riskAgedFindingPoints       = 5
+	riskStaleFindingDays        = 90
+	riskStaleFindingPoints      = 10
+	riskExtraEndpointPoints     = 2
+	riskExtraEndpointMaxPoints  = 10
+	riskMaxScore                = 100
+
+	riskScopeTask     = "task"
+	riskScopeHost     = "host"
+	riskScopeEndpoint = "endpoint"
+)
+
+// riskExposures classify an endpoint by its service name, or by its port when
+// the service was not identified as one of them.
+var riskExposures = []struct {
+	category string
+	points   int
+	services []string
+	ports    []int
+}{
+	{"database", 20,
+		[]string{"mysql", "mariadb", "postgres", "redis", "mongo", "mssql", "ms-sql", "oracle", "elasticsearch", "memcache", "couchdb", "cassandra", "clickhouse", "influxdb"},
+		[]int{1433, 1521, 3306, 5432, 5984, 6379, 8086, 8123, 9042, 9200, 11211, 27017}},
+	{"remote access", 15,
+		[]string{"ssh", "telnet", "rdp", "ms-wbt-server", "vnc", "winrm"},
+		[]int{22, 23, 3389, 5900, 5985, 5986}},
+	{"management", 15,
+		[]string{"snmp", "ipmi", "docker", "kubernetes", "kubelet", "etcd", "java-rmi", "jmx", "winbox"},
+		[]int{161, 623, 2375, 2376, 2379, 6443, 8291, 10250}},
+}
+
+type riskEndpoint struct {
+	ip        string
+	port      int
+	service   string
+	findings  []riskFinding
+	potential []model.ScanTaskRunPotentialVulnerability
+}
+
+type riskFinding struct {
+	id             string
+	severity       string
+	cvssScore      float64
+	knownExploited bool
+	ageDays        int
+}
+
+type riskLabel struct {
+	network *net.IPNet
+	cidr    string
+	level   string
+}
+
+// scoreScanTaskRunRiskTx scores the endpoints, hosts and task of a run from
+// its open ports, actionable validated findings, correlated CVEs and the
+// current criticality labels. It runs after triage and CVE correlation so
+// both are settled for the run.
+func scoreScanTaskRunRiskTx(tx *sql.Tx, runID int64) error {
+	endpoints := make(map[string]*riskEndpoint)
+	endpoint := func(ip string, port int) *riskEndpoint {
+		key := fmt.Sprintf("%s:%d", ip, port)
+		if endpoints[key] == nil {
+			endpoints[key] = &riskEndpoint{ip: ip, port: port}
+		}
+		return endpoints[key]
+	}
+
+	rows, err := tx.Query(`SELECT ip, port, service_type FROM scan_task_run_ports WHERE scan_task_run_id = ?`, runID)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var ip, service string
+		var port int
+		if err := rows.Scan(&ip, &port, &service); err != nil {
+			rows.Close()
+			return err
+		}
+		endpoint(ip, port).service = strings.ToLower(service)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	rows, err = tx.Query(`
+		SELECT finding.finding_key, COALESCE(finding.template_id, ''), COALESCE(finding.severity, ''),
+			COALESCE(finding.target_ip, ''), COALESCE(finding.target_port, 0), COALESCE(triage.state, ?),
+			COALESCE(record.cvss_score, 0), known.cve_id IS NOT NULL,
+			COALESCE((
+				SELECT CAST(julianday(COALESCE(current.started_at, current.scheduled_for)) -
+					MIN(julianday(COALESCE(seen_run.started_at, seen_run.scheduled_for))) AS INTEGER)
+				FROM scan_task_run_vulnerabilities AS seen
+				JOIN scan_task_runs AS seen_run ON seen_run.id = seen.scan_task_run_id
+				WHERE seen.finding_key = finding.finding_key AND (seen_run.status = ? OR seen_run.id = current.id)
+			), 0)
+		FROM scan_task_run_vulnerabilities AS finding
+		JOIN scan_task_runs AS current ON current.id = finding.scan_task_run_id
+		LEFT JOIN finding_triage AS triage ON triage.finding_key = finding.finding_key
+		LEFT JOIN cve_records AS record ON record.cve_id = UPPER(finding.template_id)
+		LEFT JOIN cve_known_exploited AS known ON known.cve_id = UPPER(finding.template_id)
+		WHERE finding.scan_task_run_id = ?
+		ORDER BY finding.finding_key`, model.FindingStateOpen, model.ScanTaskRunStatusSuccess, runID)
+	if isMissingCVETable(err) || isMissingFindingTriageTable(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]struct{})
+	for rows.Next() {
+		var key, templateID, severity, ip, state string
+		var port int
+		var finding riskFinding
+		if err := rows.Scan(&key, &templateID, &severity, &ip, &port, &state, &finding.cvssScore, &finding.knownExploited, &finding.ageDays); err != nil {
+			rows.Close()
+			return err
+		}
+		if _, duplicate := seen[key]; duplicate || ip == "" || !model.FindingStateActionable(state) {
+			continue
+		}
+		seen[key] = struct{}{}
+		finding.id, finding.severity = templateID, strings.ToLower(severity)
+		if finding.id == "" {
+			finding.id = key
+		}
+		scored := endpoint(ip, port)
+		scored.findings = append(scored.findings, finding)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	rows, err = tx.Query(`SELECT ip, port, cve_id, cvss_score, known_exploited FROM scan_task_run_cve_matches WHERE scan_task_run_id = ?`, runID)
+	if isMissingCVETable(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var match model.ScanTaskRunPotentialVulnerability
+		if err := rows.Scan(&match.IP, &match.Port, &match.CVEID, &match.CVSSScore, &match.KnownExploited); err != nil {
+			rows.Close()
+			return err
+		}
+		scored := endpoint(match.IP, match.Port)
+		scored.potential = append(scored.potential, match)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	labels, err := listAssetCriticalityLabels(tx)
+	if err != nil {
+		return err
+	}
+	parsed := make([]riskLabel, 0, len(labels))
+	for _, label := range labels {
+		if _, network, err := net.ParseCIDR(label.CIDR); err == nil {
+			parsed = append(parsed, riskLabel{network: network, cidr: label.CIDR, level: label.Level})
+		}
+	}
+
+	risk := scoreRunRisk(endpoints, parsed)
+	err = insertRiskScoreTx(tx, runID, riskScopeTask, model.AssetRiskScore{Score: risk.Score, Level: risk.Level, Factors: []model.RiskFactor{}})
+	if isMissingRiskTable(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, host := range risk.Hosts {
+		if err := insertRiskScoreTx(tx, runID, riskScopeHost, host); err != nil {
+			return err
+		}
+	}
+	for _, scored := range risk.Endpoints {
+		if err := insertRiskScoreTx(tx, runID, riskScopeEndpoint, scored); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertRiskScoreTx(tx *sql.Tx, runID int64, scope string, score model.AssetRiskScore) error {
+	factorsJSON, err := json.Marshal(score.Factors)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO scan_task_run_risk_scores (scan_task_run_id, scope, ip, port, score, level, factors_json)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`, runID, scope, score.IP, score.Port, score.Score, score.Level, string(factorsJSON))
+	return err
+}
+
+// scoreRunRisk keeps endpoints and hosts with a positive score. A host scores
+// its riskiest endpoint plus a little for every other scored endpoint.
+func scoreRunRisk(endpoints map[string]*riskEndpoint, labels []riskLabel) model.ScanTaskRunRisk {
+	risk := model.ScanTaskRunRisk{Hosts: make([]model.AssetRiskScore, 0), Endpoints: make([]model.AssetRiskScore, 0)}
+	byHost := make(map[string][]model.AssetRiskScore)
+	for _, endpoint := range endpoints {
+		scored := scoreRiskEndpoint(*endpoint, criticalityLabelFor(endpoint.ip, labels))
+		if scored.Score == 0 {
+			continue
+		}
+		risk.Endpoints = append(risk.Endpoints, scored)
+		byHost[scored.IP] = append(byHost[scored.IP], scored)
+	}
+	sortAssetRiskScores(risk.Endpoints)
+	for ip, scored := range byHost {
+		sortAssetRiskScores(scored)
+		host := model.AssetRiskScore{IP: ip, Factors: []model.RiskFactor{{
+			Kind: model.RiskFactorEndpoint, Detail: fmt.Sprintf("%s:%d", ip, scored[0].Port), Points: scored[0].Score,
+		}}}
+		if others := len(scored) - 1; others > 0 {
+			detail := fmt.Sprintf("%d more scored endpoints", others)
+			if others == 1 {
+				detail = "1 more scored endpoint"
+			}
+			host.Factors = append(host.Factors, model.RiskFactor{
+				Kind: model.RiskFactorSpread, Detail: detail,
+				Points: min(others*riskExtraEndpointPoints, riskExtraEndpointMaxPoints),
+			})
+		}
+		host.Score = sumRiskFactors(host.Factors)
+		host.Level = model.RiskLevelForScore(host.Score)
+		risk.Hosts = append(risk.Hosts, host)
+		if host.Score > risk.Score {
+			risk.Score = host.Score
+		}
+	}
+	sortAssetRiskScores(risk.Hosts)
+	risk.Level = model.RiskLevelForScore(risk.Score)
+	return risk
+}
+
+func scoreRiskEndpoint(endpoint riskEndpoint, label *riskLabel) model.AssetRiskScore {
+	scored := model.AssetRiskScore{IP: endpoint.ip, Port: endpoint.port, Factors: make([]model.RiskFactor, 0)}
+
+	findings := make([]riskFinding, 0, len(endpoint.findings))
+	for _, finding := range endpoint.findings {
+		if riskSeverityPoints[finding.severity] > 0 {
+			findings = append(findings, finding)
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool {
+		left, right := riskSeverityPoints[findings[i].severity], riskSeverityPoints[findings[j].severity]
+		if left != right {
+			return left > right
+		}
+		return findings[i].id < findings[j].id
+	})
+	if len(findings) > 0 {
+		detail := findings[0].severity + " " + findings[0].id
+		if len(findings) > 1 {
+			detail += fmt.Sprintf(" and %d more", len(findings)-1)
+		}
+		scored.Factors = append(scored.Factors, model.RiskFactor{
+			Kind: model.RiskFactorFinding, Detail: detail,
+			Points: riskSeverityPoints[findings[0].severity] + min((len(findings)-1)*riskExtraFindingPoints, riskExtraFindingMaxPoints),
+		})
+	}
+
+	var exploited, exploitedPotential, cvssID, oldestID string
+	var cvss float64
+	oldest := -1
+	for _, finding := range findings {
+		if finding.knownExploited && exploited == "" {
+			exploited = finding.id
+		}
+		if finding.cvssScore > cvss {
+			cvss, cvssID = finding.cvssScore, finding.id
+		}
+		if finding.ageDays > oldest {
+			oldest, oldestID = finding.ageDays, finding.id
+		}
+	}
+	for _, match := range endpoint.potential {
+		if match.KnownExploited && (exploitedPotential == "" || match.CVEID < exploitedPotential) {
+			exploitedPotential = match.CVEID
+		}
+		if match.CVSSScore > cvss {
+			cvss, cvssID = match.CVSSScore, match.CVEID
+		}
+	}
+	switch {
+	case exploited != "":
+		scored.Factors = append(scored.Factors, model.RiskFactor{Kind: model.RiskFactorKnownExploited, Detail: exploited + " validated", Points: riskKnownExploitedPoints})
+	case exploitedPotential != "":
+		scored.Factors = append(scored.Factors, model.RiskFactor{Kind: model.RiskFactorKnownExploited, Detail: exploitedPotential + " potential", Points: riskPotentialKnownExploited})
+	}
+	if cvss > 0 {
+		scored.Factors = append(scored.Factors, model.RiskFactor{
+			Kind: model.RiskFactorCVSS, Detail: fmt.Sprintf("%s CVSS %.1f", cvssID, cvss),
+			Points: min(int(math.Round(cvss)), riskCVSSMaxPoints),
+		})
+	}
+	if category, points, reason := riskExposureOf(endpoint); points > 0 {
+		scored.Factors = append(scored.Factors, model.RiskFactor{Kind: model.RiskFactorExposure, Detail: category + " (" + reason + ")", Points: points})
+	}
+	switch {
+	case oldest >= riskStaleFindingDays:
+		scored.Factors = append(scored.Factors, model.RiskFactor{Kind: model.RiskFactorAge, Detail: fmt.Sprintf("%s open %d days", oldestID, oldest), Points: riskStaleFindingPoints})
+	case oldest >= riskAgedFindingDays:
+		scored.Factors = append(scored.Factors, model.RiskFactor{Kind: model.RiskFactorAge, Detail: fmt.Sprintf("%s open %d days", oldestID, oldest), Points: riskAgedFindingPoints})
+	}
+
+	base := sumRiskFactors(scored.Factors)
+	if label != nil && base > 0 {
+		scaled := int(math.Round(float64(base*riskCriticalityPercent[label.level]) / 100))
+		scored.Factors = append(scored.Factors, model.RiskFactor{
+			Kind: model.RiskFactorCriticality, Detail: fmt.Sprintf("%s (%s)", label.level, label.cidr), Points: scaled - base,
+		})
+	}
+	scored.Score = sumRiskFactors(scored.Factors)
+	scored.Level = model.RiskLevelForScore(scored.Score)
+	return scored
+}
+
+func riskExposureOf(endpoint riskEndpoint) (string, int, string) {
+	if endpoint.service != "" {
+		for _, exposure := range riskExposures {
+			for _, service := range exposure.services {
+				if strings.Contains(endpoint.service, service) {
+					return exposure.category, exposure.points, endpoint.service
+				}
+			}
+		}
+	}
+	for _, exposure := range riskExposures {
+		for _, port := range exposure.ports {
+			if endpoint.port == port {
+				return exposure.category, exposure.points, fmt.Sprintf("port %d", port)
+			}
+		}
+	}
+	return "", 0, ""
+}
+
+// criticalityLabelFor returns the most specific label covering ip.
+func criticalityLabelFor(ip string, labels []riskLabel) *riskLabel {
+	address := net.ParseIP(ip)
+	if address == nil {
+		return nil
+	}
+	var best *riskLabel
+	bestBits := -1
+	for index := range labels {
+		if !labels[index].network.Contains(address) {
+			continue
+		}
+		if bits, _ := labels[index].network.Mask.Size(); bits > bestBits {
+			best, bestBits = &labels[index], bits
+		}
+	}
+	return best
+}
+
+func sumRiskFactors(factors []model.RiskFactor) int {
+	total := 0
+	for _, factor := range factors {
+		total += factor.Points
+	}
+	if total > riskMaxScore {
+		return riskMaxScore
+	}
+	if total < 0 {
+		return 0
+	}
+	return total
+}
+
+func sortAssetRiskScores(scores []model.AssetRiskScore) {
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		if scores[i].IP != scores[j].IP {
+			return scores[i].IP < scores[j].IP
+		}
+		return scores[i].Port < scores[j].Port
+	})
+}
+
+func loadScanTaskRunRisk(db *sql.DB, snapshot *model.ScanTaskRunSnapshot) error {
+	rows, err := db.Query(`
+		SELECT scope, ip, port, score, level, factors_json
+		FROM scan_task_run_risk_scores
+		WHERE scan_task_run_id = ?
+		ORDER BY score DESC, ip ASC, port ASC`, snapshot.RunID)
+	if isMissingRiskTable(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	risk := model.ScanTaskRunRisk{Hosts: make([]model.AssetRiskScore, 0), Endpoints: make([]model.AssetRiskScore, 0)}
+	scored := false
+	for rows.Next() {
+		var scope, factorsJSON string
+		var score model.AssetRiskScore
+		if err := rows.Scan(&scope, &score.IP, &score.Port, &score.Score, &score.Level, &factorsJSON); err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(factorsJSON), &score.Factors); err != nil {
+			return fmt.Errorf("decode risk factors of %s %s:%d: %w", scope, score.IP, score.Port, err)
+		}
+		switch scope {
+		case riskScopeTask:
+			risk.Score, risk.Level, scored = score.Score, score.Level, true
+		case riskScopeHost:
+			risk.Hosts = append(risk.Hosts, score)
+		case riskScopeEndpoint:
+			risk.Endpoints = append(risk.Endpoints, score)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if scored {
+		snapshot.Risk = &risk
+	}
+	return nil
+}
+
+// latestHostRiskScores returns, per IP, the highest host score of the latest
+// successful run of each task.
+func latestHostRiskScores(db *sql.DB) (map[string]int, error) {
+	scores := make(map[string]int)
+	rows, err := db.Query(`
+		SELECT risk.ip, MAX(risk.score)
+		FROM scan_task_run_risk_scores AS risk
+		JOIN scan_task_runs AS run ON run.id = risk.scan_task_run_id
+		WHERE risk.scope = ? AND run.id = (
+			SELECT latest.id FROM scan_task_runs AS latest
+			WHERE latest.scan_task_id = run.scan_task_id AND latest.status = ? AND latest.snapshot_written_at IS NOT NULL
+			ORDER BY latest.sequence DESC, latest.id DESC LIMIT 1
+		)
+		GROUP BY risk.ip`, riskScopeHost, model.ScanTaskRunStatusSuccess)
+	if isMissingRiskTable(err) {
+		return scores, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ip string
+		var score int
+		if err := rows.Scan(&ip, &score); err != nil {
+			return nil, err
+		}
+		scores[ip] = score
+	}
+	return scores, rows.Err()
+}
+
+// normalizeCriticalityCIDR accepts a CIDR or a single address and returns the
+// network in canonical form.
+func normalizeCriticalityCIDR(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if address := net.ParseIP(value); address != nil {
+		if address.To4() != nil {
+			return address.String() + "/32", nil
+		}
+		return address.String() + "/128", nil
+	}
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid criticality CIDR: %s", value)
+	}
+	return network.String(), nil
+}
+
+// SetAssetCriticalityLabel creates or replaces the label of one CIDR.
+func SetAssetCriticalityLabel(db *sql.DB, cidr, level, note string) (model.AssetCriticalityLabel, error) {
+	normalized, err := normalizeCriticalityCIDR(cidr)
+	if err != nil {
+		return model.AssetCriticalityLabel{}, err
+	}
+	level = strings.ToLower(strings.TrimSpace(level))
+	if !model.IsAssetCriticality(level) {
+		return model.AssetCriticalityLabel{}, fmt.Errorf("invalid criticality level: %s", level)
+	}
+	if _, err := db.Exec(`
+		INSERT INTO asset_criticality_labels (cidr, level, note) VALUES (?, ?, ?)
+		ON CONFLICT(cidr) DO UPDATE SET level = excluded.level, note = excluded.note, updated_at = datetime('now')`,
+		normalized, level, strings.TrimSpace(note)); err != nil {
+		return model.AssetCriticalityLabel{}, err
+	}
+	var label model.AssetCriticalityLabel
+	err = db.QueryRow(`SELECT id, cidr, level, note, created_at, updated_at FROM asset_criticality_labels WHERE cidr = ?`, normalized).
+		Scan(&label.ID, &label.CIDR, &label.Level, &label.Note, &label.CreatedAt, &label.UpdatedAt)
+	return label, err
+}
+
+func ListAssetCriticalityLabels(db *sql.DB) ([]model.AssetCriticalityLabel, error) {
+	return listAssetCriticalityLabels(db)
+}
+
+type riskQueryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func listAssetCriticalityLabels(queryer riskQueryer) ([]model.AssetCriticalityLabel, error) {
+	labels := make([]model.AssetCriticalityLabel, 0)
+	rows, err := queryer.Query(`SELECT id, cidr, level, note, created_at, updated_at FROM asset_criticality_labels ORDER BY cidr ASC`)
+	if isMissingRiskTable(err) {
+		return labels, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var label model.AssetCriticalityLabel
+		if err := rows.Scan(&label.ID, &label.CIDR, &label.Level, &label.Note, &label.CreatedAt, &label.UpdatedAt); err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+	return labels, rows.Err()
+}
+
+func RemoveAssetCriticalityLabel(db *sql.DB, cidr string) error {
+	normalized, err := normalizeCriticalityCIDR(cidr)
+	if err != nil {
+		return err
+	}
+	result, err := db.Exec(`DELETE FROM asset_criticality_labels WHERE cidr = ?`, normalized)
+	if err != nil {
+		return err
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrAssetCriticalityLabelNotFound
+	}
+	return nil
+}
+
+func isMissingRiskTable(err error) bool {
+	if err == nil {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "no such table: scan_task_run_risk_scores") || strings.Contains(message, "no such table: asset_criticality_labels")
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"golandproject/yscan/internal/model"
+)
+
+func TestSaveScanTaskRunSnapshotScoresEndpointHostAndTaskRisk(t *testing.T) {
+	db := openTestDB(t)
+	if err := initSQLiteSchema(db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+	if _, err := ImportCVERecords(db, []model.CVERecord{{ID: "CVE-2024-1111", CVSSVersion: "3.1", CVSSScore: 9.8, CVSSSeverity: "CRITICAL"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReplaceKnownExploitedCVEs(db, []model.KnownExploitedCVE{{CVEID: "CVE-2024-1111", DateAdded: "2024-05-01"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SetAssetCriticalityLabel(db, "192.168.150.0/24", "medium", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SetAssetCriticalityLabel(db, "192.168.150.0/24", "LOW", "lab network"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SetAssetCriticalityLabel(db, "192.168.150.30", "high", "payments"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SetAssetCriticalityLabel(db, "192.168.150.0/24", "urgent", ""); err == nil {
+		t.Fatal("unknown criticality level was accepted")
+	}
+	labels, err := ListAssetCriticalityLabels(db)
+	if err != nil || len(labels) != 2 || labels[0].CIDR != "192.168.150.0/24" || labels[0].Level != "low" || labels[0].Note != "lab network" || labels[1].CIDR != "192.168.150.30/32" {
+		t.Fatalf("labels=%#v err=%v", labels, err)
+	}
+
+	exploited := model.ScanTaskRunVulnerability{
+		FindingKey: "CVE-2024-1111:http://192.168.150.30:8080", TemplateID: "CVE-2024-1111", Severity: "critical",
+		Target: "http://192.168.150.30:8080", TargetIP: "192.168.150.30", TargetPort: 8080,
+	}
+	task := createScheduledTaskForTest(t, db, "192.168.150.0/24")
+	first := createRunningTaskRun(t, db, task.ID, "2026-06-01T02:00:00Z")
+	if err := SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{
+		RunID:           first.ID,
+		Ports:           []model.ScanTaskRunPort{{IP: "192.168.150.30", Port: 8080, ServiceType: "http"}},
+		Vulnerabilities: []model.ScanTaskRunVulnerability{exploited},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE scan_task_runs SET status = 'success' WHERE id = ?`, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	firstSnapshot, err := GetScanTaskRunSnapshot(db, first.ID)
+	if err != nil || firstSnapshot.Risk == nil || firstSnapshot.Risk.Score != 94 || firstSnapshot.Risk.Level != model.RiskLevelCritical {
+		t.Fatalf("first run risk=%#v err=%v", firstSnapshot.Risk, err)
+	}
+
+	accepted := model.ScanTaskRunVulnerability{
+		FindingKey: "exposed-panel:https://192.168.150.20", TemplateID: "exposed-panel", Severity: "high",
+		Target: "https://192.168.150.20", TargetIP: "192.168.150.20", TargetPort: 443,
+	}
+	if _, err := db.Exec(`INSERT INTO finding_triage (finding_key, state, risk_accepted_until) VALUES (?, ?, '2099-01-01T00:00:00Z')`, accepted.FindingKey, model.FindingStateRiskAccepted); err != nil {
+		t.Fatal(err)
+	}
+	second := createRunningTaskRun(t, db, task.ID, "2026-08-01T02:00:00Z")
+	if err := SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{
+		RunID: second.ID,
+		Ports: []model.ScanTaskRunPort{
+			{IP: "192.168.150.20", Port: 22, ServiceType: "ssh"},
+			{IP: "192.168.150.30", Port: 3306, ServiceType: "mysql"},
+			{IP: "192.168.150.30", Port: 8080, ServiceType: "http"},
+		},
+		Vulnerabilities: []model.ScanTaskRunVulnerability{accepted, exploited},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := GetScanTaskRunSnapshot(db, second.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &model.ScanTaskRunRisk{
+		Score: 100, Level: model.RiskLevelCritical,
+		Hosts: []model.AssetRiskScore{
+			{IP: "192.168.150.30", Score: 100, Level: model.RiskLevelCritical, Factors: []model.RiskFactor{
+				{Kind: model.RiskFactorEndpoint, Detail: "192.168.150.30:8080", Points: 100},
+				{Kind: model.RiskFactorSpread, Detail: "1 more scored endpoint", Points: 2},
+			}},
+			{IP: "192.168.150.20", Score: 11, Level: model.RiskLevelLow, Factors: []model.RiskFactor{
+				{Kind: model.RiskFactorEndpoint, Detail: "192.168.150.20:22", Points: 11},
+			}},
+		},
+		Endpoints: []model.AssetRiskScore{
+			{IP: "192.168.150.30", Port: 8080, Score: 100, Level: model.RiskLevelCritical, Factors: []model.RiskFactor{
+				{Kind: model.RiskFactorFinding, Detail: "critical CVE-2024-1111", Points: 40},
+				{Kind: model.RiskFactorKnownExploited, Detail: "CVE-2024-1111 validated", Points: 25},
+				{Kind: model.RiskFactorCVSS, Detail: "CVE-2024-1111 CVSS 9.8", Points: 10},
+				{Kind: model.RiskFactorAge, Detail: "CVE-2024-1111 open 61 days", Points: 5},
+				{Kind: model.RiskFactorCriticality, Detail: "high (192.168.150.30/32)", Points: 20},
+			}},
+			{IP: "192.168.150.30", Port: 3306, Score: 25, Level: model.RiskLevelLow, Factors: []model.RiskFactor{
+				{Kind: model.RiskFactorExposure, Detail: "database (mysql)", Points: 20},
+				{Kind: model.RiskFactorCriticality, Detail: "high (192.168.150.30/32)", Points: 5},
+			}},
+			{IP: "192.168.150.20", Port: 22, Score: 11, Level: model.RiskLevelLow, Factors: []model.RiskFactor{
+				{Kind: model.RiskFactorExposure, Detail: "remote access (ssh)", Points: 15},
+				{Kind: model.RiskFactorCriticality, Detail: "low (192.168.150.0/24)", Points: -4},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(snapshot.Risk, want) {
+		t.Fatalf("risk = %#v, want %#v", snapshot.Risk, want)
+	}
+
+	if _, err := db.Exec(`UPDATE scan_task_runs SET status = 'success' WHERE id = ?`, second.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := SyncHostInventory(db, task.Target, []string{"192.168.150.10", "192.168.150.20", "192.168.150.30"}); err != nil {
+		t.Fatal(err)
+	}
+	hosts, err := ListHostInventory(db, HostInventoryQuery{Sort: HostInventorySortRisk})
+	if err != nil || len(hosts) != 3 {
+		t.Fatalf("hosts=%#v err=%v", hosts, err)
+	}
+	if hosts[0].IP != "192.168.150.30" || hosts[0].RiskScore != 100 || hosts[0].RiskLevel != model.RiskLevelCritical ||
+		hosts[1].IP != "192.168.150.20" || hosts[1].RiskScore != 11 || hosts[2].IP != "192.168.150.10" || hosts[2].RiskLevel != model.RiskLevelNone {
+		t.Fatalf("hosts by risk=%#v", hosts)
+	}
+	if _, err := ListHostInventory(db, HostInventoryQuery{Sort: "severity"}); err == nil {
+		t.Fatal("unknown host inventory sort was accepted")
+	}
+
+	if err := RemoveAssetCriticalityLabel(db, "192.168.150.30/32"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveAssetCriticalityLabel(db, "192.168.150.30"); !errors.Is(err, ErrAssetCriticalityLabelNotFound) {
+		t.Fatalf("remove missing label err=%v", err)
+	}
+}
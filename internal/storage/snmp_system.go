@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"strings"
+
+	"golandproject/yscan/internal/model"
+)
+
+// snmpConclusionProtocol marks conclusions drawn from SNMP inventory rather
+// than from a fingerprint rule match.
+const snmpConclusionProtocol = "snmp"
+
+func validateScanTaskRunSNMPSystems(systems []model.ScanTaskRunSNMPSystem) error {
+	seen := make(map[string]struct{}, len(systems))
+	for _, system := range systems {
+		ip := strings.TrimSpace(system.IP)
+		if net.ParseIP(ip) == nil || system.Port < 1 || system.Port > 65535 || strings.TrimSpace(system.Credential) == "" {
+			return fmt.Errorf("invalid snapshot SNMP system: %s", ip)
+		}
+		if _, duplicate := seen[ip]; duplicate {
+			return fmt.Errorf("duplicate snapshot SNMP system: %s", ip)
+		}
+		seen[ip] = struct{}{}
+		indexes := make(map[int]struct{}, len(system.Interfaces))
+		for _, entry := range system.Interfaces {
+			if entry.Index < 1 {
+				return fmt.Errorf("invalid snapshot SNMP interface: %s/%d", ip, entry.Index)
+			}
+			if _, duplicate := indexes[entry.Index]; duplicate {
+				return fmt.Errorf("duplicate snapshot SNMP interface: %s/%d", ip, entry.Index)
+			}
+			indexes[entry.Index] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// saveScanTaskRunSNMPSystemsTx stores the inventory and concludes the mapped
+// operating system on the SNMP endpoint, so reports and template selection
+// see network gear the same way they see rule-matched products.
+func saveScanTaskRunSNMPSystemsTx(tx *sql.Tx, runID int64, systems []model.ScanTaskRunSNMPSystem) error {
+	for _, system := range systems {
+		ip := strings.TrimSpace(system.IP)
+		if _, err := tx.Exec(`
+			INSERT INTO scan_task_run_snmp_systems
+				(scan_task_run_id, ip, port, credential, sys_descr, sys_object_id, sys_name, sys_location, vendor, product_key, version, cpe)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			runID, ip, system.Port, system.Credential, system.SysDescr, system.SysObjectID, system.SysName, system.SysLocation,
+			system.Vendor, system.Product, system.Version, system.CPE); err != nil {
+			return err
+		}
+		for _, entry := range system.Interfaces {
+			if _, err := tx.Exec(`
+				INSERT INTO scan_task_run_snmp_interfaces
+					(scan_task_run_id, ip, if_index, description, if_type, speed, phys_address, admin_status, oper_status)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				runID, ip, entry.Index, entry.Description, entry.Type, entry.Speed, entry.PhysAddress, entry.AdminStatus, entry.OperStatus); err != nil {
+				return err
+			}
+		}
+		if err := concludeSNMPSystemTx(tx, runID, system); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// concludeScanTaskRunSNMPSystemsTx re-derives the SNMP operating system
+// conclusions of a derived run from the inventory copied into it.
+func concludeScanTaskRunSNMPSystemsTx(tx *sql.Tx, runID int64) error {
+	rows, err := tx.Query(`
+		SELECT ip, port, product_key, version, cpe
+		FROM scan_task_run_snmp_systems
+		WHERE scan_task_run_id = ? AND product_key <> ''
+		ORDER BY ip ASC`, runID)
+	if isMissingSNMPSystemTable(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	systems := make([]model.ScanTaskRunSNMPSystem, 0)
+	for rows.Next() {
+		var system model.ScanTaskRunSNMPSystem
+		if err := rows.Scan(&system.IP, &system.Port, &system.Product, &system.Version, &system.CPE); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		systems = append(systems, system)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return err
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	for _, system := range systems {
+		if err := concludeSNMPSystemTx(tx, runID, system); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// concludeSNMPSystemTx records the mapped product of one inventory unless a
+// rule match already concluded it on the same endpoint.
+func concludeSNMPSystemTx(tx *sql.Tx, runID int64, system model.ScanTaskRunSNMPSystem) error {
+	if system.Product == "" {
+		return nil
+	}
+	versionStatus, versionSources := observedConclusionStatus(system.Version)
+	cpeStatus, cpeSources := observedConclusionStatus(system.CPE)
+	_, err := tx.Exec(`
+		INSERT INTO asset_fingerprint_conclusions
+			(scan_task_run_id, ip, port, protocol, product_key, product_role, version, cpe, tags_json, conclusion_status,
+			 product_status, product_source_count, version_status, version_source_count, cpe_status, cpe_source_count)
+		VALUES (?, ?, ?, ?, ?, 'operating_system', ?, ?, '["snmp"]', 'matched', 'matched', 1, ?, ?, ?, ?)
+		ON CONFLICT(scan_task_run_id, ip, port, protocol, product_key) DO NOTHING`,
+		runID, strings.TrimSpace(system.IP), system.Port, snmpConclusionProtocol, system.Product, nullIfEmpty(system.Version), nullIfEmpty(system.CPE),
+		versionStatus, versionSources, cpeStatus, cpeSources)
+	return err
+}
+
+func observedConclusionStatus(value string) (string, int) {
+	if value == "" {
+		return "unobserved", 0
+	}
+	return "matched", 1
+}
+
+func loadScanTaskRunSNMPSystems(db *sql.DB, snapshot *model.ScanTaskRunSnapshot) error {
+	rows, err := db.Query(`
+		SELECT ip, port, credential, sys_descr, sys_object_id, sys_name, sys_location, vendor, product_key, version, cpe
+		FROM scan_task_run_snmp_systems
+		WHERE scan_task_run_id = ?
+		ORDER BY ip ASC`, snapshot.RunID)
+	if isMissingSNMPSystemTable(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	byIP := make(map[string]int)
+	for rows.Next() {
+		var system model.ScanTaskRunSNMPSystem
+		if err := rows.Scan(&system.IP, &system.Port, &system.Credential, &system.SysDescr, &system.SysObjectID, &system.SysName,
+			&system.SysLocation, &system.Vendor, &system.Product, &system.Version, &system.CPE); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		byIP[system.IP] = len(snapshot.SNMPSystems)
+		snapshot.SNMPSystems = append(snapshot.SNMPSystems, system)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return err
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	rows, err = db.Query(`
+		SELECT ip, if_index, description, if_type, speed, phys_address, admin_status, oper_status
+		FROM scan_task_run_snmp_interfaces
+		WHERE scan_task_run_id = ?
+		ORDER BY ip ASC, if_index ASC`, snapshot.RunID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ip string
+		var entry model.ScanTaskRunSNMPInterface
+		if err := rows.Scan(&ip, &entry.Index, &entry.Description, &entry.Type, &entry.Speed, &entry.PhysAddress, &entry.AdminStatus, &entry.OperStatus); err != nil {
+			return err
+		}
+		if position, found := byIP[ip]; found {
+			snapshot.SNMPSystems[position].Interfaces = append(snapshot.SNMPSystems[position].Interfaces, entry)
+		}
+	}
+	return rows.Err()
+}
+
+func isMissingSNMPSystemTable(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "no such table: scan_task_run_snmp_")
+}
@@ -240,6 +240,90 @@ func ensureSQLiteMigrations(db *sql.DB) error {
 			error_message TEXT,
 			PRIMARY KEY (scan_task_run_id, ip, port, protocol)
 		)`,
+		`CREATE TABLE IF NOT EXISTS scan_task_run_host_identities (
+			scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+			ip TEXT NOT NULL,
+			port INTEGER NOT NULL,
+			source TEXT NOT NULL,
+			computer_name TEXT NOT NULL DEFAULT '',
+			domain_name TEXT NOT NULL DEFAULT '',
+			dns_computer_name TEXT NOT NULL DEFAULT '',
+			dns_domain_name TEXT NOT NULL DEFAULT '',
+			dns_tree_name TEXT NOT NULL DEFAULT '',
+			os_version TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (scan_task_run_id, ip, port, source)
+		)`,
+		`CREATE TABLE IF NOT EXISTS scan_task_run_ssh_host_keys (
+			scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+			ip TEXT NOT NULL,
+			port INTEGER NOT NULL,
+			algorithm TEXT NOT NULL,
+			fingerprint TEXT NOT NULL,
+			bits INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (scan_task_run_id, ip, port, algorithm)
+		)`,
+		`CREATE TABLE IF NOT EXISTS scan_task_run_snmp_systems (
+			scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+			ip TEXT NOT NULL,
+			port INTEGER NOT NULL,
+			credential TEXT NOT NULL,
+			sys_descr TEXT NOT NULL DEFAULT '',
+			sys_object_id TEXT NOT NULL DEFAULT '',
+			sys_name TEXT NOT NULL DEFAULT '',
+			sys_location TEXT NOT NULL DEFAULT '',
+			vendor TEXT NOT NULL DEFAULT '',
+			product_key TEXT NOT NULL DEFAULT '',
+			version TEXT NOT NULL DEFAULT '',
+			cpe TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (scan_task_run_id, ip)
+		)`,
+		`CREATE TABLE IF NOT EXISTS scan_task_run_snmp_interfaces (
+			scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+			ip TEXT NOT NULL,
+			if_index INTEGER NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			if_type INTEGER NOT NULL DEFAULT 0,
+			speed INTEGER NOT NULL DEFAULT 0,
+			phys_address TEXT NOT NULL DEFAULT '',
+			admin_status TEXT NOT NULL DEFAULT '',
+			oper_status TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (scan_task_run_id, ip, if_index)
+		)`,
+		`CREATE TABLE IF NOT EXISTS scan_task_run_web_assets (
+			scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+			ip TEXT NOT NULL,
+			port INTEGER NOT NULL,
+			protocol TEXT NOT NULL,
+			path TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			captured_length INTEGER NOT NULL DEFAULT 0,
+			sha256 TEXT NOT NULL,
+			truncated INTEGER NOT NULL DEFAULT 0,
+			versions_json TEXT NOT NULL DEFAULT '[]',
+			PRIMARY KEY (scan_task_run_id, ip, port, protocol, path)
+		)`,
+		`CREATE TABLE IF NOT EXISTS scan_task_run_weaknesses (
+			scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+			ip TEXT NOT NULL,
+			port INTEGER NOT NULL,
+			protocol TEXT NOT NULL,
+			check_id TEXT NOT NULL,
+			subject TEXT NOT NULL DEFAULT '',
+			severity TEXT NOT NULL,
+			path TEXT NOT NULL,
+			detail TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (scan_task_run_id, ip, port, protocol, check_id, subject)
+		)`,
+		`CREATE TABLE IF NOT EXISTS scan_task_run_tls_fingerprints (
+			scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+			ip TEXT NOT NULL,
+			port INTEGER NOT NULL,
+			fingerprint TEXT NOT NULL,
+			ja4s TEXT NOT NULL DEFAULT '',
+			responses INTEGER NOT NULL,
+			variants INTEGER NOT NULL,
+			PRIMARY KEY (scan_task_run_id, ip, port)
+		)`,
 		`CREATE TABLE IF NOT EXISTS scan_task_run_vulnerabilities (
 			scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id),
 			finding_key TEXT NOT NULL,
@@ -432,6 +516,138 @@ func ensureSQLiteMigrations(db *sql.DB) error {
 			created_at DATETIME NOT NULL DEFAULT (datetime('now')),
 			UNIQUE(scan_task_run_id, ip, port, protocol, product_key)
 		)`,
+		`CREATE TABLE IF NOT EXISTS fingerprint_overrides (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			kind TEXT NOT NULL CHECK (kind IN ('suppress', 'assert')),
+			source_key TEXT NOT NULL DEFAULT '',
+			source_rule_id TEXT NOT NULL DEFAULT '',
+			product_key TEXT NOT NULL DEFAULT '',
+			ip TEXT NOT NULL DEFAULT '',
+			port INTEGER NOT NULL DEFAULT 0,
+			protocol TEXT NOT NULL DEFAULT '',
+			version TEXT NOT NULL DEFAULT '',
+			cpe TEXT NOT NULL DEFAULT '',
+			reason TEXT NOT NULL,
+			expires_at TEXT,
+			created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+			revoked_at DATETIME
+		)`,
+		`CREATE TABLE IF NOT EXISTS scan_task_run_fingerprint_overrides (
+			scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+			fingerprint_override_id INTEGER NOT NULL REFERENCES fingerprint_overrides(id),
+			ip TEXT NOT NULL,
+			port INTEGER NOT NULL,
+			protocol TEXT NOT NULL,
+			product_key TEXT NOT NULL,
+			effect TEXT NOT NULL CHECK (effect IN ('suppressed', 'asserted', 'superseded')),
+			reason TEXT NOT NULL,
+			PRIMARY KEY (scan_task_run_id, fingerprint_override_id, ip, port, protocol, product_key)
+		)`,
+		`CREATE TABLE IF NOT EXISTS scan_task_run_raw_evidence (
+			scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+			ip TEXT NOT NULL,
+			port INTEGER NOT NULL,
+			sequence INTEGER NOT NULL,
+			protocol TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			probe_name TEXT NOT NULL DEFAULT '',
+			summary TEXT NOT NULL,
+			sealed BLOB NOT NULL,
+			PRIMARY KEY (scan_task_run_id, ip, port, sequence)
+		)`,
+		`CREATE TABLE IF NOT EXISTS scan_task_run_derivations (
+			scan_task_run_id INTEGER PRIMARY KEY REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+			source_scan_task_run_id INTEGER REFERENCES scan_task_runs(id) ON DELETE SET NULL,
+			created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+		)`,
+		`CREATE TABLE IF NOT EXISTS cve_records (
+			cve_id TEXT PRIMARY KEY,
+			published TEXT NOT NULL DEFAULT '',
+			last_modified TEXT NOT NULL DEFAULT '',
+			description TEXT NOT NULL DEFAULT '',
+			cvss_version TEXT NOT NULL DEFAULT '',
+			cvss_score REAL NOT NULL DEFAULT 0,
+			cvss_severity TEXT NOT NULL DEFAULT '',
+			cvss_vector TEXT NOT NULL DEFAULT '',
+			imported_at DATETIME NOT NULL DEFAULT (datetime('now'))
+		)`,
+		`CREATE TABLE IF NOT EXISTS cve_cpe_matches (
+			cve_id TEXT NOT NULL REFERENCES cve_records(cve_id) ON DELETE CASCADE,
+			criteria TEXT NOT NULL,
+			part TEXT NOT NULL,
+			vendor TEXT NOT NULL,
+			product TEXT NOT NULL,
+			version TEXT NOT NULL,
+			version_update TEXT NOT NULL DEFAULT '',
+			version_start_including TEXT NOT NULL DEFAULT '',
+			version_start_excluding TEXT NOT NULL DEFAULT '',
+			version_end_including TEXT NOT NULL DEFAULT '',
+			version_end_excluding TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE TABLE IF NOT EXISTS cve_known_exploited (
+			cve_id TEXT PRIMARY KEY,
+			vendor_project TEXT NOT NULL DEFAULT '',
+			product TEXT NOT NULL DEFAULT '',
+			vulnerability_name TEXT NOT NULL DEFAULT '',
+			date_added TEXT NOT NULL DEFAULT '',
+			due_date TEXT NOT NULL DEFAULT '',
+			known_ransomware TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE TABLE IF NOT EXISTS scan_task_run_cve_matches (
+			scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+			ip TEXT NOT NULL,
+			port INTEGER NOT NULL,
+			protocol TEXT NOT NULL,
+			product_key TEXT NOT NULL,
+			cpe TEXT NOT NULL,
+			version TEXT NOT NULL,
+			cve_id TEXT NOT NULL,
+			criteria TEXT NOT NULL,
+			version_range TEXT NOT NULL DEFAULT '',
+			cvss_version TEXT NOT NULL DEFAULT '',
+			cvss_score REAL NOT NULL DEFAULT 0,
+			severity TEXT NOT NULL DEFAULT '',
+			known_exploited INTEGER NOT NULL DEFAULT 0,
+			kev_date_added TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (scan_task_run_id, ip, port, protocol, product_key, cve_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS finding_triage (
+			finding_key TEXT PRIMARY KEY,
+			state TEXT NOT NULL DEFAULT 'open',
+			assignee TEXT NOT NULL DEFAULT '',
+			risk_accepted_until TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+			updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+		)`,
+		`CREATE TABLE IF NOT EXISTS finding_triage_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			finding_key TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			state TEXT NOT NULL DEFAULT '',
+			assignee TEXT NOT NULL DEFAULT '',
+			author TEXT NOT NULL DEFAULT '',
+			comment TEXT NOT NULL DEFAULT '',
+			scan_task_run_id INTEGER REFERENCES scan_task_runs(id) ON DELETE SET NULL,
+			created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+		)`,
+		`CREATE TABLE IF NOT EXISTS scan_task_run_risk_scores (
+			scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+			scope TEXT NOT NULL CHECK (scope IN ('task', 'host', 'endpoint')),
+			ip TEXT NOT NULL DEFAULT '',
+			port INTEGER NOT NULL DEFAULT 0,
+			score INTEGER NOT NULL DEFAULT 0,
+			level TEXT NOT NULL,
+			factors_json TEXT NOT NULL DEFAULT '[]',
+			PRIMARY KEY (scan_task_run_id, scope, ip, port)
+		)`,
+		`CREATE TABLE IF NOT EXISTS asset_criticality_labels (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			cidr TEXT NOT NULL UNIQUE,
+			level TEXT NOT NULL CHECK (level IN ('low', 'medium', 'high', 'critical')),
+			note TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+			updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+		)`,
 		`CREATE TABLE IF NOT EXISTS template_mapping_imports (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			revision TEXT NOT NULL,
@@ -547,6 +763,17 @@ func ensureSQLiteMigrations(db *sql.DB) error {
 		`CREATE INDEX IF NOT EXISTS idx_scan_task_run_protocol_evidence_run ON scan_task_run_protocol_evidence(scan_task_run_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_scan_task_run_vulnerabilities_run ON scan_task_run_vulnerabilities(scan_task_run_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_scan_task_run_endpoint_validation_run ON scan_task_run_endpoint_validation(scan_task_run_id, ip, port)`,
+		`CREATE INDEX IF NOT EXISTS idx_scan_task_run_host_identities_ip ON scan_task_run_host_identities(ip, scan_task_run_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_scan_task_run_ssh_host_keys_endpoint ON scan_task_run_ssh_host_keys(ip, port, algorithm, scan_task_run_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_scan_task_run_snmp_systems_ip ON scan_task_run_snmp_systems(ip, scan_task_run_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_scan_task_run_web_assets_sha256 ON scan_task_run_web_assets(sha256)`,
+		`CREATE INDEX IF NOT EXISTS idx_scan_task_run_weaknesses_check ON scan_task_run_weaknesses(check_id, scan_task_run_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_scan_task_run_tls_fingerprints_ja4s ON scan_task_run_tls_fingerprints(ja4s, scan_task_run_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_cve_cpe_matches_product ON cve_cpe_matches(vendor, product)`,
+		`CREATE INDEX IF NOT EXISTS idx_cve_cpe_matches_cve ON cve_cpe_matches(cve_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_scan_task_run_cve_matches_cve ON scan_task_run_cve_matches(cve_id, scan_task_run_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_finding_triage_events_key ON finding_triage_events(finding_key, id)`,
+		`CREATE INDEX IF NOT EXISTS idx_scan_task_run_risk_scores_ip ON scan_task_run_risk_scores(ip, scope, scan_task_run_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_template_candidate_endpoints_run ON scan_task_run_template_candidate_endpoints(scan_task_run_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_template_candidate_products_run ON scan_task_run_template_candidate_products(scan_task_run_id, ip, port, protocol)`,
 		`CREATE INDEX IF NOT EXISTS idx_fingerprint_imports_source_active ON fingerprint_imports(fingerprint_source_id, is_active)`,
@@ -1207,6 +1434,97 @@ CREATE TABLE IF NOT EXISTS scan_task_run_endpoint_validation (
     PRIMARY KEY (scan_task_run_id, ip, port, protocol)
 );
 
+CREATE TABLE IF NOT EXISTS scan_task_run_host_identities (
+    scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+    ip TEXT NOT NULL,
+    port INTEGER NOT NULL,
+    source TEXT NOT NULL CHECK (source IN ('smb', 'rdp', 'http', 'winrm')),
+    computer_name TEXT NOT NULL DEFAULT '',
+    domain_name TEXT NOT NULL DEFAULT '',
+    dns_computer_name TEXT NOT NULL DEFAULT '',
+    dns_domain_name TEXT NOT NULL DEFAULT '',
+    dns_tree_name TEXT NOT NULL DEFAULT '',
+    os_version TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (scan_task_run_id, ip, port, source)
+);
+
+CREATE TABLE IF NOT EXISTS scan_task_run_ssh_host_keys (
+    scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+    ip TEXT NOT NULL,
+    port INTEGER NOT NULL,
+    algorithm TEXT NOT NULL,
+    fingerprint TEXT NOT NULL,
+    bits INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (scan_task_run_id, ip, port, algorithm)
+);
+
+CREATE TABLE IF NOT EXISTS scan_task_run_snmp_systems (
+    scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+    ip TEXT NOT NULL,
+    port INTEGER NOT NULL,
+    credential TEXT NOT NULL,
+    sys_descr TEXT NOT NULL DEFAULT '',
+    sys_object_id TEXT NOT NULL DEFAULT '',
+    sys_name TEXT NOT NULL DEFAULT '',
+    sys_location TEXT NOT NULL DEFAULT '',
+    vendor TEXT NOT NULL DEFAULT '',
+    product_key TEXT NOT NULL DEFAULT '',
+    version TEXT NOT NULL DEFAULT '',
+    cpe TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (scan_task_run_id, ip)
+);
+
+CREATE TABLE IF NOT EXISTS scan_task_run_snmp_interfaces (
+    scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+    ip TEXT NOT NULL,
+    if_index INTEGER NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    if_type INTEGER NOT NULL DEFAULT 0,
+    speed INTEGER NOT NULL DEFAULT 0,
+    phys_address TEXT NOT NULL DEFAULT '',
+    admin_status TEXT NOT NULL DEFAULT '',
+    oper_status TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (scan_task_run_id, ip, if_index)
+);
+
+CREATE TABLE IF NOT EXISTS scan_task_run_web_assets (
+    scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+    ip TEXT NOT NULL,
+    port INTEGER NOT NULL,
+    protocol TEXT NOT NULL,
+    path TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    captured_length INTEGER NOT NULL DEFAULT 0,
+    sha256 TEXT NOT NULL,
+    truncated INTEGER NOT NULL DEFAULT 0,
+    versions_json TEXT NOT NULL DEFAULT '[]',
+    PRIMARY KEY (scan_task_run_id, ip, port, protocol, path)
+);
+
+CREATE TABLE IF NOT EXISTS scan_task_run_weaknesses (
+    scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+    ip TEXT NOT NULL,
+    port INTEGER NOT NULL,
+    protocol TEXT NOT NULL,
+    check_id TEXT NOT NULL,
+    subject TEXT NOT NULL DEFAULT '',
+    severity TEXT NOT NULL,
+    path TEXT NOT NULL,
+    detail TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (scan_task_run_id, ip, port, protocol, check_id, subject)
+);
+
+CREATE TABLE IF NOT EXISTS scan_task_run_tls_fingerprints (
+    scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+    ip TEXT NOT NULL,
+    port INTEGER NOT NULL,
+    fingerprint TEXT NOT NULL,
+    ja4s TEXT NOT NULL DEFAULT '',
+    responses INTEGER NOT NULL,
+    variants INTEGER NOT NULL,
+    PRIMARY KEY (scan_task_run_id, ip, port)
+);
+
 CREATE TABLE IF NOT EXISTS scan_task_run_vulnerabilities (
     scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id),
     finding_key      TEXT NOT NULL,
@@ -1416,6 +1734,150 @@ CREATE TABLE IF NOT EXISTS asset_fingerprint_conclusions (
     UNIQUE(scan_task_run_id, ip, port, protocol, product_key)
 );
 
+CREATE TABLE IF NOT EXISTS fingerprint_overrides (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL CHECK (kind IN ('suppress', 'assert')),
+    source_key TEXT NOT NULL DEFAULT '',
+    source_rule_id TEXT NOT NULL DEFAULT '',
+    product_key TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    port INTEGER NOT NULL DEFAULT 0,
+    protocol TEXT NOT NULL DEFAULT '',
+    version TEXT NOT NULL DEFAULT '',
+    cpe TEXT NOT NULL DEFAULT '',
+    reason TEXT NOT NULL,
+    expires_at TEXT,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    revoked_at DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS scan_task_run_fingerprint_overrides (
+    scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+    fingerprint_override_id INTEGER NOT NULL REFERENCES fingerprint_overrides(id),
+    ip TEXT NOT NULL,
+    port INTEGER NOT NULL,
+    protocol TEXT NOT NULL,
+    product_key TEXT NOT NULL,
+    effect TEXT NOT NULL CHECK (effect IN ('suppressed', 'asserted', 'superseded')),
+    reason TEXT NOT NULL,
+    PRIMARY KEY (scan_task_run_id, fingerprint_override_id, ip, port, protocol, product_key)
+);
+
+CREATE TABLE IF NOT EXISTS scan_task_run_raw_evidence (
+    scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+    ip TEXT NOT NULL,
+    port INTEGER NOT NULL,
+    sequence INTEGER NOT NULL,
+    protocol TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    probe_name TEXT NOT NULL DEFAULT '',
+    summary TEXT NOT NULL,
+    sealed BLOB NOT NULL,
+    PRIMARY KEY (scan_task_run_id, ip, port, sequence)
+);
+
+CREATE TABLE IF NOT EXISTS scan_task_run_derivations (
+    scan_task_run_id INTEGER PRIMARY KEY REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+    source_scan_task_run_id INTEGER REFERENCES scan_task_runs(id) ON DELETE SET NULL,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS cve_records (
+    cve_id TEXT PRIMARY KEY,
+    published TEXT NOT NULL DEFAULT '',
+    last_modified TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    cvss_version TEXT NOT NULL DEFAULT '',
+    cvss_score REAL NOT NULL DEFAULT 0,
+    cvss_severity TEXT NOT NULL DEFAULT '',
+    cvss_vector TEXT NOT NULL DEFAULT '',
+    imported_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS cve_cpe_matches (
+    cve_id TEXT NOT NULL REFERENCES cve_records(cve_id) ON DELETE CASCADE,
+    criteria TEXT NOT NULL,
+    part TEXT NOT NULL,
+    vendor TEXT NOT NULL,
+    product TEXT NOT NULL,
+    version TEXT NOT NULL,
+    version_update TEXT NOT NULL DEFAULT '',
+    version_start_including TEXT NOT NULL DEFAULT '',
+    version_start_excluding TEXT NOT NULL DEFAULT '',
+    version_end_including TEXT NOT NULL DEFAULT '',
+    version_end_excluding TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS cve_known_exploited (
+    cve_id TEXT PRIMARY KEY,
+    vendor_project TEXT NOT NULL DEFAULT '',
+    product TEXT NOT NULL DEFAULT '',
+    vulnerability_name TEXT NOT NULL DEFAULT '',
+    date_added TEXT NOT NULL DEFAULT '',
+    due_date TEXT NOT NULL DEFAULT '',
+    known_ransomware TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS scan_task_run_cve_matches (
+    scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+    ip TEXT NOT NULL,
+    port INTEGER NOT NULL,
+    protocol TEXT NOT NULL,
+    product_key TEXT NOT NULL,
+    cpe TEXT NOT NULL,
+    version TEXT NOT NULL,
+    cve_id TEXT NOT NULL,
+    criteria TEXT NOT NULL,
+    version_range TEXT NOT NULL DEFAULT '',
+    cvss_version TEXT NOT NULL DEFAULT '',
+    cvss_score REAL NOT NULL DEFAULT 0,
+    severity TEXT NOT NULL DEFAULT '',
+    known_exploited INTEGER NOT NULL DEFAULT 0,
+    kev_date_added TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (scan_task_run_id, ip, port, protocol, product_key, cve_id)
+);
+
+CREATE TABLE IF NOT EXISTS finding_triage (
+    finding_key TEXT PRIMARY KEY,
+    state TEXT NOT NULL DEFAULT 'open',
+    assignee TEXT NOT NULL DEFAULT '',
+    risk_accepted_until TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS finding_triage_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    finding_key TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    state TEXT NOT NULL DEFAULT '',
+    assignee TEXT NOT NULL DEFAULT '',
+    author TEXT NOT NULL DEFAULT '',
+    comment TEXT NOT NULL DEFAULT '',
+    scan_task_run_id INTEGER REFERENCES scan_task_runs(id) ON DELETE SET NULL,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS scan_task_run_risk_scores (
+    scan_task_run_id INTEGER NOT NULL REFERENCES scan_task_runs(id) ON DELETE CASCADE,
+    scope TEXT NOT NULL CHECK (scope IN ('task', 'host', 'endpoint')),
+    ip TEXT NOT NULL DEFAULT '',
+    port INTEGER NOT NULL DEFAULT 0,
+    score INTEGER NOT NULL DEFAULT 0,
+    level TEXT NOT NULL,
+    factors_json TEXT NOT NULL DEFAULT '[]',
+    PRIMARY KEY (scan_task_run_id, scope, ip, port)
+);
+
+CREATE TABLE IF NOT EXISTS asset_criticality_labels (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    cidr TEXT NOT NULL UNIQUE,
+    level TEXT NOT NULL CHECK (level IN ('low', 'medium', 'high', 'critical')),
+    note TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
 CREATE TABLE IF NOT EXISTS template_mapping_imports (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     revision TEXT NOT NULL,
@@ -1503,6 +1965,17 @@ CREATE INDEX IF NOT EXISTS idx_scan_task_run_ports_run ON scan_task_run_ports(sc
 CREATE INDEX IF NOT EXISTS idx_scan_task_run_protocol_evidence_run ON scan_task_run_protocol_evidence(scan_task_run_id);
 CREATE INDEX IF NOT EXISTS idx_scan_task_run_vulnerabilities_run ON scan_task_run_vulnerabilities(scan_task_run_id);
 CREATE INDEX IF NOT EXISTS idx_scan_task_run_endpoint_validation_run ON scan_task_run_endpoint_validation(scan_task_run_id, ip, port);
+CREATE INDEX IF NOT EXISTS idx_scan_task_run_host_identities_ip ON scan_task_run_host_identities(ip, scan_task_run_id);
+CREATE INDEX IF NOT EXISTS idx_scan_task_run_ssh_host_keys_endpoint ON scan_task_run_ssh_host_keys(ip, port, algorithm, scan_task_run_id);
+CREATE INDEX IF NOT EXISTS idx_scan_task_run_snmp_systems_ip ON scan_task_run_snmp_systems(ip, scan_task_run_id);
+CREATE INDEX IF NOT EXISTS idx_scan_task_run_web_assets_sha256 ON scan_task_run_web_assets(sha256);
+CREATE INDEX IF NOT EXISTS idx_scan_task_run_weaknesses_check ON scan_task_run_weaknesses(check_id, scan_task_run_id);
+CREATE INDEX IF NOT EXISTS idx_scan_task_run_tls_fingerprints_ja4s ON scan_task_run_tls_fingerprints(ja4s, scan_task_run_id);
+CREATE INDEX IF NOT EXISTS idx_cve_cpe_matches_product ON cve_cpe_matches(vendor, product);
+CREATE INDEX IF NOT EXISTS idx_cve_cpe_matches_cve ON cve_cpe_matches(cve_id);
+CREATE INDEX IF NOT EXISTS idx_scan_task_run_cve_matches_cve ON scan_task_run_cve_matches(cve_id, scan_task_run_id);
+CREATE INDEX IF NOT EXISTS idx_finding_triage_events_key ON finding_triage_events(finding_key, id);
+CREATE INDEX IF NOT EXISTS idx_scan_task_run_risk_scores_ip ON scan_task_run_risk_scores(ip, scope, scan_task_run_id);
 CREATE INDEX IF NOT EXISTS idx_template_candidate_endpoints_run ON scan_task_run_template_candidate_endpoints(scan_task_run_id);
 CREATE INDEX IF NOT EXISTS idx_template_candidate_products_run ON scan_task_run_template_candidate_products(scan_task_run_id, ip, port, protocol);
 CREATE INDEX IF NOT EXISTS idx_fingerprint_imports_source_active ON fingerprint_imports(fingerprint_source_id, is_active);
@@ -2983,13 +3456,31 @@ func ListHostScopeMemberships(db *sql.DB, query HostScopeMembershipQuery) ([]mod
 	return memberships, nil
 }
 
+const (
+	HostInventorySortIP   = "ip"
+	HostInventorySortRisk = "risk"
+)
+
 type HostInventoryQuery struct {
 	Scope    string
 	Source   string
 	IsActive *bool
+	// Search matches the IP or any collected host identity name.
+	Search string
+	// Sort is HostInventorySortIP (the default) or HostInventorySortRisk,
+	// which lists the riskiest hosts first.
+	Sort string
 }
 
 func ListHostInventory(db *sql.DB, query HostInventoryQuery) ([]model.HostInventory, error) {
+	sortByRisk := false
+	switch strings.TrimSpace(query.Sort) {
+	case "", HostInventorySortIP:
+	case HostInventorySortRisk:
+		sortByRisk = true
+	default:
+		return nil, fmt.Errorf("invalid host inventory sort: %s", query.Sort)
+	}
 	clauses := make([]string, 0, 2)
 	args := make([]interface{}, 0, 2)
 
@@ -3006,6 +3497,11 @@ func ListHostInventory(db *sql.DB, query HostInventoryQuery) ([]model.HostInvent
 		)`)
 		args = append(args, scope)
 	}
+	if search := strings.TrimSpace(query.Search); search != "" {
+		clause, searchArgs := hostIdentitySearchClause(search)
+		clauses = append(clauses, clause)
+		args = append(args, searchArgs...)
+	}
 	if query.IsActive != nil {
 		clauses = append(clauses, "host_inventory.is_active = ?")
 		if *query.IsActive {
@@ -3065,6 +3561,17 @@ func ListHostInventory(db *sql.DB, query HostInventoryQuery) ([]model.HostInvent
 		return nil, err
 	}
 
+	scores, err := latestHostRiskScores(db)
+	if err != nil {
+		return nil, err
+	}
+	for index := range hosts {
+		hosts[index].RiskScore = scores[hosts[index].IP]
+		hosts[index].RiskLevel = model.RiskLevelForScore(hosts[index].RiskScore)
+	}
+	if sortByRisk {
+		sort.SliceStable(hosts, func(i, j int) bool { return hosts[i].RiskScore > hosts[j].RiskScore })
+	}
 	return hosts, nil
 }
 
@@ -3109,6 +3616,10 @@ func GetAssetDetail(db *sql.DB, ip string) (model.AssetDetail, error) {
 	if err != nil {
 		return model.AssetDetail{}, err
 	}
+	detail.Identities, err = loadAssetHostIdentities(db, ip)
+	if err != nil {
+		return model.AssetDetail{}, err
+	}
 
 	rows, err := db.Query(latestAssetPortRunsCTE+`
 		SELECT inventory.port, inventory.service_type, inventory.last_seen,
@@ -3134,6 +3645,7 @@ func GetAssetDetail(db *sql.DB, ip string) (model.AssetDetail, error) {
 		port.State = "open"
 		port.ProtocolEvidence = make([]model.ScanTaskRunProtocolEvidence, 0)
 		port.Technologies = make([]model.AssetTechnology, 0)
+		port.FingerprintOverrides = make([]model.ScanTaskRunFingerprintOverride, 0)
 		port.UnresolvedReasons = make([]string, 0)
 		port.Validation.UnmappedProducts = make([]string, 0)
 		port.Validation.Findings = make([]model.ScanTaskRunVulnerability, 0)
@@ -3152,6 +3664,9 @@ func GetAssetDetail(db *sql.DB, ip string) (model.AssetDetail, error) {
 	if err := loadAssetPortTechnologySet(db, ip, detail.Ports); err != nil {
 		return model.AssetDetail{}, err
 	}
+	if err := loadAssetPortOverrideSet(db, ip, detail.Ports); err != nil {
+		return model.AssetDetail{}, err
+	}
 	if err := loadAssetPortValidationSet(db, ip, detail.Ports); err != nil {
 		return model.AssetDetail{}, err
 	}
@@ -951,6 +951,64 @@ func TestGetAssetDetailKeepsEndpointProfileWithinOneRun(t *testing.T) {
 	}
 }
 
+func TestHostIdentitiesPersistPerRunAndDriveAssetSearch(t *testing.T) {
+	db := openTestDB(t)
+	if err := initSQLiteSchema(db); err != nil {
+		t.Fatalf("initSQLiteSchema: %v", err)
+	}
+	const ip = "192.168.10.30"
+	if err := SyncHostInventory(db, "ip:"+ip, []string{ip, "192.168.10.31"}); err != nil {
+		t.Fatalf("sync hosts: %v", err)
+	}
+	task := createScheduledTaskForTest(t, db, ip)
+	oldRun := createRunningTaskRun(t, db, task.ID, "2026-08-07T02:00:00Z")
+	if err := SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{
+		RunID:          oldRun.ID,
+		HostIdentities: []model.ScanTaskRunHostIdentity{{IP: ip, Port: 445, Source: model.HostIdentitySourceSMB, ComputerName: "OLDNAME"}},
+	}); err != nil {
+		t.Fatalf("save old snapshot: %v", err)
+	}
+	newRun := createRunningTaskRun(t, db, task.ID, "2026-08-08T02:00:00Z")
+	identities := []model.ScanTaskRunHostIdentity{
+		{IP: ip, Port: 445, Source: model.HostIdentitySourceSMB, ComputerName: "FILES01", DomainName: "CORP", DNSTreeName: "corp.example", OSVersion: "10.0.20348"},
+		{IP: ip, Port: 3389, Source: model.HostIdentitySourceRDP, ComputerName: "FILES01", DNSComputerName: "files01.corp.example"},
+	}
+	if err := SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{RunID: newRun.ID, HostIdentities: identities}); err != nil {
+		t.Fatalf("save new snapshot: %v", err)
+	}
+	invalidRun := createRunningTaskRun(t, db, task.ID, "2026-08-09T02:00:00Z")
+	if err := SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{
+		RunID:          invalidRun.ID,
+		HostIdentities: []model.ScanTaskRunHostIdentity{{IP: ip, Port: 445, Source: "ldap"}},
+	}); err == nil {
+		t.Fatal("unknown identity source was accepted")
+	}
+
+	snapshot, err := GetScanTaskRunSnapshot(db, newRun.ID)
+	if err != nil {
+		t.Fatalf("get snapshot: %v", err)
+	}
+	if !reflect.DeepEqual(snapshot.HostIdentities, identities) {
+		t.Fatalf("snapshot identities = %#v, want %#v", snapshot.HostIdentities, identities)
+	}
+	detail, err := GetAssetDetail(db, ip)
+	if err != nil {
+		t.Fatalf("get asset detail: %v", err)
+	}
+	if !reflect.DeepEqual(detail.Identities, identities) {
+		t.Fatalf("asset identities = %#v, want latest run %#v", detail.Identities, identities)
+	}
+	for search, want := range map[string]int{"files01": 1, "CORP.EXAMPLE": 1, "192.168.10.31": 1, "oldname": 1, "100%": 0} {
+		hosts, err := ListHostInventory(db, HostInventoryQuery{Search: search})
+		if err != nil {
+			t.Fatalf("search %q: %v", search, err)
+		}
+		if len(hosts) != want {
+			t.Fatalf("search %q = %#v, want %d hosts", search, hosts, want)
+		}
+	}
+}
+
 func TestAssetEndpointValidationDoesNotInheritRunSuccessWithoutCandidates(t *testing.T) {
 	ports := []model.AssetPort{{
 		ObservationRunID: 7,
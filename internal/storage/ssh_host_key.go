@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"strings"
+
+	"golandproject/yscan/internal/model"
+)
+
+func validateScanTaskRunSSHHostKeys(keys []model.ScanTaskRunSSHHostKey) error {
+	seen := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		identity := fmt.Sprintf("%s:%d/%s", strings.TrimSpace(key.IP), key.Port, key.Algorithm)
+		if net.ParseIP(strings.TrimSpace(key.IP)) == nil || key.Port < 1 || key.Port > 65535 || strings.TrimSpace(key.Algorithm) == "" ||
+			!strings.HasPrefix(key.Fingerprint, "SHA256:") || key.Bits < 0 {
+			return fmt.Errorf("invalid snapshot SSH host key: %s", identity)
+		}
+		if _, duplicate := seen[identity]; duplicate {
+			return fmt.Errorf("duplicate snapshot SSH host key: %s", identity)
+		}
+		seen[identity] = struct{}{}
+	}
+	return nil
+}
+
+func saveScanTaskRunSSHHostKeysTx(tx *sql.Tx, runID int64, keys []model.ScanTaskRunSSHHostKey) error {
+	for _, key := range keys {
+		if _, err := tx.Exec(`
+			INSERT INTO scan_task_run_ssh_host_keys (scan_task_run_id, ip, port, algorithm, fingerprint, bits)
+			VALUES (?, ?, ?, ?, ?, ?)`, runID, strings.TrimSpace(key.IP), key.Port, key.Algorithm, key.Fingerprint, key.Bits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadScanTaskRunSSHHostKeys(db *sql.DB, snapshot *model.ScanTaskRunSnapshot) error {
+	rows, err := db.Query(`
+		SELECT ip, port, algorithm, fingerprint, bits
+		FROM scan_task_run_ssh_host_keys
+		WHERE scan_task_run_id = ?
+		ORDER BY ip ASC, port ASC, algorithm ASC`, snapshot.RunID)
+	if isMissingSSHHostKeyTable(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key model.ScanTaskRunSSHHostKey
+		if err := rows.Scan(&key.IP, &key.Port, &key.Algorithm, &key.Fingerprint, &key.Bits); err != nil {
+			return err
+		}
+		snapshot.SSHHostKeys = append(snapshot.SSHHostKeys, key)
+	}
+	return rows.Err()
+}
+
+// rankedSSHHostKeysCTE numbers the observations of every endpoint key from
+// the newest completed snapshot, across all tasks.
+const rankedSSHHostKeysCTE = `
+	WITH ranked_ssh_host_keys AS (
+		SELECT host_key.scan_task_run_id, host_key.ip, host_key.port, host_key.algorithm, host_key.fingerprint,
+			ROW_NUMBER() OVER (PARTITION BY host_key.ip, host_key.port, host_key.algorithm ORDER BY host_key.scan_task_run_id DESC) AS position
+		FROM scan_task_run_ssh_host_keys AS host_key
+		JOIN scan_task_runs AS run ON run.id = host_key.scan_task_run_id
+		WHERE run.snapshot_written_at IS NOT NULL
+	)`
+
+// GetSSHHostKeyReport flags host keys that the latest observation shows on
+// more than one IP, and endpoints whose latest key differs from the one they
+// presented in their previous observation.
+func GetSSHHostKeyReport(db *sql.DB) (model.SSHHostKeyReport, error) {
+	report := model.SSHHostKeyReport{Clones: make([]model.SSHHostKeyClone, 0), Changes: make([]model.SSHHostKeyChange, 0)}
+	rows, err := db.Query(rankedSSHHostKeysCTE + `
+		SELECT latest.algorithm, latest.fingerprint, latest.ip, latest.port, latest.scan_task_run_id
+		FROM ranked_ssh_host_keys AS latest
+		WHERE latest.position = 1 AND latest.fingerprint IN (
+			SELECT fingerprint FROM ranked_ssh_host_keys
+			WHERE position = 1
+			GROUP BY fingerprint
+			HAVING COUNT(DISTINCT ip) > 1
+		)
+		ORDER BY latest.fingerprint ASC, latest.ip ASC, latest.port ASC`)
+	if isMissingSSHHostKeyTable(err) {
+		return report, nil
+	}
+	if err != nil {
+		return model.SSHHostKeyReport{}, err
+	}
+	for rows.Next() {
+		var algorithm, fingerprint string
+		var endpoint model.SSHHostKeyEndpoint
+		if err := rows.Scan(&algorithm, &fingerprint, &endpoint.IP, &endpoint.Port, &endpoint.RunID); err != nil {
+			_ = rows.Close()
+			return model.SSHHostKeyReport{}, err
+		}
+		if count := len(report.Clones); count == 0 || report.Clones[count-1].Fingerprint != fingerprint {
+			report.Clones = append(report.Clones, model.SSHHostKeyClone{Algorithm: algorithm, Fingerprint: fingerprint})
+		}
+		clone := &report.Clones[len(report.Clones)-1]
+		clone.Endpoints = append(clone.Endpoints, endpoint)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return model.SSHHostKeyReport{}, err
+	}
+	if err := rows.Close(); err != nil {
+		return model.SSHHostKeyReport{}, err
+	}
+
+	rows, err = db.Query(rankedSSHHostKeysCTE + `
+		SELECT latest.ip, latest.port, latest.algorithm, previous.fingerprint, latest.fingerprint,
+			previous.scan_task_run_id, latest.scan_task_run_id
+		FROM ranked_ssh_host_keys AS latest
+		JOIN ranked_ssh_host_keys AS previous
+			ON previous.ip = latest.ip AND previous.port = latest.port AND previous.algorithm = latest.algorithm AND previous.position = 2
+		WHERE latest.position = 1 AND latest.fingerprint <> previous.fingerprint
+		ORDER BY latest.ip ASC, latest.port ASC, latest.algorithm ASC`)
+	if err != nil {
+		return model.SSHHostKeyReport{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var change model.SSHHostKeyChange
+		if err := rows.Scan(&change.IP, &change.Port, &change.Algorithm, &change.Before, &change.After, &change.BaselineRunID, &change.CurrentRunID); err != nil {
+			return model.SSHHostKeyReport{}, err
+		}
+		report.Changes = append(report.Changes, change)
+	}
+	return report, rows.Err()
+}
+
+func isMissingSSHHostKeyTable(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "no such table: scan_task_run_ssh_host_keys")
+}
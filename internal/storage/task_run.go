@@ -277,10 +277,12 @@ func CreateScanTaskRun(db *sql.DB, run model.ScanTaskRun) (model.ScanTaskRun, er
 			run.Trigger = model.ScanTaskRunTriggerManual
 		}
 	}
-	if run.Trigger != model.ScanTaskRunTriggerInitial && run.Trigger != model.ScanTaskRunTriggerManual && run.Trigger != model.ScanTaskRunTriggerScheduled {
+	if run.Trigger != model.ScanTaskRunTriggerInitial && run.Trigger != model.ScanTaskRunTriggerManual && run.Trigger != model.ScanTaskRunTriggerScheduled && run.Trigger != model.ScanTaskRunTriggerImport && run.Trigger != model.ScanTaskRunTriggerPassive {
 		return model.ScanTaskRun{}, fmt.Errorf("invalid scan task run trigger: %s", run.Trigger)
 	}
-	if task.Mode == model.ScanTaskModeOnce {
+	// Imported and passive runs record observations made elsewhere, so they
+	// never count as the single execution of a one-time task.
+	if task.Mode == model.ScanTaskModeOnce && run.Trigger != model.ScanTaskRunTriggerImport && run.Trigger != model.ScanTaskRunTriggerPassive {
 		var existingRuns int
 		if err := tx.QueryRow(`SELECT COUNT(1) FROM scan_task_runs WHERE scan_task_id = ?`, run.ScanTaskID).Scan(&existingRuns); err != nil {
 			return model.ScanTaskRun{}, err
@@ -344,6 +346,18 @@ func GetScanTaskRun(db *sql.DB, runID int64) (model.ScanTaskRun, error) {
 	return run, err
 }
 
+// GetLatestSuccessfulScanTaskRun returns the most recent successful run of a
+// task whose snapshot was written, or ErrScanTaskRunNotFound.
+func GetLatestSuccessfulScanTaskRun(db *sql.DB, scanTaskID int64) (model.ScanTaskRun, error) {
+	run, err := scanScanTaskRun(db.QueryRow(scanTaskRunSelect+`
+		WHERE scan_task_id = ? AND status = ? AND snapshot_written_at IS NOT NULL
+		ORDER BY sequence DESC, id DESC LIMIT 1`, scanTaskID, model.ScanTaskRunStatusSuccess))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ScanTaskRun{}, ErrScanTaskRunNotFound
+	}
+	return run, err
+}
+
 func ListScanTaskRuns(db *sql.DB, scanTaskID int64) ([]model.ScanTaskRun, error) {
 	rows, err := db.Query(scanTaskRunSelect+` WHERE scan_task_id = ? ORDER BY sequence ASC`, scanTaskID)
 	if err != nil {
@@ -365,8 +379,9 @@ func ListScanTaskRuns(db *sql.DB, scanTaskID int64) ([]model.ScanTaskRun, error)
 	return runs, nil
 }
 
-// ClaimQueuedScanTaskRun claims an initial or explicitly manual run. Cron
-// occurrences use the due-run path and are never silently backfilled here.
+// ClaimQueuedScanTaskRun claims an initial, explicitly manual or revalidation
+// run. Cron occurrences use the due-run path and are never silently backfilled
+// here.
 func ClaimQueuedScanTaskRun(db *sql.DB) (*model.ScanTaskRun, error) {
 	tx, err := db.Begin()
 	if err != nil {
@@ -378,14 +393,14 @@ func ClaimQueuedScanTaskRun(db *sql.DB) (*model.ScanTaskRun, error) {
 		SELECT run.id
 		FROM scan_task_runs AS run
 		JOIN scan_tasks AS task ON task.id = run.scan_task_id
-		WHERE run.status = ? AND run.trigger IN (?, ?) AND task.status = ?
+		WHERE run.status = ? AND run.trigger IN (?, ?, ?) AND task.status = ?
 			AND NOT EXISTS (
 				SELECT 1 FROM scan_task_runs AS active
 				WHERE active.id <> run.id AND active.status IN (?, ?)
 			)
 		ORDER BY run.created_at ASC, run.id ASC
 		LIMIT 1`,
-		model.ScanTaskRunStatusQueued, model.ScanTaskRunTriggerInitial, model.ScanTaskRunTriggerManual, model.ScanTaskStatusEnabled,
+		model.ScanTaskRunStatusQueued, model.ScanTaskRunTriggerInitial, model.ScanTaskRunTriggerManual, model.ScanTaskRunTriggerRevalidate, model.ScanTaskStatusEnabled,
 		model.ScanTaskRunStatusRunning, model.ScanTaskRunStatusCancelRequested,
 	).Scan(&runID)
 	if errors.Is(err, sql.ErrNoRows) {
@@ -759,9 +774,39 @@ func SaveScanTaskRunSnapshot(db *sql.DB, snapshot model.ScanTaskRunSnapshot) err
 			return err
 		}
 	}
+	if err := applyFindingTriageTx(tx, snapshot.RunID, snapshot.Vulnerabilities); err != nil {
+		return err
+	}
 	if err := saveFingerprintRunMatchesTx(tx, snapshot.RunID, snapshot.FingerprintMatches); err != nil {
 		return err
 	}
+	if err := saveScanTaskRunHostIdentitiesTx(tx, snapshot.RunID, snapshot.HostIdentities); err != nil {
+		return err
+	}
+	if err := saveScanTaskRunSSHHostKeysTx(tx, snapshot.RunID, snapshot.SSHHostKeys); err != nil {
+		return err
+	}
+	if err := saveScanTaskRunSNMPSystemsTx(tx, snapshot.RunID, snapshot.SNMPSystems); err != nil {
+		return err
+	}
+	if err := saveScanTaskRunWebAssetsTx(tx, snapshot.RunID, snapshot.WebAssets); err != nil {
+		return err
+	}
+	if err := saveScanTaskRunWeaknessesTx(tx, snapshot.RunID, snapshot.Weaknesses); err != nil {
+		return err
+	}
+	if err := saveScanTaskRunTLSFingerprintsTx(tx, snapshot.RunID, snapshot.TLSFingerprints); err != nil {
+		return err
+	}
+	if err := saveScanTaskRunRawEvidenceTx(tx, snapshot.RunID, snapshot.RawEvidence); err != nil {
+		return err
+	}
+	if err := correlateScanTaskRunCVEsTx(tx, snapshot.RunID); err != nil {
+		return err
+	}
+	if err := scoreScanTaskRunRiskTx(tx, snapshot.RunID); err != nil {
+		return err
+	}
 	return tx.Commit()
 }
 
@@ -802,6 +847,30 @@ func GetScanTaskRunSnapshot(db *sql.DB, runID int64) (model.ScanTaskRunSnapshot,
 	if err := loadScanTaskRunVulnerabilities(db, &snapshot); err != nil {
 		return model.ScanTaskRunSnapshot{}, err
 	}
+	if err := loadScanTaskRunHostIdentities(db, &snapshot); err != nil {
+		return model.ScanTaskRunSnapshot{}, err
+	}
+	if err := loadScanTaskRunSSHHostKeys(db, &snapshot); err != nil {
+		return model.ScanTaskRunSnapshot{}, err
+	}
+	if err := loadScanTaskRunSNMPSystems(db, &snapshot); err != nil {
+		return model.ScanTaskRunSnapshot{}, err
+	}
+	if err := loadScanTaskRunWebAssets(db, &snapshot); err != nil {
+		return model.ScanTaskRunSnapshot{}, err
+	}
+	if err := loadScanTaskRunWeaknesses(db, &snapshot); err != nil {
+		return model.ScanTaskRunSnapshot{}, err
+	}
+	if err := loadScanTaskRunTLSFingerprints(db, &snapshot); err != nil {
+		return model.ScanTaskRunSnapshot{}, err
+	}
+	if err := loadScanTaskRunPotentialVulnerabilities(db, &snapshot); err != nil {
+		return model.ScanTaskRunSnapshot{}, err
+	}
+	if err := loadScanTaskRunRisk(db, &snapshot); err != nil {
+		return model.ScanTaskRunSnapshot{}, err
+	}
 	rows, err := db.Query(`
 		SELECT candidate.template_id, candidate.path, candidate.source, candidate.reason,
 			COALESCE(candidate.template_sha256, ''), COALESCE(candidate.template_set_revision, ''),
@@ -1009,7 +1078,25 @@ func validateScanTaskRunSnapshot(snapshot model.ScanTaskRunSnapshot) error {
 			}
 		}
 	}
-	return nil
+	if err := validateScanTaskRunHostIdentities(snapshot.HostIdentities); err != nil {
+		return err
+	}
+	if err := validateScanTaskRunSSHHostKeys(snapshot.SSHHostKeys); err != nil {
+		return err
+	}
+	if err := validateScanTaskRunSNMPSystems(snapshot.SNMPSystems); err != nil {
+		return err
+	}
+	if err := validateScanTaskRunWebAssets(snapshot.WebAssets); err != nil {
+		return err
+	}
+	if err := validateScanTaskRunWeaknesses(snapshot.Weaknesses); err != nil {
+		return err
+	}
+	if err := validateScanTaskRunTLSFingerprints(snapshot.TLSFingerprints); err != nil {
+		return err
+	}
+	return validateScanTaskRunRawEvidence(snapshot.RawEvidence)
 }
 
 func loadScanTaskRunHosts(db *sql.DB, snapshot *model.ScanTaskRunSnapshot) error {
@@ -1166,7 +1253,8 @@ func isEndpointValidationReason(reason string) bool {
 	case "", model.ValidationReasonUnidentifiedProduct, model.ValidationReasonMappingMissing,
 		model.ValidationReasonTemplateMissing, model.ValidationReasonNucleiMissing,
 		model.ValidationReasonTemplateDirectory, model.ValidationReasonPolicyFiltered,
-		model.ValidationReasonExecutionFailed, model.ValidationReasonRunFailure:
+		model.ValidationReasonExecutionFailed, model.ValidationReasonExecutionTimeout,
+		model.ValidationReasonMemoryLimit, model.ValidationReasonRunFailure:
 		return true
 	default:
 		return false
@@ -1195,6 +1283,12 @@ func validProtocolEvidenceIdentity(evidenceType, protocol, probeName string) boo
 		return protocol == "tcp" && probeName != ""
 	case model.ProtocolEvidenceWeb:
 		return (protocol == "http" || protocol == "https") && probeName == ""
+	case model.ProtocolEvidenceImported:
+		return (protocol == "tcp" || protocol == "http" || protocol == "https") && probeName != ""
+	case model.ProtocolEvidenceWebPage:
+		return (protocol == "http" || protocol == "https") && strings.HasPrefix(probeName, "/")
+	case model.ProtocolEvidenceTLSFingerprint:
+		return protocol == "tls" && probeName != ""
 	default:
 		return false
 	}
@@ -1268,7 +1362,10 @@ func loadScanTaskRunVulnerabilities(db *sql.DB, snapshot *model.ScanTaskRunSnaps
 		finding.Evidence = evidence.String
 		snapshot.Vulnerabilities = append(snapshot.Vulnerabilities, finding)
 	}
-	return rows.Err()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return loadFindingTriageStates(db, snapshot.RunID, snapshot.Vulnerabilities)
 }
 
 func boolToInt(value bool) int {
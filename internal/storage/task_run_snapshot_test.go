@@ -43,11 +43,16 @@ func TestScanTaskRunSnapshotIsWriteOnceAndIndependentOfGlobalInventory(t *testin
 			Target:     "https://192.168.10.10",
 			TargetIP:   "192.168.10.10",
 			TargetPort: 443,
+			State:      model.FindingStateOpen,
 		}},
 	}
 	if err := SaveScanTaskRunSnapshot(db, want); err != nil {
 		t.Fatalf("save snapshot: %v", err)
 	}
+	endpointRisk := model.AssetRiskScore{IP: "192.168.10.10", Port: 443, Score: 25, Level: model.RiskLevelLow, Factors: []model.RiskFactor{{Kind: model.RiskFactorFinding, Detail: "high CVE-2026-0001", Points: 25}}}
+	want.Risk = &model.ScanTaskRunRisk{Score: 25, Level: model.RiskLevelLow, Endpoints: []model.AssetRiskScore{endpointRisk}, Hosts: []model.AssetRiskScore{{
+		IP: "192.168.10.10", Score: 25, Level: model.RiskLevelLow, Factors: []model.RiskFactor{{Kind: model.RiskFactorEndpoint, Detail: "192.168.10.10:443", Points: 25}},
+	}}}
 	got, err := GetScanTaskRunSnapshot(db, run.ID)
 	if err != nil {
 		t.Fatalf("get snapshot: %v", err)
@@ -153,3 +158,184 @@ func createRunningTaskRun(t *testing.T, db *sql.DB, taskID int64, scheduledFor s
 	}
 	return run
 }
+
+func TestSSHHostKeyReportFlagsClonesAndChangedKeys(t *testing.T) {
+	db := openTestDB(t)
+	if err := initSQLiteSchema(db); err != nil {
+		t.Fatalf("initSQLiteSchema: %v", err)
+	}
+	task := createScheduledTaskForTest(t, db, "192.168.10.0/24")
+	first := createRunningTaskRun(t, db, task.ID, "2026-08-07T02:00:00Z")
+	if err := SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{RunID: first.ID, SSHHostKeys: []model.ScanTaskRunSSHHostKey{
+		{IP: "192.168.10.10", Port: 22, Algorithm: "ssh-ed25519", Fingerprint: "SHA256:first", Bits: 256},
+		{IP: "192.168.10.11", Port: 22, Algorithm: "ssh-ed25519", Fingerprint: "SHA256:template", Bits: 256},
+	}}); err != nil {
+		t.Fatalf("save first snapshot: %v", err)
+	}
+	second := createRunningTaskRun(t, db, task.ID, "2026-08-08T02:00:00Z")
+	keys := []model.ScanTaskRunSSHHostKey{
+		{IP: "192.168.10.10", Port: 22, Algorithm: "ssh-ed25519", Fingerprint: "SHA256:rotated", Bits: 256},
+		{IP: "192.168.10.11", Port: 22, Algorithm: "ssh-ed25519", Fingerprint: "SHA256:template", Bits: 256},
+		{IP: "192.168.10.12", Port: 2222, Algorithm: "ssh-ed25519", Fingerprint: "SHA256:template", Bits: 256},
+	}
+	if err := SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{RunID: second.ID, SSHHostKeys: keys}); err != nil {
+		t.Fatalf("save second snapshot: %v", err)
+	}
+	invalid := createRunningTaskRun(t, db, task.ID, "2026-08-09T02:00:00Z")
+	if err := SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{RunID: invalid.ID, SSHHostKeys: []model.ScanTaskRunSSHHostKey{
+		{IP: "192.168.10.10", Port: 22, Algorithm: "ssh-ed25519", Fingerprint: "d41d8cd9"},
+	}}); err == nil {
+		t.Fatal("fingerprint without SHA256 prefix was accepted")
+	}
+
+	snapshot, err := GetScanTaskRunSnapshot(db, second.ID)
+	if err != nil {
+		t.Fatalf("get snapshot: %v", err)
+	}
+	if !reflect.DeepEqual(snapshot.SSHHostKeys, keys) {
+		t.Fatalf("snapshot keys = %#v, want %#v", snapshot.SSHHostKeys, keys)
+	}
+	report, err := GetSSHHostKeyReport(db)
+	if err != nil {
+		t.Fatalf("get SSH host key report: %v", err)
+	}
+	wantClones := []model.SSHHostKeyClone{{Algorithm: "ssh-ed25519", Fingerprint: "SHA256:template", Endpoints: []model.SSHHostKeyEndpoint{
+		{IP: "192.168.10.11", Port: 22, RunID: second.ID},
+		{IP: "192.168.10.12", Port: 2222, RunID: second.ID},
+	}}}
+	if !reflect.DeepEqual(report.Clones, wantClones) {
+		t.Fatalf("clones = %#v, want %#v", report.Clones, wantClones)
+	}
+	wantChanges := []model.SSHHostKeyChange{{IP: "192.168.10.10", Port: 22, Algorithm: "ssh-ed25519", Before: "SHA256:first", After: "SHA256:rotated", BaselineRunID: first.ID, CurrentRunID: second.ID}}
+	if !reflect.DeepEqual(report.Changes, wantChanges) {
+		t.Fatalf("changes = %#v, want %#v", report.Changes, wantChanges)
+	}
+}
+
+func TestSNMPSystemsPersistAndConcludeMappedProducts(t *testing.T) {
+	db := openTestDB(t)
+	if err := initSQLiteSchema(db); err != nil {
+		t.Fatalf("initSQLiteSchema: %v", err)
+	}
+	task := createScheduledTaskForTest(t, db, "192.168.10.0/24")
+	run := createRunningTaskRun(t, db, task.ID, "2026-08-10T02:00:00Z")
+	systems := []model.ScanTaskRunSNMPSystem{
+		{
+			IP: "192.168.10.1", Port: 161, Credential: "core", SysDescr: "Cisco IOS XE Software, Version 17.03.04", SysObjectID: "1.3.6.1.4.1.9.1.2494",
+			SysName: "core-sw1", SysLocation: "DC1", Vendor: "cisco", Product: "cisco ios xe", Version: "17.03.04", CPE: "cpe:2.3:o:cisco:ios_xe:17.03.04:*:*:*:*:*:*:*",
+			Interfaces: []model.ScanTaskRunSNMPInterface{
+				{Index: 1, Description: "GigabitEthernet1/0/1", Type: 6, Speed: 1000000000, PhysAddress: "00:11:22:33:44:55", AdminStatus: "up", OperStatus: "up"},
+				{Index: 2, Description: "GigabitEthernet1/0/2", Type: 6, Speed: 1000000000, AdminStatus: "up", OperStatus: "down"},
+			},
+		},
+		{IP: "192.168.10.2", Port: 161, Credential: "core", SysDescr: "ACME appliance", SysObjectID: "1.3.6.1.4.1.99999.1", SysName: "unknown-box"},
+	}
+	if err := SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{RunID: run.ID, SNMPSystems: systems}); err != nil {
+		t.Fatalf("save snapshot: %v", err)
+	}
+	snapshot, err := GetScanTaskRunSnapshot(db, run.ID)
+	if err != nil {
+		t.Fatalf("get snapshot: %v", err)
+	}
+	if !reflect.DeepEqual(snapshot.SNMPSystems, systems) {
+		t.Fatalf("snapshot SNMP systems = %#v, want %#v", snapshot.SNMPSystems, systems)
+	}
+	conclusions, err := ListFingerprintRunConclusions(db, run.ID)
+	if err != nil {
+		t.Fatalf("list conclusions: %v", err)
+	}
+	if len(conclusions) != 1 || conclusions[0]["ip"] != "192.168.10.1" || conclusions[0]["protocol"] != "snmp" ||
+		conclusions[0]["product_key"] != "cisco ios xe" || conclusions[0]["version"] != "17.03.04" || conclusions[0]["version_status"] != "matched" {
+		t.Fatalf("conclusions = %#v", conclusions)
+	}
+
+	invalid := createRunningTaskRun(t, db, task.ID, "2026-08-11T02:00:00Z")
+	if err := SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{RunID: invalid.ID, SNMPSystems: []model.ScanTaskRunSNMPSystem{
+		{IP: "192.168.10.1", Port: 161, Credential: "core", Interfaces: []model.ScanTaskRunSNMPInterface{{Index: 3}, {Index: 3}}},
+	}}); err == nil {
+		t.Fatal("duplicate SNMP interface index was accepted")
+	}
+}
+
+func TestWebAssetsAndCrawledPagesPersistInSnapshot(t *testing.T) {
+	db := openTestDB(t)
+	if err := initSQLiteSchema(db); err != nil {
+		t.Fatalf("initSQLiteSchema: %v", err)
+	}
+	task := createScheduledTaskForTest(t, db, "192.168.20.0/24")
+	run := createRunningTaskRun(t, db, task.ID, "2026-08-12T02:00:00Z")
+	digest := strings.Repeat("ab", 32)
+	assets := []model.ScanTaskRunWebAsset{
+		{IP: "192.168.20.5", Port: 8080, Protocol: "http", Kind: "script", Path: "/static/jquery-3.6.0.min.js", CapturedLength: 89000, SHA256: digest, Versions: []string{"jquery 3.6.0"}},
+		{IP: "192.168.20.5", Port: 8080, Protocol: "http", Kind: "style", Path: "/css/app.css", CapturedLength: 12, SHA256: digest, Truncated: true},
+	}
+	page := model.ScanTaskRunProtocolEvidence{
+		IP: "192.168.20.5", Port: 8080, EvidenceType: model.ProtocolEvidenceWebPage, ProbeName: "/login", Protocol: "http",
+		Responded: true, StatusCode: 200, Title: "Sign in", BodyCapturedLength: 42, BodySHA256: digest,
+	}
+	snapshot := model.ScanTaskRunSnapshot{RunID: run.ID, ProtocolEvidence: []model.ScanTaskRunProtocolEvidence{page}, WebAssets: assets}
+	if err := SaveScanTaskRunSnapshot(db, snapshot); err != nil {
+		t.Fatalf("save snapshot: %v", err)
+	}
+	loaded, err := GetScanTaskRunSnapshot(db, run.ID)
+	if err != nil {
+		t.Fatalf("get snapshot: %v", err)
+	}
+	if len(loaded.WebAssets) != 2 || loaded.WebAssets[0].Path != "/css/app.css" || !loaded.WebAssets[0].Truncated ||
+		loaded.WebAssets[1].Versions[0] != "jquery 3.6.0" || loaded.WebAssets[1].CapturedLength != 89000 {
+		t.Fatalf("web assets = %#v", loaded.WebAssets)
+	}
+	if len(loaded.ProtocolEvidence) != 1 || loaded.ProtocolEvidence[0].EvidenceType != model.ProtocolEvidenceWebPage || loaded.ProtocolEvidence[0].ProbeName != "/login" {
+		t.Fatalf("protocol evidence = %#v", loaded.ProtocolEvidence)
+	}
+
+	invalid := createRunningTaskRun(t, db, task.ID, "2026-08-13T02:00:00Z")
+	page.ProbeName = "login"
+	if err := SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{RunID: invalid.ID, ProtocolEvidence: []model.ScanTaskRunProtocolEvidence{page}}); err == nil {
+		t.Fatal("web page evidence without an absolute path was accepted")
+	}
+	if err := SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{RunID: invalid.ID, WebAssets: []model.ScanTaskRunWebAsset{assets[0], assets[0]}}); err == nil {
+		t.Fatal("duplicate web asset was accepted")
+	}
+}
+
+func TestTLSFingerprintsPersistInSnapshot(t *testing.T) {
+	db := openTestDB(t)
+	if err := initSQLiteSchema(db); err != nil {
+		t.Fatalf("initSQLiteSchema: %v", err)
+	}
+	task := createScheduledTaskForTest(t, db, "192.168.21.0/24")
+	run := createRunningTaskRun(t, db, task.ID, "2026-08-14T02:00:00Z")
+	hellos := model.ScanTaskRunProtocolEvidence{
+		IP: "192.168.21.7", Port: 443, EvidenceType: model.ProtocolEvidenceTLSFingerprint, ProbeName: "hello-variants", Protocol: "tls",
+		Responded: true, BannerCapturedLength: 812, BannerSHA256: strings.Repeat("cd", 32),
+	}
+	fingerprint := model.ScanTaskRunTLSFingerprint{
+		IP: "192.168.21.7", Port: 443, Fingerprint: strings.Repeat("2ad", 10) + strings.Repeat("ef", 16),
+		JA4S: "t130200_1301_234ea6891581", Responses: 9, Variants: 10,
+	}
+	snapshot := model.ScanTaskRunSnapshot{RunID: run.ID, ProtocolEvidence: []model.ScanTaskRunProtocolEvidence{hellos}, TLSFingerprints: []model.ScanTaskRunTLSFingerprint{fingerprint}}
+	if err := SaveScanTaskRunSnapshot(db, snapshot); err != nil {
+		t.Fatalf("save snapshot: %v", err)
+	}
+	loaded, err := GetScanTaskRunSnapshot(db, run.ID)
+	if err != nil {
+		t.Fatalf("get snapshot: %v", err)
+	}
+	if len(loaded.TLSFingerprints) != 1 || loaded.TLSFingerprints[0] != fingerprint {
+		t.Fatalf("TLS fingerprints = %#v", loaded.TLSFingerprints)
+	}
+	if len(loaded.ProtocolEvidence) != 1 || loaded.ProtocolEvidence[0].EvidenceType != model.ProtocolEvidenceTLSFingerprint || loaded.ProtocolEvidence[0].BannerCapturedLength != 812 {
+		t.Fatalf("protocol evidence = %#v", loaded.ProtocolEvidence)
+	}
+
+	invalid := createRunningTaskRun(t, db, task.ID, "2026-08-15T02:00:00Z")
+	hellos.Protocol = "tcp"
+	if err := SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{RunID: invalid.ID, ProtocolEvidence: []model.ScanTaskRunProtocolEvidence{hellos}}); err == nil {
+		t.Fatal("TLS fingerprint evidence outside the tls protocol was accepted")
+	}
+	fingerprint.Responses = 11
+	if err := SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{RunID: invalid.ID, TLSFingerprints: []model.ScanTaskRunTLSFingerprint{fingerprint}}); err == nil {
+		t.Fatal("TLS fingerprint with more responses than variants was accepted")
+	}
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"strings"
+
+	"golandproject/yscan/internal/model"
+)
+
+func validateScanTaskRunTLSFingerprints(fingerprints []model.ScanTaskRunTLSFingerprint) error {
+	seen := make(map[string]struct{}, len(fingerprints))
+	for _, fingerprint := range fingerprints {
+		ip := strings.TrimSpace(fingerprint.IP)
+		key := fmt.Sprintf("%s:%d", ip, fingerprint.Port)
+		if net.ParseIP(ip) == nil || fingerprint.Port < 1 || fingerprint.Port > 65535 || strings.TrimSpace(fingerprint.Fingerprint) == "" ||
+			fingerprint.Responses < 1 || fingerprint.Responses > fingerprint.Variants {
+			return fmt.Errorf("invalid snapshot TLS fingerprint: %s", key)
+		}
+		if _, duplicate := seen[key]; duplicate {
+			return fmt.Errorf("duplicate snapshot TLS fingerprint: %s", key)
+		}
+		seen[key] = struct{}{}
+	}
+	return nil
+}
+
+func saveScanTaskRunTLSFingerprintsTx(tx *sql.Tx, runID int64, fingerprints []model.ScanTaskRunTLSFingerprint) error {
+	for _, fingerprint := range fingerprints {
+		if _, err := tx.Exec(`
+			INSERT INTO scan_task_run_tls_fingerprints
+				(scan_task_run_id, ip, port, fingerprint, ja4s, responses, variants)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			runID, strings.TrimSpace(fingerprint.IP), fingerprint.Port, strings.ToLower(strings.TrimSpace(fingerprint.Fingerprint)),
+			strings.ToLower(strings.TrimSpace(fingerprint.JA4S)), fingerprint.Responses, fingerprint.Variants); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadScanTaskRunTLSFingerprints(db *sql.DB, snapshot *model.ScanTaskRunSnapshot) error {
+	rows, err := db.Query(`
+		SELECT ip, port, fingerprint, ja4s, responses, variants
+		FROM scan_task_run_tls_fingerprints
+		WHERE scan_task_run_id = ?
+		ORDER BY ip ASC, port ASC`, snapshot.RunID)
+	if isMissingTLSFingerprintTable(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var fingerprint model.ScanTaskRunTLSFingerprint
+		if err := rows.Scan(&fingerprint.IP, &fingerprint.Port, &fingerprint.Fingerprint, &fingerprint.JA4S,
+			&fingerprint.Responses, &fingerprint.Variants); err != nil {
+			return err
+		}
+		snapshot.TLSFingerprints = append(snapshot.TLSFingerprints, fingerprint)
+	}
+	return rows.Err()
+}
+
+func isMissingTLSFingerprintTable(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "no such table: scan_task_run_tls_fingerprints")
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"strings"
+
+	"golandproject/yscan/internal/model"
+)
+
+func validateScanTaskRunWeaknesses(weaknesses []model.ScanTaskRunWeakness) error {
+	seen := make(map[string]struct{}, len(weaknesses))
+	for _, weakness := range weaknesses {
+		ip := strings.TrimSpace(weakness.IP)
+		protocol := strings.ToLower(strings.TrimSpace(weakness.Protocol))
+		key := fmt.Sprintf("%s:%d/%s/%s/%s", ip, weakness.Port, protocol, weakness.Check, weakness.Subject)
+		if net.ParseIP(ip) == nil || weakness.Port < 1 || weakness.Port > 65535 || (protocol != "http" && protocol != "https") ||
+			strings.TrimSpace(weakness.Check) == "" || strings.TrimSpace(weakness.Severity) == "" || !strings.HasPrefix(weakness.Path, "/") {
+			return fmt.Errorf("invalid snapshot weakness: %s", key)
+		}
+		if _, duplicate := seen[key]; duplicate {
+			return fmt.Errorf("duplicate snapshot weakness: %s", key)
+		}
+		seen[key] = struct{}{}
+	}
+	return nil
+}
+
+func saveScanTaskRunWeaknessesTx(tx *sql.Tx, runID int64, weaknesses []model.ScanTaskRunWeakness) error {
+	for _, weakness := range weaknesses {
+		if _, err := tx.Exec(`
+			INSERT INTO scan_task_run_weaknesses
+				(scan_task_run_id, ip, port, protocol, check_id, subject, severity, path, detail)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			runID, strings.TrimSpace(weakness.IP), weakness.Port, strings.ToLower(strings.TrimSpace(weakness.Protocol)),
+			weakness.Check, weakness.Subject, weakness.Severity, weakness.Path, weakness.Detail); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadScanTaskRunWeaknesses(db *sql.DB, snapshot *model.ScanTaskRunSnapshot) error {
+	rows, err := db.Query(`
+		SELECT ip, port, protocol, check_id, subject, severity, path, detail
+		FROM scan_task_run_weaknesses
+		WHERE scan_task_run_id = ?
+		ORDER BY ip ASC, port ASC, protocol ASC, check_id ASC, subject ASC`, snapshot.RunID)
+	if isMissingWeaknessTable(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var weakness model.ScanTaskRunWeakness
+		if err := rows.Scan(&weakness.IP, &weakness.Port, &weakness.Protocol, &weakness.Check, &weakness.Subject,
+			&weakness.Severity, &weakness.Path, &weakness.Detail); err != nil {
+			return err
+		}
+		snapshot.Weaknesses = append(snapshot.Weaknesses, weakness)
+	}
+	return rows.Err()
+}
+
+func isMissingWeaknessTable(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "no such table: scan_task_run_weaknesses")
+}
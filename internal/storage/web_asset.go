@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"golandproject/yscan/internal/model"
+)
+
+func validateScanTaskRunWebAssets(assets []model.ScanTaskRunWebAsset) error {
+	seen := make(map[string]struct{}, len(assets))
+	for _, asset := range assets {
+		ip := strings.TrimSpace(asset.IP)
+		protocol := strings.ToLower(strings.TrimSpace(asset.Protocol))
+		key := fmt.Sprintf("%s:%d/%s%s", ip, asset.Port, protocol, asset.Path)
+		if net.ParseIP(ip) == nil || asset.Port < 1 || asset.Port > 65535 || (protocol != "http" && protocol != "https") ||
+			!strings.HasPrefix(asset.Path, "/") || strings.TrimSpace(asset.Kind) == "" || asset.CapturedLength < 0 {
+			return fmt.Errorf("invalid snapshot web asset: %s", key)
+		}
+		if strings.TrimSpace(asset.SHA256) == "" {
+			return fmt.Errorf("invalid snapshot web asset digest: %s", key)
+		}
+		if err := validateProtocolEvidenceDigest(asset.SHA256); err != nil {
+			return fmt.Errorf("invalid snapshot web asset digest: %s", key)
+		}
+		if _, duplicate := seen[key]; duplicate {
+			return fmt.Errorf("duplicate snapshot web asset: %s", key)
+		}
+		seen[key] = struct{}{}
+	}
+	return nil
+}
+
+func saveScanTaskRunWebAssetsTx(tx *sql.Tx, runID int64, assets []model.ScanTaskRunWebAsset) error {
+	for _, asset := range assets {
+		versions := asset.Versions
+		if versions == nil {
+			versions = []string{}
+		}
+		versionsJSON, err := json.Marshal(versions)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO scan_task_run_web_assets
+				(scan_task_run_id, ip, port, protocol, path, kind, captured_length, sha256, truncated, versions_json)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			runID, strings.TrimSpace(asset.IP), asset.Port, strings.ToLower(strings.TrimSpace(asset.Protocol)), asset.Path,
+			asset.Kind, asset.CapturedLength, strings.ToLower(strings.TrimSpace(asset.SHA256)), asset.Truncated, string(versionsJSON)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadScanTaskRunWebAssets(db *sql.DB, snapshot *model.ScanTaskRunSnapshot) error {
+	rows, err := db.Query(`
+		SELECT ip, port, protocol, path, kind, captured_length, sha256, truncated, versions_json
+		FROM scan_task_run_web_assets
+		WHERE scan_task_run_id = ?
+		ORDER BY ip ASC, port ASC, protocol ASC, path ASC`, snapshot.RunID)
+	if isMissingWebAssetTable(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var asset model.ScanTaskRunWebAsset
+		var versionsJSON string
+		if err := rows.Scan(&asset.IP, &asset.Port, &asset.Protocol, &asset.Path, &asset.Kind, &asset.CapturedLength,
+			&asset.SHA256, &asset.Truncated, &versionsJSON); err != nil {
+			return err
+		}
+		_ = json.Unmarshal([]byte(versionsJSON), &asset.Versions)
+		snapshot.WebAssets = append(snapshot.WebAssets, asset)
+	}
+	return rows.Err()
+}
+
+func isMissingWebAssetTable(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "no such table: scan_task_run_web_assets")
+}
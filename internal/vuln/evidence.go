@@ -0,0 +1,266 @@
+package vuln
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	evidenceRedacted            = "[REDACTED]"
+	DefaultEvidenceMaxBodyBytes = 2048
+)
+
+// EvidenceRedaction controls how stored finding evidence is shown to people.
+// Header values whose name is listed in Headers, and key=value or "key":
+// "value" pairs whose key contains one of Keys, are replaced; bearer tokens
+// and JWTs are replaced wherever they appear. Message bodies and plain-text
+// evidence longer than MaxBodyBytes are truncated.
+type EvidenceRedaction struct {
+	Headers      []string
+	Keys         []string
+	MaxBodyBytes int
+}
+
+// FindingEvidence is the redacted view of one stored finding evidence.
+// Nuclei results expose the matched request and response; built-in checks
+// and unparsed evidence are returned as Text.
+type FindingEvidence struct {
+	Format           string   `json:"format"`
+	Request          string   `json:"request,omitempty"`
+	Response         string   `json:"response,omitempty"`
+	MatcherName      string   `json:"matcher_name,omitempty"`
+	ExtractedResults []string `json:"extracted_results,omitempty"`
+	Text             string   `json:"text,omitempty"`
+	Redactions       int      `json:"redactions"`
+	Truncated        bool     `json:"truncated"`
+}
+
+const (
+	FindingEvidenceNuclei = "nuclei"
+	FindingEvidenceText   = "text"
+)
+
+var (
+	evidenceBearerPattern = regexp.MustCompile(`(?i)\b(bearer|basic|digest|token)\s+[A-Za-z0-9._~+/=-]{8,}`)
+	evidenceJWTPattern    = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{4,}\.[A-Za-z0-9_-]{4,}\.[A-Za-z0-9_-]*`)
+)
+
+var configuredEvidenceRedaction struct {
+	sync.RWMutex
+	redaction *EvidenceRedaction
+}
+
+// DefaultEvidenceRedaction masks credentials, session cookies and common
+// secret parameters and keeps at most DefaultEvidenceMaxBodyBytes of a body.
+func DefaultEvidenceRedaction() EvidenceRedaction {
+	return EvidenceRedaction{
+		Headers: []string{"authorization", "proxy-authorization", "cookie", "set-cookie", "x-api-key", "x-auth-token", "x-csrf-token", "x-xsrf-token", "x-amz-security-token"},
+		Keys: []string{"password", "passwd", "pwd", "secret", "token", "session", "apikey", "api_key", "api-key", "access_key", "private_key",
+			"credential", "auth"},
+		MaxBodyBytes: DefaultEvidenceMaxBodyBytes,
+	}
+}
+
+// ConfigureEvidenceRedaction adds operator-supplied header and key names to
+// the defaults and replaces the body limit when maxBodyBytes is positive.
+func ConfigureEvidenceRedaction(extraNames []string, maxBodyBytes int) {
+	redaction := DefaultEvidenceRedaction()
+	for _, name := range extraNames {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		redaction.Headers = append(redaction.Headers, name)
+		redaction.Keys = append(redaction.Keys, name)
+	}
+	if maxBodyBytes > 0 {
+		redaction.MaxBodyBytes = maxBodyBytes
+	}
+	configuredEvidenceRedaction.Lock()
+	configuredEvidenceRedaction.redaction = &redaction
+	configuredEvidenceRedaction.Unlock()
+}
+
+// ConfiguredEvidenceRedaction returns the redaction set by
+// ConfigureEvidenceRedaction, or the defaults.
+func ConfiguredEvidenceRedaction() EvidenceRedaction {
+	configuredEvidenceRedaction.RLock()
+	defer configuredEvidenceRedaction.RUnlock()
+	if configuredEvidenceRedaction.redaction == nil {
+		return DefaultEvidenceRedaction()
+	}
+	return *configuredEvidenceRedaction.redaction
+}
+
+// RedactFindingEvidence parses evidence stored with a finding and returns it
+// with secrets masked. Nuclei JSONL lines are split into request, response
+// and matcher details; anything else is treated as text.
+func RedactFindingEvidence(raw string, redaction EvidenceRedaction) FindingEvidence {
+	redactor := newEvidenceRedactor(redaction)
+	var row struct {
+		Request          string   `json:"request"`
+		Response         string   `json:"response"`
+		MatcherName      string   `json:"matcher-name"`
+		ExtractedResults []string `json:"extracted-results"`
+	}
+	trimmed := strings.TrimSpace(raw)
+	if strings.HasPrefix(trimmed, "{") && json.Unmarshal([]byte(trimmed), &row) == nil {
+		evidence := FindingEvidence{Format: FindingEvidenceNuclei, MatcherName: row.MatcherName}
+		evidence.Request = redactor.message(row.Request)
+		evidence.Response = redactor.message(row.Response)
+		for _, value := range row.ExtractedResults {
+			evidence.ExtractedResults = append(evidence.ExtractedResults, redactor.truncate(redactor.text(value)))
+		}
+		evidence.Redactions, evidence.Truncated = redactor.redactions, redactor.truncated
+		return evidence
+	}
+	evidence := FindingEvidence{Format: FindingEvidenceText, Text: redactor.truncate(redactor.text(raw))}
+	evidence.Redactions, evidence.Truncated = redactor.redactions, redactor.truncated
+	return evidence
+}
+
+// Excerpt renders the redacted evidence as one bounded block of text, for
+// reports that cannot show the full request and response.
+func (evidence FindingEvidence) Excerpt(limit int) string {
+	parts := make([]string, 0, 4)
+	if evidence.MatcherName != "" {
+		parts = append(parts, "matcher: "+evidence.MatcherName)
+	}
+	if len(evidence.ExtractedResults) > 0 {
+		parts = append(parts, "extracted: "+strings.Join(evidence.ExtractedResults, ", "))
+	}
+	for _, value := range []string{evidence.Request, evidence.Response, evidence.Text} {
+		if strings.TrimSpace(value) != "" {
+			parts = append(parts, strings.TrimSpace(value))
+		}
+	}
+	excerpt := strings.Join(parts, "\n\n")
+	if limit <= 0 || len(excerpt) <= limit {
+		return excerpt
+	}
+	kept := truncateUTF8(excerpt, limit)
+	return kept + fmt.Sprintf("\n[excerpt truncated, %d bytes omitted]", len(excerpt)-len(kept))
+}
+
+type evidenceRedactor struct {
+	headers    map[string]struct{}
+	keys       *regexp.Regexp
+	maxBody    int
+	redactions int
+	truncated  bool
+}
+
+func newEvidenceRedactor(redaction EvidenceRedaction) *evidenceRedactor {
+	redactor := &evidenceRedactor{headers: make(map[string]struct{}), maxBody: redaction.MaxBodyBytes}
+	for _, header := range redaction.Headers {
+		if header = strings.ToLower(strings.TrimSpace(header)); header != "" {
+			redactor.headers[header] = struct{}{}
+		}
+	}
+	fragments := make([]string, 0, len(redaction.Keys))
+	for _, key := range redaction.Keys {
+		if key = strings.TrimSpace(key); key != "" {
+			fragments = append(fragments, regexp.QuoteMeta(key))
+		}
+	}
+	if len(fragments) > 0 {
+		redactor.keys = regexp.MustCompile(`(?i)(["']?[\w.-]*(?:` + strings.Join(fragments, "|") + `)[\w.-]*["']?[ \t]*[:=][ \t]*)` +
+			`("(?:[^"\\\r\n]|\\.)*"?|'(?:[^'\\\r\n]|\\.)*'?|[^"'&,;}<\r\n]*[^"'&,;}<\s])`)
+	}
+	return redactor
+}
+
+// message redacts one HTTP message: sensitive header values are replaced
+// whole, and the start line and body go through the text rules.
+func (redactor *evidenceRedactor) message(value string) string {
+	if value == "" {
+		return ""
+	}
+	head, body, separator := value, "", ""
+	for _, candidate := range []string{"\r\n\r\n", "\n\n"} {
+		if index := strings.Index(value, candidate); index >= 0 {
+			head, body, separator = value[:index], value[index+len(candidate):], candidate
+			break
+		}
+	}
+	lines := strings.Split(head, "\n")
+	for index, line := range lines {
+		if index == 0 {
+			lines[index] = redactor.startLine(line)
+			continue
+		}
+		name, _, found := strings.Cut(line, ":")
+		if _, sensitive := redactor.headers[strings.ToLower(strings.TrimSpace(name))]; found && sensitive {
+			lines[index] = name + ": " + evidenceRedacted
+			if strings.HasSuffix(line, "\r") {
+				lines[index] += "\r"
+			}
+			redactor.redactions++
+			continue
+		}
+		lines[index] = redactor.text(line)
+	}
+	redacted := strings.Join(lines, "\n")
+	if separator == "" {
+		return redacted
+	}
+	return redacted + separator + redactor.truncate(redactor.text(body))
+}
+
+// startLine redacts a request or status line field by field, so a secret
+// query parameter is masked without swallowing the protocol version after it.
+func (redactor *evidenceRedactor) startLine(line string) string {
+	fields := strings.SplitN(line, " ", 3)
+	for index, field := range fields {
+		fields[index] = redactor.text(field)
+	}
+	return strings.Join(fields, " ")
+}
+
+// text masks tokens and key/value secrets. A quoted value is masked up to its
+// closing quote and an unquoted one up to the end of the line or the next
+// parameter separator, so values with spaces such as "Basic <token>" or a
+// quoted passphrase are masked whole.
+func (redactor *evidenceRedactor) text(value string) string {
+	replace := func(pattern *regexp.Regexp, value, replacement string) string {
+		return pattern.ReplaceAllStringFunc(value, func(match string) string {
+			redactor.redactions++
+			return pattern.ReplaceAllString(match, replacement)
+		})
+	}
+	value = replace(evidenceJWTPattern, value, evidenceRedacted)
+	if redactor.keys != nil {
+		value = redactor.keys.ReplaceAllStringFunc(value, func(match string) string {
+			redactor.redactions++
+			groups := redactor.keys.FindStringSubmatch(match)
+			if quote := groups[2][:1]; quote == `"` || quote == "'" {
+				return groups[1] + quote + evidenceRedacted + quote
+			}
+			return groups[1] + evidenceRedacted
+		})
+	}
+	value = replace(evidenceBearerPattern, value, "$1 "+evidenceRedacted)
+	return value
+}
+
+func (redactor *evidenceRedactor) truncate(value string) string {
+	if redactor.maxBody <= 0 || len(value) <= redactor.maxBody {
+		return value
+	}
+	kept := truncateUTF8(value, redactor.maxBody)
+	redactor.truncated = true
+	return kept + fmt.Sprintf("\n[truncated, %d bytes omitted]", len(value)-len(kept))
+}
+
+func truncateUTF8(value string, limit int) string {
+	if len(value) <= limit {
+		return value
+	}
+	for limit > 0 && value[limit]&0xc0 == 0x80 {
+		limit--
+	}
+	return value[:limit]
+}
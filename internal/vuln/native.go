@@ -0,0 +1,610 @@
+package vuln
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golandproject/yscan/internal/model"
+)
+
+// NativeCheckRevision identifies the built-in check set. It is recorded as the
+// template set revision of native candidates.
+const NativeCheckRevision = "native-checks-v1"
+
+const (
+	nativeCheckPathPrefix   = "native:"
+	defaultNativeTimeout    = 5 * time.Second
+	maxNativeResponseBytes  = 64 * 1024
+	maxNativeEvidenceBytes  = 512
+	maxNativeFTPReplyLines  = 64
+	mongoOpMsg              = 2013
+	nativeCheckUserAgent    = "yscan-native-check/1"
+	nativeCheckVulnType     = "native"
+	maxDotenvEvidenceFields = 8
+)
+
+var ErrNativeCheckMissing = fmt.Errorf("%w: unknown native check", ErrTemplateMissing)
+
+// NativeCheck is one built-in read-only check. Products lists the normalized
+// fingerprint products and scanned service names the check applies to; it is
+// the check's own mapping and needs no reviewed template mapping.
+type NativeCheck struct {
+	ID          string
+	Name        string
+	Severity    string
+	Description string
+	Products    []string
+	// Path is the request path of HTTP checks; other checks speak the
+	// service's own protocol over TCP.
+	Path  string
+	probe nativeProbe
+}
+
+// nativeProbe reports whether endpoint is exposed, with a short excerpt that
+// shows it. Excerpts never carry credentials or file contents. A network
+// error means the exposure was not observed.
+type nativeProbe func(ctx context.Context, endpoint nativeEndpoint) (string, bool, error)
+
+type nativeEndpoint struct {
+	address string
+	baseURL string
+}
+
+var nativeWebProducts = []string{"http", "https", "http-unknown", "nginx", "apache", "apache http server", "iis", "lighttpd", "caddy", "jetty", "tomcat"}
+
+var nativeChecks = []NativeCheck{
+	{ID: "docker-api-unauth", Name: "Docker Engine API without authentication", Severity: "critical", Description: "The Docker Engine API answers /version without client authentication; anyone who reaches it controls the host's containers.", Products: []string{"docker"}, Path: "/version", probe: probeDockerVersion},
+	{ID: "dotenv-exposure", Name: "Exposed .env file", Severity: "high", Description: "The web server serves the application's .env file, which usually holds credentials.", Products: nativeWebProducts, Path: "/.env", probe: probeDotenv},
+	{ID: "elasticsearch-unauth-access", Name: "Elasticsearch without authentication", Severity: "high", Description: "Elasticsearch answers cluster information without authentication; indexed data is likely readable.", Products: []string{"elasticsearch", "opensearch"}, Path: "/", probe: probeElasticsearchRoot},
+	{ID: "ftp-anonymous-login", Name: "FTP anonymous login", Severity: "medium", Description: "The FTP server accepts the anonymous account.", Products: []string{"ftp", "pure-ftpd", "vsftpd", "proftpd", "filezilla server"}, probe: probeFTPAnonymous},
+	{ID: "git-config-exposure", Name: "Exposed .git directory", Severity: "medium", Description: "The web server serves .git/config; repository history and source are likely downloadable.", Products: nativeWebProducts, Path: "/.git/config", probe: probeGitConfig},
+	{ID: "kibana-unauth-status", Name: "Kibana without authentication", Severity: "medium", Description: "Kibana answers /api/status without authentication.", Products: []string{"kibana"}, Path: "/api/status", probe: probeKibanaStatus},
+	{ID: "kubelet-api-anonymous", Name: "Kubelet API allows anonymous access", Severity: "critical", Description: "The kubelet lists its pods to an anonymous client.", Products: []string{"kubelet"}, Path: "/pods", probe: probeKubeletPods},
+	{ID: "kubernetes-api-anonymous", Name: "Kubernetes API allows anonymous listing", Severity: "critical", Description: "The Kubernetes API server lists namespaces to an anonymous client.", Products: []string{"kubernetes", "kube-apiserver"}, Path: "/api/v1/namespaces", probe: probeKubernetesNamespaces},
+	{ID: "memcached-stats-unauth", Name: "Memcached without authentication", Severity: "medium", Description: "Memcached answers the stats command without authentication; cached data is likely readable.", Products: []string{"memcached"}, probe: probeMemcachedStats},
+	{ID: "mongodb-unauth-list-databases", Name: "MongoDB without authentication", Severity: "high", Description: "MongoDB lists its databases without authentication.", Products: []string{"mongodb"}, probe: probeMongoDBListDatabases},
+	{ID: "redis-unauth-info", Name: "Redis without authentication", Severity: "high", Description: "Redis answers INFO without authentication; stored data is readable and writable.", Products: []string{"redis"}, probe: probeRedisInfo},
+}
+
+// NativeChecks returns the built-in checks ordered by ID.
+func NativeChecks() []NativeCheck {
+	checks := make([]NativeCheck, 0, len(nativeChecks))
+	for _, check := range nativeChecks {
+		check.Products = append([]string(nil), check.Products...)
+		checks = append(checks, check)
+	}
+	sort.Slice(checks, func(left, right int) bool { return checks[left].ID < checks[right].ID })
+	return checks
+}
+
+// SelectNativeChecks returns the built-in checks mapped to product, a
+// normalized fingerprint product or scanned service name.
+func SelectNativeChecks(product string) []NativeCheck {
+	product = strings.ToLower(strings.TrimSpace(product))
+	selected := make([]NativeCheck, 0)
+	if product == "" {
+		return selected
+	}
+	for _, check := range NativeChecks() {
+		for _, candidate := range check.Products {
+			if candidate == product {
+				selected = append(selected, check)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// NativeCheckPath is the candidate path recorded for a built-in check, so
+// audits can tell it from a template file.
+func NativeCheckPath(id string) string {
+	return nativeCheckPathPrefix + id
+}
+
+func nativeCheckByID(id string) (NativeCheck, bool) {
+	for _, check := range nativeChecks {
+		if check.ID == id {
+			return check, true
+		}
+	}
+	return NativeCheck{}, false
+}
+
+// NativeValidator runs built-in checks by ID and needs neither the Nuclei
+// binary nor a template directory. Each check sends at most a few read-only
+// requests; an endpoint that refuses or cannot be reached is not reported.
+type NativeValidator struct {
+	// Timeout bounds each check on each port; zero means five seconds.
+	Timeout time.Duration
+}
+
+func (validator NativeValidator) Validate(ctx context.Context, ip string, ports []model.ScanResult, checks []string) ValidationResult {
+	timeout := validator.Timeout
+	if timeout <= 0 {
+		timeout = defaultNativeTimeout
+	}
+	selected := make([]NativeCheck, 0, len(checks))
+	for _, id := range checks {
+		check, ok := nativeCheckByID(strings.TrimSpace(id))
+		if !ok {
+			return ValidationResult{Err: fmt.Errorf("%w: %s", ErrNativeCheckMissing, id)}
+		}
+		selected = append(selected, check)
+	}
+	result := ValidationResult{Started: true, Findings: make([]model.NucleiFinding, 0)}
+	for _, port := range ports {
+		if !port.Open {
+			continue
+		}
+		_, portText, err := net.SplitHostPort(port.Address)
+		portNumber, parseErr := strconv.Atoi(portText)
+		if err != nil || parseErr != nil {
+			continue
+		}
+		scheme := "http"
+		if strings.EqualFold(strings.TrimSpace(port.Service), "https") {
+			scheme = "https"
+		}
+		address := net.JoinHostPort(ip, portText)
+		endpoint := nativeEndpoint{address: address, baseURL: scheme + "://" + address}
+		for _, check := range selected {
+			if err := ctx.Err(); err != nil {
+				result.Err = err
+				return result
+			}
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			evidence, exposed, _ := check.probe(checkCtx, endpoint)
+			cancel()
+			result.Executed = true
+			if err := ctx.Err(); err != nil {
+				result.Err = err
+				return result
+			}
+			if !exposed {
+				continue
+			}
+			matchedAt := address
+			if check.Path != "" {
+				matchedAt = endpoint.baseURL + check.Path
+			}
+			evidence = truncateUTF8(evidence, maxNativeEvidenceBytes)
+			result.Findings = append(result.Findings, model.NucleiFinding{
+				TemplateID: check.ID, VulnType: nativeCheckVulnType, Name: check.Name, Severity: check.Severity,
+				Description: check.Description, Host: ip, MatchedAt: matchedAt, Target: matchedAt,
+				TargetIP: ip, TargetPort: portNumber, ScanTime: time.Now().UTC().Format(time.RFC3339),
+				Evidence: evidence, Tags: nativeCheckVulnType,
+			})
+		}
+	}
+	return result
+}
+
+func probeRedisInfo(ctx context.Context, endpoint nativeEndpoint) (string, bool, error) {
+	response, err := nativeExchange(ctx, endpoint, []byte("INFO server\r\n"), func(data []byte) bool {
+		return (bytes.HasPrefix(data, []byte("-")) && bytes.Contains(data, []byte("\r\n"))) || nativeLine(data, "redis_version:") != ""
+	})
+	version := nativeLine(response, "redis_version:")
+	if !bytes.HasPrefix(response, []byte("$")) || version == "" {
+		return "", false, err
+	}
+	return "INFO answered without AUTH: " + version, true, nil
+}
+
+func probeMemcachedStats(ctx context.Context, endpoint nativeEndpoint) (string, bool, error) {
+	response, err := nativeExchange(ctx, endpoint, []byte("stats\r\n"), func(data []byte) bool {
+		return bytes.Contains(data, []byte("END\r\n")) || bytes.Contains(data, []byte("ERROR"))
+	})
+	version := nativeLine(response, "STAT version ")
+	if nativeLine(response, "STAT pid ") == "" || version == "" {
+		return "", false, err
+	}
+	return "stats answered without authentication: " + version, true, nil
+}
+
+func probeFTPAnonymous(ctx context.Context, endpoint nativeEndpoint) (string, bool, error) {
+	conn, err := nativeDial(ctx, endpoint)
+	if err != nil {
+		return "", false, err
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(io.LimitReader(conn, maxNativeResponseBytes))
+	if code, _, err := readFTPReply(reader); err != nil || code != 220 {
+		return "", false, err
+	}
+	if _, err := conn.Write([]byte("USER anonymous\r\n")); err != nil {
+		return "", false, err
+	}
+	code, reply, err := readFTPReply(reader)
+	if err == nil && code == 331 {
+		if _, err := conn.Write([]byte("PASS anonymous@\r\n")); err != nil {
+			return "", false, err
+		}
+		code, reply, err = readFTPReply(reader)
+	}
+	_, _ = conn.Write([]byte("QUIT\r\n"))
+	if err != nil || code != 230 {
+		return "", false, err
+	}
+	return "anonymous login accepted: " + reply, true, nil
+}
+
+// readFTPReply reads one possibly multi-line reply and returns its code and
+// final line.
+func readFTPReply(reader *bufio.Reader) (int, string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return 0, "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if len(line) < 3 {
+		return 0, "", fmt.Errorf("malformed ftp reply %q", line)
+	}
+	code, err := strconv.Atoi(line[:3])
+	if err != nil {
+		return 0, "", fmt.Errorf("malformed ftp reply %q", line)
+	}
+	if len(line) == 3 || line[3] != '-' {
+		return code, line, nil
+	}
+	for index := 0; index < maxNativeFTPReplyLines; index++ {
+		next, err := reader.ReadString('\n')
+		if err != nil {
+			return 0, "", err
+		}
+		next = strings.TrimRight(next, "\r\n")
+		if strings.HasPrefix(next, line[:3]+" ") {
+			return code, next, nil
+		}
+	}
+	return 0, "", errors.New("ftp reply exceeds line limit")
+}
+
+// probeMongoDBListDatabases sends one OP_MSG listDatabases with nameOnly.
+// Servers older than MongoDB 3.6 do not speak OP_MSG and are not reported.
+func probeMongoDBListDatabases(ctx context.Context, endpoint nativeEndpoint) (string, bool, error) {
+	conn, err := nativeDial(ctx, endpoint)
+	if err != nil {
+		return "", false, err
+	}
+	defer conn.Close()
+	command := mongoListDatabasesCommand()
+	message := binary.LittleEndian.AppendUint32(nil, uint32(16+4+1+len(command)))
+	message = binary.LittleEndian.AppendUint32(message, 1)
+	message = binary.LittleEndian.AppendUint32(message, 0)
+	message = binary.LittleEndian.AppendUint32(message, mongoOpMsg)
+	message = binary.LittleEndian.AppendUint32(message, 0)
+	message = append(message, 0)
+	message = append(message, command...)
+	if _, err := conn.Write(message); err != nil {
+		return "", false, err
+	}
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", false, err
+	}
+	size := int(binary.LittleEndian.Uint32(header[0:4]))
+	if binary.LittleEndian.Uint32(header[12:16]) != mongoOpMsg || size < 16+4+1+5 || size > maxNativeResponseBytes {
+		return "", false, nil
+	}
+	body := make([]byte, size-16)
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return "", false, err
+	}
+	if body[4] != 0 {
+		return "", false, nil
+	}
+	ok, databases := 0.0, -1
+	err = bsonElements(body[5:], func(kind byte, name string, value []byte) error {
+		switch {
+		case name == "ok" && kind == 0x01:
+			ok = math.Float64frombits(binary.LittleEndian.Uint64(value))
+		case name == "ok" && kind == 0x10:
+			ok = float64(int32(binary.LittleEndian.Uint32(value)))
+		case name == "databases" && kind == 0x04:
+			databases = 0
+			return bsonElements(value, func(byte, string, []byte) error {
+				databases++
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil || ok != 1 || databases < 0 {
+		return "", false, err
+	}
+	return fmt.Sprintf("listDatabases answered without authentication: %d databases", databases), true, nil
+}
+
+func mongoListDatabasesCommand() []byte {
+	elements := []byte{0x10}
+	elements = append(elements, "listDatabases\x00"...)
+	elements = binary.LittleEndian.AppendUint32(elements, 1)
+	elements = append(elements, 0x08)
+	elements = append(elements, "nameOnly\x00"...)
+	elements = append(elements, 1)
+	elements = append(elements, 0x02)
+	elements = append(elements, "$db\x00"...)
+	elements = binary.LittleEndian.AppendUint32(elements, uint32(len("admin\x00")))
+	elements = append(elements, "admin\x00"...)
+	document := binary.LittleEndian.AppendUint32(nil, uint32(4+len(elements)+1))
+	document = append(document, elements...)
+	return append(document, 0)
+}
+
+// bsonElements visits the top-level elements of one BSON document. Only the
+// element types a listDatabases reply can carry are understood.
+func bsonElements(document []byte, visit func(kind byte, name string, value []byte) error) error {
+	if len(document) < 5 {
+		return errors.New("short bson document")
+	}
+	size := int(int32(binary.LittleEndian.Uint32(document)))
+	if size < 5 || size > len(document) || document[size-1] != 0 {
+		return errors.New("malformed bson document")
+	}
+	remaining := document[4 : size-1]
+	for len(remaining) > 0 {
+		kind := remaining[0]
+		end := bytes.IndexByte(remaining[1:], 0)
+		if end < 0 {
+			return errors.New("malformed bson element name")
+		}
+		name := string(remaining[1 : 1+end])
+		remaining = remaining[2+end:]
+		length, err := bsonValueLength(kind, remaining)
+		if err != nil {
+			return err
+		}
+		if err := visit(kind, name, remaining[:length]); err != nil {
+			return err
+		}
+		remaining = remaining[length:]
+	}
+	return nil
+}
+
+func bsonValueLength(kind byte, data []byte) (int, error) {
+	length := 0
+	switch kind {
+	case 0x0A:
+		length = 0
+	case 0x08:
+		length = 1
+	case 0x10:
+		length = 4
+	case 0x01, 0x09, 0x11, 0x12:
+		length = 8
+	case 0x07:
+		length = 12
+	case 0x13:
+		length = 16
+	case 0x02, 0x03, 0x04, 0x05:
+		if len(data) < 4 {
+			return 0, errors.New("short bson value")
+		}
+		declared := int(int32(binary.LittleEndian.Uint32(data)))
+		if declared < 0 {
+			return 0, errors.New("negative bson length")
+		}
+		switch kind {
+		case 0x02:
+			length = 4 + declared
+		case 0x05:
+			length = 4 + 1 + declared
+		default:
+			length = declared
+		}
+	default:
+		return 0, fmt.Errorf("unsupported bson type 0x%02x", kind)
+	}
+	if length > len(data) {
+		return 0, errors.New("truncated bson value")
+	}
+	return length, nil
+}
+
+func probeElasticsearchRoot(ctx context.Context, endpoint nativeEndpoint) (string, bool, error) {
+	var document struct {
+		ClusterName string `json:"cluster_name"`
+		Tagline     string `json:"tagline"`
+		Version     struct {
+			Number string `json:"number"`
+		} `json:"version"`
+	}
+	if err := nativeGetJSON(ctx, endpoint, "/", &document); err != nil {
+		return "", false, err
+	}
+	if document.Version.Number == "" || (document.ClusterName == "" && !strings.Contains(document.Tagline, "for Search")) {
+		return "", false, nil
+	}
+	return "cluster information readable without authentication: version " + document.Version.Number, true, nil
+}
+
+func probeKibanaStatus(ctx context.Context, endpoint nativeEndpoint) (string, bool, error) {
+	var document struct {
+		Version struct {
+			Number string `json:"number"`
+		} `json:"version"`
+		Status json.RawMessage `json:"status"`
+	}
+	if err := nativeGetJSON(ctx, endpoint, "/api/status", &document); err != nil {
+		return "", false, err
+	}
+	if document.Version.Number == "" || len(document.Status) == 0 {
+		return "", false, nil
+	}
+	return "status readable without authentication: version " + document.Version.Number, true, nil
+}
+
+func probeDockerVersion(ctx context.Context, endpoint nativeEndpoint) (string, bool, error) {
+	var document struct {
+		Version    string `json:"Version"`
+		APIVersion string `json:"ApiVersion"`
+	}
+	if err := nativeGetJSON(ctx, endpoint, "/version", &document); err != nil {
+		return "", false, err
+	}
+	if document.APIVersion == "" {
+		return "", false, nil
+	}
+	return fmt.Sprintf("engine API answered without authentication: version %s, API %s", document.Version, document.APIVersion), true, nil
+}
+
+func probeKubernetesNamespaces(ctx context.Context, endpoint nativeEndpoint) (string, bool, error) {
+	return probeKubernetesList(ctx, endpoint, "/api/v1/namespaces", "NamespaceList", "namespaces")
+}
+
+func probeKubeletPods(ctx context.Context, endpoint nativeEndpoint) (string, bool, error) {
+	return probeKubernetesList(ctx, endpoint, "/pods", "PodList", "pods")
+}
+
+func probeKubernetesList(ctx context.Context, endpoint nativeEndpoint, path, kind, noun string) (string, bool, error) {
+	var document struct {
+		Kind  string            `json:"kind"`
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := nativeGetJSON(ctx, endpoint, path, &document); err != nil {
+		return "", false, err
+	}
+	if document.Kind != kind {
+		return "", false, nil
+	}
+	return fmt.Sprintf("anonymous client listed %d %s", len(document.Items), noun), true, nil
+}
+
+func probeGitConfig(ctx context.Context, endpoint nativeEndpoint) (string, bool, error) {
+	status, body, err := nativeGet(ctx, endpoint, "/.git/config")
+	if err != nil || status != http.StatusOK || nativeLooksLikeHTML(body) {
+		return "", false, err
+	}
+	if !bytes.Contains(body, []byte("[core]")) || !bytes.Contains(body, []byte("repositoryformatversion")) {
+		return "", false, nil
+	}
+	return ".git/config readable with a [core] section", true, nil
+}
+
+var dotenvAssignment = regexp.MustCompile(`^(?:export\s+)?([A-Za-z_][A-Za-z0-9_]*)\s*=`)
+
+// probeDotenv records only variable names; values are never kept.
+func probeDotenv(ctx context.Context, endpoint nativeEndpoint) (string, bool, error) {
+	status, body, err := nativeGet(ctx, endpoint, "/.env")
+	if err != nil || status != http.StatusOK || nativeLooksLikeHTML(body) {
+		return "", false, err
+	}
+	names := make([]string, 0)
+	for _, line := range strings.Split(string(body), "\n") {
+		if match := dotenvAssignment.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			names = append(names, match[1])
+		}
+	}
+	if len(names) < 2 {
+		return "", false, nil
+	}
+	shown := names
+	if len(shown) > maxDotenvEvidenceFields {
+		shown = shown[:maxDotenvEvidenceFields]
+	}
+	return fmt.Sprintf(".env readable with %d variables: %s", len(names), strings.Join(shown, ", ")), true, nil
+}
+
+func nativeDial(ctx context.Context, endpoint nativeEndpoint) (net.Conn, error) {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", endpoint.address)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	return conn, nil
+}
+
+// nativeExchange writes request and reads until done accepts the response,
+// the peer stops sending or the response limit is reached.
+func nativeExchange(ctx context.Context, endpoint nativeEndpoint, request []byte, done func([]byte) bool) ([]byte, error) {
+	conn, err := nativeDial(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if _, err := conn.Write(request); err != nil {
+		return nil, err
+	}
+	response := make([]byte, 0, 4096)
+	buffer := make([]byte, 4096)
+	for len(response) < maxNativeResponseBytes {
+		n, err := conn.Read(buffer[:min(len(buffer), maxNativeResponseBytes-len(response))])
+		response = append(response, buffer[:n]...)
+		if done(response) {
+			return response, nil
+		}
+		if err != nil {
+			return response, err
+		}
+	}
+	return response, nil
+}
+
+// nativeLine returns the complete response line starting with prefix.
+func nativeLine(data []byte, prefix string) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasSuffix(line, "\r") && strings.HasPrefix(line, prefix) {
+			return strings.TrimSpace(line)
+		}
+	}
+	return ""
+}
+
+func nativeGet(ctx context.Context, endpoint nativeEndpoint, path string) (int, []byte, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext:       (&net.Dialer{}).DialContext,
+			DisableKeepAlives: true,
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true}, // internal endpoints commonly use private certificates.
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.baseURL+path, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	request.Header.Set("User-Agent", nativeCheckUserAgent)
+	response, err := client.Do(request)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxNativeResponseBytes))
+	return response.StatusCode, body, err
+}
+
+func nativeGetJSON(ctx context.Context, endpoint nativeEndpoint, path string, document any) error {
+	status, body, err := nativeGet(ctx, endpoint, path)
+	if err != nil {
+		return err
+	}
+	// Refused or non-JSON answers leave document empty, which no probe
+	// reports as an exposure.
+	if status == http.StatusOK {
+		_ = json.Unmarshal(body, document)
+	}
+	return nil
+}
+
+func nativeLooksLikeHTML(body []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(body), []byte("<"))
+}
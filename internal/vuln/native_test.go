@@ -0,0 +1,217 @@
+package vuln
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"golandproject/yscan/internal/model"
+)
+
+func TestSelectNativeChecksUsesCheckProductMapping(t *testing.T) {
+	ids := func(checks []NativeCheck) string {
+		values := make([]string, 0, len(checks))
+		for _, check := range checks {
+			values = append(values, check.ID)
+		}
+		return strings.Join(values, ",")
+	}
+	if got := ids(SelectNativeChecks(" Redis ")); got != "redis-unauth-info" {
+		t.Fatalf("redis checks=%s", got)
+	}
+	if got := ids(SelectNativeChecks("nginx")); got != "dotenv-exposure,git-config-exposure" {
+		t.Fatalf("web checks=%s", got)
+	}
+	if got := SelectNativeChecks("postgresql"); len(got) != 0 {
+		t.Fatalf("unmapped product checks=%v", got)
+	}
+}
+
+func TestNativeValidatorReportsUnauthenticatedTCPServices(t *testing.T) {
+	redis := serveNativeTCP(t, "redis", func(conn net.Conn) {
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		if strings.HasPrefix(line, "INFO") {
+			body := "# Server\r\nredis_version:7.2.4\r\nredis_mode:standalone\r\n"
+			_, _ = io.WriteString(conn, "$"+strconv.Itoa(len(body))+"\r\n"+body+"\r\n")
+		}
+	})
+	guarded := serveNativeTCP(t, "redis", func(conn net.Conn) {
+		_, _ = bufio.NewReader(conn).ReadString('\n')
+		_, _ = io.WriteString(conn, "-NOAUTH Authentication required.\r\n")
+	})
+	memcached := serveNativeTCP(t, "memcached", func(conn net.Conn) {
+		_, _ = bufio.NewReader(conn).ReadString('\n')
+		_, _ = io.WriteString(conn, "STAT pid 1\r\nSTAT version 1.6.21\r\nEND\r\n")
+	})
+	ftp := serveNativeTCP(t, "ftp", func(conn net.Conn) {
+		reader := bufio.NewReader(conn)
+		_, _ = io.WriteString(conn, "220-welcome\r\n220 ready\r\n")
+		_, _ = reader.ReadString('\n')
+		_, _ = io.WriteString(conn, "331 send password\r\n")
+		_, _ = reader.ReadString('\n')
+		_, _ = io.WriteString(conn, "230 Login successful.\r\n")
+		_, _ = reader.ReadString('\n')
+	})
+	mongo := serveNativeTCP(t, "mongodb", func(conn net.Conn) {
+		header := make([]byte, 16)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		if _, err := io.ReadFull(conn, make([]byte, binary.LittleEndian.Uint32(header)-16)); err != nil {
+			return
+		}
+		_, _ = conn.Write(mongoReplyForTest())
+	})
+
+	validator := NativeValidator{Timeout: 2 * time.Second}
+	cases := []struct {
+		port  model.ScanResult
+		check string
+		want  string
+	}{
+		{redis, "redis-unauth-info", "redis_version:7.2.4"},
+		{guarded, "redis-unauth-info", ""},
+		{memcached, "memcached-stats-unauth", "STAT version 1.6.21"},
+		{ftp, "ftp-anonymous-login", "230 Login successful."},
+		{mongo, "mongodb-unauth-list-databases", "2 databases"},
+	}
+	for _, test := range cases {
+		result := validator.Validate(context.Background(), "127.0.0.1", []model.ScanResult{test.port}, []string{test.check})
+		if result.Err != nil || !result.Started || !result.Executed {
+			t.Fatalf("%s result=%#v", test.check, result)
+		}
+		if test.want == "" {
+			if len(result.Findings) != 0 {
+				t.Fatalf("%s reported a guarded service: %#v", test.check, result.Findings)
+			}
+			continue
+		}
+		if len(result.Findings) != 1 {
+			t.Fatalf("%s findings=%#v", test.check, result.Findings)
+		}
+		finding := result.Findings[0]
+		if finding.TemplateID != test.check || finding.TargetIP != "127.0.0.1" || finding.TargetPort == 0 || finding.Target != test.port.Address || !strings.Contains(finding.Evidence, test.want) {
+			t.Fatalf("%s finding=%#v", test.check, finding)
+		}
+	}
+}
+
+func TestNativeValidatorWebChecksKeepSecretsOutOfEvidence(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case "/.env":
+			_, _ = io.WriteString(writer, "# app\nAPP_KEY=base64:secret-value\nDB_PASSWORD=hunter2\n")
+		case "/.git/config":
+			_, _ = io.WriteString(writer, "<html>not found</html>")
+		case "/version":
+			_, _ = io.WriteString(writer, `{"Version":"24.0.7","ApiVersion":"1.43"}`)
+		default:
+			http.NotFound(writer, request)
+		}
+	}))
+	defer server.Close()
+	port := model.ScanResult{Address: strings.TrimPrefix(server.URL, "http://"), Open: true, Service: "http"}
+	ip, _, _ := net.SplitHostPort(port.Address)
+
+	result := NativeValidator{}.Validate(context.Background(), ip, []model.ScanResult{port}, []string{"dotenv-exposure", "git-config-exposure", "docker-api-unauth", "kibana-unauth-status"})
+	if result.Err != nil || !result.Executed || len(result.Findings) != 2 {
+		t.Fatalf("web result=%#v", result)
+	}
+	dotenv, docker := result.Findings[0], result.Findings[1]
+	if dotenv.TemplateID != "dotenv-exposure" || dotenv.MatchedAt != server.URL+"/.env" || !strings.Contains(dotenv.Evidence, "APP_KEY, DB_PASSWORD") || strings.Contains(dotenv.Evidence, "hunter2") || strings.Contains(dotenv.Evidence, "secret-value") {
+		t.Fatalf("dotenv finding=%#v", dotenv)
+	}
+	if docker.TemplateID != "docker-api-unauth" || docker.Severity != "critical" || !strings.Contains(docker.Evidence, "API 1.43") {
+		t.Fatalf("docker finding=%#v", docker)
+	}
+}
+
+func TestNativeValidatorCutsEvidenceOnARuneBoundary(t *testing.T) {
+	redis := serveNativeTCP(t, "redis", func(conn net.Conn) {
+		_, _ = bufio.NewReader(conn).ReadString('\n')
+		body := "# Server\r\nredis_version:" + strings.Repeat("界", 200) + "\r\n"
+		_, _ = io.WriteString(conn, "$"+strconv.Itoa(len(body))+"\r\n"+body+"\r\n")
+	})
+	result := NativeValidator{Timeout: 2 * time.Second}.Validate(context.Background(), "127.0.0.1", []model.ScanResult{redis}, []string{"redis-unauth-info"})
+	if result.Err != nil || len(result.Findings) != 1 {
+		t.Fatalf("result=%#v", result)
+	}
+	evidence := result.Findings[0].Evidence
+	if len(evidence) > maxNativeEvidenceBytes || len(evidence) < maxNativeEvidenceBytes-2 || !utf8.ValidString(evidence) {
+		t.Fatalf("evidence is %d bytes, valid UTF-8=%v", len(evidence), utf8.ValidString(evidence))
+	}
+}
+
+func TestNativeValidatorRejectsUnknownChecks(t *testing.T) {
+	result := NativeValidator{}.Validate(context.Background(), "127.0.0.1", []model.ScanResult{{Address: "127.0.0.1:1", Open: true}}, []string{"rm-rf"})
+	if !errors.Is(result.Err, ErrNativeCheckMissing) || !errors.Is(result.Err, ErrTemplateMissing) || result.Started {
+		t.Fatalf("unknown check result=%#v", result)
+	}
+}
+
+func serveNativeTCP(t *testing.T, service string, handle func(net.Conn)) model.ScanResult {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+			handle(conn)
+			_ = conn.Close()
+		}
+	}()
+	return model.ScanResult{Address: listener.Addr().String(), Open: true, Service: service}
+}
+
+func mongoReplyForTest() []byte {
+	database := func(name string) []byte {
+		element := []byte{0x02}
+		element = append(element, "name\x00"...)
+		element = binary.LittleEndian.AppendUint32(element, uint32(len(name)+1))
+		element = append(element, name+"\x00"...)
+		return bsonDocumentForTest(element)
+	}
+	var databases []byte
+	for index, name := range []string{"admin", "orders"} {
+		databases = append(databases, 0x03)
+		databases = append(databases, strconv.Itoa(index)+"\x00"...)
+		databases = append(databases, database(name)...)
+	}
+	elements := []byte{0x04}
+	elements = append(elements, "databases\x00"...)
+	elements = append(elements, bsonDocumentForTest(databases)...)
+	elements = append(elements, 0x01)
+	elements = append(elements, "ok\x00"...)
+	elements = binary.LittleEndian.AppendUint64(elements, 0x3ff0000000000000)
+	document := bsonDocumentForTest(elements)
+	reply := binary.LittleEndian.AppendUint32(nil, uint32(16+4+1+len(document)))
+	reply = binary.LittleEndian.AppendUint32(reply, 2)
+	reply = binary.LittleEndian.AppendUint32(reply, 1)
+	reply = binary.LittleEndian.AppendUint32(reply, mongoOpMsg)
+	reply = binary.LittleEndian.AppendUint32(reply, 0)
+	reply = append(reply, 0)
+	return append(reply, document...)
+}
+
+func bsonDocumentForTest(elements []byte) []byte {
+	document := binary.LittleEndian.AppendUint32(nil, uint32(4+len(elements)+1))
+	document = append(document, elements...)
+	return append(document, 0)
+}
@@ -26,11 +26,17 @@ import (
 
 const maxNucleiStderrBytes = 32 * 1024
 
+// nucleiWaitDelay bounds how long Wait keeps copying output after Nuclei
+// exits or is canceled, in case a child it started still holds the pipes.
+var nucleiWaitDelay = 5 * time.Second
+
 var (
 	ErrNoTemplates              = errors.New("no usable nuclei templates")
 	ErrNucleiMissing            = errors.New("nuclei executable missing")
 	ErrTemplateDirectoryMissing = errors.New("nuclei template directory missing")
 	ErrTemplateMissing          = errors.New("nuclei template missing")
+	ErrNucleiTimeout            = errors.New("nuclei execution timed out")
+	ErrNucleiMemoryLimit        = errors.New("nuclei exceeded its memory limit")
 )
 
 var configuredNucleiBinary struct {
@@ -44,14 +50,54 @@ func ConfigureNucleiBinary(path string) {
 	configuredNucleiBinary.Unlock()
 }
 
-// NucleiExecutionResult separates process startup and actual template
-// execution from findings and errors. Callers must not infer coverage from an
-// empty finding list or a final process error.
-type NucleiExecutionResult struct {
-	Findings []model.NucleiFinding
-	Started  bool
-	Executed bool
-	Err      error
+// I/O scheduling classes accepted by NucleiLimits.IOClass.
+const (
+	NucleiIOClassNone       = "none"
+	NucleiIOClassBestEffort = "best-effort"
+	NucleiIOClassIdle       = "idle"
+)
+
+// NucleiLimits bounds every Nuclei process, i.e. one endpoint batch. Zero
+// Timeout or MaxMemoryBytes disables that limit. Memory is enforced through a
+// cgroup v2 child group when the current group already enables the memory
+// controller for its children, or when DelegateCgroup allows yscan to enable
+// it by moving itself into a leaf group; otherwise the process RSS is
+// sampled. Nice and IOClass are applied right after start. Only Timeout is
+// enforced outside Linux.
+type NucleiLimits struct {
+	Timeout        time.Duration
+	MaxMemoryBytes int64
+	Nice           int
+	IOClass        string
+	DelegateCgroup bool
+}
+
+var configuredNucleiLimits struct {
+	sync.RWMutex
+	limits NucleiLimits
+}
+
+func ConfigureNucleiLimits(limits NucleiLimits) {
+	configuredNucleiLimits.Lock()
+	configuredNucleiLimits.limits = limits
+	configuredNucleiLimits.Unlock()
+}
+
+func currentNucleiLimits() NucleiLimits {
+	configuredNucleiLimits.RLock()
+	defer configuredNucleiLimits.RUnlock()
+	return configuredNucleiLimits.limits
+}
+
+// NucleiExecutionResult is the ValidationResult of one Nuclei invocation.
+type NucleiExecutionResult = ValidationResult
+
+// NucleiValidator runs Nuclei with the supplied reviewed template files as
+// its checks.
+type NucleiValidator struct{}
+
+func (NucleiValidator) Validate(ctx context.Context, ip string, ports []model.ScanResult, checks []string) ValidationResult {
+	return ExecuteNucleiForOpenPortsWithTemplatePaths(ctx, ip, ports, checks)
 }
 
 type nucleiJSONLine struct {
@@ -117,6 +163,19 @@ func ExecuteNucleiForOpenPortsWithTemplatePaths(ctx context.Context, ip string,
 	return executeNucleiForOpenPortsWithTemplatePaths(ctx, ip, openPorts, paths, nil)
 }
 
+// NucleiTemplatePathCommand returns the targets and the command line that
+// ExecuteNucleiForOpenPortsWithTemplatePaths would start, without starting
+// it. targetFile stands in for the temporary target list. An undetected
+// binary is shown as "nuclei" together with the detection error.
+func NucleiTemplatePathCommand(ip string, openPorts []model.ScanResult, templatePaths []string, targetFile string) ([]string, []string, error) {
+	nucleiPath, err := detectNucleiBinary()
+	if err != nil {
+		nucleiPath = "nuclei"
+	}
+	targets := buildTargets(ip, openPorts)
+	return targets, append([]string{nucleiPath}, buildNucleiArgs(targetFile, templatePaths, nil)...), err
+}
+
 func runNucleiForOpenPortsWithTemplatePaths(ctx context.Context, ip string, openPorts []model.ScanResult, templatePaths []string, tags []string) ([]model.NucleiFinding, error) {
 	result := executeNucleiForOpenPortsWithTemplatePaths(ctx, ip, openPorts, templatePaths, tags)
 	return result.Findings, result.Err
@@ -152,15 +211,20 @@ func executeNucleiForOpenPortsWithTemplatePaths(ctx context.Context, ip string,
 
 	args := buildNucleiArgs(tmp.Name(), templatePaths, tags)
 
-	cmd := newNucleiCommand(ctx, nucleiPath, args...)
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return NucleiExecutionResult{Err: err}
-	}
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return NucleiExecutionResult{Err: err}
-	}
+	limits := currentNucleiLimits()
+	runCtx := ctx
+	if limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, limits.Timeout)
+		defer cancel()
+	}
+	cmd := newNucleiCommand(runCtx, nucleiPath, args...)
+	guard := prepareNucleiLimits(cmd, limits)
+	defer guard.close()
+	stdout, stdoutWriter := io.Pipe()
+	stderrOutput := &boundedOutput{limit: maxNucleiStderrBytes}
+	cmd.Stdout, cmd.Stderr = stdoutWriter, stderrOutput
+	cmd.WaitDelay = nucleiWaitDelay
 
 	if err := cmd.Start(); err != nil {
 		if ctxErr := ctx.Err(); ctxErr != nil {
@@ -169,37 +233,43 @@ func executeNucleiForOpenPortsWithTemplatePaths(ctx context.Context, ip string,
 		return NucleiExecutionResult{Err: err}
 	}
 	result := NucleiExecutionResult{Started: true}
+	guard.started(cmd.Process)
 
 	stdoutDone := make(chan nucleiStdoutResult, 1)
 	go func() {
 		findings, err := parseNucleiJSONL(stdout, ip)
+		// Unblock the copy into stdoutWriter if parsing stopped early.
+		_ = stdout.Close()
 		stdoutDone <- nucleiStdoutResult{findings: findings, err: err}
 	}()
 
-	stderrOutput := &boundedOutput{limit: maxNucleiStderrBytes}
-	stderrDone := make(chan error, 1)
-	go func() {
-		_, err := io.Copy(stderrOutput, stderr)
-		stderrDone <- err
-	}()
-
-	stdoutResult := <-stdoutDone
-	stderrErr := <-stderrDone
 	waitErr := cmd.Wait()
+	_ = stdoutWriter.Close()
+	stdoutResult := <-stdoutDone
+	if errors.Is(waitErr, exec.ErrWaitDelay) {
+		// Nuclei itself exited cleanly; only an orphaned child held its output.
+		waitErr = nil
+	}
+	memoryExceeded := guard.finish()
 	result.Findings = stdoutResult.findings
 	if ctxErr := ctx.Err(); ctxErr != nil {
 		result.Executed = true
 		result.Err = ctxErr
 		return result
 	}
-	if stdoutResult.err != nil {
+	if memoryExceeded {
 		result.Executed = true
-		result.Err = stdoutResult.err
+		result.Err = fmt.Errorf("%w of %d MiB", ErrNucleiMemoryLimit, limits.MaxMemoryBytes>>20)
 		return result
 	}
-	if stderrErr != nil {
+	if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
 		result.Executed = true
-		result.Err = stderrErr
+		result.Err = fmt.Errorf("%w after %s", ErrNucleiTimeout, limits.Timeout)
+		return result
+	}
+	if stdoutResult.err != nil {
+		result.Executed = true
+		result.Err = stdoutResult.err
 		return result
 	}
 	if waitErr != nil {
@@ -0,0 +1,293 @@
+//go:build linux
+
+package vuln
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const (
+	cgroupRoot            = "/sys/fs/cgroup"
+	cgroupServiceLeaf     = "yscan"
+	rssSampleInterval     = 200 * time.Millisecond
+	ioprioWhoProcess      = 1
+	ioprioClassShift      = 13
+	ioprioClassBestEffort = 2
+	ioprioClassIdle       = 3
+	ioprioLowestLevel     = 7
+)
+
+var nucleiCgroupSequence atomic.Int64
+
+// nucleiCgroupParent is resolved once per process: enabling the memory
+// controller may move yscan itself into a leaf group.
+var nucleiCgroupParent struct {
+	once sync.Once
+	path string
+	err  error
+}
+
+// nucleiLimitGuard applies NucleiLimits to one started process and reports
+// whether it was stopped for exceeding MaxMemoryBytes.
+type nucleiLimitGuard struct {
+	limits   NucleiLimits
+	cgroup   string
+	cgroupFD *os.File
+	stop     chan struct{}
+	done     chan struct{}
+	exceeded atomic.Bool
+}
+
+// prepareNucleiLimits places cmd in a fresh memory-limited cgroup when the
+// memory controller is available to yscan's cgroup v2 children. Otherwise
+// memory is enforced by sampling RSS once the process has started.
+func prepareNucleiLimits(cmd *exec.Cmd, limits NucleiLimits) *nucleiLimitGuard {
+	guard := &nucleiLimitGuard{limits: limits}
+	if limits.MaxMemoryBytes <= 0 {
+		return guard
+	}
+	directory, file, err := createNucleiCgroup(limits.MaxMemoryBytes, limits.DelegateCgroup)
+	if err != nil {
+		return guard
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(file.Fd())
+	guard.cgroup, guard.cgroupFD = directory, file
+	return guard
+}
+
+func (guard *nucleiLimitGuard) started(process *os.Process) {
+	if process == nil {
+		return
+	}
+	if guard.limits.Nice > 0 {
+		_ = syscall.Setpriority(syscall.PRIO_PROCESS, process.Pid, guard.limits.Nice)
+	}
+	switch guard.limits.IOClass {
+	case NucleiIOClassBestEffort:
+		setIOPriority(process.Pid, ioprioClassBestEffort<<ioprioClassShift|ioprioLowestLevel)
+	case NucleiIOClassIdle:
+		setIOPriority(process.Pid, ioprioClassIdle<<ioprioClassShift)
+	}
+	if guard.limits.MaxMemoryBytes <= 0 || guard.cgroup != "" {
+		return
+	}
+	guard.stop, guard.done = make(chan struct{}), make(chan struct{})
+	go guard.watchRSS(process)
+}
+
+func (guard *nucleiLimitGuard) watchRSS(process *os.Process) {
+	defer close(guard.done)
+	ticker := time.NewTicker(rssSampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-guard.stop:
+			return
+		case <-ticker.C:
+		}
+		rss, err := processRSS(process.Pid)
+		if err != nil {
+			continue
+		}
+		if rss > guard.limits.MaxMemoryBytes {
+			guard.exceeded.Store(true)
+			_ = process.Kill()
+			return
+		}
+	}
+}
+
+// finish stops RSS sampling after Wait and reports whether the memory limit
+// ended the process.
+func (guard *nucleiLimitGuard) finish() bool {
+	guard.stopSampling()
+	if guard.cgroup != "" && cgroupOOMKills(guard.cgroup) > 0 {
+		guard.exceeded.Store(true)
+	}
+	return guard.exceeded.Load()
+}
+
+func (guard *nucleiLimitGuard) close() {
+	guard.stopSampling()
+	if guard.cgroupFD != nil {
+		_ = guard.cgroupFD.Close()
+		guard.cgroupFD = nil
+	}
+	if guard.cgroup != "" {
+		_ = os.Remove(guard.cgroup)
+		guard.cgroup = ""
+	}
+}
+
+func (guard *nucleiLimitGuard) stopSampling() {
+	if guard.stop != nil {
+		close(guard.stop)
+		<-guard.done
+		guard.stop = nil
+	}
+}
+
+func setIOPriority(pid, priority int) {
+	_, _, _ = syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(pid), uintptr(priority))
+}
+
+func createNucleiCgroup(maxMemoryBytes int64, delegate bool) (string, *os.File, error) {
+	parent, err := delegatedNucleiCgroupParent(delegate)
+	if err != nil {
+		return "", nil, err
+	}
+	directory := filepath.Join(parent, fmt.Sprintf("yscan-nuclei-%d-%d", os.Getpid(), nucleiCgroupSequence.Add(1)))
+	if err := os.Mkdir(directory, 0755); err != nil {
+		return "", nil, err
+	}
+	if err := os.WriteFile(filepath.Join(directory, "memory.max"), []byte(strconv.FormatInt(maxMemoryBytes, 10)), 0644); err != nil {
+		_ = os.Remove(directory)
+		return "", nil, err
+	}
+	_ = os.WriteFile(filepath.Join(directory, "memory.swap.max"), []byte("0"), 0644)
+	file, err := os.Open(directory)
+	if err != nil {
+		_ = os.Remove(directory)
+		return "", nil, err
+	}
+	return directory, file, nil
+}
+
+// delegatedNucleiCgroupParent returns the group that Nuclei groups are
+// created in, logging once when there is none and RSS sampling applies.
+func delegatedNucleiCgroupParent(delegate bool) (string, error) {
+	nucleiCgroupParent.once.Do(func() {
+		current, err := currentCgroup()
+		if err == nil {
+			nucleiCgroupParent.path, err = enableNucleiCgroupParent(cgroupRoot, current, delegate)
+		}
+		if err != nil {
+			nucleiCgroupParent.err = err
+			log.Printf("nuclei memory limit falls back to RSS sampling: %v", err)
+		}
+	})
+	return nucleiCgroupParent.path, nucleiCgroupParent.err
+}
+
+// enableNucleiCgroupParent returns the group below root at current whose
+// children may use the memory controller. A group that already enables it
+// for its children is used as is. Otherwise, and only with delegate set, the
+// controller is enabled there: cgroup v2 refuses controllers on a non-root
+// group that holds processes, so yscan first moves itself into a leaf child.
+// This needs a delegated subtree, e.g. a systemd unit with Delegate=yes. The
+// root group is never modified.
+func enableNucleiCgroupParent(root, current string, delegate bool) (string, error) {
+	if current == "/" {
+		return "", fmt.Errorf("yscan runs in the root cgroup")
+	}
+	parent := filepath.Join(root, current)
+	subtree, err := os.ReadFile(filepath.Join(parent, "cgroup.subtree_control"))
+	if err != nil {
+		return "", err
+	}
+	if containsField(string(subtree), "memory") {
+		return parent, nil
+	}
+	if !delegate {
+		return "", fmt.Errorf("memory controller is not enabled for the children of %s and cgroup delegation is off", parent)
+	}
+	available, err := os.ReadFile(filepath.Join(parent, "cgroup.controllers"))
+	if err != nil {
+		return "", err
+	}
+	if !containsField(string(available), "memory") {
+		return "", fmt.Errorf("memory controller is not delegated to %s", parent)
+	}
+	leaf := filepath.Join(parent, cgroupServiceLeaf)
+	if err := os.Mkdir(leaf, 0755); err != nil && !os.IsExist(err) {
+		return "", fmt.Errorf("create leaf cgroup %s: %w", leaf, err)
+	}
+	if err := os.WriteFile(filepath.Join(leaf, "cgroup.procs"), []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		return "", fmt.Errorf("move yscan into leaf cgroup %s: %w", leaf, err)
+	}
+	if err := os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte("+memory"), 0644); err != nil {
+		return "", fmt.Errorf("enable memory controller in %s: %w", parent, err)
+	}
+	return parent, nil
+}
+
+// currentCgroup returns the cgroup v2 path of this process relative to the
+// unified hierarchy root.
+func currentCgroup() (string, error) {
+	content, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			return strings.TrimSpace(path), nil
+		}
+	}
+	return "", fmt.Errorf("cgroup v2 is not mounted")
+}
+
+func cgroupOOMKills(directory string) int64 {
+	file, err := os.Open(filepath.Join(directory, "memory.events"))
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "oom_kill" {
+			count, _ := strconv.ParseInt(fields[1], 10, 64)
+			return count
+		}
+	}
+	return 0
+}
+
+func processRSS(pid int) (int64, error) {
+	file, err := os.Open(filepath.Join("/proc", strconv.Itoa(pid), "status"))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		value, ok := strings.CutPrefix(scanner.Text(), "VmRSS:")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			break
+		}
+		kilobytes, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return kilobytes << 10, nil
+	}
+	return 0, fmt.Errorf("VmRSS missing for process %d", pid)
+}
+
+func containsField(value, field string) bool {
+	for _, current := range strings.Fields(value) {
+		if current == field {
+			return true
+		}
+	}
+	return false
+}
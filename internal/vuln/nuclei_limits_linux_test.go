@@ -0,0 +1,72 @@
+//go:build linux
+
+package vuln
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeCgroupFixture(t *testing.T, directory, controllers, subtree string) {
+	t.Helper()
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"cgroup.controllers": controllers, "cgroup.subtree_control": subtree} {
+		if err := os.WriteFile(filepath.Join(directory, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestNucleiCgroupParentNeverModifiesTheRootGroup(t *testing.T) {
+	root := t.TempDir()
+	writeCgroupFixture(t, root, "cpu memory pids\n", "cpu memory\n")
+	if parent, err := enableNucleiCgroupParent(root, "/", true); err == nil {
+		t.Fatalf("root group was used as Nuclei parent %s", parent)
+	}
+	if _, err := os.Stat(filepath.Join(root, cgroupServiceLeaf)); !os.IsNotExist(err) {
+		t.Fatalf("leaf group was created in the root group: %v", err)
+	}
+}
+
+func TestNucleiCgroupParentMovesIntoALeafOnlyWhenDelegated(t *testing.T) {
+	root := t.TempDir()
+	service := filepath.Join(root, "system.slice", "yscan.service")
+	writeCgroupFixture(t, service, "cpu memory pids\n", "")
+
+	if _, err := enableNucleiCgroupParent(root, "/system.slice/yscan.service", false); err == nil || !strings.Contains(err.Error(), "delegation is off") {
+		t.Fatalf("undelegated parent err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(service, cgroupServiceLeaf)); !os.IsNotExist(err) {
+		t.Fatalf("leaf group was created without delegation: %v", err)
+	}
+
+	parent, err := enableNucleiCgroupParent(root, "/system.slice/yscan.service", true)
+	if err != nil || parent != service {
+		t.Fatalf("delegated parent = %s err=%v", parent, err)
+	}
+	procs, err := os.ReadFile(filepath.Join(service, cgroupServiceLeaf, "cgroup.procs"))
+	if err != nil || strings.TrimSpace(string(procs)) == "" {
+		t.Fatalf("leaf cgroup.procs = %q err=%v", procs, err)
+	}
+	subtree, err := os.ReadFile(filepath.Join(service, "cgroup.subtree_control"))
+	if err != nil || string(subtree) != "+memory" {
+		t.Fatalf("subtree_control = %q err=%v", subtree, err)
+	}
+}
+
+func TestNucleiCgroupParentUsesAnAlreadyEnabledGroupWithoutDelegation(t *testing.T) {
+	root := t.TempDir()
+	service := filepath.Join(root, "yscan.slice")
+	writeCgroupFixture(t, service, "memory\n", "memory\n")
+	parent, err := enableNucleiCgroupParent(root, "/yscan.slice", false)
+	if err != nil || parent != service {
+		t.Fatalf("parent = %s err=%v", parent, err)
+	}
+	if _, err := os.Stat(filepath.Join(service, cgroupServiceLeaf)); !os.IsNotExist(err) {
+		t.Fatalf("leaf group was created for an enabled parent: %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+
+package vuln
+
+import (
+	"os"
+	"os/exec"
+)
+
+// nucleiLimitGuard is a no-op outside Linux: only NucleiLimits.Timeout, which
+// is enforced through the command context, applies there.
+type nucleiLimitGuard struct{}
+
+func prepareNucleiLimits(*exec.Cmd, NucleiLimits) *nucleiLimitGuard {
+	return &nucleiLimitGuard{}
+}
+
+func (*nucleiLimitGuard) started(*os.Process) {}
+
+func (*nucleiLimitGuard) finish() bool { return false }
+
+func (*nucleiLimitGuard) close() {}
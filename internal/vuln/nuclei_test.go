@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -219,6 +221,34 @@ func TestRunNucleiPreservesParsedFindingWhenProcessExitsNonZero(t *testing.T) {
 	}
 }
 
+func TestRunNucleiReturnsWhenAnOrphanedChildHoldsOutput(t *testing.T) {
+	originalDetect := detectNucleiBinary
+	originalCommand := newNucleiCommand
+	originalDelay := nucleiWaitDelay
+	t.Cleanup(func() {
+		detectNucleiBinary = originalDetect
+		newNucleiCommand = originalCommand
+		nucleiWaitDelay = originalDelay
+	})
+	template := t.TempDir() + "/reviewed.yaml"
+	if err := os.WriteFile(template, []byte("id: reviewed\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	detectNucleiBinary = func() (string, error) { return "test-nuclei", nil }
+	nucleiWaitDelay = 100 * time.Millisecond
+	newNucleiCommand = func(ctx context.Context, _ string, _ ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sh", "-c", `echo '{"template-id":"orphan","host":"http://127.0.0.1:8080","info":{"name":"Orphan","severity":"low"}}'; sleep 30 &`)
+	}
+	started := time.Now()
+	execution := ExecuteNucleiForOpenPortsWithTemplatePaths(context.Background(), "127.0.0.1", []model.ScanResult{{Address: "127.0.0.1:8080", Open: true, Service: "http"}}, []string{template})
+	if elapsed := time.Since(started); elapsed > 10*time.Second {
+		t.Fatalf("execution waited %s for the orphaned child", elapsed)
+	}
+	if execution.Err != nil || !execution.Executed || len(execution.Findings) != 1 || execution.Findings[0].TemplateID != "orphan" {
+		t.Fatalf("execution=%#v", execution)
+	}
+}
+
 func TestNucleiFindingThenFailureHelper(t *testing.T) {
 	if os.Getenv("YSCAN_NUCLEI_FINDING_FAILURE_HELPER") != "1" {
 		return
@@ -295,3 +325,64 @@ func TestRunNucleiStderrHelper(t *testing.T) {
 	_, _ = os.Stderr.Write(bytes.Repeat([]byte("e"), maxNucleiStderrBytes*8))
 	os.Exit(1)
 }
+
+func TestRunNucleiStopsBatchAtConfiguredLimits(t *testing.T) {
+	originalDetect := detectNucleiBinary
+	originalResolve := resolveNucleiTemplates
+	originalCommand := newNucleiCommand
+	originalLimits := currentNucleiLimits()
+	t.Cleanup(func() {
+		detectNucleiBinary = originalDetect
+		resolveNucleiTemplates = originalResolve
+		newNucleiCommand = originalCommand
+		ConfigureNucleiLimits(originalLimits)
+	})
+	detectNucleiBinary = func() (string, error) { return "test-nuclei", nil }
+	resolveNucleiTemplates = func(string) (string, error) { return t.TempDir(), nil }
+
+	tests := []struct {
+		name   string
+		helper string
+		limits NucleiLimits
+		want   error
+	}{
+		{"timeout", "YSCAN_NUCLEI_HELPER=1", NucleiLimits{Timeout: 200 * time.Millisecond, Nice: 5, IOClass: NucleiIOClassIdle}, ErrNucleiTimeout},
+		{"memory", "YSCAN_NUCLEI_MEMORY_HELPER=1", NucleiLimits{Timeout: 10 * time.Second, MaxMemoryBytes: 64 << 20}, ErrNucleiMemoryLimit},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.name == "memory" && runtime.GOOS != "linux" {
+				t.Skip("memory limits are enforced on Linux only")
+			}
+			newNucleiCommand = func(ctx context.Context, _ string, _ ...string) *exec.Cmd {
+				cmd := exec.CommandContext(ctx, os.Args[0], "-test.run=TestRunNuclei(Cancellation|Memory)Helper", "--")
+				cmd.Env = append(os.Environ(), test.helper, "YSCAN_NUCLEI_MARKER="+filepath.Join(t.TempDir(), "started"))
+				return cmd
+			}
+			ConfigureNucleiLimits(test.limits)
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			result := ExecuteNucleiForOpenPortsWithTags(ctx, "127.0.0.1", []model.ScanResult{{Address: "127.0.0.1:80", Open: true}}, "templates", nil)
+			if !errors.Is(result.Err, test.want) || !result.Started || !result.Executed {
+				t.Fatalf("result=%#v, want %v", result, test.want)
+			}
+			if ctx.Err() != nil || errors.Is(result.Err, context.DeadlineExceeded) {
+				t.Fatalf("batch limit was reported as parent cancellation: %v", result.Err)
+			}
+		})
+	}
+}
+
+func TestRunNucleiMemoryHelper(t *testing.T) {
+	if os.Getenv("YSCAN_NUCLEI_MEMORY_HELPER") != "1" {
+		return
+	}
+	var blocks [][]byte
+	for range 64 {
+		block := bytes.Repeat([]byte{1}, 8<<20)
+		blocks = append(blocks, block)
+		time.Sleep(20 * time.Millisecond)
+	}
+	_, _ = fmt.Fprintln(os.Stderr, len(blocks))
+	os.Exit(0)
+}
@@ -0,0 +1,31 @@
+package vuln
+
+import (
+	"context"
+
+	"golandproject/yscan/internal/model"
+)
+
+// ValidationResult separates process startup and actual check execution from
+// findings and errors. Callers must not infer coverage from an empty finding
+// list or a final error.
+type ValidationResult struct {
+	Findings []model.NucleiFinding
+	Started  bool
+	Executed bool
+	Err      error
+}
+
+// Validator runs a set of checks against the open ports of one host. What a
+// check names depends on the implementation: NucleiValidator takes reviewed
+// template files and NativeValidator takes built-in check IDs.
+type Validator interface {
+	Validate(ctx context.Context, ip string, ports []model.ScanResult, checks []string) ValidationResult
+}
+
+// ValidatorFunc adapts a plain function to Validator.
+type ValidatorFunc func(context.Context, string, []model.ScanResult, []string) ValidationResult
+
+func (validate ValidatorFunc) Validate(ctx context.Context, ip string, ports []model.ScanResult, checks []string) ValidationResult {
+	return validate(ctx, ip, ports, checks)
+}
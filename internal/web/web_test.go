@@ -277,7 +277,7 @@ func TestAssetPageUsesFixedSearchableNavigation(t *testing.T) {
 		t.Fatal("asset navigation does not use the fixed desktop track")
 	}
 	section := pageSection(t, page, "async function renderAssets()", "let fingerprintRulePage")
-	for _, expected := range []string{"asset-nav", "id=\"asset-search\"", "asset-list", "data-asset-search", "最后发现"} {
+	for _, expected := range []string{"asset-nav", "id=\"asset-search\"", "asset-list", "data-asset-search", "最后发现", "id=\"asset-sort\"", "sort=${assetSort}", "asset.risk_score"} {
 		if !strings.Contains(section, expected) {
 			t.Fatalf("asset navigation missing %q", expected)
 		}
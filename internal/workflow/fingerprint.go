@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"net"
 	"sort"
 	"strconv"
@@ -25,7 +26,11 @@ func collectRunFingerprintMatches(ctx context.Context, db *sql.DB, run model.Sca
 	if err != nil {
 		return nil, nil, err
 	}
-	return collectRunFingerprintMatchesWithEngine(ctx, engine, ip, results)
+	vault, err := runEvidenceVault(run)
+	if err != nil {
+		return nil, nil, err
+	}
+	return collectRunFingerprintMatchesWithEngine(ctx, engine, vault, run.Config.TLSFingerprint, ip, results)
 }
 
 func newRunFingerprintCollector(db *sql.DB, run model.ScanTaskRun) (func(context.Context, *sql.DB, model.ScanTaskRun, string, []model.ScanResult) ([]model.ScanResult, []model.FingerprintRunMatch, error), error) {
@@ -35,20 +40,38 @@ func newRunFingerprintCollector(db *sql.DB, run model.ScanTaskRun) (func(context
 func newRunFingerprintCollectorWithLoader(db *sql.DB, run model.ScanTaskRun, loader func(*sql.DB, int64) (*fingerprint.Engine, error)) func(context.Context, *sql.DB, model.ScanTaskRun, string, []model.ScanResult) ([]model.ScanResult, []model.FingerprintRunMatch, error) {
 	var once sync.Once
 	var engine *fingerprint.Engine
+	var vault *fingerprint.EvidenceVault
 	var loadErr error
 	return func(ctx context.Context, _ *sql.DB, _ model.ScanTaskRun, ip string, results []model.ScanResult) ([]model.ScanResult, []model.FingerprintRunMatch, error) {
 		if len(results) == 0 {
 			return results, nil, nil
 		}
-		once.Do(func() { engine, loadErr = loader(db, run.ID) })
+		once.Do(func() {
+			if engine, loadErr = loader(db, run.ID); loadErr == nil {
+				vault, loadErr = runEvidenceVault(run)
+			}
+		})
 		if loadErr != nil {
 			return results, nil, loadErr
 		}
-		return collectRunFingerprintMatchesWithEngine(ctx, engine, ip, results)
+		return collectRunFingerprintMatchesWithEngine(ctx, engine, vault, run.Config.TLSFingerprint, ip, results)
 	}
 }
 
-func collectRunFingerprintMatchesWithEngine(ctx context.Context, engine *fingerprint.Engine, ip string, results []model.ScanResult) ([]model.ScanResult, []model.FingerprintRunMatch, error) {
+// runEvidenceVault opens the home key only for tasks that opted into raw
+// evidence retention; a missing key is created on first use.
+func runEvidenceVault(run model.ScanTaskRun) (*fingerprint.EvidenceVault, error) {
+	if !run.Config.RetainRawEvidence {
+		return nil, nil
+	}
+	vault, err := fingerprint.OpenEvidenceVault(true)
+	if err != nil {
+		return nil, fmt.Errorf("open raw evidence vault: %w", err)
+	}
+	return vault, nil
+}
+
+func collectRunFingerprintMatchesWithEngine(ctx context.Context, engine *fingerprint.Engine, vault *fingerprint.EvidenceVault, tlsFingerprint bool, ip string, results []model.ScanResult) ([]model.ScanResult, []model.FingerprintRunMatch, error) {
 	allowedPorts := make(map[int]struct{}, len(results))
 	for _, result := range results {
 		if port, ok := scanResultPort(ip, result); ok {
@@ -70,58 +93,49 @@ func collectRunFingerprintMatchesWithEngine(ctx context.Context, engine *fingerp
 		tcpEvidence := fingerprint.NewBannerEvidence(results[index].Banner, results[index].BannerTruncated)
 		results[index].ProtocolEvidence = append(results[index].ProtocolEvidence, protocolEvidenceFromBanner(tcpEvidence))
 		tcpSummary := bannerEvidenceSummary(tcpEvidence)
-		matchSets := []endpointEvidenceMatches{{protocol: "tcp", summary: tcpSummary, matches: engine.Match(tcpEvidence)}}
-		collected, collectErr := fingerprint.CollectWebEvidence(ctx, ip, port, webEvidenceService(results[index].Service), fingerprint.WebEvidenceOptions{AllowedPorts: allowedPorts})
+		matchSets := []endpointEvidenceMatches{{protocol: "tcp", summary: tcpSummary, matches: engine.Match(tcpEvidence), raw: fingerprint.NewRawEvidence(tcpEvidence)}}
+		webOptions := fingerprint.WebEvidenceOptions{AllowedPorts: allowedPorts}
+		collected, collectErr := fingerprint.CollectWebEvidence(ctx, ip, port, webEvidenceService(results[index].Service), webOptions)
 		if collectErr == nil {
 			webEvidence := collected.Evidence
 			webEvidence.Protocol = collected.Protocol
-			matchSets = append(matchSets, endpointEvidenceMatches{protocol: collected.Protocol, summary: collected.Summary, matches: engine.Match(webEvidence)})
+			matchSets = append(matchSets, endpointEvidenceMatches{protocol: collected.Protocol, summary: collected.Summary, matches: engine.Match(webEvidence), raw: fingerprint.NewRawEvidence(webEvidence)})
 			results[index].Service = collectedWebService(results[index].Service, collected.Protocol)
 			results[index].ProtocolEvidence = append(results[index].ProtocolEvidence, protocolEvidenceFromWeb(collected))
+			// Pages behind the root response often carry the only product
+			// markers (login forms, API banners, bundled libraries).
+			crawled, _ := fingerprint.CrawlWebApplication(ctx, ip, port, collected, webOptions, fingerprint.CrawlOptions{})
+			for _, page := range crawled.Pages {
+				pageEvidence := page.Evidence
+				pageEvidence.Protocol = collected.Protocol
+				matchSets = append(matchSets, endpointEvidenceMatches{protocol: collected.Protocol, summary: page.Summary, matches: engine.Match(pageEvidence), raw: fingerprint.NewRawEvidence(pageEvidence)})
+				results[index].ProtocolEvidence = append(results[index].ProtocolEvidence, protocolEvidenceFromWebPage(collected.Protocol, page))
+			}
+			results[index].WebAssets = append(results[index].WebAssets, webAssetsFromCrawl(collected.Protocol, crawled.Assets)...)
+			results[index].Weaknesses = append(results[index].Weaknesses, weaknessesFromPosture(collected.Protocol, fingerprint.AnalyzeEndpointPosture(collected, crawled.Pages))...)
 		} else if !errors.Is(collectErr, fingerprint.ErrNotWebService) {
 			// A failed HTTP request never discards an already collected banner.
 			matchSets[0].summary += " web_evidence_unavailable"
 		}
+		// Each TLS fingerprint costs ten extra connections, so only tasks
+		// that opted in send the ClientHello variants.
+		if tlsFingerprint && tlsEndpointCandidate(port, results[index].Service, collected.Protocol) {
+			tlsSets, tlsEvidence, tlsFingerprints := collectTLSFingerprintMatches(ctx, engine, ip, port)
+			matchSets = append(matchSets, tlsSets...)
+			results[index].ProtocolEvidence = append(results[index].ProtocolEvidence, tlsEvidence...)
+			results[index].TLSFingerprints = append(results[index].TLSFingerprints, tlsFingerprints...)
+		}
 		probeSets, probeEvidence, probeErr := collectNmapProbeMatches(ctx, engine, ip, port, results[index].Service)
 		matchSets = append(matchSets, probeSets...)
 		results[index].ProtocolEvidence = append(results[index].ProtocolEvidence, probeEvidence...)
-		hardProducts := make(map[string]hardProductCandidate)
-		for _, set := range matchSets {
-			for _, match := range set.matches {
-				persisted = append(persisted, model.FingerprintRunMatch{
-					FingerprintImportID:     match.FingerprintImportID,
-					FingerprintSourceRuleID: match.FingerprintSourceRuleID,
-					SourceKey:               match.SourceKey,
-					SourceRuleID:            match.SourceRuleID,
-					IP:                      ip,
-					Port:                    port,
-					Protocol:                set.protocol,
-					Product:                 match.Product,
-					SourceProduct:           match.SourceProduct,
-					ProductRole:             match.ProductRole,
-					ExclusiveGroup:          match.ExclusiveGroup,
-					Version:                 match.Version,
-					CPE:                     match.CPE,
-					Tags:                    match.Tags,
-					Soft:                    match.Soft,
-					EvidenceSummary:         set.summary,
-					Evidence:                append([]model.FingerprintMatchEvidence(nil), match.MatcherHits...),
-				})
-				if !match.Soft {
-					product := strings.ToLower(strings.TrimSpace(match.Product))
-					if product != "" {
-						candidate := hardProducts[product]
-						candidate.role = match.ProductRole
-						candidate.exclusiveGroup = match.ExclusiveGroup
-						if candidate.source == "" {
-							candidate.source = match.SourceKey
-						} else if candidate.source != match.SourceKey {
-							candidate.source = "multiple"
-						}
-						hardProducts[product] = candidate
-					}
-				}
+		endpointMatches, hardProducts := endpointRunMatches(ip, port, matchSets)
+		persisted = append(persisted, endpointMatches...)
+		if vault != nil {
+			retained, err := sealEndpointEvidence(vault, ip, port, matchSets)
+			if err != nil {
+				return results, persisted, err
 			}
+			results[index].RawEvidence = append(results[index].RawEvidence, retained...)
 		}
 		results[index].Product, results[index].FingerprintSource = resolvedHardProduct(hardProducts)
 		if endpointServiceUnknown(results[index].Service) {
@@ -139,6 +153,54 @@ func collectRunFingerprintMatchesWithEngine(ctx context.Context, engine *fingerp
 	return results, persisted, nil
 }
 
+// endpointRunMatches converts engine matches for one endpoint into persisted
+// run matches and the hard product candidates used for its conclusion.
+func endpointRunMatches(ip string, port int, matchSets []endpointEvidenceMatches) ([]model.FingerprintRunMatch, map[string]hardProductCandidate) {
+	persisted := make([]model.FingerprintRunMatch, 0)
+	hardProducts := make(map[string]hardProductCandidate)
+	for _, set := range matchSets {
+		for _, match := range set.matches {
+			persisted = append(persisted, match.RunMatch(ip, port, set.protocol, set.summary))
+			if !match.Soft {
+				product := strings.ToLower(strings.TrimSpace(match.Product))
+				if product != "" {
+					candidate := hardProducts[product]
+					candidate.role = match.ProductRole
+					candidate.exclusiveGroup = match.ExclusiveGroup
+					if candidate.source == "" {
+						candidate.source = match.SourceKey
+					} else if candidate.source != match.SourceKey {
+						candidate.source = "multiple"
+					}
+					hardProducts[product] = candidate
+				}
+			}
+		}
+	}
+	return persisted, hardProducts
+}
+
+// sealEndpointEvidence keeps the inputs behind one endpoint's matches so a
+// later rule revision can replay them. Sets without evidence are skipped and
+// the count is bounded per endpoint.
+func sealEndpointEvidence(vault *fingerprint.EvidenceVault, ip string, port int, matchSets []endpointEvidenceMatches) ([]model.ScanTaskRunRawEvidence, error) {
+	retained := make([]model.ScanTaskRunRawEvidence, 0, minInt(len(matchSets), fingerprint.MaxRawEvidencePerEndpoint))
+	for _, set := range matchSets {
+		if set.raw.Kind == "" {
+			continue
+		}
+		if len(retained) == fingerprint.MaxRawEvidencePerEndpoint {
+			break
+		}
+		record, err := vault.Seal(ip, port, len(retained)+1, set.summary, set.raw)
+		if err != nil {
+			return retained, fmt.Errorf("seal raw evidence %s:%d: %w", ip, port, err)
+		}
+		retained = append(retained, record)
+	}
+	return retained, nil
+}
+
 func endpointServiceUnknown(service string) bool {
 	switch strings.ToLower(strings.TrimSpace(service)) {
 	case "", "unknown", "none_unknown", "tcp", "tcp-unknown":
@@ -181,6 +243,7 @@ func collectNmapProbeMatches(ctx context.Context, engine *fingerprint.Engine, ip
 				protocol: "tcp",
 				summary:  "tcp probe=" + probe.Name + " " + bannerEvidenceSummary(evidence),
 				matches:  engine.MatchNmapTCPProbeResponse(probe.Name, response),
+				raw:      fingerprint.NewRawProbeEvidence(probe.Name, response),
 			}, evidence: protocolEvidenceFromProbe(probe.Name, evidence)}
 		}(index, probe)
 	}
@@ -204,6 +267,56 @@ func collectNmapProbeMatches(ctx context.Context, engine *fingerprint.Engine, ip
 	return sets, evidence, nil
 }
 
+const (
+	tlsFingerprintProbeName      = "hello-variants"
+	tlsFingerprintEndpointBudget = 5 * time.Second
+)
+
+var tlsServicePorts = map[int]struct{}{443: {}, 465: {}, 636: {}, 853: {}, 990: {}, 992: {}, 993: {}, 994: {}, 995: {}, 5061: {}, 8443: {}, 9443: {}}
+
+// tlsEndpointCandidate limits ClientHello probing to endpoints that already
+// spoke HTTPS or whose service or port conventionally carries TLS.
+func tlsEndpointCandidate(port int, service, webProtocol string) bool {
+	if webProtocol == "https" {
+		return true
+	}
+	service = strings.ToLower(strings.TrimSpace(service))
+	if strings.Contains(service, "ssl") || strings.Contains(service, "tls") || strings.Contains(service, "https") {
+		return true
+	}
+	_, ok := tlsServicePorts[port]
+	return ok
+}
+
+func collectTLSFingerprintMatches(ctx context.Context, engine *fingerprint.Engine, ip string, port int) ([]endpointEvidenceMatches, []model.ScanTaskRunProtocolEvidence, []model.ScanTaskRunTLSFingerprint) {
+	observed, err := fingerprint.CollectTLSFingerprint(ctx, ip, port, fingerprint.TLSFingerprintOptions{Budget: tlsFingerprintEndpointBudget})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, nil, nil
+		}
+		return nil, []model.ScanTaskRunProtocolEvidence{{
+			EvidenceType: model.ProtocolEvidenceTLSFingerprint, ProbeName: tlsFingerprintProbeName, Protocol: "tls",
+			Outcome: model.ProtocolProbeOutcomeNoResponse,
+		}}, nil
+	}
+	evidence := fingerprint.Evidence{Protocol: "tls", TLSFingerprint: observed.Fingerprint, JA4S: observed.JA4S}
+	set := endpointEvidenceMatches{
+		protocol: "tls",
+		summary:  fmt.Sprintf("tls fingerprint=%s ja4s=%s responses=%d/%d", observed.Fingerprint, observed.JA4S, observed.Responses, observed.Variants),
+		matches:  engine.Match(evidence),
+		raw:      fingerprint.NewRawEvidence(evidence),
+	}
+	observation := model.ScanTaskRunProtocolEvidence{
+		EvidenceType: model.ProtocolEvidenceTLSFingerprint, ProbeName: tlsFingerprintProbeName, Protocol: "tls",
+		Responded: true, Outcome: model.ProtocolProbeOutcomeResponded,
+		BannerCapturedLength: observed.CapturedLength, BannerSHA256: observed.CapturedSHA256,
+	}
+	fingerprints := []model.ScanTaskRunTLSFingerprint{{
+		Fingerprint: observed.Fingerprint, JA4S: observed.JA4S, Responses: observed.Responses, Variants: observed.Variants,
+	}}
+	return []endpointEvidenceMatches{set}, []model.ScanTaskRunProtocolEvidence{observation}, fingerprints
+}
+
 type nmapProbeResult struct {
 	index    int
 	set      endpointEvidenceMatches
@@ -364,6 +477,44 @@ func protocolEvidenceFromWeb(collected fingerprint.CollectedEvidence) model.Scan
 	}
 }
 
+func protocolEvidenceFromWebPage(protocol string, page fingerprint.CrawledPage) model.ScanTaskRunProtocolEvidence {
+	observation := protocolEvidenceFromWeb(fingerprint.CollectedEvidence{Evidence: page.Evidence, Protocol: protocol})
+	observation.EvidenceType = model.ProtocolEvidenceWebPage
+	observation.ProbeName = page.Path
+	return observation
+}
+
+func webAssetsFromCrawl(protocol string, assets []fingerprint.WebAsset) []model.ScanTaskRunWebAsset {
+	converted := make([]model.ScanTaskRunWebAsset, 0, len(assets))
+	for _, asset := range assets {
+		converted = append(converted, model.ScanTaskRunWebAsset{
+			Protocol:       protocol,
+			Kind:           asset.Kind,
+			Path:           asset.Path,
+			CapturedLength: asset.CapturedLength,
+			SHA256:         asset.SHA256,
+			Truncated:      asset.Truncated,
+			Versions:       append([]string(nil), asset.Versions...),
+		})
+	}
+	return converted
+}
+
+func weaknessesFromPosture(protocol string, weaknesses []fingerprint.Weakness) []model.ScanTaskRunWeakness {
+	converted := make([]model.ScanTaskRunWeakness, 0, len(weaknesses))
+	for _, weakness := range weaknesses {
+		converted = append(converted, model.ScanTaskRunWeakness{
+			Protocol: protocol,
+			Check:    weakness.Check,
+			Subject:  weakness.Subject,
+			Severity: weakness.Severity,
+			Path:     weakness.Path,
+			Detail:   safeProtocolLabel(weakness.Detail, 256),
+		})
+	}
+	return converted
+}
+
 func headerValue(headers map[string]string, name string) string {
 	for key, value := range headers {
 		if strings.EqualFold(key, name) {
@@ -421,6 +572,8 @@ type endpointEvidenceMatches struct {
 	protocol string
 	summary  string
 	matches  []fingerprint.Match
+	// raw is the engine input behind matches, kept only for retention.
+	raw fingerprint.RawEvidence
 }
 
 func bannerEvidenceSummary(evidence fingerprint.Evidence) string {
@@ -0,0 +1,124 @@
+package workflow
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"strconv"
+	"strings"
+
+	"golandproject/yscan/internal/fingerprint"
+	"golandproject/yscan/internal/hostid"
+	"golandproject/yscan/internal/model"
+	"golandproject/yscan/internal/snmp"
+)
+
+// collectHostIdentities asks the open SMB, RDP, HTTP and WinRM endpoints of
+// ip for an anonymous NTLM challenge. Failures are expected on non-Windows
+// hosts and never fail the run.
+func collectHostIdentities(ctx context.Context, ip string, results []model.ScanResult) []model.ScanTaskRunHostIdentity {
+	endpoints := make([]hostid.Endpoint, 0)
+	for _, port := range uniqueSnapshotPorts(snapshotPorts(ip, results)) {
+		endpoints = append(endpoints, hostid.Endpoint{Port: port.Port, Service: port.ServiceType})
+	}
+	return hostIdentitiesFromObservations(ip, hostid.Collect(ctx, ip, endpoints))
+}
+
+func hostIdentitiesFromObservations(ip string, observations []hostid.Observation) []model.ScanTaskRunHostIdentity {
+	identities := make([]model.ScanTaskRunHostIdentity, 0, len(observations))
+	for _, observation := range observations {
+		identities = append(identities, model.ScanTaskRunHostIdentity{
+			IP:              ip,
+			Port:            observation.Port,
+			Source:          observation.Source,
+			ComputerName:    observation.Identity.NetBIOSComputerName,
+			DomainName:      observation.Identity.NetBIOSDomainName,
+			DNSComputerName: observation.Identity.DNSComputerName,
+			DNSDomainName:   observation.Identity.DNSDomainName,
+			DNSTreeName:     observation.Identity.DNSTreeName,
+			OSVersion:       observation.Identity.OSVersion,
+		})
+	}
+	return identities
+}
+
+// collectSSHHostKeys records the host keys every open SSH endpoint of ip
+// presents. Endpoints that fail the key exchange are skipped.
+func collectSSHHostKeys(ctx context.Context, ip string, results []model.ScanResult) []model.ScanTaskRunSSHHostKey {
+	keys := make([]model.ScanTaskRunSSHHostKey, 0)
+	for _, port := range uniqueSnapshotPorts(snapshotPorts(ip, results)) {
+		if !hostid.IsSSHEndpoint(hostid.Endpoint{Port: port.Port, Service: port.ServiceType}) || ctx.Err() != nil {
+			continue
+		}
+		collected, err := hostid.CollectSSHHostKeys(ctx, ip, port.Port)
+		if err != nil {
+			continue
+		}
+		for _, key := range collected {
+			keys = append(keys, model.ScanTaskRunSSHHostKey{IP: ip, Port: port.Port, Algorithm: key.Algorithm, Fingerprint: key.Fingerprint, Bits: key.Bits})
+		}
+	}
+	return keys
+}
+
+// collectSNMPSystem reads the system group and ifTable of ip with the named
+// credential. A credential that cannot be resolved fails the run; a device
+// that does not answer simply has no inventory.
+func collectSNMPSystem(ctx context.Context, credentialName, ip string) (*model.ScanTaskRunSNMPSystem, error) {
+	credential, err := snmp.LookupCredential(credentialName)
+	if err != nil {
+		return nil, err
+	}
+	system, err := snmp.CollectSystem(ctx, net.JoinHostPort(ip, strconv.Itoa(snmp.Port)), credential)
+	if err != nil {
+		return nil, ctx.Err()
+	}
+	return snmpSystemFromInventory(ip, credential, system), nil
+}
+
+// matchSNMPSystemFingerprints runs the sysDescr of an SNMP inventory through
+// this run's frozen engine, so rules written against it (such as Recog's
+// snmp.sys_description fingerprints) conclude on the SNMP endpoint.
+func matchSNMPSystemFingerprints(db *sql.DB, run model.ScanTaskRun, system model.ScanTaskRunSNMPSystem) ([]model.FingerprintRunMatch, error) {
+	if strings.TrimSpace(system.SysDescr) == "" {
+		return nil, nil
+	}
+	engine, err := fingerprint.LoadRunEngineCached(db, run.ID)
+	if err != nil {
+		return nil, err
+	}
+	summary := "snmp sysdescr_bytes=" + strconv.Itoa(len(system.SysDescr))
+	matches, _ := endpointRunMatches(system.IP, system.Port, []endpointEvidenceMatches{{
+		protocol: "snmp", summary: summary, matches: engine.Match(fingerprint.NewSNMPSysDescrEvidence(system.SysDescr)),
+	}})
+	return matches, nil
+}
+
+func snmpSystemFromInventory(ip string, credential snmp.Credential, system snmp.System) *model.ScanTaskRunSNMPSystem {
+	product, _ := snmp.IdentifyProduct(system)
+	result := &model.ScanTaskRunSNMPSystem{
+		IP:          ip,
+		Port:        snmp.Port,
+		Credential:  credential.Name,
+		SysDescr:    system.Description,
+		SysObjectID: system.ObjectID,
+		SysName:     system.Name,
+		SysLocation: system.Location,
+		Vendor:      product.Vendor,
+		Product:     product.Key,
+		Version:     product.Version,
+		CPE:         product.CPE,
+	}
+	for _, entry := range system.Interfaces {
+		result.Interfaces = append(result.Interfaces, model.ScanTaskRunSNMPInterface{
+			Index:       entry.Index,
+			Description: entry.Description,
+			Type:        entry.Type,
+			Speed:       entry.Speed,
+			PhysAddress: entry.PhysAddress,
+			AdminStatus: entry.AdminStatus,
+			OperStatus:  entry.OperStatus,
+		})
+	}
+	return result
+}
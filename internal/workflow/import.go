@@ -0,0 +1,302 @@
+package workflow
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golandproject/yscan/internal/fingerprint"
+	"golandproject/yscan/internal/ingest"
+	"golandproject/yscan/internal/model"
+	"golandproject/yscan/internal/planner"
+	"golandproject/yscan/internal/storage"
+	"golandproject/yscan/internal/vuln"
+)
+
+// ImportTaskRunOptions configures a run whose discovery came from an external
+// scanner or a packet capture. Product conclusions still come only from this
+// run's frozen fingerprint engine.
+type ImportTaskRunOptions struct {
+	DB             *sql.DB
+	Run            model.ScanTaskRun
+	Observations   ingest.Result
+	CheckCanceled  func() (bool, error)
+	UpdateProgress func(int) error
+}
+
+type ImportTaskRunExecutor struct {
+	Options ImportTaskRunOptions
+}
+
+func NewImportTaskRunExecutor(options ImportTaskRunOptions) ImportTaskRunExecutor {
+	return ImportTaskRunExecutor{Options: options}
+}
+
+func (executor ImportTaskRunExecutor) Execute(ctx context.Context, run model.ScanTaskRun) (model.ScanTaskRunSnapshot, error) {
+	options := executor.Options
+	options.Run = run
+	return RunImportTaskRun(ctx, options)
+}
+
+type importDependencies struct {
+	loadEngine        func(*sql.DB, int64) (*fingerprint.Engine, error)
+	loadTemplateIndex func(string) (string, *planner.NucleiTemplateIndex, error)
+	templateValidator vuln.Validator
+	nativeValidator   vuln.Validator
+}
+
+// RunImportTaskRun converts imported observations into the run snapshot and
+// applies the same optional validation as an active run.
+func RunImportTaskRun(ctx context.Context, options ImportTaskRunOptions) (model.ScanTaskRunSnapshot, error) {
+	return runImportTaskRun(ctx, options, importDependencies{
+		loadEngine:        fingerprint.LoadRunEngineCached,
+		loadTemplateIndex: loadNucleiTemplateIndex,
+		templateValidator: vuln.NucleiValidator{},
+		nativeValidator:   vuln.NativeValidator{},
+	})
+}
+
+func runImportTaskRun(ctx context.Context, options ImportTaskRunOptions, dependencies importDependencies) (model.ScanTaskRunSnapshot, error) {
+	if options.DB == nil {
+		return model.ScanTaskRunSnapshot{}, errors.New("import task run database is required")
+	}
+	if options.Run.ID <= 0 || options.Run.ScanTaskID <= 0 || !importedTrigger(options.Run.Trigger) {
+		return model.ScanTaskRunSnapshot{}, errors.New("invalid import scan task run")
+	}
+	if dependencies.loadEngine == nil || dependencies.templateValidator == nil {
+		return model.ScanTaskRunSnapshot{}, errors.New("import task run dependencies are required")
+	}
+	for _, host := range options.Observations.Hosts {
+		if !ingest.TargetContains(options.Run.Target, host.IP) {
+			return model.ScanTaskRunSnapshot{}, fmt.Errorf("imported host %s is outside run target %s", host.IP, options.Run.Target)
+		}
+	}
+	if err := checkCanceled(ctx, options.CheckCanceled); err != nil {
+		return model.ScanTaskRunSnapshot{}, err
+	}
+	if err := updateProgress(options.UpdateProgress, 10); err != nil {
+		return model.ScanTaskRunSnapshot{}, err
+	}
+	hosts := make([]string, 0, len(options.Observations.Hosts))
+	for _, host := range options.Observations.Hosts {
+		hosts = append(hosts, host.IP)
+	}
+	snapshot := model.ScanTaskRunSnapshot{
+		RunID:              options.Run.ID,
+		Hosts:              snapshotHosts(hosts),
+		Ports:              make([]model.ScanTaskRunPort, 0),
+		ProtocolEvidence:   make([]model.ScanTaskRunProtocolEvidence, 0),
+		Vulnerabilities:    make([]model.ScanTaskRunVulnerability, 0),
+		FingerprintMatches: make([]model.FingerprintRunMatch, 0),
+		Validation:         initialRunValidation(options.Run.Config.VulnerabilityOn),
+	}
+	engine, err := dependencies.loadEngine(options.DB, options.Run.ID)
+	if err != nil {
+		return snapshot, err
+	}
+	// Imported and passive observations keep separate inventory scopes so
+	// neither closes ports that only the other source reported.
+	scope := options.Run.Trigger + ":" + options.Run.Target
+	if err := storage.SyncHostInventory(options.DB, scope, hosts); err != nil {
+		return snapshot, err
+	}
+	// Passive captures come from segments where active scanning is not
+	// permitted, so they never start native checks or Nuclei even when the
+	// task enables validation.
+	passiveOnly := options.Run.Trigger == model.ScanTaskRunTriggerPassive && options.Run.Config.VulnerabilityOn
+	validationOn := options.Run.Config.VulnerabilityOn && !passiveOnly
+	if passiveOnly {
+		snapshot.Validation = initialRunValidation(false)
+	}
+	var validation *runValidationTracker
+	templateRoot := options.Run.Config.NucleiTemplates
+	var templateIndex *planner.NucleiTemplateIndex
+	templateIndexLoaded := false
+	if validationOn {
+		validation = newRunValidationTracker()
+	}
+	for index, host := range options.Observations.Hosts {
+		if err := checkCanceled(ctx, options.CheckCanceled); err != nil {
+			return snapshot, err
+		}
+		openPorts, matches := importedEndpointResults(engine, options.Observations.Format, host)
+		snapshot.Ports = append(snapshot.Ports, snapshotPorts(host.IP, openPorts)...)
+		snapshot.ProtocolEvidence = append(snapshot.ProtocolEvidence, snapshotProtocolEvidence(host.IP, openPorts)...)
+		snapshot.FingerprintMatches = append(snapshot.FingerprintMatches, matches...)
+		// Without declared coverage only observed ports are authoritative;
+		// absent ports are not evidence of closure.
+		coverage := storage.SelectedPortScanCoverage(portsFromResults(host.IP, openPorts))
+		if len(options.Observations.ScannedPorts) > 0 {
+			coverage = storage.SelectedPortScanCoverage(options.Observations.ScannedPorts)
+		}
+		if err := storage.SyncOpenAndScopePorts(options.DB, scope, host.IP, openPorts, coverage); err != nil {
+			return snapshot, err
+		}
+		var validationMatches []model.FingerprintRunMatch
+		if validationOn {
+			if validationMatches, err = unsuppressedMatches(options.DB, host.IP, snapshot.FingerprintMatches); err != nil {
+				return snapshot, err
+			}
+		}
+		if passiveOnly {
+			for _, endpoint := range initialEndpointValidations(host.IP, openPorts, false) {
+				endpoint.Reason = model.ValidationReasonPassiveSource
+				snapshot.EndpointValidations = append(snapshot.EndpointValidations, endpoint)
+			}
+		} else if validationOn && nativeValidationEnabled(options.Run) {
+			validation.register(host.IP, openPorts, validationMatches)
+			nativeResult := runNativeCheckValidation(ctx, host.IP, openPorts, validationMatches, dependencies.nativeValidator)
+			validation.observe(nativeResult)
+			snapshot.TemplateCandidates = uniqueTemplateCandidates(append(snapshot.TemplateCandidates, nativeResult.candidates...))
+			snapshot.Vulnerabilities = uniqueSnapshotVulnerabilities(append(snapshot.Vulnerabilities, snapshotVulnerabilities(nativeResult.findings)...))
+		} else if validationOn {
+			validation.register(host.IP, openPorts, validationMatches)
+			if dependencies.loadTemplateIndex != nil && !templateIndexLoaded {
+				templateRoot, templateIndex, err = dependencies.loadTemplateIndex(options.Run.Config.NucleiTemplates)
+				templateIndexLoaded = true
+				if err != nil {
+					validation.failAll(err)
+					validation.finish(&snapshot, err)
+					return snapshot, err
+				}
+			}
+			mappingResult := runFingerprintMappingValidation(ctx, options.DB, options.Run, host.IP, openPorts, validationMatches, templateRoot, templateIndex, materializedTemplateExecutor(dependencies.templateValidator))
+			validation.observe(mappingResult)
+			if mappingResult.err != nil && !errors.Is(mappingResult.err, vuln.ErrNoTemplates) {
+				snapshot.TemplateCandidates = uniqueTemplateCandidates(append(snapshot.TemplateCandidates, mappingResult.candidates...))
+				snapshot.Vulnerabilities = uniqueSnapshotVulnerabilities(append(snapshot.Vulnerabilities, snapshotVulnerabilities(mappingResult.findings)...))
+				validation.finish(&snapshot, mappingResult.err)
+				return snapshot, mappingResult.err
+			}
+			fallbackResult := runServiceTagValidation(ctx, host.IP, portsWithoutFingerprintMappings(openPorts, mappingResult.candidates, validationMatches), templateIndex, materializedTemplateExecutor(dependencies.templateValidator))
+			validation.observe(fallbackResult)
+			allCandidates := append(mappingResult.candidates, fallbackResult.candidates...)
+			allFindings := append(mappingResult.findings, fallbackResult.findings...)
+			snapshot.TemplateCandidates = uniqueTemplateCandidates(append(snapshot.TemplateCandidates, allCandidates...))
+			snapshot.Vulnerabilities = uniqueSnapshotVulnerabilities(append(snapshot.Vulnerabilities, snapshotVulnerabilities(allFindings)...))
+			if fallbackResult.err != nil && !errors.Is(fallbackResult.err, vuln.ErrNoTemplates) {
+				validation.finish(&snapshot, fallbackResult.err)
+				return snapshot, fallbackResult.err
+			}
+		}
+		progress := 20 + int(float64(index+1)/float64(len(options.Observations.Hosts))*80)
+		if err := updateProgress(options.UpdateProgress, progress); err != nil {
+			return snapshot, err
+		}
+	}
+	if err := storage.DeactivateScopePortsForInactiveHosts(options.DB, scope); err != nil {
+		return snapshot, err
+	}
+	snapshot.Ports = uniqueSnapshotPorts(snapshot.Ports)
+	snapshot.ProtocolEvidence = uniqueProtocolEvidence(snapshot.ProtocolEvidence)
+	snapshot.Vulnerabilities = uniqueSnapshotVulnerabilities(snapshot.Vulnerabilities)
+	snapshot.TemplateCandidates = uniqueTemplateCandidates(snapshot.TemplateCandidates)
+	if validation != nil {
+		validation.finish(&snapshot, nil)
+	}
+	if err := updateProgress(options.UpdateProgress, 100); err != nil {
+		return snapshot, err
+	}
+	return snapshot, nil
+}
+
+// importedEndpointResults feeds the banner and web observations of each
+// imported service through Engine.Match. Scanner product names are kept only
+// as protocol evidence so the run conclusion stays reproducible from rules.
+func importedEndpointResults(engine *fingerprint.Engine, format string, host ingest.Host) ([]model.ScanResult, []model.FingerprintRunMatch) {
+	results := make([]model.ScanResult, 0, len(host.Services))
+	persisted := make([]model.FingerprintRunMatch, 0)
+	for _, service := range host.Services {
+		result := model.ScanResult{
+			Address: net.JoinHostPort(host.IP, strconv.Itoa(service.Port)), Open: true,
+			Service: service.ServiceType(), Banner: service.Banner, BannerTruncated: service.BannerTruncated,
+		}
+		matchSets := make([]endpointEvidenceMatches, 0, 2)
+		if label := service.ProductLabel(); label != "" {
+			result.ProtocolEvidence = append(result.ProtocolEvidence, model.ScanTaskRunProtocolEvidence{
+				EvidenceType: model.ProtocolEvidenceImported, ProbeName: format + ":service", Protocol: "tcp",
+				Responded: true, Server: safeProtocolLabel(label, 256),
+			})
+		}
+		if strings.TrimSpace(service.Banner) != "" {
+			evidence := fingerprint.NewBannerEvidence(service.Banner, service.BannerTruncated)
+			matchSets = append(matchSets, endpointEvidenceMatches{protocol: "tcp", summary: "imported=" + format + " " + bannerEvidenceSummary(evidence), matches: engine.Match(evidence)})
+			observation := protocolEvidenceFromBanner(evidence)
+			observation.EvidenceType, observation.ProbeName = model.ProtocolEvidenceImported, format+":banner"
+			result.ProtocolEvidence = append(result.ProtocolEvidence, observation)
+		}
+		if protocol := service.WebProtocol(); protocol != "" && importedWebObserved(service) {
+			headers := service.HTTPHeaders
+			if len(headers) == 0 && service.HTTPServer != "" {
+				headers = map[string]string{"Server": service.HTTPServer}
+			}
+			endpoint := (&url.URL{Scheme: protocol, Host: result.Address, Path: "/"}).String()
+			evidence := fingerprint.NewWebHeaderEvidence(protocol, endpoint, service.HTTPStatus, headers, service.HTTPTitle)
+			summary := fmt.Sprintf("http imported=%s status=%d protocol=%s headers=%d%s header_sha256=%s", format, evidence.StatusCode, protocol, evidence.HeaderCapturedLength, truncationSummary(evidence.HeaderTruncated), evidence.HeaderCapturedSHA256)
+			matchSets = append(matchSets, endpointEvidenceMatches{protocol: protocol, summary: summary, matches: engine.Match(evidence)})
+			result.ProtocolEvidence = append(result.ProtocolEvidence, model.ScanTaskRunProtocolEvidence{
+				EvidenceType: model.ProtocolEvidenceImported, ProbeName: format + ":http", Protocol: protocol, Responded: true,
+				StatusCode: evidence.StatusCode, Server: safeProtocolLabel(headerValue(evidence.Headers, "server"), 256),
+				Title: safeProtocolLabel(evidence.Title, 256), HeaderCapturedLength: evidence.HeaderCapturedLength,
+				HeaderSHA256: evidence.HeaderCapturedSHA256, HeaderTruncated: evidence.HeaderTruncated,
+			})
+		}
+		if service.TLSServerName != "" || service.TLSCertificateSHA256 != "" {
+			result.ProtocolEvidence = append(result.ProtocolEvidence, model.ScanTaskRunProtocolEvidence{
+				EvidenceType: model.ProtocolEvidenceImported, ProbeName: format + ":tls", Protocol: "tcp", Responded: true,
+				Server: safeProtocolLabel(importedTLSSummary(service), 256), Title: safeProtocolLabel(service.TLSServerName, 256),
+				BannerCapturedLength: service.TLSCertificateLength, BannerSHA256: service.TLSCertificateSHA256,
+			})
+		}
+		for _, script := range service.Scripts {
+			sum := sha256.Sum256([]byte(script.Output))
+			observation := model.ScanTaskRunProtocolEvidence{
+				EvidenceType: model.ProtocolEvidenceImported, ProbeName: safeProtocolLabel(format+":script:"+script.ID, 128),
+				Protocol: "tcp", Responded: script.Output != "",
+			}
+			if observation.Responded {
+				observation.BannerCapturedLength = len(script.Output)
+				observation.BannerSHA256 = hex.EncodeToString(sum[:])
+			}
+			result.ProtocolEvidence = append(result.ProtocolEvidence, observation)
+		}
+		matches, hardProducts := endpointRunMatches(host.IP, service.Port, matchSets)
+		persisted = append(persisted, matches...)
+		result.Product, result.FingerprintSource = resolvedHardProduct(hardProducts)
+		if endpointServiceUnknown(result.Service) {
+			if candidate, exists := hardProducts[result.Product]; exists && candidate.role == "network_service" {
+				result.Service = result.Product
+			}
+		}
+		results = append(results, result)
+	}
+	return results, persisted
+}
+
+func importedWebObserved(service ingest.Service) bool {
+	return service.HTTPServer != "" || service.HTTPTitle != "" || len(service.HTTPHeaders) > 0 || service.HTTPStatus > 0
+}
+
+func importedTrigger(trigger string) bool {
+	return trigger == model.ScanTaskRunTriggerImport || trigger == model.ScanTaskRunTriggerPassive
+}
+
+// importedTLSSummary keeps the certificate identity readable in reports; the
+// DER bytes are represented only by their digest.
+func importedTLSSummary(service ingest.Service) string {
+	parts := make([]string, 0, 2)
+	if service.TLSCertificateSubject != "" {
+		parts = append(parts, "subject="+service.TLSCertificateSubject)
+	}
+	if len(service.TLSCertificateNames) > 0 {
+		parts = append(parts, "san="+strings.Join(service.TLSCertificateNames, ","))
+	}
+	return strings.Join(parts, " ")
+}
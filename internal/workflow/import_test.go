@@ -0,0 +1,152 @@
+package workflow
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"golandproject/yscan/internal/fingerprint"
+	"golandproject/yscan/internal/ingest"
+	"golandproject/yscan/internal/model"
+	"golandproject/yscan/internal/planner"
+	"golandproject/yscan/internal/vuln"
+)
+
+func importTestDependencies() importDependencies {
+	return importDependencies{
+		loadEngine: func(*sql.DB, int64) (*fingerprint.Engine, error) { return &fingerprint.Engine{}, nil },
+		templateValidator: vuln.ValidatorFunc(func(context.Context, string, []model.ScanResult, []string) vuln.NucleiExecutionResult {
+			return vuln.NucleiExecutionResult{}
+		}),
+	}
+}
+
+func TestRunImportTaskRunBuildsSnapshotFromObservations(t *testing.T) {
+	db := openWorkflowDB(t)
+	progress := make([]int, 0)
+	snapshot, err := runImportTaskRun(context.Background(), ImportTaskRunOptions{
+		DB:  db,
+		Run: model.ScanTaskRun{ID: 90, ScanTaskID: 9, ScanType: model.ScanTypeSubnet, Target: "10.0.0.0/24", Trigger: model.ScanTaskRunTriggerImport},
+		Observations: ingest.Result{
+			Format:       ingest.FormatNmapXML,
+			ScannedPorts: []int{22, 80},
+			Hosts: []ingest.Host{{IP: "10.0.0.5", Services: []ingest.Service{
+				{Port: 22, Name: "ssh", Product: "OpenSSH", Version: "9.6", Banner: "SSH-2.0-OpenSSH_9.6", Scripts: []ingest.Script{{ID: "banner", Output: "SSH-2.0-OpenSSH_9.6"}}},
+				{Port: 80, Name: "http", HTTPServer: "nginx", HTTPTitle: "Portal"},
+			}}},
+		},
+		UpdateProgress: func(value int) error {
+			progress = append(progress, value)
+			return nil
+		},
+	}, importTestDependencies())
+	if err != nil {
+		t.Fatalf("run import task run: %v", err)
+	}
+	if len(snapshot.Hosts) != 1 || len(snapshot.Ports) != 2 {
+		t.Fatalf("snapshot hosts=%#v ports=%#v", snapshot.Hosts, snapshot.Ports)
+	}
+	for _, port := range snapshot.Ports {
+		if port.Product != "" {
+			t.Fatalf("scanner product became a run conclusion: %#v", port)
+		}
+	}
+	if snapshot.Ports[0].ServiceType != "ssh" || snapshot.Ports[1].ServiceType != "http" {
+		t.Fatalf("service types = %#v", snapshot.Ports)
+	}
+	probes := make([]string, 0)
+	for _, evidence := range snapshot.ProtocolEvidence {
+		if evidence.EvidenceType != model.ProtocolEvidenceImported {
+			t.Fatalf("unexpected evidence type %#v", evidence)
+		}
+		probes = append(probes, evidence.ProbeName)
+	}
+	joined := strings.Join(probes, ",")
+	for _, want := range []string{"nmap-xml:service", "nmap-xml:banner", "nmap-xml:script:banner", "nmap-xml:http"} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("protocol evidence probes = %s, missing %s", joined, want)
+		}
+	}
+	if snapshot.Validation.Status != model.ScanTaskRunValidationDisabled {
+		t.Fatalf("validation = %#v", snapshot.Validation)
+	}
+	if len(progress) == 0 || progress[len(progress)-1] != 100 {
+		t.Fatalf("progress = %v", progress)
+	}
+	var scopePorts int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM host_inventory_scope_ports WHERE scope = ? AND is_active = 1`, "import:10.0.0.0/24").Scan(&scopePorts); err != nil || scopePorts != 2 {
+		t.Fatalf("import scope ports=%d err=%v", scopePorts, err)
+	}
+}
+
+func TestRunImportTaskRunRejectsOutOfScopeHostsAndOtherTriggers(t *testing.T) {
+	db := openWorkflowDB(t)
+	run := model.ScanTaskRun{ID: 91, ScanTaskID: 9, Target: "10.0.0.0/24", Trigger: model.ScanTaskRunTriggerImport}
+	observations := ingest.Result{Format: ingest.FormatMasscanJSON, Hosts: []ingest.Host{{IP: "10.0.1.5"}}}
+	if _, err := runImportTaskRun(context.Background(), ImportTaskRunOptions{DB: db, Run: run, Observations: observations}, importTestDependencies()); err == nil || !strings.Contains(err.Error(), "outside run target") {
+		t.Fatalf("out-of-scope err = %v", err)
+	}
+	run.Trigger = model.ScanTaskRunTriggerManual
+	if _, err := runImportTaskRun(context.Background(), ImportTaskRunOptions{DB: db, Run: run}, importTestDependencies()); err == nil {
+		t.Fatal("manual run was accepted as an import")
+	}
+}
+
+func TestRunPassiveTaskRunRecordsTLSEvidenceInPassiveScope(t *testing.T) {
+	db := openWorkflowDB(t)
+	snapshot, err := runImportTaskRun(context.Background(), ImportTaskRunOptions{
+		DB:  db,
+		Run: model.ScanTaskRun{ID: 92, ScanTaskID: 9, Target: "192.168.1.0/27", Trigger: model.ScanTaskRunTriggerPassive},
+		Observations: ingest.Result{Format: ingest.FormatPcap, Hosts: []ingest.Host{{IP: "192.168.1.10", Services: []ingest.Service{{
+			Port: 443, Tunnel: "ssl", TLSServerName: "intranet.example", TLSCertificateSubject: "CN=intranet.example",
+			TLSCertificateNames: []string{"intranet.example"}, TLSCertificateSHA256: strings.Repeat("c", 64), TLSCertificateLength: 410,
+		}}}}},
+	}, importTestDependencies())
+	if err != nil {
+		t.Fatalf("run passive task run: %v", err)
+	}
+	if len(snapshot.ProtocolEvidence) != 1 {
+		t.Fatalf("protocol evidence = %#v", snapshot.ProtocolEvidence)
+	}
+	evidence := snapshot.ProtocolEvidence[0]
+	if evidence.ProbeName != "pcap:tls" || evidence.Title != "intranet.example" || evidence.Server != "subject=CN=intranet.example san=intranet.example" || evidence.BannerCapturedLength != 410 {
+		t.Fatalf("tls evidence = %#v", evidence)
+	}
+	var scopes int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM host_inventory_scope_ports WHERE scope = ?`, "passive:192.168.1.0/27").Scan(&scopes); err != nil || scopes != 1 {
+		t.Fatalf("passive scope ports=%d err=%v", scopes, err)
+	}
+}
+
+func TestRunPassiveTaskRunNeverStartsValidation(t *testing.T) {
+	db := openWorkflowDB(t)
+	dependencies := importTestDependencies()
+	root, templateIndex := workflowTemplateIndexFixture(t, workflowTemplateSpec{id: "redis-unauth", product: "redis", protocol: "tcp"})
+	dependencies.loadTemplateIndex = func(string) (string, *planner.NucleiTemplateIndex, error) { return root, templateIndex, nil }
+	dependencies.templateValidator = vuln.ValidatorFunc(func(context.Context, string, []model.ScanResult, []string) vuln.NucleiExecutionResult {
+		t.Fatal("passive run started Nuclei")
+		return vuln.NucleiExecutionResult{}
+	})
+	dependencies.nativeValidator = vuln.ValidatorFunc(func(context.Context, string, []model.ScanResult, []string) vuln.ValidationResult {
+		t.Fatal("passive run started native checks")
+		return vuln.ValidationResult{}
+	})
+	observations := ingest.Result{Format: ingest.FormatPcap, Hosts: []ingest.Host{{IP: "192.168.1.20", Services: []ingest.Service{{Port: 6379, Name: "redis"}}}}}
+	for _, validator := range []string{model.ValidatorNuclei, model.ValidatorNative} {
+		snapshot, err := runImportTaskRun(context.Background(), ImportTaskRunOptions{
+			DB:           db,
+			Run:          model.ScanTaskRun{ID: 93, ScanTaskID: 9, Target: "192.168.1.0/27", Trigger: model.ScanTaskRunTriggerPassive, Config: model.ScanTaskConfig{VulnerabilityOn: true, Validator: validator}},
+			Observations: observations,
+		}, dependencies)
+		if err != nil {
+			t.Fatalf("%s: run passive task run: %v", validator, err)
+		}
+		if snapshot.Validation.Status != model.ScanTaskRunValidationDisabled || len(snapshot.Vulnerabilities) != 0 {
+			t.Fatalf("%s: validation = %#v", validator, snapshot.Validation)
+		}
+		if len(snapshot.EndpointValidations) != 1 || snapshot.EndpointValidations[0].Enabled || snapshot.EndpointValidations[0].Reason != model.ValidationReasonPassiveSource {
+			t.Fatalf("%s: endpoint validations = %#v", validator, snapshot.EndpointValidations)
+		}
+	}
+}
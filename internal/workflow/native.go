@@ -0,0 +1,91 @@
+package workflow
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golandproject/yscan/internal/model"
+	"golandproject/yscan/internal/vuln"
+)
+
+func nativeValidationEnabled(run model.ScanTaskRun) bool {
+	return run.Config.Validator == model.ValidatorNative
+}
+
+// runNativeCheckValidation selects built-in checks by the products this run
+// identified on each open endpoint of ip, plus the scanned service name, and
+// runs them through validator. Each check runs at most once per port, on the
+// first protocol that selects it in https, http, tcp order.
+func runNativeCheckValidation(ctx context.Context, ip string, ports []model.ScanResult, matches []model.FingerprintRunMatch, validator vuln.Validator) validationExecutionResult {
+	result := validationExecutionResult{identifiedProducts: make(map[string]struct{}), mappedProducts: make(map[string]struct{})}
+	if validator == nil {
+		validator = vuln.NativeValidator{}
+	}
+	for _, portResult := range ports {
+		if !portResult.Open {
+			continue
+		}
+		_, portText, err := net.SplitHostPort(portResult.Address)
+		port, parseErr := strconv.Atoi(portText)
+		if err != nil || parseErr != nil {
+			continue
+		}
+		products := make(map[string][]string)
+		addProduct := func(protocol, product string) {
+			protocol = strings.ToLower(strings.TrimSpace(protocol))
+			product = strings.ToLower(strings.TrimSpace(product))
+			if product == "" || containsValue(products[protocol], product) {
+				return
+			}
+			products[protocol] = append(products[protocol], product)
+		}
+		for _, match := range matches {
+			if match.Soft || match.IP != ip || match.Port != port || match.Product == "" {
+				continue
+			}
+			addProduct(match.Protocol, match.Product)
+			result.identifiedProducts[validationProductIdentity(ip, port, match.Protocol, match.Product)] = struct{}{}
+		}
+		addProduct(candidateProtocolForService(portResult.Service), portResult.Service)
+
+		seen := make(map[string]struct{})
+		for _, protocol := range []string{"https", "http", "tcp"} {
+			sort.Strings(products[protocol])
+			checkIDs := make([]string, 0)
+			invocationCandidates := make([]model.ScanTaskRunTemplateCandidate, 0)
+			for _, product := range products[protocol] {
+				for _, check := range vuln.SelectNativeChecks(product) {
+					if _, duplicate := seen[check.ID]; duplicate {
+						continue
+					}
+					seen[check.ID] = struct{}{}
+					checkIDs = append(checkIDs, check.ID)
+					invocationCandidates = append(invocationCandidates, model.ScanTaskRunTemplateCandidate{
+						TemplateID: check.ID, Path: vuln.NativeCheckPath(check.ID), ProductKey: product,
+						Source: "native_check", Reason: "built-in safe check mapped to " + product,
+						TemplateSetRevision: vuln.NativeCheckRevision, IP: ip, Port: port, Protocol: protocol,
+					})
+					result.mappedProducts[validationProductIdentity(ip, port, protocol, product)] = struct{}{}
+				}
+			}
+			if len(checkIDs) == 0 {
+				continue
+			}
+			target := portResult
+			if protocol == "http" || protocol == "https" {
+				target.Service = protocol
+			}
+			execution := validator.Validate(ctx, ip, []model.ScanResult{target}, checkIDs)
+			result.findings = append(result.findings, execution.Findings...)
+			if execution.Executed {
+				markTemplateCandidatesExecuted(invocationCandidates)
+			}
+			result.observeExecution(invocationCandidates, execution)
+			result.candidates = append(result.candidates, invocationCandidates...)
+		}
+	}
+	return result
+}
@@ -0,0 +1,86 @@
+package workflow
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golandproject/yscan/internal/model"
+	"golandproject/yscan/internal/planner"
+	"golandproject/yscan/internal/storage"
+	"golandproject/yscan/internal/vuln"
+)
+
+func TestNativeValidatorRunsBuiltInChecksWithoutTemplates(t *testing.T) {
+	const ip = "192.168.81.10"
+	db := openFullWorkflowDB(t)
+	task, err := storage.CreateScanTask(db, model.ScanTask{Target: "192.168.81.0/28", ScanType: model.ScanTypeSubnet, Mode: model.ScanTaskModeOnce, Config: model.ScanTaskConfig{
+		VulnerabilityOn: true, NucleiTemplates: filepath.Join(t.TempDir(), "missing"), Validator: model.ValidatorNative,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := createPlannedRun(t, db, task.ID, model.ScanTaskRunSnapshot{
+		Hosts: []model.ScanTaskRunHost{{IP: ip, IsActive: true}},
+		Ports: []model.ScanTaskRunPort{{IP: ip, Port: 80, ServiceType: "http"}, {IP: ip, Port: 5432, ServiceType: "postgresql"}, {IP: ip, Port: 6379, ServiceType: "redis"}},
+	})
+
+	plan, err := PlanValidation(context.Background(), db, task, source)
+	if err != nil {
+		t.Fatalf("plan native validation: %v", err)
+	}
+	if plan.Validator != model.ValidatorNative || plan.TemplateSetRevision != vuln.NativeCheckRevision || plan.Error != "" || len(plan.Endpoints) != 3 || len(plan.Invocations) != 2 {
+		t.Fatalf("native plan=%#v", plan)
+	}
+	endpoints := make(map[int]model.ValidationPlanEndpoint)
+	for _, endpoint := range plan.Endpoints {
+		endpoints[endpoint.Port] = endpoint
+	}
+	web, database, redis := endpoints[80], endpoints[5432], endpoints[6379]
+	if web.Port != 80 || len(web.Candidates) != 2 || web.Candidates[0].TemplateID != "dotenv-exposure" || web.Candidates[0].Path != vuln.NativeCheckPath("dotenv-exposure") {
+		t.Fatalf("web endpoint=%#v", web)
+	}
+	if database.Port != 5432 || len(database.Candidates) != 0 || database.Reason != model.ValidationReasonUnidentifiedProduct {
+		t.Fatalf("database endpoint=%#v", database)
+	}
+	if redis.Port != 6379 || len(redis.Candidates) != 1 || redis.Candidates[0].Source != "native_check" || redis.Candidates[0].Executed {
+		t.Fatalf("redis endpoint=%#v", redis)
+	}
+	if invocation := plan.Invocations[0]; invocation.Port != 80 || len(invocation.Command) != 0 || strings.Join(invocation.Targets, ",") != "http://"+ip+":80" {
+		t.Fatalf("web invocation=%#v", invocation)
+	}
+
+	run, err := storage.CreateRevalidationScanTaskRun(db, source.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var validated []string
+	snapshot, err := runRevalidateTaskRun(context.Background(), RevalidateTaskRunOptions{DB: db, Run: run}, revalidateDependencies{
+		loadTemplateIndex: func(string) (string, *planner.NucleiTemplateIndex, error) {
+			t.Fatal("native validation must not load Nuclei templates")
+			return "", nil, nil
+		},
+		templateValidator: vuln.ValidatorFunc(func(context.Context, string, []model.ScanResult, []string) vuln.NucleiExecutionResult {
+			t.Fatal("native validation must not start Nuclei")
+			return vuln.NucleiExecutionResult{}
+		}),
+		nativeValidator: vuln.ValidatorFunc(func(_ context.Context, target string, ports []model.ScanResult, checks []string) vuln.ValidationResult {
+			validated = append(validated, ports[0].Address+"="+strings.Join(checks, "+"))
+			result := vuln.ValidationResult{Started: true, Executed: true}
+			if checks[0] == "redis-unauth-info" {
+				result.Findings = []model.NucleiFinding{{TemplateID: "redis-unauth-info", Severity: "high", Target: ports[0].Address, TargetIP: target, TargetPort: 6379, Evidence: "INFO answered without AUTH: redis_version:7.2.4"}}
+			}
+			return result
+		}),
+	})
+	if err != nil {
+		t.Fatalf("native revalidation: %v", err)
+	}
+	if strings.Join(validated, ",") != ip+":80=dotenv-exposure+git-config-exposure,"+ip+":6379=redis-unauth-info" {
+		t.Fatalf("validated=%v", validated)
+	}
+	if snapshot.Validation.Status != model.ScanTaskRunValidationSuccess || snapshot.Validation.ExecutedTemplateCount != 3 || len(snapshot.Vulnerabilities) != 1 || snapshot.Vulnerabilities[0].TemplateID != "redis-unauth-info" {
+		t.Fatalf("native snapshot validation=%#v vulnerabilities=%#v", snapshot.Validation, snapshot.Vulnerabilities)
+	}
+}
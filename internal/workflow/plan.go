@@ -0,0 +1,239 @@
+package workflow
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golandproject/yscan/internal/model"
+	"golandproject/yscan/internal/planner"
+	"golandproject/yscan/internal/storage"
+	"golandproject/yscan/internal/vuln"
+)
+
+const (
+	planTemplateSnapshot = "<template-snapshot>"
+	planTargetsFile      = "<targets-file>"
+)
+
+// PlanValidation reports what vulnerability validation would do for task if
+// it observed exactly the endpoints and fingerprint matches run recorded.
+// Templates, or built-in checks for the native validator, are selected by the
+// same code a real run uses, but the executor only records each invocation;
+// nothing is started or contacted.
+func PlanValidation(ctx context.Context, db *sql.DB, task model.ScanTask, run model.ScanTaskRun) (model.ValidationPlan, error) {
+	if db == nil {
+		return model.ValidationPlan{}, fmt.Errorf("validation plan database is required")
+	}
+	if run.ScanTaskID != task.ID {
+		return model.ValidationPlan{}, storage.ErrScanTaskRunNotFound
+	}
+	if run.Status != model.ScanTaskRunStatusSuccess || run.SnapshotWrittenAt == "" {
+		return model.ValidationPlan{}, storage.ErrScanTaskRunSnapshotUnavailable
+	}
+	snapshot, err := storage.GetScanTaskRunSnapshot(db, run.ID)
+	if err != nil {
+		return model.ValidationPlan{}, err
+	}
+	matches, err := storage.LoadFingerprintRunMatches(db, run.ID)
+	if err != nil {
+		return model.ValidationPlan{}, err
+	}
+	if matches, err = storage.WithoutSuppressedFingerprintMatches(db, matches); err != nil {
+		return model.ValidationPlan{}, err
+	}
+	plan := model.ValidationPlan{
+		ScanTaskID: task.ID, ScanTaskRunID: run.ID, VulnerabilityOn: task.Config.VulnerabilityOn,
+		Endpoints: make([]model.ValidationPlanEndpoint, 0), Invocations: make([]model.ValidationPlanInvocation, 0),
+	}
+
+	hosts, ips := snapshotHostResults(snapshot.Ports)
+	services := make(map[string]string)
+	for _, port := range snapshot.Ports {
+		services[validationEndpointIdentity(port.IP, port.Port, candidateProtocolForService(port.ServiceType))] = strings.ToLower(strings.TrimSpace(port.ServiceType))
+	}
+
+	tracker := newRunValidationTracker()
+	if task.Config.Validator == model.ValidatorNative {
+		plan.Validator, plan.TemplateSetRevision = model.ValidatorNative, vuln.NativeCheckRevision
+		record := vuln.ValidatorFunc(func(_ context.Context, ip string, ports []model.ScanResult, checks []string) vuln.ValidationResult {
+			invocation := model.ValidationPlanInvocation{IP: ip, Port: planInvocationPort(ports), Targets: make([]string, 0, len(ports)), TemplateIDs: append([]string(nil), checks...), Command: make([]string, 0)}
+			for _, port := range ports {
+				switch port.Service {
+				case "http", "https":
+					invocation.Targets = append(invocation.Targets, port.Service+"://"+port.Address)
+				default:
+					invocation.Targets = append(invocation.Targets, port.Address)
+				}
+			}
+			plan.Invocations = append(plan.Invocations, invocation)
+			return vuln.ValidationResult{}
+		})
+		candidates := make([]model.ScanTaskRunTemplateCandidate, 0)
+		for _, ip := range ips {
+			if err := ctx.Err(); err != nil {
+				return model.ValidationPlan{}, err
+			}
+			tracker.register(ip, hosts[ip], matches)
+			result := runNativeCheckValidation(ctx, ip, hosts[ip], matches, record)
+			tracker.observe(result)
+			candidates = append(candidates, result.candidates...)
+		}
+		plan.Endpoints = planEndpoints(tracker, nil, uniqueTemplateCandidates(candidates), matches, services)
+		return plan, nil
+	}
+	plan.Validator = model.ValidatorNuclei
+	root, templateIndex, err := loadNucleiTemplateIndex(task.Config.NucleiTemplates)
+	if err != nil {
+		plan.Error = safeValidationError(err)
+		for _, ip := range ips {
+			tracker.register(ip, hosts[ip], matches)
+		}
+		tracker.failAll(err)
+		plan.Endpoints = planEndpoints(tracker, nil, nil, matches, services)
+		return plan, nil
+	}
+	plan.TemplatesRoot, plan.TemplateSetRevision = root, templateIndex.Revision
+
+	// Invocations name the content-addressed files a run would write and the
+	// template IDs they carry; nothing is written for the plan.
+	record := func(_ context.Context, ip string, ports []model.ScanResult, templates []planner.PinnedNucleiTemplate) vuln.NucleiExecutionResult {
+		digests, err := planner.VerifyPinnedNucleiTemplates(templates)
+		if err != nil {
+			return vuln.NucleiExecutionResult{Err: err}
+		}
+		shown := make([]string, 0, len(digests))
+		for _, digest := range digests {
+			shown = append(shown, planTemplateSnapshot+"/"+digest+".yaml")
+		}
+		names := make([]string, 0, len(templates))
+		for _, template := range templates {
+			if !containsValue(names, template.TemplateID) {
+				names = append(names, template.TemplateID)
+			}
+		}
+		sort.Strings(names)
+		targets, command, detectErr := vuln.NucleiTemplatePathCommand(ip, ports, shown, planTargetsFile)
+		if detectErr != nil && plan.Error == "" {
+			plan.Error = safeValidationError(detectErr)
+		}
+		plan.Invocations = append(plan.Invocations, model.ValidationPlanInvocation{IP: ip, Port: planInvocationPort(ports), Targets: targets, TemplateIDs: names, Command: command})
+		return vuln.NucleiExecutionResult{}
+	}
+
+	candidates := make([]model.ScanTaskRunTemplateCandidate, 0)
+	for _, ip := range ips {
+		if err := ctx.Err(); err != nil {
+			return model.ValidationPlan{}, err
+		}
+		ports := hosts[ip]
+		tracker.register(ip, ports, matches)
+		mappingResult := runFingerprintMappingValidation(ctx, db, run, ip, ports, matches, root, templateIndex, record)
+		tracker.observe(mappingResult)
+		if mappingResult.err != nil {
+			return model.ValidationPlan{}, mappingResult.err
+		}
+		fallbackResult := runServiceTagValidation(ctx, ip, portsWithoutFingerprintMappings(ports, mappingResult.candidates, matches), templateIndex, record)
+		tracker.observe(fallbackResult)
+		candidates = append(candidates, mappingResult.candidates...)
+		candidates = append(candidates, fallbackResult.candidates...)
+	}
+	candidates = uniqueTemplateCandidates(candidates)
+
+	plan.Endpoints = planEndpoints(tracker, templateIndex, candidates, matches, services)
+	return plan, nil
+}
+
+// planEndpoints mirrors the endpoint reasons of runValidationTracker.finish
+// for a run in which every planned invocation would execute.
+func planEndpoints(tracker *runValidationTracker, templateIndex *planner.NucleiTemplateIndex, candidates []model.ScanTaskRunTemplateCandidate, matches []model.FingerprintRunMatch, services map[string]string) []model.ValidationPlanEndpoint {
+	keys := make([]string, 0, len(tracker.endpoints))
+	for key := range tracker.endpoints {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	endpoints := make([]model.ValidationPlanEndpoint, 0, len(keys))
+	for _, key := range keys {
+		state := tracker.endpoints[key]
+		endpoint := model.ValidationPlanEndpoint{
+			IP: state.ip, Port: state.port, Protocol: state.protocol, Products: make([]string, 0, len(state.identified)),
+			Candidates: make([]model.ScanTaskRunTemplateCandidate, 0), Filtered: make([]model.ValidationPlanFilteredTemplate, 0),
+		}
+		for product := range state.identified {
+			endpoint.Products = append(endpoint.Products, product)
+		}
+		sort.Strings(endpoint.Products)
+		for _, candidate := range candidates {
+			if validationEndpointKey(candidate) == key {
+				endpoint.Candidates = append(endpoint.Candidates, candidate)
+			}
+		}
+		endpoint.Filtered = planFilteredTemplates(templateIndex, key, state, matches, services[key])
+		switch {
+		case state.err != nil:
+			endpoint.Reason = validationErrorReason(state.err)
+			endpoint.Error = safeValidationError(state.err)
+		case len(endpoint.Candidates) > 0:
+		case state.policyFiltered:
+			endpoint.Reason = model.ValidationReasonPolicyFiltered
+		case len(state.identified) == 0:
+			endpoint.Reason = model.ValidationReasonUnidentifiedProduct
+		default:
+			endpoint.Reason = model.ValidationReasonMappingMissing
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
+
+func planFilteredTemplates(templateIndex *planner.NucleiTemplateIndex, key string, state *endpointValidationTracker, matches []model.FingerprintRunMatch, service string) []model.ValidationPlanFilteredTemplate {
+	filtered := make([]model.ValidationPlanFilteredTemplate, 0)
+	if templateIndex == nil {
+		return filtered
+	}
+	seen := make(map[string]struct{})
+	add := func(product, cpe string) {
+		for _, template := range templateIndex.FilteredTemplates(product, cpe, state.protocol) {
+			identity := product + "\x00" + template.Path
+			if _, duplicate := seen[identity]; duplicate {
+				continue
+			}
+			seen[identity] = struct{}{}
+			filtered = append(filtered, model.ValidationPlanFilteredTemplate{TemplateID: template.TemplateID, Path: template.Path, ProductKey: product, Reason: template.Reason})
+		}
+	}
+	for _, match := range matches {
+		if !match.Soft && match.Product != "" && validationEndpointIdentity(match.IP, match.Port, match.Protocol) == key {
+			add(strings.ToLower(strings.TrimSpace(match.Product)), match.CPE)
+		}
+	}
+	if service != "" {
+		add(service, "")
+	}
+	return filtered
+}
+
+func planInvocationPort(ports []model.ScanResult) int {
+	if len(ports) == 0 {
+		return 0
+	}
+	_, rawPort, err := net.SplitHostPort(ports[0].Address)
+	if err != nil {
+		return 0
+	}
+	port, _ := strconv.Atoi(rawPort)
+	return port
+}
+
+func containsValue(values []string, value string) bool {
+	for _, existing := range values {
+		if existing == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package workflow
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golandproject/yscan/internal/model"
+	"golandproject/yscan/internal/planner"
+	"golandproject/yscan/internal/storage"
+)
+
+func TestPlanValidationSelectsTemplatesWithoutExecuting(t *testing.T) {
+	const ip = "192.168.78.10"
+	db := openFullWorkflowDB(t)
+	root, _ := workflowTemplateIndexFixture(t, workflowTemplateSpec{id: "redis-safe", product: "redis", protocol: "tcp"})
+	unsafe := "id: redis-default-login\ninfo:\n  name: redis-default-login\n  severity: high\n  metadata: {product: redis}\n  tags: redis,config,vuln\ntcp:\n  - inputs:\n      - data: \"info\\r\\n\"\n"
+	if err := os.WriteFile(filepath.Join(root, "redis-default-login.yaml"), []byte(unsafe), 0600); err != nil {
+		t.Fatal(err)
+	}
+	task, err := storage.CreateScanTask(db, model.ScanTask{Target: "192.168.78.0/28", ScanType: model.ScanTypeSubnet, Mode: model.ScanTaskModeOnce, Config: model.ScanTaskConfig{VulnerabilityOn: true, NucleiTemplates: root}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := createPlannedRun(t, db, task.ID, model.ScanTaskRunSnapshot{
+		Hosts: []model.ScanTaskRunHost{{IP: ip, IsActive: true}},
+		Ports: []model.ScanTaskRunPort{{IP: ip, Port: 6379, ServiceType: "redis"}, {IP: ip, Port: 8080, ServiceType: "http"}},
+	})
+
+	// A plan never materializes templates, so an unusable temp directory
+	// must not surface as an endpoint error.
+	t.Setenv("TMPDIR", filepath.Join(t.TempDir(), "missing"))
+	plan, err := PlanValidation(context.Background(), db, task, run)
+	if err != nil {
+		t.Fatalf("plan validation: %v", err)
+	}
+	if plan.ScanTaskRunID != run.ID || plan.TemplatesRoot != root || len(plan.Endpoints) != 2 {
+		t.Fatalf("plan=%#v", plan)
+	}
+	redis, web := plan.Endpoints[0], plan.Endpoints[1]
+	if redis.Port != 6379 || redis.Reason != "" || len(redis.Candidates) != 1 || redis.Candidates[0].TemplateID != "redis-safe" || redis.Candidates[0].Executed {
+		t.Fatalf("redis endpoint=%#v", redis)
+	}
+	if len(redis.Filtered) != 1 || redis.Filtered[0].TemplateID != "redis-default-login" || redis.Filtered[0].Reason != planner.NucleiFilterReasonUnsafeIdentity {
+		t.Fatalf("redis filtered=%#v", redis.Filtered)
+	}
+	if web.Port != 8080 || web.Reason != model.ValidationReasonUnidentifiedProduct || len(web.Candidates) != 0 {
+		t.Fatalf("web endpoint=%#v", web)
+	}
+	if len(plan.Invocations) != 1 {
+		t.Fatalf("invocations=%#v", plan.Invocations)
+	}
+	invocation := plan.Invocations[0]
+	command := strings.Join(invocation.Command, " ")
+	if invocation.IP != ip || invocation.Port != 6379 || len(invocation.TemplateIDs) != 1 || invocation.TemplateIDs[0] != "redis-safe" || !strings.Contains(command, planTemplateSnapshot+"/") || !strings.Contains(command, planTargetsFile) || strings.Contains(command, root) {
+		t.Fatalf("invocation=%#v", invocation)
+	}
+
+	other, err := storage.CreateScanTask(db, model.ScanTask{Target: "192.168.78.16/28", ScanType: model.ScanTypeSubnet, Mode: model.ScanTaskModeOnce})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PlanValidation(context.Background(), db, other, run); !errors.Is(err, storage.ErrScanTaskRunNotFound) {
+		t.Fatalf("foreign run err=%v", err)
+	}
+}
+
+func createPlannedRun(t *testing.T, db *sql.DB, taskID int64, snapshot model.ScanTaskRunSnapshot) model.ScanTaskRun {
+	t.Helper()
+	run, err := storage.CreateScanTaskRun(db, model.ScanTaskRun{ScanTaskID: taskID, ScheduledFor: "2026-10-19T02:00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE scan_task_runs SET status = ? WHERE id = ?`, model.ScanTaskRunStatusRunning, run.ID); err != nil {
+		t.Fatal(err)
+	}
+	snapshot.RunID = run.ID
+	if err := storage.SaveScanTaskRunSnapshot(db, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE scan_task_runs SET status = ?, finished_at = datetime('now') WHERE id = ?`, model.ScanTaskRunStatusSuccess, run.ID); err != nil {
+		t.Fatal(err)
+	}
+	run, err = storage.GetLatestSuccessfulScanTaskRun(db, taskID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return run
+}
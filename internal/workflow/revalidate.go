@@ -0,0 +1,188 @@
+package workflow
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+
+	"golandproject/yscan/internal/model"
+	"golandproject/yscan/internal/planner"
+	"golandproject/yscan/internal/storage"
+	"golandproject/yscan/internal/vuln"
+)
+
+// RevalidateTaskRunOptions configures a run that repeats only vulnerability
+// validation. Endpoints, protocol evidence and fingerprint matches are taken
+// unchanged from the source run the new run was derived from.
+type RevalidateTaskRunOptions struct {
+	DB             *sql.DB
+	Run            model.ScanTaskRun
+	CheckCanceled  func() (bool, error)
+	UpdateProgress func(int) error
+}
+
+type RevalidateTaskRunExecutor struct {
+	Options RevalidateTaskRunOptions
+}
+
+func NewRevalidateTaskRunExecutor(options RevalidateTaskRunOptions) RevalidateTaskRunExecutor {
+	return RevalidateTaskRunExecutor{Options: options}
+}
+
+func (executor RevalidateTaskRunExecutor) Execute(ctx context.Context, run model.ScanTaskRun) (model.ScanTaskRunSnapshot, error) {
+	options := executor.Options
+	options.Run = run
+	return RunRevalidateTaskRun(ctx, options)
+}
+
+type revalidateDependencies struct {
+	loadTemplateIndex func(string) (string, *planner.NucleiTemplateIndex, error)
+	templateValidator vuln.Validator
+	nativeValidator   vuln.Validator
+}
+
+// RunRevalidateTaskRun validates the source run's endpoints with the current
+// template set, or the built-in checks when the task uses the native
+// validator. Nothing is rediscovered, so inventory is left untouched.
+func RunRevalidateTaskRun(ctx context.Context, options RevalidateTaskRunOptions) (model.ScanTaskRunSnapshot, error) {
+	return runRevalidateTaskRun(ctx, options, revalidateDependencies{
+		loadTemplateIndex: loadNucleiTemplateIndex,
+		templateValidator: vuln.NucleiValidator{},
+		nativeValidator:   vuln.NativeValidator{},
+	})
+}
+
+func runRevalidateTaskRun(ctx context.Context, options RevalidateTaskRunOptions, dependencies revalidateDependencies) (model.ScanTaskRunSnapshot, error) {
+	if options.DB == nil {
+		return model.ScanTaskRunSnapshot{}, errors.New("revalidate task run database is required")
+	}
+	if options.Run.ID <= 0 || options.Run.ScanTaskID <= 0 || options.Run.Trigger != model.ScanTaskRunTriggerRevalidate {
+		return model.ScanTaskRunSnapshot{}, errors.New("invalid revalidate scan task run")
+	}
+	if dependencies.loadTemplateIndex == nil || dependencies.templateValidator == nil {
+		return model.ScanTaskRunSnapshot{}, errors.New("revalidate task run dependencies are required")
+	}
+	if !options.Run.Config.VulnerabilityOn {
+		return model.ScanTaskRunSnapshot{}, storage.ErrRevalidationDisabled
+	}
+	sourceID, err := storage.GetScanTaskRunDerivationSource(options.DB, options.Run.ID)
+	if err != nil {
+		return model.ScanTaskRunSnapshot{}, err
+	}
+	if sourceID <= 0 {
+		return model.ScanTaskRunSnapshot{}, fmt.Errorf("revalidate run %d has no source run", options.Run.ID)
+	}
+	source, err := storage.GetScanTaskRunSnapshot(options.DB, sourceID)
+	if err != nil {
+		return model.ScanTaskRunSnapshot{}, fmt.Errorf("load source run %d: %w", sourceID, err)
+	}
+	matches, err := storage.LoadFingerprintRunMatches(options.DB, sourceID)
+	if err != nil {
+		return model.ScanTaskRunSnapshot{}, fmt.Errorf("load source run %d fingerprint matches: %w", sourceID, err)
+	}
+	// The copied matches keep every observation; validation acts only on the
+	// products no suppression ruled out.
+	validationMatches, err := storage.WithoutSuppressedFingerprintMatches(options.DB, matches)
+	if err != nil {
+		return model.ScanTaskRunSnapshot{}, err
+	}
+	if err := checkCanceled(ctx, options.CheckCanceled); err != nil {
+		return model.ScanTaskRunSnapshot{}, err
+	}
+	if err := updateProgress(options.UpdateProgress, 10); err != nil {
+		return model.ScanTaskRunSnapshot{}, err
+	}
+	snapshot := model.ScanTaskRunSnapshot{
+		RunID:              options.Run.ID,
+		Hosts:              source.Hosts,
+		Ports:              source.Ports,
+		ProtocolEvidence:   source.ProtocolEvidence,
+		Vulnerabilities:    make([]model.ScanTaskRunVulnerability, 0),
+		TemplateCandidates: make([]model.ScanTaskRunTemplateCandidate, 0),
+		FingerprintMatches: matches,
+		HostIdentities:     source.HostIdentities,
+		SSHHostKeys:        source.SSHHostKeys,
+		SNMPSystems:        source.SNMPSystems,
+		WebAssets:          source.WebAssets,
+		Weaknesses:         source.Weaknesses,
+		TLSFingerprints:    source.TLSFingerprints,
+		Validation:         initialRunValidation(options.Run.Config.VulnerabilityOn),
+	}
+	hosts, ips := snapshotHostResults(source.Ports)
+	validation := newRunValidationTracker()
+	native := nativeValidationEnabled(options.Run)
+	templateRoot := options.Run.Config.NucleiTemplates
+	var templateIndex *planner.NucleiTemplateIndex
+	if !native {
+		templateRoot, templateIndex, err = dependencies.loadTemplateIndex(options.Run.Config.NucleiTemplates)
+		if err != nil {
+			for _, ip := range ips {
+				validation.register(ip, hosts[ip], validationMatches)
+			}
+			validation.failAll(err)
+			validation.finish(&snapshot, err)
+			return snapshot, err
+		}
+	}
+	for index, ip := range ips {
+		if err := checkCanceled(ctx, options.CheckCanceled); err != nil {
+			return snapshot, err
+		}
+		openPorts := hosts[ip]
+		validation.register(ip, openPorts, validationMatches)
+		if native {
+			nativeResult := runNativeCheckValidation(ctx, ip, openPorts, validationMatches, dependencies.nativeValidator)
+			validation.observe(nativeResult)
+			snapshot.TemplateCandidates = uniqueTemplateCandidates(append(snapshot.TemplateCandidates, nativeResult.candidates...))
+			snapshot.Vulnerabilities = uniqueSnapshotVulnerabilities(append(snapshot.Vulnerabilities, snapshotVulnerabilities(nativeResult.findings)...))
+		} else {
+			mappingResult := runFingerprintMappingValidation(ctx, options.DB, options.Run, ip, openPorts, validationMatches, templateRoot, templateIndex, materializedTemplateExecutor(dependencies.templateValidator))
+			validation.observe(mappingResult)
+			if mappingResult.err != nil && !errors.Is(mappingResult.err, vuln.ErrNoTemplates) {
+				snapshot.TemplateCandidates = uniqueTemplateCandidates(append(snapshot.TemplateCandidates, mappingResult.candidates...))
+				snapshot.Vulnerabilities = uniqueSnapshotVulnerabilities(append(snapshot.Vulnerabilities, snapshotVulnerabilities(mappingResult.findings)...))
+				validation.finish(&snapshot, mappingResult.err)
+				return snapshot, mappingResult.err
+			}
+			fallbackResult := runServiceTagValidation(ctx, ip, portsWithoutFingerprintMappings(openPorts, mappingResult.candidates, validationMatches), templateIndex, materializedTemplateExecutor(dependencies.templateValidator))
+			validation.observe(fallbackResult)
+			allCandidates := append(mappingResult.candidates, fallbackResult.candidates...)
+			allFindings := append(mappingResult.findings, fallbackResult.findings...)
+			snapshot.TemplateCandidates = uniqueTemplateCandidates(append(snapshot.TemplateCandidates, allCandidates...))
+			snapshot.Vulnerabilities = uniqueSnapshotVulnerabilities(append(snapshot.Vulnerabilities, snapshotVulnerabilities(allFindings)...))
+			if fallbackResult.err != nil && !errors.Is(fallbackResult.err, vuln.ErrNoTemplates) {
+				validation.finish(&snapshot, fallbackResult.err)
+				return snapshot, fallbackResult.err
+			}
+		}
+		progress := 10 + int(float64(index+1)/float64(len(ips))*90)
+		if err := updateProgress(options.UpdateProgress, progress); err != nil {
+			return snapshot, err
+		}
+	}
+	validation.finish(&snapshot, nil)
+	if err := updateProgress(options.UpdateProgress, 100); err != nil {
+		return snapshot, err
+	}
+	return snapshot, nil
+}
+
+// snapshotHostResults rebuilds the per-host open port results validation
+// consumes from the ports recorded in a run snapshot.
+func snapshotHostResults(ports []model.ScanTaskRunPort) (map[string][]model.ScanResult, []string) {
+	hosts := make(map[string][]model.ScanResult)
+	for _, port := range ports {
+		address := net.JoinHostPort(port.IP, strconv.Itoa(port.Port))
+		hosts[port.IP] = append(hosts[port.IP], model.ScanResult{Address: address, Open: true, Service: port.ServiceType, Product: port.Product})
+	}
+	ips := make([]string, 0, len(hosts))
+	for ip := range hosts {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+	return hosts, ips
+}
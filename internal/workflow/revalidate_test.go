@@ -0,0 +1,100 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"golandproject/yscan/internal/diff"
+	"golandproject/yscan/internal/model"
+	"golandproject/yscan/internal/storage"
+	"golandproject/yscan/internal/vuln"
+)
+
+func TestRevalidateRunReusesSourceEndpointsAndDiffsFindingsOnly(t *testing.T) {
+	const ip = "192.168.79.10"
+	db := openFullWorkflowDB(t)
+	root, _ := workflowTemplateIndexFixture(t, workflowTemplateSpec{id: "redis-safe", product: "redis", protocol: "tcp"})
+	task, err := storage.CreateScanTask(db, model.ScanTask{Target: "192.168.79.0/28", ScanType: model.ScanTypeSubnet, Mode: model.ScanTaskModeOnce, Config: model.ScanTaskConfig{VulnerabilityOn: true, NucleiTemplates: root}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale := model.ScanTaskRunVulnerability{FindingKey: "redis-old|" + ip + ":6379|" + ip + "|6379", TemplateID: "redis-old", Severity: "medium", Target: ip + ":6379", TargetIP: ip, TargetPort: 6379}
+	source := createPlannedRun(t, db, task.ID, model.ScanTaskRunSnapshot{
+		Hosts:           []model.ScanTaskRunHost{{IP: ip, IsActive: true}},
+		Ports:           []model.ScanTaskRunPort{{IP: ip, Port: 6379, ServiceType: "redis"}},
+		Vulnerabilities: []model.ScanTaskRunVulnerability{stale},
+	})
+
+	run, err := storage.CreateRevalidationScanTaskRun(db, source.ID)
+	if err != nil {
+		t.Fatalf("create revalidation run: %v", err)
+	}
+	if run.Trigger != model.ScanTaskRunTriggerRevalidate || run.Status != model.ScanTaskRunStatusQueued || run.Target != source.Target {
+		t.Fatalf("revalidation run=%#v", run)
+	}
+	if linked, err := storage.GetScanTaskRunDerivationSource(db, run.ID); err != nil || linked != source.ID {
+		t.Fatalf("derivation source=%d err=%v", linked, err)
+	}
+
+	executions := 0
+	snapshot, err := runRevalidateTaskRun(context.Background(), RevalidateTaskRunOptions{DB: db, Run: run}, revalidateDependencies{
+		loadTemplateIndex: loadNucleiTemplateIndex,
+		templateValidator: vuln.ValidatorFunc(func(_ context.Context, target string, ports []model.ScanResult, _ []string) vuln.NucleiExecutionResult {
+			executions++
+			return vuln.NucleiExecutionResult{Started: true, Executed: true, Findings: []model.NucleiFinding{{TemplateID: "redis-safe", Severity: "high", Target: ports[0].Address, TargetIP: target, TargetPort: 6379}}}
+		}),
+	})
+	if err != nil {
+		t.Fatalf("revalidate: %v", err)
+	}
+	if executions != 1 || len(snapshot.Ports) != 1 || len(snapshot.Vulnerabilities) != 1 || snapshot.Vulnerabilities[0].TemplateID != "redis-safe" || snapshot.Validation.Status != model.ScanTaskRunValidationSuccess {
+		t.Fatalf("executions=%d snapshot=%#v", executions, snapshot)
+	}
+
+	if _, err := db.Exec(`UPDATE scan_task_runs SET status = ? WHERE id = ?`, model.ScanTaskRunStatusRunning, run.ID); err != nil {
+		t.Fatal(err)
+	}
+	snapshot.RunID = run.ID
+	if err := storage.SaveScanTaskRunSnapshot(db, snapshot); err != nil {
+		t.Fatalf("save revalidation snapshot: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE scan_task_runs SET status = ? WHERE id = ?`, model.ScanTaskRunStatusSuccess, run.ID); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := diff.CompareRunWithPreviousSuccess(db, run.ID)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if !changes.FindingsOnly || changes.BaselineRunID != source.ID || len(changes.VulnerabilityChanges.New) != 1 || changes.VulnerabilityChanges.New[0].TemplateID != "redis-safe" ||
+		len(changes.VulnerabilityChanges.Resolved) != 1 || changes.VulnerabilityChanges.Resolved[0].TemplateID != "redis-old" || len(changes.PortChanges.Opened) != 0 {
+		t.Fatalf("revalidation changes=%#v", changes)
+	}
+	if explicit, err := diff.CompareScanTaskRuns(db, source.ID, run.ID); err != nil || !explicit.FindingsOnly {
+		t.Fatalf("source baseline changes=%#v err=%v", explicit, err)
+	}
+	// A sibling revalidation of the same source is not run's source.
+	sibling, err := storage.CreateRevalidationScanTaskRun(db, source.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE scan_task_runs SET status = ? WHERE id = ?`, model.ScanTaskRunStatusRunning, sibling.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.SaveScanTaskRunSnapshot(db, model.ScanTaskRunSnapshot{RunID: sibling.ID, Hosts: snapshot.Hosts}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE scan_task_runs SET status = ? WHERE id = ?`, model.ScanTaskRunStatusSuccess, sibling.ID); err != nil {
+		t.Fatal(err)
+	}
+	if full, err := diff.CompareScanTaskRuns(db, sibling.ID, run.ID); err != nil || full.FindingsOnly || len(full.PortChanges.Opened) != 1 {
+		t.Fatalf("sibling baseline changes=%#v err=%v", full, err)
+	}
+
+	if _, err := db.Exec(`UPDATE scan_tasks SET config_json = '{}' WHERE id = ?`, task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.CreateRevalidationScanTaskRun(db, source.ID); !errors.Is(err, storage.ErrRevalidationDisabled) {
+		t.Fatalf("revalidation without validation err=%v", err)
+	}
+}
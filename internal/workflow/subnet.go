@@ -62,15 +62,20 @@ func (executor SubnetTaskRunExecutor) Execute(ctx context.Context, run model.Sca
 }
 
 type subnetDependencies struct {
-	discover             func(context.Context, string, pipeline.SubnetDiscoveryOptions) ([]string, error)
-	scanHost             func(context.Context, string, string) ([]model.ScanResult, error)
-	scanSelected         func(context.Context, string, string, []int) ([]model.ScanResult, error)
-	collectFingerprints  func(context.Context, *sql.DB, model.ScanTaskRun, string, []model.ScanResult) ([]model.ScanResult, []model.FingerprintRunMatch, error)
-	runNuclei            func(context.Context, string, []model.ScanResult, string, []string) ([]model.NucleiFinding, error)
-	executeNuclei        func(context.Context, string, []model.ScanResult, string, []string) vuln.NucleiExecutionResult
-	loadTemplateIndex    func(string) (string, *planner.NucleiTemplateIndex, error)
-	executeTemplatePaths func(context.Context, string, []model.ScanResult, []string) vuln.NucleiExecutionResult
-	saveFindings         func(*sql.DB, int64, []model.NucleiFinding) error
+	discover            func(context.Context, string, pipeline.SubnetDiscoveryOptions) ([]string, error)
+	scanHost            func(context.Context, string, string) ([]model.ScanResult, error)
+	scanSelected        func(context.Context, string, string, []int) ([]model.ScanResult, error)
+	collectFingerprints func(context.Context, *sql.DB, model.ScanTaskRun, string, []model.ScanResult) ([]model.ScanResult, []model.FingerprintRunMatch, error)
+	collectIdentities   func(context.Context, string, []model.ScanResult) []model.ScanTaskRunHostIdentity
+	collectHostKeys     func(context.Context, string, []model.ScanResult) []model.ScanTaskRunSSHHostKey
+	collectSNMP         func(context.Context, string, string) (*model.ScanTaskRunSNMPSystem, error)
+	matchSNMP           func(*sql.DB, model.ScanTaskRun, model.ScanTaskRunSNMPSystem) ([]model.FingerprintRunMatch, error)
+	runNuclei           func(context.Context, string, []model.ScanResult, string, []string) ([]model.NucleiFinding, error)
+	executeNuclei       func(context.Context, string, []model.ScanResult, string, []string) vuln.NucleiExecutionResult
+	loadTemplateIndex   func(string) (string, *planner.NucleiTemplateIndex, error)
+	templateValidator   vuln.Validator
+	nativeValidator     vuln.Validator
+	saveFindings        func(*sql.DB, int64, []model.NucleiFinding) error
 }
 
 // RunSubnet executes discovery, quick profiling, optional vulnerability
@@ -99,14 +104,19 @@ func RunSubnetTaskRun(ctx context.Context, options SubnetTaskRunOptions) (model.
 		return model.ScanTaskRunSnapshot{}, err
 	}
 	return runSubnetTaskRun(ctx, options, subnetDependencies{
-		discover:             pipeline.DiscoverAliveHosts,
-		scanHost:             scan.RunQuickDiscovery,
-		scanSelected:         scan.RunSelectedDiscovery,
-		collectFingerprints:  collector,
-		runNuclei:            vuln.RunNucleiForOpenPortsWithTags,
-		executeNuclei:        vuln.ExecuteNucleiForOpenPortsWithTags,
-		loadTemplateIndex:    loadNucleiTemplateIndex,
-		executeTemplatePaths: vuln.ExecuteNucleiForOpenPortsWithTemplatePaths,
+		discover:            pipeline.DiscoverAliveHosts,
+		scanHost:            scan.RunQuickDiscovery,
+		scanSelected:        scan.RunSelectedDiscovery,
+		collectFingerprints: collector,
+		collectIdentities:   collectHostIdentities,
+		collectHostKeys:     collectSSHHostKeys,
+		collectSNMP:         collectSNMPSystem,
+		matchSNMP:           matchSNMPSystemFingerprints,
+		runNuclei:           vuln.RunNucleiForOpenPortsWithTags,
+		executeNuclei:       vuln.ExecuteNucleiForOpenPortsWithTags,
+		loadTemplateIndex:   loadNucleiTemplateIndex,
+		templateValidator:   vuln.NucleiValidator{},
+		nativeValidator:     vuln.NativeValidator{},
 	})
 }
 
@@ -136,7 +146,7 @@ func runSubnetTaskRun(ctx context.Context, options SubnetTaskRunOptions, depende
 	if strings.TrimSpace(options.Network) == "" {
 		options.Network = "tcp"
 	}
-	if dependencies.discover == nil || dependencies.scanHost == nil || (dependencies.runNuclei == nil && dependencies.executeNuclei == nil && dependencies.executeTemplatePaths == nil) {
+	if dependencies.discover == nil || dependencies.scanHost == nil || (dependencies.runNuclei == nil && dependencies.executeNuclei == nil && dependencies.templateValidator == nil) {
 		return model.ScanTaskRunSnapshot{}, errors.New("subnet task run dependencies are required")
 	}
 	if err := checkCanceled(ctx, options.CheckCanceled); err != nil {
@@ -211,6 +221,9 @@ func runSubnetTaskRun(ctx context.Context, options SubnetTaskRunOptions, depende
 		if err != nil {
 			snapshot.Ports = uniqueSnapshotPorts(append(snapshot.Ports, snapshotPorts(ip, openPorts)...))
 			snapshot.ProtocolEvidence = uniqueProtocolEvidence(append(snapshot.ProtocolEvidence, snapshotProtocolEvidence(ip, openPorts)...))
+			snapshot.WebAssets = append(snapshot.WebAssets, snapshotWebAssets(ip, openPorts)...)
+			snapshot.Weaknesses = append(snapshot.Weaknesses, snapshotWeaknesses(ip, openPorts)...)
+			snapshot.TLSFingerprints = append(snapshot.TLSFingerprints, snapshotTLSFingerprints(ip, openPorts)...)
 			return snapshot, err
 		}
 		if dependencies.collectFingerprints != nil {
@@ -218,6 +231,10 @@ func runSubnetTaskRun(ctx context.Context, options SubnetTaskRunOptions, depende
 			openPorts, matches, err = dependencies.collectFingerprints(ctx, options.DB, options.Run, ip, openPorts)
 			snapshot.Ports = append(snapshot.Ports, snapshotPorts(ip, openPorts)...)
 			snapshot.ProtocolEvidence = append(snapshot.ProtocolEvidence, snapshotProtocolEvidence(ip, openPorts)...)
+			snapshot.WebAssets = append(snapshot.WebAssets, snapshotWebAssets(ip, openPorts)...)
+			snapshot.Weaknesses = append(snapshot.Weaknesses, snapshotWeaknesses(ip, openPorts)...)
+			snapshot.TLSFingerprints = append(snapshot.TLSFingerprints, snapshotTLSFingerprints(ip, openPorts)...)
+			snapshot.RawEvidence = append(snapshot.RawEvidence, snapshotRawEvidence(openPorts)...)
 			snapshot.FingerprintMatches = append(snapshot.FingerprintMatches, matches...)
 			if err != nil {
 				return snapshot, err
@@ -230,9 +247,43 @@ func runSubnetTaskRun(ctx context.Context, options SubnetTaskRunOptions, depende
 			snapshot.Ports = append(snapshot.Ports, snapshotPorts(ip, openPorts)...)
 			snapshot.ProtocolEvidence = append(snapshot.ProtocolEvidence, snapshotProtocolEvidence(ip, openPorts)...)
 		}
+		if dependencies.collectIdentities != nil {
+			snapshot.HostIdentities = append(snapshot.HostIdentities, dependencies.collectIdentities(ctx, ip, openPorts)...)
+		}
+		if dependencies.collectHostKeys != nil {
+			snapshot.SSHHostKeys = append(snapshot.SSHHostKeys, dependencies.collectHostKeys(ctx, ip, openPorts)...)
+		}
+		if dependencies.collectSNMP != nil && options.Run.Config.SNMPCredential != "" {
+			system, err := dependencies.collectSNMP(ctx, options.Run.Config.SNMPCredential, ip)
+			if err != nil {
+				return snapshot, err
+			}
+			if system != nil {
+				snapshot.SNMPSystems = append(snapshot.SNMPSystems, *system)
+				if dependencies.matchSNMP != nil {
+					matches, err := dependencies.matchSNMP(options.DB, options.Run, *system)
+					if err != nil {
+						return snapshot, err
+					}
+					snapshot.FingerprintMatches = append(snapshot.FingerprintMatches, matches...)
+				}
+			}
+		}
 
+		var validationMatches []model.FingerprintRunMatch
 		if options.Run.Config.VulnerabilityOn {
-			validation.register(ip, openPorts, snapshot.FingerprintMatches)
+			if validationMatches, err = unsuppressedMatches(options.DB, ip, snapshot.FingerprintMatches); err != nil {
+				return snapshot, err
+			}
+		}
+		if options.Run.Config.VulnerabilityOn && nativeValidationEnabled(options.Run) {
+			validation.register(ip, openPorts, validationMatches)
+			nativeResult := runNativeCheckValidation(ctx, ip, openPorts, validationMatches, dependencies.nativeValidator)
+			validation.observe(nativeResult)
+			snapshot.TemplateCandidates = uniqueTemplateCandidates(append(snapshot.TemplateCandidates, nativeResult.candidates...))
+			snapshot.Vulnerabilities = uniqueSnapshotVulnerabilities(append(snapshot.Vulnerabilities, snapshotVulnerabilities(nativeResult.findings)...))
+		} else if options.Run.Config.VulnerabilityOn {
+			validation.register(ip, openPorts, validationMatches)
 			if dependencies.loadTemplateIndex != nil && !templateIndexLoaded {
 				templateRoot, templateIndex, err = dependencies.loadTemplateIndex(options.Run.Config.NucleiTemplates)
 				templateIndexLoaded = true
@@ -242,7 +293,7 @@ func runSubnetTaskRun(ctx context.Context, options SubnetTaskRunOptions, depende
 					return snapshot, err
 				}
 			}
-			mappingResult := runFingerprintMappingValidation(ctx, options.DB, options.Run, ip, openPorts, snapshot.FingerprintMatches, templateRoot, templateIndex, dependencies.executeTemplatePaths)
+			mappingResult := runFingerprintMappingValidation(ctx, options.DB, options.Run, ip, openPorts, validationMatches, templateRoot, templateIndex, materializedTemplateExecutor(dependencies.templateValidator))
 			validation.observe(mappingResult)
 			snapshot.TemplateCandidates = uniqueTemplateCandidates(append(snapshot.TemplateCandidates, mappingResult.candidates...))
 			snapshot.Vulnerabilities = uniqueSnapshotVulnerabilities(append(snapshot.Vulnerabilities, snapshotVulnerabilities(mappingResult.findings)...))
@@ -250,7 +301,7 @@ func runSubnetTaskRun(ctx context.Context, options SubnetTaskRunOptions, depende
 				validation.finish(&snapshot, mappingResult.err)
 				return snapshot, mappingResult.err
 			}
-			fallbackResult := runServiceTagValidation(ctx, ip, portsWithoutFingerprintMappings(openPorts, mappingResult.candidates, snapshot.FingerprintMatches), templateIndex, dependencies.executeTemplatePaths)
+			fallbackResult := runServiceTagValidation(ctx, ip, portsWithoutFingerprintMappings(openPorts, mappingResult.candidates, validationMatches), templateIndex, materializedTemplateExecutor(dependencies.templateValidator))
 			validation.observe(fallbackResult)
 			allCandidates := append(mappingResult.candidates, fallbackResult.candidates...)
 			allFindings := append(mappingResult.findings, fallbackResult.findings...)
@@ -334,10 +385,22 @@ func (result *validationExecutionResult) observeExecution(candidates []model.Sca
 	}
 }
 
-func runFingerprintMappingValidation(ctx context.Context, db *sql.DB, run model.ScanTaskRun, ip string, ports []model.ScanResult, matches []model.FingerprintRunMatch, templatesRoot string, templateIndex *planner.NucleiTemplateIndex, executeTemplatePaths func(context.Context, string, []model.ScanResult, []string) vuln.NucleiExecutionResult) validationExecutionResult {
+// unsuppressedMatches returns the matches of ip that validation may act on:
+// a product an operator suppressed selects no templates or native checks.
+func unsuppressedMatches(db *sql.DB, ip string, matches []model.FingerprintRunMatch) ([]model.FingerprintRunMatch, error) {
+	hostMatches := make([]model.FingerprintRunMatch, 0)
+	for _, match := range matches {
+		if match.IP == ip {
+			hostMatches = append(hostMatches, match)
+		}
+	}
+	return storage.WithoutSuppressedFingerprintMatches(db, hostMatches)
+}
+
+func runFingerprintMappingValidation(ctx context.Context, db *sql.DB, run model.ScanTaskRun, ip string, ports []model.ScanResult, matches []model.FingerprintRunMatch, templatesRoot string, templateIndex *planner.NucleiTemplateIndex, execute pinnedTemplateExecutor) validationExecutionResult {
 	result := validationExecutionResult{identifiedProducts: make(map[string]struct{}), mappedProducts: make(map[string]struct{})}
-	if executeTemplatePaths == nil {
-		executeTemplatePaths = vuln.ExecuteNucleiForOpenPortsWithTemplatePaths
+	if execute == nil {
+		execute = materializedTemplateExecutor(nil)
 	}
 	portResults := make(map[int]model.ScanResult)
 	for _, portResult := range ports {
@@ -401,7 +464,7 @@ func runFingerprintMappingValidation(ctx context.Context, db *sql.DB, run model.
 				pinnedTemplates = append(pinnedTemplates, candidate.Pinned)
 			}
 			if len(pinnedTemplates) > 0 {
-				execution := executePinnedNucleiTemplates(ctx, ip, []model.ScanResult{portResult}, pinnedTemplates, executeTemplatePaths)
+				execution := execute(ctx, ip, []model.ScanResult{portResult}, pinnedTemplates)
 				result.findings = append(result.findings, execution.Findings...)
 				if execution.Executed {
 					markTemplateCandidatesExecuted(invocationCandidates)
@@ -467,7 +530,7 @@ func runFingerprintMappingValidation(ctx context.Context, db *sql.DB, run model.
 		sort.Strings(invocationKeys)
 		for _, key := range invocationKeys {
 			invocation := invocations[key]
-			execution := executePinnedNucleiTemplates(ctx, ip, []model.ScanResult{invocation.port}, invocation.templates, executeTemplatePaths)
+			execution := execute(ctx, ip, []model.ScanResult{invocation.port}, invocation.templates)
 			result.findings = append(result.findings, execution.Findings...)
 			if execution.Executed {
 				markTemplateCandidatesExecuted(invocation.candidates)
@@ -479,13 +542,27 @@ func runFingerprintMappingValidation(ctx context.Context, db *sql.DB, run model.
 	return result
 }
 
-func executePinnedNucleiTemplates(ctx context.Context, ip string, ports []model.ScanResult, templates []planner.PinnedNucleiTemplate, execute func(context.Context, string, []model.ScanResult, []string) vuln.NucleiExecutionResult) vuln.NucleiExecutionResult {
+// pinnedTemplateExecutor runs one Nuclei invocation over pinned templates.
+type pinnedTemplateExecutor func(context.Context, string, []model.ScanResult, []planner.PinnedNucleiTemplate) vuln.NucleiExecutionResult
+
+// materializedTemplateExecutor writes the pinned templates to a private
+// snapshot directory for each invocation and validates with their paths.
+func materializedTemplateExecutor(validator vuln.Validator) pinnedTemplateExecutor {
+	if validator == nil {
+		validator = vuln.NucleiValidator{}
+	}
+	return func(ctx context.Context, ip string, ports []model.ScanResult, templates []planner.PinnedNucleiTemplate) vuln.NucleiExecutionResult {
+		return executePinnedNucleiTemplates(ctx, ip, ports, templates, validator)
+	}
+}
+
+func executePinnedNucleiTemplates(ctx context.Context, ip string, ports []model.ScanResult, templates []planner.PinnedNucleiTemplate, validator vuln.Validator) vuln.NucleiExecutionResult {
 	snapshot, err := planner.MaterializePinnedNucleiTemplates(templates)
 	if err != nil {
 		return vuln.NucleiExecutionResult{Err: err}
 	}
 	defer snapshot.Close()
-	return execute(ctx, ip, ports, snapshot.Paths)
+	return validator.Validate(ctx, ip, ports, snapshot.Paths)
 }
 
 func validationProductIdentity(ip string, port int, protocol, product string) string {
@@ -537,13 +614,13 @@ func portsWithoutFingerprintMappings(ports []model.ScanResult, candidates []mode
 	return result
 }
 
-func runServiceTagValidation(ctx context.Context, ip string, ports []model.ScanResult, templateIndex *planner.NucleiTemplateIndex, executeTemplatePaths func(context.Context, string, []model.ScanResult, []string) vuln.NucleiExecutionResult) validationExecutionResult {
+func runServiceTagValidation(ctx context.Context, ip string, ports []model.ScanResult, templateIndex *planner.NucleiTemplateIndex, execute pinnedTemplateExecutor) validationExecutionResult {
 	result := validationExecutionResult{}
 	if templateIndex == nil {
 		return result
 	}
-	if executeTemplatePaths == nil {
-		executeTemplatePaths = vuln.ExecuteNucleiForOpenPortsWithTemplatePaths
+	if execute == nil {
+		execute = materializedTemplateExecutor(nil)
 	}
 	for _, port := range ports {
 		if !port.Open {
@@ -573,7 +650,7 @@ func runServiceTagValidation(ctx context.Context, ip string, ports []model.ScanR
 				IP: ip, Port: portNumber, Protocol: protocol,
 			})
 		}
-		execution := executePinnedNucleiTemplates(ctx, ip, []model.ScanResult{port}, pinned, executeTemplatePaths)
+		execution := execute(ctx, ip, []model.ScanResult{port}, pinned)
 		result.findings = append(result.findings, execution.Findings...)
 		if execution.Executed {
 			markTemplateCandidatesExecuted(invocationCandidates)
@@ -874,6 +951,10 @@ func validationErrorReason(err error) string {
 		return model.ValidationReasonNucleiMissing
 	case errors.Is(err, vuln.ErrTemplateDirectoryMissing):
 		return model.ValidationReasonTemplateDirectory
+	case errors.Is(err, vuln.ErrNucleiTimeout):
+		return model.ValidationReasonExecutionTimeout
+	case errors.Is(err, vuln.ErrNucleiMemoryLimit):
+		return model.ValidationReasonMemoryLimit
 	default:
 		return model.ValidationReasonExecutionFailed
 	}
@@ -1162,6 +1243,68 @@ func snapshotProtocolEvidence(ip string, results []model.ScanResult) []model.Sca
 	return observations
 }
 
+// snapshotWebAssets attaches crawled scripts and stylesheets to the endpoint
+// that referenced them.
+func snapshotWebAssets(ip string, results []model.ScanResult) []model.ScanTaskRunWebAsset {
+	assets := make([]model.ScanTaskRunWebAsset, 0)
+	for _, result := range results {
+		port, ok := scanResultPort(ip, result)
+		if !ok {
+			continue
+		}
+		for _, asset := range result.WebAssets {
+			asset.IP = ip
+			asset.Port = port
+			assets = append(assets, asset)
+		}
+	}
+	return assets
+}
+
+// snapshotWeaknesses attaches web posture findings to their endpoint.
+func snapshotWeaknesses(ip string, results []model.ScanResult) []model.ScanTaskRunWeakness {
+	weaknesses := make([]model.ScanTaskRunWeakness, 0)
+	for _, result := range results {
+		port, ok := scanResultPort(ip, result)
+		if !ok {
+			continue
+		}
+		for _, weakness := range result.Weaknesses {
+			weakness.IP = ip
+			weakness.Port = port
+			weaknesses = append(weaknesses, weakness)
+		}
+	}
+	return weaknesses
+}
+
+// snapshotTLSFingerprints attaches TLS server fingerprints to their endpoint.
+func snapshotTLSFingerprints(ip string, results []model.ScanResult) []model.ScanTaskRunTLSFingerprint {
+	fingerprints := make([]model.ScanTaskRunTLSFingerprint, 0)
+	for _, result := range results {
+		port, ok := scanResultPort(ip, result)
+		if !ok {
+			continue
+		}
+		for _, fingerprint := range result.TLSFingerprints {
+			fingerprint.IP = ip
+			fingerprint.Port = port
+			fingerprints = append(fingerprints, fingerprint)
+		}
+	}
+	return fingerprints
+}
+
+// snapshotRawEvidence gathers sealed fingerprint inputs; each record already
+// names its endpoint.
+func snapshotRawEvidence(results []model.ScanResult) []model.ScanTaskRunRawEvidence {
+	records := make([]model.ScanTaskRunRawEvidence, 0)
+	for _, result := range results {
+		records = append(records, result.RawEvidence...)
+	}
+	return records
+}
+
 func uniqueProtocolEvidence(observations []model.ScanTaskRunProtocolEvidence) []model.ScanTaskRunProtocolEvidence {
 	byKey := make(map[string]model.ScanTaskRunProtocolEvidence, len(observations))
 	for _, observation := range observations {
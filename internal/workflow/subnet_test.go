@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -511,9 +512,9 @@ func TestRunSubnetTaskRunKeepsObservationsWhenNucleiFails(t *testing.T) {
 			return results, []model.FingerprintRunMatch{{IP: host, Port: 6379, Protocol: "tcp", Product: "redis"}}, nil
 		},
 		loadTemplateIndex: func(string) (string, *planner.NucleiTemplateIndex, error) { return root, templateIndex, nil },
-		executeTemplatePaths: func(_ context.Context, host string, _ []model.ScanResult, _ []string) vuln.NucleiExecutionResult {
+		templateValidator: vuln.ValidatorFunc(func(_ context.Context, host string, _ []model.ScanResult, _ []string) vuln.NucleiExecutionResult {
 			return vuln.NucleiExecutionResult{Started: true, Executed: true, Findings: []model.NucleiFinding{{TemplateID: "redis-partial", Target: host + ":6379", TargetIP: host, TargetPort: 6379}}, Err: nucleiErr}
-		},
+		}),
 	})
 	if err != nil {
 		t.Fatalf("endpoint-level nuclei failure must not abort later endpoints: %v", err)
@@ -580,14 +581,14 @@ tcp:
 	match := model.FingerprintRunMatch{IP: ip, Port: 6379, Protocol: "tcp", Product: "redis", CPE: "cpe:2.3:a:redislabs:redis:*:*:*:*:*:*:*:*"}
 	executions := 0
 	result := runFingerprintMappingValidation(context.Background(), db, model.ScanTaskRun{ID: 77}, ip, []model.ScanResult{port}, []model.FingerprintRunMatch{match}, root, index,
-		func(_ context.Context, target string, ports []model.ScanResult, paths []string) vuln.NucleiExecutionResult {
+		materializedTemplateExecutor(vuln.ValidatorFunc(func(_ context.Context, target string, ports []model.ScanResult, paths []string) vuln.NucleiExecutionResult {
 			executions++
 			content, readErr := os.ReadFile(paths[0])
 			if target != ip || len(ports) != 1 || len(paths) != 1 || paths[0] == templatePath || readErr != nil || !strings.Contains(string(content), "id: exposed-redis") {
 				t.Fatalf("automatic invocation target=%s ports=%#v paths=%#v", target, ports, paths)
 			}
 			return vuln.NucleiExecutionResult{Started: true, Executed: true, Findings: []model.NucleiFinding{{TemplateID: "exposed-redis", Name: "Redis Exposure", Target: ip + ":6379", TargetIP: ip, TargetPort: 6379}}}
-		})
+		})))
 	if result.err != nil || executions != 1 || len(result.candidates) != 1 || result.candidates[0].Source != "automatic_template_index" || result.candidates[0].ProductKey != "redis" || result.candidates[0].TemplateSHA256 == "" || result.candidates[0].TemplateSetRevision != index.Revision {
 		t.Fatalf("automatic mapping result=%#v executions=%d", result, executions)
 	}
@@ -605,21 +606,21 @@ func TestServiceFallbackExecutesOnlyStrictPinnedIndexEntries(t *testing.T) {
 	root, index := workflowTemplateIndexFixture(t, workflowTemplateSpec{id: "redis-safe", product: "redis", protocol: "tcp"})
 	executions := 0
 	result := runServiceTagValidation(context.Background(), ip, []model.ScanResult{{Address: ip + ":6379", Open: true, Service: "redis"}}, index,
-		func(_ context.Context, _ string, _ []model.ScanResult, paths []string) vuln.NucleiExecutionResult {
+		materializedTemplateExecutor(vuln.ValidatorFunc(func(_ context.Context, _ string, _ []model.ScanResult, paths []string) vuln.NucleiExecutionResult {
 			executions++
 			if len(paths) != 1 || strings.HasPrefix(paths[0], root) {
 				t.Fatalf("service fallback received mutable or unexpected paths: %#v", paths)
 			}
 			return vuln.NucleiExecutionResult{Started: true, Executed: true}
-		})
+		})))
 	if executions != 1 || len(result.candidates) != 1 || result.candidates[0].TemplateID != "redis-safe" || result.candidates[0].Source != "service_tag" || !result.candidates[0].Executed {
 		t.Fatalf("strict service fallback result=%#v executions=%d", result, executions)
 	}
 	result = runServiceTagValidation(context.Background(), ip, []model.ScanResult{{Address: ip + ":8080", Open: true, Service: "http"}}, index,
-		func(context.Context, string, []model.ScanResult, []string) vuln.NucleiExecutionResult {
+		materializedTemplateExecutor(vuln.ValidatorFunc(func(context.Context, string, []model.ScanResult, []string) vuln.NucleiExecutionResult {
 			t.Fatal("generic HTTP service must not select templates")
 			return vuln.NucleiExecutionResult{}
-		})
+		})))
 	if len(result.candidates) != 0 {
 		t.Fatalf("generic service candidates=%#v", result.candidates)
 	}
@@ -635,6 +636,8 @@ func TestValidationErrorReasonsUseTypedDependencies(t *testing.T) {
 		{planner.ErrPinnedTemplateMissing, model.ValidationReasonTemplateMissing},
 		{vuln.ErrNucleiMissing, model.ValidationReasonNucleiMissing},
 		{vuln.ErrTemplateDirectoryMissing, model.ValidationReasonTemplateDirectory},
+		{fmt.Errorf("%w after 30m0s", vuln.ErrNucleiTimeout), model.ValidationReasonExecutionTimeout},
+		{fmt.Errorf("%w of 2048 MiB", vuln.ErrNucleiMemoryLimit), model.ValidationReasonMemoryLimit},
 		{errors.New("fixture"), model.ValidationReasonExecutionFailed},
 	}
 	for _, test := range tests {
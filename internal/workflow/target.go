@@ -41,13 +41,18 @@ func (executor TargetTaskRunExecutor) Execute(ctx context.Context, run model.Sca
 }
 
 type targetDependencies struct {
-	scanHost             func(context.Context, string, string) (scan.PortScanOutcome, error)
-	scanSelected         func(context.Context, string, string, []int) (scan.PortScanOutcome, error)
-	collectFingerprints  func(context.Context, *sql.DB, model.ScanTaskRun, string, []model.ScanResult) ([]model.ScanResult, []model.FingerprintRunMatch, error)
-	runNuclei            func(context.Context, string, []model.ScanResult, string, []string) ([]model.NucleiFinding, error)
-	executeNuclei        func(context.Context, string, []model.ScanResult, string, []string) vuln.NucleiExecutionResult
-	loadTemplateIndex    func(string) (string, *planner.NucleiTemplateIndex, error)
-	executeTemplatePaths func(context.Context, string, []model.ScanResult, []string) vuln.NucleiExecutionResult
+	scanHost            func(context.Context, string, string) (scan.PortScanOutcome, error)
+	scanSelected        func(context.Context, string, string, []int) (scan.PortScanOutcome, error)
+	collectFingerprints func(context.Context, *sql.DB, model.ScanTaskRun, string, []model.ScanResult) ([]model.ScanResult, []model.FingerprintRunMatch, error)
+	collectIdentities   func(context.Context, string, []model.ScanResult) []model.ScanTaskRunHostIdentity
+	collectHostKeys     func(context.Context, string, []model.ScanResult) []model.ScanTaskRunSSHHostKey
+	collectSNMP         func(context.Context, string, string) (*model.ScanTaskRunSNMPSystem, error)
+	matchSNMP           func(*sql.DB, model.ScanTaskRun, model.ScanTaskRunSNMPSystem) ([]model.FingerprintRunMatch, error)
+	runNuclei           func(context.Context, string, []model.ScanResult, string, []string) ([]model.NucleiFinding, error)
+	executeNuclei       func(context.Context, string, []model.ScanResult, string, []string) vuln.NucleiExecutionResult
+	loadTemplateIndex   func(string) (string, *planner.NucleiTemplateIndex, error)
+	templateValidator   vuln.Validator
+	nativeValidator     vuln.Validator
 }
 
 // RunTargetTaskRun collects the observations for one IP run without reading
@@ -64,13 +69,18 @@ func RunTargetTaskRun(ctx context.Context, options TargetTaskRunOptions) (model.
 		return model.ScanTaskRunSnapshot{}, err
 	}
 	return runTargetTaskRun(ctx, options, targetDependencies{
-		scanHost:             scan.RunDiscoveryWithOutcome,
-		scanSelected:         scan.RunSelectedDiscoveryWithOutcome,
-		collectFingerprints:  collector,
-		runNuclei:            vuln.RunNucleiForOpenPortsWithTags,
-		executeNuclei:        vuln.ExecuteNucleiForOpenPortsWithTags,
-		loadTemplateIndex:    loadNucleiTemplateIndex,
-		executeTemplatePaths: vuln.ExecuteNucleiForOpenPortsWithTemplatePaths,
+		scanHost:            scan.RunDiscoveryWithOutcome,
+		scanSelected:        scan.RunSelectedDiscoveryWithOutcome,
+		collectFingerprints: collector,
+		collectIdentities:   collectHostIdentities,
+		collectHostKeys:     collectSSHHostKeys,
+		collectSNMP:         collectSNMPSystem,
+		matchSNMP:           matchSNMPSystemFingerprints,
+		runNuclei:           vuln.RunNucleiForOpenPortsWithTags,
+		executeNuclei:       vuln.ExecuteNucleiForOpenPortsWithTags,
+		loadTemplateIndex:   loadNucleiTemplateIndex,
+		templateValidator:   vuln.NucleiValidator{},
+		nativeValidator:     vuln.NativeValidator{},
 	})
 }
 
@@ -88,7 +98,7 @@ func runTargetTaskRun(ctx context.Context, options TargetTaskRunOptions, depende
 	if strings.TrimSpace(options.Network) == "" {
 		options.Network = "tcp"
 	}
-	if dependencies.scanHost == nil || (dependencies.runNuclei == nil && dependencies.executeNuclei == nil && dependencies.executeTemplatePaths == nil) {
+	if dependencies.scanHost == nil || (dependencies.runNuclei == nil && dependencies.executeNuclei == nil && dependencies.templateValidator == nil) {
 		return model.ScanTaskRunSnapshot{}, errors.New("target task run dependencies are required")
 	}
 	if err := checkCanceled(ctx, options.CheckCanceled); err != nil {
@@ -149,6 +159,28 @@ func runTargetTaskRun(ctx context.Context, options TargetTaskRunOptions, depende
 	active := len(snapshotPorts(target, openPorts)) > 0
 	snapshot := partialTargetSnapshot(options.Run.ID, target, openPorts, options.Run.Config.VulnerabilityOn)
 	snapshot.FingerprintMatches = fingerprintMatches
+	if dependencies.collectIdentities != nil {
+		snapshot.HostIdentities = dependencies.collectIdentities(ctx, target, openPorts)
+	}
+	if dependencies.collectHostKeys != nil {
+		snapshot.SSHHostKeys = dependencies.collectHostKeys(ctx, target, openPorts)
+	}
+	if dependencies.collectSNMP != nil && options.Run.Config.SNMPCredential != "" {
+		system, err := dependencies.collectSNMP(ctx, options.Run.Config.SNMPCredential, target)
+		if err != nil {
+			return snapshot, err
+		}
+		if system != nil {
+			snapshot.SNMPSystems = []model.ScanTaskRunSNMPSystem{*system}
+			if dependencies.matchSNMP != nil {
+				matches, err := dependencies.matchSNMP(options.DB, options.Run, *system)
+				if err != nil {
+					return snapshot, err
+				}
+				snapshot.FingerprintMatches = append(snapshot.FingerprintMatches, matches...)
+			}
+		}
+	}
 	scope := "ip:" + target
 	if err := storage.SyncHostInventory(options.DB, scope, activeTargets(target, active)); err != nil {
 		return snapshot, err
@@ -163,12 +195,29 @@ func runTargetTaskRun(ctx context.Context, options TargetTaskRunOptions, depende
 	if err := storage.DeactivateScopePortsForInactiveHosts(options.DB, scope); err != nil {
 		return snapshot, err
 	}
+	var validationMatches []model.FingerprintRunMatch
 	if options.Run.Config.VulnerabilityOn {
+		if validationMatches, err = unsuppressedMatches(options.DB, target, fingerprintMatches); err != nil {
+			return snapshot, err
+		}
+	}
+	if options.Run.Config.VulnerabilityOn && nativeValidationEnabled(options.Run) {
+		if err := updateProgress(options.UpdateProgress, 85); err != nil {
+			return snapshot, err
+		}
+		validation := newRunValidationTracker()
+		validation.register(target, openPorts, validationMatches)
+		nativeResult := runNativeCheckValidation(ctx, target, openPorts, validationMatches, dependencies.nativeValidator)
+		validation.observe(nativeResult)
+		snapshot.Vulnerabilities = uniqueSnapshotVulnerabilities(snapshotVulnerabilities(nativeResult.findings))
+		snapshot.TemplateCandidates = uniqueTemplateCandidates(nativeResult.candidates)
+		validation.finish(&snapshot, nil)
+	} else if options.Run.Config.VulnerabilityOn {
 		if err := updateProgress(options.UpdateProgress, 85); err != nil {
 			return snapshot, err
 		}
 		validation := newRunValidationTracker()
-		validation.register(target, openPorts, fingerprintMatches)
+		validation.register(target, openPorts, validationMatches)
 		templateRoot := options.Run.Config.NucleiTemplates
 		var templateIndex *planner.NucleiTemplateIndex
 		if dependencies.loadTemplateIndex != nil {
@@ -179,7 +228,7 @@ func runTargetTaskRun(ctx context.Context, options TargetTaskRunOptions, depende
 				return snapshot, err
 			}
 		}
-		mappingResult := runFingerprintMappingValidation(ctx, options.DB, options.Run, target, openPorts, fingerprintMatches, templateRoot, templateIndex, dependencies.executeTemplatePaths)
+		mappingResult := runFingerprintMappingValidation(ctx, options.DB, options.Run, target, openPorts, validationMatches, templateRoot, templateIndex, materializedTemplateExecutor(dependencies.templateValidator))
 		validation.observe(mappingResult)
 		snapshot.Vulnerabilities = uniqueSnapshotVulnerabilities(snapshotVulnerabilities(mappingResult.findings))
 		snapshot.TemplateCandidates = uniqueTemplateCandidates(mappingResult.candidates)
@@ -187,7 +236,7 @@ func runTargetTaskRun(ctx context.Context, options TargetTaskRunOptions, depende
 			validation.finish(&snapshot, mappingResult.err)
 			return snapshot, mappingResult.err
 		}
-		fallbackResult := runServiceTagValidation(ctx, target, portsWithoutFingerprintMappings(openPorts, mappingResult.candidates, fingerprintMatches), templateIndex, dependencies.executeTemplatePaths)
+		fallbackResult := runServiceTagValidation(ctx, target, portsWithoutFingerprintMappings(openPorts, mappingResult.candidates, validationMatches), templateIndex, materializedTemplateExecutor(dependencies.templateValidator))
 		validation.observe(fallbackResult)
 		allFindings := append(mappingResult.findings, fallbackResult.findings...)
 		allCandidates := append(mappingResult.candidates, fallbackResult.candidates...)
@@ -209,6 +258,8 @@ func partialTargetSnapshot(runID int64, ip string, results []model.ScanResult, v
 	ports := uniqueSnapshotPorts(snapshotPorts(ip, results))
 	snapshot := model.ScanTaskRunSnapshot{
 		RunID: runID, Ports: ports, ProtocolEvidence: uniqueProtocolEvidence(snapshotProtocolEvidence(ip, results)), Hosts: make([]model.ScanTaskRunHost, 0, 1),
+		WebAssets: snapshotWebAssets(ip, results), Weaknesses: snapshotWeaknesses(ip, results),
+		TLSFingerprints: snapshotTLSFingerprints(ip, results), RawEvidence: snapshotRawEvidence(results),
 		Vulnerabilities: make([]model.ScanTaskRunVulnerability, 0), FingerprintMatches: make([]model.FingerprintRunMatch, 0),
 	}
 	snapshot.Validation = initialRunValidation(len(vulnerabilityOn) > 0 && vulnerabilityOn[0])
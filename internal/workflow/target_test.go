@@ -42,6 +42,28 @@ func TestRunFingerprintCollectorLoadsOnlyForFirstOpenEndpoint(t *testing.T) {
 	}
 }
 
+func TestTLSFingerprintRequiresTaskOptIn(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("<html><title>tls fixture</title></html>"))
+	}))
+	t.Cleanup(server.Close)
+	address := strings.TrimPrefix(server.URL, "https://")
+	for _, optIn := range []bool{false, true} {
+		results := []model.ScanResult{{Address: address, Open: true, Service: "https"}}
+		collected, _, err := collectRunFingerprintMatchesWithEngine(context.Background(), &fingerprint.Engine{}, nil, optIn, "127.0.0.1", results)
+		if err != nil {
+			t.Fatal(err)
+		}
+		probed := false
+		for _, evidence := range collected[0].ProtocolEvidence {
+			probed = probed || evidence.EvidenceType == model.ProtocolEvidenceTLSFingerprint
+		}
+		if probed != optIn {
+			t.Fatalf("tls_fingerprint=%v probed=%v evidence=%#v", optIn, probed, collected[0].ProtocolEvidence)
+		}
+	}
+}
+
 func TestFiveWebEndpointsKeepProtocolResponsesWhenPassiveBannersAreEmpty(t *testing.T) {
 	results := make([]model.ScanResult, 0, 5)
 	servers := make([]*httptest.Server, 0, 5)
@@ -62,7 +84,7 @@ func TestFiveWebEndpointsKeepProtocolResponsesWhenPassiveBannersAreEmpty(t *test
 		}
 		results = append(results, model.ScanResult{Address: net.JoinHostPort(host, portText), Open: true, Service: "http"})
 	}
-	collected, _, err := collectRunFingerprintMatchesWithEngine(context.Background(), &fingerprint.Engine{}, "127.0.0.1", results)
+	collected, _, err := collectRunFingerprintMatchesWithEngine(context.Background(), &fingerprint.Engine{}, nil, false, "127.0.0.1", results)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -186,6 +208,39 @@ func TestRunTargetTaskRunBuildsSnapshotIndependentOfInventory(t *testing.T) {
 	}
 }
 
+func TestSuppressedProductSelectsNoTemplates(t *testing.T) {
+	db := openFullWorkflowDB(t)
+	const ip = "192.168.80.18"
+	if _, err := storage.CreateFingerprintOverride(db, model.FingerprintOverride{Kind: model.FingerprintOverrideSuppress, ProductKey: "nginx", IP: ip, Reason: "reverse proxy in front of the real service"}); err != nil {
+		t.Fatal(err)
+	}
+	root, templateIndex := workflowTemplateIndexFixture(t, workflowTemplateSpec{id: "nginx-safe", product: "nginx", protocol: "http"})
+	snapshot, err := runTargetTaskRun(context.Background(), TargetTaskRunOptions{
+		DB: db, Run: model.ScanTaskRun{ID: 98, ScanTaskID: 12, ScanType: model.ScanTypeIP, Target: ip, Config: model.ScanTaskConfig{VulnerabilityOn: true}},
+	}, targetDependencies{
+		scanHost: func(context.Context, string, string) (scan.PortScanOutcome, error) {
+			return scan.PortScanOutcome{Results: []model.ScanResult{{Address: ip + ":8080", Open: true, Service: "http"}}, AttemptedPorts: 65535, TotalPorts: 65535}, nil
+		},
+		collectFingerprints: func(_ context.Context, _ *sql.DB, _ model.ScanTaskRun, host string, results []model.ScanResult) ([]model.ScanResult, []model.FingerprintRunMatch, error) {
+			return results, []model.FingerprintRunMatch{{IP: host, Port: 8080, Protocol: "http", Product: "nginx"}}, nil
+		},
+		loadTemplateIndex: func(string) (string, *planner.NucleiTemplateIndex, error) { return root, templateIndex, nil },
+		templateValidator: vuln.ValidatorFunc(func(context.Context, string, []model.ScanResult, []string) vuln.NucleiExecutionResult {
+			t.Fatal("suppressed product selected a template")
+			return vuln.NucleiExecutionResult{}
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.TemplateCandidates) != 0 || len(snapshot.EndpointValidations) != 1 || snapshot.EndpointValidations[0].Reason != model.ValidationReasonUnidentifiedProduct {
+		t.Fatalf("candidates=%#v endpoints=%#v", snapshot.TemplateCandidates, snapshot.EndpointValidations)
+	}
+	if len(snapshot.FingerprintMatches) != 1 {
+		t.Fatalf("suppression dropped the recorded match: %#v", snapshot.FingerprintMatches)
+	}
+}
+
 func TestNonStandardHTTPSNoTemplatesIsNotSuccessfulValidation(t *testing.T) {
 	db := openFullWorkflowDB(t)
 	const ip = "192.168.80.17"
@@ -200,9 +255,9 @@ func TestNonStandardHTTPSNoTemplatesIsNotSuccessfulValidation(t *testing.T) {
 			return results, []model.FingerprintRunMatch{{IP: host, Port: 30957, Protocol: "https", Product: "nginx"}}, nil
 		},
 		loadTemplateIndex: func(string) (string, *planner.NucleiTemplateIndex, error) { return root, templateIndex, nil },
-		executeTemplatePaths: func(context.Context, string, []model.ScanResult, []string) vuln.NucleiExecutionResult {
+		templateValidator: vuln.ValidatorFunc(func(context.Context, string, []model.ScanResult, []string) vuln.NucleiExecutionResult {
 			return vuln.NucleiExecutionResult{Started: true, Err: vuln.ErrNoTemplates}
-		},
+		}),
 	})
 	if err != nil {
 		t.Fatalf("no-template validation must not fail asset collection: %v", err)
@@ -255,13 +310,13 @@ func TestValidationKeepsEarlierExecutedEndpointWhenLaterEndpointHasNoTemplates(t
 			return results, []model.FingerprintRunMatch{{IP: host, Port: 80, Protocol: "http", Product: "nginx"}, {IP: host, Port: 443, Protocol: "https", Product: "php"}}, nil
 		},
 		loadTemplateIndex: func(string) (string, *planner.NucleiTemplateIndex, error) { return root, templateIndex, nil },
-		executeTemplatePaths: func(_ context.Context, _ string, ports []model.ScanResult, _ []string) vuln.NucleiExecutionResult {
+		templateValidator: vuln.ValidatorFunc(func(_ context.Context, _ string, ports []model.ScanResult, _ []string) vuln.NucleiExecutionResult {
 			calls++
 			if strings.HasSuffix(ports[0].Address, ":443") {
 				return vuln.NucleiExecutionResult{Started: true, Err: vuln.ErrNoTemplates}
 			}
 			return vuln.NucleiExecutionResult{Started: true, Executed: true, Findings: []model.NucleiFinding{{TemplateID: "first-success", Name: "First", Description: "parsed description", Target: "http://" + ip, TargetIP: ip, TargetPort: 80}}}
-		},
+		}),
 	})
 	if err != nil {
 		t.Fatalf("mixed validation must preserve successful endpoint: %v", err)
@@ -299,6 +354,42 @@ func TestRunTargetTaskRunCollectsFingerprintsBeforeSnapshot(t *testing.T) {
 	}
 }
 
+func TestRunTargetTaskRunCollectsSNMPOnlyWithCredential(t *testing.T) {
+	db := openWorkflowDB(t)
+	var requested []string
+	dependencies := targetDependencies{
+		scanHost: func(context.Context, string, string) (scan.PortScanOutcome, error) {
+			return scan.PortScanOutcome{AttemptedPorts: 65535, TotalPorts: 65535}, nil
+		},
+		runNuclei: func(context.Context, string, []model.ScanResult, string, []string) ([]model.NucleiFinding, error) {
+			return nil, nil
+		},
+		collectSNMP: func(_ context.Context, credential, ip string) (*model.ScanTaskRunSNMPSystem, error) {
+			requested = append(requested, credential+"@"+ip)
+			return &model.ScanTaskRunSNMPSystem{IP: ip, Port: 161, Credential: credential, SysName: "edge-1", SysDescr: "Linux edge-1 5.15.0"}, nil
+		},
+		matchSNMP: func(_ *sql.DB, _ model.ScanTaskRun, system model.ScanTaskRunSNMPSystem) ([]model.FingerprintRunMatch, error) {
+			return []model.FingerprintRunMatch{{IP: system.IP, Port: system.Port, Protocol: "snmp", Product: "linux", EvidenceSummary: system.SysDescr}}, nil
+		},
+	}
+	run := model.ScanTaskRun{ID: 93, ScanTaskID: 12, ScanType: model.ScanTypeIP, Target: "192.168.80.12"}
+	snapshot, err := runTargetTaskRun(context.Background(), TargetTaskRunOptions{DB: db, Run: run}, dependencies)
+	if err != nil || len(requested) != 0 || len(snapshot.SNMPSystems) != 0 {
+		t.Fatalf("run without credential: err=%v requested=%v systems=%#v", err, requested, snapshot.SNMPSystems)
+	}
+	run.Config.SNMPCredential = "edge"
+	snapshot, err = runTargetTaskRun(context.Background(), TargetTaskRunOptions{DB: db, Run: run}, dependencies)
+	if err != nil {
+		t.Fatalf("run target task run: %v", err)
+	}
+	if !reflect.DeepEqual(requested, []string{"edge@192.168.80.12"}) || len(snapshot.SNMPSystems) != 1 || snapshot.SNMPSystems[0].SysName != "edge-1" {
+		t.Fatalf("SNMP collection: requested=%v systems=%#v", requested, snapshot.SNMPSystems)
+	}
+	if len(snapshot.FingerprintMatches) != 1 || snapshot.FingerprintMatches[0].Protocol != "snmp" || snapshot.FingerprintMatches[0].Port != 161 {
+		t.Fatalf("sysDescr matches = %#v", snapshot.FingerprintMatches)
+	}
+}
+
 func TestRunTargetTaskRunReturnsFingerprintPartialOnCancellation(t *testing.T) {
 	db := openWorkflowDB(t)
 	const ip = "192.168.80.15"
@@ -18,15 +18,20 @@ import (
 
 	"golandproject/yscan/internal/api"
 	"golandproject/yscan/internal/assist"
+	"golandproject/yscan/internal/cve"
 	"golandproject/yscan/internal/domain"
+	"golandproject/yscan/internal/finding"
 	"golandproject/yscan/internal/fingerprint"
 	"golandproject/yscan/internal/identify"
+	"golandproject/yscan/internal/ingest"
 	"golandproject/yscan/internal/model"
 	"golandproject/yscan/internal/pipeline"
 	"golandproject/yscan/internal/report"
+	"golandproject/yscan/internal/risk"
 	appRuntime "golandproject/yscan/internal/runtime"
 	"golandproject/yscan/internal/scan"
 	"golandproject/yscan/internal/schedule"
+	"golandproject/yscan/internal/snmp"
 	"golandproject/yscan/internal/storage"
 	"golandproject/yscan/internal/vuln"
 	"golandproject/yscan/internal/workflow"
@@ -569,6 +574,86 @@ func parseCLIConfig(args []string) ([]string, cliConfig, error) {
 			if err := captureRuntimeFlagEquals(current, "--nuclei-binary=", appRuntime.ConfigNucleiBinary, cfg.Runtime); err != nil {
 				return nil, cfg, err
 			}
+		case current == "--evidence-cidrs":
+			var err error
+			i, err = captureRuntimeFlag(args, i, current, appRuntime.ConfigEvidenceCIDRs, cfg.Runtime)
+			if err != nil {
+				return nil, cfg, err
+			}
+		case strings.HasPrefix(current, "--evidence-cidrs="):
+			if err := captureRuntimeFlagEquals(current, "--evidence-cidrs=", appRuntime.ConfigEvidenceCIDRs, cfg.Runtime); err != nil {
+				return nil, cfg, err
+			}
+		case current == "--evidence-redact":
+			var err error
+			i, err = captureRuntimeFlag(args, i, current, appRuntime.ConfigEvidenceRedact, cfg.Runtime)
+			if err != nil {
+				return nil, cfg, err
+			}
+		case strings.HasPrefix(current, "--evidence-redact="):
+			if err := captureRuntimeFlagEquals(current, "--evidence-redact=", appRuntime.ConfigEvidenceRedact, cfg.Runtime); err != nil {
+				return nil, cfg, err
+			}
+		case current == "--evidence-max-body-bytes":
+			var err error
+			i, err = captureRuntimeFlag(args, i, current, appRuntime.ConfigEvidenceMaxBody, cfg.Runtime)
+			if err != nil {
+				return nil, cfg, err
+			}
+		case strings.HasPrefix(current, "--evidence-max-body-bytes="):
+			if err := captureRuntimeFlagEquals(current, "--evidence-max-body-bytes=", appRuntime.ConfigEvidenceMaxBody, cfg.Runtime); err != nil {
+				return nil, cfg, err
+			}
+		case current == "--nuclei-timeout":
+			var err error
+			i, err = captureRuntimeFlag(args, i, current, appRuntime.ConfigNucleiTimeout, cfg.Runtime)
+			if err != nil {
+				return nil, cfg, err
+			}
+		case strings.HasPrefix(current, "--nuclei-timeout="):
+			if err := captureRuntimeFlagEquals(current, "--nuclei-timeout=", appRuntime.ConfigNucleiTimeout, cfg.Runtime); err != nil {
+				return nil, cfg, err
+			}
+		case current == "--nuclei-max-memory-mb":
+			var err error
+			i, err = captureRuntimeFlag(args, i, current, appRuntime.ConfigNucleiMaxMemoryMB, cfg.Runtime)
+			if err != nil {
+				return nil, cfg, err
+			}
+		case strings.HasPrefix(current, "--nuclei-max-memory-mb="):
+			if err := captureRuntimeFlagEquals(current, "--nuclei-max-memory-mb=", appRuntime.ConfigNucleiMaxMemoryMB, cfg.Runtime); err != nil {
+				return nil, cfg, err
+			}
+		case current == "--nuclei-nice":
+			var err error
+			i, err = captureRuntimeFlag(args, i, current, appRuntime.ConfigNucleiNice, cfg.Runtime)
+			if err != nil {
+				return nil, cfg, err
+			}
+		case strings.HasPrefix(current, "--nuclei-nice="):
+			if err := captureRuntimeFlagEquals(current, "--nuclei-nice=", appRuntime.ConfigNucleiNice, cfg.Runtime); err != nil {
+				return nil, cfg, err
+			}
+		case current == "--nuclei-ionice":
+			var err error
+			i, err = captureRuntimeFlag(args, i, current, appRuntime.ConfigNucleiIOClass, cfg.Runtime)
+			if err != nil {
+				return nil, cfg, err
+			}
+		case strings.HasPrefix(current, "--nuclei-ionice="):
+			if err := captureRuntimeFlagEquals(current, "--nuclei-ionice=", appRuntime.ConfigNucleiIOClass, cfg.Runtime); err != nil {
+				return nil, cfg, err
+			}
+		case current == "--nuclei-cgroup":
+			var err error
+			i, err = captureRuntimeFlag(args, i, current, appRuntime.ConfigNucleiCgroup, cfg.Runtime)
+			if err != nil {
+				return nil, cfg, err
+			}
+		case strings.HasPrefix(current, "--nuclei-cgroup="):
+			if err := captureRuntimeFlagEquals(current, "--nuclei-cgroup=", appRuntime.ConfigNucleiCgroup, cfg.Runtime); err != nil {
+				return nil, cfg, err
+			}
 		default:
 			filtered = append(filtered, args[i])
 		}
@@ -771,6 +856,21 @@ func (executor logicalScanTaskRunExecutor) Execute(ctx context.Context, run mode
 		}
 		return storage.UpdateScanTaskRunProgress(executor.db, run.ID, stage, progress)
 	}
+	if run.Trigger == model.ScanTaskRunTriggerImport || run.Trigger == model.ScanTaskRunTriggerPassive {
+		return model.ScanTaskRunSnapshot{}, errors.New("imported scan task run observations are only available to the import command")
+	}
+	if run.Trigger == model.ScanTaskRunTriggerRevalidate {
+		return workflow.RunRevalidateTaskRun(ctx, workflow.RevalidateTaskRunOptions{
+			DB: executor.db, Run: run,
+			UpdateProgress: func(progress int) error {
+				stage := model.ScanTaskRunStageValidation
+				if progress >= 100 {
+					progress, stage = 94, model.ScanTaskRunStageSnapshot
+				}
+				return storage.UpdateScanTaskRunProgress(executor.db, run.ID, stage, progress)
+			},
+		})
+	}
 	switch run.ScanType {
 	case model.ScanTypeIP:
 		return runTargetTaskRun(ctx, workflow.TargetTaskRunOptions{
@@ -787,7 +887,19 @@ func (executor logicalScanTaskRunExecutor) Execute(ctx context.Context, run mode
 	}
 }
 
-func executeLogicalScanTaskRun(ctx context.Context, db *sql.DB, baseTask model.Scanner, run model.ScanTaskRun) (returnErr error) {
+func executeLogicalScanTaskRun(ctx context.Context, db *sql.DB, baseTask model.Scanner, run model.ScanTaskRun) error {
+	runTimeout := 30 * time.Minute
+	if run.ScanType == model.ScanTypeIP {
+		// The discovery budget is derived from scanner concurrency and the
+		// per-port hard deadline. Remaining time covers evidence and validation.
+		runTimeout = scan.FullPortScanWorstCaseBudget() + 20*time.Minute
+	}
+	return executeScanTaskRunWith(ctx, db, run, logicalScanTaskRunExecutor{db: db, baseTask: baseTask}, runTimeout)
+}
+
+// executeScanTaskRunWith owns the run lifecycle shared by active and imported
+// runs: execution, snapshot persistence, reporting and final publication.
+func executeScanTaskRunWith(ctx context.Context, db *sql.DB, run model.ScanTaskRun, runExecutor schedule.ScanTaskRunExecutor, runTimeout time.Duration) (returnErr error) {
 	log.Printf("scan task run %d started (%s %s)", run.ID, run.ScanType, run.Target)
 	defer func() {
 		if returnErr != nil {
@@ -796,16 +908,10 @@ func executeLogicalScanTaskRun(ctx context.Context, db *sql.DB, baseTask model.S
 		}
 		log.Printf("scan task run %d finished", run.ID)
 	}()
-	runTimeout := 30 * time.Minute
-	if run.ScanType == model.ScanTypeIP {
-		// The discovery budget is derived from scanner concurrency and the
-		// per-port hard deadline. Remaining time covers evidence and validation.
-		runTimeout = scan.FullPortScanWorstCaseBudget() + 20*time.Minute
-	}
 	scanCtx, cancel := context.WithTimeout(ctx, runTimeout)
 	defer cancel()
 
-	executor := schedule.NewExecutor(db, logicalScanTaskRunExecutor{db: db, baseTask: baseTask})
+	executor := schedule.NewExecutor(db, runExecutor)
 	var executionErr error
 	if run.Status == model.ScanTaskRunStatusRunning {
 		executionErr = executor.ExecuteClaimedRun(scanCtx, run.ID)
@@ -937,10 +1043,21 @@ func runMainArgs(rawArgs []string) (returnErr error) {
 		return err
 	}
 	vuln.ConfigureNucleiBinary(runtimeConfig.NucleiBinary)
+	vuln.ConfigureNucleiLimits(vuln.NucleiLimits{
+		Timeout:        runtimeConfig.NucleiTimeout,
+		MaxMemoryBytes: int64(runtimeConfig.NucleiMaxMemoryMB) << 20,
+		Nice:           runtimeConfig.NucleiNice,
+		IOClass:        runtimeConfig.NucleiIOClass,
+		DelegateCgroup: runtimeConfig.NucleiCgroup == "delegate",
+	})
+	vuln.ConfigureEvidenceRedaction(runtimeConfig.EvidenceRedact, runtimeConfig.EvidenceMaxBody)
 	if err := paths.Prepare(); err != nil {
 		return err
 	}
 	report.ConfigureHomeDirectory(paths.ReportsDir)
+	snmp.ConfigureCredentialStore(snmp.NewCredentialStore(paths))
+	fingerprint.ConfigureLocalRulesDirectory(paths.FingerprintRules)
+	fingerprint.ConfigureEvidenceVault(paths)
 	backgroundChild := false
 	if len(args) > 1 && strings.EqualFold(args[0], "server") && args[1] == "--background-child" {
 		backgroundChild = true
@@ -1266,11 +1383,29 @@ func effectiveBackgroundArguments(config appRuntime.Config, cli cliConfig) []str
 		"--log-max-bytes", strconv.FormatInt(config.LogMaxBytes, 10),
 		"--log-max-files", strconv.Itoa(config.LogMaxFiles),
 		"--nuclei-binary", config.NucleiBinary,
+		"--nuclei-timeout", config.NucleiTimeout.String(),
+		"--nuclei-max-memory-mb", strconv.Itoa(config.NucleiMaxMemoryMB),
+		"--nuclei-nice", strconv.Itoa(config.NucleiNice),
 		"--dns-mode", cli.DNSResolveMode,
 	}
 	if len(config.AllowCIDRs) > 0 {
 		arguments = append(arguments, "--trusted-cidrs", strings.Join(config.AllowCIDRs, ","))
 	}
+	if config.NucleiIOClass != "" {
+		arguments = append(arguments, "--nuclei-ionice", config.NucleiIOClass)
+	}
+	if config.NucleiCgroup != "" {
+		arguments = append(arguments, "--nuclei-cgroup", config.NucleiCgroup)
+	}
+	if len(config.EvidenceCIDRs) > 0 {
+		arguments = append(arguments, "--evidence-cidrs", strings.Join(config.EvidenceCIDRs, ","))
+	}
+	if len(config.EvidenceRedact) > 0 {
+		arguments = append(arguments, "--evidence-redact", strings.Join(config.EvidenceRedact, ","))
+	}
+	if config.EvidenceMaxBody > 0 {
+		arguments = append(arguments, "--evidence-max-body-bytes", strconv.Itoa(config.EvidenceMaxBody))
+	}
 	if config.NucleiTemplates != "" {
 		arguments = append(arguments, "--templates", config.NucleiTemplates)
 	}
@@ -1372,6 +1507,15 @@ func printCLIUsage() {
 	fmt.Println("usage: yscan [--home <dir>] scan <internal-ip|cidr> [--vuln] [--port-spec <ports>]")
 	fmt.Println("       yscan subnet <internal-cidr> [--vuln] [--port-spec <ports>]")
 	fmt.Println("       yscan schedule help")
+	fmt.Println("       yscan import --format nmap-xml|masscan-json --task <scan_task_id> <file>")
+	fmt.Println("       yscan passive import [--task <scan_task_id>] <file.pcap>")
+	fmt.Println("       yscan snmp credential list|add|remove ...")
+	fmt.Println("       yscan cve import <nvd-feed.json[.gz]|kev.json>... | cve status")
+	fmt.Println("       yscan findings --all [--severity <severity>] [--template <id>] [--product <key>] [--cidr <cidr>] [--state <state>] [--since <date>] [--until <date>]")
+	fmt.Println("       yscan findings show|triage|comment <finding_key> ...")
+	fmt.Println("       yscan risk show <scan_task_id> <run_id> | risk label list|set|remove ...")
+	fmt.Println("       yscan plan <scan_task_id> [--run <run_id>]")
+	fmt.Println("       yscan revalidate <scan_task_id> <run_id>")
 	fmt.Println("       yscan server [listen_addr] [--allow-cidr <cidr>]...")
 	fmt.Println("       yscan server start|stop|restart|status|logs|uninstall")
 	fmt.Println("       yscan legacy-list|legacy-status|legacy-findings ...")
@@ -1386,7 +1530,7 @@ type preparedServerStartup struct {
 
 func validateServerStartup(args []string, runtimeConfig appRuntime.Config) (string, api.AccessPolicy, error) {
 	addr := runtimeConfig.ListenAddress
-	policy := api.AccessPolicy{TrustedCIDRs: append([]string(nil), runtimeConfig.AllowCIDRs...)}
+	policy := api.AccessPolicy{TrustedCIDRs: append([]string(nil), runtimeConfig.AllowCIDRs...), EvidenceCIDRs: append([]string(nil), runtimeConfig.EvidenceCIDRs...)}
 	index := 1
 	if len(args) >= 2 && strings.TrimSpace(args[1]) != "" && !strings.HasPrefix(strings.TrimSpace(args[1]), "--") {
 		addr = strings.TrimSpace(args[1])
@@ -1467,7 +1611,12 @@ func runByArgs(args []string, task model.Scanner, db *sql.DB, runtimeConfig appR
 		return runScheduleCommand([]string{"list"}, task, db)
 	case "status":
 		return runScheduleCommand(append([]string{"show"}, args[1:]...), task, db)
-	case "cancel", "findings", "report", "changes", "asset":
+	case "findings":
+		if len(args) > 1 && finding.IsCommand(args[1]) {
+			return finding.RunCLI(db, args[1:], os.Stdout)
+		}
+		return runScheduleCommand(append([]string{command}, args[1:]...), task, db)
+	case "cancel", "report", "changes", "asset":
 		return runScheduleCommand(append([]string{command}, args[1:]...), task, db)
 	case "legacy-list":
 		printTaskList(db)
@@ -1549,6 +1698,30 @@ func runByArgs(args []string, task model.Scanner, db *sql.DB, runtimeConfig appR
 	case "fingerprint":
 		return runFingerprintCommand(args[1:], db)
 
+	case "import":
+		return runImportCommand(context.Background(), db, args[1:], os.Stdout)
+
+	case "passive":
+		if len(args) < 2 || args[1] != "import" {
+			return errors.New(passiveImportUsage)
+		}
+		return runPassiveImportCommand(context.Background(), db, args[2:], os.Stdout)
+
+	case "snmp":
+		return runSNMPCommand(args[1:])
+
+	case "cve":
+		return cve.RunCLI(db, args[1:], os.Stdout)
+
+	case "risk":
+		return risk.RunCLI(db, args[1:], os.Stdout)
+
+	case "plan":
+		return runPlanCommand(context.Background(), db, args[1:], os.Stdout)
+
+	case "revalidate":
+		return runRevalidateCommand(context.Background(), db, task, args[1:], os.Stdout)
+
 	default:
 		return fmt.Errorf("unknown command: %s", command)
 	}
@@ -1643,7 +1816,7 @@ func runScheduleCommand(args []string, baseTask model.Scanner, db *sql.DB) error
 		DNSResolveMode:  baseTask.DNSResolveMode,
 		DNSDenyCIDRs:    baseTask.DNSDenyCIDRs,
 	}, func(ctx context.Context, run model.ScanTaskRun) error {
-		if run.Config.VulnerabilityOn {
+		if run.Config.VulnerabilityOn && run.Config.Validator != model.ValidatorNative {
 			warnIfNucleiMissing()
 			warnIfNucleiTemplatesMissing(run.Config.NucleiTemplates)
 		}
@@ -1661,3 +1834,327 @@ func runFingerprintCommand(args []string, db *sql.DB) error {
 	}
 	return fingerprint.RunCLI(context.Background(), registry, args, os.Stdout)
 }
+
+// runSNMPCommand manages the sealed SNMP credentials of the active home.
+func runSNMPCommand(args []string) error {
+	return snmp.RunCLI(snmp.ConfiguredCredentialStore(), args, os.LookupEnv, os.Stdout)
+}
+
+const importUsage = "usage: yscan import --format nmap-xml|masscan-json --task <scan_task_id> <file>"
+
+// runImportCommand records an external nmap or masscan result as a run of an
+// existing task. Hosts outside the task target are dropped before the run is
+// created so an overlapping export cannot widen the authorized scope.
+func runImportCommand(ctx context.Context, db *sql.DB, args []string, output io.Writer) error {
+	format, path := "", ""
+	var taskID int64
+	for index := 0; index < len(args); index++ {
+		switch argument := strings.TrimSpace(args[index]); argument {
+		case "--format":
+			value, next, err := requiredFlagValue(args, index, argument)
+			if err != nil {
+				return err
+			}
+			format, index = strings.ToLower(value), next
+		case "--task":
+			value, next, err := requiredFlagValue(args, index, argument)
+			if err != nil {
+				return err
+			}
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || parsed <= 0 {
+				return errors.New("invalid scan task id")
+			}
+			taskID, index = parsed, next
+		default:
+			if strings.HasPrefix(argument, "-") || path != "" {
+				return errors.New(importUsage)
+			}
+			path = argument
+		}
+	}
+	if format == "" || taskID <= 0 || path == "" {
+		return errors.New(importUsage)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open import file: %w", err)
+	}
+	observations, err := ingest.Parse(format, file)
+	_ = file.Close()
+	if err != nil {
+		return err
+	}
+	task, err := storage.GetScanTask(db, taskID)
+	if err != nil {
+		return err
+	}
+	return executeObservationRun(ctx, db, task, model.ScanTaskRunTriggerImport, observations, output)
+}
+
+const planUsage = "usage: yscan plan <scan_task_id> [--run <run_id>]"
+
+// runPlanCommand prints the validation plan of a task: the templates each
+// endpoint of its last successful run (or --run) would get, why others were
+// left out, and the Nuclei command lines. Nothing is executed.
+func runPlanCommand(ctx context.Context, db *sql.DB, args []string, output io.Writer) error {
+	var taskID, runID int64
+	for index := 0; index < len(args); index++ {
+		switch argument := strings.TrimSpace(args[index]); argument {
+		case "--run":
+			value, next, err := requiredFlagValue(args, index, argument)
+			if err != nil {
+				return err
+			}
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || parsed <= 0 {
+				return errors.New("invalid scan task run id")
+			}
+			runID, index = parsed, next
+		default:
+			if strings.HasPrefix(argument, "-") || taskID != 0 {
+				return errors.New(planUsage)
+			}
+			parsed, err := strconv.ParseInt(argument, 10, 64)
+			if err != nil || parsed <= 0 {
+				return errors.New("invalid scan task id")
+			}
+			taskID = parsed
+		}
+	}
+	if taskID <= 0 {
+		return errors.New(planUsage)
+	}
+	task, err := storage.GetScanTask(db, taskID)
+	if err != nil {
+		return err
+	}
+	var run model.ScanTaskRun
+	if runID > 0 {
+		run, err = storage.GetScanTaskRun(db, runID)
+	} else {
+		run, err = storage.GetLatestSuccessfulScanTaskRun(db, taskID)
+	}
+	if err != nil {
+		return err
+	}
+	plan, err := workflow.PlanValidation(ctx, db, task, run)
+	if err != nil {
+		return err
+	}
+	writeValidationPlan(output, plan)
+	return nil
+}
+
+func writeValidationPlan(output io.Writer, plan model.ValidationPlan) {
+	fmt.Fprintf(output, "ScanTask %d run %d validation plan (dry run, nothing executed)\n", plan.ScanTaskID, plan.ScanTaskRunID)
+	if !plan.VulnerabilityOn {
+		fmt.Fprintln(output, "Note: vulnerability validation is disabled for this task")
+	}
+	if plan.TemplatesRoot != "" {
+		fmt.Fprintf(output, "Templates: %s (revision %s)\n", plan.TemplatesRoot, plan.TemplateSetRevision)
+	}
+	if plan.Error != "" {
+		fmt.Fprintf(output, "Warning: %s\n", plan.Error)
+	}
+	for _, endpoint := range plan.Endpoints {
+		products := "-"
+		if len(endpoint.Products) > 0 {
+			products = strings.Join(endpoint.Products, ",")
+		}
+		status := "selected"
+		if endpoint.Reason != "" {
+			status = "skipped reason=" + endpoint.Reason
+		}
+		fmt.Fprintf(output, "\n%s:%d/%s products=%s %s\n", endpoint.IP, endpoint.Port, endpoint.Protocol, products, status)
+		if endpoint.Error != "" {
+			fmt.Fprintf(output, "  error: %s\n", endpoint.Error)
+		}
+		for _, candidate := range endpoint.Candidates {
+			fmt.Fprintf(output, "  + %s (%s) %s\n", candidate.TemplateID, candidate.Source, candidate.Reason)
+		}
+		for _, filtered := range endpoint.Filtered {
+			fmt.Fprintf(output, "  - %s (%s) filtered: %s\n", filtered.TemplateID, filtered.ProductKey, filtered.Reason)
+		}
+	}
+	if len(plan.Invocations) == 0 {
+		fmt.Fprintln(output, "\nNo Nuclei invocations")
+		return
+	}
+	fmt.Fprintln(output, "\nNuclei invocations:")
+	for _, invocation := range plan.Invocations {
+		fmt.Fprintf(output, "  %s:%d templates=%s targets=%s\n", invocation.IP, invocation.Port, strings.Join(invocation.TemplateIDs, ","), strings.Join(invocation.Targets, ","))
+		fmt.Fprintf(output, "    %s\n", strings.Join(invocation.Command, " "))
+	}
+}
+
+const revalidateUsage = "usage: yscan revalidate <scan_task_id> <run_id>"
+
+// runRevalidateCommand repeats only vulnerability validation against the
+// endpoints and fingerprint matches of a successful run, using the task's
+// current template set, and waits for the linked run to finish.
+func runRevalidateCommand(ctx context.Context, db *sql.DB, baseTask model.Scanner, args []string, output io.Writer) error {
+	if len(args) != 2 {
+		return errors.New(revalidateUsage)
+	}
+	taskID, err := strconv.ParseInt(strings.TrimSpace(args[0]), 10, 64)
+	if err != nil || taskID <= 0 {
+		return errors.New("invalid scan task id")
+	}
+	sourceID, err := strconv.ParseInt(strings.TrimSpace(args[1]), 10, 64)
+	if err != nil || sourceID <= 0 {
+		return errors.New("invalid scan task run id")
+	}
+	source, err := storage.GetScanTaskRun(db, sourceID)
+	if err != nil {
+		return err
+	}
+	if source.ScanTaskID != taskID {
+		return storage.ErrScanTaskRunNotFound
+	}
+	run, err := storage.CreateRevalidationScanTaskRun(db, source.ID)
+	if err != nil {
+		return err
+	}
+	if run.Config.Validator != model.ValidatorNative {
+		warnIfNucleiMissing()
+		warnIfNucleiTemplatesMissing(run.Config.NucleiTemplates)
+	}
+	fmt.Fprintf(output, "ScanTask %d run %d revalidating endpoints of run %d\n", taskID, run.ID, source.ID)
+	if err := executeLogicalScanTaskRun(ctx, db, baseTask, run); err != nil {
+		if errors.Is(err, schedule.ErrGlobalConcurrencyUnavailable) {
+			if cancelErr := storage.CancelScanTaskRun(db, taskID, run.ID); cancelErr == nil {
+				_ = storage.FinalizeQueuedCancellation(db)
+			}
+			return fmt.Errorf("another scan task run is active; retry the revalidation later: %w", err)
+		}
+		return err
+	}
+	completed, err := storage.GetScanTaskRun(db, run.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(output, "ScanTask run %d finished: %s (report: %s)\n", completed.ID, completed.Status, completed.ReportPath)
+	if completed.Status != model.ScanTaskRunStatusSuccess {
+		return fmt.Errorf("scan task run %d finished with status %s: %s", completed.ID, completed.Status, completed.ErrorMessage)
+	}
+	return nil
+}
+
+const passiveImportUsage = "usage: yscan passive import [--task <scan_task_id>] <file.pcap|file.pcapng>"
+
+// runPassiveImportCommand records services reconstructed from a packet
+// capture as a passive run. Without --task a one-time task is created for the
+// smallest internal range covering the captured hosts; it is never scanned
+// actively unless an operator asks for it.
+func runPassiveImportCommand(ctx context.Context, db *sql.DB, args []string, output io.Writer) error {
+	path := ""
+	var taskID int64
+	for index := 0; index < len(args); index++ {
+		switch argument := strings.TrimSpace(args[index]); argument {
+		case "--task":
+			value, next, err := requiredFlagValue(args, index, argument)
+			if err != nil {
+				return err
+			}
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || parsed <= 0 {
+				return errors.New("invalid scan task id")
+			}
+			taskID, index = parsed, next
+		default:
+			if strings.HasPrefix(argument, "-") || path != "" {
+				return errors.New(passiveImportUsage)
+			}
+			path = argument
+		}
+	}
+	if path == "" {
+		return errors.New(passiveImportUsage)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open capture file: %w", err)
+	}
+	observations, err := ingest.ParsePcap(file)
+	_ = file.Close()
+	if err != nil {
+		return err
+	}
+	var task model.ScanTask
+	if taskID > 0 {
+		task, err = storage.GetScanTask(db, taskID)
+	} else {
+		task, err = createPassiveScanTask(db, observations)
+	}
+	if err != nil {
+		return err
+	}
+	return executeObservationRun(ctx, db, task, model.ScanTaskRunTriggerPassive, observations, output)
+}
+
+func createPassiveScanTask(db *sql.DB, observations ingest.Result) (model.ScanTask, error) {
+	target, ok := observations.CoveringTarget()
+	if !ok {
+		return model.ScanTask{}, errors.New("capture contains no internal IPv4 hosts")
+	}
+	scanType := model.ScanTypeIP
+	if strings.Contains(target, "/") {
+		scanType = model.ScanTypeSubnet
+	}
+	normalized, err := schedule.NormalizeInternalScanTarget(scanType, target)
+	if err != nil {
+		return model.ScanTask{}, fmt.Errorf("capture spans more than one internal range; pass --task: %w", err)
+	}
+	return storage.CreateScanTask(db, model.ScanTask{Target: normalized, ScanType: scanType, Mode: model.ScanTaskModeOnce})
+}
+
+// executeObservationRun records observations collected outside yscan as one
+// run of task and waits for its report.
+func executeObservationRun(ctx context.Context, db *sql.DB, task model.ScanTask, trigger string, observations ingest.Result, output io.Writer) error {
+	observations, skipped := observations.WithinTarget(task.Target)
+	if skipped > 0 {
+		fmt.Fprintf(output, "Skipped %d imported hosts outside task target %s\n", skipped, task.Target)
+	}
+	run, err := storage.CreateScanTaskRun(db, model.ScanTaskRun{
+		ScanTaskID: task.ID, Trigger: trigger,
+		ScheduledFor: time.Now().UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(output, "ScanTask %d run %d importing %d hosts (%s)\n", task.ID, run.ID, len(observations.Hosts), observations.Format)
+	importer := workflow.NewImportTaskRunExecutor(workflow.ImportTaskRunOptions{
+		DB: db, Observations: observations,
+		UpdateProgress: func(progress int) error {
+			stage := model.ScanTaskRunStageProfiling
+			if run.Config.VulnerabilityOn && progress >= 85 {
+				stage = model.ScanTaskRunStageValidation
+			}
+			if progress >= 100 {
+				progress, stage = 94, model.ScanTaskRunStageSnapshot
+			}
+			return storage.UpdateScanTaskRunProgress(db, run.ID, stage, progress)
+		},
+	})
+	if err := executeScanTaskRunWith(ctx, db, run, importer, 30*time.Minute); err != nil {
+		if errors.Is(err, schedule.ErrGlobalConcurrencyUnavailable) {
+			// An import is bound to this process; it must not wait in the queue
+			// for a runner that cannot supply its observations.
+			if cancelErr := storage.CancelScanTaskRun(db, task.ID, run.ID); cancelErr == nil {
+				_ = storage.FinalizeQueuedCancellation(db)
+			}
+			return fmt.Errorf("another scan task run is active; retry the import later: %w", err)
+		}
+		return err
+	}
+	completed, err := storage.GetScanTaskRun(db, run.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(output, "ScanTask run %d finished: %s (report: %s)\n", completed.ID, completed.Status, completed.ReportPath)
+	if completed.Status != model.ScanTaskRunStatusSuccess {
+		return fmt.Errorf("scan task run %d finished with status %s: %s", completed.ID, completed.Status, completed.ErrorMessage)
+	}
+	return nil
+}
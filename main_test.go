@@ -161,7 +161,7 @@ func TestParseCLIConfigCapturesRuntimeOverrides(t *testing.T) {
 }
 
 func TestParseCLIConfigRejectsMissingValues(t *testing.T) {
-	flags := []string{"--templates", "--dns-mode", "--dns-deny-cidr", "--home", "--listen", "--trusted-cidrs", "--max-concurrency", "--sqlite-busy-timeout", "--log-max-bytes", "--log-max-files", "--nuclei-binary"}
+	flags := []string{"--templates", "--dns-mode", "--dns-deny-cidr", "--home", "--listen", "--trusted-cidrs", "--max-concurrency", "--sqlite-busy-timeout", "--log-max-bytes", "--log-max-files", "--nuclei-binary", "--nuclei-timeout", "--nuclei-max-memory-mb", "--nuclei-nice", "--nuclei-ionice", "--nuclei-cgroup", "--evidence-cidrs", "--evidence-redact", "--evidence-max-body-bytes"}
 	for _, flag := range flags {
 		t.Run(flag, func(t *testing.T) {
 			if _, _, err := parseCLIConfig([]string{flag}); err == nil || !strings.Contains(err.Error(), "requires a value") {
@@ -175,7 +175,7 @@ func TestParseCLIConfigRejectsMissingValues(t *testing.T) {
 }
 
 func TestEffectiveBackgroundArgumentsPreserveResolvedConfiguration(t *testing.T) {
-	config := appRuntime.Config{ListenAddress: "127.0.0.1:39091", AllowCIDRs: []string{"10.0.0.0/8"}, MaxConcurrency: 4, SQLiteBusyTimeout: 7 * time.Second, LogMaxBytes: 2048, LogMaxFiles: 5, NucleiBinary: "/opt/nuclei", NucleiTemplates: "/opt/templates"}
+	config := appRuntime.Config{ListenAddress: "127.0.0.1:39091", AllowCIDRs: []string{"10.0.0.0/8"}, MaxConcurrency: 4, SQLiteBusyTimeout: 7 * time.Second, LogMaxBytes: 2048, LogMaxFiles: 5, NucleiBinary: "/opt/nuclei", NucleiTemplates: "/opt/templates", NucleiTimeout: 15 * time.Minute, NucleiMaxMemoryMB: 512, NucleiNice: 19, NucleiIOClass: "idle", NucleiCgroup: "delegate", EvidenceCIDRs: []string{"10.0.5.0/24"}, EvidenceRedact: []string{"x-tenant"}, EvidenceMaxBody: 512}
 	arguments := effectiveBackgroundArguments(config, cliConfig{DNSResolveMode: "internal", DNSDenyCIDRs: []string{"192.168.0.0/16"}})
 	_, parsed, err := parseCLIConfig(arguments)
 	if err != nil {
@@ -188,7 +188,9 @@ func TestEffectiveBackgroundArgumentsPreserveResolvedConfiguration(t *testing.T)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if resolved.ListenAddress != config.ListenAddress || resolved.MaxConcurrency != config.MaxConcurrency || resolved.SQLiteBusyTimeout != config.SQLiteBusyTimeout || resolved.NucleiBinary != config.NucleiBinary || resolved.NucleiTemplates != config.NucleiTemplates || strings.Join(resolved.AllowCIDRs, ",") != strings.Join(config.AllowCIDRs, ",") {
+	if resolved.ListenAddress != config.ListenAddress || resolved.MaxConcurrency != config.MaxConcurrency || resolved.SQLiteBusyTimeout != config.SQLiteBusyTimeout || resolved.NucleiBinary != config.NucleiBinary || resolved.NucleiTemplates != config.NucleiTemplates || strings.Join(resolved.AllowCIDRs, ",") != strings.Join(config.AllowCIDRs, ",") ||
+		resolved.NucleiTimeout != config.NucleiTimeout || resolved.NucleiMaxMemoryMB != config.NucleiMaxMemoryMB || resolved.NucleiNice != config.NucleiNice || resolved.NucleiIOClass != config.NucleiIOClass || resolved.NucleiCgroup != config.NucleiCgroup ||
+		strings.Join(resolved.EvidenceCIDRs, ",") != strings.Join(config.EvidenceCIDRs, ",") || strings.Join(resolved.EvidenceRedact, ",") != strings.Join(config.EvidenceRedact, ",") || resolved.EvidenceMaxBody != config.EvidenceMaxBody {
 		t.Fatalf("background configuration = %#v, want %#v", resolved, config)
 	}
 }